@@ -0,0 +1,142 @@
+package tcglog
+
+import (
+	"errors"
+	"fmt"
+)
+
+// PCRAnchor is a trusted set of intermediate PCR values at a particular position in a log, such as the values
+// verified during a previous attestation. It allows a verifier to validate only the events that were appended to
+// the log after the anchor point, rather than replaying the entire log each time. This is useful for long-running
+// systems with logs that grow continuously, such as the IMA measurement list.
+//
+// The anchor point is described by the number of events recorded against each PCR, which corresponds to the Index
+// field of the next event for that PCR.
+type PCRAnchor struct {
+	EventCounts map[PCRIndex]uint      // The number of events recorded against each PCR that Values accounts for
+	Values      map[PCRIndex]DigestMap // The PCR values at the anchor point
+}
+
+func copyPCRValues(values map[PCRIndex]DigestMap) map[PCRIndex]DigestMap {
+	out := make(map[PCRIndex]DigestMap)
+	for pcr, digests := range values {
+		out[pcr] = DigestMap{}
+		for alg, digest := range digests {
+			out[pcr][alg] = append(Digest(nil), digest...)
+		}
+	}
+	return out
+}
+
+// extendPCRValues extends the supplied events in to values. An error is returned for digests with an unsupported
+// algorithm, which are retained with LogOptions.PreserveUnknownDigests, because the corresponding bank can't be
+// computed.
+func extendPCRValues(values map[PCRIndex]DigestMap, events []*Event) error {
+	// Values for PCRs 17-22 at the anchor point imply that a dynamic launch has already happened.
+	drtm := dynamicLaunchTracker{launched: hasDRTMPCRValues(values)}
+	for _, event := range events {
+		if !isPCRIndexInRange(event.PCRIndex) {
+			return fmt.Errorf("event %d: %w", event.Index, wrapPCRIndexOutOfRangeError(event.PCRIndex))
+		}
+		if locality, ok := startupLocality(event); ok {
+			if _, exists := values[0]; !exists {
+				var algs AlgorithmIdList
+				for alg := range event.Digests {
+					if alg.supported() {
+						algs = append(algs, alg)
+					}
+				}
+				values[0] = initialPCR0Values(algs, locality)
+			}
+			continue
+		}
+		if !doesEventTypeExtendPCR(event.EventType) {
+			continue
+		}
+		if drtm.isDynamicLaunch(event) {
+			resetDRTMPCRs(values)
+		}
+		if _, exists := values[event.PCRIndex]; !exists {
+			values[event.PCRIndex] = DigestMap{}
+		}
+		for alg, digest := range event.Digests {
+			if !alg.supported() {
+				return fmt.Errorf("event %d: cannot extend PCR %d: %w", event.Index, event.PCRIndex,
+					&UnknownAlgorithmError{Algorithm: alg})
+			}
+			current, exists := values[event.PCRIndex][alg]
+			if !exists {
+				current = make(Digest, alg.size())
+			}
+			values[event.PCRIndex][alg] = performHashExtendOperation(alg, current, digest)
+		}
+	}
+	return nil
+}
+
+// NewPCRAnchor returns an anchor for the state of the PCRs after the supplied events, which must be every event
+// from the start of the log, have been measured. The anchor can be stored by a verifier once the events have been
+// validated, and then supplied to ReplayFromAnchor during subsequent attestations.
+func NewPCRAnchor(events []*Event) (*PCRAnchor, error) {
+	values := make(map[PCRIndex]DigestMap)
+	if err := extendPCRValues(values, events); err != nil {
+		return nil, err
+	}
+	counts := make(map[PCRIndex]uint)
+	for _, event := range events {
+		counts[event.PCRIndex]++
+	}
+	return &PCRAnchor{EventCounts: counts, Values: values}, nil
+}
+
+// ReplayFromAnchor computes the expected PCR values after the supplied events have been measured, starting from
+// the trusted values in anchor. The events must be the events that immediately follow the anchor point in the
+// log, in order, and this is checked using the Index field of each event. Each event is extended in to the banks
+// for which it has a digest. PCRs and banks that aren't in the anchor are assumed to start with a value of zero.
+// The anchor is not modified.
+//
+// An error is returned if an event has a digest for an algorithm that isn't supported, which is possible if the
+// log was read with LogOptions.PreserveUnknownDigests.
+//
+// The digests of the events aren't validated against their data, and events that are missing from the end of the
+// supplied events can't be detected. The returned values should be compared with values obtained from a quote in
+// order to verify the events.
+func ReplayFromAnchor(anchor *PCRAnchor, events []*Event) (map[PCRIndex]DigestMap, error) {
+	if anchor == nil {
+		return nil, errors.New("no anchor")
+	}
+	next := make(map[PCRIndex]uint)
+	for pcr, n := range anchor.EventCounts {
+		next[pcr] = n
+	}
+	for _, event := range events {
+		if event.Index != next[event.PCRIndex] {
+			return nil, fmt.Errorf("event %d in PCR %d doesn't follow the anchor point (expected index %d)",
+				event.Index, event.PCRIndex, next[event.PCRIndex])
+		}
+		next[event.PCRIndex]++
+	}
+
+	values := copyPCRValues(anchor.Values)
+	if err := extendPCRValues(values, events); err != nil {
+		return nil, err
+	}
+	return values, nil
+}
+
+// Advance returns a new anchor for the state of the PCRs after the supplied events, which must immediately follow
+// the anchor point, have been measured. This should only be done once the events have been verified.
+func (a *PCRAnchor) Advance(events []*Event) (*PCRAnchor, error) {
+	values, err := ReplayFromAnchor(a, events)
+	if err != nil {
+		return nil, err
+	}
+	counts := make(map[PCRIndex]uint)
+	for pcr, n := range a.EventCounts {
+		counts[pcr] = n
+	}
+	for _, event := range events {
+		counts[event.PCRIndex]++
+	}
+	return &PCRAnchor{EventCounts: counts, Values: values}, nil
+}
@@ -0,0 +1,77 @@
+package tcglog
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+)
+
+func TestReplayFromAnchor(t *testing.T) {
+	events := readTestEvents(t, makeTestCryptoAgileLog(t, 12))
+
+	full, err := NewPCRAnchor(events)
+	if err != nil {
+		t.Fatalf("NewPCRAnchor failed: %v", err)
+	}
+
+	anchor, err := NewPCRAnchor(events[:5])
+	if err != nil {
+		t.Fatalf("NewPCRAnchor failed: %v", err)
+	}
+	values, err := ReplayFromAnchor(anchor, events[5:])
+	if err != nil {
+		t.Fatalf("ReplayFromAnchor failed: %v", err)
+	}
+	if len(values) != len(full.Values) {
+		t.Fatalf("Unexpected number of PCRs (%d)", len(values))
+	}
+	for pcr, digests := range full.Values {
+		for alg, digest := range digests {
+			if !bytes.Equal(values[pcr][alg], digest) {
+				t.Errorf("Unexpected value for PCR %d, bank %s", pcr, alg)
+			}
+		}
+	}
+
+	advanced, err := anchor.Advance(events[5:8])
+	if err != nil {
+		t.Fatalf("Advance failed: %v", err)
+	}
+	n := uint(0)
+	for _, count := range advanced.EventCounts {
+		n += count
+	}
+	if n != 8 {
+		t.Errorf("Unexpected event count (%d)", n)
+	}
+	if _, err := ReplayFromAnchor(advanced, events[8:]); err != nil {
+		t.Errorf("ReplayFromAnchor failed: %v", err)
+	}
+
+	// Events that don't immediately follow the anchor point must be rejected. Skipping the first event in
+	// PCR 0 after the anchor point is detected when the next event in PCR 0 is encountered.
+	anchor, err = NewPCRAnchor(events[:1])
+	if err != nil {
+		t.Fatalf("NewPCRAnchor failed: %v", err)
+	}
+	if _, err := ReplayFromAnchor(anchor, events[2:]); err == nil {
+		t.Errorf("ReplayFromAnchor should have failed")
+	}
+}
+
+func TestReplayFromAnchorUnsupportedAlgorithm(t *testing.T) {
+	events := readTestEvents(t, makeTestCryptoAgileLog(t, 4))
+	anchor, err := NewPCRAnchor(events[:2])
+	if err != nil {
+		t.Fatalf("NewPCRAnchor failed: %v", err)
+	}
+
+	// This is a digest retained with LogOptions.PreserveUnknownDigests.
+	events[3].Digests[AlgorithmId(0x1234)] = make(Digest, 32)
+
+	_, err = ReplayFromAnchor(anchor, events[2:])
+	var e *UnknownAlgorithmError
+	if !errors.As(err, &e) || e.Algorithm != AlgorithmId(0x1234) {
+		t.Errorf("Unexpected error: %v", err)
+	}
+}
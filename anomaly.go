@@ -0,0 +1,144 @@
+package tcglog
+
+import (
+	"fmt"
+	"math"
+)
+
+const (
+	// FindingLogTooSmall indicates that a log contains fewer events than expected, and may have been
+	// truncated.
+	FindingLogTooSmall FindingCode = "log-too-small"
+
+	// FindingLogMissingSeparators indicates that a log doesn't contain an EV_SEPARATOR event for each of
+	// PCRs 0-7, and may have been truncated.
+	FindingLogMissingSeparators FindingCode = "log-missing-separators"
+
+	// FindingLogTooLarge indicates that a log is larger than expected.
+	FindingLogTooLarge FindingCode = "log-too-large"
+
+	// FindingHighEntropyEventData indicates that an event which is expected to contain structured data
+	// contains a high-entropy blob.
+	FindingHighEntropyEventData FindingCode = "high-entropy-event-data"
+)
+
+// AnomalyThresholds controls the heuristics used by DetectAnomalies.
+type AnomalyThresholds struct {
+	MinEvents          int     // The minimum number of events expected in a log
+	MaxLogSize         int64   // The maximum expected size of a log, in bytes
+	MaxEntropy         float64 // The maximum expected entropy, in bits per byte, of structured event data
+	MinEntropyDataSize int     // The minimum size of event data for which entropy is tested
+	MaxPhysicalAddress uint64  // The maximum plausible physical address of a loaded image, or zero for no limit
+}
+
+// DefaultAnomalyThresholds contains thresholds that are suitable for logs produced by typical PC firmware.
+var DefaultAnomalyThresholds = AnomalyThresholds{
+	MinEvents:          10,
+	MaxLogSize:         1024 * 1024,
+	MaxEntropy:         7.0,
+	MinEntropyDataSize: 64,
+	MaxPhysicalAddress: 1 << 52}
+
+// shannonEntropy returns the Shannon entropy of data in bits per byte.
+func shannonEntropy(data []byte) float64 {
+	if len(data) == 0 {
+		return 0
+	}
+
+	var counts [256]int
+	for _, b := range data {
+		counts[b]++
+	}
+
+	var entropy float64
+	for _, c := range counts {
+		if c == 0 {
+			continue
+		}
+		p := float64(c) / float64(len(data))
+		entropy -= p * math.Log2(p)
+	}
+	return entropy
+}
+
+// doesEventTypeContainStructuredData indicates whether events of the specified type are expected to contain
+// strings or structured data rather than arbitrary binary blobs.
+func doesEventTypeContainStructuredData(t EventType) bool {
+	switch t {
+	case EventTypeSeparator, EventTypeAction, EventTypeEFIAction, EventTypeOmitBootDeviceEvents,
+		EventTypeEFIVariableBoot, EventTypeEFIVariableBoot2, EventTypeEFIGPTEvent,
+		EventTypeEFIBootServicesApplication, EventTypeEFIBootServicesDriver, EventTypeEFIRuntimeServicesDriver:
+		return true
+	default:
+		return false
+	}
+}
+
+// DetectAnomalies applies heuristics to the supplied events, read from a log of logSize bytes, in order to
+// identify logs that are abnormally small and possibly truncated, abnormally large, or that contain
+// high-entropy blobs in events that should contain structured data. It is intended to help triage tampered
+// or corrupted logs.
+func DetectAnomalies(events []*Event, logSize int64, thresholds AnomalyThresholds) (out []Finding) {
+	if len(events) < thresholds.MinEvents {
+		out = append(out, Finding{
+			Code:     FindingLogTooSmall,
+			Severity: FindingSeverityWarning,
+			Message: fmt.Sprintf("log contains %d events, which is fewer than the expected minimum of %d",
+				len(events), thresholds.MinEvents)})
+	}
+
+	if thresholds.MaxLogSize > 0 && logSize > thresholds.MaxLogSize {
+		out = append(out, Finding{
+			Code:     FindingLogTooLarge,
+			Severity: FindingSeverityWarning,
+			Message: fmt.Sprintf("log is %d bytes, which is larger than the expected maximum of %d bytes",
+				logSize, thresholds.MaxLogSize)})
+	}
+
+	seenSeparator := make(map[PCRIndex]bool)
+	for _, event := range events {
+		if event.EventType == EventTypeSeparator {
+			seenSeparator[event.PCRIndex] = true
+		}
+
+		if !doesEventTypeContainStructuredData(event.EventType) {
+			continue
+		}
+		data := event.Data.Bytes()
+		if len(data) < thresholds.MinEntropyDataSize {
+			continue
+		}
+		if entropy := shannonEntropy(data); entropy > thresholds.MaxEntropy {
+			out = append(out, Finding{
+				Code:     FindingHighEntropyEventData,
+				Severity: FindingSeverityWarning,
+				Event:    event,
+				Message: fmt.Sprintf("event data has an entropy of %.2f bits per byte, which is unexpected "+
+					"for this event type", entropy)})
+		}
+	}
+
+	if f := missingSeparatorsFinding(seenSeparator); f != nil {
+		out = append(out, *f)
+	}
+
+	return
+}
+
+// missingSeparatorsFinding returns a FindingLogMissingSeparators finding if seen doesn't contain each of
+// PCRs 0-7, or nil if it does.
+func missingSeparatorsFinding(seen map[PCRIndex]bool) *Finding {
+	var missing PCRArgList
+	for pcr := PCRIndex(0); pcr <= 7; pcr++ {
+		if !seen[pcr] {
+			missing = append(missing, pcr)
+		}
+	}
+	if len(missing) == 0 {
+		return nil
+	}
+	return &Finding{
+		Code:     FindingLogMissingSeparators,
+		Severity: FindingSeverityWarning,
+		Message:  fmt.Sprintf("log is missing EV_SEPARATOR events for PCRs %s", missing.String())}
+}
@@ -0,0 +1,125 @@
+package tcglog
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func makeTestAnomalyEvents(separators []PCRIndex, extra ...*Event) (events []*Event) {
+	for i := 0; i < 8; i++ {
+		events = append(events, &Event{PCRIndex: 0, EventType: EventTypeAction,
+			Data: &opaqueEventData{data: []byte("action")}})
+	}
+	for _, pcr := range separators {
+		events = append(events, &Event{PCRIndex: pcr, EventType: EventTypeSeparator,
+			Data: &opaqueEventData{data: make([]byte, 4)}})
+	}
+	return append(events, extra...)
+}
+
+func TestDetectAnomalies(t *testing.T) {
+	allSeparators := []PCRIndex{0, 1, 2, 3, 4, 5, 6, 7}
+
+	highEntropy := make([]byte, 256)
+	for i := range highEntropy {
+		highEntropy[i] = byte(i)
+	}
+
+	for _, data := range []struct {
+		desc     string
+		events   []*Event
+		logSize  int64
+		expected []FindingCode
+	}{
+		{
+			desc:    "none",
+			events:  makeTestAnomalyEvents(allSeparators),
+			logSize: 4096,
+		},
+		{
+			desc:     "too small",
+			events:   makeTestAnomalyEvents(allSeparators)[8:],
+			logSize:  4096,
+			expected: []FindingCode{FindingLogTooSmall},
+		},
+		{
+			desc:     "too large",
+			events:   makeTestAnomalyEvents(allSeparators),
+			logSize:  DefaultAnomalyThresholds.MaxLogSize + 1,
+			expected: []FindingCode{FindingLogTooLarge},
+		},
+		{
+			desc:     "missing separators",
+			events:   makeTestAnomalyEvents([]PCRIndex{0, 1, 2, 3, 4, 5, 6}),
+			logSize:  4096,
+			expected: []FindingCode{FindingLogMissingSeparators},
+		},
+		{
+			desc: "high entropy",
+			events: makeTestAnomalyEvents(allSeparators, &Event{PCRIndex: 4,
+				EventType: EventTypeEFIBootServicesApplication, Data: &opaqueEventData{data: highEntropy}}),
+			logSize:  4096,
+			expected: []FindingCode{FindingHighEntropyEventData},
+		},
+		{
+			desc: "high entropy in unstructured event",
+			events: makeTestAnomalyEvents(allSeparators, &Event{PCRIndex: 1,
+				EventType: EventTypeEFIHandoffTables, Data: &opaqueEventData{data: highEntropy}}),
+			logSize: 4096,
+		},
+		{
+			desc: "low entropy",
+			events: makeTestAnomalyEvents(allSeparators, &Event{PCRIndex: 4,
+				EventType: EventTypeEFIBootServicesApplication, Data: &opaqueEventData{data: make([]byte, 256)}}),
+			logSize: 4096,
+		},
+	} {
+		t.Run(data.desc, func(t *testing.T) {
+			findings := DetectAnomalies(data.events, data.logSize, DefaultAnomalyThresholds)
+			if len(findings) != len(data.expected) {
+				t.Fatalf("Unexpected findings: %v", findings)
+			}
+			for i, f := range findings {
+				if f.Code != data.expected[i] {
+					t.Errorf("Unexpected finding %s", f.Code)
+				}
+			}
+		})
+	}
+}
+
+func TestReplayAndValidateLogSize(t *testing.T) {
+	dir, err := ioutil.TempDir("", "tcglog-test")
+	if err != nil {
+		t.Fatalf("TempDir failed: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	data := makeTestCryptoAgileLog(t, 4)
+	path := filepath.Join(dir, "log")
+	if err := ioutil.WriteFile(path, data, 0644); err != nil {
+		t.Fatalf("WriteFile failed: %v", err)
+	}
+
+	// The size of the log must be determined from the bytes read rather than from the file size, which
+	// securityfs reports as zero.
+	thresholds := DefaultAnomalyThresholds
+	thresholds.MaxLogSize = int64(len(data)) - 1
+	result, err := ReplayAndValidateLogContext(context.Background(), path, LogOptions{},
+		LogValidateOptions{AnomalyThresholds: &thresholds})
+	if err != nil {
+		t.Fatalf("ReplayAndValidateLogContext failed: %v", err)
+	}
+	var found bool
+	for _, f := range result.AllFindings {
+		if f.Code == FindingLogTooLarge {
+			found = true
+		}
+	}
+	if !found {
+		t.Errorf("Missing %s finding", FindingLogTooLarge)
+	}
+}
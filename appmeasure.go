@@ -0,0 +1,242 @@
+package tcglog
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+)
+
+// DefaultAppMeasurementPCR is the PCR that applications should measure to by default. PCR 23 is reserved for
+// application support and is resettable from locality 0.
+const DefaultAppMeasurementPCR PCRIndex = 23
+
+// AppMeasurementType identifies the convention used for an application-level measurement. It is recorded in the
+// TaggedEventID field of an EV_EVENT_TAG event.
+type AppMeasurementType uint32
+
+const (
+	AppMeasurementFile           AppMeasurementType = 0x544c4101 // The contents of a file
+	AppMeasurementConfig         AppMeasurementType = 0x544c4102 // A JSON configuration in canonical form
+	AppMeasurementContainerImage AppMeasurementType = 0x544c4103 // The digest of a container image
+)
+
+func (t AppMeasurementType) String() string {
+	switch t {
+	case AppMeasurementFile:
+		return "file"
+	case AppMeasurementConfig:
+		return "config"
+	case AppMeasurementContainerImage:
+		return "container-image"
+	default:
+		return fmt.Sprintf("%08x", uint32(t))
+	}
+}
+
+type appMeasurementRecord struct {
+	Name   string          `json:"name"`
+	Size   int64           `json:"size,omitempty"`
+	Mode   uint32          `json:"mode,omitempty"`
+	Config json.RawMessage `json:"config,omitempty"`
+	Digest string          `json:"digest,omitempty"`
+}
+
+// AppMeasurementEventData corresponds to the event data for an application-level measurement made with
+// MeasureFile, MeasureConfig or MeasureContainerImage. The event data is a TCG_PCClientTaggedEvent structure
+// with a JSON description of the measurement.
+type AppMeasurementEventData struct {
+	data   []byte
+	Type   AppMeasurementType
+	Name   string      // The path of a file, the name of a configuration or the reference of a container image
+	Size   int64       // The size of a file
+	Mode   os.FileMode // The permissions of a file
+	Config []byte      // The canonical form of a configuration, which is the measured data
+	Digest string      // The digest of a container image in the form "<algorithm>:<hex>", which is the measured data
+}
+
+func (e *AppMeasurementEventData) String() string {
+	switch e.Type {
+	case AppMeasurementFile:
+		return fmt.Sprintf("app-file{ path=%q, size=%d, mode=%v }", e.Name, e.Size, e.Mode)
+	case AppMeasurementConfig:
+		return fmt.Sprintf("app-config{ name=%q, config=%s }", e.Name, e.Config)
+	case AppMeasurementContainerImage:
+		return fmt.Sprintf("app-container-image{ image=%q, digest=%s }", e.Name, e.Digest)
+	}
+	return ""
+}
+
+func (e *AppMeasurementEventData) Bytes() []byte {
+	return e.data
+}
+
+// measuredBytes returns the data that was measured for this event if it is recorded in the log.
+func (e *AppMeasurementEventData) measuredBytes() []byte {
+	switch e.Type {
+	case AppMeasurementConfig:
+		return e.Config
+	case AppMeasurementContainerImage:
+		return []byte(e.Digest)
+	}
+	return nil
+}
+
+// https://trustedcomputinggroup.org/wp-content/uploads/TCG_PCClientImplementation_1-21_1_00.pdf
+//  (section 11.3.2.1 "Event Tagging Structures")
+func encodeAppMeasurementEventData(t AppMeasurementType, record *appMeasurementRecord) ([]byte, error) {
+	payload, err := json.Marshal(record)
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	binary.Write(&buf, binary.LittleEndian, uint32(t))
+	binary.Write(&buf, binary.LittleEndian, uint32(len(payload)))
+	buf.Write(payload)
+	return buf.Bytes(), nil
+}
+
+func decodeEventDataApp(data []byte) (EventData, int) {
+	if len(data) < 8 {
+		return nil, 0
+	}
+
+	t := AppMeasurementType(binary.LittleEndian.Uint32(data[0:]))
+	switch t {
+	case AppMeasurementFile, AppMeasurementConfig, AppMeasurementContainerImage:
+	default:
+		return nil, 0
+	}
+
+	size := binary.LittleEndian.Uint32(data[4:])
+	if int64(size) > int64(len(data)-8) {
+		return nil, 0
+	}
+	payload := data[8 : 8+size]
+
+	var record appMeasurementRecord
+	if err := json.Unmarshal(payload, &record); err != nil {
+		return nil, 0
+	}
+
+	return &AppMeasurementEventData{
+		data:   data,
+		Type:   t,
+		Name:   record.Name,
+		Size:   record.Size,
+		Mode:   os.FileMode(record.Mode),
+		Config: []byte(record.Config),
+		Digest: record.Digest}, len(data) - 8 - int(size)
+}
+
+// CanonicalizeJSON returns the canonical form of the supplied JSON document, which is used by MeasureConfig.
+// The canonical form has no insignificant whitespace, object members sorted by key, and numbers preserved
+// exactly as they appear in the input. This means that configurations that differ only in formatting or member
+// order produce the same measurement.
+func CanonicalizeJSON(data []byte) ([]byte, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+
+	var v interface{}
+	if err := dec.Decode(&v); err != nil {
+		return nil, err
+	}
+	if dec.More() {
+		return nil, fmt.Errorf("unexpected data after JSON document")
+	}
+
+	return json.Marshal(v)
+}
+
+// MeasureFile measures the contents of the file at path to the specified PCR and records an event in the log at
+// logPath that contains the path, size and permissions of the file. See MeasureAndLog for details of how the log
+// is updated.
+func MeasureFile(logPath string, extender PCRExtender, pcrIndex PCRIndex, path string) (*Event, error) {
+	fi, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	if !fi.Mode().IsRegular() {
+		return nil, fmt.Errorf("%s is not a regular file", path)
+	}
+
+	contents, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := encodeAppMeasurementEventData(AppMeasurementFile, &appMeasurementRecord{
+		Name: path,
+		Size: int64(len(contents)),
+		Mode: uint32(fi.Mode().Perm())})
+	if err != nil {
+		return nil, fmt.Errorf("cannot encode event data: %w", err)
+	}
+
+	return MeasureAndLog(logPath, extender, pcrIndex, EventTypeEventTag, data, contents)
+}
+
+// MeasureConfig measures the canonical form (see CanonicalizeJSON) of the supplied JSON configuration to the
+// specified PCR and records an event in the log at logPath that contains the name and canonical form of the
+// configuration. See MeasureAndLog for details of how the log is updated.
+func MeasureConfig(logPath string, extender PCRExtender, pcrIndex PCRIndex, name string,
+	config []byte) (*Event, error) {
+	canonical, err := CanonicalizeJSON(config)
+	if err != nil {
+		return nil, fmt.Errorf("invalid configuration: %w", err)
+	}
+
+	data, err := encodeAppMeasurementEventData(AppMeasurementConfig, &appMeasurementRecord{
+		Name:   name,
+		Config: canonical})
+	if err != nil {
+		return nil, fmt.Errorf("cannot encode event data: %w", err)
+	}
+
+	return MeasureAndLog(logPath, extender, pcrIndex, EventTypeEventTag, data, canonical)
+}
+
+func checkContainerImageDigest(digest string) error {
+	i := strings.IndexByte(digest, ':')
+	if i < 0 {
+		return errors.New("no algorithm")
+	}
+	alg, err := ParseAlgorithm(digest[:i])
+	if err != nil {
+		return err
+	}
+	d, err := hex.DecodeString(digest[i+1:])
+	if err != nil {
+		return err
+	}
+	if len(d) != alg.size() {
+		return fmt.Errorf("invalid length for %s (got %d bytes, expected %d)", alg, len(d), alg.size())
+	}
+	return nil
+}
+
+// MeasureContainerImage measures the digest of a container image to the specified PCR and records an event in
+// the log at logPath that contains the image reference and digest. The digest must be in the form
+// "<algorithm>:<hex>", eg, "sha256:e3b0c442...", and its length must match the size of the algorithm. See
+// MeasureAndLog for details of how the log is updated.
+func MeasureContainerImage(logPath string, extender PCRExtender, pcrIndex PCRIndex, image,
+	digest string) (*Event, error) {
+	if err := checkContainerImageDigest(digest); err != nil {
+		return nil, fmt.Errorf("invalid image digest %q: %w", digest, err)
+	}
+
+	data, err := encodeAppMeasurementEventData(AppMeasurementContainerImage, &appMeasurementRecord{
+		Name:   image,
+		Digest: digest})
+	if err != nil {
+		return nil, fmt.Errorf("cannot encode event data: %w", err)
+	}
+
+	return MeasureAndLog(logPath, extender, pcrIndex, EventTypeEventTag, data, []byte(digest))
+}
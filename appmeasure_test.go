@@ -0,0 +1,168 @@
+package tcglog
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestCanonicalizeJSON(t *testing.T) {
+	a, err := CanonicalizeJSON([]byte("{\n  \"b\": [1, 2.50],\n  \"a\": {\"y\": true, \"x\": null}\n}\n"))
+	if err != nil {
+		t.Fatalf("CanonicalizeJSON failed: %v", err)
+	}
+	if string(a) != `{"a":{"x":null,"y":true},"b":[1,2.50]}` {
+		t.Errorf("Unexpected canonical form: %s", a)
+	}
+
+	if _, err := CanonicalizeJSON([]byte(`{"a": 1} {"b": 2}`)); err == nil {
+		t.Errorf("CanonicalizeJSON should fail with trailing data")
+	}
+}
+
+func TestAppMeasurements(t *testing.T) {
+	dir, err := ioutil.TempDir("", "tcglog-test")
+	if err != nil {
+		t.Fatalf("TempDir failed: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	logPath := filepath.Join(dir, "log")
+	if err := CreateLogFile(logPath, AlgorithmIdList{AlgorithmSha256}); err != nil {
+		t.Fatalf("CreateLogFile failed: %v", err)
+	}
+
+	filePath := filepath.Join(dir, "file")
+	if err := ioutil.WriteFile(filePath, []byte("file contents"), 0600); err != nil {
+		t.Fatalf("WriteFile failed: %v", err)
+	}
+
+	extender := &mockPCRExtender{}
+	if _, err := MeasureFile(logPath, extender, DefaultAppMeasurementPCR, filePath); err != nil {
+		t.Fatalf("MeasureFile failed: %v", err)
+	}
+	if _, err := MeasureConfig(logPath, extender, DefaultAppMeasurementPCR, "app.json",
+		[]byte(`{"z": 1, "a": "b"}`)); err != nil {
+		t.Fatalf("MeasureConfig failed: %v", err)
+	}
+	if _, err := MeasureContainerImage(logPath, extender, DefaultAppMeasurementPCR, "docker.io/library/foo:latest",
+		"sha256:e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"); err != nil {
+		t.Fatalf("MeasureContainerImage failed: %v", err)
+	}
+	if _, err := MeasureContainerImage(logPath, extender, DefaultAppMeasurementPCR, "foo", "e3b0c442"); err == nil {
+		t.Errorf("MeasureContainerImage should fail with an invalid digest")
+	}
+
+	result, err := ReplayAndValidateLog(logPath, LogOptions{EnableAppMeasurements: true})
+	if err != nil {
+		t.Fatalf("ReplayAndValidateLog failed: %v", err)
+	}
+	if len(result.ValidatedEvents) != 4 {
+		t.Fatalf("Unexpected number of events (%d)", len(result.ValidatedEvents))
+	}
+
+	file, ok := result.ValidatedEvents[1].Event.Data.(*AppMeasurementEventData)
+	if !ok {
+		t.Fatalf("Unexpected event data type %T", result.ValidatedEvents[1].Event.Data)
+	}
+	if file.Type != AppMeasurementFile || file.Name != filePath || file.Size != 13 || file.Mode != 0600 {
+		t.Errorf("Unexpected file event: %s", file)
+	}
+
+	config, ok := result.ValidatedEvents[2].Event.Data.(*AppMeasurementEventData)
+	if !ok {
+		t.Fatalf("Unexpected event data type %T", result.ValidatedEvents[2].Event.Data)
+	}
+	if config.Type != AppMeasurementConfig || !bytes.Equal(config.Config, []byte(`{"a":"b","z":1}`)) {
+		t.Errorf("Unexpected config event: %s", config)
+	}
+
+	image, ok := result.ValidatedEvents[3].Event.Data.(*AppMeasurementEventData)
+	if !ok {
+		t.Fatalf("Unexpected event data type %T", result.ValidatedEvents[3].Event.Data)
+	}
+	if image.Type != AppMeasurementContainerImage || image.Name != "docker.io/library/foo:latest" {
+		t.Errorf("Unexpected container image event: %s", image)
+	}
+
+	for i, e := range result.ValidatedEvents[1:] {
+		if len(e.IncorrectDigestValues) > 0 {
+			t.Errorf("Event %d has incorrect digests", i+1)
+		}
+	}
+	if !bytes.Equal(result.ExpectedPCRValues[23][AlgorithmSha256], extender.values[23][AlgorithmSha256]) {
+		t.Errorf("Unexpected PCR value")
+	}
+}
+
+func TestMeasureContainerImageInvalidDigest(t *testing.T) {
+	dir, err := ioutil.TempDir("", "tcglog-test")
+	if err != nil {
+		t.Fatalf("TempDir failed: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	logPath := filepath.Join(dir, "log")
+	if err := CreateLogFile(logPath, AlgorithmIdList{AlgorithmSha256}); err != nil {
+		t.Fatalf("CreateLogFile failed: %v", err)
+	}
+	extender := &mockPCRExtender{}
+
+	for _, data := range []struct {
+		desc   string
+		digest string
+	}{
+		{desc: "NoAlgorithm", digest: "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"},
+		{desc: "EmptyAlgorithm", digest: ":e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"},
+		{desc: "UnknownAlgorithm", digest: "foo:e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"},
+		{desc: "InvalidHex", digest: "sha256:zz"},
+		{desc: "Empty", digest: "sha256:"},
+		{desc: "TooShort", digest: "sha256:e3b0c442"},
+		{desc: "WrongAlgorithm", digest: "sha1:e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"},
+	} {
+		t.Run(data.desc, func(t *testing.T) {
+			if _, err := MeasureContainerImage(logPath, extender, DefaultAppMeasurementPCR, "foo",
+				data.digest); err == nil {
+				t.Errorf("MeasureContainerImage should fail with an invalid digest")
+			}
+		})
+	}
+	if len(extender.values) > 0 {
+		t.Errorf("Unexpected PCR extend")
+	}
+}
+
+func TestDecodeEventDataAppNotEnabled(t *testing.T) {
+	data, err := encodeAppMeasurementEventData(AppMeasurementConfig,
+		&appMeasurementRecord{Name: "app.json", Config: []byte(`{"a":"b"}`)})
+	if err != nil {
+		t.Fatalf("encodeAppMeasurementEventData failed: %v", err)
+	}
+
+	for _, d := range []struct {
+		desc      string
+		pcr       PCRIndex
+		eventType EventType
+		options   LogOptions
+		app       bool
+	}{
+		{desc: "Enabled", pcr: DefaultAppMeasurementPCR, eventType: EventTypeEventTag,
+			options: LogOptions{EnableAppMeasurements: true}, app: true},
+		{desc: "Disabled", pcr: DefaultAppMeasurementPCR, eventType: EventTypeEventTag},
+		{desc: "OtherEventType", pcr: DefaultAppMeasurementPCR, eventType: EventTypeIPL,
+			options: LogOptions{EnableAppMeasurements: true}},
+		// Enabling another decoder for the same event doesn't enable app decoding.
+		{desc: "XenEnabled", pcr: 17, eventType: EventTypeIPL, options: LogOptions{EnableXen: true}},
+		{desc: "XenEnabledEventTag", pcr: 17, eventType: EventTypeEventTag, options: LogOptions{EnableXen: true}},
+		{desc: "GrubEnabled", pcr: 8, eventType: EventTypeIPL, options: LogOptions{EnableGrub: true}},
+	} {
+		t.Run(d.desc, func(t *testing.T) {
+			out, _ := decodeEventData(d.pcr, d.eventType, data, &d.options, false)
+			if _, ok := out.(*AppMeasurementEventData); ok != d.app {
+				t.Errorf("Unexpected event data type %T", out)
+			}
+		})
+	}
+}
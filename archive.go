@@ -0,0 +1,150 @@
+package tcglog
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// DefaultLogPath is the path of the event log for the default TPM, as exported by the Linux kernel.
+const DefaultLogPath = "/sys/kernel/security/tpm0/binary_bios_measurements"
+
+const (
+	bootIDPath        = "/proc/sys/kernel/random/boot_id"
+	archivedLogSuffix = ".tcglog"
+)
+
+// ArchivedLog describes a log for a previous boot that has been stored with ArchiveLog.
+type ArchivedLog struct {
+	BootID  string    // The boot ID of the boot that the log was obtained from
+	Path    string    // The path of the archived log
+	ModTime time.Time // The time at which the log was archived
+}
+
+// CurrentBootID returns the ID of the current boot, as exported by the Linux kernel.
+func CurrentBootID() (string, error) {
+	data, err := ioutil.ReadFile(bootIDPath)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(data)), nil
+}
+
+func isValidBootID(bootID string) bool {
+	if bootID == "" {
+		return false
+	}
+	for _, c := range bootID {
+		if !(c >= '0' && c <= '9') && !(c >= 'a' && c <= 'f') && c != '-' {
+			return false
+		}
+	}
+	return true
+}
+
+// ArchiveLog copies the log at logPath to dir, using a name derived from bootID, so that it is available for
+// comparison with the logs of later boots. The log is written atomically. If a log has already been archived
+// for the specified boot with the same contents, this does nothing. This returns the path of the archived log.
+// The log is stored for LocalHost in the same way as with the Store returned from NewFileStore for dir.
+func ArchiveLog(dir, logPath, bootID string) (string, error) {
+	if !isValidBootID(bootID) {
+		return "", fmt.Errorf("invalid boot ID \"%s\"", bootID)
+	}
+
+	data, err := ioutil.ReadFile(logPath)
+	if err != nil {
+		return "", err
+	}
+
+	if err := NewFileStore(dir).SaveLog(LocalHost, bootID, data); err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, bootID+archivedLogSuffix), nil
+}
+
+// ListArchivedLogs returns the logs that have been archived to dir, ordered from the most recently archived.
+func ListArchivedLogs(dir string) ([]ArchivedLog, error) {
+	if _, err := os.Stat(dir); err != nil {
+		return nil, err
+	}
+	boots, err := NewFileStore(dir).ListBoots(LocalHost)
+	if err != nil {
+		return nil, err
+	}
+
+	var logs []ArchivedLog
+	for _, boot := range boots {
+		logs = append(logs, ArchivedLog{
+			BootID:  boot.BootID,
+			Path:    filepath.Join(dir, boot.BootID+archivedLogSuffix),
+			ModTime: boot.ModTime})
+	}
+	return logs, nil
+}
+
+// PreviousArchivedLog returns the most recently archived log in dir that wasn't obtained from the boot with the
+// specified ID, or nil if there isn't one.
+func PreviousArchivedLog(dir, bootID string) (*ArchivedLog, error) {
+	logs, err := ListArchivedLogs(dir)
+	if err != nil {
+		return nil, err
+	}
+	for _, log := range logs {
+		if log.BootID != bootID {
+			return &log, nil
+		}
+	}
+	return nil, nil
+}
+
+// CompareBanksWithPreviousBoot compares the digest algorithms declared in the log for the current boot with
+// those declared in the most recently archived log from a different boot in dir, using CompareBootBanks. This
+// returns no findings if there isn't a log from a previous boot.
+func CompareBanksWithPreviousBoot(dir, bootID string, current AlgorithmIdList) ([]Finding, error) {
+	previous, err := PreviousArchivedLog(dir, bootID)
+	if err != nil || previous == nil {
+		return nil, err
+	}
+
+	f, err := os.Open(previous.Path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	log, err := NewLog(f, LogOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("cannot parse log for boot %s: %w", previous.BootID, err)
+	}
+	return CompareBootBanks(log.Algorithms, current), nil
+}
+
+// PruneArchivedLogs removes all but the keep most recently archived logs from dir, along with any findings stored
+// for the same boots, and returns the logs that were removed.
+func PruneArchivedLogs(dir string, keep int) ([]ArchivedLog, error) {
+	if keep < 0 {
+		return nil, errors.New("invalid number of logs to keep")
+	}
+
+	logs, err := ListArchivedLogs(dir)
+	if err != nil {
+		return nil, err
+	}
+	if len(logs) <= keep {
+		return nil, nil
+	}
+
+	store := NewFileStore(dir)
+	var removed []ArchivedLog
+	for _, log := range logs[keep:] {
+		if err := store.RemoveBoot(LocalHost, log.BootID); err != nil {
+			return removed, err
+		}
+		removed = append(removed, log)
+	}
+	return removed, nil
+}
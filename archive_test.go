@@ -0,0 +1,130 @@
+package tcglog
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestArchiveLog(t *testing.T) {
+	dir, err := ioutil.TempDir("", "tcglog-archive-test")
+	if err != nil {
+		t.Fatalf("TempDir failed: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	logPath := filepath.Join(dir, "log")
+	if err := ioutil.WriteFile(logPath, makeTestCryptoAgileLog(t, 2), 0600); err != nil {
+		t.Fatalf("WriteFile failed: %v", err)
+	}
+	archiveDir := filepath.Join(dir, "archive")
+	if err := os.Mkdir(archiveDir, 0700); err != nil {
+		t.Fatalf("Mkdir failed: %v", err)
+	}
+
+	bootIDs := []string{
+		"0a1b2c3d-0000-0000-0000-000000000001",
+		"0a1b2c3d-0000-0000-0000-000000000002",
+		"0a1b2c3d-0000-0000-0000-000000000003"}
+	for i, id := range bootIDs {
+		path, err := ArchiveLog(archiveDir, logPath, id)
+		if err != nil {
+			t.Fatalf("ArchiveLog failed: %v", err)
+		}
+		mtime := time.Unix(int64(1000+i), 0)
+		if err := os.Chtimes(path, mtime, mtime); err != nil {
+			t.Fatalf("Chtimes failed: %v", err)
+		}
+	}
+	if _, err := ArchiveLog(archiveDir, logPath, bootIDs[0]); err != nil {
+		t.Errorf("Archiving the same log twice failed: %v", err)
+	}
+	if _, err := ArchiveLog(archiveDir, logPath, "../foo"); err == nil {
+		t.Errorf("ArchiveLog should fail with an invalid boot ID")
+	}
+
+	removed, err := PruneArchivedLogs(archiveDir, 2)
+	if err != nil {
+		t.Fatalf("PruneArchivedLogs failed: %v", err)
+	}
+	if len(removed) != 1 || removed[0].BootID != bootIDs[0] {
+		t.Errorf("Unexpected removed logs: %v", removed)
+	}
+
+	logs, err := ListArchivedLogs(archiveDir)
+	if err != nil {
+		t.Fatalf("ListArchivedLogs failed: %v", err)
+	}
+	if len(logs) != 2 || logs[0].BootID != bootIDs[2] || logs[1].BootID != bootIDs[1] {
+		t.Errorf("Unexpected archived logs: %v", logs)
+	}
+}
+
+func TestCompareBanksWithPreviousBoot(t *testing.T) {
+	dir, err := ioutil.TempDir("", "tcglog-archive-test")
+	if err != nil {
+		t.Fatalf("TempDir failed: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	logPath := filepath.Join(dir, "log")
+	if err := ioutil.WriteFile(logPath, makeTestCryptoAgileLog(t, 2), 0600); err != nil {
+		t.Fatalf("WriteFile failed: %v", err)
+	}
+	archiveDir := filepath.Join(dir, "archive")
+	if err := os.Mkdir(archiveDir, 0700); err != nil {
+		t.Fatalf("Mkdir failed: %v", err)
+	}
+
+	previousBootID := "0a1b2c3d-0000-0000-0000-000000000001"
+	currentBootID := "0a1b2c3d-0000-0000-0000-000000000002"
+
+	findings, err := CompareBanksWithPreviousBoot(archiveDir, currentBootID, AlgorithmIdList{AlgorithmSha1})
+	if err != nil {
+		t.Fatalf("CompareBanksWithPreviousBoot failed: %v", err)
+	}
+	if len(findings) != 0 {
+		t.Errorf("Unexpected findings without a previous boot: %v", findings)
+	}
+
+	if _, err := ArchiveLog(archiveDir, logPath, previousBootID); err != nil {
+		t.Fatalf("ArchiveLog failed: %v", err)
+	}
+
+	findings, err = CompareBanksWithPreviousBoot(archiveDir, currentBootID,
+		AlgorithmIdList{AlgorithmSha1, AlgorithmSha256})
+	if err != nil {
+		t.Fatalf("CompareBanksWithPreviousBoot failed: %v", err)
+	}
+	if len(findings) != 0 {
+		t.Errorf("Unexpected findings: %v", findings)
+	}
+
+	findings, err = CompareBanksWithPreviousBoot(archiveDir, currentBootID, AlgorithmIdList{AlgorithmSha1})
+	if err != nil {
+		t.Fatalf("CompareBanksWithPreviousBoot failed: %v", err)
+	}
+	if len(findings) != 1 || findings[0].Code != FindingBankDowngrade || findings[0].Severity != FindingSeverityError {
+		t.Errorf("Unexpected findings: %v", findings)
+	}
+
+	// Removing a weaker bank isn't a downgrade of the strongest algorithm.
+	findings, err = CompareBanksWithPreviousBoot(archiveDir, currentBootID, AlgorithmIdList{AlgorithmSha256})
+	if err != nil {
+		t.Fatalf("CompareBanksWithPreviousBoot failed: %v", err)
+	}
+	if len(findings) != 1 || findings[0].Code != FindingBankDowngrade || findings[0].Severity != FindingSeverityWarning {
+		t.Errorf("Unexpected findings: %v", findings)
+	}
+
+	// The log from the current boot is ignored.
+	findings, err = CompareBanksWithPreviousBoot(archiveDir, previousBootID, AlgorithmIdList{AlgorithmSha1})
+	if err != nil {
+		t.Fatalf("CompareBanksWithPreviousBoot failed: %v", err)
+	}
+	if len(findings) != 0 {
+		t.Errorf("Unexpected findings: %v", findings)
+	}
+}
@@ -0,0 +1,146 @@
+package tcglog
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+)
+
+type peSection struct {
+	offset uint32
+	size   uint32
+}
+
+// ComputeAuthenticodeDigest computes the Authenticode digest of the PE image in r, which is size bytes long,
+// using the specified algorithm. This is the digest that firmware measures for EV_EFI_BOOT_SERVICES_APPLICATION,
+// EV_EFI_BOOT_SERVICES_DRIVER and EV_EFI_RUNTIME_SERVICES_DRIVER events, and that shim measures for the images
+// that it loads.
+//
+// https://download.microsoft.com/download/9/c/5/9c5b2167-8017-4bae-9fde-d599bac8184a/Authenticode_PE.docx
+//  (section "Calculating the PE Image Hash")
+func ComputeAuthenticodeDigest(r io.ReaderAt, size int64, alg AlgorithmId) (Digest, error) {
+	if !alg.supported() {
+		return nil, fmt.Errorf("unsupported digest algorithm %s", alg)
+	}
+
+	read := func(off int64, n int) ([]byte, error) {
+		if off < 0 || off+int64(n) > size {
+			return nil, errors.New("image is truncated")
+		}
+		b := make([]byte, n)
+		if _, err := r.ReadAt(b, off); err != nil {
+			return nil, err
+		}
+		return b, nil
+	}
+
+	dos, err := read(0, 0x40)
+	if err != nil {
+		return nil, err
+	}
+	if dos[0] != 'M' || dos[1] != 'Z' {
+		return nil, errors.New("missing MS-DOS header")
+	}
+	peOff := int64(binary.LittleEndian.Uint32(dos[0x3c:]))
+
+	coff, err := read(peOff, 24)
+	if err != nil {
+		return nil, err
+	}
+	if string(coff[:4]) != "PE\x00\x00" {
+		return nil, errors.New("missing PE signature")
+	}
+	numSections := int(binary.LittleEndian.Uint16(coff[6:]))
+	optSize := int(binary.LittleEndian.Uint16(coff[20:]))
+	optOff := peOff + 24
+
+	opt, err := read(optOff, optSize)
+	if err != nil {
+		return nil, err
+	}
+	if len(opt) < 2 {
+		return nil, errors.New("missing optional header")
+	}
+
+	var numDirsOff, dirsOff int
+	switch binary.LittleEndian.Uint16(opt) {
+	case 0x10b: // PE32
+		numDirsOff, dirsOff = 92, 96
+	case 0x20b: // PE32+
+		numDirsOff, dirsOff = 108, 112
+	default:
+		return nil, errors.New("invalid optional header magic")
+	}
+	if len(opt) < dirsOff {
+		return nil, errors.New("optional header is truncated")
+	}
+	const checksumOff = 64
+	headersSize := int64(binary.LittleEndian.Uint32(opt[60:]))
+
+	h := alg.newHash()
+	hashRange := func(start, end int64) error {
+		if end < start {
+			return errors.New("invalid image layout")
+		}
+		b, err := read(start, int(end-start))
+		if err != nil {
+			return err
+		}
+		h.Write(b)
+		return nil
+	}
+
+	// Hash the headers, excluding the checksum and the certificate table entry.
+	checksum := optOff + checksumOff
+	if err := hashRange(0, checksum); err != nil {
+		return nil, err
+	}
+	var certSize int64
+	if binary.LittleEndian.Uint32(opt[numDirsOff:]) > 4 && len(opt) >= dirsOff+5*8 {
+		certDir := optOff + int64(dirsOff) + 4*8
+		certSize = int64(binary.LittleEndian.Uint32(opt[dirsOff+4*8+4:]))
+		if err := hashRange(checksum+4, certDir); err != nil {
+			return nil, err
+		}
+		if err := hashRange(certDir+8, headersSize); err != nil {
+			return nil, err
+		}
+	} else if err := hashRange(checksum+4, headersSize); err != nil {
+		return nil, err
+	}
+
+	// Hash the sections in the order that they appear in the file.
+	sectionHeaders, err := read(optOff+int64(optSize), numSections*40)
+	if err != nil {
+		return nil, err
+	}
+	var sections []peSection
+	for i := 0; i < numSections; i++ {
+		s := sectionHeaders[i*40:]
+		section := peSection{size: binary.LittleEndian.Uint32(s[16:]), offset: binary.LittleEndian.Uint32(s[20:])}
+		if section.size == 0 {
+			continue
+		}
+		sections = append(sections, section)
+	}
+	sort.Slice(sections, func(i, j int) bool { return sections[i].offset < sections[j].offset })
+
+	hashed := headersSize
+	for _, s := range sections {
+		if err := hashRange(int64(s.offset), int64(s.offset)+int64(s.size)); err != nil {
+			return nil, err
+		}
+		hashed += int64(s.size)
+	}
+
+	// Hash any trailing data that isn't part of the certificate table.
+	if end := size - certSize; end > hashed {
+		if err := hashRange(hashed, end); err != nil {
+			return nil, err
+		}
+	}
+
+	return h.Sum(nil), nil
+}
@@ -0,0 +1,75 @@
+package tcglog
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+)
+
+// makeTestPEImage returns a minimal PE32+ image with a single section and a certificate table that contains
+// cert.
+func makeTestPEImage(section, cert []byte) []byte {
+	const (
+		peOff       = 0x40
+		optOff      = peOff + 24
+		optSize     = 112 + 16*8
+		sectionOff  = optOff + optSize
+		headersSize = 0x200
+	)
+
+	image := make([]byte, headersSize)
+	copy(image, "MZ")
+	binary.LittleEndian.PutUint32(image[0x3c:], peOff)
+	copy(image[peOff:], "PE\x00\x00")
+	binary.LittleEndian.PutUint16(image[peOff+6:], 1)        // NumberOfSections
+	binary.LittleEndian.PutUint16(image[peOff+20:], optSize) // SizeOfOptionalHeader
+	binary.LittleEndian.PutUint16(image[optOff:], 0x20b)     // Magic
+	binary.LittleEndian.PutUint32(image[optOff+60:], headersSize)
+	binary.LittleEndian.PutUint32(image[optOff+108:], 16) // NumberOfRvaAndSizes
+
+	copy(image[sectionOff:], ".text")
+	binary.LittleEndian.PutUint32(image[sectionOff+16:], uint32(len(section))) // SizeOfRawData
+	binary.LittleEndian.PutUint32(image[sectionOff+20:], headersSize)          // PointerToRawData
+	image = append(image, section...)
+
+	certDir := optOff + 112 + 4*8
+	binary.LittleEndian.PutUint32(image[certDir:], uint32(len(image)))
+	binary.LittleEndian.PutUint32(image[certDir+4:], uint32(len(cert)))
+	return append(image, cert...)
+}
+
+func TestComputeAuthenticodeDigest(t *testing.T) {
+	compute := func(image []byte) Digest {
+		digest, err := ComputeAuthenticodeDigest(bytes.NewReader(image), int64(len(image)), AlgorithmSha256)
+		if err != nil {
+			t.Fatalf("ComputeAuthenticodeDigest failed: %v", err)
+		}
+		return digest
+	}
+
+	section := bytes.Repeat([]byte{0xcc}, 0x200)
+	image := makeTestPEImage(section, nil)
+	digest := compute(image)
+
+	// The digest doesn't include the checksum, the certificate table entry or the certificates.
+	signed := makeTestPEImage(section, []byte("signature"))
+	binary.LittleEndian.PutUint32(signed[0x40+24+64:], 0x12345678)
+	if !bytes.Equal(compute(signed), digest) {
+		t.Errorf("Signing the image changed the digest")
+	}
+
+	// It does include the headers and the sections.
+	modified := makeTestPEImage(section, nil)
+	modified[0x100] = 1
+	if bytes.Equal(compute(modified), digest) {
+		t.Errorf("Modifying the headers didn't change the digest")
+	}
+	modified = makeTestPEImage(append([]byte{0}, section[1:]...), nil)
+	if bytes.Equal(compute(modified), digest) {
+		t.Errorf("Modifying the section didn't change the digest")
+	}
+
+	if _, err := ComputeAuthenticodeDigest(bytes.NewReader(image[:0x100]), 0x100, AlgorithmSha256); err == nil {
+		t.Errorf("ComputeAuthenticodeDigest should have failed for a truncated image")
+	}
+}
@@ -0,0 +1,88 @@
+package tcglog
+
+import (
+	"fmt"
+)
+
+const (
+	// FindingBankNotActive indicates that the log contains digests for an algorithm for which the
+	// corresponding PCR bank is not active on the TPM. This is common with virtual TPMs, and consistency
+	// checks against PCR values for this bank are not possible.
+	FindingBankNotActive FindingCode = "bank-not-active"
+
+	// FindingBankNotInLog indicates that a PCR bank is active on the TPM but the log doesn't contain digests
+	// for the corresponding algorithm.
+	FindingBankNotInLog FindingCode = "bank-not-in-log"
+
+	// FindingBankDowngrade indicates that a PCR bank that was active during a previous boot is no longer active.
+	// This may be the result of a TPM reconfiguration, but it can also indicate an attempt to downgrade the
+	// platform to a weaker digest algorithm for which PCR values can be forged more easily.
+	FindingBankDowngrade FindingCode = "bank-downgrade"
+)
+
+// CompareBanks compares the digest algorithms declared in a log with the PCR banks that are active on the TPM,
+// and returns a finding for each mismatch. Callers can determine the active banks without using
+// TPM2_GetCapability by observing which banks return values from TPM2_PCR_Read.
+func CompareBanks(logAlgorithms, tpmBanks AlgorithmIdList) (out []Finding) {
+	for _, alg := range logAlgorithms {
+		if tpmBanks.Contains(alg) {
+			continue
+		}
+		out = append(out, Finding{
+			Code:     FindingBankNotActive,
+			Severity: FindingSeverityWarning,
+			Message: fmt.Sprintf("the log contains %s digests but the %s PCR bank is not active on the TPM",
+				alg, alg)})
+	}
+
+	for _, alg := range tpmBanks {
+		if logAlgorithms.Contains(alg) || !alg.supported() {
+			continue
+		}
+		out = append(out, Finding{
+			Code:     FindingBankNotInLog,
+			Severity: FindingSeverityWarning,
+			Message: fmt.Sprintf("the %s PCR bank is active on the TPM but the log doesn't contain %s digests",
+				alg, alg)})
+	}
+
+	return
+}
+
+// strongestAlgorithmSize returns the digest size of the strongest supported algorithm in the list, or zero if
+// the list doesn't contain any supported algorithms.
+func strongestAlgorithmSize(algs AlgorithmIdList) (size int) {
+	for _, alg := range algs {
+		if !alg.supported() {
+			continue
+		}
+		if s := alg.size(); s > size {
+			size = s
+		}
+	}
+	return size
+}
+
+// CompareBootBanks compares the digest algorithms declared in the logs from two consecutive boots of the same
+// platform, and returns a finding for each algorithm that was present in the previous log but isn't present in
+// the current one. The finding is an error if the strongest remaining algorithm is weaker than the strongest
+// algorithm from the previous boot, and a warning otherwise.
+func CompareBootBanks(previous, current AlgorithmIdList) (out []Finding) {
+	severity := FindingSeverityWarning
+	if strongestAlgorithmSize(current) < strongestAlgorithmSize(previous) {
+		severity = FindingSeverityError
+	}
+
+	for _, alg := range previous {
+		if current.Contains(alg) || !alg.supported() {
+			continue
+		}
+		out = append(out, Finding{
+			Code:     FindingBankDowngrade,
+			Severity: severity,
+			Message: fmt.Sprintf("the log from the previous boot contains %s digests but the log from the "+
+				"current boot doesn't", alg)})
+	}
+
+	return
+}
@@ -0,0 +1,132 @@
+package tcglog
+
+import (
+	"testing"
+)
+
+func TestCompareBanks(t *testing.T) {
+	for _, data := range []struct {
+		desc          string
+		logAlgorithms AlgorithmIdList
+		tpmBanks      AlgorithmIdList
+		codes         []FindingCode
+		messages      []string
+	}{
+		{
+			desc:          "Match",
+			logAlgorithms: AlgorithmIdList{AlgorithmSha1, AlgorithmSha256},
+			tpmBanks:      AlgorithmIdList{AlgorithmSha256, AlgorithmSha1},
+		},
+		{
+			desc:          "NotActive",
+			logAlgorithms: AlgorithmIdList{AlgorithmSha1, AlgorithmSha256},
+			tpmBanks:      AlgorithmIdList{AlgorithmSha256},
+			codes:         []FindingCode{FindingBankNotActive},
+			messages:      []string{"the log contains SHA-1 digests but the SHA-1 PCR bank is not active on the TPM"},
+		},
+		{
+			desc:          "NotInLog",
+			logAlgorithms: AlgorithmIdList{AlgorithmSha256},
+			tpmBanks:      AlgorithmIdList{AlgorithmSha256, AlgorithmSha384},
+			codes:         []FindingCode{FindingBankNotInLog},
+			messages: []string{"the SHA-384 PCR bank is active on the TPM but the log doesn't contain SHA-384 " +
+				"digests"},
+		},
+		{
+			desc:          "NotInLogUnsupported",
+			logAlgorithms: AlgorithmIdList{AlgorithmSha256},
+			tpmBanks:      AlgorithmIdList{AlgorithmSha256, AlgorithmId(0x1234)},
+		},
+		{
+			desc:          "Both",
+			logAlgorithms: AlgorithmIdList{AlgorithmSha1},
+			tpmBanks:      AlgorithmIdList{AlgorithmSha256},
+			codes:         []FindingCode{FindingBankNotActive, FindingBankNotInLog},
+		},
+	} {
+		t.Run(data.desc, func(t *testing.T) {
+			findings := CompareBanks(data.logAlgorithms, data.tpmBanks)
+			if len(findings) != len(data.codes) {
+				t.Fatalf("Unexpected findings: %v", findings)
+			}
+			for i, f := range findings {
+				if f.Code != data.codes[i] {
+					t.Errorf("Unexpected code %s", f.Code)
+				}
+				if f.Severity != FindingSeverityWarning {
+					t.Errorf("Unexpected severity %s", f.Severity)
+				}
+				if i < len(data.messages) && f.Message != data.messages[i] {
+					t.Errorf("Unexpected message %q", f.Message)
+				}
+			}
+		})
+	}
+}
+
+func TestCompareBootBanks(t *testing.T) {
+	for _, data := range []struct {
+		desc     string
+		previous AlgorithmIdList
+		current  AlgorithmIdList
+		removed  AlgorithmIdList
+		severity FindingSeverity
+	}{
+		{
+			desc:     "Unchanged",
+			previous: AlgorithmIdList{AlgorithmSha1, AlgorithmSha256},
+			current:  AlgorithmIdList{AlgorithmSha256, AlgorithmSha1},
+		},
+		{
+			desc:     "Added",
+			previous: AlgorithmIdList{AlgorithmSha256},
+			current:  AlgorithmIdList{AlgorithmSha256, AlgorithmSha384},
+		},
+		{
+			desc:     "WeakerRemoved",
+			previous: AlgorithmIdList{AlgorithmSha1, AlgorithmSha256},
+			current:  AlgorithmIdList{AlgorithmSha256},
+			removed:  AlgorithmIdList{AlgorithmSha1},
+			severity: FindingSeverityWarning,
+		},
+		{
+			desc:     "Downgrade",
+			previous: AlgorithmIdList{AlgorithmSha1, AlgorithmSha256},
+			current:  AlgorithmIdList{AlgorithmSha1},
+			removed:  AlgorithmIdList{AlgorithmSha256},
+			severity: FindingSeverityError,
+		},
+		{
+			desc:     "Replaced",
+			previous: AlgorithmIdList{AlgorithmSha384},
+			current:  AlgorithmIdList{AlgorithmSha256},
+			removed:  AlgorithmIdList{AlgorithmSha384},
+			severity: FindingSeverityError,
+		},
+		{
+			desc:     "UnsupportedRemoved",
+			previous: AlgorithmIdList{AlgorithmSha256, AlgorithmId(0x1234)},
+			current:  AlgorithmIdList{AlgorithmSha256},
+		},
+	} {
+		t.Run(data.desc, func(t *testing.T) {
+			findings := CompareBootBanks(data.previous, data.current)
+			if len(findings) != len(data.removed) {
+				t.Fatalf("Unexpected findings: %v", findings)
+			}
+			for i, f := range findings {
+				if f.Code != FindingBankDowngrade {
+					t.Errorf("Unexpected code %s", f.Code)
+				}
+				if f.Severity != data.severity {
+					t.Errorf("Unexpected severity %s", f.Severity)
+				}
+				expected := "the log from the previous boot contains " + data.removed[i].String() +
+					" digests but the log from the current boot doesn't"
+				if f.Message != expected {
+					t.Errorf("Unexpected message %q", f.Message)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,199 @@
+package tcglog
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+)
+
+// BIMLocatorType describes how the location of a reference manifest or platform certificate is recorded in a
+// SP800-155 Event3 event.
+type BIMLocatorType uint32
+
+const (
+	BIMLocatorRaw          BIMLocatorType = 0 // The locator contains the data itself
+	BIMLocatorURI          BIMLocatorType = 1 // The locator is a URI
+	BIMLocatorDevicePath   BIMLocatorType = 2 // The locator is a local device path
+	BIMLocatorUEFIVariable BIMLocatorType = 3 // The locator is a UEFI variable
+	BIMLocatorLBA          BIMLocatorType = 4 // The locator is a logical block address
+)
+
+func (t BIMLocatorType) String() string {
+	switch t {
+	case BIMLocatorRaw:
+		return "raw"
+	case BIMLocatorURI:
+		return "uri"
+	case BIMLocatorDevicePath:
+		return "device-path"
+	case BIMLocatorUEFIVariable:
+		return "uefi-variable"
+	case BIMLocatorLBA:
+		return "lba"
+	default:
+		return fmt.Sprintf("%#x", uint32(t))
+	}
+}
+
+// BIMReferenceManifestEventData corresponds to the event data for the EV_NO_ACTION events that identify the
+// BIOS Integrity Measurement reference manifest described in NIST SP 800-155. The original "SP800-155 Event"
+// layout only contains VendorId and Guid, although some firmware appends the platform and firmware
+// descriptions of the later layouts to it. The "SP800-155 Event2" layout always contains these descriptions,
+// and the "SP800-155 Event3" layout also records where the reference manifest and platform certificate can be
+// found.
+type BIMReferenceManifestEventData struct {
+	data    []byte
+	Version int // The layout version - 1, 2 or 3 for the "SP800-155 Event", "Event2" and "Event3" signatures
+
+	VendorId uint32  // The IANA Private Enterprise Number of the platform manufacturer
+	Guid     EFIGUID // The reference manifest GUID
+
+	PlatformManufacturer   string
+	PlatformModel          string
+	PlatformVersion        string
+	FirmwareManufacturer   string
+	FirmwareManufacturerId uint32 // The IANA Private Enterprise Number of the firmware manufacturer
+	FirmwareVersion        string
+
+	RIMLocatorType          BIMLocatorType
+	RIMLocator              []byte // The location of the reference manifest, or nil if it isn't recorded
+	PlatformCertLocatorType BIMLocatorType
+	PlatformCertLocator     []byte // The location of the platform certificate, or nil if it isn't recorded
+}
+
+func (e *BIMReferenceManifestEventData) String() string {
+	var builder bytes.Buffer
+	fmt.Fprintf(&builder, "Sp800_155_PlatformId_Event{ VendorId: %d, ReferenceManifestGuid: %s", e.VendorId,
+		&e.Guid)
+	if e.PlatformManufacturer != "" {
+		fmt.Fprintf(&builder, ", PlatformManufacturer: %q, PlatformModel: %q, PlatformVersion: %q",
+			e.PlatformManufacturer, e.PlatformModel, e.PlatformVersion)
+	}
+	if e.FirmwareManufacturer != "" {
+		fmt.Fprintf(&builder, ", FirmwareManufacturer: %q, FirmwareVersion: %q", e.FirmwareManufacturer,
+			e.FirmwareVersion)
+	}
+	if uri, ok := e.RIMLocatorURI(); ok {
+		fmt.Fprintf(&builder, ", RIMLocator: %s", uri)
+	}
+	builder.WriteString(" }")
+	return builder.String()
+}
+
+func (e *BIMReferenceManifestEventData) Bytes() []byte {
+	return e.data
+}
+
+func (e *BIMReferenceManifestEventData) Type() NoActionEventType {
+	return BiosIntegrityMeasurement
+}
+
+// RIMLocatorURI returns the URI from which the reference manifest can be retrieved, if the event records one.
+func (e *BIMReferenceManifestEventData) RIMLocatorURI() (string, bool) {
+	if e.RIMLocatorType != BIMLocatorURI || len(e.RIMLocator) == 0 {
+		return "", false
+	}
+	return string(bytes.TrimRight(e.RIMLocator, "\x00")), true
+}
+
+// readBIMString reads a string that is prefixed with a single byte size. Some firmware includes a NULL
+// terminator in the size.
+func readBIMString(r io.Reader) (string, error) {
+	var size uint8
+	if err := binary.Read(r, binary.LittleEndian, &size); err != nil {
+		return "", err
+	}
+	str := make([]byte, size)
+	if _, err := io.ReadFull(r, str); err != nil {
+		return "", err
+	}
+	return string(bytes.TrimRight(str, "\x00")), nil
+}
+
+func readBIMLocator(r *bytes.Reader) (BIMLocatorType, []byte, error) {
+	var hdr [8]byte
+	if _, err := io.ReadFull(r, hdr[:]); err != nil {
+		return 0, nil, err
+	}
+	t := BIMLocatorType(binary.LittleEndian.Uint32(hdr[0:]))
+	size := binary.LittleEndian.Uint32(hdr[4:])
+	if size == 0 {
+		return t, nil, nil
+	}
+	if int64(size) > int64(r.Len()) {
+		return 0, nil, io.ErrUnexpectedEOF
+	}
+	locator := make([]byte, size)
+	if _, err := io.ReadFull(r, locator); err != nil {
+		return 0, nil, err
+	}
+	return t, locator, nil
+}
+
+func (e *BIMReferenceManifestEventData) decodePlatformDescription(r io.Reader) (err error) {
+	if e.PlatformManufacturer, err = readBIMString(r); err != nil {
+		return err
+	}
+	if e.PlatformModel, err = readBIMString(r); err != nil {
+		return err
+	}
+	if e.PlatformVersion, err = readBIMString(r); err != nil {
+		return err
+	}
+	if e.FirmwareManufacturer, err = readBIMString(r); err != nil {
+		return err
+	}
+	if err := binary.Read(r, binary.LittleEndian, &e.FirmwareManufacturerId); err != nil {
+		return err
+	}
+	if e.FirmwareVersion, err = readBIMString(r); err != nil {
+		return err
+	}
+	return nil
+}
+
+// https://trustedcomputinggroup.org/wp-content/uploads/TCG_PCClientSpecPlat_TPM_2p0_1p04_pub.pdf
+//  (section 9.4.5.2 "BIOS Integrity Measurement Reference Manifest Event")
+// https://trustedcomputinggroup.org/wp-content/uploads/TCG_PCClient_PFP_r1p05_v23_pub.pdf
+//  (section 10.4.5.2 "SP800-155 Event")
+// https://trustedcomputinggroup.org/wp-content/uploads/TCG_EFI_Platform_1_22_Final_-v15.pdf
+//  (section 7.4 "EV_NO_ACTION Event Types")
+func decodeBIMReferenceManifestEvent(stream *bytes.Reader, data []byte,
+	version int) (*BIMReferenceManifestEventData, error) {
+	d := &BIMReferenceManifestEventData{data: data, Version: version}
+
+	if err := binary.Read(stream, binary.LittleEndian, &d.VendorId); err != nil {
+		return nil, err
+	}
+	guid, err := readEFIGUID(stream)
+	if err != nil {
+		return nil, err
+	}
+	d.Guid = guid
+
+	if version == 1 {
+		// The platform description is optional for this layout, so ignore it if it's malformed.
+		if stream.Len() > 0 {
+			desc := *d
+			if err := desc.decodePlatformDescription(stream); err == nil {
+				d = &desc
+			}
+		}
+		return d, nil
+	}
+	if err := d.decodePlatformDescription(stream); err != nil {
+		return nil, err
+	}
+	if version == 2 {
+		return d, nil
+	}
+
+	if d.RIMLocatorType, d.RIMLocator, err = readBIMLocator(stream); err != nil {
+		return nil, err
+	}
+	if d.PlatformCertLocatorType, d.PlatformCertLocator, err = readBIMLocator(stream); err != nil {
+		return nil, err
+	}
+	return d, nil
+}
@@ -0,0 +1,92 @@
+package tcglog
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+)
+
+func makeBIMEventData(signature string, full bool, locator string) []byte {
+	var data bytes.Buffer
+	data.WriteString(signature)
+	binary.Write(&data, binary.LittleEndian, uint32(1234))
+	binary.Write(&data, binary.LittleEndian, *EFIGlobalVariableGUID)
+	if !full {
+		return data.Bytes()
+	}
+	for _, s := range []string{"Acme\x00", "Widget 3000", "1.0"} {
+		data.WriteByte(byte(len(s)))
+		data.WriteString(s)
+	}
+	data.WriteByte(4)
+	data.WriteString("Acme")
+	binary.Write(&data, binary.LittleEndian, uint32(5678))
+	data.WriteByte(5)
+	data.WriteString("2.3.4")
+	if locator == "" {
+		return data.Bytes()
+	}
+	binary.Write(&data, binary.LittleEndian, BIMLocatorURI)
+	binary.Write(&data, binary.LittleEndian, uint32(len(locator)))
+	data.WriteString(locator)
+	binary.Write(&data, binary.LittleEndian, BIMLocatorRaw)
+	binary.Write(&data, binary.LittleEndian, uint32(0))
+	return data.Bytes()
+}
+
+func TestDecodeBIMReferenceManifestEvent(t *testing.T) {
+	for _, data := range []struct {
+		desc    string
+		data    []byte
+		version int
+		full    bool
+		uri     string
+	}{
+		{desc: "event", data: makeBIMEventData("SP800-155 Event\x00", false, ""), version: 1},
+		{desc: "event/extended", data: makeBIMEventData("SP800-155 Event\x00", true, ""), version: 1, full: true},
+		{desc: "event/garbage", data: append(makeBIMEventData("SP800-155 Event\x00", false, ""), 0xff), version: 1},
+		{desc: "event2", data: makeBIMEventData("SP800-155 Event2", true, ""), version: 2, full: true},
+		{
+			desc:    "event3",
+			data:    makeBIMEventData("SP800-155 Event3", true, "https://example.com/rim.swidtag"),
+			version: 3,
+			full:    true,
+			uri:     "https://example.com/rim.swidtag",
+		},
+	} {
+		t.Run(data.desc, func(t *testing.T) {
+			out, _, err := decodeEventDataTCG(EventTypeNoAction, data.data, false)
+			if err != nil {
+				t.Fatalf("decodeEventDataTCG failed: %v", err)
+			}
+			d, ok := out.(*BIMReferenceManifestEventData)
+			if !ok {
+				t.Fatalf("Unexpected event data type %T", out)
+			}
+			if d.Version != data.version || d.VendorId != 1234 || d.Guid != *EFIGlobalVariableGUID {
+				t.Errorf("Unexpected event data %#v", d)
+			}
+			if data.full {
+				if d.PlatformManufacturer != "Acme" || d.PlatformModel != "Widget 3000" || d.PlatformVersion != "1.0" ||
+					d.FirmwareManufacturer != "Acme" || d.FirmwareManufacturerId != 5678 || d.FirmwareVersion != "2.3.4" {
+					t.Errorf("Unexpected platform description %#v", d)
+				}
+			} else if d.PlatformManufacturer != "" {
+				t.Errorf("Unexpected platform manufacturer %q", d.PlatformManufacturer)
+			}
+			uri, ok := d.RIMLocatorURI()
+			if ok != (data.uri != "") || uri != data.uri {
+				t.Errorf("Unexpected RIM locator URI %q", uri)
+			}
+			if d.PlatformCertLocator != nil {
+				t.Errorf("Unexpected platform certificate locator %x", d.PlatformCertLocator)
+			}
+		})
+	}
+
+	truncated := makeBIMEventData("SP800-155 Event3", true, "https://example.com/rim.swidtag")
+	out, _, err := decodeEventDataTCG(EventTypeNoAction, truncated[:len(truncated)-10], false)
+	if err == nil {
+		t.Errorf("decodeEventDataTCG should fail for a truncated event (%v)", out)
+	}
+}
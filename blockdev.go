@@ -0,0 +1,133 @@
+package tcglog
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// BlockDevice describes a block device on the current system.
+type BlockDevice struct {
+	Name        string   // The kernel name of the device, eg, "nvme0n1p1"
+	Path        string   // The path of the device node, eg, "/dev/nvme0n1p1"
+	MountPoints []string // The paths at which the device is mounted, if any
+}
+
+func (d *BlockDevice) String() string {
+	if len(d.MountPoints) == 0 {
+		return d.Path
+	}
+	return fmt.Sprintf("%s (%s)", d.MountPoints[0], d.Name)
+}
+
+// BlockDeviceResolver maps hard drive device path nodes to block devices on a Linux system, using the
+// partition UUID symlinks maintained by udev and the mount table of the current process.
+type BlockDeviceResolver struct {
+	root string
+}
+
+// NewBlockDeviceResolver returns a new BlockDeviceResolver for the system with the specified root directory,
+// which would normally be "/".
+func NewBlockDeviceResolver(root string) *BlockDeviceResolver {
+	return &BlockDeviceResolver{root: root}
+}
+
+// partUUID returns the partition UUID that udev uses for the partition identified by node.
+func partUUID(node *EFIHardDriveDevicePathNode) (string, error) {
+	switch node.SignatureType {
+	case 0x01:
+		return fmt.Sprintf("%08x-%02x", binary.LittleEndian.Uint32(node.Signature[:]), node.PartitionNumber), nil
+	case 0x02:
+		return efiGUIDText(node.PartitionGUID()), nil
+	default:
+		return "", fmt.Errorf("unsupported partition signature type %d", node.SignatureType)
+	}
+}
+
+func unescapeMountInfoField(field string) string {
+	var builder bytes.Buffer
+	for i := 0; i < len(field); i++ {
+		if field[i] == '\\' && i+3 < len(field) {
+			if c, err := strconv.ParseUint(field[i+1:i+4], 8, 8); err == nil {
+				builder.WriteByte(byte(c))
+				i += 3
+				continue
+			}
+		}
+		builder.WriteByte(field[i])
+	}
+	return builder.String()
+}
+
+func (r *BlockDeviceResolver) mountPoints(devNum string) ([]string, error) {
+	f, err := os.Open(filepath.Join(r.root, "proc/self/mountinfo"))
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var out []string
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 5 {
+			continue
+		}
+		if fields[2] == devNum {
+			out = append(out, unescapeMountInfoField(fields[4]))
+		}
+	}
+	return out, scanner.Err()
+}
+
+// ResolveHardDriveNode returns the block device corresponding to the partition that the supplied hard drive
+// device path node refers to.
+func (r *BlockDeviceResolver) ResolveHardDriveNode(node *EFIHardDriveDevicePathNode) (*BlockDevice, error) {
+	uuid, err := partUUID(node)
+	if err != nil {
+		return nil, err
+	}
+
+	target, err := os.Readlink(filepath.Join(r.root, "dev/disk/by-partuuid", uuid))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, errors.New("no block device found for partition " + uuid)
+		}
+		return nil, err
+	}
+
+	name := filepath.Base(target)
+	dev := &BlockDevice{Name: name, Path: filepath.Join("/dev", name)}
+
+	devNum, err := ioutil.ReadFile(filepath.Join(r.root, "sys/class/block", name, "dev"))
+	if err != nil {
+		return nil, err
+	}
+	dev.MountPoints, err = r.mountPoints(strings.TrimSpace(string(devNum)))
+	if err != nil {
+		return nil, err
+	}
+
+	return dev, nil
+}
+
+// ResolveDevicePath returns the block device that the supplied device path refers to, and the path of the
+// file on that device.
+func (r *BlockDeviceResolver) ResolveDevicePath(path EFIDevicePath) (*BlockDevice, string, error) {
+	hd := path.HardDrive()
+	if hd == nil {
+		return nil, "", errors.New("device path doesn't refer to a partition")
+	}
+	dev, err := r.ResolveHardDriveNode(hd)
+	if err != nil {
+		return nil, "", err
+	}
+	return dev, path.FilePath(), nil
+}
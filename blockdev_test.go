@@ -0,0 +1,56 @@
+package tcglog
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestBlockDeviceResolver(t *testing.T) {
+	root, err := ioutil.TempDir("", "tcglog-blockdev-test")
+	if err != nil {
+		t.Fatalf("TempDir failed: %v", err)
+	}
+	defer os.RemoveAll(root)
+
+	for _, dir := range []string{"dev/disk/by-partuuid", "sys/class/block/nvme0n1p1", "proc/self"} {
+		if err := os.MkdirAll(filepath.Join(root, dir), 0755); err != nil {
+			t.Fatalf("MkdirAll failed: %v", err)
+		}
+	}
+	if err := os.Symlink("../../nvme0n1p1", filepath.Join(root, "dev/disk/by-partuuid",
+		"d719b2cb-3d3a-4596-a3bc-dad00e67656f")); err != nil {
+		t.Fatalf("Symlink failed: %v", err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(root, "sys/class/block/nvme0n1p1/dev"), []byte("259:1\n"), 0644); err != nil {
+		t.Fatalf("WriteFile failed: %v", err)
+	}
+	mountInfo := "22 1 259:2 / / rw,relatime shared:1 - ext4 /dev/nvme0n1p2 rw\n" +
+		"35 22 259:1 / /boot/efi rw,relatime shared:2 - vfat /dev/nvme0n1p1 rw\n" +
+		"36 22 259:1 / /mnt/efi\\040copy rw,relatime shared:3 - vfat /dev/nvme0n1p1 rw\n"
+	if err := ioutil.WriteFile(filepath.Join(root, "proc/self/mountinfo"), []byte(mountInfo), 0644); err != nil {
+		t.Fatalf("WriteFile failed: %v", err)
+	}
+
+	node := &EFIHardDriveDevicePathNode{PartitionNumber: 1, SignatureType: 0x02,
+		Signature: [16]byte{0xcb, 0xb2, 0x19, 0xd7, 0x3a, 0x3d, 0x96, 0x45, 0xa3, 0xbc, 0xda, 0xd0, 0x0e, 0x67, 0x65, 0x6f}}
+	path := EFIDevicePath{node, EFIFilePathDevicePathNode("\\EFI\\ubuntu\\shimx64.efi")}
+
+	dev, file, err := NewBlockDeviceResolver(root).ResolveDevicePath(path)
+	if err != nil {
+		t.Fatalf("ResolveDevicePath failed: %v", err)
+	}
+	if dev.Name != "nvme0n1p1" || dev.Path != "/dev/nvme0n1p1" {
+		t.Errorf("Unexpected device: %+v", dev)
+	}
+	if len(dev.MountPoints) != 2 || dev.MountPoints[0] != "/boot/efi" || dev.MountPoints[1] != "/mnt/efi copy" {
+		t.Errorf("Unexpected mount points: %q", dev.MountPoints)
+	}
+	if dev.String() != "/boot/efi (nvme0n1p1)" {
+		t.Errorf("Unexpected string: %s", dev)
+	}
+	if file != "\\EFI\\ubuntu\\shimx64.efi" {
+		t.Errorf("Unexpected file: %s", file)
+	}
+}
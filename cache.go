@@ -0,0 +1,111 @@
+package tcglog
+
+import (
+	"container/list"
+	"crypto/sha256"
+	"sync"
+)
+
+// CacheKind identifies the type of verification that a cached result belongs to.
+type CacheKind string
+
+const (
+	CacheKindAuthenticodeDigest CacheKind = "authenticode-digest" // The Authenticode digests of a PE image
+	CacheKindRIMLookup          CacheKind = "rim-lookup"          // The result of a reference integrity manifest lookup
+	CacheKindCertificate        CacheKind = "certificate"         // A parsed X.509 certificate
+)
+
+// CacheKey is a content-addressed key for a VerificationCache. It is derived from the kind of verification and
+// the SHA-256 digest of the data that the verification is performed on, so results can be shared between logs
+// that reference the same binaries, variables or certificates.
+type CacheKey struct {
+	Kind   CacheKind
+	Digest [sha256.Size]byte
+}
+
+// NewCacheKey returns the key for the result of the specified kind of verification on the supplied data.
+func NewCacheKey(kind CacheKind, data []byte) CacheKey {
+	return CacheKey{Kind: kind, Digest: sha256.Sum256(data)}
+}
+
+// VerificationCache is implemented by caches for the results of expensive verifications, such as computing
+// Authenticode digests, looking up reference integrity manifests or parsing certificates. This is useful when
+// validating many logs that reference the same components. Implementations must be safe for concurrent use.
+type VerificationCache interface {
+	// Get returns the cached result for the specified key, if there is one.
+	Get(key CacheKey) (interface{}, bool)
+
+	// Put adds a result to the cache.
+	Put(key CacheKey, value interface{})
+}
+
+type memoryCacheEntry struct {
+	key   CacheKey
+	value interface{}
+}
+
+type memoryVerificationCache struct {
+	mu         sync.Mutex
+	maxEntries int
+	entries    map[CacheKey]*list.Element
+	lru        *list.List
+}
+
+func (c *memoryVerificationCache) Get(key CacheKey) (interface{}, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	elem, ok := c.entries[key]
+	if !ok {
+		return nil, false
+	}
+	c.lru.MoveToFront(elem)
+	return elem.Value.(*memoryCacheEntry).value, true
+}
+
+func (c *memoryVerificationCache) Put(key CacheKey, value interface{}) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if elem, ok := c.entries[key]; ok {
+		elem.Value.(*memoryCacheEntry).value = value
+		c.lru.MoveToFront(elem)
+		return
+	}
+
+	c.entries[key] = c.lru.PushFront(&memoryCacheEntry{key: key, value: value})
+	if c.maxEntries > 0 && c.lru.Len() > c.maxEntries {
+		oldest := c.lru.Back()
+		c.lru.Remove(oldest)
+		delete(c.entries, oldest.Value.(*memoryCacheEntry).key)
+	}
+}
+
+// NewMemoryVerificationCache returns a VerificationCache that stores results in memory. If maxEntries is greater
+// than zero, the least recently used results are evicted once the cache contains more than maxEntries results.
+func NewMemoryVerificationCache(maxEntries int) VerificationCache {
+	return &memoryVerificationCache{
+		maxEntries: maxEntries,
+		entries:    make(map[CacheKey]*list.Element),
+		lru:        list.New()}
+}
+
+// cachedVerify returns the result of the specified kind of verification on data from cache if it is present, or
+// computes it with fn and adds it to the cache. Errors are not cached. If cache is nil, fn is always called.
+func cachedVerify(cache VerificationCache, kind CacheKind, data []byte, fn func() (interface{}, error)) (interface{}, error) {
+	if cache == nil {
+		return fn()
+	}
+
+	key := NewCacheKey(kind, data)
+	if value, ok := cache.Get(key); ok {
+		return value, nil
+	}
+
+	value, err := fn()
+	if err != nil {
+		return nil, err
+	}
+	cache.Put(key, value)
+	return value, nil
+}
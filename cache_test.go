@@ -0,0 +1,70 @@
+package tcglog
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestMemoryVerificationCacheEviction(t *testing.T) {
+	cache := NewMemoryVerificationCache(2)
+
+	a := NewCacheKey(CacheKindCertificate, []byte("a"))
+	b := NewCacheKey(CacheKindCertificate, []byte("b"))
+	c := NewCacheKey(CacheKindCertificate, []byte("c"))
+
+	cache.Put(a, 1)
+	cache.Put(b, 2)
+	if v, ok := cache.Get(a); !ok || v != 1 {
+		t.Errorf("Unexpected result for a (%v, %v)", v, ok)
+	}
+	cache.Put(c, 3)
+
+	if _, ok := cache.Get(b); ok {
+		t.Errorf("b should have been evicted")
+	}
+	if v, ok := cache.Get(a); !ok || v != 1 {
+		t.Errorf("Unexpected result for a (%v, %v)", v, ok)
+	}
+	if v, ok := cache.Get(c); !ok || v != 3 {
+		t.Errorf("Unexpected result for c (%v, %v)", v, ok)
+	}
+
+	if _, ok := cache.Get(NewCacheKey(CacheKindRIMLookup, []byte("a"))); ok {
+		t.Errorf("Keys for different kinds should not collide")
+	}
+}
+
+func TestCachedVerify(t *testing.T) {
+	cache := NewMemoryVerificationCache(0)
+
+	calls := 0
+	fn := func() (interface{}, error) {
+		calls++
+		return "result", nil
+	}
+	for i := 0; i < 3; i++ {
+		v, err := cachedVerify(cache, CacheKindAuthenticodeDigest, []byte("image"), fn)
+		if err != nil {
+			t.Fatalf("cachedVerify failed: %v", err)
+		}
+		if v != "result" {
+			t.Errorf("Unexpected result %v", v)
+		}
+	}
+	if calls != 1 {
+		t.Errorf("Unexpected number of calls (%d)", calls)
+	}
+
+	failing := func() (interface{}, error) {
+		calls++
+		return nil, errors.New("error")
+	}
+	for i := 0; i < 2; i++ {
+		if _, err := cachedVerify(cache, CacheKindAuthenticodeDigest, []byte("other"), failing); err == nil {
+			t.Errorf("cachedVerify should have failed")
+		}
+	}
+	if calls != 3 {
+		t.Errorf("Errors should not be cached")
+	}
+}
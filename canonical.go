@@ -0,0 +1,59 @@
+package tcglog
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+)
+
+// CanonicalLog is a normalized representation of a sequence of events.
+type CanonicalLog struct {
+	Data   []byte // The canonical encoding of the events
+	Digest Digest // The SHA-256 digest of Data
+}
+
+// writeCanonicalEvent writes the canonical encoding of a single event, which is one line of the format produced
+// by Canonicalize.
+func writeCanonicalEvent(buf *bytes.Buffer, event *Event) error {
+	if !isPCRIndexInRange(event.PCRIndex) {
+		return wrapPCRIndexOutOfRangeError(event.PCRIndex)
+	}
+	if len(event.Digests) == 0 {
+		return errors.New("no digests")
+	}
+
+	fmt.Fprintf(buf, "event %d 0x%08x", event.PCRIndex, uint32(event.EventType))
+	for _, alg := range sortedDigestAlgorithms(event.Digests) {
+		fmt.Fprintf(buf, " 0x%04x:%x", uint16(alg), []byte(event.Digests[alg]))
+	}
+	var data []byte
+	if event.Data != nil {
+		data = event.Data.Bytes()
+	}
+	fmt.Fprintf(buf, " %x\n", data)
+	return nil
+}
+
+// Canonicalize produces a normalized representation of the supplied events and a digest of it, so that two
+// copies of the same log can be compared or deduplicated by digest. The encoding only depends on the PCR index,
+// event type, digests and raw data of each event, in the order supplied. The Index field of each event and the
+// order in which digests are stored are ignored, and digests are always encoded in ascending order of algorithm.
+//
+// The format is:
+//	tcglog-canonical 1
+//	event <pcr> <event type> <alg>:<digest> ... <data>
+//	...
+func Canonicalize(events []*Event) (*CanonicalLog, error) {
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "tcglog-canonical 1\n")
+
+	for i, event := range events {
+		if err := writeCanonicalEvent(&buf, event); err != nil {
+			return nil, fmt.Errorf("event %d: %w", i, err)
+		}
+	}
+
+	h := sha256.Sum256(buf.Bytes())
+	return &CanonicalLog{Data: buf.Bytes(), Digest: h[:]}, nil
+}
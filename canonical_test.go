@@ -0,0 +1,54 @@
+package tcglog
+
+import (
+	"bytes"
+	"io"
+	"testing"
+)
+
+func readTestEvents(t *testing.T, data []byte) (out []*Event) {
+	log, err := NewLog(bytes.NewReader(data), LogOptions{})
+	if err != nil {
+		t.Fatalf("NewLog failed: %v", err)
+	}
+	for {
+		event, err := log.NextEvent()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("NextEvent failed: %v", err)
+		}
+		out = append(out, event)
+	}
+	return
+}
+
+func TestCanonicalize(t *testing.T) {
+	data := makeTestCryptoAgileLog(t, 5)
+
+	c1, err := Canonicalize(readTestEvents(t, data))
+	if err != nil {
+		t.Fatalf("Canonicalize failed: %v", err)
+	}
+	events := readTestEvents(t, data)
+	for _, e := range events {
+		e.Index += 100
+	}
+	c2, err := Canonicalize(events)
+	if err != nil {
+		t.Fatalf("Canonicalize failed: %v", err)
+	}
+	if !bytes.Equal(c1.Data, c2.Data) || !bytes.Equal(c1.Digest, c2.Digest) {
+		t.Errorf("Canonical forms of the same log differ")
+	}
+
+	events[len(events)-1].PCRIndex ^= 1
+	c3, err := Canonicalize(events)
+	if err != nil {
+		t.Fatalf("Canonicalize failed: %v", err)
+	}
+	if bytes.Equal(c1.Digest, c3.Digest) {
+		t.Errorf("Canonical digests of different logs are the same")
+	}
+}
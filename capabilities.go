@@ -0,0 +1,80 @@
+package tcglog
+
+import (
+	"runtime/debug"
+	"sort"
+)
+
+// CapabilitiesVersion is incremented whenever support for a new specification, event type, digest algorithm or
+// format is added to this package. It is reported by Capabilities.
+const CapabilitiesVersion = 3
+
+const modulePath = "github.com/chrisccoulson/tcglog-parser"
+
+// ParserCapabilities describes what the version of this package that is linked in to a binary supports, so that
+// orchestration layers can check whether a deployed agent can parse the logs that a platform emits.
+type ParserCapabilities struct {
+	Version       int             `json:"version"`                 // CapabilitiesVersion
+	ModuleVersion string          `json:"moduleVersion,omitempty"` // The module version from the build information
+	Specs         []Spec          `json:"specs"`                   // The log specifications that can be parsed
+	EventTypes    []EventType     `json:"eventTypes"`              // The event types that are recognized
+	Algorithms    AlgorithmIdList `json:"algorithms"`              // The digest algorithms that can be verified
+	CCTypes       []CCType        `json:"ccTypes"`                 // The confidential computing environments
+	InputFormats  []string        `json:"inputFormats"`            // The formats that can be read
+	OutputFormats []string        `json:"outputFormats"`           // The formats that can be written
+}
+
+// moduleVersion returns the version of this module from the build information of the running binary, if it is
+// available.
+func moduleVersion() string {
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return ""
+	}
+	if info.Main.Path == modulePath {
+		return info.Main.Version
+	}
+	for _, dep := range info.Deps {
+		if dep.Path != modulePath {
+			continue
+		}
+		if dep.Replace != nil {
+			return dep.Replace.Version
+		}
+		return dep.Version
+	}
+	return ""
+}
+
+// Capabilities returns a description of the specifications, event types, digest algorithms and formats that are
+// supported by this package at runtime. The digest algorithms include those added with RegisterAlgorithm, and
+// exclude those for which the hash implementation hasn't been linked in to the binary.
+func Capabilities() *ParserCapabilities {
+	c := &ParserCapabilities{
+		Version:       CapabilitiesVersion,
+		ModuleVersion: moduleVersion(),
+		Specs:         []Spec{SpecPCClient, SpecEFI_1_2, SpecEFI_2},
+		EventTypes:    append([]EventType(nil), knownEventTypes[:]...),
+		CCTypes:       []CCType{CCTypeSEV, CCTypeTDX},
+		InputFormats:  []string{"tcg", "ccel", "txt", "ima-binary", "ima-ascii"},
+		OutputFormats: []string{"json", "ndjson", "cel-tlv", "cel-json", "cel-cbor", "sbom-cyclonedx", "sbom-spdx"}}
+
+	candidates := AlgorithmIdList{AlgorithmSha1, AlgorithmSha256, AlgorithmSha384, AlgorithmSha512,
+		AlgorithmSm3_256, AlgorithmSha3_256, AlgorithmSha3_384, AlgorithmSha3_512}
+	registeredAlgorithmsMu.RLock()
+	for alg := range registeredAlgorithms {
+		if !candidates.Contains(alg) {
+			candidates = append(candidates, alg)
+		}
+	}
+	registeredAlgorithmsMu.RUnlock()
+
+	for _, alg := range candidates {
+		if alg.supported() {
+			c.Algorithms = append(c.Algorithms, alg)
+		}
+	}
+	sort.Slice(c.Algorithms, func(i, j int) bool { return c.Algorithms[i] < c.Algorithms[j] })
+
+	return c
+}
@@ -0,0 +1,58 @@
+package tcglog
+
+import (
+	"crypto/md5"
+	"encoding/json"
+	"testing"
+)
+
+func TestCapabilities(t *testing.T) {
+	const algorithmVendor AlgorithmId = 0x8002
+
+	c := Capabilities()
+	if c.Version != CapabilitiesVersion {
+		t.Errorf("Unexpected version %d", c.Version)
+	}
+	if !c.Algorithms.Contains(AlgorithmSha256) || !c.Algorithms.Contains(AlgorithmSm3_256) {
+		t.Errorf("Unexpected algorithms %v", c.Algorithms)
+	}
+	if c.Algorithms.Contains(algorithmVendor) {
+		t.Errorf("Unexpected algorithms %v", c.Algorithms)
+	}
+
+	found := false
+	for _, eventType := range c.EventTypes {
+		if eventType == EventTypeTXTHashStart {
+			found = true
+		}
+	}
+	if !found {
+		t.Errorf("Missing event type %s", EventTypeTXTHashStart)
+	}
+
+	if err := RegisterAlgorithm(algorithmVendor, "vendor2", md5.Size, md5.New); err != nil {
+		t.Fatalf("RegisterAlgorithm failed: %v", err)
+	}
+	defer func() {
+		registeredAlgorithmsMu.Lock()
+		defer registeredAlgorithmsMu.Unlock()
+		delete(registeredAlgorithms, algorithmVendor)
+	}()
+	if c := Capabilities(); !c.Algorithms.Contains(algorithmVendor) {
+		t.Errorf("Registered algorithm is missing from %v", c.Algorithms)
+	}
+
+	data, err := json.Marshal(c)
+	if err != nil {
+		t.Fatalf("Marshal failed: %v", err)
+	}
+	var decoded struct {
+		Specs []string `json:"specs"`
+	}
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatalf("Unmarshal failed: %v", err)
+	}
+	if len(decoded.Specs) != 3 || decoded.Specs[2] != "efi-2" {
+		t.Errorf("Unexpected specs %v", decoded.Specs)
+	}
+}
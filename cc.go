@@ -0,0 +1,114 @@
+package tcglog
+
+import (
+	"bytes"
+	"fmt"
+)
+
+// CCType corresponds to the type of a confidential computing environment. The values are in sync with those
+// used in the CC Type field of the CCEL ACPI table.
+type CCType uint8
+
+const (
+	CCTypeNone CCType = 0 // Not a confidential computing environment
+	CCTypeSEV  CCType = 1 // AMD SEV
+	CCTypeTDX  CCType = 2 // Intel TDX
+)
+
+func (t CCType) String() string {
+	switch t {
+	case CCTypeNone:
+		return "none"
+	case CCTypeSEV:
+		return "SEV"
+	case CCTypeTDX:
+		return "TDX"
+	default:
+		return fmt.Sprintf("CCType(%d)", uint8(t))
+	}
+}
+
+// MRIndex corresponds to the index of a measurement register in a confidential computing environment. In a
+// confidential computing event log, this is what is recorded in the PCRIndex field of each event, so
+// PCRIndex(mr) can be used to look up the events and replayed values associated with a measurement register.
+type MRIndex uint32
+
+const (
+	MRTD  MRIndex = 0 // Intel TDX build time measurement register
+	RTMR0 MRIndex = 1 // Intel TDX runtime measurement register 0
+	RTMR1 MRIndex = 2 // Intel TDX runtime measurement register 1
+	RTMR2 MRIndex = 3 // Intel TDX runtime measurement register 2
+	RTMR3 MRIndex = 4 // Intel TDX runtime measurement register 3
+)
+
+func (i MRIndex) String() string {
+	switch i {
+	case MRTD:
+		return "MRTD"
+	case RTMR0, RTMR1, RTMR2, RTMR3:
+		return fmt.Sprintf("RTMR%d", uint32(i-RTMR0))
+	default:
+		return fmt.Sprintf("MR%d", uint32(i))
+	}
+}
+
+// TDXMRIndexForPCR returns the TDX measurement register that is used in place of the specified PCR, and false
+// if there isn't one.
+// https://uefi.org/sites/default/files/resources/UEFI_Spec_2_10_Aug29.pdf
+//  (section 38.4.1 "EFI_CC_MEASUREMENT_PROTOCOL", "TDX" mapping table)
+func TDXMRIndexForPCR(pcr PCRIndex) (MRIndex, bool) {
+	switch {
+	case pcr == 0:
+		return MRTD, true
+	case pcr == 1 || pcr == 7:
+		return RTMR0, true
+	case pcr >= 2 && pcr <= 6:
+		return RTMR1, true
+	case pcr >= 8 && pcr <= 15:
+		return RTMR2, true
+	default:
+		return 0, false
+	}
+}
+
+// CCEvidence corresponds to measurement register values obtained from confidential computing evidence, such
+// as a TDX quote, which can be cross-referenced against the values replayed from a confidential computing
+// event log.
+type CCEvidence struct {
+	Type      CCType
+	Algorithm AlgorithmId        // The digest algorithm used by the measurement registers
+	Registers map[MRIndex]Digest // The measurement register values from the evidence
+}
+
+const (
+	// FindingCCEvidenceMismatch indicates that a measurement register value from confidential computing
+	// evidence is inconsistent with the value replayed from the log.
+	FindingCCEvidenceMismatch FindingCode = "cc-evidence-mismatch"
+)
+
+// CompareCCEvidence compares the measurement register values from evidence with those replayed from a
+// confidential computing event log, and returns a finding for each register that is inconsistent. Registers
+// that are not extended from the log, such as MRTD, are ignored.
+func CompareCCEvidence(expected map[PCRIndex]DigestMap, evidence *CCEvidence) (out []Finding) {
+	for _, mr := range []MRIndex{RTMR0, RTMR1, RTMR2, RTMR3} {
+		actual, ok := evidence.Registers[mr]
+		if !ok {
+			continue
+		}
+
+		e := expected[PCRIndex(mr)][evidence.Algorithm]
+		if e == nil {
+			e = make(Digest, len(actual))
+		}
+		if bytes.Equal(e, actual) {
+			continue
+		}
+
+		out = append(out, Finding{
+			Code:     FindingCCEvidenceMismatch,
+			Severity: FindingSeverityError,
+			Message: fmt.Sprintf("%s value from %s evidence (%x) is inconsistent with the value replayed "+
+				"from the log (%x)", mr, evidence.Type, actual, e)})
+	}
+	return
+}
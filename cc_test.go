@@ -0,0 +1,136 @@
+package tcglog
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestCompareCCEvidence(t *testing.T) {
+	rtmr0 := AlgorithmSha384.hash([]byte("rtmr0"))
+	rtmr2 := AlgorithmSha384.hash([]byte("rtmr2"))
+	expected := map[PCRIndex]DigestMap{
+		PCRIndex(RTMR0): {AlgorithmSha384: rtmr0},
+		PCRIndex(RTMR2): {AlgorithmSha384: rtmr2},
+	}
+	zero := make(Digest, AlgorithmSha384.size())
+	other := AlgorithmSha384.hash([]byte("other"))
+
+	for _, data := range []struct {
+		desc       string
+		algorithm  AlgorithmId
+		registers  map[MRIndex]Digest
+		mismatches []MRIndex
+	}{
+		{
+			desc:      "Consistent",
+			algorithm: AlgorithmSha384,
+			registers: map[MRIndex]Digest{RTMR0: rtmr0, RTMR2: rtmr2},
+		},
+		{
+			desc:      "ConsistentNoEvents",
+			algorithm: AlgorithmSha384,
+			registers: map[MRIndex]Digest{RTMR0: rtmr0, RTMR1: zero, RTMR2: rtmr2, RTMR3: zero},
+		},
+		{
+			desc:      "MRTDIgnored",
+			algorithm: AlgorithmSha384,
+			registers: map[MRIndex]Digest{MRTD: other, RTMR0: rtmr0},
+		},
+		{
+			desc:       "Mismatch",
+			algorithm:  AlgorithmSha384,
+			registers:  map[MRIndex]Digest{RTMR0: rtmr0, RTMR2: other},
+			mismatches: []MRIndex{RTMR2},
+		},
+		{
+			desc:       "MismatchNoEvents",
+			algorithm:  AlgorithmSha384,
+			registers:  map[MRIndex]Digest{RTMR1: other, RTMR3: other},
+			mismatches: []MRIndex{RTMR1, RTMR3},
+		},
+		{
+			desc:       "WrongAlgorithm",
+			algorithm:  AlgorithmSha256,
+			registers:  map[MRIndex]Digest{RTMR0: rtmr0},
+			mismatches: []MRIndex{RTMR0},
+		},
+	} {
+		t.Run(data.desc, func(t *testing.T) {
+			evidence := &CCEvidence{Type: CCTypeTDX, Algorithm: data.algorithm, Registers: data.registers}
+			findings := CompareCCEvidence(expected, evidence)
+			if len(findings) != len(data.mismatches) {
+				t.Fatalf("Unexpected findings: %v", findings)
+			}
+			for i, f := range findings {
+				if f.Code != FindingCCEvidenceMismatch || f.Severity != FindingSeverityError {
+					t.Errorf("Unexpected finding %s", &f)
+				}
+				mr := data.mismatches[i]
+				e := expected[PCRIndex(mr)][data.algorithm]
+				if e == nil {
+					e = make(Digest, len(data.registers[mr]))
+				}
+				msg := fmt.Sprintf("%s value from TDX evidence (%x) is inconsistent with the value replayed from "+
+					"the log (%x)", mr, data.registers[mr], e)
+				if f.Message != msg {
+					t.Errorf("Unexpected message %q", f.Message)
+				}
+			}
+		})
+	}
+}
+
+func TestTDXMRIndexForPCR(t *testing.T) {
+	for _, data := range []struct {
+		pcr PCRIndex
+		mr  MRIndex
+		ok  bool
+	}{
+		{0, MRTD, true},
+		{1, RTMR0, true},
+		{7, RTMR0, true},
+		{2, RTMR1, true},
+		{6, RTMR1, true},
+		{8, RTMR2, true},
+		{15, RTMR2, true},
+		{16, 0, false},
+		{23, 0, false},
+	} {
+		mr, ok := TDXMRIndexForPCR(data.pcr)
+		if mr != data.mr || ok != data.ok {
+			t.Errorf("Unexpected result for PCR %d: %s, %v", data.pcr, mr, ok)
+		}
+	}
+}
+
+func TestMRIndexString(t *testing.T) {
+	for _, data := range []struct {
+		mr       MRIndex
+		expected string
+	}{
+		{MRTD, "MRTD"},
+		{RTMR0, "RTMR0"},
+		{RTMR3, "RTMR3"},
+		{MRIndex(5), "MR5"},
+	} {
+		if s := data.mr.String(); s != data.expected {
+			t.Errorf("Unexpected string %q", s)
+		}
+	}
+}
+
+func TestCCTypeString(t *testing.T) {
+	for _, data := range []struct {
+		ccType   CCType
+		expected string
+	}{
+		{CCTypeNone, "none"},
+		{CCTypeSEV, "SEV"},
+		{CCTypeTDX, "TDX"},
+		{CCType(3), "CCType(3)"},
+	} {
+		if s := data.ccType.String(); s != data.expected {
+			t.Errorf("Unexpected string %q", s)
+		}
+	}
+}
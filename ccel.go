@@ -0,0 +1,112 @@
+package tcglog
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io/ioutil"
+)
+
+const (
+	// CCELTablePath is the path of the CCEL ACPI table on Linux.
+	CCELTablePath = "/sys/firmware/acpi/tables/CCEL"
+
+	// CCELDataPath is the path of the confidential computing event log area described by the CCEL ACPI table
+	// on Linux.
+	CCELDataPath = "/sys/firmware/acpi/tables/data/CCEL"
+)
+
+// CCELTable corresponds to the CCEL ACPI table, which describes the location of a confidential computing event
+// log.
+type CCELTable struct {
+	Revision             uint8
+	OEMID                string
+	OEMTableID           string
+	CCType               CCType // The type of confidential computing environment
+	CCSubType            uint8
+	LogAreaMinimumLength uint64 // The size of the event log area
+	LogAreaStartAddress  uint64 // The physical address of the event log area
+}
+
+// DecodeCCELTable decodes the supplied CCEL ACPI table.
+// https://uefi.org/specs/ACPI/6.5/05_ACPI_Software_Programming_Model.html
+//  (section 5.2.35 "Confidential Computing Event Log Table (CCEL)")
+func DecodeCCELTable(data []byte) (*CCELTable, error) {
+	const tableSize = 56
+
+	if len(data) < tableSize {
+		return nil, fmt.Errorf("CCEL table is too small (got %d bytes, expected >= %d bytes)", len(data), tableSize)
+	}
+
+	// EFI_ACPI_DESCRIPTION_HEADER.Signature
+	if string(data[0:4]) != "CCEL" {
+		return nil, fmt.Errorf("invalid CCEL table signature (%q)", data[0:4])
+	}
+	// EFI_ACPI_DESCRIPTION_HEADER.Length
+	if length := binary.LittleEndian.Uint32(data[4:]); int64(length) != int64(len(data)) {
+		return nil, fmt.Errorf("CCEL table length field is inconsistent with the table size (got %d, "+
+			"expected %d)", length, len(data))
+	}
+
+	var sum uint8
+	for _, b := range data {
+		sum += b
+	}
+	if sum != 0 {
+		return nil, errors.New("CCEL table has an invalid checksum")
+	}
+
+	return &CCELTable{
+		Revision:             data[8],
+		OEMID:                string(data[10:16]),
+		OEMTableID:           string(data[16:24]),
+		CCType:               CCType(data[36]),
+		CCSubType:            data[37],
+		LogAreaMinimumLength: binary.LittleEndian.Uint64(data[40:]),
+		LogAreaStartAddress:  binary.LittleEndian.Uint64(data[48:])}, nil
+}
+
+// ReadCCELTable reads and decodes the CCEL ACPI table exposed by the kernel.
+func ReadCCELTable() (*CCELTable, error) {
+	data, err := ioutil.ReadFile(CCELTablePath)
+	if err != nil {
+		return nil, err
+	}
+	return DecodeCCELTable(data)
+}
+
+// NewCCELLog creates a new Log instance that reads a confidential computing event log, such as the one
+// produced by TDX guest firmware, from the supplied data. Confidential computing event logs use the
+// crypto-agile log format, but the PCRIndex field of each event contains a measurement register index (see
+// MRIndex), and the log is padded with 0xff bytes to the size of the log area.
+func NewCCELLog(data []byte, ccType CCType, options LogOptions) (*Log, error) {
+	if ccType == CCTypeNone {
+		return nil, errors.New("invalid confidential computing environment type")
+	}
+	return newLog(bytes.NewReader(data), options, ccType)
+}
+
+// ReplayAndValidateCCEL reads the confidential computing event log described by the CCEL ACPI table exposed by
+// the kernel, replays it in order to compute the expected measurement register values and validates the
+// digests of each event against the data recorded with it where possible. The expected values in the result
+// are indexed by PCRIndex(mr), where mr is a MRIndex.
+func ReplayAndValidateCCEL(options LogOptions, validateOptions LogValidateOptions) (*LogValidateResult, error) {
+	table, err := ReadCCELTable()
+	if err != nil {
+		return nil, fmt.Errorf("cannot read CCEL table: %w", err)
+	}
+
+	data, err := ioutil.ReadFile(CCELDataPath)
+	if err != nil {
+		return nil, err
+	}
+
+	log, err := NewCCELLog(data, table.CCType, options)
+	if err != nil {
+		return nil, err
+	}
+
+	return replayAndValidateLog(context.Background(), log, int64(len(data)), validateOptions)
+}
@@ -0,0 +1,78 @@
+package tcglog
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"testing"
+)
+
+func makeTestCCELTable(ccType CCType, laml, lasa uint64) []byte {
+	data := make([]byte, 56)
+	copy(data[0:], "CCEL")
+	binary.LittleEndian.PutUint32(data[4:], uint32(len(data)))
+	data[8] = 1
+	copy(data[10:], "INTEL ")
+	data[36] = uint8(ccType)
+	binary.LittleEndian.PutUint64(data[40:], laml)
+	binary.LittleEndian.PutUint64(data[48:], lasa)
+
+	var sum uint8
+	for _, b := range data {
+		sum += b
+	}
+	data[9] = -sum
+	return data
+}
+
+func TestDecodeCCELTable(t *testing.T) {
+	table, err := DecodeCCELTable(makeTestCCELTable(CCTypeTDX, 0x10000, 0x7f000000))
+	if err != nil {
+		t.Fatalf("DecodeCCELTable failed: %v", err)
+	}
+	if table.CCType != CCTypeTDX {
+		t.Errorf("Unexpected CC type: %s", table.CCType)
+	}
+	if table.LogAreaMinimumLength != 0x10000 {
+		t.Errorf("Unexpected log area minimum length: %d", table.LogAreaMinimumLength)
+	}
+	if table.LogAreaStartAddress != 0x7f000000 {
+		t.Errorf("Unexpected log area start address: 0x%x", table.LogAreaStartAddress)
+	}
+	if table.OEMID != "INTEL " {
+		t.Errorf("Unexpected OEM ID: %q", table.OEMID)
+	}
+}
+
+func TestDecodeCCELTableInvalidChecksum(t *testing.T) {
+	data := makeTestCCELTable(CCTypeTDX, 0x10000, 0x7f000000)
+	data[9]++
+	if _, err := DecodeCCELTable(data); err == nil {
+		t.Errorf("DecodeCCELTable should have failed")
+	}
+}
+
+func TestNewCCELLogStopsAtPadding(t *testing.T) {
+	data := makeTestCryptoAgileLog(t, 3)
+	data = append(data, bytes.Repeat([]byte{0xff}, 4096)...)
+
+	log, err := NewCCELLog(data, CCTypeTDX, LogOptions{})
+	if err != nil {
+		t.Fatalf("NewCCELLog failed: %v", err)
+	}
+
+	n := 0
+	for {
+		_, err := log.NextEvent()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("NextEvent failed: %v", err)
+		}
+		n++
+	}
+	if n != 4 {
+		t.Errorf("Unexpected number of events (got %d)", n)
+	}
+}
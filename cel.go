@@ -0,0 +1,290 @@
+package tcglog
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+)
+
+// CELFormat corresponds to an encoding of the TCG Canonical Event Log.
+type CELFormat int
+
+const (
+	CELFormatTLV  CELFormat = iota // CEL-TLV
+	CELFormatJSON                  // CEL-JSON
+	CELFormatCBOR                  // CEL-CBOR
+)
+
+// ParseCELFormat parses a CELFormat from its name ("tlv", "json" or "cbor").
+func ParseCELFormat(format string) (CELFormat, error) {
+	switch format {
+	case "tlv":
+		return CELFormatTLV, nil
+	case "json":
+		return CELFormatJSON, nil
+	case "cbor":
+		return CELFormatCBOR, nil
+	default:
+		return 0, fmt.Errorf("unrecognized CEL format \"%s\"", format)
+	}
+}
+
+// https://trustedcomputinggroup.org/wp-content/uploads/TCG_IWG_CEL_v1_r0p41_pub.pdf
+//  (section 4 "CEL Data Model", section 5 "CEL Encodings")
+const (
+	celTypeRecnum      uint8 = 0
+	celTypePCR         uint8 = 1
+	celTypeNVIndex     uint8 = 2
+	celTypeDigests     uint8 = 3
+	celTypeMgt         uint8 = 4
+	celTypePCClientStd uint8 = 5
+
+	celPCClientStdEventType uint8 = 0
+	celPCClientStdEventData uint8 = 1
+)
+
+// CELEncoder converts events to records in the TCG Canonical Event Log format. Each event is encoded as a
+// record with pcclient_std content. Records are numbered sequentially starting from zero, in the order that
+// events are supplied to Encode.
+type CELEncoder struct {
+	w      io.Writer
+	format CELFormat
+	recnum uint64
+	closed bool
+}
+
+// NewCELEncoder returns a new CELEncoder that writes records to w in the specified format. Close must be called
+// after the last event has been encoded.
+func NewCELEncoder(w io.Writer, format CELFormat) (*CELEncoder, error) {
+	switch format {
+	case CELFormatTLV, CELFormatJSON, CELFormatCBOR:
+	default:
+		return nil, errors.New("invalid CEL format")
+	}
+	return &CELEncoder{w: w, format: format}, nil
+}
+
+func sortedDigestAlgorithms(digests DigestMap) (out AlgorithmIdList) {
+	for alg := range digests {
+		out = append(out, alg)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i] < out[j] })
+	return
+}
+
+func writeCELTLV(w *bytes.Buffer, t uint8, value []byte) {
+	w.WriteByte(t)
+	binary.Write(w, binary.BigEndian, uint32(len(value)))
+	w.Write(value)
+}
+
+func (e *CELEncoder) encodeTLV(event *Event) ([]byte, error) {
+	var buf bytes.Buffer
+
+	var recnum [8]byte
+	binary.BigEndian.PutUint64(recnum[:], e.recnum)
+	writeCELTLV(&buf, celTypeRecnum, recnum[:])
+
+	var pcr [4]byte
+	binary.BigEndian.PutUint32(pcr[:], uint32(event.PCRIndex))
+	writeCELTLV(&buf, celTypePCR, pcr[:])
+
+	var digests bytes.Buffer
+	for _, alg := range sortedDigestAlgorithms(event.Digests) {
+		// The type of each digest is the TPM_ALG_ID, encoded in the single byte type field.
+		if alg > math.MaxUint8 {
+			return nil, fmt.Errorf("cannot encode digest for algorithm %s in a CEL-TLV record", alg)
+		}
+		writeCELTLV(&digests, uint8(alg), event.Digests[alg])
+	}
+	writeCELTLV(&buf, celTypeDigests, digests.Bytes())
+
+	var content bytes.Buffer
+	var eventType [4]byte
+	binary.BigEndian.PutUint32(eventType[:], uint32(event.EventType))
+	writeCELTLV(&content, celPCClientStdEventType, eventType[:])
+	writeCELTLV(&content, celPCClientStdEventData, event.Data.Bytes())
+	writeCELTLV(&buf, celTypePCClientStd, content.Bytes())
+
+	return buf.Bytes(), nil
+}
+
+func (e *CELEncoder) encodeJSON(event *Event) ([]byte, error) {
+	type digest struct {
+		HashAlg AlgorithmId `json:"hashAlg"`
+		Digest  Digest      `json:"digest"`
+	}
+	var digests []digest
+	for _, alg := range sortedDigestAlgorithms(event.Digests) {
+		digests = append(digests, digest{alg, event.Digests[alg]})
+	}
+
+	type content struct {
+		EventType uint32 `json:"event_type"`
+		EventData string `json:"event_data"`
+	}
+
+	return json.Marshal(struct {
+		Recnum      uint64   `json:"recnum"`
+		PCR         PCRIndex `json:"pcr"`
+		Digests     []digest `json:"digests"`
+		ContentType string   `json:"content_type"`
+		Content     content  `json:"content"`
+	}{e.recnum, event.PCRIndex, digests, "pcclient_std",
+		content{uint32(event.EventType), base64.StdEncoding.EncodeToString(event.Data.Bytes())}})
+}
+
+const (
+	cborMajorUint  = 0
+	cborMajorBytes = 2
+	cborMajorMap   = 5
+	cborIndefArray = 0x9f
+	cborBreak      = 0xff
+)
+
+func writeCBORHead(w *bytes.Buffer, major uint8, n uint64) {
+	switch {
+	case n < 24:
+		w.WriteByte(major<<5 | uint8(n))
+	case n <= 0xff:
+		w.WriteByte(major<<5 | 24)
+		w.WriteByte(uint8(n))
+	case n <= 0xffff:
+		w.WriteByte(major<<5 | 25)
+		binary.Write(w, binary.BigEndian, uint16(n))
+	case n <= 0xffffffff:
+		w.WriteByte(major<<5 | 26)
+		binary.Write(w, binary.BigEndian, uint32(n))
+	default:
+		w.WriteByte(major<<5 | 27)
+		binary.Write(w, binary.BigEndian, n)
+	}
+}
+
+func writeCBORBytes(w *bytes.Buffer, data []byte) {
+	writeCBORHead(w, cborMajorBytes, uint64(len(data)))
+	w.Write(data)
+}
+
+func (e *CELEncoder) encodeCBOR(event *Event) []byte {
+	var buf bytes.Buffer
+
+	writeCBORHead(&buf, cborMajorMap, 4)
+
+	writeCBORHead(&buf, cborMajorUint, uint64(celTypeRecnum))
+	writeCBORHead(&buf, cborMajorUint, e.recnum)
+
+	writeCBORHead(&buf, cborMajorUint, uint64(celTypePCR))
+	writeCBORHead(&buf, cborMajorUint, uint64(event.PCRIndex))
+
+	writeCBORHead(&buf, cborMajorUint, uint64(celTypeDigests))
+	algs := sortedDigestAlgorithms(event.Digests)
+	writeCBORHead(&buf, cborMajorMap, uint64(len(algs)))
+	for _, alg := range algs {
+		writeCBORHead(&buf, cborMajorUint, uint64(alg))
+		writeCBORBytes(&buf, event.Digests[alg])
+	}
+
+	writeCBORHead(&buf, cborMajorUint, uint64(celTypePCClientStd))
+	writeCBORHead(&buf, cborMajorMap, 2)
+	writeCBORHead(&buf, cborMajorUint, uint64(celPCClientStdEventType))
+	writeCBORHead(&buf, cborMajorUint, uint64(event.EventType))
+	writeCBORHead(&buf, cborMajorUint, uint64(celPCClientStdEventData))
+	writeCBORBytes(&buf, event.Data.Bytes())
+
+	return buf.Bytes()
+}
+
+// Encode writes a record for the supplied event.
+func (e *CELEncoder) Encode(event *Event) error {
+	if e.closed {
+		return errors.New("encoder is closed")
+	}
+
+	var data []byte
+	switch e.format {
+	case CELFormatTLV:
+		var err error
+		data, err = e.encodeTLV(event)
+		if err != nil {
+			return err
+		}
+	case CELFormatJSON:
+		record, err := e.encodeJSON(event)
+		if err != nil {
+			return err
+		}
+		if e.recnum == 0 {
+			data = append([]byte("["), record...)
+		} else {
+			data = append([]byte(","), record...)
+		}
+	case CELFormatCBOR:
+		data = e.encodeCBOR(event)
+		if e.recnum == 0 {
+			data = append([]byte{cborIndefArray}, data...)
+		}
+	}
+
+	if _, err := e.w.Write(data); err != nil {
+		return err
+	}
+	e.recnum++
+	return nil
+}
+
+// Close completes the encoding. CEL-JSON and CEL-CBOR logs are arrays of records, and this writes the end of
+// the array.
+func (e *CELEncoder) Close() error {
+	if e.closed {
+		return nil
+	}
+	e.closed = true
+
+	var data []byte
+	switch e.format {
+	case CELFormatJSON:
+		if e.recnum == 0 {
+			data = []byte("[")
+		}
+		data = append(data, ']')
+	case CELFormatCBOR:
+		if e.recnum == 0 {
+			data = []byte{cborIndefArray}
+		}
+		data = append(data, cborBreak)
+	}
+
+	_, err := e.w.Write(data)
+	return err
+}
+
+// WriteCEL reads the remaining events from log and writes them to w as a Canonical Event Log in the specified
+// format.
+func WriteCEL(w io.Writer, log *Log, format CELFormat) error {
+	enc, err := NewCELEncoder(w, format)
+	if err != nil {
+		return err
+	}
+
+	for {
+		event, err := log.NextEvent()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		if err := enc.Encode(event); err != nil {
+			return err
+		}
+	}
+
+	return enc.Close()
+}
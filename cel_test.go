@@ -0,0 +1,123 @@
+package tcglog
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"testing"
+)
+
+func TestCELEncoder(t *testing.T) {
+	event := &Event{
+		PCRIndex:  7,
+		EventType: EventTypeSeparator,
+		Digests:   DigestMap{AlgorithmSha1: make(Digest, 20)},
+		Data:      &opaqueEventData{data: []byte{0, 0, 0, 0}}}
+
+	for _, data := range []struct {
+		desc     string
+		format   CELFormat
+		expected string
+	}{
+		{
+			desc:   "TLV",
+			format: CELFormatTLV,
+			expected: "00000000080000000000000000" +
+				"010000000400000007" +
+				"0300000019" + "0400000014" + "0000000000000000000000000000000000000000" +
+				"0500000012" + "000000000400000004" + "010000000400000000",
+		},
+		{
+			desc:   "CBOR",
+			format: CELFormatCBOR,
+			expected: "9f" + "a4" + "0000" + "0107" +
+				"03a1" + "0454" + "0000000000000000000000000000000000000000" +
+				"05a2" + "0004" + "014400000000" + "ff",
+		},
+	} {
+		t.Run(data.desc, func(t *testing.T) {
+			var buf bytes.Buffer
+			enc, err := NewCELEncoder(&buf, data.format)
+			if err != nil {
+				t.Fatalf("NewCELEncoder failed: %v", err)
+			}
+			if err := enc.Encode(event); err != nil {
+				t.Fatalf("Encode failed: %v", err)
+			}
+			if err := enc.Close(); err != nil {
+				t.Fatalf("Close failed: %v", err)
+			}
+			if hex.EncodeToString(buf.Bytes()) != data.expected {
+				t.Errorf("Unexpected encoding: %x", buf.Bytes())
+			}
+		})
+	}
+
+	t.Run("JSON", func(t *testing.T) {
+		var buf bytes.Buffer
+		enc, err := NewCELEncoder(&buf, CELFormatJSON)
+		if err != nil {
+			t.Fatalf("NewCELEncoder failed: %v", err)
+		}
+		for i := 0; i < 2; i++ {
+			if err := enc.Encode(event); err != nil {
+				t.Fatalf("Encode failed: %v", err)
+			}
+		}
+		if err := enc.Close(); err != nil {
+			t.Fatalf("Close failed: %v", err)
+		}
+
+		var records []struct {
+			Recnum      uint64 `json:"recnum"`
+			PCR         uint32 `json:"pcr"`
+			ContentType string `json:"content_type"`
+			Content     struct {
+				EventType uint32 `json:"event_type"`
+				EventData string `json:"event_data"`
+			} `json:"content"`
+		}
+		if err := json.Unmarshal(buf.Bytes(), &records); err != nil {
+			t.Fatalf("Unmarshal failed: %v", err)
+		}
+		if len(records) != 2 {
+			t.Fatalf("Unexpected number of records (%d)", len(records))
+		}
+		for i, r := range records {
+			if r.Recnum != uint64(i) || r.PCR != 7 || r.ContentType != "pcclient_std" ||
+				r.Content.EventType != uint32(EventTypeSeparator) || r.Content.EventData != "AAAAAA==" {
+				t.Errorf("Unexpected record %d: %+v", i, r)
+			}
+		}
+	})
+}
+
+func TestCELEncoderTLVDigests(t *testing.T) {
+	event := &Event{
+		PCRIndex:  0,
+		EventType: EventTypeNoAction,
+		Digests: DigestMap{
+			AlgorithmSha256: bytes.Repeat([]byte{0xbb}, 32),
+			AlgorithmSha1:   bytes.Repeat([]byte{0xaa}, 20)},
+		Data: &opaqueEventData{data: nil}}
+
+	var buf bytes.Buffer
+	enc, err := NewCELEncoder(&buf, CELFormatTLV)
+	if err != nil {
+		t.Fatalf("NewCELEncoder failed: %v", err)
+	}
+	if err := enc.Encode(event); err != nil {
+		t.Fatalf("Encode failed: %v", err)
+	}
+
+	// Each digest is a nested TLV with the TPM_ALG_ID in its single byte type field.
+	expected := "00000000080000000000000000" +
+		"010000000400000000" +
+		"030000003e" +
+		"0400000014" + "aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa" +
+		"0b00000020" + "bbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbb" +
+		"050000000e" + "000000000400000003" + "0100000000"
+	if hex.EncodeToString(buf.Bytes()) != expected {
+		t.Errorf("Unexpected encoding: %x", buf.Bytes())
+	}
+}
@@ -0,0 +1,149 @@
+package tcglog
+
+import (
+	"errors"
+	"io"
+)
+
+var errChunkShort = errors.New("more data is required")
+
+// chunkReader is an io.Reader over the data that has been supplied to a ChunkParser. Reads that can't be
+// satisfied because more data hasn't arrived yet fail with errChunkShort rather than io.EOF, so that the parser
+// can rewind to the start of the current event and try again once there is more data.
+type chunkReader struct {
+	data   []byte
+	off    int
+	short  bool
+	closed bool
+}
+
+func (r *chunkReader) Read(p []byte) (int, error) {
+	if len(p) == 0 {
+		return 0, nil
+	}
+	if r.off == len(r.data) {
+		if r.closed {
+			return 0, io.EOF
+		}
+		r.short = true
+		return 0, errChunkShort
+	}
+	n := copy(p, r.data[r.off:])
+	r.off += n
+	return n, nil
+}
+
+func (r *chunkReader) append(chunk []byte) {
+	// Discard data that belongs to events that have already been returned.
+	r.data = append(r.data[:0], r.data[r.off:]...)
+	r.off = 0
+	r.data = append(r.data, chunk...)
+}
+
+// ChunkParser parses an event log that is supplied incrementally in chunks of arbitrary size, such as the
+// payloads of network packets as they arrive at an attestation server. Events are returned as soon as all of
+// their bytes have been received. Only the bytes of the current incomplete event are buffered.
+type ChunkParser struct {
+	options LogOptions
+	r       chunkReader
+	log     *Log
+}
+
+// NewChunkParser returns a new ChunkParser.
+func NewChunkParser(options LogOptions) *ChunkParser {
+	return &ChunkParser{options: options}
+}
+
+// Spec returns the specification to which the log conforms. This is SpecUnknown until the first event has been
+// received.
+func (p *ChunkParser) Spec() Spec {
+	if p.log == nil {
+		return SpecUnknown
+	}
+	return p.log.Spec
+}
+
+// Algorithms returns the digest algorithms that appear in the log. This is nil until the first event has been
+// received.
+func (p *ChunkParser) Algorithms() AlgorithmIdList {
+	if p.log == nil {
+		return nil
+	}
+	return p.log.Algorithms
+}
+
+func (p *ChunkParser) parse() (events []*Event, err error) {
+	if p.log == nil {
+		start := p.r.off
+		log, err := newLogFromReader(&p.r, p.options, CCTypeNone)
+		if p.r.short {
+			p.r.off = start
+			p.r.short = false
+			return nil, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		p.log = log
+	}
+
+	for {
+		start := p.r.off
+		event, _, err := p.log.nextEventInternal()
+		if p.r.short {
+			p.r.off = start
+			p.r.short = false
+			p.log.failed = false
+			return events, nil
+		}
+		switch {
+		case err == io.EOF:
+			return events, nil
+		case err != nil:
+			return events, err
+		}
+		events = append(events, event)
+	}
+}
+
+// Write supplies the next chunk of the log to the parser, and returns the events that were completed by it. No
+// events are returned if the chunk doesn't complete an event. Once an error has been returned, the parser can't
+// be used any more.
+func (p *ChunkParser) Write(chunk []byte) ([]*Event, error) {
+	if p.r.closed {
+		return nil, errors.New("parser is closed")
+	}
+	p.r.append(chunk)
+	return p.parse()
+}
+
+// Close indicates that the whole log has been supplied to the parser. An error is returned if the log is empty
+// or ends with an incomplete event.
+func (p *ChunkParser) Close() error {
+	if p.r.closed {
+		return nil
+	}
+	p.r.closed = true
+	_, err := p.parse()
+	return err
+}
+
+// ParseChunks parses an event log that is supplied in chunks on the specified channel, and calls fn for each
+// event as soon as it has been received. The log is complete when the channel is closed. Because chunks are only
+// received from the channel once fn has returned for the events in the previous chunk, a slow consumer applies
+// back-pressure to the producer. If fn returns an error, parsing stops and the error is returned.
+func ParseChunks(chunks <-chan []byte, options LogOptions, fn func(event *Event) error) error {
+	p := NewChunkParser(options)
+	for chunk := range chunks {
+		events, err := p.Write(chunk)
+		if err != nil {
+			return err
+		}
+		for _, event := range events {
+			if err := fn(event); err != nil {
+				return err
+			}
+		}
+	}
+	return p.Close()
+}
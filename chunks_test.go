@@ -0,0 +1,89 @@
+package tcglog
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestChunkParser(t *testing.T) {
+	data := makeTestCryptoAgileLog(t, 10)
+	expected := readTestEvents(t, data)
+
+	for _, size := range []int{1, 7, 64, len(data)} {
+		p := NewChunkParser(LogOptions{})
+
+		var events []*Event
+		for i := 0; i < len(data); i += size {
+			end := i + size
+			if end > len(data) {
+				end = len(data)
+			}
+			e, err := p.Write(data[i:end])
+			if err != nil {
+				t.Fatalf("Write failed (chunk size %d): %v", size, err)
+			}
+			events = append(events, e...)
+		}
+		if err := p.Close(); err != nil {
+			t.Errorf("Close failed (chunk size %d): %v", size, err)
+		}
+
+		if p.Spec() != SpecEFI_2 {
+			t.Errorf("Unexpected spec (chunk size %d): %v", size, p.Spec())
+		}
+		if len(events) != len(expected) {
+			t.Fatalf("Unexpected number of events (chunk size %d): %d", size, len(events))
+		}
+		for i := range events {
+			a, _ := json.Marshal(events[i])
+			b, _ := json.Marshal(expected[i])
+			if string(a) != string(b) {
+				t.Errorf("Unexpected event %d (chunk size %d): %s", i, size, a)
+			}
+		}
+	}
+}
+
+func TestChunkParserTruncated(t *testing.T) {
+	data := makeTestCryptoAgileLog(t, 2)
+
+	p := NewChunkParser(LogOptions{})
+	if _, err := p.Write(data[:len(data)-1]); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+	if err := p.Close(); err == nil {
+		t.Errorf("Close should have failed for a truncated log")
+	}
+
+	p = NewChunkParser(LogOptions{})
+	if err := p.Close(); err == nil {
+		t.Errorf("Close should have failed for an empty log")
+	}
+}
+
+func TestParseChunks(t *testing.T) {
+	data := makeTestCryptoAgileLog(t, 5)
+
+	chunks := make(chan []byte)
+	go func() {
+		for i := 0; i < len(data); i += 10 {
+			end := i + 10
+			if end > len(data) {
+				end = len(data)
+			}
+			chunks <- data[i:end]
+		}
+		close(chunks)
+	}()
+
+	n := 0
+	if err := ParseChunks(chunks, LogOptions{}, func(event *Event) error {
+		n++
+		return nil
+	}); err != nil {
+		t.Fatalf("ParseChunks failed: %v", err)
+	}
+	if n != 6 {
+		t.Errorf("Unexpected number of events (%d)", n)
+	}
+}
@@ -0,0 +1,106 @@
+package tcglog
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+)
+
+// CombinedEvidence is the result of parsing an evidence blob that consists of a TCG event log followed by a
+// Linux IMA measurement list in the binary format, as produced by some attestation agents.
+type CombinedEvidence struct {
+	Spec       Spec            // The specification to which the TCG event log conforms
+	Algorithms AlgorithmIdList // The digest algorithms that appear in the TCG event log
+	TCGEvents  []*Event        // The events from the TCG event log
+	IMAEvents  []*Event        // The events from the IMA measurement list, if there is one
+	IMAOffset  int             // The offset of the IMA measurement list in the blob, or the size of the blob
+
+	// PCRValues contains the expected PCR values after replaying the events from both segments.
+	PCRValues map[PCRIndex]DigestMap
+}
+
+// isPrintableIMATemplateName indicates whether name could be the name of an IMA template, which is either the
+// name of a built-in template or a template format string such as "d-ng|n-ng".
+func isPrintableIMATemplateName(name []byte) bool {
+	if len(name) == 0 || len(name) > imaEventNameLenMax {
+		return false
+	}
+	for _, c := range name {
+		if c < 0x21 || c > 0x7e {
+			return false
+		}
+	}
+	return true
+}
+
+// looksLikeIMAEntry indicates whether data begins with something that looks like the header of an entry in a
+// binary IMA measurement list with template hashes of the specified algorithm. This is a quick check that avoids
+// attempting to parse the remainder of the blob as an IMA measurement list after every TCG event.
+func looksLikeIMAEntry(data []byte, alg AlgorithmId) bool {
+	nameOffset := 4 + alg.size()
+	if len(data) < nameOffset+4 {
+		return false
+	}
+	if !isPCRIndexInRange(PCRIndex(binary.LittleEndian.Uint32(data))) {
+		return false
+	}
+	n := binary.LittleEndian.Uint32(data[nameOffset:])
+	if n > imaEventNameLenMax || len(data) < nameOffset+4+int(n) {
+		return false
+	}
+	return isPrintableIMATemplateName(data[nameOffset+4 : nameOffset+4+int(n)])
+}
+
+// ParseCombinedEvidence parses an evidence blob that consists of a TCG event log optionally followed by a Linux
+// IMA measurement list in the binary format, with template hashes computed using imaAlg. The boundary between
+// the segments is detected by checking whether the remainder of the blob is a valid IMA measurement list after
+// each event in the TCG event log. The events from both segments are replayed to compute the expected PCR
+// values.
+func ParseCombinedEvidence(data []byte, options LogOptions, imaAlg AlgorithmId) (*CombinedEvidence, error) {
+	if !imaAlg.supported() {
+		return nil, fmt.Errorf("unsupported digest algorithm %s", imaAlg)
+	}
+
+	r := bytes.NewReader(data)
+	log, err := NewLogFromReader(r, options)
+	if err != nil {
+		return nil, fmt.Errorf("cannot parse TCG event log: %w", err)
+	}
+
+	out := &CombinedEvidence{Spec: log.Spec, Algorithms: log.Algorithms, IMAOffset: len(data)}
+
+	// The Spec ID event has already been read from r, and is returned from an internal buffer.
+	first := true
+	for {
+		offset := len(data) - r.Len()
+		if !first && looksLikeIMAEntry(data[offset:], imaAlg) {
+			events, err := ParseIMAMeasurementList(bytes.NewReader(data[offset:]), imaAlg)
+			if err == nil {
+				out.IMAEvents = events
+				out.IMAOffset = offset
+				break
+			}
+		}
+
+		event, err := log.NextEvent()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("cannot parse TCG event log at offset %d: %w", offset, err)
+		}
+		out.TCGEvents = append(out.TCGEvents, event)
+		first = false
+	}
+
+	out.PCRValues = make(map[PCRIndex]DigestMap)
+	if err := extendPCRValues(out.PCRValues, out.TCGEvents); err != nil {
+		return nil, err
+	}
+	if err := ReplayIMAEvents(out.PCRValues, out.IMAEvents); err != nil {
+		return nil, err
+	}
+
+	return out, nil
+}
@@ -0,0 +1,67 @@
+package tcglog
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestParseCombinedEvidence(t *testing.T) {
+	tcg := makeTestCryptoAgileLog(t, 10)
+
+	var ng bytes.Buffer
+	encodeIMAField(&ng, append([]byte("sha256:\x00"), AlgorithmSha256.hash([]byte("foo"))...))
+	encodeIMAField(&ng, []byte("/usr/bin/foo\x00"))
+	var ima bytes.Buffer
+	for i := 0; i < 3; i++ {
+		_, entry := makeTestIMAEntry("ima-ng", ng.Bytes())
+		ima.Write(entry)
+	}
+
+	evidence, err := ParseCombinedEvidence(append(append([]byte(nil), tcg...), ima.Bytes()...), LogOptions{},
+		AlgorithmSha1)
+	if err != nil {
+		t.Fatalf("ParseCombinedEvidence failed: %v", err)
+	}
+	if evidence.Spec != SpecEFI_2 {
+		t.Errorf("Unexpected spec: %v", evidence.Spec)
+	}
+	if len(evidence.TCGEvents) != 11 || len(evidence.IMAEvents) != 3 {
+		t.Fatalf("Unexpected number of events (TCG: %d, IMA: %d)", len(evidence.TCGEvents),
+			len(evidence.IMAEvents))
+	}
+	if evidence.IMAOffset != len(tcg) {
+		t.Errorf("Unexpected IMA offset %d", evidence.IMAOffset)
+	}
+
+	log, err := NewLog(bytes.NewReader(tcg), LogOptions{})
+	if err != nil {
+		t.Fatalf("NewLog failed: %v", err)
+	}
+	expected, err := log.ReplayPCRs()
+	if err != nil {
+		t.Fatalf("ReplayPCRs failed: %v", err)
+	}
+	if err := ReplayIMAEvents(expected, evidence.IMAEvents); err != nil {
+		t.Fatalf("ReplayIMAEvents failed: %v", err)
+	}
+	for pcr := PCRIndex(0); pcr <= IMAPCR; pcr++ {
+		for _, alg := range []AlgorithmId{AlgorithmSha1, AlgorithmSha256} {
+			if !bytes.Equal(evidence.PCRValues[pcr][alg], expected[pcr][alg]) {
+				t.Errorf("Unexpected value for PCR %d, bank %s", pcr, alg)
+			}
+		}
+	}
+
+	evidence, err = ParseCombinedEvidence(tcg, LogOptions{}, AlgorithmSha1)
+	if err != nil {
+		t.Fatalf("ParseCombinedEvidence failed: %v", err)
+	}
+	if len(evidence.TCGEvents) != 11 || len(evidence.IMAEvents) != 0 || evidence.IMAOffset != len(tcg) {
+		t.Errorf("Unexpected result for a log without an IMA segment")
+	}
+
+	if _, err := ParseCombinedEvidence(append(append([]byte(nil), tcg...), 1, 2, 3), LogOptions{},
+		AlgorithmSha1); err == nil {
+		t.Errorf("ParseCombinedEvidence should have failed for trailing garbage")
+	}
+}
@@ -0,0 +1,147 @@
+package tcglog
+
+// ComplianceReference refers to a topic in a published security guideline.
+type ComplianceReference struct {
+	Document string `json:"document"`
+	Topic    string `json:"topic"`
+}
+
+var (
+	// NISTSP800193Protection refers to the protection principle of NIST SP 800-193 "Platform Firmware
+	// Resiliency Guidelines".
+	NISTSP800193Protection = ComplianceReference{"NIST SP 800-193", "Protection"}
+
+	// NISTSP800193Detection refers to the detection principle of NIST SP 800-193 "Platform Firmware
+	// Resiliency Guidelines".
+	NISTSP800193Detection = ComplianceReference{"NIST SP 800-193", "Detection"}
+
+	// NISTSP800155Measurement refers to the measurement of BIOS integrity in NIST SP 800-155 "BIOS Integrity
+	// Measurement Guidelines".
+	NISTSP800155Measurement = ComplianceReference{"NIST SP 800-155", "BIOS integrity measurement"}
+
+	// NISTSP800155Reporting refers to the secure reporting of measurements in NIST SP 800-155 "BIOS Integrity
+	// Measurement Guidelines".
+	NISTSP800155Reporting = ComplianceReference{"NIST SP 800-155", "Secure reporting of measurements"}
+
+	// NISTSP800155RootOfTrust refers to the root of trust for measurement in NIST SP 800-155 "BIOS Integrity
+	// Measurement Guidelines".
+	NISTSP800155RootOfTrust = ComplianceReference{"NIST SP 800-155", "Root of trust for measurement"}
+)
+
+var findingComplianceReferences = map[FindingCode][]ComplianceReference{
+	FindingLogTooSmall:          {NISTSP800155Measurement, NISTSP800193Detection},
+	FindingLogMissingSeparators: {NISTSP800155Measurement, NISTSP800193Detection},
+	FindingLogTooLarge:          {NISTSP800155Measurement, NISTSP800193Detection},
+	FindingHighEntropyEventData: {NISTSP800155Measurement},
+	FindingCCEvidenceMismatch:   {NISTSP800155Reporting, NISTSP800193Detection},
+	FindingBankNotActive:        {NISTSP800155Reporting},
+	FindingBankNotInLog:         {NISTSP800155Reporting},
+	FindingBankDowngrade:        {NISTSP800155Reporting, NISTSP800193Detection},
+	FindingPCRReset:             {NISTSP800155RootOfTrust, NISTSP800193Detection},
+	FindingPCRValueMismatch:     {NISTSP800155Reporting, NISTSP800193Detection},
+	FindingSpecViolation:        {NISTSP800155Measurement},
+	FindingDigestSizeMismatch:   {NISTSP800155Reporting},
+	FindingMissingDigest:        {NISTSP800155Reporting},
+
+	FindingImplausibleImageLoadAddress: {NISTSP800155Measurement},
+	FindingUndecodedPlatformConfig:     {NISTSP800155Measurement},
+}
+
+// ComplianceReferencesForFinding returns the guidance that is relevant to findings with the specified code.
+func ComplianceReferencesForFinding(code FindingCode) []ComplianceReference {
+	return findingComplianceReferences[code]
+}
+
+// BootPhase describes the phase of the boot in which an event was measured.
+type BootPhase int
+
+const (
+	BootPhaseUnknown   BootPhase = iota
+	BootPhasePreOS               // Measured by the platform firmware, before the transition to the OS-present environment
+	BootPhaseOSPresent           // Measured after the transition to the OS-present environment
+)
+
+func (p BootPhase) String() string {
+	switch p {
+	case BootPhasePreOS:
+		return "pre-os"
+	case BootPhaseOSPresent:
+		return "os-present"
+	default:
+		return "unknown"
+	}
+}
+
+// MarshalJSON implements json.Marshaler.
+func (p BootPhase) MarshalJSON() ([]byte, error) {
+	return []byte("\"" + p.String() + "\""), nil
+}
+
+// ComplianceReferencesForBootPhase returns the guidance that is relevant to measurements made during the
+// specified boot phase.
+func ComplianceReferencesForBootPhase(phase BootPhase) []ComplianceReference {
+	switch phase {
+	case BootPhasePreOS:
+		return []ComplianceReference{NISTSP800155Measurement, NISTSP800155RootOfTrust, NISTSP800193Protection}
+	default:
+		return nil
+	}
+}
+
+// ComplianceAnnotation associates a finding or event with the guidance that is relevant to it.
+type ComplianceAnnotation struct {
+	Finding    *Finding              `json:"finding,omitempty"`
+	Event      *Event                `json:"event,omitempty"`
+	Phase      BootPhase             `json:"phase"`
+	References []ComplianceReference `json:"references"`
+}
+
+// BootPhases returns the boot phase in which each event was measured, indexed by position in ValidatedEvents.
+// Events in PCRs 0-7 up to and including the EV_SEPARATOR event in each PCR are considered to be pre-OS.
+func (r *LogValidateResult) BootPhases() []BootPhase {
+	out := make([]BootPhase, len(r.ValidatedEvents))
+	seenSeparator := make(map[PCRIndex]bool)
+	for i, e := range r.ValidatedEvents {
+		pcr := e.Event.PCRIndex
+		if isPreOSPCR(pcr) && !seenSeparator[pcr] {
+			out[i] = BootPhasePreOS
+		} else {
+			out[i] = BootPhaseOSPresent
+		}
+		if e.Event.EventType == EventTypeSeparator {
+			seenSeparator[pcr] = true
+		}
+	}
+	return out
+}
+
+// AnnotateCompliance returns annotations that associate the findings in this result, and events that have
+// digests which aren't consistent with their data, with relevant NIST guidance for compliance reporting.
+func (r *LogValidateResult) AnnotateCompliance() (out []ComplianceAnnotation) {
+	phases := r.BootPhases()
+	eventPhase := make(map[*Event]BootPhase)
+	for i, e := range r.ValidatedEvents {
+		eventPhase[e.Event] = phases[i]
+	}
+
+	for i := range r.Findings {
+		f := &r.Findings[i]
+		a := ComplianceAnnotation{Finding: f, Event: f.Event}
+		if f.Event != nil {
+			a.Phase = eventPhase[f.Event]
+		}
+		a.References = append(a.References, ComplianceReferencesForFinding(f.Code)...)
+		out = append(out, a)
+	}
+
+	for i, e := range r.ValidatedEvents {
+		if len(e.IncorrectDigestValues) == 0 {
+			continue
+		}
+		a := ComplianceAnnotation{Event: e.Event, Phase: phases[i]}
+		a.References = append(a.References, NISTSP800155Measurement, NISTSP800193Detection)
+		out = append(out, a)
+	}
+
+	return
+}
@@ -0,0 +1,133 @@
+package tcglog
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Component describes a measured boot component, such as a firmware driver, a bootloader or a kernel.
+type Component struct {
+	Name    string `json:"name"`              // The name of the component, eg, "shim"
+	Version string `json:"version,omitempty"` // The version of the component, eg, "15.8"
+	Vendor  string `json:"vendor,omitempty"`  // The vendor or distribution that built the component, eg, "Ubuntu 24.04"
+	Type    string `json:"type,omitempty"`    // The kind of component, eg, "bootloader" or "kernel"
+}
+
+func (c *Component) String() string {
+	var fields []string
+	for _, f := range []string{c.Vendor, c.Name, c.Version} {
+		if f != "" {
+			fields = append(fields, f)
+		}
+	}
+	return strings.Join(fields, " ")
+}
+
+// ComponentDatabase is implemented by databases that map the digests of measured boot components to a
+// description of those components, so that events can be identified heuristically. Databases might be generated
+// from distribution metadata or from a collection of known-good binaries.
+type ComponentDatabase interface {
+	// LookupComponent returns the component with the specified digest, or nil if it is unknown.
+	LookupComponent(alg AlgorithmId, digest Digest) *Component
+}
+
+type componentDatabaseEntry struct {
+	Component
+	Digests map[string]string `json:"digests"`
+}
+
+// MemoryComponentDatabase is a ComponentDatabase that is stored in memory. It can be serialized to and from JSON
+// with ReadComponentDatabase and Write.
+type MemoryComponentDatabase struct {
+	entries []*componentDatabaseEntry
+	index   map[AlgorithmId]map[string]*Component
+}
+
+// NewMemoryComponentDatabase returns a new empty MemoryComponentDatabase.
+func NewMemoryComponentDatabase() *MemoryComponentDatabase {
+	return &MemoryComponentDatabase{index: make(map[AlgorithmId]map[string]*Component)}
+}
+
+// Add adds a component with the specified digests to the database.
+func (db *MemoryComponentDatabase) Add(component *Component, digests DigestMap) {
+	entry := &componentDatabaseEntry{Component: *component, Digests: make(map[string]string)}
+	for _, alg := range sortedDigestAlgorithms(digests) {
+		entry.Digests[alg.jsonName()] = hex.EncodeToString(digests[alg])
+		if _, ok := db.index[alg]; !ok {
+			db.index[alg] = make(map[string]*Component)
+		}
+		db.index[alg][string(digests[alg])] = &entry.Component
+	}
+	db.entries = append(db.entries, entry)
+}
+
+// LookupComponent implements ComponentDatabase.LookupComponent.
+func (db *MemoryComponentDatabase) LookupComponent(alg AlgorithmId, digest Digest) *Component {
+	return db.index[alg][string(digest)]
+}
+
+// Write serializes the database to w as JSON.
+func (db *MemoryComponentDatabase) Write(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(struct {
+		Components []*componentDatabaseEntry `json:"components"`
+	}{db.entries})
+}
+
+// ReadComponentDatabase reads a database in the JSON format written by MemoryComponentDatabase.Write. Each
+// component is an object with "name", "version", "vendor" and "type" fields, and a "digests" object that maps
+// algorithm names such as "sha256" to hexadecimal digests.
+func ReadComponentDatabase(r io.Reader) (*MemoryComponentDatabase, error) {
+	var in struct {
+		Components []*componentDatabaseEntry `json:"components"`
+	}
+	if err := json.NewDecoder(r).Decode(&in); err != nil {
+		return nil, fmt.Errorf("cannot decode component database: %w", err)
+	}
+
+	db := NewMemoryComponentDatabase()
+	for i, entry := range in.Components {
+		digests := DigestMap{}
+		for name, value := range entry.Digests {
+			alg, err := ParseAlgorithm(name)
+			if err != nil {
+				return nil, fmt.Errorf("component %d: %w", i, err)
+			}
+			digest, err := hex.DecodeString(value)
+			if err != nil || len(digest) != alg.size() {
+				return nil, fmt.Errorf("component %d: invalid %s digest", i, name)
+			}
+			digests[alg] = digest
+		}
+		db.Add(&entry.Component, digests)
+	}
+	return db, nil
+}
+
+// IdentifiedComponent associates an event with the component that it measured.
+type IdentifiedComponent struct {
+	Event     *Event
+	Algorithm AlgorithmId // The algorithm of the digest that matched
+	Component *Component
+}
+
+// IdentifyComponents looks up the digests of each of the supplied events in db, and returns the events that
+// measured a known component. Only events that extend a PCR are considered.
+func IdentifyComponents(events []*Event, db ComponentDatabase) (out []IdentifiedComponent) {
+	for _, event := range events {
+		if !doesEventTypeExtendPCR(event.EventType) {
+			continue
+		}
+		for _, alg := range sortedDigestAlgorithms(event.Digests) {
+			if c := db.LookupComponent(alg, event.Digests[alg]); c != nil {
+				out = append(out, IdentifiedComponent{Event: event, Algorithm: alg, Component: c})
+				break
+			}
+		}
+	}
+	return
+}
@@ -0,0 +1,49 @@
+package tcglog
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestComponentDatabase(t *testing.T) {
+	shim := &Component{Name: "shim", Version: "15.8", Vendor: "Ubuntu 24.04", Type: "bootloader"}
+	if shim.String() != "Ubuntu 24.04 shim 15.8" {
+		t.Errorf("Unexpected string: %s", shim)
+	}
+
+	db := NewMemoryComponentDatabase()
+	db.Add(shim, DigestMap{
+		AlgorithmSha1:   AlgorithmSha1.hash([]byte("shim")),
+		AlgorithmSha256: AlgorithmSha256.hash([]byte("shim"))})
+
+	var buf bytes.Buffer
+	if err := db.Write(&buf); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+	db, err := ReadComponentDatabase(&buf)
+	if err != nil {
+		t.Fatalf("ReadComponentDatabase failed: %v", err)
+	}
+
+	events := []*Event{
+		{PCRIndex: 4, EventType: EventTypeEFIAction,
+			Digests: DigestMap{AlgorithmSha256: AlgorithmSha256.hash([]byte("foo"))}},
+		{PCRIndex: 4, EventType: EventTypeEFIBootServicesApplication,
+			Digests: DigestMap{AlgorithmSha256: AlgorithmSha256.hash([]byte("shim"))}},
+		{PCRIndex: 0, EventType: EventTypeNoAction,
+			Digests: DigestMap{AlgorithmSha256: AlgorithmSha256.hash([]byte("shim"))}},
+	}
+	identified := IdentifyComponents(events, db)
+	if len(identified) != 1 {
+		t.Fatalf("Unexpected number of identified components (%d)", len(identified))
+	}
+	if identified[0].Event != events[1] || identified[0].Algorithm != AlgorithmSha256 ||
+		*identified[0].Component != *shim {
+		t.Errorf("Unexpected identified component: %+v", identified[0])
+	}
+
+	if _, err := ReadComponentDatabase(bytes.NewReader([]byte(
+		`{"components":[{"name":"foo","digests":{"sha256":"00"}}]}`))); err == nil {
+		t.Errorf("ReadComponentDatabase should have failed for an invalid digest")
+	}
+}
@@ -31,8 +31,52 @@ const (
 	EventTypeEFIAction                  EventType = 0x80000007 // EV_EFI_ACTION
 	EventTypeEFIPlatformFirmwareBlob    EventType = 0x80000008 // EV_EFI_PLATFORM_FIRMWARE_BLOB
 	EventTypeEFIHandoffTables           EventType = 0x80000009 // EF_EFI_HANDOFF_TABLES
+	EventTypeEFIPlatformFirmwareBlob2   EventType = 0x8000000a // EV_EFI_PLATFORM_FIRMWARE_BLOB2
+	EventTypeEFIHandoffTables2          EventType = 0x8000000b // EV_EFI_HANDOFF_TABLES2
+	EventTypeEFIVariableBoot2           EventType = 0x8000000c // EV_EFI_VARIABLE_BOOT2
 	EventTypeEFIHCRTMEvent              EventType = 0x80000010 // EF_EFI_HCRTM_EVENT
 	EventTypeEFIVariableAuthority       EventType = 0x800000e0 // EV_EFI_VARIABLE_AUTHORITY
+	EventTypeEFISPDMFirmwareBlob        EventType = 0x800000e1 // EV_EFI_SPDM_FIRMWARE_BLOB
+	EventTypeEFISPDMFirmwareConfig      EventType = 0x800000e2 // EV_EFI_SPDM_FIRMWARE_CONFIG
+	EventTypeEFISPDMDevicePolicy        EventType = 0x800000e3 // EV_EFI_SPDM_DEVICE_POLICY
+	EventTypeEFISPDMDeviceAuthority     EventType = 0x800000e4 // EV_EFI_SPDM_DEVICE_AUTHORITY
+)
+
+// Event types recorded by Intel TXT in PCRs 17 and 18 during a dynamic launch.
+// https://www.intel.com/content/dam/www/public/us/en/documents/guides/intel-txt-software-development-guide.pdf
+//  (appendix F "TPM Event Log")
+const (
+	EventTypeTXTPCRMapping         EventType = 0x00000401 // EVTYPE_PCRMAPPING
+	EventTypeTXTHashStart          EventType = 0x00000402 // EVTYPE_HASH_START
+	EventTypeTXTCombinedHash       EventType = 0x00000403 // EVTYPE_COMBINED_HASH
+	EventTypeTXTMLEHash            EventType = 0x00000404 // EVTYPE_MLE_HASH
+	EventTypeTXTBIOSACRegData      EventType = 0x0000040a // EVTYPE_BIOSAC_REG_DATA
+	EventTypeTXTCPUSCRTMStat       EventType = 0x0000040b // EVTYPE_CPU_SCRTM_STAT
+	EventTypeTXTLCPControlHash     EventType = 0x0000040c // EVTYPE_LCP_CONTROL_HASH
+	EventTypeTXTElementsDataHash   EventType = 0x0000040d // EVTYPE_ELEMENTS_DATA_HASH
+	EventTypeTXTSTMHash            EventType = 0x0000040e // EVTYPE_STM_HASH
+	EventTypeTXTOSSINITDataCapHash EventType = 0x0000040f // EVTYPE_OSSINITDATA_CAP_HASH
+	EventTypeTXTSINITPubKeyHash    EventType = 0x00000410 // EVTYPE_SINIT_PUBKEY_HASH
+	EventTypeTXTLCPHash            EventType = 0x00000411 // EVTYPE_LCP_HASH
+	EventTypeTXTLCPDetailsHash     EventType = 0x00000412 // EVTYPE_LCP_DETAILS_HASH
+	EventTypeTXTLCPAuthoritiesHash EventType = 0x00000413 // EVTYPE_LCP_AUTHORITIES_HASH
+	EventTypeTXTNVInfoHash         EventType = 0x00000414 // EVTYPE_NV_INFO_HASH
+	EventTypeTXTColdBootBIOSHash   EventType = 0x00000415 // EVTYPE_COLD_BOOT_BIOS_HASH
+	EventTypeTXTKMHash             EventType = 0x00000416 // EVTYPE_KM_HASH
+	EventTypeTXTBPMHash            EventType = 0x00000417 // EVTYPE_BPM_HASH
+	EventTypeTXTKMInfoHash         EventType = 0x00000418 // EVTYPE_KM_INFO_HASH
+	EventTypeTXTBPMInfoHash        EventType = 0x00000419 // EVTYPE_BPM_INFO_HASH
+	EventTypeTXTBootPolicyHash     EventType = 0x0000041a // EVTYPE_BOOT_POL_HASH
+	EventTypeTXTRandomValue        EventType = 0x000004fe // EVTYPE_RANDOM_VALUE
+	EventTypeTXTCapValue           EventType = 0x000004ff // EVTYPE_CAP_VALUE
+)
+
+// Event types recorded in PCRs 17 and 18 by Secure Launch implementations, such as Xen and Linux, during a
+// dynamic launch. These are defined relative to the TXT event type base of 0x400.
+const (
+	EventTypeSLaunch      EventType = 0x00000502 // DLE_EVTYPE_SLAUNCH
+	EventTypeSLaunchStart EventType = 0x00000503 // DLE_EVTYPE_SLAUNCH_START
+	EventTypeSLaunchEnd   EventType = 0x00000504 // DLE_EVTYPE_SLAUNCH_END
 )
 
 const (
@@ -40,6 +84,11 @@ const (
 	AlgorithmSha256 AlgorithmId = 0x000b // TPM_ALG_SHA256
 	AlgorithmSha384 AlgorithmId = 0x000c // TPM_ALG_SHA384
 	AlgorithmSha512 AlgorithmId = 0x000d // TPM_ALG_SHA512
+
+	AlgorithmSm3_256  AlgorithmId = 0x0012 // TPM_ALG_SM3_256
+	AlgorithmSha3_256 AlgorithmId = 0x0027 // TPM_ALG_SHA3_256
+	AlgorithmSha3_384 AlgorithmId = 0x0028 // TPM_ALG_SHA3_384
+	AlgorithmSha3_512 AlgorithmId = 0x0029 // TPM_ALG_SHA3_512
 )
 
 const (
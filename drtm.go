@@ -0,0 +1,62 @@
+package tcglog
+
+import (
+	"bytes"
+)
+
+// https://trustedcomputinggroup.org/wp-content/uploads/PC-ClientSpecific_Platform_Profile_for_TPM_2p0_Systems_v51.pdf
+//  (section 4.6 "PCR Attributes")
+const (
+	firstDRTMPCR PCRIndex = 17
+	lastDRTMPCR  PCRIndex = 22
+)
+
+// isDRTMPCR indicates whether the specified PCR is reset by a dynamic launch.
+func isDRTMPCR(pcr PCRIndex) bool {
+	return pcr >= firstDRTMPCR && pcr <= lastDRTMPCR
+}
+
+// preLaunchDRTMPCRValue returns the value that PCRs 17-22 have in the specified bank after TPM2_Startup and
+// before a dynamic launch, which is all ones.
+func preLaunchDRTMPCRValue(alg AlgorithmId) Digest {
+	return bytes.Repeat([]byte{0xff}, alg.size())
+}
+
+// dynamicLaunchTracker detects the dynamic launch events that reset PCRs 17-22 to zero. A dynamic launch is
+// assumed to happen before the first event that is measured to one of these PCRs. Launches that happen
+// afterwards, such as on resume from S3 with Intel TXT, are detected from the TXT EVTYPE_HASH_START event.
+type dynamicLaunchTracker struct {
+	launched bool
+}
+
+// isDynamicLaunch indicates whether the supplied event is the first measurement made after a dynamic launch.
+func (t *dynamicLaunchTracker) isDynamicLaunch(event *Event) bool {
+	if !isDRTMPCR(event.PCRIndex) || !doesEventTypeExtendPCR(event.EventType) {
+		return false
+	}
+	launch := !t.launched || (event.PCRIndex == firstDRTMPCR && event.EventType == EventTypeTXTHashStart)
+	t.launched = true
+	return launch
+}
+
+// resetDRTMPCRs sets the value of each of PCRs 17-22 in values to zero, as a dynamic launch does.
+func resetDRTMPCRs(values map[PCRIndex]DigestMap) {
+	for pcr, digests := range values {
+		if !isDRTMPCR(pcr) {
+			continue
+		}
+		for alg := range digests {
+			digests[alg] = make(Digest, alg.size())
+		}
+	}
+}
+
+// hasDRTMPCRValues indicates whether values contains any of PCRs 17-22.
+func hasDRTMPCRValues(values map[PCRIndex]DigestMap) bool {
+	for pcr := range values {
+		if isDRTMPCR(pcr) {
+			return true
+		}
+	}
+	return false
+}
@@ -5,6 +5,7 @@ import (
 	"encoding/binary"
 	"fmt"
 	"io"
+	"strings"
 	"unicode/utf16"
 	"unicode/utf8"
 )
@@ -59,6 +60,32 @@ func (g *EFIGUID) String() string {
 	return fmt.Sprintf("{%08x-%04x-%04x-%04x-%012x}", g.Data1, g.Data2, g.Data3, binary.BigEndian.Uint16(g.Data4[0:2]), g.Data4[2:])
 }
 
+// efiGUIDText returns the GUID in the registry format used by the UEFI device path text form, without braces.
+func efiGUIDText(g *EFIGUID) string {
+	return strings.Trim(g.String(), "{}")
+}
+
+// efiGUIDSize is the size of the binary representation of an EFI_GUID.
+const efiGUIDSize = 16
+
+// decodeEFIGUID decodes an EFI_GUID from the start of b, which must be at least efiGUIDSize bytes long.
+func decodeEFIGUID(b []byte) (out EFIGUID) {
+	out.Data1 = binary.LittleEndian.Uint32(b[0:])
+	out.Data2 = binary.LittleEndian.Uint16(b[4:])
+	out.Data3 = binary.LittleEndian.Uint16(b[6:])
+	copy(out.Data4[:], b[8:16])
+	return
+}
+
+// readEFIGUID reads an EFI_GUID from r. This avoids the reflection performed by binary.Read for structures.
+func readEFIGUID(r io.Reader) (EFIGUID, error) {
+	var b [efiGUIDSize]byte
+	if _, err := io.ReadFull(r, b[:]); err != nil {
+		return EFIGUID{}, err
+	}
+	return decodeEFIGUID(b[:]), nil
+}
+
 func NewEFIGUID(a uint32, b, c, d uint16, e [6]uint8) *EFIGUID {
 	guid := &EFIGUID{Data1: a, Data2: b, Data3: c}
 	binary.BigEndian.PutUint16(guid.Data4[0:2], d)
@@ -78,6 +105,11 @@ func parseEFI_1_2_SpecIdEvent(stream io.Reader, eventData *SpecIdEventData) erro
 	}
 
 	// TCG_EfiSpecIdEventStruct.vendorInfo
+	if r, ok := stream.(interface{ Len() int }); ok {
+		if err := checkLengthField("vendorInfoSize", uint64(vendorInfoSize), 1, r); err != nil {
+			return err
+		}
+	}
 	eventData.VendorInfo = make([]byte, vendorInfoSize)
 	if _, err := io.ReadFull(stream, eventData.VendorInfo); err != nil {
 		return wrapSpecIdEventReadError(err)
@@ -86,6 +118,9 @@ func parseEFI_1_2_SpecIdEvent(stream io.Reader, eventData *SpecIdEventData) erro
 	return nil
 }
 
+// maxDigestSize is the size of the largest digest that a TPM can produce (sizeof(TPMU_HA)).
+const maxDigestSize = 64
+
 // https://trustedcomputinggroup.org/wp-content/uploads/TCG_PCClientSpecPlat_TPM_2p0_1p04_pub.pdf
 //  (secion 9.4.5.1 "Specification ID Version Event")
 func parseEFI_2_SpecIdEvent(stream io.Reader, eventData *SpecIdEventData) error {
@@ -100,17 +135,27 @@ func parseEFI_2_SpecIdEvent(stream io.Reader, eventData *SpecIdEventData) error
 	if numberOfAlgorithms < 1 {
 		return invalidSpecIdEventError{"numberOfAlgorithms is zero"}
 	}
+	if r, ok := stream.(interface{ Len() int }); ok && uint64(numberOfAlgorithms)*4 > uint64(r.Len()) {
+		// Avoid allocating space for more algorithms than the event could contain.
+		return invalidSpecIdEventError{"numberOfAlgorithms is too large"}
+	}
 
 	// TCG_EfiSpecIdEvent.digestSizes
 	eventData.DigestSizes = make([]EFISpecIdEventAlgorithmSize, numberOfAlgorithms)
-	if err := binary.Read(stream, binary.LittleEndian, eventData.DigestSizes); err != nil {
-		return wrapSpecIdEventReadError(err)
+	for i := range eventData.DigestSizes {
+		var algSize [4]byte
+		if _, err := io.ReadFull(stream, algSize[:]); err != nil {
+			return wrapSpecIdEventReadError(err)
+		}
+		eventData.DigestSizes[i] = EFISpecIdEventAlgorithmSize{
+			AlgorithmId: AlgorithmId(binary.LittleEndian.Uint16(algSize[0:])),
+			DigestSize:  binary.LittleEndian.Uint16(algSize[2:])}
 	}
 	for _, d := range eventData.DigestSizes {
-		if d.AlgorithmId.supported() && d.AlgorithmId.size() != int(d.DigestSize) {
-			return invalidSpecIdEventError{
-				fmt.Sprintf("digestSize for algorithmId 0x%04x doesn't match expected size "+
-					"(got: %d, expected: %d)", d.AlgorithmId, d.DigestSize, d.AlgorithmId.size())}
+		// Sizes that don't match a supported algorithm are checked when the log is opened, but sizes that
+		// aren't possible for any algorithm would result in the rest of the log being misparsed.
+		if d.DigestSize == 0 || d.DigestSize > maxDigestSize {
+			return &InvalidDigestSizeError{Algorithm: d.AlgorithmId, DigestSize: d.DigestSize}
 		}
 	}
 
@@ -121,6 +166,11 @@ func parseEFI_2_SpecIdEvent(stream io.Reader, eventData *SpecIdEventData) error
 	}
 
 	// TCG_EfiSpecIdEvent.vendorInfo
+	if r, ok := stream.(interface{ Len() int }); ok {
+		if err := checkLengthField("vendorInfoSize", uint64(vendorInfoSize), 1, r); err != nil {
+			return err
+		}
+	}
 	eventData.VendorInfo = make([]byte, vendorInfoSize)
 	if _, err := io.ReadFull(stream, eventData.VendorInfo); err != nil {
 		return wrapSpecIdEventReadError(err)
@@ -157,47 +207,16 @@ func decodeStartupLocalityEvent(stream io.Reader, data []byte) (*startupLocality
 	return &startupLocalityEventData{data: data, Locality: locality}, nil
 }
 
-type bimReferenceManifestEventData struct {
-	data     []byte
-	VendorId uint32
-	Guid     EFIGUID
-}
-
-func (e *bimReferenceManifestEventData) String() string {
-	return fmt.Sprintf("Sp800_155_PlatformId_Event{ VendorId: %d, ReferenceManifestGuid: %s }",
-		e.VendorId, &e.Guid)
-}
-
-func (e *bimReferenceManifestEventData) Bytes() []byte {
-	return e.data
-}
-
-func (e *bimReferenceManifestEventData) Type() NoActionEventType {
-	return BiosIntegrityMeasurement
-}
-
-// https://trustedcomputinggroup.org/wp-content/uploads/TCG_PCClientSpecPlat_TPM_2p0_1p04_pub.pdf
-//  (section 9.4.5.2 "BIOS Integrity Measurement Reference Manifest Event")
-// https://trustedcomputinggroup.org/wp-content/uploads/TCG_EFI_Platform_1_22_Final_-v15.pdf
-//  (section 7.4 "EV_NO_ACTION Event Types")
-func decodeBIMReferenceManifestEvent(stream io.Reader, data []byte) (*bimReferenceManifestEventData, error) {
-	var d struct{
-		VendorId uint32
-		Guid EFIGUID
-	}
-	if err := binary.Read(stream, binary.LittleEndian, &d); err != nil {
-		return nil, err
-	}
-
-	return &bimReferenceManifestEventData{data: data, VendorId: d.VendorId, Guid: d.Guid}, nil
-}
-
 // EFIVariableEventData corresponds to the EFI_VARIABLE_DATA type.
 type EFIVariableEventData struct {
 	data         []byte
 	VariableName EFIGUID
 	UnicodeName  string
 	VariableData []byte
+
+	// SignatureLists contains the decoded variable data for EV_EFI_VARIABLE_DRIVER_CONFIG events that measure
+	// the PK, KEK, db or dbx variables. It is nil for other events, or if the variable data is malformed.
+	SignatureLists []*EFISignatureList
 }
 
 func (e *EFIVariableEventData) String() string {
@@ -233,8 +252,8 @@ func (e *EFIVariableEventData) EncodeMeasuredBytes(buf io.Writer) error {
 func decodeEventDataEFIVariableImpl(data []byte, eventType EventType) (*EFIVariableEventData, int, error) {
 	stream := bytes.NewReader(data)
 
-	var guid EFIGUID
-	if err := binary.Read(stream, binary.LittleEndian, &guid); err != nil {
+	guid, err := readEFIGUID(stream)
+	if err != nil {
 		return nil, 0, err
 	}
 
@@ -248,20 +267,34 @@ func decodeEventDataEFIVariableImpl(data []byte, eventType EventType) (*EFIVaria
 		return nil, 0, err
 	}
 
+	if err := checkLengthField("UnicodeNameLength", unicodeNameLength, 2, stream); err != nil {
+		return nil, 0, err
+	}
 	utf16Name, err := extractUTF16Buffer(stream, unicodeNameLength)
 	if err != nil {
 		return nil, 0, err
 	}
 
+	if err := checkLengthField("VariableDataLength", variableDataLength, 1, stream); err != nil {
+		return nil, 0, err
+	}
 	variableData := make([]byte, variableDataLength)
 	if _, err := io.ReadFull(stream, variableData); err != nil {
 		return nil, 0, err
 	}
 
-	return &EFIVariableEventData{data: data,
+	d := &EFIVariableEventData{data: data,
 		VariableName: guid,
 		UnicodeName:  convertUtf16ToString(utf16Name),
-		VariableData: variableData}, stream.Len(), nil
+		VariableData: variableData}
+
+	if eventType == EventTypeEFIVariableDriverConfig && isEFISignatureDatabaseVariable(&guid, d.UnicodeName) {
+		if lists, err := DecodeEFISignatureDatabase(variableData); err == nil {
+			d.SignatureLists = lists
+		}
+	}
+
+	return d, stream.Len(), nil
 }
 
 func decodeEventDataEFIVariable(data []byte, eventType EventType) (out EventData, trailingBytes int, err error) {
@@ -272,19 +305,20 @@ func decodeEventDataEFIVariable(data []byte, eventType EventType) (out EventData
 	return
 }
 
-type efiDevicePathNodeType uint8
+// EFIDevicePathNodeType corresponds to the type of a node in an EFI device path.
+type EFIDevicePathNodeType uint8
 
-func (t efiDevicePathNodeType) String() string {
+func (t EFIDevicePathNodeType) String() string {
 	switch t {
-	case efiDevicePathNodeHardware:
+	case EFIDevicePathNodeHardware:
 		return "HardwarePath"
-	case efiDevicePathNodeACPI:
+	case EFIDevicePathNodeACPI:
 		return "AcpiPath"
-	case efiDevicePathNodeMsg:
+	case EFIDevicePathNodeMsg:
 		return "Msg"
-	case efiDevicePathNodeMedia:
+	case EFIDevicePathNodeMedia:
 		return "MediaPath"
-	case efiDevicePathNodeBBS:
+	case EFIDevicePathNodeBBS:
 		return "BbsPath"
 	default:
 		return fmt.Sprintf("Path[%02x]", uint8(t))
@@ -292,12 +326,13 @@ func (t efiDevicePathNodeType) String() string {
 }
 
 const (
-	efiDevicePathNodeHardware efiDevicePathNodeType = 0x01
-	efiDevicePathNodeACPI                           = 0x02
-	efiDevicePathNodeMsg                            = 0x03
-	efiDevicePathNodeMedia                          = 0x04
-	efiDevicePathNodeBBS                            = 0x05
-	efiDevicePathNodeEoH                            = 0x7f
+	EFIDevicePathNodeHardware EFIDevicePathNodeType = 0x01
+	EFIDevicePathNodeACPI     EFIDevicePathNodeType = 0x02
+	EFIDevicePathNodeMsg      EFIDevicePathNodeType = 0x03
+	EFIDevicePathNodeMedia    EFIDevicePathNodeType = 0x04
+	EFIDevicePathNodeBBS      EFIDevicePathNodeType = 0x05
+
+	efiDevicePathNodeEoH EFIDevicePathNodeType = 0x7f
 )
 
 const (
@@ -315,159 +350,329 @@ const (
 	efiMediaDevicePathNodeRelOffsetRange = 0x08
 )
 
-func firmwareDevicePathNodeToString(subType uint8, data []byte) (string, error) {
-	stream := bytes.NewReader(data)
+// EFIDevicePathNode corresponds to a single node in an EFI device path. The concrete type of a node can be
+// determined with a type switch. Nodes with a type that isn't decoded by this package are represented by
+// *EFIGenericDevicePathNode.
+type EFIDevicePathNode interface {
+	String() string
+	Text() string // The node in the text form defined by the UEFI specification
+}
+
+// EFIDevicePath corresponds to an EFI device path.
+type EFIDevicePath []EFIDevicePathNode
+
+func (p EFIDevicePath) String() string {
+	var builder bytes.Buffer
+	for _, node := range p {
+		builder.WriteString(node.String())
+	}
+	return builder.String()
+}
+
+// Text returns the device path in the text form defined by the UEFI specification (section 10.6 "EFI Device
+// Path Display Format Overview"), eg, "PciRoot(0x0)/Pci(0x1d,0x0)/HD(1,GPT,...)/\EFI\BOOT\BOOTX64.EFI".
+func (p EFIDevicePath) Text() string {
+	var nodes []string
+	for _, node := range p {
+		nodes = append(nodes, node.Text())
+	}
+	return strings.Join(nodes, "/")
+}
 
-	var name EFIGUID
-	if err := binary.Read(stream, binary.LittleEndian, &name); err != nil {
-		return "", err
+// HardDrive returns the hard drive node from this path, which identifies the partition that a file was loaded
+// from, or nil if there isn't one.
+func (p EFIDevicePath) HardDrive() *EFIHardDriveDevicePathNode {
+	for _, node := range p {
+		if hd, ok := node.(*EFIHardDriveDevicePathNode); ok {
+			return hd
+		}
 	}
+	return nil
+}
 
+// FilePath returns the path of the file that this device path refers to, constructed from its file path
+// nodes, or an empty string if it has no file path nodes.
+func (p EFIDevicePath) FilePath() string {
 	var builder bytes.Buffer
-	switch subType {
-	case efiMediaDevicePathNodeFvFile:
-		builder.WriteString("\\FvFile")
-	case efiMediaDevicePathNodeFv:
-		builder.WriteString("\\Fv")
-	default:
-		return "", fmt.Errorf("invalid sub type for firmware device path node: %d", subType)
+	for _, node := range p {
+		if f, ok := node.(EFIFilePathDevicePathNode); ok {
+			builder.WriteString(string(f))
+		}
+	}
+	return builder.String()
+}
+
+// EFIGenericDevicePathNode corresponds to a device path node with a type that isn't decoded by this package.
+type EFIGenericDevicePathNode struct {
+	Type    EFIDevicePathNodeType
+	SubType uint8
+	Data    []byte
+}
+
+func (n *EFIGenericDevicePathNode) String() string {
+	var builder bytes.Buffer
+	fmt.Fprintf(&builder, "\\%s(%d", n.Type, n.SubType)
+	if len(n.Data) > 0 {
+		fmt.Fprintf(&builder, ", 0x")
+		for _, b := range n.Data {
+			fmt.Fprintf(&builder, "%02x", b)
+		}
 	}
+	fmt.Fprintf(&builder, ")")
+	return builder.String()
+}
+
+func (n *EFIGenericDevicePathNode) Text() string {
+	return fmt.Sprintf("%s(%d,%x)", n.Type, n.SubType, n.Data)
+}
 
-	fmt.Fprintf(&builder, "(%s)", &name)
-	return builder.String(), nil
+// EFIFirmwareFileDevicePathNode corresponds to a PIWG firmware file device path node.
+type EFIFirmwareFileDevicePathNode EFIGUID
+
+func (n *EFIFirmwareFileDevicePathNode) String() string {
+	return fmt.Sprintf("\\FvFile(%s)", (*EFIGUID)(n))
+}
+
+func (n *EFIFirmwareFileDevicePathNode) Text() string {
+	return fmt.Sprintf("FvFile(%s)", efiGUIDText((*EFIGUID)(n)))
 }
 
-func acpiDevicePathNodeToString(data []byte) (string, error) {
+// EFIFirmwareVolumeDevicePathNode corresponds to a PIWG firmware volume device path node.
+type EFIFirmwareVolumeDevicePathNode EFIGUID
+
+func (n *EFIFirmwareVolumeDevicePathNode) String() string {
+	return fmt.Sprintf("\\Fv(%s)", (*EFIGUID)(n))
+}
+
+func (n *EFIFirmwareVolumeDevicePathNode) Text() string {
+	return fmt.Sprintf("Fv(%s)", efiGUIDText((*EFIGUID)(n)))
+}
+
+func decodeFirmwareDevicePathNode(subType uint8, data []byte) (EFIDevicePathNode, error) {
 	stream := bytes.NewReader(data)
 
-	var hid uint32
-	if err := binary.Read(stream, binary.LittleEndian, &hid); err != nil {
-		return "", err
+	name, err := readEFIGUID(stream)
+	if err != nil {
+		return nil, err
 	}
 
-	var uid uint32
-	if err := binary.Read(stream, binary.LittleEndian, &uid); err != nil {
-		return "", err
+	switch subType {
+	case efiMediaDevicePathNodeFvFile:
+		return (*EFIFirmwareFileDevicePathNode)(&name), nil
+	case efiMediaDevicePathNodeFv:
+		return (*EFIFirmwareVolumeDevicePathNode)(&name), nil
+	default:
+		return nil, fmt.Errorf("invalid sub type for firmware device path node: %d", subType)
 	}
+}
+
+// EFIACPIDevicePathNode corresponds to an ACPI device path node.
+type EFIACPIDevicePathNode struct {
+	HID uint32
+	UID uint32
+}
 
-	if hid&0xffff == 0x41d0 {
-		switch hid >> 16 {
+func (n *EFIACPIDevicePathNode) String() string {
+	if n.HID&0xffff == 0x41d0 {
+		switch n.HID >> 16 {
 		case 0x0a03:
-			return fmt.Sprintf("\\PciRoot(0x%x)", uid), nil
+			return fmt.Sprintf("\\PciRoot(0x%x)", n.UID)
 		case 0x0a08:
-			return fmt.Sprintf("\\PcieRoot(0x%x)", uid), nil
+			return fmt.Sprintf("\\PcieRoot(0x%x)", n.UID)
 		case 0x0604:
-			return fmt.Sprintf("\\Floppy(0x%x)", uid), nil
+			return fmt.Sprintf("\\Floppy(0x%x)", n.UID)
 		default:
-			return fmt.Sprintf("\\Acpi(PNP%04x,0x%x)", hid>>16, uid), nil
+			return fmt.Sprintf("\\Acpi(PNP%04x,0x%x)", n.HID>>16, n.UID)
 		}
 	} else {
-		return fmt.Sprintf("\\Acpi(0x%08x,0x%x)", hid, uid), nil
+		return fmt.Sprintf("\\Acpi(0x%08x,0x%x)", n.HID, n.UID)
 	}
 }
 
-func pciDevicePathNodeToString(data []byte) (string, error) {
+func (n *EFIACPIDevicePathNode) Text() string {
+	return strings.TrimPrefix(n.String(), "\\")
+}
+
+func decodeACPIDevicePathNode(data []byte) (*EFIACPIDevicePathNode, error) {
 	stream := bytes.NewReader(data)
 
-	var function uint8
-	if err := binary.Read(stream, binary.LittleEndian, &function); err != nil {
-		return "", err
+	var n EFIACPIDevicePathNode
+	if err := binary.Read(stream, binary.LittleEndian, &n.HID); err != nil {
+		return nil, err
 	}
-
-	var device uint8
-	if err := binary.Read(stream, binary.LittleEndian, &device); err != nil {
-		return "", err
+	if err := binary.Read(stream, binary.LittleEndian, &n.UID); err != nil {
+		return nil, err
 	}
-
-	return fmt.Sprintf("\\Pci(0x%x,0x%x)", device, function), nil
+	return &n, nil
 }
 
-func luDevicePathNodeToString(data []byte) (string, error) {
-	stream := bytes.NewReader(data)
+// EFIPCIDevicePathNode corresponds to a PCI device path node.
+type EFIPCIDevicePathNode struct {
+	Function uint8
+	Device   uint8
+}
 
-	var lun uint8
-	if err := binary.Read(stream, binary.LittleEndian, &lun); err != nil {
-		return "", err
-	}
+func (n *EFIPCIDevicePathNode) String() string {
+	return fmt.Sprintf("\\Pci(0x%x,0x%x)", n.Device, n.Function)
+}
 
-	return fmt.Sprintf("\\Unit(0x%x)", lun), nil
+func (n *EFIPCIDevicePathNode) Text() string {
+	return strings.TrimPrefix(n.String(), "\\")
 }
 
-func hardDriveDevicePathNodeToString(data []byte) (string, error) {
+func decodePCIDevicePathNode(data []byte) (*EFIPCIDevicePathNode, error) {
 	stream := bytes.NewReader(data)
 
-	var partNumber uint32
-	if err := binary.Read(stream, binary.LittleEndian, &partNumber); err != nil {
-		return "", err
+	var n EFIPCIDevicePathNode
+	if err := binary.Read(stream, binary.LittleEndian, &n.Function); err != nil {
+		return nil, err
 	}
-
-	var partStart uint64
-	if err := binary.Read(stream, binary.LittleEndian, &partStart); err != nil {
-		return "", err
+	if err := binary.Read(stream, binary.LittleEndian, &n.Device); err != nil {
+		return nil, err
 	}
+	return &n, nil
+}
 
-	var partSize uint64
-	if err := binary.Read(stream, binary.LittleEndian, &partSize); err != nil {
-		return "", err
-	}
+// EFILUDevicePathNode corresponds to a logical unit device path node.
+type EFILUDevicePathNode struct {
+	LUN uint8
+}
 
-	var sig [16]byte
-	if _, err := io.ReadFull(stream, sig[:]); err != nil {
-		return "", err
-	}
+func (n *EFILUDevicePathNode) String() string {
+	return fmt.Sprintf("\\Unit(0x%x)", n.LUN)
+}
+
+func (n *EFILUDevicePathNode) Text() string {
+	return strings.TrimPrefix(n.String(), "\\")
+}
+
+func decodeLUDevicePathNode(data []byte) (*EFILUDevicePathNode, error) {
+	stream := bytes.NewReader(data)
 
-	var partFormat uint8
-	if err := binary.Read(stream, binary.LittleEndian, &partFormat); err != nil {
-		return "", err
+	var n EFILUDevicePathNode
+	if err := binary.Read(stream, binary.LittleEndian, &n.LUN); err != nil {
+		return nil, err
 	}
+	return &n, nil
+}
+
+// EFIHardDriveDevicePathNode corresponds to a hard drive media device path node, which identifies a partition.
+type EFIHardDriveDevicePathNode struct {
+	PartitionNumber uint32
+	PartitionStart  uint64
+	PartitionSize   uint64
+	Signature       [16]byte // The MBR signature or GPT partition GUID, depending on SignatureType
+	MBRType         uint8
+	SignatureType   uint8
+}
 
-	var sigType uint8
-	if err := binary.Read(stream, binary.LittleEndian, &sigType); err != nil {
-		return "", err
+// PartitionGUID returns the unique GUID of the partition if this node refers to a GPT partition, or nil
+// otherwise.
+func (n *EFIHardDriveDevicePathNode) PartitionGUID() *EFIGUID {
+	if n.SignatureType != 0x02 {
+		return nil
 	}
+	guid := decodeEFIGUID(n.Signature[:])
+	return &guid
+}
 
+func (n *EFIHardDriveDevicePathNode) String() string {
 	var builder bytes.Buffer
 
-	switch sigType {
+	switch n.SignatureType {
 	case 0x01:
-		fmt.Fprintf(&builder, "\\HD(%d,MBR,0x%08x,", partNumber, binary.LittleEndian.Uint32(sig[:]))
+		fmt.Fprintf(&builder, "\\HD(%d,MBR,0x%08x,", n.PartitionNumber, binary.LittleEndian.Uint32(n.Signature[:]))
 	case 0x02:
-		r := bytes.NewReader(sig[:])
-		var guid EFIGUID
-		if err := binary.Read(r, binary.LittleEndian, &guid); err != nil {
-			return "", err
-		}
-		fmt.Fprintf(&builder, "\\HD(%d,GPT,%s,", partNumber, &guid)
+		fmt.Fprintf(&builder, "\\HD(%d,GPT,%s,", n.PartitionNumber, n.PartitionGUID())
 	default:
-		fmt.Fprintf(&builder, "\\HD(%d,%d,0,", partNumber, sigType)
+		fmt.Fprintf(&builder, "\\HD(%d,%d,0,", n.PartitionNumber, n.SignatureType)
 	}
 
-	fmt.Fprintf(&builder, "0x%016x, 0x%016x)", partStart, partSize)
-	return builder.String(), nil
+	fmt.Fprintf(&builder, "0x%016x, 0x%016x)", n.PartitionStart, n.PartitionSize)
+	return builder.String()
+}
+
+func (n *EFIHardDriveDevicePathNode) Text() string {
+	var signature string
+	switch n.SignatureType {
+	case 0x01:
+		signature = fmt.Sprintf("MBR,0x%08x", binary.LittleEndian.Uint32(n.Signature[:]))
+	case 0x02:
+		signature = "GPT," + efiGUIDText(n.PartitionGUID())
+	default:
+		signature = fmt.Sprintf("%d,0", n.SignatureType)
+	}
+	return fmt.Sprintf("HD(%d,%s,0x%x,0x%x)", n.PartitionNumber, signature, n.PartitionStart, n.PartitionSize)
 }
 
-func sataDevicePathNodeToString(data []byte) (string, error) {
+func decodeHardDriveDevicePathNode(data []byte) (*EFIHardDriveDevicePathNode, error) {
 	stream := bytes.NewReader(data)
 
-	var hbaPortNumber uint16
-	if err := binary.Read(stream, binary.LittleEndian, &hbaPortNumber); err != nil {
-		return "", err
+	var n EFIHardDriveDevicePathNode
+	if err := binary.Read(stream, binary.LittleEndian, &n.PartitionNumber); err != nil {
+		return nil, err
 	}
-
-	var portMultiplierPortNumber uint16
-	if err := binary.Read(stream, binary.LittleEndian, &portMultiplierPortNumber); err != nil {
-		return "", err
+	if err := binary.Read(stream, binary.LittleEndian, &n.PartitionStart); err != nil {
+		return nil, err
+	}
+	if err := binary.Read(stream, binary.LittleEndian, &n.PartitionSize); err != nil {
+		return nil, err
 	}
+	if _, err := io.ReadFull(stream, n.Signature[:]); err != nil {
+		return nil, err
+	}
+	if err := binary.Read(stream, binary.LittleEndian, &n.MBRType); err != nil {
+		return nil, err
+	}
+	if err := binary.Read(stream, binary.LittleEndian, &n.SignatureType); err != nil {
+		return nil, err
+	}
+	return &n, nil
+}
+
+// EFISATADevicePathNode corresponds to a SATA device path node.
+type EFISATADevicePathNode struct {
+	HBAPortNumber            uint16
+	PortMultiplierPortNumber uint16
+	LUN                      uint16
+}
+
+func (n *EFISATADevicePathNode) String() string {
+	return fmt.Sprintf("\\Sata(0x%x,0x%x,0x%x)", n.HBAPortNumber, n.PortMultiplierPortNumber, n.LUN)
+}
+
+func (n *EFISATADevicePathNode) Text() string {
+	return strings.TrimPrefix(n.String(), "\\")
+}
+
+func decodeSATADevicePathNode(data []byte) (*EFISATADevicePathNode, error) {
+	stream := bytes.NewReader(data)
 
-	var lun uint16
-	if err := binary.Read(stream, binary.LittleEndian, &lun); err != nil {
-		return "", err
+	var n EFISATADevicePathNode
+	if err := binary.Read(stream, binary.LittleEndian, &n.HBAPortNumber); err != nil {
+		return nil, err
+	}
+	if err := binary.Read(stream, binary.LittleEndian, &n.PortMultiplierPortNumber); err != nil {
+		return nil, err
+	}
+	if err := binary.Read(stream, binary.LittleEndian, &n.LUN); err != nil {
+		return nil, err
 	}
+	return &n, nil
+}
+
+// EFIFilePathDevicePathNode corresponds to a file path media device path node.
+type EFIFilePathDevicePathNode string
+
+func (n EFIFilePathDevicePathNode) String() string {
+	return string(n)
+}
 
-	return fmt.Sprintf("\\Sata(0x%x,0x%x,0x%x)", hbaPortNumber, portMultiplierPortNumber, lun), nil
+func (n EFIFilePathDevicePathNode) Text() string {
+	return string(n)
 }
 
-func filePathDevicePathNodeToString(data []byte) string {
+func decodeFilePathDevicePathNode(data []byte) EFIFilePathDevicePathNode {
 	u16 := make([]uint16, len(data)/2)
 	stream := bytes.NewReader(data)
 	binary.Read(stream, binary.LittleEndian, &u16)
@@ -476,141 +681,152 @@ func filePathDevicePathNodeToString(data []byte) string {
 	for _, r := range utf16.Decode(u16) {
 		buf.WriteRune(r)
 	}
-	return buf.String()
+	return EFIFilePathDevicePathNode(strings.TrimRight(buf.String(), "\x00"))
 }
 
-func relOffsetRangePathNodeToString(data []byte) (string, error) {
+// EFIRelativeOffsetRangeDevicePathNode corresponds to a relative offset range media device path node.
+type EFIRelativeOffsetRangeDevicePathNode struct {
+	StartingOffset uint64
+	EndingOffset   uint64
+}
+
+func (n *EFIRelativeOffsetRangeDevicePathNode) String() string {
+	return fmt.Sprintf("\\Offset(0x%x,0x%x)", n.StartingOffset, n.EndingOffset)
+}
+
+func (n *EFIRelativeOffsetRangeDevicePathNode) Text() string {
+	return strings.TrimPrefix(n.String(), "\\")
+}
+
+func decodeRelOffsetRangeDevicePathNode(data []byte) (*EFIRelativeOffsetRangeDevicePathNode, error) {
 	stream := bytes.NewReader(data)
 
 	if _, err := stream.Seek(4, io.SeekCurrent); err != nil {
-		return "", err
+		return nil, err
 	}
 
-	var start uint64
-	if err := binary.Read(stream, binary.LittleEndian, &start); err != nil {
-		return "", err
+	var n EFIRelativeOffsetRangeDevicePathNode
+	if err := binary.Read(stream, binary.LittleEndian, &n.StartingOffset); err != nil {
+		return nil, err
 	}
-
-	var end uint64
-	if err := binary.Read(stream, binary.LittleEndian, &end); err != nil {
-		return "", err
+	if err := binary.Read(stream, binary.LittleEndian, &n.EndingOffset); err != nil {
+		return nil, err
 	}
-
-	return fmt.Sprintf("\\Offset(0x%x,0x%x)", start, end), nil
+	return &n, nil
 }
 
-func decodeDevicePathNode(stream io.Reader) (string, error) {
-	var t efiDevicePathNodeType
-	if err := binary.Read(stream, binary.LittleEndian, &t); err != nil {
-		return "", err
+func decodeDevicePathNode(stream io.Reader) (EFIDevicePathNode, error) {
+	var rawType uint8
+	if err := binary.Read(stream, binary.LittleEndian, &rawType); err != nil {
+		return nil, err
 	}
+	t := EFIDevicePathNodeType(rawType)
 
 	if t == efiDevicePathNodeEoH {
-		return "", nil
+		return nil, nil
 	}
 
 	var subType uint8
 	if err := binary.Read(stream, binary.LittleEndian, &subType); err != nil {
-		return "", err
+		return nil, err
 	}
 
 	var length uint16
 	if err := binary.Read(stream, binary.LittleEndian, &length); err != nil {
-		return "", err
+		return nil, err
 	}
 
 	if length < 4 {
-		return "", fmt.Errorf("unexpected device path node length (got %d, expected >= 4)", length)
+		return nil, fmt.Errorf("unexpected device path node length (got %d, expected >= 4)", length)
 	}
 
+	if r, ok := stream.(interface{ Len() int }); ok {
+		if err := checkLengthField("Length", uint64(length-4), 1, r); err != nil {
+			return nil, err
+		}
+	}
 	data := make([]byte, length-4)
 	if _, err := io.ReadFull(stream, data); err != nil {
-		return "", err
+		return nil, err
 	}
 
 	switch t {
-	case efiDevicePathNodeMedia:
+	case EFIDevicePathNodeMedia:
 		switch subType {
 		case efiMediaDevicePathNodeFvFile:
 			fallthrough
 		case efiMediaDevicePathNodeFv:
-			return firmwareDevicePathNodeToString(subType, data)
+			return decodeFirmwareDevicePathNode(subType, data)
 		case efiMediaDevicePathNodeHardDrive:
-			return hardDriveDevicePathNodeToString(data)
+			return decodeHardDriveDevicePathNode(data)
 		case efiMediaDevicePathNodeFilePath:
-			return filePathDevicePathNodeToString(data), nil
+			return decodeFilePathDevicePathNode(data), nil
 		case efiMediaDevicePathNodeRelOffsetRange:
-			return relOffsetRangePathNodeToString(data)
+			return decodeRelOffsetRangeDevicePathNode(data)
 		}
-	case efiDevicePathNodeACPI:
+	case EFIDevicePathNodeACPI:
 		switch subType {
 		case efiACPIDevicePathNodeNormal:
-			return acpiDevicePathNodeToString(data)
+			return decodeACPIDevicePathNode(data)
 		}
-	case efiDevicePathNodeHardware:
+	case EFIDevicePathNodeHardware:
 		switch subType {
 		case efiHardwareDevicePathNodePCI:
-			return pciDevicePathNodeToString(data)
+			return decodePCIDevicePathNode(data)
 		}
-	case efiDevicePathNodeMsg:
+	case EFIDevicePathNodeMsg:
 		switch subType {
 		case efiMsgDevicePathNodeLU:
-			return luDevicePathNodeToString(data)
+			return decodeLUDevicePathNode(data)
 		case efiMsgDevicePathNodeSATA:
-			return sataDevicePathNodeToString(data)
+			return decodeSATADevicePathNode(data)
 		}
 
 	}
 
-	var builder bytes.Buffer
-	fmt.Fprintf(&builder, "\\%s(%d", t, subType)
-	if len(data) > 0 {
-		fmt.Fprintf(&builder, ", 0x")
-		for _, b := range data {
-			fmt.Fprintf(&builder, "%02x", b)
-		}
-	}
-	fmt.Fprintf(&builder, ")")
-	return builder.String(), nil
+	return &EFIGenericDevicePathNode{Type: t, SubType: subType, Data: data}, nil
 }
 
-func decodeDevicePath(data []byte) (string, error) {
+func decodeDevicePath(data []byte) (EFIDevicePath, error) {
 	stream := bytes.NewReader(data)
-	var builder bytes.Buffer
+	var path EFIDevicePath
 
 	for {
 		node, err := decodeDevicePathNode(stream)
 		if err != nil {
-			return "", err
+			return nil, err
 		}
-		if node == "" {
-			return builder.String(), nil
+		if node == nil {
+			return path, nil
 		}
-		fmt.Fprintf(&builder, "%s", node)
+		path = append(path, node)
 	}
 }
 
-type efiImageLoadEventData struct {
+// EFIImageLoadEventData corresponds to the event data for EV_EFI_BOOT_SERVICES_APPLICATION,
+// EV_EFI_BOOT_SERVICES_DRIVER and EV_EFI_RUNTIME_SERVICES_DRIVER events.
+type EFIImageLoadEventData struct {
 	data             []byte
-	locationInMemory uint64
-	lengthInMemory   uint64
-	linkTimeAddress  uint64
-	path             string
+	LocationInMemory uint64
+	LengthInMemory   uint64
+	LinkTimeAddress  uint64
+	DevicePath       EFIDevicePath
+	RawDevicePath    []byte // The device path in its binary form
 }
 
-func (e *efiImageLoadEventData) String() string {
+func (e *EFIImageLoadEventData) String() string {
 	return fmt.Sprintf("UEFI_IMAGE_LOAD_EVENT{ ImageLocationInMemory: 0x%016x, ImageLengthInMemory: %d, "+
-		"ImageLinkTimeAddress: 0x%016x, DevicePath: %s }", e.locationInMemory, e.lengthInMemory,
-		e.linkTimeAddress, e.path)
+		"ImageLinkTimeAddress: 0x%016x, DevicePath: %s }", e.LocationInMemory, e.LengthInMemory,
+		e.LinkTimeAddress, e.DevicePath)
 }
 
-func (e *efiImageLoadEventData) Bytes() []byte {
+func (e *EFIImageLoadEventData) Bytes() []byte {
 	return e.data
 }
 
 // https://trustedcomputinggroup.org/wp-content/uploads/TCG_EFI_Platform_1_22_Final_-v15.pdf (section 4 "Measuring PE/COFF Image Files")
 // https://trustedcomputinggroup.org/wp-content/uploads/TCG_PCClientSpecPlat_TPM_2p0_1p04_pub.pdf (section 9.2.3 "UEFI_IMAGE_LOAD_EVENT Structure")
-func decodeEventDataEFIImageLoadImpl(data []byte) (*efiImageLoadEventData, error) {
+func decodeEventDataEFIImageLoadImpl(data []byte) (*EFIImageLoadEventData, error) {
 	stream := bytes.NewReader(data)
 
 	var locationInMemory uint64
@@ -633,6 +849,9 @@ func decodeEventDataEFIImageLoadImpl(data []byte) (*efiImageLoadEventData, error
 		return nil, err
 	}
 
+	if err := checkLengthField("LengthOfDevicePath", devicePathLength, 1, stream); err != nil {
+		return nil, err
+	}
 	devicePathBuf := make([]byte, devicePathLength)
 
 	if _, err := io.ReadFull(stream, devicePathBuf); err != nil {
@@ -644,11 +863,12 @@ func decodeEventDataEFIImageLoadImpl(data []byte) (*efiImageLoadEventData, error
 		return nil, err
 	}
 
-	return &efiImageLoadEventData{data: data,
-		locationInMemory: locationInMemory,
-		lengthInMemory:   lengthInMemory,
-		linkTimeAddress:  linkTimeAddress,
-		path:             path}, nil
+	return &EFIImageLoadEventData{data: data,
+		LocationInMemory: locationInMemory,
+		LengthInMemory:   lengthInMemory,
+		LinkTimeAddress:  linkTimeAddress,
+		DevicePath:       path,
+		RawDevicePath:    devicePathBuf}, nil
 }
 
 func decodeEventDataEFIImageLoad(data []byte) (out EventData, trailingBytes int, err error) {
@@ -659,6 +879,246 @@ func decodeEventDataEFIImageLoad(data []byte) (out EventData, trailingBytes int,
 	return
 }
 
+// EFIPlatformFirmwareBlobEventData corresponds to the UEFI_PLATFORM_FIRMWARE_BLOB structure, which is the event
+// data for EV_EFI_PLATFORM_FIRMWARE_BLOB events and EV_POST_CODE events that measure a firmware blob, or the
+// UEFI_PLATFORM_FIRMWARE_BLOB2 structure, which is the event data for EV_EFI_PLATFORM_FIRMWARE_BLOB2 events.
+type EFIPlatformFirmwareBlobEventData struct {
+	data        []byte
+	hasDesc     bool
+	Description string // The blob description, only present in EV_EFI_PLATFORM_FIRMWARE_BLOB2 events
+	BlobBase    uint64 // The physical address of the blob
+	BlobLength  uint64 // The length of the blob
+}
+
+func (e *EFIPlatformFirmwareBlobEventData) String() string {
+	if e.hasDesc {
+		return fmt.Sprintf("UEFI_PLATFORM_FIRMWARE_BLOB2{ BlobDescription: \"%s\", BlobBase: 0x%016x, BlobLength: %d }",
+			e.Description, e.BlobBase, e.BlobLength)
+	}
+	return fmt.Sprintf("UEFI_PLATFORM_FIRMWARE_BLOB{ BlobBase: 0x%016x, BlobLength: %d }", e.BlobBase, e.BlobLength)
+}
+
+func (e *EFIPlatformFirmwareBlobEventData) Bytes() []byte {
+	return e.data
+}
+
+// https://trustedcomputinggroup.org/wp-content/uploads/TCG_PCClientSpecPlat_TPM_2p0_1p04_pub.pdf
+//  (section 9.2.5 "UEFI_PLATFORM_FIRMWARE_BLOB Structure")
+func decodeEventDataEFIPlatformFirmwareBlob(data []byte) (*EFIPlatformFirmwareBlobEventData, int, error) {
+	if len(data) < 16 {
+		return nil, 0, io.ErrUnexpectedEOF
+	}
+	return &EFIPlatformFirmwareBlobEventData{
+		data:       data,
+		BlobBase:   binary.LittleEndian.Uint64(data),
+		BlobLength: binary.LittleEndian.Uint64(data[8:])}, len(data) - 16, nil
+}
+
+// https://trustedcomputinggroup.org/wp-content/uploads/TCG_PCClientPlatformFirmwareProfile_r1p05_v23_pub.pdf
+//  (section 10.4.4 "UEFI_PLATFORM_FIRMWARE_BLOB2 Structure")
+func decodeEventDataEFIPlatformFirmwareBlob2(data []byte) (*EFIPlatformFirmwareBlobEventData, int, error) {
+	stream := bytes.NewReader(data)
+
+	// UEFI_PLATFORM_FIRMWARE_BLOB2.BlobDescriptionSize
+	var descSize uint8
+	if err := binary.Read(stream, binary.LittleEndian, &descSize); err != nil {
+		return nil, 0, err
+	}
+
+	// UEFI_PLATFORM_FIRMWARE_BLOB2.BlobDescription
+	if err := checkLengthField("BlobDescriptionSize", uint64(descSize), 1, stream); err != nil {
+		return nil, 0, err
+	}
+	desc := make([]byte, descSize)
+	if _, err := io.ReadFull(stream, desc); err != nil {
+		return nil, 0, err
+	}
+
+	// UEFI_PLATFORM_FIRMWARE_BLOB2.{BlobBase, BlobLength}
+	var blob struct {
+		Base   uint64
+		Length uint64
+	}
+	if err := binary.Read(stream, binary.LittleEndian, &blob.Base); err != nil {
+		return nil, 0, err
+	}
+	if err := binary.Read(stream, binary.LittleEndian, &blob.Length); err != nil {
+		return nil, 0, err
+	}
+
+	return &EFIPlatformFirmwareBlobEventData{
+		data:        data,
+		hasDesc:     true,
+		Description: string(bytes.TrimRight(desc, "\x00")),
+		BlobBase:    blob.Base,
+		BlobLength:  blob.Length}, stream.Len(), nil
+}
+
+var (
+	// EFIACPI10TableGUID is the vendor GUID of the EFI configuration table entry for ACPI 1.0 tables.
+	EFIACPI10TableGUID = NewEFIGUID(0xeb9d2d30, 0x2d88, 0x11d3, 0x9a16, [...]uint8{0x00, 0x90, 0x27, 0x3f, 0xc1, 0x4d})
+
+	// EFIACPI20TableGUID is the vendor GUID of the EFI configuration table entry for ACPI 2.0 and later tables.
+	EFIACPI20TableGUID = NewEFIGUID(0x8868e871, 0xe4f1, 0x11d3, 0xbc22, [...]uint8{0x00, 0x80, 0xc7, 0x3c, 0x88, 0x81})
+
+	// EFISMBIOSTableGUID is the vendor GUID of the EFI configuration table entry for SMBIOS tables.
+	EFISMBIOSTableGUID = NewEFIGUID(0xeb9d2d31, 0x2d88, 0x11d3, 0x9a16, [...]uint8{0x00, 0x90, 0x27, 0x3f, 0xc1, 0x4d})
+
+	// EFISMBIOS3TableGUID is the vendor GUID of the EFI configuration table entry for SMBIOS 3.0 tables.
+	EFISMBIOS3TableGUID = NewEFIGUID(0xf2fd1544, 0x9794, 0x4a2c, 0x992e, [...]uint8{0xe5, 0xbb, 0xcf, 0x20, 0xe3, 0x94})
+)
+
+var efiConfigurationTableNames = []struct {
+	guid *EFIGUID
+	name string
+}{
+	{EFIACPI10TableGUID, "ACPI 1.0"},
+	{EFIACPI20TableGUID, "ACPI 2.0"},
+	{EFISMBIOSTableGUID, "SMBIOS"},
+	{EFISMBIOS3TableGUID, "SMBIOS 3.0"},
+}
+
+// EFIConfigurationTable corresponds to the EFI_CONFIGURATION_TABLE type.
+type EFIConfigurationTable struct {
+	VendorGUID  EFIGUID
+	VendorTable uint64 // The physical address of the table
+}
+
+// Name returns a description of the table if its vendor GUID is well known, eg, "SMBIOS". If it isn't, an
+// empty string is returned.
+func (t *EFIConfigurationTable) Name() string {
+	for _, n := range efiConfigurationTableNames {
+		if *n.guid == t.VendorGUID {
+			return n.name
+		}
+	}
+	return ""
+}
+
+func (t *EFIConfigurationTable) String() string {
+	if name := t.Name(); name != "" {
+		return fmt.Sprintf("{ VendorGuid: %s (%s), VendorTable: 0x%016x }", &t.VendorGUID, name, t.VendorTable)
+	}
+	return fmt.Sprintf("{ VendorGuid: %s, VendorTable: 0x%016x }", &t.VendorGUID, t.VendorTable)
+}
+
+// EFIHandoffTablesEventData corresponds to the UEFI_HANDOFF_TABLE_POINTERS structure, which is the event data
+// for EV_EFI_HANDOFF_TABLES events, or the UEFI_HANDOFF_TABLE_POINTERS2 structure, which is the event data for
+// EV_EFI_HANDOFF_TABLES2 events.
+type EFIHandoffTablesEventData struct {
+	data        []byte
+	hasDesc     bool
+	Description string // The table description, only present in EV_EFI_HANDOFF_TABLES2 events
+	Tables      []EFIConfigurationTable
+}
+
+func (e *EFIHandoffTablesEventData) String() string {
+	var builder bytes.Buffer
+	if e.hasDesc {
+		fmt.Fprintf(&builder, "UEFI_HANDOFF_TABLE_POINTERS2{ TableDescription: \"%s\", TableEntry: [", e.Description)
+	} else {
+		fmt.Fprintf(&builder, "UEFI_HANDOFF_TABLE_POINTERS{ TableEntry: [")
+	}
+	for i, t := range e.Tables {
+		if i > 0 {
+			fmt.Fprintf(&builder, ", ")
+		}
+		fmt.Fprintf(&builder, "%s", &t)
+	}
+	fmt.Fprintf(&builder, "] }")
+	return builder.String()
+}
+
+func (e *EFIHandoffTablesEventData) Bytes() []byte {
+	return e.data
+}
+
+// decodeEFIConfigurationTables decodes the NumberOfTables and TableEntry fields that are common to the
+// UEFI_HANDOFF_TABLE_POINTERS and UEFI_HANDOFF_TABLE_POINTERS2 structures.
+func decodeEFIConfigurationTables(stream *bytes.Reader) ([]EFIConfigurationTable, error) {
+	// NumberOfTables
+	var numberOfTables uint64
+	if err := binary.Read(stream, binary.LittleEndian, &numberOfTables); err != nil {
+		return nil, err
+	}
+
+	// TableEntry[].VendorTable is a pointer, which is 8 bytes unless the event data is exactly the size
+	// required for 4 byte pointers.
+	ptrSize := 8
+	if numberOfTables > 0 && uint64(stream.Len()) == numberOfTables*uint64(efiGUIDSize+4) {
+		ptrSize = 4
+	}
+	if err := checkLengthField("NumberOfTables", numberOfTables, uint64(efiGUIDSize+ptrSize), stream); err != nil {
+		return nil, err
+	}
+
+	tables := make([]EFIConfigurationTable, numberOfTables)
+	for i := range tables {
+		t := &tables[i]
+		guid, err := readEFIGUID(stream)
+		if err != nil {
+			return nil, err
+		}
+		t.VendorGUID = guid
+		if ptrSize == 4 {
+			var ptr uint32
+			if err := binary.Read(stream, binary.LittleEndian, &ptr); err != nil {
+				return nil, err
+			}
+			t.VendorTable = uint64(ptr)
+		} else if err := binary.Read(stream, binary.LittleEndian, &t.VendorTable); err != nil {
+			return nil, err
+		}
+	}
+
+	return tables, nil
+}
+
+// https://trustedcomputinggroup.org/wp-content/uploads/TCG_PCClientSpecPlat_TPM_2p0_1p04_pub.pdf
+//  (section 9.2.6 "UEFI_HANDOFF_TABLE_POINTERS Structure")
+func decodeEventDataEFIHandoffTables(data []byte) (*EFIHandoffTablesEventData, int, error) {
+	stream := bytes.NewReader(data)
+
+	tables, err := decodeEFIConfigurationTables(stream)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return &EFIHandoffTablesEventData{data: data, Tables: tables}, stream.Len(), nil
+}
+
+// https://trustedcomputinggroup.org/wp-content/uploads/TCG_PCClientPlatformFirmwareProfile_r1p05_v23_pub.pdf
+//  (section 10.4.2 "UEFI_HANDOFF_TABLE_POINTERS2 Structure")
+func decodeEventDataEFIHandoffTables2(data []byte) (*EFIHandoffTablesEventData, int, error) {
+	stream := bytes.NewReader(data)
+
+	// UEFI_HANDOFF_TABLE_POINTERS2.TableDescriptionSize
+	var descSize uint8
+	if err := binary.Read(stream, binary.LittleEndian, &descSize); err != nil {
+		return nil, 0, err
+	}
+
+	// UEFI_HANDOFF_TABLE_POINTERS2.TableDescription
+	if err := checkLengthField("TableDescriptionSize", uint64(descSize), 1, stream); err != nil {
+		return nil, 0, err
+	}
+	desc := make([]byte, descSize)
+	if _, err := io.ReadFull(stream, desc); err != nil {
+		return nil, 0, err
+	}
+
+	tables, err := decodeEFIConfigurationTables(stream)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return &EFIHandoffTablesEventData{
+		data:        data,
+		Description: string(bytes.TrimRight(desc, "\x00")),
+		Tables:      tables,
+		hasDesc:     true}, stream.Len(), nil
+}
+
 type efiGPTPartitionEntry struct {
 	typeGUID   EFIGUID
 	uniqueGUID EFIGUID
@@ -693,6 +1153,9 @@ func (e *efiGPTEventData) Bytes() []byte {
 	return e.data
 }
 
+// efiGPTPartitionEntryMinSize is the size of the fields of a GPT partition entry that precede the partition name.
+const efiGPTPartitionEntryMinSize = 56
+
 func decodeEventDataEFIGPTImpl(data []byte) (*efiGPTEventData, int, error) {
 	stream := bytes.NewReader(data)
 
@@ -702,8 +1165,8 @@ func decodeEventDataEFIGPTImpl(data []byte) (*efiGPTEventData, int, error) {
 	}
 
 	// UEFI_GPT_DATA.UEFIPartitionHeader.DiskGUID
-	var diskGUID EFIGUID
-	if err := binary.Read(stream, binary.LittleEndian, &diskGUID); err != nil {
+	diskGUID, err := readEFIGUID(stream)
+	if err != nil {
 		return nil, 0, err
 	}
 
@@ -729,6 +1192,15 @@ func decodeEventDataEFIGPTImpl(data []byte) (*efiGPTEventData, int, error) {
 		return nil, 0, err
 	}
 
+	if numberOfParts > 0 {
+		if partEntrySize < efiGPTPartitionEntryMinSize {
+			return nil, 0, fmt.Errorf("invalid SizeOfPartitionEntry (%d)", partEntrySize)
+		}
+		if err := checkLengthField("NumberOfPartitions", numberOfParts, uint64(partEntrySize), stream); err != nil {
+			return nil, 0, err
+		}
+	}
+
 	eventData := &efiGPTEventData{diskGUID: diskGUID, partitions: make([]efiGPTPartitionEntry, numberOfParts)}
 
 	for i := uint64(0); i < numberOfParts; i++ {
@@ -739,13 +1211,13 @@ func decodeEventDataEFIGPTImpl(data []byte) (*efiGPTEventData, int, error) {
 
 		entryStream := bytes.NewReader(entryData)
 
-		var typeGUID EFIGUID
-		if err := binary.Read(entryStream, binary.LittleEndian, &typeGUID); err != nil {
+		typeGUID, err := readEFIGUID(entryStream)
+		if err != nil {
 			return nil, 0, err
 		}
 
-		var uniqueGUID EFIGUID
-		if err := binary.Read(entryStream, binary.LittleEndian, &uniqueGUID); err != nil {
+		uniqueGUID, err := readEFIGUID(entryStream)
+		if err != nil {
 			return nil, 0, err
 		}
 
@@ -755,7 +1227,7 @@ func decodeEventDataEFIGPTImpl(data []byte) (*efiGPTEventData, int, error) {
 		}
 
 		nameUtf16 := make([]uint16, entryStream.Len()/2)
-		if err := binary.Read(entryStream, binary.LittleEndian, &nameUtf16); err != nil {
+		if err := binary.Read(entryStream, binary.LittleEndian, nameUtf16); err != nil {
 			return nil, 0, err
 		}
 
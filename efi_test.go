@@ -2,6 +2,11 @@ package tcglog
 
 import (
 	"bytes"
+	"context"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"io"
 	"testing"
 )
 
@@ -14,8 +19,8 @@ func TestEFIVariableEventDataEncode(t *testing.T) {
 		{
 			desc: "db",
 			in: EFIVariableEventData{
-				VariableName: EFIGUID{0xd719b2cb, 0x3d3a, 0x4596, 0xa3bc,
-					[...]uint8{0xda, 0xd0, 0x0e, 0x67, 0x65, 0x6f}},
+				VariableName: *NewEFIGUID(0xd719b2cb, 0x3d3a, 0x4596, 0xa3bc,
+					[...]uint8{0xda, 0xd0, 0x0e, 0x67, 0x65, 0x6f}),
 				UnicodeName:  "db",
 				VariableData: []byte("foo")},
 			out: []byte{0xcb, 0xb2, 0x19, 0xd7, 0x3a, 0x3d, 0x96, 0x45, 0xa3, 0xbc, 0xda, 0xd0, 0x0e,
@@ -25,8 +30,8 @@ func TestEFIVariableEventDataEncode(t *testing.T) {
 		{
 			desc: "dbx",
 			in: EFIVariableEventData{
-				VariableName: EFIGUID{0xd719b2cb, 0x3d3a, 0x4596, 0xa3bc,
-					[...]uint8{0xda, 0xd0, 0x0e, 0x67, 0x65, 0x6f}},
+				VariableName: *NewEFIGUID(0xd719b2cb, 0x3d3a, 0x4596, 0xa3bc,
+					[...]uint8{0xda, 0xd0, 0x0e, 0x67, 0x65, 0x6f}),
 				UnicodeName:  "dbx",
 				VariableData: []byte("bar")},
 			out: []byte{0xcb, 0xb2, 0x19, 0xd7, 0x3a, 0x3d, 0x96, 0x45, 0xa3, 0xbc, 0xda, 0xd0, 0x0e,
@@ -46,3 +51,412 @@ func TestEFIVariableEventDataEncode(t *testing.T) {
 		})
 	}
 }
+
+func TestDecodeDevicePath(t *testing.T) {
+	var data bytes.Buffer
+	// PciRoot(0x0)
+	data.Write([]byte{0x02, 0x01, 0x0c, 0x00, 0xd0, 0x41, 0x03, 0x0a, 0x00, 0x00, 0x00, 0x00})
+	// Pci(0x1d,0x0)
+	data.Write([]byte{0x01, 0x01, 0x06, 0x00, 0x00, 0x1d})
+	// HD(1,GPT,...)
+	data.Write([]byte{0x04, 0x01, 0x2a, 0x00, 0x01, 0x00, 0x00, 0x00, 0x00, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00,
+		0x00, 0x00, 0x00, 0x10, 0x00, 0x00, 0x00, 0x00, 0x00, 0xcb, 0xb2, 0x19, 0xd7, 0x3a, 0x3d, 0x96, 0x45,
+		0xa3, 0xbc, 0xda, 0xd0, 0x0e, 0x67, 0x65, 0x6f, 0x02, 0x02})
+	// \EFI\a.efi
+	data.Write([]byte{0x04, 0x04, 0x1a, 0x00, '\\', 0, 'E', 0, 'F', 0, 'I', 0, '\\', 0, 'a', 0, '.', 0, 'e', 0,
+		'f', 0, 'i', 0, 0, 0})
+	// End
+	data.Write([]byte{0x7f, 0xff, 0x04, 0x00})
+
+	path, err := decodeDevicePath(data.Bytes())
+	if err != nil {
+		t.Fatalf("decodeDevicePath failed: %v", err)
+	}
+	if len(path) != 4 {
+		t.Fatalf("Unexpected number of nodes (%d)", len(path))
+	}
+
+	expected := "\\PciRoot(0x0)\\Pci(0x1d,0x0)\\HD(1,GPT,{d719b2cb-3d3a-4596-a3bc-dad00e67656f},0x0000000000000800, " +
+		"0x0000000000100000)\\EFI\\a.efi"
+	if path.String() != expected {
+		t.Errorf("Unexpected path string: %q", path.String())
+	}
+
+	expectedText := "PciRoot(0x0)/Pci(0x1d,0x0)/HD(1,GPT,d719b2cb-3d3a-4596-a3bc-dad00e67656f,0x800,0x100000)/" +
+		"\\EFI\\a.efi"
+	if path.Text() != expectedText {
+		t.Errorf("Unexpected path text: %q", path.Text())
+	}
+
+	hd := path.HardDrive()
+	if hd == nil {
+		t.Fatalf("Missing hard drive node")
+	}
+	if hd.PartitionNumber != 1 || hd.PartitionGUID().String() != "{d719b2cb-3d3a-4596-a3bc-dad00e67656f}" {
+		t.Errorf("Unexpected hard drive node: %s", hd)
+	}
+	if path.FilePath() != "\\EFI\\a.efi" {
+		t.Errorf("Unexpected file path: %q", path.FilePath())
+	}
+}
+
+func TestReadEFIGUID(t *testing.T) {
+	var data bytes.Buffer
+	binary.Write(&data, binary.LittleEndian, *EFIGlobalVariableGUID)
+
+	guid, err := readEFIGUID(bytes.NewReader(data.Bytes()))
+	if err != nil {
+		t.Fatalf("readEFIGUID failed: %v", err)
+	}
+	if guid != *EFIGlobalVariableGUID {
+		t.Errorf("Unexpected GUID (%s)", &guid)
+	}
+
+	if _, err := readEFIGUID(bytes.NewReader(data.Bytes()[:10])); err != io.ErrUnexpectedEOF {
+		t.Errorf("Unexpected error for truncated data (%v)", err)
+	}
+}
+
+func TestDecodeEFISignatureDatabase(t *testing.T) {
+	var data bytes.Buffer
+	binary.Write(&data, binary.LittleEndian, *EFICertSHA256GUID)
+	binary.Write(&data, binary.LittleEndian, uint32(28+2*48))
+	binary.Write(&data, binary.LittleEndian, uint32(0))
+	binary.Write(&data, binary.LittleEndian, uint32(48))
+	for i := 0; i < 2; i++ {
+		binary.Write(&data, binary.LittleEndian, *EFIGlobalVariableGUID)
+		data.Write(bytes.Repeat([]byte{byte(i)}, 32))
+	}
+
+	lists, err := DecodeEFISignatureDatabase(data.Bytes())
+	if err != nil {
+		t.Fatalf("DecodeEFISignatureDatabase failed: %v", err)
+	}
+	if len(lists) != 1 {
+		t.Fatalf("Unexpected number of lists (%d)", len(lists))
+	}
+	if alg, ok := lists[0].DigestAlgorithm(); !ok || alg != AlgorithmSha256 {
+		t.Errorf("Unexpected digest algorithm")
+	}
+	if len(lists[0].Signatures) != 2 {
+		t.Fatalf("Unexpected number of signatures (%d)", len(lists[0].Signatures))
+	}
+	for i, s := range lists[0].Signatures {
+		if s.SignatureOwner != *EFIGlobalVariableGUID || !bytes.Equal(s.Data, bytes.Repeat([]byte{byte(i)}, 32)) {
+			t.Errorf("Unexpected signature %d", i)
+		}
+	}
+
+	if _, err := DecodeEFISignatureDatabase(data.Bytes()[:data.Len()-1]); err == nil {
+		t.Errorf("DecodeEFISignatureDatabase should fail for truncated data")
+	}
+}
+
+func TestDecodeEventDataEFIHandoffTables(t *testing.T) {
+	var data bytes.Buffer
+	binary.Write(&data, binary.LittleEndian, uint64(2))
+	binary.Write(&data, binary.LittleEndian, *EFISMBIOS3TableGUID)
+	binary.Write(&data, binary.LittleEndian, uint64(0x7a3f0000))
+	unknown := NewEFIGUID(0x12345678, 0x1234, 0x5678, 0x9abc, [...]uint8{0xde, 0xf0, 0x12, 0x34, 0x56, 0x78})
+	binary.Write(&data, binary.LittleEndian, *unknown)
+	binary.Write(&data, binary.LittleEndian, uint64(0x7a400000))
+
+	out, trailing, err := decodeEventDataTCG(EventTypeEFIHandoffTables, data.Bytes(), false)
+	if err != nil {
+		t.Fatalf("decodeEventDataTCG failed: %v", err)
+	}
+	if trailing != 0 {
+		t.Errorf("Unexpected trailing bytes %d", trailing)
+	}
+	d, ok := out.(*EFIHandoffTablesEventData)
+	if !ok {
+		t.Fatalf("Unexpected event data type %T", out)
+	}
+	if len(d.Tables) != 2 {
+		t.Fatalf("Unexpected number of tables %d", len(d.Tables))
+	}
+	if d.Tables[0].VendorGUID != *EFISMBIOS3TableGUID || d.Tables[0].VendorTable != 0x7a3f0000 ||
+		d.Tables[0].Name() != "SMBIOS 3.0" {
+		t.Errorf("Unexpected table %s", &d.Tables[0])
+	}
+	if d.Tables[1].VendorGUID != *unknown || d.Tables[1].VendorTable != 0x7a400000 || d.Tables[1].Name() != "" {
+		t.Errorf("Unexpected table %s", &d.Tables[1])
+	}
+
+	var truncated bytes.Buffer
+	binary.Write(&truncated, binary.LittleEndian, uint64(1000))
+	if _, _, err := decodeEventDataTCG(EventTypeEFIHandoffTables, truncated.Bytes(), false); err == nil {
+		t.Errorf("decodeEventDataTCG should have failed for truncated data")
+	}
+}
+
+func TestDecodeEventDataEFIHandoffTables2(t *testing.T) {
+	var data bytes.Buffer
+	desc := "SMBIOS\x00"
+	data.WriteByte(uint8(len(desc)))
+	data.WriteString(desc)
+	binary.Write(&data, binary.LittleEndian, uint64(1))
+	binary.Write(&data, binary.LittleEndian, *EFISMBIOS3TableGUID)
+	binary.Write(&data, binary.LittleEndian, uint64(0x7a3f0000))
+
+	out, _, err := decodeEventDataTCG(EventTypeEFIHandoffTables2, data.Bytes(), false)
+	if err != nil {
+		t.Fatalf("decodeEventDataTCG failed: %v", err)
+	}
+	d, ok := out.(*EFIHandoffTablesEventData)
+	if !ok {
+		t.Fatalf("Unexpected event data type %T", out)
+	}
+	if d.Description != "SMBIOS" {
+		t.Errorf("Unexpected description %q", d.Description)
+	}
+	if len(d.Tables) != 1 || d.Tables[0].VendorGUID != *EFISMBIOS3TableGUID || d.Tables[0].VendorTable != 0x7a3f0000 {
+		t.Errorf("Unexpected tables %v", d.Tables)
+	}
+	if d.String() != "UEFI_HANDOFF_TABLE_POINTERS2{ TableDescription: \"SMBIOS\", TableEntry: [{ VendorGuid: "+
+		"{f2fd1544-9794-4a2c-992e-e5bbcf20e394} (SMBIOS 3.0), VendorTable: 0x000000007a3f0000 }] }" {
+		t.Errorf("Unexpected string %s", d)
+	}
+}
+
+func TestDecodeEventDataEFIPlatformFirmwareBlob(t *testing.T) {
+	var blob bytes.Buffer
+	binary.Write(&blob, binary.LittleEndian, uint64(0xff000000))
+	binary.Write(&blob, binary.LittleEndian, uint64(0x1000000))
+
+	var blob2 bytes.Buffer
+	desc := "Fv(8c8ce578-8a3d-4f1c-9935-896185c32dd3)"
+	blob2.WriteByte(uint8(len(desc)))
+	blob2.WriteString(desc)
+	blob2.Write(blob.Bytes())
+
+	for _, data := range []struct {
+		desc      string
+		eventType EventType
+		data      []byte
+		descr     string
+		str       string
+	}{
+		{
+			desc:      "blob",
+			eventType: EventTypeEFIPlatformFirmwareBlob,
+			data:      blob.Bytes(),
+			str:       "UEFI_PLATFORM_FIRMWARE_BLOB{ BlobBase: 0x00000000ff000000, BlobLength: 16777216 }",
+		},
+		{
+			desc:      "blob2",
+			eventType: EventTypeEFIPlatformFirmwareBlob2,
+			data:      blob2.Bytes(),
+			descr:     desc,
+			str: "UEFI_PLATFORM_FIRMWARE_BLOB2{ BlobDescription: \"Fv(8c8ce578-8a3d-4f1c-9935-896185c32dd3)\", " +
+				"BlobBase: 0x00000000ff000000, BlobLength: 16777216 }",
+		},
+	} {
+		t.Run(data.desc, func(t *testing.T) {
+			out, _, err := decodeEventDataTCG(data.eventType, data.data, false)
+			if err != nil {
+				t.Fatalf("decodeEventDataTCG failed: %v", err)
+			}
+			d, ok := out.(*EFIPlatformFirmwareBlobEventData)
+			if !ok {
+				t.Fatalf("Unexpected event data type %T", out)
+			}
+			if d.Description != data.descr {
+				t.Errorf("Unexpected description %q", d.Description)
+			}
+			if d.BlobBase != 0xff000000 || d.BlobLength != 0x1000000 {
+				t.Errorf("Unexpected blob (base: 0x%x, length: %d)", d.BlobBase, d.BlobLength)
+			}
+			if d.String() != data.str {
+				t.Errorf("Unexpected string %s", d)
+			}
+		})
+	}
+}
+
+func decodeHexString(t *testing.T, s string) []byte {
+	b, err := hex.DecodeString(s)
+	if err != nil {
+		t.Fatalf("DecodeString failed: %v", err)
+	}
+	return b
+}
+
+func TestValidateEFIVariableBoot2(t *testing.T) {
+	// The UEFI_VARIABLE_DATA structure for a BootOrder variable containing a single entry, and the SHA-256 digest
+	// of the entire structure, which is what firmware such as edk2 measures for EV_EFI_VARIABLE_BOOT2 events.
+	bootOrderData := decodeHexString(t, "61dfe48bca93d211aa0d00e098032b8c0900000000000000020000000000000042006f006f0074"+
+		"004f0072006400650072000100")
+	bootOrderDigest := decodeHexString(t, "4e93b6abf5532ff7a4da93769c41874f62cef02a9abc60b6baa62227762e5964")
+	// The SHA-256 digest of the variable data alone.
+	varDataDigest := decodeHexString(t, "47dc540c94ceb704a23875c11273e16bb0b8a87aed84de911f2133568115f254")
+
+	var buf bytes.Buffer
+	w, err := NewLogWriter(&buf, SpecEFI_2, AlgorithmIdList{AlgorithmSha256})
+	if err != nil {
+		t.Fatalf("NewLogWriter failed: %v", err)
+	}
+	if err := w.WriteSpecIdEvent(0, nil); err != nil {
+		t.Fatalf("WriteSpecIdEvent failed: %v", err)
+	}
+	// An EV_EFI_VARIABLE_BOOT event that only measures the variable data, which some firmware does, followed
+	// by EV_EFI_VARIABLE_BOOT2 events that measure the entire structure and only the variable data.
+	for _, e := range []struct {
+		eventType EventType
+		digest    Digest
+	}{
+		{EventTypeEFIVariableBoot, varDataDigest},
+		{EventTypeEFIVariableBoot2, bootOrderDigest},
+		{EventTypeEFIVariableBoot2, varDataDigest},
+	} {
+		if err := w.WriteEvent(&Event{
+			PCRIndex:  1,
+			EventType: e.eventType,
+			Digests:   DigestMap{AlgorithmSha256: e.digest},
+			Data:      &opaqueEventData{data: bootOrderData}}); err != nil {
+			t.Fatalf("WriteEvent failed: %v", err)
+		}
+	}
+
+	log, err := NewLog(bytes.NewReader(buf.Bytes()), LogOptions{})
+	if err != nil {
+		t.Fatalf("NewLog failed: %v", err)
+	}
+	result, err := replayAndValidateLog(context.Background(), log, int64(buf.Len()), LogValidateOptions{})
+	if err != nil {
+		t.Fatalf("replayAndValidateLog failed: %v", err)
+	}
+	if len(result.ValidatedEvents) != 4 {
+		t.Fatalf("Unexpected number of events: %d", len(result.ValidatedEvents))
+	}
+	if result.EfiBootVariableBehaviour != EFIBootVariableBehaviourVarDataOnly {
+		t.Errorf("Unexpected EV_EFI_VARIABLE_BOOT behaviour")
+	}
+	if len(result.ValidatedEvents[1].IncorrectDigestValues) > 0 {
+		t.Errorf("Unexpected incorrect digest for EV_EFI_VARIABLE_BOOT event")
+	}
+
+	// The EV_EFI_VARIABLE_BOOT quirk doesn't apply to EV_EFI_VARIABLE_BOOT2 events.
+	e := result.ValidatedEvents[2]
+	if e.Event.EventType.String() != "EV_EFI_VARIABLE_BOOT2" {
+		t.Errorf("Unexpected event type %s", e.Event.EventType)
+	}
+	d, ok := e.Event.Data.(*EFIVariableEventData)
+	if !ok || d.UnicodeName != "BootOrder" || !bytes.Equal(d.VariableData, []byte{0x01, 0x00}) {
+		t.Fatalf("Unexpected event data: %s", e.Event.Data)
+	}
+	if !bytes.Equal(e.MeasuredBytes, bootOrderData) || len(e.IncorrectDigestValues) > 0 {
+		t.Errorf("Unexpected measured bytes: %x", e.MeasuredBytes)
+	}
+
+	e = result.ValidatedEvents[3]
+	if len(e.IncorrectDigestValues) != 1 || !bytes.Equal(e.IncorrectDigestValues[0].Expected, bootOrderDigest) {
+		t.Errorf("EV_EFI_VARIABLE_BOOT2 event that only measures the variable data should have an incorrect digest")
+	}
+}
+
+func TestDecodeEventDataHostileLengths(t *testing.T) {
+	u64 := func(v uint64) []byte {
+		var b [8]byte
+		binary.LittleEndian.PutUint64(b[:], v)
+		return b[:]
+	}
+	gptHeader := func(partEntrySize uint32, numberOfParts uint64) []byte {
+		var b bytes.Buffer
+		b.Write(make([]byte, 56+efiGUIDSize+12))
+		binary.Write(&b, binary.LittleEndian, partEntrySize)
+		b.Write(make([]byte, 4))
+		b.Write(u64(numberOfParts))
+		return b.Bytes()
+	}
+
+	// These inputs previously caused the decoders to allocate memory based on untrusted lengths.
+	for _, data := range []struct {
+		desc      string
+		pcr       PCRIndex
+		eventType EventType
+		data      []byte
+		limit     string
+	}{
+		{
+			desc:      "image load device path length",
+			pcr:       4,
+			eventType: EventTypeEFIBootServicesApplication,
+			data:      append(make([]byte, 24), u64(0xffffffffffffffff)...),
+			limit:     "LengthOfDevicePath",
+		},
+		{
+			desc:      "GPT number of partitions",
+			pcr:       5,
+			eventType: EventTypeEFIGPTEvent,
+			data:      append(gptHeader(128, 0x100000000), make([]byte, 128)...),
+			limit:     "NumberOfPartitions",
+		},
+		{
+			desc:      "GPT zero sized partition entries",
+			pcr:       5,
+			eventType: EventTypeEFIGPTEvent,
+			data:      gptHeader(0, 0x10000000000),
+		},
+		{
+			desc:      "variable name length",
+			pcr:       7,
+			eventType: EventTypeEFIVariableDriverConfig,
+			data:      append(append(make([]byte, efiGUIDSize), u64(0x4000000000000000)...), u64(0)...),
+			limit:     "UnicodeNameLength",
+		},
+		{
+			desc:      "variable data length",
+			pcr:       7,
+			eventType: EventTypeEFIVariableDriverConfig,
+			data:      append(append(make([]byte, efiGUIDSize), u64(0)...), u64(0xffffffffffffffff)...),
+			limit:     "VariableDataLength",
+		},
+		{
+			desc:      "handoff tables count",
+			pcr:       1,
+			eventType: EventTypeEFIHandoffTables,
+			data:      append(u64(0x1000000000), make([]byte, 24)...),
+			limit:     "NumberOfTables",
+		},
+	} {
+		t.Run(data.desc, func(t *testing.T) {
+			out, _ := decodeEventData(data.pcr, data.eventType, data.data, &LogOptions{}, false)
+			d, ok := out.(*BrokenEventData)
+			if !ok {
+				t.Fatalf("Unexpected event data %#v", out)
+			}
+			if data.limit == "" {
+				return
+			}
+			var limitErr *LimitExceededError
+			if !errors.As(d.Error, &limitErr) || limitErr.Limit != data.limit {
+				t.Errorf("Unexpected error: %v", d.Error)
+			}
+		})
+	}
+}
+
+func TestDecodeEventDataEFIGPTNoPartitions(t *testing.T) {
+	// A header with no partitions and a SizeOfPartitionEntry of zero previously caused a divide by zero.
+	out, trailing, err := decodeEventDataEFIGPT(make([]byte, 100))
+	if err != nil {
+		t.Fatalf("decodeEventDataEFIGPT failed: %v", err)
+	}
+	d, ok := out.(*efiGPTEventData)
+	if !ok {
+		t.Fatalf("Unexpected event data %#v", out)
+	}
+	if len(d.partitions) != 0 {
+		t.Errorf("Unexpected partitions")
+	}
+	if trailing != 0 {
+		t.Errorf("Unexpected trailing bytes (%d)", trailing)
+	}
+}
+
+func TestCheckLengthFieldZeroSize(t *testing.T) {
+	if err := checkLengthField("foo", 0, 0, bytes.NewReader(nil)); err == nil {
+		t.Errorf("Expected an error")
+	}
+}
@@ -0,0 +1,153 @@
+package tcglog
+
+import (
+	"bytes"
+	"crypto/x509"
+	"encoding/binary"
+	"fmt"
+	"io"
+)
+
+var (
+	// EFICertX509GUID is the signature type of EFI_SIGNATURE_LIST structures that contain DER encoded X.509
+	// certificates.
+	EFICertX509GUID = NewEFIGUID(0xa5c059a1, 0x94e4, 0x4aa7, 0x87b5, [...]uint8{0xab, 0x15, 0x5c, 0x2b, 0xf0, 0x72})
+
+	// EFICertSHA1GUID is the signature type of EFI_SIGNATURE_LIST structures that contain SHA-1 digests.
+	EFICertSHA1GUID = NewEFIGUID(0x826ca512, 0xcf10, 0x4ac9, 0xb187, [...]uint8{0xbe, 0x01, 0x49, 0x66, 0x31, 0xbd})
+
+	// EFICertSHA256GUID is the signature type of EFI_SIGNATURE_LIST structures that contain SHA-256 digests.
+	EFICertSHA256GUID = NewEFIGUID(0xc1c41626, 0x504c, 0x4092, 0xaca9, [...]uint8{0x41, 0xf9, 0x36, 0x93, 0x43, 0x28})
+
+	// EFICertSHA384GUID is the signature type of EFI_SIGNATURE_LIST structures that contain SHA-384 digests.
+	EFICertSHA384GUID = NewEFIGUID(0xff3e5307, 0x9fd0, 0x48c9, 0x85f1, [...]uint8{0x8a, 0xd5, 0x6c, 0x70, 0x1e, 0x01})
+
+	// EFICertSHA512GUID is the signature type of EFI_SIGNATURE_LIST structures that contain SHA-512 digests.
+	EFICertSHA512GUID = NewEFIGUID(0x093e0fae, 0xa6c4, 0x4f50, 0x9f1b, [...]uint8{0xd4, 0x1e, 0x2b, 0x89, 0xc1, 0x9a})
+)
+
+// EFISignatureData corresponds to the EFI_SIGNATURE_DATA type.
+type EFISignatureData struct {
+	SignatureOwner EFIGUID
+	Data           []byte // The certificate or digest, depending on the type of the containing list
+}
+
+// ParseCertificate parses the signature data as a DER encoded X.509 certificate. This is only meaningful for
+// signatures in a list with the type EFICertX509GUID. If cache is not nil, parsed certificates are cached so
+// that the same certificate isn't parsed repeatedly when it appears in many logs.
+func (d *EFISignatureData) ParseCertificate(cache VerificationCache) (*x509.Certificate, error) {
+	cert, err := cachedVerify(cache, CacheKindCertificate, d.Data, func() (interface{}, error) {
+		return x509.ParseCertificate(d.Data)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return cert.(*x509.Certificate), nil
+}
+
+// EFISignatureList corresponds to the EFI_SIGNATURE_LIST type.
+type EFISignatureList struct {
+	SignatureType   EFIGUID
+	SignatureHeader []byte
+	Signatures      []*EFISignatureData
+}
+
+// IsX509 indicates whether this list contains X.509 certificates.
+func (l *EFISignatureList) IsX509() bool {
+	return l.SignatureType == *EFICertX509GUID
+}
+
+// DigestAlgorithm returns the algorithm of the digests contained in this list, and false if the list doesn't
+// contain digests.
+func (l *EFISignatureList) DigestAlgorithm() (AlgorithmId, bool) {
+	switch l.SignatureType {
+	case *EFICertSHA1GUID:
+		return AlgorithmSha1, true
+	case *EFICertSHA256GUID:
+		return AlgorithmSha256, true
+	case *EFICertSHA384GUID:
+		return AlgorithmSha384, true
+	case *EFICertSHA512GUID:
+		return AlgorithmSha512, true
+	default:
+		return 0, false
+	}
+}
+
+func decodeEFISignatureList(stream *bytes.Reader) (*EFISignatureList, error) {
+	const hdrSize = 28
+
+	var b [hdrSize]byte
+	if _, err := io.ReadFull(stream, b[:]); err != nil {
+		return nil, err
+	}
+	hdr := struct {
+		SignatureType       EFIGUID
+		SignatureListSize   uint32
+		SignatureHeaderSize uint32
+		SignatureSize       uint32
+	}{
+		SignatureType:       decodeEFIGUID(b[:]),
+		SignatureListSize:   binary.LittleEndian.Uint32(b[16:]),
+		SignatureHeaderSize: binary.LittleEndian.Uint32(b[20:]),
+		SignatureSize:       binary.LittleEndian.Uint32(b[24:])}
+	if hdr.SignatureListSize < hdrSize || int64(hdr.SignatureListSize-hdrSize) > int64(stream.Len()) {
+		return nil, fmt.Errorf("invalid SignatureListSize (%d)", hdr.SignatureListSize)
+	}
+	if hdr.SignatureHeaderSize > hdr.SignatureListSize-hdrSize {
+		return nil, fmt.Errorf("invalid SignatureHeaderSize (%d)", hdr.SignatureHeaderSize)
+	}
+	signaturesSize := hdr.SignatureListSize - hdrSize - hdr.SignatureHeaderSize
+	if hdr.SignatureSize < 16 || signaturesSize%hdr.SignatureSize != 0 {
+		return nil, fmt.Errorf("invalid SignatureSize (%d)", hdr.SignatureSize)
+	}
+
+	l := &EFISignatureList{
+		SignatureType:   hdr.SignatureType,
+		SignatureHeader: make([]byte, hdr.SignatureHeaderSize)}
+	if _, err := io.ReadFull(stream, l.SignatureHeader); err != nil {
+		return nil, err
+	}
+
+	for i := uint32(0); i < signaturesSize/hdr.SignatureSize; i++ {
+		d := &EFISignatureData{Data: make([]byte, hdr.SignatureSize-16)}
+		owner, err := readEFIGUID(stream)
+		if err != nil {
+			return nil, err
+		}
+		d.SignatureOwner = owner
+		if _, err := io.ReadFull(stream, d.Data); err != nil {
+			return nil, err
+		}
+		l.Signatures = append(l.Signatures, d)
+	}
+
+	return l, nil
+}
+
+// DecodeEFISignatureDatabase decodes the supplied data as a sequence of EFI_SIGNATURE_LIST structures, which is
+// the format of the PK, KEK, db and dbx variables.
+func DecodeEFISignatureDatabase(data []byte) ([]*EFISignatureList, error) {
+	stream := bytes.NewReader(data)
+
+	var out []*EFISignatureList
+	for stream.Len() > 0 {
+		l, err := decodeEFISignatureList(stream)
+		if err != nil {
+			return nil, fmt.Errorf("cannot decode EFI_SIGNATURE_LIST %d: %v", len(out), err)
+		}
+		out = append(out, l)
+	}
+	return out, nil
+}
+
+func isEFISignatureDatabaseVariable(guid *EFIGUID, name string) bool {
+	switch {
+	case *guid == *EFIGlobalVariableGUID:
+		return name == "PK" || name == "KEK"
+	case *guid == *EFIImageSecurityDatabaseGUID:
+		return name == "db" || name == "dbx" || name == "dbt" || name == "dbr"
+	default:
+		return false
+	}
+}
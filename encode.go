@@ -0,0 +1,110 @@
+package tcglog
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+)
+
+// https://trustedcomputinggroup.org/wp-content/uploads/TCG_PCClientImplementation_1-21_1_00.pdf
+//  (section 11.1.1 "TCG_PCClientPCREventStruct Structure")
+func writeEvent_1_2(w io.Writer, pcrIndex PCRIndex, eventType EventType, digest Digest, data []byte) error {
+	if len(digest) != AlgorithmSha1.size() {
+		return fmt.Errorf("invalid digest size (got %d, expected %d)", len(digest), AlgorithmSha1.size())
+	}
+	if err := binary.Write(w, binary.LittleEndian, eventHeader_1_2{PCRIndex: pcrIndex, EventType: eventType}); err != nil {
+		return err
+	}
+	if _, err := w.Write(digest); err != nil {
+		return err
+	}
+	if err := binary.Write(w, binary.LittleEndian, uint32(len(data))); err != nil {
+		return err
+	}
+	_, err := w.Write(data)
+	return err
+}
+
+// https://trustedcomputinggroup.org/wp-content/uploads/TCG_PCClientSpecPlat_TPM_2p0_1p04_pub.pdf
+//  (section 9.2.2 "TCG_PCR_EVENT2 Structure")
+func writeEvent_2(w io.Writer, pcrIndex PCRIndex, eventType EventType, digests DigestMap,
+	algSizes []EFISpecIdEventAlgorithmSize, data []byte) error {
+	header := eventHeader_2{PCRIndex: pcrIndex, EventType: eventType, Count: uint32(len(algSizes))}
+	if err := binary.Write(w, binary.LittleEndian, header); err != nil {
+		return err
+	}
+	for _, s := range algSizes {
+		digest, ok := digests[s.AlgorithmId]
+		if !ok {
+			return fmt.Errorf("missing digest for algorithm %s", s.AlgorithmId)
+		}
+		if len(digest) != int(s.DigestSize) {
+			return fmt.Errorf("invalid digest size for algorithm %s (got %d, expected %d)", s.AlgorithmId,
+				len(digest), s.DigestSize)
+		}
+		if err := binary.Write(w, binary.LittleEndian, s.AlgorithmId); err != nil {
+			return err
+		}
+		if _, err := w.Write(digest); err != nil {
+			return err
+		}
+	}
+	if err := binary.Write(w, binary.LittleEndian, uint32(len(data))); err != nil {
+		return err
+	}
+	_, err := w.Write(data)
+	return err
+}
+
+// https://trustedcomputinggroup.org/wp-content/uploads/TCG_PCClientImplementation_1-21_1_00.pdf
+//  (section 11.3.4.1 "Specification Event")
+// https://trustedcomputinggroup.org/wp-content/uploads/TCG_EFI_Platform_1_22_Final_-v15.pdf
+//  (section 7.4 "EV_NO_ACTION Event Types")
+// https://trustedcomputinggroup.org/wp-content/uploads/TCG_PCClientSpecPlat_TPM_2p0_1p04_pub.pdf
+//  (secion 9.4.5.1 "Specification ID Version Event")
+func encodeSpecIdEvent(spec Spec, algSizes []EFISpecIdEventAlgorithmSize, platformClass uint32, vendorInfo []byte) ([]byte, error) {
+	if len(vendorInfo) > 0xff {
+		return nil, fmt.Errorf("vendor info is too large (%d bytes)", len(vendorInfo))
+	}
+
+	var buf bytes.Buffer
+	switch spec {
+	case SpecPCClient:
+		buf.WriteString("Spec ID Event00\x00")
+		binary.Write(&buf, binary.LittleEndian, specIdEventCommon{
+			PlatformClass:    platformClass,
+			SpecVersionMinor: 2,
+			SpecVersionMajor: 1,
+			SpecErrata:       1,
+			UintnSize:        1})
+	case SpecEFI_1_2:
+		buf.WriteString("Spec ID Event02\x00")
+		binary.Write(&buf, binary.LittleEndian, specIdEventCommon{
+			PlatformClass:    platformClass,
+			SpecVersionMinor: 2,
+			SpecVersionMajor: 1,
+			SpecErrata:       2,
+			UintnSize:        2})
+	case SpecEFI_2:
+		buf.WriteString("Spec ID Event03\x00")
+		binary.Write(&buf, binary.LittleEndian, specIdEventCommon{
+			PlatformClass:    platformClass,
+			SpecVersionMajor: 2,
+			UintnSize:        2})
+		binary.Write(&buf, binary.LittleEndian, uint32(len(algSizes)))
+		binary.Write(&buf, binary.LittleEndian, algSizes)
+	default:
+		return nil, fmt.Errorf("cannot encode a Spec ID event for spec %d", spec)
+	}
+	buf.WriteByte(uint8(len(vendorInfo)))
+	buf.Write(vendorInfo)
+	return buf.Bytes(), nil
+}
+
+func algSizesForAlgorithms(algorithms AlgorithmIdList) (out []EFISpecIdEventAlgorithmSize) {
+	for _, alg := range algorithms {
+		out = append(out, EFISpecIdEventAlgorithmSize{AlgorithmId: alg, DigestSize: uint16(alg.size())})
+	}
+	return
+}
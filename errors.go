@@ -0,0 +1,114 @@
+package tcglog
+
+import (
+	"fmt"
+	"io"
+)
+
+// ErrUnexpectedEOF is the cause of a LogReadError when a log ends in the middle of an event. It is the same
+// value as io.ErrUnexpectedEOF, so either can be used with errors.Is.
+var ErrUnexpectedEOF = io.ErrUnexpectedEOF
+
+// LogReadError is returned when an event can't be read from a log because of an error from the underlying
+// reader, or because the log is truncated.
+type LogReadError struct {
+	Err error // The underlying error
+}
+
+func (e *LogReadError) Error() string {
+	return fmt.Sprintf("error when reading from log stream (%v)", e.Err)
+}
+
+func (e *LogReadError) Unwrap() error {
+	return e.Err
+}
+
+// PCRIndexOutOfRangeError is returned when an event is associated with a PCR index that is out of range.
+type PCRIndexOutOfRangeError struct {
+	PCRIndex PCRIndex
+}
+
+func (e *PCRIndexOutOfRangeError) Error() string {
+	return fmt.Sprintf("log entry has an out-of-range PCR index (%d)", e.PCRIndex)
+}
+
+// UnknownAlgorithmError is returned when an event in a crypto-agile log contains a digest for an algorithm that
+// isn't described by the Spec ID event. The digest can't be skipped because its size isn't known, so this is a
+// hard error even in permissive mode. It is also used for algorithms that are described by the Spec ID event but
+// aren't supported by this package, which is only an error if LogOptions.Strict is set and
+// LogOptions.PreserveUnknownDigests is not.
+type UnknownAlgorithmError struct {
+	Algorithm AlgorithmId
+}
+
+func (e *UnknownAlgorithmError) Error() string {
+	return fmt.Sprintf("crypto-agile log entry contains a digest for an unrecognized algorithm (%s)", e.Algorithm)
+}
+
+// DigestSizeMismatchError is returned when the Spec ID event of a crypto-agile log declares a digest size for a
+// supported algorithm that doesn't match the size of digests produced by that algorithm. Digests for the
+// algorithm are skipped, and this is only an error if LogOptions.Strict is set.
+type DigestSizeMismatchError struct {
+	Algorithm  AlgorithmId
+	DigestSize uint16 // The size declared in the Spec ID event
+}
+
+func (e *DigestSizeMismatchError) Error() string {
+	return fmt.Sprintf("Spec ID event declares a digest size of %d for algorithm %s, which produces digests "+
+		"of %d bytes", e.DigestSize, e.Algorithm, e.Algorithm.size())
+}
+
+// MissingDigestError is returned when an event in a crypto-agile log doesn't contain a digest for an algorithm
+// that is described by the Spec ID event. This is only an error if LogOptions.Strict is set, but it is also
+// returned by Log.ReplayPCRs for such an event because the PCR value in that bank can't be computed.
+type MissingDigestError struct {
+	Algorithm AlgorithmId
+}
+
+func (e *MissingDigestError) Error() string {
+	return fmt.Sprintf("crypto-agile log entry is missing a digest value for algorithm %s that was present in "+
+		"the Spec ID Event", e.Algorithm)
+}
+
+// InvalidDigestSizeError is returned when the Spec ID event of a crypto-agile log declares a digest size that
+// isn't possible for any algorithm. The rest of the log can't be parsed reliably, so this is always an error.
+type InvalidDigestSizeError struct {
+	Algorithm  AlgorithmId
+	DigestSize uint16 // The size declared in the Spec ID event
+}
+
+func (e *InvalidDigestSizeError) Error() string {
+	return fmt.Sprintf("invalid SpecIdEvent (impossible digest size of %d for algorithm %s)", e.DigestSize,
+		e.Algorithm)
+}
+
+// LogWarning describes a specification violation that was tolerated whilst parsing a log in permissive mode.
+type LogWarning struct {
+	PCRIndex  PCRIndex  // The PCR index of the event that the violation was detected in
+	EventType EventType // The type of the event that the violation was detected in
+	Index     uint      // The index of the event that the violation was detected in
+	Err       error     // The violation
+}
+
+func (w *LogWarning) Error() string {
+	return fmt.Sprintf("event %d in PCR %d (%s): %v", w.Index, w.PCRIndex, w.EventType, w.Err)
+}
+
+func (w *LogWarning) Unwrap() error {
+	return w.Err
+}
+
+func wrapLogReadError(origErr error, partial bool) error {
+	if origErr == io.EOF {
+		if !partial {
+			return origErr
+		}
+		origErr = ErrUnexpectedEOF
+	}
+
+	return &LogReadError{Err: origErr}
+}
+
+func wrapPCRIndexOutOfRangeError(pcrIndex PCRIndex) error {
+	return &PCRIndexOutOfRangeError{PCRIndex: pcrIndex}
+}
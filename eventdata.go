@@ -44,22 +44,38 @@ func (e *opaqueEventData) Bytes() []byte {
 
 func decodeEventDataImpl(pcrIndex PCRIndex, eventType EventType, data []byte, options *LogOptions,
 	hasDigestOfSeparatorError bool) (EventData, int, error) {
-	switch {
-	case options.EnableGrub && (pcrIndex == 8 || pcrIndex == 9):
-		if d, n := decodeEventDataGRUB(pcrIndex, eventType, data); d != nil {
+	if isDRTMPCR(pcrIndex) && isTXTEventType(eventType) {
+		return decodeEventDataTXT(eventType, data), 0, nil
+	}
+
+	// Each of the optional decoders only applies to the events that it is enabled for. If one doesn't
+	// recognize the event data, the next one is tried before falling back to the TCG decoder.
+	if options.EnableGrub && options.GrubVariant.isDecodablePCR(pcrIndex) {
+		if d, n, e := decodeEventDataGRUB(pcrIndex, eventType, data, options.GrubVariant); d != nil {
 			return d, n, nil
+		} else if e != nil {
+			return nil, 0, e
 		}
-		fallthrough
-	case options.EnableSystemdEFIStub && pcrIndex == options.SystemdEFIStubPCR && eventType == EventTypeIPL:
+	}
+	if options.EnableSystemdEFIStub && pcrIndex == options.SystemdEFIStubPCR && eventType == EventTypeIPL {
 		if d, n, e := decodeEventDataSystemdEFIStub(data); d != nil {
 			return d, n, nil
 		} else if e != nil {
 			return nil, 0, e
 		}
-		fallthrough
-	default:
-		return decodeEventDataTCG(eventType, data, hasDigestOfSeparatorError)
 	}
+	if options.EnableXen && isPCRInList(pcrIndex, options.xenPCRs()) {
+		if d, n := decodeEventDataXen(eventType, data); d != nil {
+			return d, n, nil
+		}
+	}
+	if options.EnableAppMeasurements && eventType == EventTypeEventTag {
+		if d, n := decodeEventDataApp(data); d != nil {
+			return d, n, nil
+		}
+	}
+
+	return decodeEventDataTCG(eventType, data, hasDigestOfSeparatorError)
 }
 
 func decodeEventData(pcrIndex PCRIndex, eventType EventType, data []byte, options *LogOptions,
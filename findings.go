@@ -0,0 +1,82 @@
+package tcglog
+
+import (
+	"fmt"
+)
+
+// FindingSeverity indicates the severity of a Finding.
+type FindingSeverity int
+
+const (
+	FindingSeverityInfo    FindingSeverity = iota // Informational, not necessarily a problem
+	FindingSeverityWarning                        // Possibly indicates a problem with the log or the firmware
+	FindingSeverityError                          // Indicates a problem with the log
+)
+
+func (s FindingSeverity) String() string {
+	switch s {
+	case FindingSeverityInfo:
+		return "info"
+	case FindingSeverityWarning:
+		return "warning"
+	case FindingSeverityError:
+		return "error"
+	default:
+		return fmt.Sprintf("FindingSeverity(%d)", int(s))
+	}
+}
+
+// ParseFindingSeverity parses a FindingSeverity from its string representation.
+func ParseFindingSeverity(severity string) (FindingSeverity, error) {
+	switch severity {
+	case "info":
+		return FindingSeverityInfo, nil
+	case "warning":
+		return FindingSeverityWarning, nil
+	case "error":
+		return FindingSeverityError, nil
+	default:
+		return 0, fmt.Errorf("unrecognized finding severity %q", severity)
+	}
+}
+
+// FindingCode uniquely and stably identifies the kind of a Finding.
+type FindingCode string
+
+// Finding describes something noteworthy that was detected when analyzing or validating a log.
+type Finding struct {
+	Code     FindingCode     // Identifies the kind of finding
+	Severity FindingSeverity // The severity of this finding
+	Event    *Event          // The event associated with this finding, if any
+	Message  string          // Human readable description of this finding
+}
+
+func (f *Finding) String() string {
+	if f.Event == nil {
+		return fmt.Sprintf("[%s] %s: %s", f.Severity, f.Code, f.Message)
+	}
+	return fmt.Sprintf("[%s] %s: event %d in PCR %d (type: %s): %s", f.Severity, f.Code, f.Event.Index,
+		f.Event.PCRIndex, f.Event.EventType, f.Message)
+}
+
+// FilterFindings returns the findings that have at least the specified severity and whose codes aren't in the
+// suppressed list.
+func FilterFindings(findings []Finding, minSeverity FindingSeverity, suppressed []FindingCode) (out []Finding) {
+	for _, f := range findings {
+		if f.Severity < minSeverity {
+			continue
+		}
+		isSuppressed := false
+		for _, code := range suppressed {
+			if f.Code == code {
+				isSuppressed = true
+				break
+			}
+		}
+		if isSuppressed {
+			continue
+		}
+		out = append(out, f)
+	}
+	return
+}
@@ -0,0 +1,59 @@
+package tcglog
+
+import (
+	"testing"
+)
+
+func TestParseFindingSeverity(t *testing.T) {
+	for _, s := range []FindingSeverity{FindingSeverityInfo, FindingSeverityWarning, FindingSeverityError} {
+		severity, err := ParseFindingSeverity(s.String())
+		if err != nil {
+			t.Errorf("ParseFindingSeverity failed: %v", err)
+		}
+		if severity != s {
+			t.Errorf("Unexpected severity %s", severity)
+		}
+	}
+
+	_, err := ParseFindingSeverity("fatal")
+	if err == nil || err.Error() != "unrecognized finding severity \"fatal\"" {
+		t.Errorf("Unexpected error: %v", err)
+	}
+}
+
+func TestFilterFindings(t *testing.T) {
+	findings := []Finding{
+		{Code: FindingLogTooSmall, Severity: FindingSeverityError},
+		{Code: FindingHighEntropyEventData, Severity: FindingSeverityInfo},
+		{Code: FindingLogTooLarge, Severity: FindingSeverityWarning},
+		{Code: FindingHighEntropyEventData, Severity: FindingSeverityWarning},
+	}
+
+	for _, data := range []struct {
+		desc        string
+		minSeverity FindingSeverity
+		suppressed  []FindingCode
+		expected    []int // Indices of the findings that are expected to be returned
+	}{
+		{desc: "All", minSeverity: FindingSeverityInfo, expected: []int{0, 1, 2, 3}},
+		{desc: "Warning", minSeverity: FindingSeverityWarning, expected: []int{0, 2, 3}},
+		{desc: "Error", minSeverity: FindingSeverityError, expected: []int{0}},
+		{desc: "Suppressed", minSeverity: FindingSeverityInfo, suppressed: []FindingCode{FindingHighEntropyEventData},
+			expected: []int{0, 2}},
+		{desc: "SuppressedAndSeverity", minSeverity: FindingSeverityWarning,
+			suppressed: []FindingCode{FindingLogTooSmall, FindingLogTooLarge}, expected: []int{3}},
+		{desc: "None", minSeverity: FindingSeverityError, suppressed: []FindingCode{FindingLogTooSmall}},
+	} {
+		t.Run(data.desc, func(t *testing.T) {
+			out := FilterFindings(findings, data.minSeverity, data.suppressed)
+			if len(out) != len(data.expected) {
+				t.Fatalf("Unexpected number of findings (%d)", len(out))
+			}
+			for i, j := range data.expected {
+				if out[i] != findings[j] {
+					t.Errorf("Unexpected finding %d: %s", i, &out[i])
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,56 @@
+package tcglog
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"sort"
+)
+
+// Quirks returns a sorted list of stable identifiers that describe the quirks and findings associated with
+// the validated log. The identifiers don't depend on the position of events in the log or on the values of
+// digests, so logs from systems with identical firmware behaviour produce identical lists.
+func (r *LogValidateResult) Quirks() []string {
+	quirks := make(map[string]struct{})
+
+	if r.EfiBootVariableBehaviour == EFIBootVariableBehaviourVarDataOnly {
+		quirks["efi-boot-variable-var-data-only"] = struct{}{}
+	}
+
+	for _, e := range r.ValidatedEvents {
+		if e.MeasuredTrailingBytesCount > 0 {
+			quirks[fmt.Sprintf("trailing-measured-bytes:pcr=%d,type=%08x,count=%d", e.Event.PCRIndex,
+				uint32(e.Event.EventType), e.MeasuredTrailingBytesCount)] = struct{}{}
+		}
+		for _, v := range e.IncorrectDigestValues {
+			quirks[fmt.Sprintf("incorrect-digest:pcr=%d,type=%08x,alg=%04x", e.Event.PCRIndex,
+				uint32(e.Event.EventType), uint16(v.Algorithm))] = struct{}{}
+		}
+	}
+
+	for _, f := range r.AllFindings {
+		if f.Event == nil {
+			quirks[fmt.Sprintf("finding:%s", f.Code)] = struct{}{}
+			continue
+		}
+		quirks[fmt.Sprintf("finding:%s:pcr=%d,type=%08x", f.Code, f.Event.PCRIndex,
+			uint32(f.Event.EventType))] = struct{}{}
+	}
+
+	var out []string
+	for q := range quirks {
+		out = append(out, q)
+	}
+	sort.Strings(out)
+	return out
+}
+
+// QuirkFingerprint returns a SHA-256 digest of the identifiers returned from Quirks. This can be used by fleet
+// tooling to group hosts with identical firmware behaviour, and to detect when a host's quirk profile changes
+// between boots.
+func (r *LogValidateResult) QuirkFingerprint() Digest {
+	h := sha256.New()
+	for _, q := range r.Quirks() {
+		fmt.Fprintf(h, "%s\n", q)
+	}
+	return h.Sum(nil)
+}
@@ -0,0 +1,55 @@
+package tcglog
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+)
+
+func makeTestQuirksResult(index uint) *LogValidateResult {
+	event := &Event{Index: index, PCRIndex: 4, EventType: EventTypeEFIAction}
+	return &LogValidateResult{
+		EfiBootVariableBehaviour: EFIBootVariableBehaviourVarDataOnly,
+		ValidatedEvents: []*ValidatedEvent{
+			{Event: event, MeasuredTrailingBytesCount: 2,
+				IncorrectDigestValues: []IncorrectDigestValue{{Algorithm: AlgorithmSha256}}},
+		},
+		AllFindings: []Finding{
+			{Code: FindingLogTooLarge, Severity: FindingSeverityWarning},
+			{Code: FindingHighEntropyEventData, Severity: FindingSeverityInfo, Event: event},
+			{Code: FindingHighEntropyEventData, Severity: FindingSeverityInfo, Event: event},
+		}}
+}
+
+func TestQuirks(t *testing.T) {
+	expected := []string{
+		"efi-boot-variable-var-data-only",
+		"finding:high-entropy-event-data:pcr=4,type=80000007",
+		"finding:log-too-large",
+		"incorrect-digest:pcr=4,type=80000007,alg=000b",
+		"trailing-measured-bytes:pcr=4,type=80000007,count=2",
+	}
+	if quirks := makeTestQuirksResult(1).Quirks(); !reflect.DeepEqual(quirks, expected) {
+		t.Errorf("Unexpected quirks: %q", quirks)
+	}
+	if quirks := new(LogValidateResult).Quirks(); len(quirks) > 0 {
+		t.Errorf("Unexpected quirks: %q", quirks)
+	}
+}
+
+func TestQuirkFingerprint(t *testing.T) {
+	a := makeTestQuirksResult(1).QuirkFingerprint()
+	// The fingerprint doesn't depend on the position of events.
+	if b := makeTestQuirksResult(10).QuirkFingerprint(); !bytes.Equal(a, b) {
+		t.Errorf("Unexpected fingerprint change with a different event index")
+	}
+
+	result := makeTestQuirksResult(1)
+	result.EfiBootVariableBehaviour = EFIBootVariableBehaviourFull
+	if b := result.QuirkFingerprint(); bytes.Equal(a, b) {
+		t.Errorf("Expected a different fingerprint")
+	}
+	if len(a) != AlgorithmSha256.size() {
+		t.Errorf("Unexpected fingerprint size")
+	}
+}
@@ -0,0 +1,23 @@
+//go:build go1.18
+// +build go1.18
+
+package tcglog
+
+// DataAs returns the data associated with the supplied event as the specified type, eg,
+// DataAs[*EFIVariableEventData](event). If the event data has a different type, the zero value and false are
+// returned.
+func DataAs[T EventData](event *Event) (T, bool) {
+	d, ok := event.Data.(T)
+	return d, ok
+}
+
+// EventsWithData returns the events from the supplied list that have data of the specified type, eg,
+// EventsWithData[*EFIVariableEventData](events).
+func EventsWithData[T EventData](events []*Event) (out []*Event) {
+	for _, e := range events {
+		if _, ok := e.Data.(T); ok {
+			out = append(out, e)
+		}
+	}
+	return
+}
@@ -11,6 +11,77 @@ var (
 	grubCmdPrefix       = "grub_cmd: "
 )
 
+// GrubVariant corresponds to a variant of GRUB, which determines the PCRs that it measures to and the format
+// of the events that it logs.
+type GrubVariant int
+
+const (
+	// GrubVariantUpstream corresponds to upstream GRUB (2.04 and later), which measures commands and kernel
+	// commandlines with a prefix to PCR 8 and loaded files to PCR 9.
+	GrubVariantUpstream GrubVariant = iota
+
+	// GrubVariantTrustedGRUB2 corresponds to TrustedGRUB2, which measures loaded files to PCR 10, commands
+	// without a prefix to PCR 11 and modules to PCR 13.
+	// See https://github.com/Rohde-Schwarz/TrustedGRUB2
+	GrubVariantTrustedGRUB2
+)
+
+func (v GrubVariant) String() string {
+	switch v {
+	case GrubVariantUpstream:
+		return "upstream"
+	case GrubVariantTrustedGRUB2:
+		return "trustedgrub2"
+	default:
+		return fmt.Sprintf("GrubVariant(%d)", int(v))
+	}
+}
+
+// ParseGrubVariant returns the GrubVariant corresponding to the supplied name.
+func ParseGrubVariant(variant string) (GrubVariant, error) {
+	switch variant {
+	case "upstream":
+		return GrubVariantUpstream, nil
+	case "trustedgrub2":
+		return GrubVariantTrustedGRUB2, nil
+	default:
+		return 0, fmt.Errorf("unrecognized GRUB variant %q", variant)
+	}
+}
+
+type grubPCRUsage struct {
+	commandPCRs     []PCRIndex // PCRs containing measurements of commands and kernel commandlines
+	commandPrefixes bool       // Whether command measurements are logged with a prefix indicating their type
+	filePCRs        []PCRIndex // PCRs containing measurements of files, logged with the path of the file
+}
+
+var grubPCRUsages = map[GrubVariant]grubPCRUsage{
+	GrubVariantUpstream:     {commandPCRs: []PCRIndex{8}, commandPrefixes: true, filePCRs: []PCRIndex{9}},
+	GrubVariantTrustedGRUB2: {commandPCRs: []PCRIndex{11}, filePCRs: []PCRIndex{10, 13}},
+}
+
+func isPCRInList(pcr PCRIndex, pcrs []PCRIndex) bool {
+	for _, p := range pcrs {
+		if p == pcr {
+			return true
+		}
+	}
+	return false
+}
+
+// PCRs returns the PCRs containing measurements made by this variant of GRUB that can be interpreted.
+func (v GrubVariant) PCRs() (out []PCRIndex) {
+	usage := grubPCRUsages[v]
+	out = append(out, usage.commandPCRs...)
+	out = append(out, usage.filePCRs...)
+	return
+}
+
+func (v GrubVariant) isDecodablePCR(pcr PCRIndex) bool {
+	usage := grubPCRUsages[v]
+	return isPCRInList(pcr, usage.commandPCRs) || isPCRInList(pcr, usage.filePCRs)
+}
+
 type GrubStringEventType int
 
 const (
@@ -49,25 +120,45 @@ func (e *GrubStringEventData) EncodeMeasuredBytes(buf io.Writer) error {
 	return nil
 }
 
-func decodeEventDataGRUB(pcrIndex PCRIndex, eventType EventType, data []byte) (EventData, int) {
+// GrubFileEventData corresponds to the data of an event recorded by GRUB when it measures a file, which is the
+// path of the file. The contents of the file are measured, and are not recorded in the log.
+type GrubFileEventData struct {
+	data []byte
+	Path string
+}
+
+func (e *GrubFileEventData) String() string {
+	return fmt.Sprintf("grub_file{ %s }", e.Path)
+}
+
+func (e *GrubFileEventData) Bytes() []byte {
+	return e.data
+}
+
+func decodeEventDataGRUB(pcrIndex PCRIndex, eventType EventType, data []byte, variant GrubVariant) (EventData, int, error) {
 	if eventType != EventTypeIPL {
-		return nil, 0
+		return nil, 0, nil
 	}
 
-	switch pcrIndex {
-	case 8:
+	usage := grubPCRUsages[variant]
+
+	switch {
+	case isPCRInList(pcrIndex, usage.commandPCRs):
 		str := string(data)
+		if !usage.commandPrefixes {
+			return &GrubStringEventData{data, GrubCmd, strings.TrimSuffix(str, "\x00")}, 0, nil
+		}
 		switch {
 		case strings.HasPrefix(str, kernelCmdlinePrefix):
-			return &GrubStringEventData{data, KernelCmdline, strings.TrimSuffix(strings.TrimPrefix(str, kernelCmdlinePrefix), "\x00")}, 0
+			return &GrubStringEventData{data, KernelCmdline, strings.TrimSuffix(strings.TrimPrefix(str, kernelCmdlinePrefix), "\x00")}, 0, nil
 		case strings.HasPrefix(str, grubCmdPrefix):
-			return &GrubStringEventData{data, GrubCmd, strings.TrimSuffix(strings.TrimPrefix(str, grubCmdPrefix), "\x00")}, 0
+			return &GrubStringEventData{data, GrubCmd, strings.TrimSuffix(strings.TrimPrefix(str, grubCmdPrefix), "\x00")}, 0, nil
 		default:
-			return nil, 0
+			return nil, 0, nil
 		}
-	case 9:
-		return &asciiStringEventData{data: data}, 0
+	case isPCRInList(pcrIndex, usage.filePCRs):
+		return &GrubFileEventData{data: data, Path: strings.TrimRight(string(data), "\x00")}, 0, nil
 	default:
-		panic("unhandled PCR index")
+		return nil, 0, fmt.Errorf("PCR %d is not used by GRUB variant %s", pcrIndex, variant)
 	}
 }
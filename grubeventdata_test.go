@@ -0,0 +1,171 @@
+package tcglog
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseGrubVariant(t *testing.T) {
+	for _, data := range []struct {
+		name    string
+		variant GrubVariant
+	}{
+		{name: "upstream", variant: GrubVariantUpstream},
+		{name: "trustedgrub2", variant: GrubVariantTrustedGRUB2},
+	} {
+		t.Run(data.name, func(t *testing.T) {
+			variant, err := ParseGrubVariant(data.name)
+			if err != nil {
+				t.Fatalf("ParseGrubVariant failed: %v", err)
+			}
+			if variant != data.variant {
+				t.Errorf("Unexpected variant %s", variant)
+			}
+			if variant.String() != data.name {
+				t.Errorf("Unexpected name %s", variant)
+			}
+		})
+	}
+
+	_, err := ParseGrubVariant("foo")
+	if err == nil {
+		t.Fatalf("Expected an error")
+	}
+	if err.Error() != "unrecognized GRUB variant \"foo\"" {
+		t.Errorf("Unexpected error: %v", err)
+	}
+}
+
+func TestGrubVariantPCRs(t *testing.T) {
+	if pcrs := GrubVariantUpstream.PCRs(); !reflect.DeepEqual(pcrs, []PCRIndex{8, 9}) {
+		t.Errorf("Unexpected PCRs for upstream: %v", pcrs)
+	}
+	if pcrs := GrubVariantTrustedGRUB2.PCRs(); !reflect.DeepEqual(pcrs, []PCRIndex{11, 10, 13}) {
+		t.Errorf("Unexpected PCRs for trustedgrub2: %v", pcrs)
+	}
+}
+
+func TestDecodeEventDataGRUB(t *testing.T) {
+	for _, data := range []struct {
+		desc      string
+		variant   GrubVariant
+		pcr       PCRIndex
+		eventType EventType
+		data      string
+		cmdType   GrubStringEventType
+		str       string // The expected string. The data is expected to be opaque if this is empty
+		file      bool   // Whether the data is expected to be a file path
+	}{
+		{
+			desc:      "UpstreamGrubCmd",
+			variant:   GrubVariantUpstream,
+			pcr:       8,
+			eventType: EventTypeIPL,
+			data:      "grub_cmd: linux /vmlinuz root=/dev/sda1\x00",
+			cmdType:   GrubCmd,
+			str:       "linux /vmlinuz root=/dev/sda1",
+		},
+		{
+			desc:      "UpstreamKernelCmdline",
+			variant:   GrubVariantUpstream,
+			pcr:       8,
+			eventType: EventTypeIPL,
+			data:      "kernel_cmdline: /vmlinuz root=/dev/sda1\x00",
+			cmdType:   KernelCmdline,
+			str:       "/vmlinuz root=/dev/sda1",
+		},
+		{
+			desc:      "UpstreamNoPrefix",
+			variant:   GrubVariantUpstream,
+			pcr:       8,
+			eventType: EventTypeIPL,
+			data:      "linux /vmlinuz\x00",
+		},
+		{
+			desc:      "UpstreamFile",
+			variant:   GrubVariantUpstream,
+			pcr:       9,
+			eventType: EventTypeIPL,
+			data:      "/boot/vmlinuz\x00",
+			str:       "/boot/vmlinuz",
+			file:      true,
+		},
+		{
+			desc:      "UpstreamWrongType",
+			variant:   GrubVariantUpstream,
+			pcr:       8,
+			eventType: EventTypeEventTag,
+			data:      "grub_cmd: linux /vmlinuz\x00",
+		},
+		{
+			desc:      "TrustedGRUB2Cmd",
+			variant:   GrubVariantTrustedGRUB2,
+			pcr:       11,
+			eventType: EventTypeIPL,
+			data:      "linux /vmlinuz root=/dev/sda1\x00",
+			cmdType:   GrubCmd,
+			str:       "linux /vmlinuz root=/dev/sda1",
+		},
+		{
+			desc:      "TrustedGRUB2Module",
+			variant:   GrubVariantTrustedGRUB2,
+			pcr:       13,
+			eventType: EventTypeIPL,
+			data:      "/boot/grub/i386-pc/normal.mod",
+			str:       "/boot/grub/i386-pc/normal.mod",
+			file:      true,
+		},
+		{
+			desc:      "TrustedGRUB2UpstreamPCR",
+			variant:   GrubVariantTrustedGRUB2,
+			pcr:       8,
+			eventType: EventTypeIPL,
+			data:      "grub_cmd: linux /vmlinuz\x00",
+		},
+	} {
+		t.Run(data.desc, func(t *testing.T) {
+			options := LogOptions{EnableGrub: true, GrubVariant: data.variant}
+			out, _ := decodeEventData(data.pcr, data.eventType, []byte(data.data), &options, false)
+
+			switch {
+			case data.str == "":
+				switch out.(type) {
+				case *GrubStringEventData, *GrubFileEventData:
+					t.Errorf("Unexpected event data type %T", out)
+				}
+			case data.file:
+				d, ok := out.(*GrubFileEventData)
+				if !ok {
+					t.Fatalf("Unexpected event data type %T", out)
+				}
+				if d.Path != data.str {
+					t.Errorf("Unexpected path %q", d.Path)
+				}
+			default:
+				d, ok := out.(*GrubStringEventData)
+				if !ok {
+					t.Fatalf("Unexpected event data type %T", out)
+				}
+				if d.Type != data.cmdType {
+					t.Errorf("Unexpected type %d", d.Type)
+				}
+				if d.Str != data.str {
+					t.Errorf("Unexpected string %q", d.Str)
+				}
+			}
+			if string(out.Bytes()) != data.data {
+				t.Errorf("Unexpected bytes")
+			}
+		})
+	}
+}
+
+func TestDecodeEventDataGRUBUnusedPCR(t *testing.T) {
+	_, _, err := decodeEventDataGRUB(4, EventTypeIPL, []byte("foo"), GrubVariantUpstream)
+	if err == nil {
+		t.Fatalf("Expected an error")
+	}
+	if err.Error() != "PCR 4 is not used by GRUB variant upstream" {
+		t.Errorf("Unexpected error: %v", err)
+	}
+}
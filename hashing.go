@@ -0,0 +1,119 @@
+package tcglog
+
+// MeasuredContent describes what firmware is expected to hash in order to produce the digests for an event.
+type MeasuredContent int
+
+const (
+	// MeasuredContentUnknown indicates that the specification doesn't define what is measured for the event
+	// type.
+	MeasuredContentUnknown MeasuredContent = iota
+
+	// MeasuredContentNone indicates that events of this type aren't extended to a PCR.
+	MeasuredContentNone
+
+	// MeasuredContentEventData indicates that the event data is measured, which means that the digests can be
+	// validated from the log.
+	MeasuredContentEventData
+
+	// MeasuredContentImage indicates that a PE image is measured, using its Authenticode digest. The image
+	// isn't recorded in the log.
+	MeasuredContentImage
+
+	// MeasuredContentBlob indicates that some other data, such as a firmware volume, microcode update or
+	// ACPI table, is measured. The data isn't recorded in the log.
+	MeasuredContentBlob
+
+	// MeasuredContentDefinedByMeasurer indicates that the content is defined by the component that measures
+	// the event, such as a bootloader, rather than by the TCG specifications.
+	MeasuredContentDefinedByMeasurer
+)
+
+func (c MeasuredContent) String() string {
+	switch c {
+	case MeasuredContentNone:
+		return "none"
+	case MeasuredContentEventData:
+		return "event data"
+	case MeasuredContentImage:
+		return "image"
+	case MeasuredContentBlob:
+		return "blob"
+	case MeasuredContentDefinedByMeasurer:
+		return "defined by measurer"
+	default:
+		return "unknown"
+	}
+}
+
+var (
+	allSpecs = []Spec{SpecUnknown, SpecPCClient, SpecEFI_1_2, SpecEFI_2}
+	efiSpecs = []Spec{SpecUnknown, SpecEFI_1_2, SpecEFI_2}
+)
+
+// measuredContentRules describes what is measured for each event type, and the specifications that define it.
+// This is used both to validate the digests of events and to predict the digests of new events.
+// https://trustedcomputinggroup.org/wp-content/uploads/TCG_PCClientImplementation_1-21_1_00.pdf
+//  (section 11.3.1 "Event Types")
+// https://trustedcomputinggroup.org/wp-content/uploads/TCG_EFI_Platform_1_22_Final_-v15.pdf
+//  (section 7.2 "Event Types")
+// https://trustedcomputinggroup.org/wp-content/uploads/TCG_PCClientSpecPlat_TPM_2p0_1p04_pub.pdf
+//  (section 9.4.1 "Event Types")
+var measuredContentRules = []struct {
+	eventType EventType
+	specs     []Spec
+	content   MeasuredContent
+}{
+	{EventTypePrebootCert, allSpecs, MeasuredContentEventData},
+	{EventTypePostCode, allSpecs, MeasuredContentBlob},
+	{EventTypeNoAction, allSpecs, MeasuredContentNone},
+	{EventTypeSeparator, allSpecs, MeasuredContentEventData},
+	{EventTypeAction, allSpecs, MeasuredContentEventData},
+	{EventTypeEventTag, allSpecs, MeasuredContentEventData},
+	{EventTypeSCRTMContents, allSpecs, MeasuredContentBlob},
+	{EventTypeSCRTMVersion, allSpecs, MeasuredContentEventData},
+	{EventTypeCPUMicrocode, allSpecs, MeasuredContentBlob},
+	{EventTypePlatformConfigFlags, allSpecs, MeasuredContentEventData},
+	{EventTypeTableOfDevices, allSpecs, MeasuredContentEventData},
+	{EventTypeCompactHash, allSpecs, MeasuredContentBlob},
+	{EventTypeIPL, allSpecs, MeasuredContentDefinedByMeasurer},
+	{EventTypeIPLPartitionData, allSpecs, MeasuredContentDefinedByMeasurer},
+	{EventTypeNonhostCode, allSpecs, MeasuredContentBlob},
+	{EventTypeNonhostConfig, allSpecs, MeasuredContentBlob},
+	{EventTypeNonhostInfo, allSpecs, MeasuredContentEventData},
+	{EventTypeOmitBootDeviceEvents, allSpecs, MeasuredContentEventData},
+	{EventTypeEFIVariableDriverConfig, efiSpecs, MeasuredContentEventData},
+	{EventTypeEFIVariableBoot, efiSpecs, MeasuredContentEventData},
+	{EventTypeEFIBootServicesApplication, efiSpecs, MeasuredContentImage},
+	{EventTypeEFIBootServicesDriver, efiSpecs, MeasuredContentImage},
+	{EventTypeEFIRuntimeServicesDriver, efiSpecs, MeasuredContentImage},
+	{EventTypeEFIGPTEvent, efiSpecs, MeasuredContentEventData},
+	{EventTypeEFIAction, efiSpecs, MeasuredContentEventData},
+	{EventTypeEFIPlatformFirmwareBlob, efiSpecs, MeasuredContentBlob},
+	{EventTypeEFIHandoffTables, efiSpecs, MeasuredContentBlob},
+	{EventTypeEFIPlatformFirmwareBlob2, []Spec{SpecUnknown, SpecEFI_2}, MeasuredContentBlob},
+	{EventTypeEFIHandoffTables2, []Spec{SpecUnknown, SpecEFI_2}, MeasuredContentBlob},
+	{EventTypeEFIVariableBoot2, []Spec{SpecUnknown, SpecEFI_2}, MeasuredContentEventData},
+	{EventTypeEFIHCRTMEvent, []Spec{SpecUnknown, SpecEFI_2}, MeasuredContentBlob},
+	{EventTypeEFIVariableAuthority, efiSpecs, MeasuredContentEventData},
+	{EventTypeEFISPDMFirmwareBlob, []Spec{SpecUnknown, SpecEFI_2}, MeasuredContentBlob},
+	{EventTypeEFISPDMFirmwareConfig, []Spec{SpecUnknown, SpecEFI_2}, MeasuredContentBlob},
+	{EventTypeEFISPDMDevicePolicy, []Spec{SpecUnknown, SpecEFI_2}, MeasuredContentEventData},
+	{EventTypeEFISPDMDeviceAuthority, []Spec{SpecUnknown, SpecEFI_2}, MeasuredContentEventData},
+}
+
+// MeasuredContentForEventType returns what firmware that conforms to the specified specification is expected to
+// hash for events of the specified type. SpecUnknown can be supplied to query the rules from any specification.
+func MeasuredContentForEventType(spec Spec, eventType EventType) MeasuredContent {
+	for _, r := range measuredContentRules {
+		if r.eventType != eventType {
+			continue
+		}
+		for _, s := range r.specs {
+			if s == spec {
+				return r.content
+			}
+		}
+		break
+	}
+	return MeasuredContentUnknown
+}
@@ -0,0 +1,78 @@
+package tcglog
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestMeasuredContentForEventType(t *testing.T) {
+	for _, data := range []struct {
+		spec      Spec
+		eventType EventType
+		content   MeasuredContent
+	}{
+		{SpecEFI_2, EventTypeSeparator, MeasuredContentEventData},
+		{SpecEFI_2, EventTypeNoAction, MeasuredContentNone},
+		{SpecEFI_2, EventTypeEFIBootServicesApplication, MeasuredContentImage},
+		{SpecEFI_2, EventTypeEFIPlatformFirmwareBlob, MeasuredContentBlob},
+		{SpecEFI_2, EventTypeIPL, MeasuredContentDefinedByMeasurer},
+		{SpecPCClient, EventTypeCompactHash, MeasuredContentBlob},
+		{SpecEFI_2, EventTypeEFIHCRTMEvent, MeasuredContentBlob},
+		{SpecEFI_1_2, EventTypeEFIHCRTMEvent, MeasuredContentUnknown},
+		{SpecPCClient, EventTypeEFIVariableBoot, MeasuredContentUnknown},
+		{SpecUnknown, EventTypeEFIVariableBoot, MeasuredContentEventData},
+		{SpecEFI_2, EventTypeEFIVariableBoot2, MeasuredContentEventData},
+		{SpecEFI_1_2, EventTypeEFIVariableBoot2, MeasuredContentUnknown},
+		{SpecEFI_2, EventType(0x12345678), MeasuredContentUnknown},
+	} {
+		if c := MeasuredContentForEventType(data.spec, data.eventType); c != data.content {
+			t.Errorf("Unexpected content for %s with spec %d (got %s, expected %s)", data.eventType, data.spec,
+				c, data.content)
+		}
+	}
+}
+
+func TestPredictEventDigests(t *testing.T) {
+	algorithms := AlgorithmIdList{AlgorithmSha1, AlgorithmSha256}
+
+	variable := &EFIVariableEventData{VariableName: *EFIGlobalVariableGUID, UnicodeName: "PK", VariableData: []byte("pk")}
+	for _, data := range []struct {
+		desc      string
+		eventType EventType
+		data      EventData
+		measured  []byte
+	}{
+		{desc: "Separator", eventType: EventTypeSeparator, data: &separatorEventData{data: make([]byte, 4)},
+			measured: make([]byte, 4)},
+		{desc: "Action", eventType: EventTypeEFIAction, data: &asciiStringEventData{data: []byte("foo")},
+			measured: []byte("foo")},
+		{desc: "Variable", eventType: EventTypeEFIVariableDriverConfig, data: variable,
+			measured: eventMeasuredBytes(variable)},
+	} {
+		t.Run(data.desc, func(t *testing.T) {
+			digests, err := predictEventDigests(SpecEFI_2, data.eventType, data.data, algorithms)
+			if err != nil {
+				t.Fatalf("predictEventDigests failed: %v", err)
+			}
+			for _, alg := range algorithms {
+				if !bytes.Equal(digests[alg], alg.hash(data.measured)) {
+					t.Errorf("Unexpected %s digest", alg)
+				}
+			}
+		})
+	}
+
+	for _, data := range []struct {
+		spec      Spec
+		eventType EventType
+	}{
+		{SpecEFI_2, EventTypeEFIBootServicesApplication},
+		{SpecEFI_2, EventTypeIPL},
+		{SpecEFI_2, EventTypeNoAction},
+		{SpecPCClient, EventTypeEFIVariableDriverConfig},
+	} {
+		if _, err := predictEventDigests(data.spec, data.eventType, &opaqueEventData{}, algorithms); err == nil {
+			t.Errorf("Expected an error for %s with spec %d", data.eventType, data.spec)
+		}
+	}
+}
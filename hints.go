@@ -0,0 +1,52 @@
+package tcglog
+
+// RemediationHint describes an action that may resolve or mitigate the cause of a finding.
+type RemediationHint struct {
+	Code FindingCode // The code of the findings that this hint applies to
+	Hint string      // A human readable description of the suggested action
+}
+
+var findingRemediationHints = []RemediationHint{
+	{FindingLogTooSmall, "the log may have been truncated - check that the complete log was read and that the " +
+		"firmware didn't run out of space for it"},
+	{FindingLogMissingSeparators, "the log may have been truncated - check that the complete log was read, " +
+		"including events measured after ExitBootServices"},
+	{FindingLogTooLarge, "check that the log was read from the correct source, or increase the maximum log " +
+		"size if large logs are expected on this platform"},
+	{FindingHighEntropyEventData, "inspect the raw event data to determine whether the log has been tampered " +
+		"with or corrupted before trusting it"},
+	{FindingCCEvidenceMismatch, "check that the log and the attestation evidence were collected from the same " +
+		"boot, and don't trust either until the mismatch is explained"},
+	{FindingBankNotActive, "policies bound to this PCR bank can't be satisfied - seal against a bank that is " +
+		"both active on the TPM and present in the log"},
+	{FindingBankNotInLog, "values in this PCR bank can't be predicted from the log - disable the bank in the " +
+		"firmware or avoid sealing against it"},
+	{FindingBankDowngrade, "check whether the TPM was reconfigured intentionally, and don't trust attestations " +
+		"or unseal secrets using the remaining banks until the change is explained"},
+	{FindingPCRValueMismatch, "the log doesn't account for every measurement - check whether events are " +
+		"missing from the end of the log, such as those recorded in the final events table, and seal " +
+		"against values read from the TPM until this is resolved"},
+	{FindingPCRReset, "the value of this PCR can't be predicted from the log - avoid sealing against it"},
+	{FindingSpecViolation, "the firmware doesn't follow the specification - report it to the firmware " +
+		"vendor, and reseal using a quirk-aware prediction rather than one computed from the specification " +
+		"alone"},
+	{FindingDigestSizeMismatch, "this PCR bank can't be predicted from the log - report it to the firmware " +
+		"vendor and avoid sealing against it"},
+	{FindingMissingDigest, "this PCR bank can't be predicted from the log - avoid sealing against it and " +
+		"report it to the firmware vendor"},
+	{FindingImplausibleImageLoadAddress, "this usually indicates a firmware bug that doesn't affect PCR " +
+		"values, and can be suppressed once it has been reported to the firmware vendor"},
+	{FindingUndecodedPlatformConfig, "changes to PCR 1 caused by this event can't be explained from the log " +
+		"- compare the raw event data between boots, or avoid sealing against PCR 1"},
+}
+
+// RemediationHintsForFinding returns hints that describe actions which may resolve or mitigate the cause of
+// findings with the specified code.
+func RemediationHintsForFinding(code FindingCode) (out []string) {
+	for _, h := range findingRemediationHints {
+		if h.Code == code {
+			out = append(out, h.Hint)
+		}
+	}
+	return
+}
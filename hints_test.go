@@ -0,0 +1,16 @@
+package tcglog
+
+import (
+	"testing"
+)
+
+func TestRemediationHintsForFinding(t *testing.T) {
+	for code := range findingComplianceReferences {
+		if len(RemediationHintsForFinding(code)) == 0 {
+			t.Errorf("No remediation hints for %s", code)
+		}
+	}
+	if hints := RemediationHintsForFinding("foo"); len(hints) != 0 {
+		t.Errorf("Unexpected hints for unknown code: %v", hints)
+	}
+}
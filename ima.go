@@ -0,0 +1,393 @@
+package tcglog
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// IMAPCR is the PCR that the Linux Integrity Measurement Architecture measures to by default.
+const IMAPCR PCRIndex = 10
+
+// IMAEventType is the event type assigned to events obtained from an IMA measurement list. IMA measurements
+// don't have a TCG event type, so the type used for measurements made by OS components is used.
+const IMAEventType = EventTypeIPL
+
+const (
+	imaEventNameLenMax = 255
+	imaMaxTemplateData = 1 << 20
+)
+
+// IMAEventData corresponds to an entry in a Linux IMA measurement list. The file digest, file name and signature
+// are decoded for the "ima", "ima-ng" and "ima-sig" templates. Only the template name and raw template data are
+// available for other templates.
+type IMAEventData struct {
+	data                []byte
+	TemplateName        string
+	FileDigestAlgorithm string // The name of the algorithm used to compute FileDigest, as recorded by the kernel
+	FileDigest          []byte
+	FileName            string
+	Signature           []byte
+}
+
+func (e *IMAEventData) String() string {
+	if e.FileDigestAlgorithm == "" {
+		return e.TemplateName
+	}
+	s := fmt.Sprintf("%s %s:%x %s", e.TemplateName, e.FileDigestAlgorithm, e.FileDigest, e.FileName)
+	if len(e.Signature) > 0 {
+		s += fmt.Sprintf(" %x", e.Signature)
+	}
+	return s
+}
+
+// Bytes returns the template data in the form that it appears in the binary measurement list.
+func (e *IMAEventData) Bytes() []byte {
+	return e.data
+}
+
+func readIMAField(r io.Reader) ([]byte, error) {
+	var n uint32
+	if err := binary.Read(r, binary.LittleEndian, &n); err != nil {
+		return nil, err
+	}
+	if n > imaMaxTemplateData {
+		return nil, fmt.Errorf("field is too large (%d bytes)", n)
+	}
+	data := make([]byte, n)
+	if _, err := io.ReadFull(r, data); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+func decodeIMADigestNG(field []byte) (alg string, digest []byte, err error) {
+	i := bytes.Index(field, []byte(":\x00"))
+	if i < 0 {
+		// Entries using SHA-1 written by older kernels have no algorithm prefix.
+		if len(field) != AlgorithmSha1.size() {
+			return "", nil, errors.New("invalid file digest")
+		}
+		return "sha1", field, nil
+	}
+	return string(field[:i]), field[i+2:], nil
+}
+
+func decodeIMATemplateData(name string, data []byte) (*IMAEventData, error) {
+	d := &IMAEventData{data: data, TemplateName: name}
+	r := bytes.NewReader(data)
+
+	switch name {
+	case "ima":
+		digest := make([]byte, AlgorithmSha1.size())
+		if _, err := io.ReadFull(r, digest); err != nil {
+			return nil, err
+		}
+		fileName, err := readIMAField(r)
+		if err != nil {
+			return nil, err
+		}
+		d.FileDigestAlgorithm = "sha1"
+		d.FileDigest = digest
+		d.FileName = string(fileName)
+	case "ima-ng", "ima-sig":
+		digestField, err := readIMAField(r)
+		if err != nil {
+			return nil, err
+		}
+		alg, digest, err := decodeIMADigestNG(digestField)
+		if err != nil {
+			return nil, err
+		}
+		fileName, err := readIMAField(r)
+		if err != nil {
+			return nil, err
+		}
+		d.FileDigestAlgorithm = alg
+		d.FileDigest = digest
+		d.FileName = strings.TrimRight(string(fileName), "\x00")
+		if name == "ima-sig" {
+			sig, err := readIMAField(r)
+			if err != nil {
+				return nil, err
+			}
+			d.Signature = sig
+		}
+	default:
+		return d, nil
+	}
+
+	if r.Len() > 0 {
+		return nil, fmt.Errorf("%d trailing bytes in template data", r.Len())
+	}
+	return d, nil
+}
+
+// ParseIMAMeasurementList parses a Linux IMA measurement list in the binary format, as exported by the kernel
+// in /sys/kernel/security/ima/binary_runtime_measurements. The list is assumed to be in little-endian byte
+// order. The template hash of each entry is computed with the specified algorithm, which is AlgorithmSha1 for
+// the default list. The returned events have the type IMAEventType, and their data is of the type *IMAEventData.
+func ParseIMAMeasurementList(r io.Reader, alg AlgorithmId) ([]*Event, error) {
+	if !alg.supported() {
+		return nil, fmt.Errorf("unsupported digest algorithm %s", alg)
+	}
+
+	var events []*Event
+	for i := uint(0); ; i++ {
+		var pcr uint32
+		if err := binary.Read(r, binary.LittleEndian, &pcr); err != nil {
+			if err == io.EOF {
+				return events, nil
+			}
+			return nil, fmt.Errorf("entry %d: cannot read PCR index: %w", i, err)
+		}
+		if !isPCRIndexInRange(PCRIndex(pcr)) {
+			return nil, fmt.Errorf("entry %d: %w", i, wrapPCRIndexOutOfRangeError(PCRIndex(pcr)))
+		}
+
+		digest := make(Digest, alg.size())
+		if _, err := io.ReadFull(r, digest); err != nil {
+			return nil, fmt.Errorf("entry %d: cannot read template hash: %w", i, err)
+		}
+
+		name, err := readIMAField(r)
+		if err != nil {
+			return nil, fmt.Errorf("entry %d: cannot read template name: %w", i, err)
+		}
+		if len(name) > imaEventNameLenMax {
+			return nil, fmt.Errorf("entry %d: template name is too long", i)
+		}
+		data, err := readIMAField(r)
+		if err != nil {
+			return nil, fmt.Errorf("entry %d: cannot read template data: %w", i, err)
+		}
+
+		eventData, err := decodeIMATemplateData(string(name), data)
+		if err != nil {
+			return nil, fmt.Errorf("entry %d: cannot decode template data: %w", i, err)
+		}
+
+		events = append(events, &Event{
+			Index:     i,
+			PCRIndex:  PCRIndex(pcr),
+			EventType: IMAEventType,
+			Digests:   DigestMap{alg: digest},
+			Data:      eventData})
+	}
+}
+
+func encodeIMAField(w *bytes.Buffer, data []byte) {
+	binary.Write(w, binary.LittleEndian, uint32(len(data)))
+	w.Write(data)
+}
+
+func decodeIMAASCIIEntry(name, rest string) (*IMAEventData, error) {
+	var buf bytes.Buffer
+
+	switch name {
+	case "ima":
+		fields := strings.SplitN(rest, " ", 2)
+		if len(fields) != 2 {
+			return nil, errors.New("missing fields")
+		}
+		digest, err := hex.DecodeString(fields[0])
+		if err != nil || len(digest) != AlgorithmSha1.size() {
+			return nil, errors.New("invalid file digest")
+		}
+		buf.Write(digest)
+		encodeIMAField(&buf, []byte(fields[1]))
+	case "ima-ng", "ima-sig":
+		fields := strings.SplitN(rest, " ", 2)
+		if len(fields) != 2 {
+			return nil, errors.New("missing fields")
+		}
+		var digestField []byte
+		if i := strings.Index(fields[0], ":"); i >= 0 {
+			digest, err := hex.DecodeString(fields[0][i+1:])
+			if err != nil {
+				return nil, errors.New("invalid file digest")
+			}
+			digestField = append([]byte(fields[0][:i+1]+"\x00"), digest...)
+		} else {
+			digest, err := hex.DecodeString(fields[0])
+			if err != nil {
+				return nil, errors.New("invalid file digest")
+			}
+			digestField = digest
+		}
+		encodeIMAField(&buf, digestField)
+
+		fileName := fields[1]
+		var sig []byte
+		if name == "ima-sig" {
+			// The signature is always preceded by a space, even when it is empty.
+			i := strings.LastIndex(fileName, " ")
+			if i < 0 {
+				return nil, errors.New("missing signature field")
+			}
+			var err error
+			sig, err = hex.DecodeString(fileName[i+1:])
+			if err != nil {
+				return nil, errors.New("invalid signature")
+			}
+			fileName = fileName[:i]
+		}
+		encodeIMAField(&buf, append([]byte(fileName), 0))
+		if name == "ima-sig" {
+			encodeIMAField(&buf, sig)
+		}
+	default:
+		return nil, fmt.Errorf("unsupported template \"%s\"", name)
+	}
+
+	return decodeIMATemplateData(name, buf.Bytes())
+}
+
+// ParseIMAASCIIMeasurementList parses a Linux IMA measurement list in the ASCII format, as exported by the
+// kernel in /sys/kernel/security/ima/ascii_runtime_measurements. The template hash of each entry is computed
+// with the specified algorithm, which is AlgorithmSha1 for the default list. Only the "ima", "ima-ng" and
+// "ima-sig" templates are supported, because the ASCII format of other templates can't be decoded
+// unambiguously. The returned events are the same as those returned from ParseIMAMeasurementList for the
+// equivalent binary list.
+func ParseIMAASCIIMeasurementList(r io.Reader, alg AlgorithmId) ([]*Event, error) {
+	if !alg.supported() {
+		return nil, fmt.Errorf("unsupported digest algorithm %s", alg)
+	}
+
+	var events []*Event
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(nil, imaMaxTemplateData)
+	for i := uint(0); scanner.Scan(); i++ {
+		fields := strings.SplitN(scanner.Text(), " ", 4)
+		if len(fields) != 4 {
+			return nil, fmt.Errorf("line %d: missing fields", i+1)
+		}
+
+		pcr, err := strconv.ParseUint(fields[0], 10, 32)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: invalid PCR index: %w", i+1, err)
+		}
+		if !isPCRIndexInRange(PCRIndex(pcr)) {
+			return nil, fmt.Errorf("line %d: %w", i+1, wrapPCRIndexOutOfRangeError(PCRIndex(pcr)))
+		}
+
+		digest, err := hex.DecodeString(fields[1])
+		if err != nil || len(digest) != alg.size() {
+			return nil, fmt.Errorf("line %d: invalid template hash", i+1)
+		}
+
+		eventData, err := decodeIMAASCIIEntry(fields[2], fields[3])
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", i+1, err)
+		}
+
+		events = append(events, &Event{
+			Index:     i,
+			PCRIndex:  PCRIndex(pcr),
+			EventType: IMAEventType,
+			Digests:   DigestMap{alg: digest},
+			Data:      eventData})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return events, nil
+}
+
+// IMATemplateHash computes the template hash of the supplied IMA measurement list entry with the specified
+// algorithm. The kernel extends this to the PCR bank for that algorithm, although the measurement list only
+// records the template hash for one algorithm. For the "ima" template, the file digest and the file name padded
+// to 256 bytes are hashed. For other templates, the template data is hashed as it appears in the measurement list.
+func IMATemplateHash(data *IMAEventData, alg AlgorithmId) (Digest, error) {
+	if !alg.supported() {
+		return nil, fmt.Errorf("unsupported digest algorithm %s", alg)
+	}
+	if data.TemplateName != "ima" {
+		return alg.hash(data.data), nil
+	}
+	if len(data.FileName) > imaEventNameLenMax {
+		return nil, errors.New("file name is too long")
+	}
+	h := alg.newHash()
+	h.Write(data.FileDigest)
+	name := make([]byte, imaEventNameLenMax+1)
+	copy(name, data.FileName)
+	h.Write(name)
+	return h.Sum(nil), nil
+}
+
+// AddIMATemplateHashes adds the template hash for each of the specified algorithms to each of the supplied IMA
+// events, which must have data of the type *IMAEventData, so that ReplayIMAEvents can predict the value of the
+// IMA PCR in every PCR bank rather than only the one recorded in the measurement list. The algorithms are
+// normally those of the TCG event log. The template hashes of entries that record a measurement violation are
+// zero for every algorithm.
+func AddIMATemplateHashes(events []*Event, algs AlgorithmIdList) error {
+	for _, event := range events {
+		data, ok := event.Data.(*IMAEventData)
+		if !ok {
+			return fmt.Errorf("event %d: unexpected event data type %T", event.Index, event.Data)
+		}
+
+		violation := false
+		for alg, digest := range event.Digests {
+			if bytes.Equal(digest, make(Digest, alg.size())) {
+				violation = true
+			}
+		}
+
+		for _, alg := range algs {
+			if _, exists := event.Digests[alg]; exists {
+				continue
+			}
+			if violation {
+				event.Digests[alg] = make(Digest, alg.size())
+				continue
+			}
+			digest, err := IMATemplateHash(data, alg)
+			if err != nil {
+				return fmt.Errorf("event %d: %w", event.Index, err)
+			}
+			event.Digests[alg] = digest
+		}
+	}
+	return nil
+}
+
+// ReplayIMAEvents extends the digests of the supplied IMA events in to values, which may be the result of
+// Log.ReplayPCRs, so that the value of the PCR used by IMA can be predicted. Each event is extended in to the
+// banks for which it has a digest. PCRs that aren't already in values are assumed to start with a value of
+// zero. Entries with a template hash of zero indicate a measurement violation, for which the kernel extends
+// a digest with every byte set to 0xff.
+func ReplayIMAEvents(values map[PCRIndex]DigestMap, events []*Event) error {
+	for _, event := range events {
+		if !isPCRIndexInRange(event.PCRIndex) {
+			return wrapPCRIndexOutOfRangeError(event.PCRIndex)
+		}
+		if _, exists := values[event.PCRIndex]; !exists {
+			values[event.PCRIndex] = DigestMap{}
+		}
+		for alg, digest := range event.Digests {
+			if !alg.supported() {
+				return fmt.Errorf("unsupported digest algorithm %s", alg)
+			}
+			if len(digest) != alg.size() {
+				return fmt.Errorf("event %d: invalid %s digest size", event.Index, alg)
+			}
+			if bytes.Equal(digest, make(Digest, alg.size())) {
+				digest = Digest(bytes.Repeat([]byte{0xff}, alg.size()))
+			}
+			current, exists := values[event.PCRIndex][alg]
+			if !exists {
+				current = make(Digest, alg.size())
+			}
+			values[event.PCRIndex][alg] = performHashExtendOperation(alg, current, digest)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,160 @@
+package tcglog
+
+import (
+	"bytes"
+	"crypto/sha1"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func makeTestIMAEntry(name string, data []byte) (Digest, []byte) {
+	h := sha1.Sum(data)
+
+	var buf bytes.Buffer
+	binary.Write(&buf, binary.LittleEndian, uint32(10))
+	buf.Write(h[:])
+	binary.Write(&buf, binary.LittleEndian, uint32(len(name)))
+	buf.WriteString(name)
+	binary.Write(&buf, binary.LittleEndian, uint32(len(data)))
+	buf.Write(data)
+	return h[:], buf.Bytes()
+}
+
+func TestParseIMAMeasurementList(t *testing.T) {
+	fileDigest := sha1.Sum([]byte("foo"))
+
+	var ng bytes.Buffer
+	encodeIMAField(&ng, append([]byte("sha1:\x00"), fileDigest[:]...))
+	encodeIMAField(&ng, []byte("/usr/bin/foo bar\x00"))
+
+	var sig bytes.Buffer
+	sig.Write(ng.Bytes())
+	encodeIMAField(&sig, []byte{0x03, 0x02, 0x01})
+
+	var list bytes.Buffer
+	var ascii strings.Builder
+	for _, e := range []struct {
+		name  string
+		data  []byte
+		extra string
+	}{
+		{"ima-ng", ng.Bytes(), ""},
+		{"ima-sig", sig.Bytes(), " 030201"},
+	} {
+		th, entry := makeTestIMAEntry(e.name, e.data)
+		list.Write(entry)
+		fmt.Fprintf(&ascii, "10 %x %s sha1:%x /usr/bin/foo bar%s\n", th, e.name, fileDigest, e.extra)
+	}
+
+	binaryEvents, err := ParseIMAMeasurementList(&list, AlgorithmSha1)
+	if err != nil {
+		t.Fatalf("ParseIMAMeasurementList failed: %v", err)
+	}
+	asciiEvents, err := ParseIMAASCIIMeasurementList(strings.NewReader(ascii.String()), AlgorithmSha1)
+	if err != nil {
+		t.Fatalf("ParseIMAASCIIMeasurementList failed: %v", err)
+	}
+	if len(binaryEvents) != 2 || len(asciiEvents) != 2 {
+		t.Fatalf("Unexpected number of events (%d, %d)", len(binaryEvents), len(asciiEvents))
+	}
+
+	for i := range binaryEvents {
+		b := binaryEvents[i]
+		a := asciiEvents[i]
+		if b.PCRIndex != IMAPCR || !bytes.Equal(b.Digests[AlgorithmSha1], a.Digests[AlgorithmSha1]) {
+			t.Errorf("Unexpected event %d", i)
+		}
+		if !bytes.Equal(b.Data.Bytes(), a.Data.Bytes()) {
+			t.Errorf("Template data of event %d differs (%x, %x)", i, b.Data.Bytes(), a.Data.Bytes())
+		}
+		data := b.Data.(*IMAEventData)
+		if data.FileName != "/usr/bin/foo bar" || data.FileDigestAlgorithm != "sha1" ||
+			!bytes.Equal(data.FileDigest, fileDigest[:]) {
+			t.Errorf("Unexpected data for event %d: %s", i, data)
+		}
+	}
+	if hex.EncodeToString(binaryEvents[1].Data.(*IMAEventData).Signature) != "030201" {
+		t.Errorf("Unexpected signature")
+	}
+
+	values := make(map[PCRIndex]DigestMap)
+	if err := ReplayIMAEvents(values, binaryEvents); err != nil {
+		t.Fatalf("ReplayIMAEvents failed: %v", err)
+	}
+	expected := make(Digest, 20)
+	for _, e := range binaryEvents {
+		expected = performHashExtendOperation(AlgorithmSha1, expected, e.Digests[AlgorithmSha1])
+	}
+	if !bytes.Equal(values[IMAPCR][AlgorithmSha1], expected) {
+		t.Errorf("Unexpected PCR value")
+	}
+}
+
+func TestAddIMATemplateHashes(t *testing.T) {
+	fileDigest := sha1.Sum([]byte("foo"))
+
+	var ng bytes.Buffer
+	encodeIMAField(&ng, append([]byte("sha256:\x00"), AlgorithmSha256.hash([]byte("foo"))...))
+	encodeIMAField(&ng, []byte("/usr/bin/foo\x00"))
+
+	// The "ima" template hashes the file digest and the file name padded to 256 bytes.
+	var legacy bytes.Buffer
+	legacy.Write(fileDigest[:])
+	encodeIMAField(&legacy, []byte("/usr/bin/foo"))
+	legacyHashed := append(fileDigest[:], make([]byte, imaEventNameLenMax+1)...)
+	copy(legacyHashed[len(fileDigest):], "/usr/bin/foo")
+	legacyHash := sha1.Sum(legacyHashed)
+
+	var list bytes.Buffer
+	_, entry := makeTestIMAEntry("ima-ng", ng.Bytes())
+	list.Write(entry)
+	binary.Write(&list, binary.LittleEndian, uint32(IMAPCR))
+	list.Write(legacyHash[:])
+	encodeIMAField(&list, []byte("ima"))
+	encodeIMAField(&list, legacy.Bytes())
+	// A measurement violation, recorded with a zero template hash.
+	_, entry = makeTestIMAEntry("ima-ng", ng.Bytes())
+	copy(entry[4:], make([]byte, sha1.Size))
+	list.Write(entry)
+
+	events, err := ParseIMAMeasurementList(&list, AlgorithmSha1)
+	if err != nil {
+		t.Fatalf("ParseIMAMeasurementList failed: %v", err)
+	}
+	if len(events) != 3 {
+		t.Fatalf("Unexpected number of events (%d)", len(events))
+	}
+	if err := AddIMATemplateHashes(events, AlgorithmIdList{AlgorithmSha1, AlgorithmSha256}); err != nil {
+		t.Fatalf("AddIMATemplateHashes failed: %v", err)
+	}
+
+	if !bytes.Equal(events[0].Digests[AlgorithmSha256], AlgorithmSha256.hash(ng.Bytes())) {
+		t.Errorf("Unexpected SHA-256 template hash for ima-ng entry")
+	}
+	if sha1Hash, err := IMATemplateHash(events[1].Data.(*IMAEventData), AlgorithmSha1); err != nil ||
+		!bytes.Equal(sha1Hash, legacyHash[:]) {
+		t.Errorf("Unexpected SHA-1 template hash for ima entry: %x (%v)", sha1Hash, err)
+	}
+	if !bytes.Equal(events[1].Digests[AlgorithmSha256], AlgorithmSha256.hash(legacyHashed)) {
+		t.Errorf("Unexpected SHA-256 template hash for ima entry")
+	}
+	if !bytes.Equal(events[2].Digests[AlgorithmSha256], make([]byte, 32)) {
+		t.Errorf("Unexpected SHA-256 template hash for measurement violation")
+	}
+
+	values := make(map[PCRIndex]DigestMap)
+	if err := ReplayIMAEvents(values, events); err != nil {
+		t.Fatalf("ReplayIMAEvents failed: %v", err)
+	}
+	expected := make(Digest, 32)
+	for _, digest := range []Digest{AlgorithmSha256.hash(ng.Bytes()), AlgorithmSha256.hash(legacyHashed),
+		Digest(bytes.Repeat([]byte{0xff}, 32))} {
+		expected = performHashExtendOperation(AlgorithmSha256, expected, digest)
+	}
+	if !bytes.Equal(values[IMAPCR][AlgorithmSha256], expected) {
+		t.Errorf("Unexpected SHA-256 value for PCR %d", IMAPCR)
+	}
+}
@@ -0,0 +1,72 @@
+package tcglog
+
+import (
+	"fmt"
+)
+
+const (
+	// FindingImplausibleImageLoadAddress indicates that the location or length of an image recorded in an
+	// image load event is implausible, such as being zero, overlapping another image or being beyond the end
+	// of physical memory. This often indicates a bug in the firmware's measurement code.
+	FindingImplausibleImageLoadAddress FindingCode = "implausible-image-load-address"
+)
+
+type imageLoadRange struct {
+	event *Event
+	start uint64
+	end   uint64
+}
+
+// CheckImageLoadAddresses returns an informational finding for each image load event in the supplied events
+// with an implausible ImageLocationInMemory or ImageLengthInMemory: a zero location or length, a range that
+// overlaps the range of a previously loaded image, or a range that extends beyond maxPhysicalAddress. If
+// maxPhysicalAddress is zero, the last check is skipped.
+func CheckImageLoadAddresses(events []*Event, maxPhysicalAddress uint64) (out []Finding) {
+	var loaded []imageLoadRange
+
+	for _, event := range events {
+		d, ok := event.Data.(*EFIImageLoadEventData)
+		if !ok {
+			continue
+		}
+
+		var problems []string
+		if d.LocationInMemory == 0 {
+			problems = append(problems, "the image location is zero")
+		}
+		if d.LengthInMemory == 0 {
+			problems = append(problems, "the image length is zero")
+		}
+
+		end := d.LocationInMemory + d.LengthInMemory
+		switch {
+		case end < d.LocationInMemory:
+			problems = append(problems, "the image range overflows")
+		case maxPhysicalAddress > 0 && end > maxPhysicalAddress:
+			problems = append(problems, fmt.Sprintf("the image extends beyond the maximum physical "+
+				"address (0x%x)", maxPhysicalAddress))
+		}
+
+		if d.LengthInMemory > 0 && end >= d.LocationInMemory {
+			for _, r := range loaded {
+				if d.LocationInMemory < r.end && r.start < end {
+					problems = append(problems, fmt.Sprintf("the image overlaps the image loaded by "+
+						"event %d in PCR %d", r.event.Index, r.event.PCRIndex))
+					break
+				}
+			}
+			loaded = append(loaded, imageLoadRange{event: event, start: d.LocationInMemory, end: end})
+		}
+
+		for _, p := range problems {
+			out = append(out, Finding{
+				Code:     FindingImplausibleImageLoadAddress,
+				Severity: FindingSeverityInfo,
+				Event:    event,
+				Message: fmt.Sprintf("image load event has an implausible address (location: 0x%x, length: "+
+					"%d): %s", d.LocationInMemory, d.LengthInMemory, p)})
+		}
+	}
+
+	return
+}
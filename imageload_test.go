@@ -0,0 +1,43 @@
+package tcglog
+
+import (
+	"testing"
+)
+
+func TestCheckImageLoadAddresses(t *testing.T) {
+	makeEvent := func(index uint, location, length uint64) *Event {
+		return &Event{
+			Index:     index,
+			PCRIndex:  4,
+			EventType: EventTypeEFIBootServicesApplication,
+			Data:      &EFIImageLoadEventData{LocationInMemory: location, LengthInMemory: length}}
+	}
+
+	events := []*Event{
+		makeEvent(0, 0x10000, 0x1000),
+		makeEvent(1, 0x20000, 0x1000),
+		makeEvent(2, 0x10800, 0x1000),
+		makeEvent(3, 0, 0x1000),
+		makeEvent(4, 0x30000, 0),
+		makeEvent(5, 1<<52, 0x1000),
+	}
+
+	findings := CheckImageLoadAddresses(events, 1<<52)
+	var flagged []uint
+	for _, f := range findings {
+		if f.Code != FindingImplausibleImageLoadAddress || f.Severity != FindingSeverityInfo {
+			t.Errorf("Unexpected finding: %v", f)
+		}
+		flagged = append(flagged, f.Event.Index)
+	}
+
+	expected := []uint{2, 3, 4, 5}
+	if len(flagged) != len(expected) {
+		t.Fatalf("Unexpected findings: %v", flagged)
+	}
+	for i := range expected {
+		if flagged[i] != expected[i] {
+			t.Errorf("Unexpected findings: %v", flagged)
+		}
+	}
+}
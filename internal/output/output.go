@@ -0,0 +1,106 @@
+// Package output formats the text written by the command line tools. The output doesn't depend on the locale
+// of the environment, so that it can be parsed by other tools and compared between hosts.
+package output
+
+import (
+	"bytes"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+const ellipsis = "..."
+
+// Formatter formats digests, sizes and lines of output.
+type Formatter struct {
+	Out io.Writer // Where output is written. If this is nil, os.Stdout is used
+
+	// Width is the maximum width of each line of output, in characters. Longer lines are truncated. If this
+	// is zero, lines aren't truncated.
+	Width int
+
+	// DigestChars is the number of hexadecimal characters to display for each digest. Longer digests are
+	// truncated. If this is zero, digests are displayed in full.
+	DigestChars int
+}
+
+// Digest returns the hexadecimal representation of the supplied digest.
+func (f *Formatter) Digest(d []byte) string {
+	s := hex.EncodeToString(d)
+	if f.DigestChars <= 0 || len(s) <= f.DigestChars {
+		return s
+	}
+	return s[:f.DigestChars] + ellipsis
+}
+
+// Size returns the representation of the supplied size in bytes. Sizes of at least 1KiB are followed by an
+// approximation in binary units, eg, "1536 bytes (1.5KiB)".
+func (f *Formatter) Size(n int64) string {
+	s := strconv.FormatInt(n, 10) + " bytes"
+	units := []string{"KiB", "MiB", "GiB", "TiB"}
+	v := float64(n)
+	unit := ""
+	for _, u := range units {
+		if v < 1024 {
+			break
+		}
+		v /= 1024
+		unit = u
+	}
+	if unit == "" {
+		return s
+	}
+	return s + " (" + strconv.FormatFloat(v, 'f', 1, 64) + unit + ")"
+}
+
+// Line returns s truncated to the maximum width.
+func (f *Formatter) Line(s string) string {
+	if f.Width <= 0 || utf8.RuneCountInString(s) <= f.Width {
+		return s
+	}
+	if f.Width <= len(ellipsis) {
+		return ellipsis[:f.Width]
+	}
+	n := 0
+	for i := range s {
+		if n == f.Width-len(ellipsis) {
+			return s[:i] + ellipsis
+		}
+		n++
+	}
+	return s
+}
+
+// Printf formats according to the format specifier and writes the result, truncating each line to the maximum
+// width.
+func (f *Formatter) Printf(format string, a ...interface{}) {
+	w := f.Out
+	if w == nil {
+		w = os.Stdout
+	}
+	if f.Width <= 0 {
+		fmt.Fprintf(w, format, a...)
+		return
+	}
+
+	lines := strings.SplitAfter(fmt.Sprintf(format, a...), "\n")
+	var out bytes.Buffer
+	for _, line := range lines {
+		trimmed := strings.TrimSuffix(line, "\n")
+		out.WriteString(f.Line(trimmed))
+		if len(trimmed) < len(line) {
+			out.WriteString("\n")
+		}
+	}
+	w.Write(out.Bytes())
+}
+
+// Println formats its operands in the same way as fmt.Println and writes the result, truncating it to the
+// maximum width.
+func (f *Formatter) Println(a ...interface{}) {
+	f.Printf("%s", fmt.Sprintln(a...))
+}
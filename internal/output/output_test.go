@@ -0,0 +1,45 @@
+package output
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestFormatter(t *testing.T) {
+	f := &Formatter{Width: 12, DigestChars: 8}
+
+	if d := f.Digest([]byte{0x01, 0x23, 0x45, 0x67, 0x89, 0xab, 0xcd, 0xef}); d != "01234567..." {
+		t.Errorf("Unexpected digest %q", d)
+	}
+	if d := f.Digest([]byte{0x01, 0x23}); d != "0123" {
+		t.Errorf("Unexpected digest %q", d)
+	}
+
+	for _, data := range []struct {
+		size int64
+		out  string
+	}{
+		{size: 10, out: "10 bytes"},
+		{size: 1536, out: "1536 bytes (1.5KiB)"},
+		{size: 3 * 1024 * 1024, out: "3145728 bytes (3.0MiB)"},
+	} {
+		if s := f.Size(data.size); s != data.out {
+			t.Errorf("Unexpected size %q", s)
+		}
+	}
+
+	var buf bytes.Buffer
+	f.Out = &buf
+	f.Printf("short\n%s\n", "a line that is too long")
+	f.Println("ünïcödé strings")
+	if buf.String() != "short\na line th...\nünïcödé s...\n" {
+		t.Errorf("Unexpected output %q", buf.String())
+	}
+
+	buf.Reset()
+	f.Width = 0
+	f.Printf("a line that is too long\n")
+	if buf.String() != "a line that is too long\n" {
+		t.Errorf("Unexpected output %q", buf.String())
+	}
+}
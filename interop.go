@@ -0,0 +1,251 @@
+package tcglog
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// InteropDisagreement describes a difference between the interpretation of a log by this package and by
+// another parser.
+type InteropDisagreement struct {
+	EventIndex int      // The index of the event that the parsers disagree on, or -1 for the final PCR values
+	PCRIndex   PCRIndex // The PCR that the parsers disagree on, if EventIndex is -1
+	Field      string   // The field that the parsers disagree on
+	Ours       string   // This package's interpretation
+	Theirs     string   // The other parser's interpretation
+}
+
+func (d InteropDisagreement) String() string {
+	if d.EventIndex < 0 {
+		return fmt.Sprintf("PCR %d %s: ours=%s theirs=%s", d.PCRIndex, d.Field, d.Ours, d.Theirs)
+	}
+	return fmt.Sprintf("event %d %s: ours=%s theirs=%s", d.EventIndex, d.Field, d.Ours, d.Theirs)
+}
+
+type tpm2EventlogEvent struct {
+	pcr          string
+	eventType    string
+	eventSize    string
+	digests      map[string]string
+	variableName string
+	unicodeName  string
+}
+
+type tpm2EventlogOutput struct {
+	events []*tpm2EventlogEvent
+	pcrs   map[string]map[PCRIndex]string
+}
+
+var tpm2EventlogAlgorithms = []struct {
+	name string
+	alg  AlgorithmId
+}{
+	{"sha1", AlgorithmSha1},
+	{"sha256", AlgorithmSha256},
+	{"sha384", AlgorithmSha384},
+	{"sha512", AlgorithmSha512},
+	{"sm3_256", AlgorithmSm3_256},
+	{"sha3_256", AlgorithmSha3_256},
+	{"sha3_384", AlgorithmSha3_384},
+	{"sha3_512", AlgorithmSha3_512},
+}
+
+func normalizeTPM2EventlogHex(s string) string {
+	return strings.ToLower(strings.TrimPrefix(strings.TrimPrefix(s, "0x"), "0X"))
+}
+
+func splitTPM2EventlogField(line string) (key, value string) {
+	fields := strings.SplitN(line, ":", 2)
+	key = strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(fields[0]), "- "))
+	if len(fields) == 2 {
+		value = strings.Trim(strings.TrimSpace(fields[1]), "\"'")
+	}
+	return
+}
+
+// parseTPM2EventlogOutput extracts the fields that are compared from the YAML printed by tpm2_eventlog. Only the
+// subset of YAML emitted by tpm2_eventlog is understood.
+func parseTPM2EventlogOutput(r io.Reader) (*tpm2EventlogOutput, error) {
+	out := &tpm2EventlogOutput{pcrs: make(map[string]map[PCRIndex]string)}
+
+	var event *tpm2EventlogEvent
+	var alg string
+	var bank string
+	inPCRs := false
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(nil, 16*1024*1024)
+	for scanner.Scan() {
+		line := scanner.Text()
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" || trimmed == "---" {
+			continue
+		}
+		indent := len(line) - len(strings.TrimLeft(line, " "))
+
+		if indent == 0 && !strings.HasPrefix(trimmed, "- ") {
+			inPCRs = trimmed == "pcrs:"
+			continue
+		}
+
+		key, value := splitTPM2EventlogField(trimmed)
+
+		if inPCRs {
+			if value == "" {
+				bank = key
+				out.pcrs[bank] = make(map[PCRIndex]string)
+				continue
+			}
+			pcr, err := strconv.ParseUint(key, 10, 32)
+			if err != nil || bank == "" {
+				return nil, fmt.Errorf("invalid PCR value line \"%s\"", trimmed)
+			}
+			out.pcrs[bank][PCRIndex(pcr)] = normalizeTPM2EventlogHex(value)
+			continue
+		}
+
+		switch key {
+		case "EventNum":
+			event = &tpm2EventlogEvent{digests: make(map[string]string)}
+			out.events = append(out.events, event)
+			alg = ""
+			continue
+		}
+		if event == nil {
+			continue
+		}
+
+		switch key {
+		case "PCRIndex":
+			event.pcr = value
+		case "EventType":
+			event.eventType = value
+		case "EventSize":
+			event.eventSize = value
+			alg = ""
+		case "AlgorithmId":
+			alg = value
+		case "Digest":
+			if alg == "" {
+				// Events in the TCG_PCClientPCREvent format only have a SHA-1 digest.
+				alg = "sha1"
+			}
+			event.digests[alg] = normalizeTPM2EventlogHex(value)
+			alg = ""
+		case "VariableName":
+			event.variableName = strings.ToLower(value)
+		case "UnicodeName":
+			event.unicodeName = value
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// CompareTPM2EventlogOutput compares the supplied events and final PCR values, which may be obtained from
+// LogValidateResult, with the YAML printed by the tpm2_eventlog tool from tpm2-tools for the same log. This is
+// useful for detecting drift between parsers in different ecosystems. The number of events and the PCR index,
+// event type, event data size, digests and UEFI variable names of each event are compared, along with the final
+// value of each PCR. PCRs that are missing from either set of values are treated as being zero.
+func CompareTPM2EventlogOutput(events []*Event, values map[PCRIndex]DigestMap,
+	output io.Reader) ([]InteropDisagreement, error) {
+	theirs, err := parseTPM2EventlogOutput(output)
+	if err != nil {
+		return nil, fmt.Errorf("cannot parse tpm2_eventlog output: %w", err)
+	}
+
+	var out []InteropDisagreement
+	compare := func(index int, field, ours, theirs string) {
+		if ours != theirs {
+			out = append(out, InteropDisagreement{EventIndex: index, Field: field, Ours: ours, Theirs: theirs})
+		}
+	}
+
+	if len(events) != len(theirs.events) {
+		n := len(events)
+		if len(theirs.events) < n {
+			n = len(theirs.events)
+		}
+		compare(n, "event count", strconv.Itoa(len(events)), strconv.Itoa(len(theirs.events)))
+	}
+
+	for i, event := range events {
+		if i >= len(theirs.events) {
+			break
+		}
+		t := theirs.events[i]
+
+		compare(i, "PCR index", strconv.FormatUint(uint64(event.PCRIndex), 10), t.pcr)
+		if strings.HasPrefix(t.eventType, "EV_") {
+			compare(i, "event type", event.EventType.String(), t.eventType)
+		}
+		var data []byte
+		if event.Data != nil {
+			data = event.Data.Bytes()
+		}
+		if t.eventSize != "" {
+			compare(i, "event size", strconv.Itoa(len(data)), t.eventSize)
+		}
+
+		for _, a := range tpm2EventlogAlgorithms {
+			// Only digests that tpm2_eventlog reports are compared, because this package reports a zero
+			// digest for every algorithm for events in the TCG_PCClientPCREvent format.
+			theirDigest, ok := t.digests[a.name]
+			if !ok {
+				continue
+			}
+			digest := event.Digests[a.alg]
+			compare(i, a.name+" digest", fmt.Sprintf("%x", []byte(digest)), theirDigest)
+		}
+
+		if d, ok := event.Data.(*EFIVariableEventData); ok {
+			if t.variableName != "" {
+				compare(i, "variable name", efiGUIDText(&d.VariableName), t.variableName)
+			}
+			if t.unicodeName != "" {
+				compare(i, "unicode name", d.UnicodeName, t.unicodeName)
+			}
+		}
+	}
+
+	for _, a := range tpm2EventlogAlgorithms {
+		name, alg := a.name, a.alg
+		bank, ok := theirs.pcrs[name]
+		if !ok {
+			continue
+		}
+
+		pcrs := make(map[PCRIndex]DigestMap)
+		for pcr := range bank {
+			pcrs[pcr] = nil
+		}
+		for pcr, digests := range values {
+			if _, ok := digests[alg]; ok {
+				pcrs[pcr] = nil
+			}
+		}
+
+		zero := fmt.Sprintf("%x", make([]byte, alg.size()))
+		for _, pcr := range sortedPCRs(pcrs) {
+			ours := zero
+			if digest, ok := values[pcr][alg]; ok {
+				ours = fmt.Sprintf("%x", []byte(digest))
+			}
+			theirValue, ok := bank[pcr]
+			if !ok {
+				theirValue = zero
+			}
+			if ours != theirValue {
+				out = append(out, InteropDisagreement{EventIndex: -1, PCRIndex: pcr, Field: name + " value",
+					Ours: ours, Theirs: theirValue})
+			}
+		}
+	}
+
+	return out, nil
+}
@@ -0,0 +1,76 @@
+package tcglog
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// makeTestTPM2EventlogOutput produces output in the format printed by tpm2_eventlog for the supplied events.
+func makeTestTPM2EventlogOutput(events []*Event, values map[PCRIndex]DigestMap) string {
+	var buf bytes.Buffer
+	buf.WriteString("---\nversion: 1\nevents:\n")
+	for i, e := range events {
+		fmt.Fprintf(&buf, "- EventNum: %d\n  PCRIndex: %d\n  EventType: %s\n", i, e.PCRIndex, e.EventType)
+		if i == 0 {
+			fmt.Fprintf(&buf, "  Digest: \"%x\"\n  EventSize: %d\n  SpecID:\n  - Signature: Spec ID Event03\n"+
+				"    DigestSizes:\n    - AlgorithmId: sha1\n      DigestSize: 20\n", []byte(e.Digests[AlgorithmSha1]),
+				len(e.Data.Bytes()))
+			continue
+		}
+		fmt.Fprintf(&buf, "  DigestCount: %d\n  Digests:\n", len(e.Digests))
+		for _, alg := range sortedDigestAlgorithms(e.Digests) {
+			fmt.Fprintf(&buf, "  - AlgorithmId: %s\n    Digest: \"%x\"\n", strings.ToLower(strings.Replace(alg.String(), "-", "", -1)),
+				[]byte(e.Digests[alg]))
+		}
+		fmt.Fprintf(&buf, "  EventSize: %d\n  Event: \"%x\"\n", len(e.Data.Bytes()), e.Data.Bytes())
+	}
+	buf.WriteString("pcrs:\n")
+	for _, alg := range []AlgorithmId{AlgorithmSha1, AlgorithmSha256} {
+		fmt.Fprintf(&buf, "  %s:\n", strings.ToLower(strings.Replace(alg.String(), "-", "", -1)))
+		for _, pcr := range sortedPCRs(values) {
+			fmt.Fprintf(&buf, "    %-2d : 0x%X\n", pcr, []byte(values[pcr][alg]))
+		}
+	}
+	return buf.String()
+}
+
+func TestCompareTPM2EventlogOutput(t *testing.T) {
+	events := readTestEvents(t, makeTestCryptoAgileLog(t, 2))
+	values := make(map[PCRIndex]DigestMap)
+	for _, e := range events[1:] {
+		values[e.PCRIndex] = DigestMap{}
+		for _, alg := range []AlgorithmId{AlgorithmSha1, AlgorithmSha256} {
+			values[e.PCRIndex][alg] = performHashExtendOperation(alg, make(Digest, alg.size()), e.Digests[alg])
+		}
+	}
+	output := makeTestTPM2EventlogOutput(events, values)
+
+	disagreements, err := CompareTPM2EventlogOutput(events, values, strings.NewReader(output))
+	if err != nil {
+		t.Fatalf("CompareTPM2EventlogOutput failed: %v", err)
+	}
+	if len(disagreements) > 0 {
+		t.Errorf("Unexpected disagreements: %v", disagreements)
+	}
+
+	// Simulate a parser that interprets the last event as being in a different PCR.
+	output = strings.Replace(output, "- EventNum: 2\n  PCRIndex: 1\n", "- EventNum: 2\n  PCRIndex: 2\n", 1)
+	output = strings.Replace(output, "    1  : ", "    2  : ", -1)
+	disagreements, err = CompareTPM2EventlogOutput(events, values, strings.NewReader(output))
+	if err != nil {
+		t.Fatalf("CompareTPM2EventlogOutput failed: %v", err)
+	}
+	// The event, and PCRs 1 and 2 in each bank.
+	if len(disagreements) != 5 {
+		t.Fatalf("Unexpected disagreements: %v", disagreements)
+	}
+	if disagreements[0].EventIndex != 2 || disagreements[0].Field != "PCR index" || disagreements[0].Ours != "1" ||
+		disagreements[0].Theirs != "2" {
+		t.Errorf("Unexpected disagreement: %v", disagreements[0])
+	}
+	if disagreements[1].EventIndex != -1 || disagreements[1].PCRIndex != 1 {
+		t.Errorf("Unexpected disagreement: %v", disagreements[1])
+	}
+}
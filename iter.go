@@ -0,0 +1,38 @@
+//go:build go1.23
+// +build go1.23
+
+package tcglog
+
+import (
+	"io"
+	"iter"
+)
+
+// Events returns an iterator over the remaining events in the log, for use with a range statement, eg:
+//
+//	for event, err := range log.Events() {
+//		if err != nil {
+//			return err
+//		}
+//		...
+//	}
+//
+// Iteration stops at the end of the log, which isn't reported as an error. If an error occurs, it is yielded
+// with a nil event and iteration stops.
+func (l *Log) Events() iter.Seq2[*Event, error] {
+	return func(yield func(*Event, error) bool) {
+		for {
+			event, err := l.NextEvent()
+			switch {
+			case err == io.EOF:
+				return
+			case err != nil:
+				yield(nil, err)
+				return
+			}
+			if !yield(event, nil) {
+				return
+			}
+		}
+	}
+}
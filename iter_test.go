@@ -0,0 +1,54 @@
+//go:build go1.23
+// +build go1.23
+
+package tcglog
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestLogEvents(t *testing.T) {
+	data := makeTestCryptoAgileLog(t, 5)
+
+	log, err := NewLog(bytes.NewReader(data), LogOptions{})
+	if err != nil {
+		t.Fatalf("NewLog failed: %v", err)
+	}
+	n := 0
+	for event, err := range log.Events() {
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if n > 0 && event.EventType != EventTypeEFIAction {
+			t.Errorf("Unexpected event type %v", event.EventType)
+		}
+		n++
+	}
+	if n != 6 {
+		t.Errorf("Unexpected number of events (%d)", n)
+	}
+
+	log, err = NewLog(bytes.NewReader(data[:len(data)-1]), LogOptions{})
+	if err != nil {
+		t.Fatalf("NewLog failed: %v", err)
+	}
+	var lastErr error
+	for _, err := range log.Events() {
+		lastErr = err
+	}
+	if lastErr == nil {
+		t.Errorf("Expected an error for a truncated log")
+	}
+
+	log, err = NewLog(bytes.NewReader(data), LogOptions{})
+	if err != nil {
+		t.Fatalf("NewLog failed: %v", err)
+	}
+	for range log.Events() {
+		break
+	}
+	if _, err := log.NextEvent(); err != nil {
+		t.Errorf("NextEvent failed after breaking out of the loop: %v", err)
+	}
+}
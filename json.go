@@ -0,0 +1,661 @@
+package tcglog
+
+import (
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+)
+
+func (s Spec) jsonName() string {
+	switch s {
+	case SpecPCClient:
+		return "pc-client"
+	case SpecEFI_1_2:
+		return "efi-1.2"
+	case SpecEFI_2:
+		return "efi-2"
+	default:
+		return "unknown"
+	}
+}
+
+// MarshalJSON implements json.Marshaler.
+func (s Spec) MarshalJSON() ([]byte, error) {
+	return json.Marshal(s.jsonName())
+}
+
+// MarshalJSON implements json.Marshaler. Known algorithms are represented by their lower-case name, eg, "sha256".
+func (a AlgorithmId) MarshalJSON() ([]byte, error) {
+	return json.Marshal(a.jsonName())
+}
+
+func (a AlgorithmId) jsonName() string {
+	switch a {
+	case AlgorithmSha1:
+		return "sha1"
+	case AlgorithmSha256:
+		return "sha256"
+	case AlgorithmSha384:
+		return "sha384"
+	case AlgorithmSha512:
+		return "sha512"
+	case AlgorithmSm3_256:
+		return "sm3_256"
+	case AlgorithmSha3_256:
+		return "sha3_256"
+	case AlgorithmSha3_384:
+		return "sha3_384"
+	case AlgorithmSha3_512:
+		return "sha3_512"
+	default:
+		if r := lookupRegisteredAlgorithm(a); r != nil {
+			return r.name
+		}
+		return fmt.Sprintf("0x%04x", uint16(a))
+	}
+}
+
+// MarshalJSON implements json.Marshaler.
+func (e EventType) MarshalJSON() ([]byte, error) {
+	return json.Marshal(e.String())
+}
+
+// MarshalJSON implements json.Marshaler. Digests are represented as hexadecimal strings.
+func (d Digest) MarshalJSON() ([]byte, error) {
+	return json.Marshal(hex.EncodeToString(d))
+}
+
+// MarshalJSON implements json.Marshaler. The digests are represented as an object keyed by algorithm name.
+func (m DigestMap) MarshalJSON() ([]byte, error) {
+	out := make(map[string]Digest)
+	for alg, digest := range m {
+		out[alg.jsonName()] = digest
+	}
+	return json.Marshal(out)
+}
+
+// MarshalJSON implements json.Marshaler.
+func (t CCType) MarshalJSON() ([]byte, error) {
+	return json.Marshal(t.String())
+}
+
+// MarshalJSON implements json.Marshaler.
+func (p PlatformProfile) MarshalJSON() ([]byte, error) {
+	return json.Marshal(p.String())
+}
+
+// MarshalJSON implements json.Marshaler.
+func (r PFPRevision) MarshalJSON() ([]byte, error) {
+	return json.Marshal(r.String())
+}
+
+// MarshalJSON implements json.Marshaler. Only the metadata of the log and its Spec ID event are serialized, not
+// the other events.
+func (l *Log) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		Spec            Spec            `json:"spec"`
+		Algorithms      AlgorithmIdList `json:"algorithms"`
+		CCType          CCType          `json:"ccType"`
+		PlatformProfile PlatformProfile `json:"platformProfile"`
+		PFPRevision     PFPRevision     `json:"pfpRevision"`
+		SpecIdEvent     *Event          `json:"specIdEvent,omitempty"`
+	}{l.Spec, l.Algorithms, l.CCType, l.PlatformProfile, l.PFPRevision, l.specIdEvent})
+}
+
+// MarshalJSON implements json.Marshaler. The decoded event data is serialized in the "data" field, and the raw
+// event data is serialized as a hexadecimal string in the "rawData" field. The algorithms of any digests that
+// weren't recorded in the log are serialized in the "syntheticDigests" field.
+func (e *Event) MarshalJSON() ([]byte, error) {
+	var data interface{}
+	if m, ok := e.Data.(json.Marshaler); ok {
+		data = m
+	}
+	var raw string
+	if e.Data != nil {
+		raw = hex.EncodeToString(e.Data.Bytes())
+	}
+
+	return json.Marshal(struct {
+		Index            uint            `json:"index"`
+		PCRIndex         PCRIndex        `json:"pcr"`
+		EventType        EventType       `json:"type"`
+		Digests          DigestMap       `json:"digests"`
+		SyntheticDigests AlgorithmIdList `json:"syntheticDigests,omitempty"`
+		Data             interface{}     `json:"data"`
+		RawData          string          `json:"rawData"`
+	}{e.Index, e.PCRIndex, e.EventType, e.Digests, e.SyntheticDigests, data, raw})
+}
+
+// MarshalJSON implements json.Marshaler.
+func (e *BrokenEventData) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		Error string `json:"error"`
+	}{e.String()})
+}
+
+func (e *opaqueEventData) MarshalJSON() ([]byte, error) {
+	return []byte("null"), nil
+}
+
+func (e *unknownNoActionEventData) MarshalJSON() ([]byte, error) {
+	return []byte("null"), nil
+}
+
+// MarshalJSON implements json.Marshaler.
+func (e *RedactedEventData) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		Redacted bool `json:"redacted"`
+	}{true})
+}
+
+// MarshalJSON implements json.Marshaler.
+func (e *SpecIdEventData) MarshalJSON() ([]byte, error) {
+	type algSize struct {
+		Algorithm AlgorithmId `json:"algorithm"`
+		Size      uint16      `json:"size"`
+	}
+	var sizes []algSize
+	for _, s := range e.DigestSizes {
+		sizes = append(sizes, algSize{s.AlgorithmId, s.DigestSize})
+	}
+
+	return json.Marshal(struct {
+		Spec             Spec      `json:"spec"`
+		PlatformClass    uint32    `json:"platformClass"`
+		SpecVersionMinor uint8     `json:"specVersionMinor"`
+		SpecVersionMajor uint8     `json:"specVersionMajor"`
+		SpecErrata       uint8     `json:"specErrata"`
+		UintnSize        uint8     `json:"uintnSize"`
+		DigestSizes      []algSize `json:"digestSizes,omitempty"`
+		VendorInfo       string    `json:"vendorInfo"`
+	}{e.Spec, e.PlatformClass, e.SpecVersionMinor, e.SpecVersionMajor, e.SpecErrata, e.UintnSize, sizes,
+		hex.EncodeToString(e.VendorInfo)})
+}
+
+func (e *startupLocalityEventData) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		Locality uint8 `json:"locality"`
+	}{e.Locality})
+}
+
+// MarshalJSON implements json.Marshaler.
+func (e *BIMReferenceManifestEventData) MarshalJSON() ([]byte, error) {
+	var rimLocatorType, platformCertLocatorType string
+	if e.RIMLocator != nil {
+		rimLocatorType = e.RIMLocatorType.String()
+	}
+	if e.PlatformCertLocator != nil {
+		platformCertLocatorType = e.PlatformCertLocatorType.String()
+	}
+	var rimLocatorURI string
+	if uri, ok := e.RIMLocatorURI(); ok {
+		rimLocatorURI = uri
+	}
+	return json.Marshal(struct {
+		Version                 int    `json:"version"`
+		VendorId                uint32 `json:"vendorId"`
+		Guid                    string `json:"referenceManifestGuid"`
+		PlatformManufacturer    string `json:"platformManufacturer,omitempty"`
+		PlatformModel           string `json:"platformModel,omitempty"`
+		PlatformVersion         string `json:"platformVersion,omitempty"`
+		FirmwareManufacturer    string `json:"firmwareManufacturer,omitempty"`
+		FirmwareManufacturerId  uint32 `json:"firmwareManufacturerId,omitempty"`
+		FirmwareVersion         string `json:"firmwareVersion,omitempty"`
+		RIMLocatorType          string `json:"rimLocatorType,omitempty"`
+		RIMLocator              string `json:"rimLocator,omitempty"`
+		RIMLocatorURI           string `json:"rimLocatorUri,omitempty"`
+		PlatformCertLocatorType string `json:"platformCertLocatorType,omitempty"`
+		PlatformCertLocator     string `json:"platformCertLocator,omitempty"`
+	}{e.Version, e.VendorId, e.Guid.String(), e.PlatformManufacturer, e.PlatformModel, e.PlatformVersion,
+		e.FirmwareManufacturer, e.FirmwareManufacturerId, e.FirmwareVersion, rimLocatorType,
+		hex.EncodeToString(e.RIMLocator), rimLocatorURI, platformCertLocatorType,
+		hex.EncodeToString(e.PlatformCertLocator)})
+}
+
+func (e *asciiStringEventData) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		Str string `json:"string"`
+	}{e.String()})
+}
+
+// MarshalJSON implements json.Marshaler.
+func (e *PostCodeStringEventData) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		Description string `json:"description"`
+	}{e.Description})
+}
+
+// MarshalJSON implements json.Marshaler.
+func (e *CompactHashEventData) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		Description string `json:"description,omitempty"`
+	}{e.Description})
+}
+
+// MarshalJSON implements json.Marshaler.
+func (e *EFIPlatformFirmwareBlobEventData) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		Description string `json:"description,omitempty"`
+		BlobBase    uint64 `json:"blobBase"`
+		BlobLength  uint64 `json:"blobLength"`
+	}{e.Description, e.BlobBase, e.BlobLength})
+}
+
+// MarshalJSON implements json.Marshaler.
+func (e *EFIHandoffTablesEventData) MarshalJSON() ([]byte, error) {
+	type table struct {
+		VendorGUID  string `json:"vendorGuid"`
+		Name        string `json:"name,omitempty"`
+		VendorTable uint64 `json:"vendorTable"`
+	}
+	tables := make([]table, 0, len(e.Tables))
+	for _, t := range e.Tables {
+		tables = append(tables, table{VendorGUID: t.VendorGUID.String(), Name: t.Name(), VendorTable: t.VendorTable})
+	}
+	return json.Marshal(struct {
+		Description string  `json:"description,omitempty"`
+		Tables      []table `json:"tables"`
+	}{e.Description, tables})
+}
+
+// MarshalJSON implements json.Marshaler.
+func (e *SCRTMVersionEventData) MarshalJSON() ([]byte, error) {
+	if e.GUID != nil {
+		return json.Marshal(struct {
+			GUID string `json:"guid"`
+		}{e.GUID.String()})
+	}
+	return json.Marshal(struct {
+		Version string `json:"version"`
+	}{e.Version})
+}
+
+// MarshalJSON implements json.Marshaler.
+func (e *PlatformConfigFlagsEventData) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		Description    string `json:"description,omitempty"`
+		VendorSpecific bool   `json:"vendorSpecific,omitempty"`
+	}{e.Description, e.IsVendorSpecific()})
+}
+
+// MarshalJSON implements json.Marshaler.
+func (e *CPUMicrocodeEventData) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		Description string `json:"description"`
+	}{e.Description})
+}
+
+// MarshalJSON implements json.Marshaler.
+func (e *NonHostEventData) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		Description    string `json:"description,omitempty"`
+		BlobBase       uint64 `json:"blobBase,omitempty"`
+		BlobLength     uint64 `json:"blobLength,omitempty"`
+		VendorSpecific bool   `json:"vendorSpecific,omitempty"`
+	}{e.Description, e.BlobBase, e.BlobLength, e.IsVendorSpecific()})
+}
+
+// MarshalJSON implements json.Marshaler.
+func (e *OmitBootDeviceEventsEventData) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		Description string `json:"description"`
+	}{e.Description})
+}
+
+func (e *separatorEventData) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		IsError bool `json:"isError"`
+	}{e.isError})
+}
+
+// MarshalJSON implements json.Marshaler.
+func (e *EFIVariableEventData) MarshalJSON() ([]byte, error) {
+	type signature struct {
+		Owner string `json:"owner"`
+		Data  string `json:"data"`
+	}
+	type signatureList struct {
+		Type       string      `json:"type"`
+		Signatures []signature `json:"signatures"`
+	}
+	var lists []signatureList
+	for _, l := range e.SignatureLists {
+		sl := signatureList{Type: l.SignatureType.String(), Signatures: []signature{}}
+		for _, s := range l.Signatures {
+			sl.Signatures = append(sl.Signatures, signature{s.SignatureOwner.String(), hex.EncodeToString(s.Data)})
+		}
+		lists = append(lists, sl)
+	}
+
+	return json.Marshal(struct {
+		VariableName   string          `json:"variableName"`
+		UnicodeName    string          `json:"unicodeName"`
+		VariableData   string          `json:"variableData"`
+		SignatureLists []signatureList `json:"signatureLists,omitempty"`
+	}{e.VariableName.String(), e.UnicodeName, hex.EncodeToString(e.VariableData), lists})
+}
+
+// MarshalJSON implements json.Marshaler. The device path is serialized in the legacy form used by String in
+// the "devicePath" field, in the UEFI text form in the "devicePathText" field, in its binary form as a
+// hexadecimal string in the "devicePathBytes" field, and as a list of structured nodes in the "devicePathNodes"
+// field.
+func (e *EFIImageLoadEventData) MarshalJSON() ([]byte, error) {
+	nodes := make([]EFIDevicePathNode, 0, len(e.DevicePath))
+	nodes = append(nodes, e.DevicePath...)
+
+	return json.Marshal(struct {
+		LocationInMemory uint64              `json:"locationInMemory"`
+		LengthInMemory   uint64              `json:"lengthInMemory"`
+		LinkTimeAddress  uint64              `json:"linkTimeAddress"`
+		DevicePath       string              `json:"devicePath"`
+		DevicePathText   string              `json:"devicePathText"`
+		DevicePathBytes  string              `json:"devicePathBytes"`
+		DevicePathNodes  []EFIDevicePathNode `json:"devicePathNodes"`
+	}{e.LocationInMemory, e.LengthInMemory, e.LinkTimeAddress, e.DevicePath.String(), e.DevicePath.Text(),
+		hex.EncodeToString(e.RawDevicePath), nodes})
+}
+
+// MarshalJSON implements json.Marshaler.
+func (n *EFIGenericDevicePathNode) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		Node    string `json:"node"`
+		Type    uint8  `json:"type"`
+		SubType uint8  `json:"subType"`
+		Data    string `json:"data"`
+	}{"generic", uint8(n.Type), n.SubType, hex.EncodeToString(n.Data)})
+}
+
+// MarshalJSON implements json.Marshaler.
+func (n *EFIFirmwareFileDevicePathNode) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		Node string `json:"node"`
+		Name string `json:"name"`
+	}{"fvFile", efiGUIDText((*EFIGUID)(n))})
+}
+
+// MarshalJSON implements json.Marshaler.
+func (n *EFIFirmwareVolumeDevicePathNode) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		Node string `json:"node"`
+		Name string `json:"name"`
+	}{"fv", efiGUIDText((*EFIGUID)(n))})
+}
+
+// MarshalJSON implements json.Marshaler.
+func (n *EFIACPIDevicePathNode) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		Node string `json:"node"`
+		HID  uint32 `json:"hid"`
+		UID  uint32 `json:"uid"`
+	}{"acpi", n.HID, n.UID})
+}
+
+// MarshalJSON implements json.Marshaler.
+func (n *EFIPCIDevicePathNode) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		Node     string `json:"node"`
+		Device   uint8  `json:"device"`
+		Function uint8  `json:"function"`
+	}{"pci", n.Device, n.Function})
+}
+
+// MarshalJSON implements json.Marshaler.
+func (n *EFILUDevicePathNode) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		Node string `json:"node"`
+		LUN  uint8  `json:"lun"`
+	}{"unit", n.LUN})
+}
+
+// MarshalJSON implements json.Marshaler.
+func (n *EFIHardDriveDevicePathNode) MarshalJSON() ([]byte, error) {
+	var sigType, sig string
+	switch n.SignatureType {
+	case 0x01:
+		sigType = "mbr"
+		sig = fmt.Sprintf("0x%08x", binary.LittleEndian.Uint32(n.Signature[:]))
+	case 0x02:
+		sigType = "gpt"
+		sig = efiGUIDText(n.PartitionGUID())
+	default:
+		sigType = fmt.Sprintf("%d", n.SignatureType)
+		sig = hex.EncodeToString(n.Signature[:])
+	}
+
+	return json.Marshal(struct {
+		Node            string `json:"node"`
+		PartitionNumber uint32 `json:"partitionNumber"`
+		PartitionStart  uint64 `json:"partitionStart"`
+		PartitionSize   uint64 `json:"partitionSize"`
+		SignatureType   string `json:"signatureType"`
+		Signature       string `json:"signature"`
+	}{"hd", n.PartitionNumber, n.PartitionStart, n.PartitionSize, sigType, sig})
+}
+
+// MarshalJSON implements json.Marshaler.
+func (n *EFISATADevicePathNode) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		Node                     string `json:"node"`
+		HBAPortNumber            uint16 `json:"hbaPortNumber"`
+		PortMultiplierPortNumber uint16 `json:"portMultiplierPortNumber"`
+		LUN                      uint16 `json:"lun"`
+	}{"sata", n.HBAPortNumber, n.PortMultiplierPortNumber, n.LUN})
+}
+
+// MarshalJSON implements json.Marshaler.
+func (n EFIFilePathDevicePathNode) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		Node string `json:"node"`
+		Path string `json:"path"`
+	}{"file", string(n)})
+}
+
+// MarshalJSON implements json.Marshaler.
+func (n *EFIRelativeOffsetRangeDevicePathNode) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		Node           string `json:"node"`
+		StartingOffset uint64 `json:"startingOffset"`
+		EndingOffset   uint64 `json:"endingOffset"`
+	}{"offset", n.StartingOffset, n.EndingOffset})
+}
+
+func (e *efiGPTEventData) MarshalJSON() ([]byte, error) {
+	type partition struct {
+		TypeGuid   string `json:"partitionTypeGuid"`
+		UniqueGuid string `json:"uniquePartitionGuid"`
+		Name       string `json:"name"`
+	}
+	partitions := make([]partition, 0, len(e.partitions))
+	for _, p := range e.partitions {
+		partitions = append(partitions, partition{p.typeGUID.String(), p.uniqueGUID.String(), p.name})
+	}
+
+	return json.Marshal(struct {
+		DiskGuid   string      `json:"diskGuid"`
+		Partitions []partition `json:"partitions"`
+	}{e.diskGUID.String(), partitions})
+}
+
+// MarshalJSON implements json.Marshaler.
+func (e *GrubStringEventData) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		Type string `json:"type"`
+		Str  string `json:"string"`
+	}{grubEventTypeString(e.Type), e.Str})
+}
+
+// MarshalJSON implements json.Marshaler.
+func (e *GrubFileEventData) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		Path string `json:"path"`
+	}{e.Path})
+}
+
+// MarshalJSON implements json.Marshaler.
+func (e *SystemdEFIStubEventData) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		Str string `json:"string"`
+	}{e.Str})
+}
+
+// MarshalJSON implements json.Marshaler.
+func (e *TXTEventData) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		Description string `json:"description"`
+	}{e.Description})
+}
+
+// MarshalJSON implements json.Marshaler.
+func (e *SPDMDeviceSecurityEventData) MarshalJSON() ([]byte, error) {
+	type measurementBlock struct {
+		Index        uint8  `json:"index"`
+		ValueType    string `json:"valueType,omitempty"`
+		RawBitStream bool   `json:"rawBitStream,omitempty"`
+		Measurement  string `json:"measurement"`
+	}
+	type pciDevice struct {
+		VendorId          uint16 `json:"vendorId"`
+		DeviceId          uint16 `json:"deviceId"`
+		RevisionId        uint8  `json:"revisionId"`
+		ClassCode         string `json:"classCode"`
+		SubsystemVendorId uint16 `json:"subsystemVendorId"`
+		SubsystemId       uint16 `json:"subsystemId"`
+	}
+
+	var blocks []measurementBlock
+	for _, b := range e.MeasurementBlocks {
+		block := measurementBlock{Index: b.Index, Measurement: hex.EncodeToString(b.Measurement)}
+		if b.IsDMTF {
+			block.ValueType = b.ValueType.String()
+			block.RawBitStream = b.RawBitStream
+			block.Measurement = hex.EncodeToString(b.Value)
+		}
+		blocks = append(blocks, block)
+	}
+	var pci *pciDevice
+	if e.PCIDevice != nil {
+		pci = &pciDevice{
+			VendorId:          e.PCIDevice.VendorId,
+			DeviceId:          e.PCIDevice.DeviceId,
+			RevisionId:        e.PCIDevice.RevisionId,
+			ClassCode:         hex.EncodeToString(e.PCIDevice.ClassCode[:]),
+			SubsystemVendorId: e.PCIDevice.SubsystemVendorId,
+			SubsystemId:       e.PCIDevice.SubsystemId}
+	}
+	var authState string
+	if e.Version != 1 {
+		authState = e.AuthState.String()
+	}
+	var slotId *uint8
+	var certChain string
+	if e.SubHeaderType == SPDMSubHeaderCertChain {
+		slotId = &e.SlotId
+		certChain = hex.EncodeToString(e.CertChain)
+	}
+
+	return json.Marshal(struct {
+		Version           uint16             `json:"version"`
+		AuthState         string             `json:"authState,omitempty"`
+		DeviceType        string             `json:"deviceType"`
+		DevicePath        string             `json:"devicePath,omitempty"`
+		PCIDevice         *pciDevice         `json:"pciDevice,omitempty"`
+		SubHeaderType     string             `json:"subHeaderType"`
+		SubHeaderUID      uint64             `json:"subHeaderUid,omitempty"`
+		SPDMVersion       uint16             `json:"spdmVersion,omitempty"`
+		HashAlgo          uint32             `json:"hashAlgo"`
+		MeasurementBlocks []measurementBlock `json:"measurementBlocks,omitempty"`
+		SlotId            *uint8             `json:"slotId,omitempty"`
+		CertChain         string             `json:"certChain,omitempty"`
+	}{
+		Version:           e.Version,
+		AuthState:         authState,
+		DeviceType:        e.DeviceType.String(),
+		DevicePath:        e.DevicePath.Text(),
+		PCIDevice:         pci,
+		SubHeaderType:     e.SubHeaderType.String(),
+		SubHeaderUID:      e.SubHeaderUID,
+		SPDMVersion:       e.SPDMVersion,
+		HashAlgo:          e.HashAlgo,
+		MeasurementBlocks: blocks,
+		SlotId:            slotId,
+		CertChain:         certChain})
+}
+
+// MarshalJSON implements json.Marshaler.
+func (e *XenEventData) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		Str string `json:"string"`
+	}{e.Str})
+}
+
+// MarshalJSON implements json.Marshaler.
+func (e *LinuxEFIStubEventData) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		EventID     string `json:"eventId"`
+		Description string `json:"description"`
+	}{e.EventID.String(), e.Description})
+}
+
+// MarshalJSON implements json.Marshaler.
+func (e *TaggedEvent) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		EventID uint32         `json:"eventId"`
+		Data    string         `json:"data"`
+		Nested  []*TaggedEvent `json:"nested,omitempty"`
+	}{e.EventID, hex.EncodeToString(e.Data), e.Nested})
+}
+
+// MarshalJSON implements json.Marshaler.
+func (e *TaggedEventData) MarshalJSON() ([]byte, error) {
+	return e.TaggedEvent.MarshalJSON()
+}
+
+// MarshalJSON implements json.Marshaler.
+func (e *AppMeasurementEventData) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		Type   string          `json:"type"`
+		Name   string          `json:"name"`
+		Size   int64           `json:"size,omitempty"`
+		Mode   uint32          `json:"mode,omitempty"`
+		Config json.RawMessage `json:"config,omitempty"`
+		Digest string          `json:"digest,omitempty"`
+	}{e.Type.String(), e.Name, e.Size, uint32(e.Mode), json.RawMessage(e.Config), e.Digest})
+}
+
+// MarshalJSON implements json.Marshaler.
+func (s FindingSeverity) MarshalJSON() ([]byte, error) {
+	return json.Marshal(s.String())
+}
+
+// MarshalJSON implements json.Marshaler. The associated event is identified by its PCR and index rather than
+// being serialized in full.
+func (f *Finding) MarshalJSON() ([]byte, error) {
+	type eventRef struct {
+		PCRIndex PCRIndex `json:"pcr"`
+		Index    uint     `json:"index"`
+	}
+	var event *eventRef
+	if f.Event != nil {
+		event = &eventRef{f.Event.PCRIndex, f.Event.Index}
+	}
+
+	return json.Marshal(struct {
+		Code     FindingCode     `json:"code"`
+		Severity FindingSeverity `json:"severity"`
+		Event    *eventRef       `json:"event,omitempty"`
+		Message  string          `json:"message"`
+	}{f.Code, f.Severity, event, f.Message})
+}
+
+// MarshalJSON implements json.Marshaler.
+func (e *IMAEventData) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		TemplateName        string `json:"templateName"`
+		FileDigestAlgorithm string `json:"fileDigestAlgorithm,omitempty"`
+		FileDigest          string `json:"fileDigest,omitempty"`
+		FileName            string `json:"fileName,omitempty"`
+		Signature           string `json:"signature,omitempty"`
+	}{e.TemplateName, e.FileDigestAlgorithm, hex.EncodeToString(e.FileDigest), e.FileName,
+		hex.EncodeToString(e.Signature)})
+}
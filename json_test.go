@@ -0,0 +1,101 @@
+package tcglog
+
+import (
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestEventMarshalJSON(t *testing.T) {
+	dir, err := ioutil.TempDir("", "tcglog-test")
+	if err != nil {
+		t.Fatalf("TempDir failed: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "log")
+	if err := CreateLogFile(path, AlgorithmIdList{AlgorithmSha256}); err != nil {
+		t.Fatalf("CreateLogFile failed: %v", err)
+	}
+	if _, err := MeasureAndLog(path, &mockPCRExtender{}, 23, EventTypeAction, []byte("foo"), nil); err != nil {
+		t.Fatalf("MeasureAndLog failed: %v", err)
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	defer f.Close()
+	log, err := NewLog(f, LogOptions{})
+	if err != nil {
+		t.Fatalf("NewLog failed: %v", err)
+	}
+
+	meta, err := json.Marshal(log)
+	if err != nil {
+		t.Fatalf("Marshal failed: %v", err)
+	}
+	if string(meta) != `{"spec":"efi-2","algorithms":["sha256"],"ccType":"none","platformProfile":"client",`+
+		`"pfpRevision":"1.04","specIdEvent":{"index":0,"pcr":0,"type":"EV_NO_ACTION",`+
+		`"digests":{"sha1":"0000000000000000000000000000000000000000"},"data":{"spec":"efi-2",`+
+		`"platformClass":0,"specVersionMinor":0,"specVersionMajor":2,"specErrata":0,"uintnSize":2,"digestSizes":`+
+		`[{"algorithm":"sha256","size":32}],"vendorInfo":""},"rawData":`+
+		`"53706563204944204576656e743033000000000000020002010000000b00200000"}}` {
+		t.Errorf("Unexpected log metadata: %s", meta)
+	}
+
+	var events []*Event
+	for {
+		event, err := log.NextEvent()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("NextEvent failed: %v", err)
+		}
+		events = append(events, event)
+	}
+
+	data, err := json.Marshal(events[1])
+	if err != nil {
+		t.Fatalf("Marshal failed: %v", err)
+	}
+	expected := `{"index":0,"pcr":23,"type":"EV_ACTION",` +
+		`"digests":{"sha256":"2c26b46b68ffc68ff99b453c1d30413413422d706483bfa0f98a5e886266e7ae"},` +
+		`"data":{"string":"foo"},"rawData":"666f6f"}`
+	if string(data) != expected {
+		t.Errorf("Unexpected JSON: %s", data)
+	}
+
+	data, err = json.Marshal(events[0])
+	if err != nil {
+		t.Fatalf("Marshal failed: %v", err)
+	}
+	var spec struct {
+		Type             string
+		SyntheticDigests []string
+		Data             struct {
+			Spec        string
+			DigestSizes []struct {
+				Algorithm string
+				Size      int
+			}
+		}
+	}
+	if err := json.Unmarshal(data, &spec); err != nil {
+		t.Fatalf("Unmarshal failed: %v", err)
+	}
+	if spec.Type != "EV_NO_ACTION" || spec.Data.Spec != "efi-2" || len(spec.Data.DigestSizes) != 1 ||
+		spec.Data.DigestSizes[0].Algorithm != "sha256" || spec.Data.DigestSizes[0].Size != 32 {
+		t.Errorf("Unexpected JSON: %s", data)
+	}
+	if len(spec.SyntheticDigests) != 1 || spec.SyntheticDigests[0] != "sha256" {
+		t.Errorf("Unexpected synthetic digests: %s", data)
+	}
+	if !events[0].IsDigestSynthetic(AlgorithmSha256) || events[0].IsDigestSynthetic(AlgorithmSha1) {
+		t.Errorf("Unexpected synthetic digests")
+	}
+}
@@ -0,0 +1,65 @@
+package tcglog
+
+import (
+	"fmt"
+)
+
+const (
+	// DefaultMaxEventSize is the maximum size of the data of a single event that is accepted when
+	// LogOptions.MaxEventSize is zero.
+	DefaultMaxEventSize = 16 * 1024 * 1024
+
+	// DefaultMaxDigests is the maximum number of digests in a single event that is accepted when
+	// LogOptions.MaxDigests is zero.
+	DefaultMaxDigests = 16
+)
+
+// LimitExceededError is returned when reading an event from a log that exceeds one of the limits set in
+// LogOptions. This indicates that the log is corrupt or hostile, and no more events can be read from it.
+//
+// It is also the error in BrokenEventData when a length or count field in the event data describes more data
+// than the event contains. In this case, Limit is the name of the field.
+type LimitExceededError struct {
+	Limit string // The name of the limit that was exceeded ("MaxEventSize", "MaxDigests" or a field name)
+	Value uint64 // The value that was read from the log
+	Max   uint64 // The value of the limit
+}
+
+func (e *LimitExceededError) Error() string {
+	return fmt.Sprintf("log entry exceeds %s (%d > %d)", e.Limit, e.Value, e.Max)
+}
+
+func (o *LogOptions) maxEventSize() uint32 {
+	if o.MaxEventSize == 0 {
+		return DefaultMaxEventSize
+	}
+	return o.MaxEventSize
+}
+
+func (o *LogOptions) maxDigests() uint32 {
+	if o.MaxDigests == 0 {
+		return DefaultMaxDigests
+	}
+	return o.MaxDigests
+}
+
+// checkLengthField returns a *LimitExceededError if the length or count field with the specified name describes
+// more than the number of elements of elemSize bytes that can fit in the remaining bytes of r. Event data
+// decoders call this before allocating space for the elements. An error is returned if elemSize is zero.
+func checkLengthField(name string, n, elemSize uint64, r interface{ Len() int }) error {
+	if elemSize == 0 {
+		return fmt.Errorf("invalid element size for %s", name)
+	}
+	max := uint64(r.Len()) / elemSize
+	if n > max {
+		return &LimitExceededError{Limit: name, Value: n, Max: max}
+	}
+	return nil
+}
+
+func checkEventSize(options *LogOptions, size uint32) error {
+	if max := options.maxEventSize(); size > max {
+		return &LimitExceededError{Limit: "MaxEventSize", Value: uint64(size), Max: uint64(max)}
+	}
+	return nil
+}
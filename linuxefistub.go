@@ -0,0 +1,117 @@
+package tcglog
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+)
+
+// LinuxEFIStubPCR is the PCR that the Linux kernel's EFI stub measures the initrd and kernel commandline to.
+const LinuxEFIStubPCR PCRIndex = 9
+
+// LinuxEFIStubEventID corresponds to the TaggedEventID of an EV_EVENT_TAG event recorded by the Linux kernel's
+// EFI stub.
+type LinuxEFIStubEventID uint32
+
+const (
+	LinuxEFIStubEventInitrd      LinuxEFIStubEventID = 0x8f3b22ec // The initrd loaded by the stub
+	LinuxEFIStubEventLoadOptions LinuxEFIStubEventID = 0x8f3b22ed // The kernel commandline (LOADED_IMAGE::LoadOptions)
+)
+
+func (id LinuxEFIStubEventID) String() string {
+	switch id {
+	case LinuxEFIStubEventInitrd:
+		return "initrd"
+	case LinuxEFIStubEventLoadOptions:
+		return "load-options"
+	default:
+		return fmt.Sprintf("%08x", uint32(id))
+	}
+}
+
+// LinuxEFIStubEventData corresponds to the event data for an EV_EVENT_TAG event recorded by the Linux kernel's
+// EFI stub when it measures the initrd or the kernel commandline. The event data is a TCG_PCClientTaggedEvent
+// structure containing a description of what was measured. The measured data itself is not recorded in the log.
+type LinuxEFIStubEventData struct {
+	data        []byte
+	EventID     LinuxEFIStubEventID
+	Description string
+}
+
+func (e *LinuxEFIStubEventData) String() string {
+	return fmt.Sprintf("linux-efi-stub{ id=%s, description=%q }", e.EventID, e.Description)
+}
+
+func (e *LinuxEFIStubEventData) Bytes() []byte {
+	return e.data
+}
+
+// https://trustedcomputinggroup.org/wp-content/uploads/TCG_PCClientImplementation_1-21_1_00.pdf
+//  (section 11.3.2.1 "Event Tagging Structures")
+// See also drivers/firmware/efi/libstub/efi-stub-helper.c in the Linux kernel.
+func decodeEventDataLinuxEFIStub(data []byte) (EventData, int) {
+	if len(data) < 8 {
+		return nil, 0
+	}
+
+	id := LinuxEFIStubEventID(binary.LittleEndian.Uint32(data[0:]))
+	switch id {
+	case LinuxEFIStubEventInitrd, LinuxEFIStubEventLoadOptions:
+	default:
+		return nil, 0
+	}
+
+	size := binary.LittleEndian.Uint32(data[4:])
+	if int64(size) > int64(len(data)-8) {
+		return nil, 0
+	}
+
+	desc := bytes.TrimRight(data[8:8+size], "\x00")
+	if !isPrintableASCII(desc) {
+		return nil, 0
+	}
+
+	return &LinuxEFIStubEventData{data: data, EventID: id, Description: string(desc)}, len(data) - 8 - int(size)
+}
+
+// PredictLinuxEFIStubPCR predicts the value of LinuxEFIStubPCR for each of the specified algorithms when the
+// kernel's EFI stub loads a different initrd or is started with different load options, using the supplied
+// events from the current boot as a template. The initrd argument is the contents of the new initrd, and the
+// loadOptions argument is the new LOADED_IMAGE::LoadOptions buffer, which is normally a NUL terminated UTF-16
+// kernel commandline. Either can be nil, in which case the corresponding measurement from the log is reused.
+// Other events in LinuxEFIStubPCR, such as those recorded by a bootloader, are reused with their existing
+// digests.
+func PredictLinuxEFIStubPCR(events []*Event, algorithms AlgorithmIdList,
+	initrd, loadOptions []byte) (DigestMap, error) {
+	if err := checkPredictionAlgorithms(algorithms); err != nil {
+		return nil, err
+	}
+
+	updates := map[LinuxEFIStubEventID][]byte{
+		LinuxEFIStubEventInitrd:      initrd,
+		LinuxEFIStubEventLoadOptions: loadOptions}
+	applied := make(map[LinuxEFIStubEventID]bool)
+
+	var digests []DigestMap
+	for _, event := range events {
+		if event.PCRIndex != LinuxEFIStubPCR || !doesEventTypeExtendPCR(event.EventType) {
+			continue
+		}
+
+		d, ok := event.Data.(*LinuxEFIStubEventData)
+		if !ok || updates[d.EventID] == nil {
+			digests = append(digests, event.Digests)
+			continue
+		}
+		applied[d.EventID] = true
+		digests = append(digests, computeEventDigests(algorithms, updates[d.EventID]))
+	}
+
+	for _, id := range []LinuxEFIStubEventID{LinuxEFIStubEventInitrd, LinuxEFIStubEventLoadOptions} {
+		if updates[id] != nil && !applied[id] {
+			return nil, fmt.Errorf("no %s event is measured in the log", id)
+		}
+	}
+
+	return extendPredictedDigests(algorithms, digests)
+}
@@ -0,0 +1,140 @@
+package tcglog
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+)
+
+func makeTestTaggedEventData(id uint32, desc string) []byte {
+	data := make([]byte, 8, 8+len(desc)+1)
+	binary.LittleEndian.PutUint32(data[0:], id)
+	binary.LittleEndian.PutUint32(data[4:], uint32(len(desc)+1))
+	data = append(data, desc...)
+	return append(data, 0)
+}
+
+func TestDecodeEventDataLinuxEFIStub(t *testing.T) {
+	for _, data := range []struct {
+		desc  string
+		data  []byte
+		id    LinuxEFIStubEventID
+		descr string
+	}{
+		{
+			desc:  "initrd",
+			data:  makeTestTaggedEventData(0x8f3b22ec, "Linux initrd"),
+			id:    LinuxEFIStubEventInitrd,
+			descr: "Linux initrd",
+		},
+		{
+			desc:  "load-options",
+			data:  makeTestTaggedEventData(0x8f3b22ed, "LOADED_IMAGE::LoadOptions"),
+			id:    LinuxEFIStubEventLoadOptions,
+			descr: "LOADED_IMAGE::LoadOptions",
+		},
+	} {
+		t.Run(data.desc, func(t *testing.T) {
+			out, _, err := decodeEventDataTCG(EventTypeEventTag, data.data, false)
+			if err != nil {
+				t.Fatalf("decodeEventDataTCG failed: %v", err)
+			}
+			d, ok := out.(*LinuxEFIStubEventData)
+			if !ok {
+				t.Fatalf("Unexpected event data type %T", out)
+			}
+			if d.EventID != data.id {
+				t.Errorf("Unexpected event ID %s", d.EventID)
+			}
+			if d.Description != data.descr {
+				t.Errorf("Unexpected description %q", d.Description)
+			}
+		})
+	}
+
+	out, _, err := decodeEventDataTCG(EventTypeEventTag, makeTestTaggedEventData(0x12345678, "foo"), false)
+	if err != nil {
+		t.Fatalf("decodeEventDataTCG failed: %v", err)
+	}
+	if _, ok := out.(*TaggedEventData); !ok {
+		t.Errorf("Unexpected event data type %T for unknown tag", out)
+	}
+}
+
+func makeTestLinuxEFIStubEvent(id LinuxEFIStubEventID, desc string, measured []byte) *Event {
+	data := makeTestTaggedEventData(uint32(id), desc)
+	return &Event{
+		PCRIndex:  LinuxEFIStubPCR,
+		EventType: EventTypeEventTag,
+		Digests:   DigestMap{AlgorithmSha256: AlgorithmSha256.hash(measured)},
+		Data:      &LinuxEFIStubEventData{data: data, EventID: id, Description: desc}}
+}
+
+func TestPredictLinuxEFIStubPCR(t *testing.T) {
+	events := []*Event{
+		{PCRIndex: LinuxEFIStubPCR, EventType: EventTypeIPL,
+			Digests: DigestMap{AlgorithmSha256: AlgorithmSha256.hash([]byte("/vmlinuz"))},
+			Data:    &asciiStringEventData{data: []byte("/vmlinuz")}},
+		makeTestLinuxEFIStubEvent(LinuxEFIStubEventLoadOptions, "LOADED_IMAGE::LoadOptions", []byte("cmdline")),
+		makeTestLinuxEFIStubEvent(LinuxEFIStubEventInitrd, "Linux initrd", []byte("initrd")),
+		{PCRIndex: 8, EventType: EventTypeIPL,
+			Digests: DigestMap{AlgorithmSha256: AlgorithmSha256.hash([]byte("foo"))}},
+	}
+
+	extend := func(measurements ...string) Digest {
+		out := make(Digest, AlgorithmSha256.size())
+		for _, m := range measurements {
+			out = performHashExtendOperation(AlgorithmSha256, out, AlgorithmSha256.hash([]byte(m)))
+		}
+		return out
+	}
+
+	for _, data := range []struct {
+		desc        string
+		initrd      []byte
+		loadOptions []byte
+		expected    Digest
+	}{
+		{desc: "Unchanged", expected: extend("/vmlinuz", "cmdline", "initrd")},
+		{desc: "Initrd", initrd: []byte("newinitrd"), expected: extend("/vmlinuz", "cmdline", "newinitrd")},
+		{desc: "LoadOptions", loadOptions: []byte("newcmdline"), expected: extend("/vmlinuz", "newcmdline", "initrd")},
+		{desc: "Both", initrd: []byte("newinitrd"), loadOptions: []byte("newcmdline"),
+			expected: extend("/vmlinuz", "newcmdline", "newinitrd")},
+	} {
+		t.Run(data.desc, func(t *testing.T) {
+			value, err := PredictLinuxEFIStubPCR(events, AlgorithmIdList{AlgorithmSha256}, data.initrd,
+				data.loadOptions)
+			if err != nil {
+				t.Fatalf("PredictLinuxEFIStubPCR failed: %v", err)
+			}
+			if !bytes.Equal(value[AlgorithmSha256], data.expected) {
+				t.Errorf("Unexpected prediction")
+			}
+		})
+	}
+
+	_, err := PredictLinuxEFIStubPCR(events[:2], AlgorithmIdList{AlgorithmSha256}, []byte("newinitrd"), nil)
+	if err == nil || err.Error() != "no initrd event is measured in the log" {
+		t.Errorf("Unexpected error: %v", err)
+	}
+	if _, err := PredictLinuxEFIStubPCR(events, nil, nil, nil); err == nil {
+		t.Errorf("Expected an error with no algorithms")
+	}
+}
+
+func TestMeasuredComponentsLinuxEFIStub(t *testing.T) {
+	events := []*Event{
+		makeTestLinuxEFIStubEvent(LinuxEFIStubEventLoadOptions, "LOADED_IMAGE::LoadOptions", []byte("cmdline")),
+		makeTestLinuxEFIStubEvent(LinuxEFIStubEventInitrd, "Linux initrd", []byte("initrd")),
+	}
+	components := MeasuredComponents(events, nil)
+	if len(components) != 1 {
+		t.Fatalf("Unexpected number of components (%d)", len(components))
+	}
+	if components[0].Event != events[1] {
+		t.Errorf("Unexpected component event")
+	}
+	if components[0].Name() != "Linux initrd" {
+		t.Errorf("Unexpected component name %q", components[0].Name())
+	}
+}
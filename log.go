@@ -6,21 +6,48 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"math"
 )
 
 // LogOptions allows the behaviour of Log to be controlled.
 type LogOptions struct {
-	EnableGrub           bool     // Enable support for interpreting events recorded by GRUB
-	EnableSystemdEFIStub bool     // Enable support for interpreting events recorded by systemd's EFI linux loader stub
-	SystemdEFIStubPCR    PCRIndex // Specify the PCR that systemd's EFI linux loader stub measures to
+	EnableGrub            bool        // Enable support for interpreting events recorded by GRUB
+	GrubVariant           GrubVariant // Specify the variant of GRUB that recorded events
+	EnableSystemdEFIStub  bool        // Enable support for interpreting events recorded by systemd's EFI linux loader stub
+	SystemdEFIStubPCR     PCRIndex    // Specify the PCR that systemd's EFI linux loader stub measures to
+	EnableXen             bool        // Enable support for interpreting events recorded by Xen during a measured launch
+	EnableAppMeasurements bool        // Enable support for interpreting application-level measurements (see MeasureFile)
+
+	// XenPCRs contains the PCRs that events recorded by Xen are decoded from if EnableXen is set. XenPCRs() is
+	// used if this is nil.
+	XenPCRs []PCRIndex
+
+	// MaxEventSize is the maximum size of the data of a single event, in bytes. DefaultMaxEventSize is used if
+	// this is zero. This prevents a corrupt or hostile log from causing large allocations.
+	MaxEventSize uint32
+
+	// MaxDigests is the maximum number of digests in a single event in a crypto-agile log. DefaultMaxDigests is
+	// used if this is zero.
+	MaxDigests uint32
+
+	// Strict makes any violation of the specification a hard error, which is useful when testing firmware. By
+	// default, the parser tolerates violations that it can recover from, such as a Spec ID event that describes
+	// an unsupported digest algorithm or an event with a duplicate or missing digest, and records them as
+	// warnings that can be obtained with Log.Warnings. Note that the expected values of PCR banks for which an
+	// event is missing a digest can't be computed correctly.
+	Strict bool
+
+	// PreserveUnknownDigests retains digests for algorithms that are described by the Spec ID event but aren't
+	// supported, as opaque bytes in Event.Digests, so that they aren't lost when auditing or re-serializing
+	// logs. These algorithms are still omitted from Log.Algorithms, and their digests are ignored when
+	// replaying and validating the log.
+	PreserveUnknownDigests bool
+
+	// PlatformProfile selects the class of platform that produced the log, which determines how some PCRs are
+	// interpreted. By default, it is inferred from the Spec ID event. See Log.PlatformProfile.
+	PlatformProfile PlatformProfile
 }
 
-var zeroDigests = map[AlgorithmId][]byte{
-	AlgorithmSha1:   make([]byte, AlgorithmSha1.size()),
-	AlgorithmSha256: make([]byte, AlgorithmSha256.size()),
-	AlgorithmSha384: make([]byte, AlgorithmSha384.size()),
-	AlgorithmSha512: make([]byte, AlgorithmSha512.size())}
-
 type stream interface {
 	readNextEvent() (*Event, int, error)
 }
@@ -42,54 +69,61 @@ func isDigestOfSeparatorErrorValue(digest Digest, alg AlgorithmId) bool {
 	return bytes.Compare(digest, alg.hash(errorValue)) == 0
 }
 
-func wrapLogReadError(origErr error, partial bool) error {
-	if origErr == io.EOF {
-		if !partial {
-			return origErr
-		}
-		origErr = io.ErrUnexpectedEOF
-	}
-
-	return fmt.Errorf("error when reading from log stream (%v)", origErr)
+type eventHeader_1_2 struct {
+	PCRIndex  PCRIndex
+	EventType EventType
 }
 
-func wrapPCRIndexOutOfRangeError(pcrIndex PCRIndex) error {
-	return fmt.Errorf("log entry has an out-of-range PCR index (%d)", pcrIndex)
+// readUint32 reads a little-endian uint32 from r, using buf as scratch space. This avoids the reflection and
+// allocations performed by binary.Read, which are noticeable when parsing large logs.
+func readUint32(r io.Reader, buf []byte) (uint32, error) {
+	if _, err := io.ReadFull(r, buf[:4]); err != nil {
+		return 0, err
+	}
+	return binary.LittleEndian.Uint32(buf), nil
 }
 
-type eventHeader_1_2 struct {
-	PCRIndex  PCRIndex
-	EventType EventType
+func readUint16(r io.Reader, buf []byte) (uint16, error) {
+	if _, err := io.ReadFull(r, buf[:2]); err != nil {
+		return 0, err
+	}
+	return binary.LittleEndian.Uint16(buf), nil
 }
 
 type stream_1_2 struct {
-	r       io.ReadSeeker
+	r       io.Reader
 	options LogOptions
+	buf     [8]byte
 }
 
 // https://trustedcomputinggroup.org/wp-content/uploads/TCG_PCClientImplementation_1-21_1_00.pdf
 //  (section 11.1.1 "TCG_PCClientPCREventStruct Structure")
 func (s *stream_1_2) readNextEvent() (*Event, int, error) {
-	var header eventHeader_1_2
-	if err := binary.Read(s.r, binary.LittleEndian, &header); err != nil {
+	if _, err := io.ReadFull(s.r, s.buf[:8]); err != nil {
 		return nil, 0, wrapLogReadError(err, false)
 	}
+	header := eventHeader_1_2{
+		PCRIndex:  PCRIndex(binary.LittleEndian.Uint32(s.buf[0:])),
+		EventType: EventType(binary.LittleEndian.Uint32(s.buf[4:]))}
 
 	if !isPCRIndexInRange(header.PCRIndex) {
 		return nil, 0, wrapPCRIndexOutOfRangeError(header.PCRIndex)
 	}
 
 	digest := make(Digest, AlgorithmSha1.size())
-	if _, err := s.r.Read(digest); err != nil {
+	if _, err := io.ReadFull(s.r, digest); err != nil {
 		return nil, 0, wrapLogReadError(err, true)
 	}
 	digests := make(DigestMap)
 	digests[AlgorithmSha1] = digest
 
-	var eventSize uint32
-	if err := binary.Read(s.r, binary.LittleEndian, &eventSize); err != nil {
+	eventSize, err := readUint32(s.r, s.buf[:])
+	if err != nil {
 		return nil, 0, wrapLogReadError(err, true)
 	}
+	if err := checkEventSize(&s.options, eventSize); err != nil {
+		return nil, 0, err
+	}
 
 	event := make([]byte, eventSize)
 	if _, err := io.ReadFull(s.r, event); err != nil {
@@ -114,10 +148,13 @@ type eventHeader_2 struct {
 }
 
 type stream_2 struct {
-	r              io.ReadSeeker
+	r              io.Reader
 	options        LogOptions
 	algSizes       []EFISpecIdEventAlgorithmSize
 	readFirstEvent bool
+	stopAtPadding  bool // The log is contained in a fixed size area padded with 0xff bytes
+	warn           func(err error)
+	buf            [12]byte
 }
 
 // https://trustedcomputinggroup.org/wp-content/uploads/TCG_PCClientSpecPlat_TPM_2p0_1p04_pub.pdf
@@ -125,26 +162,38 @@ type stream_2 struct {
 func (s *stream_2) readNextEvent() (*Event, int, error) {
 	if !s.readFirstEvent {
 		s.readFirstEvent = true
-		stream := stream_1_2{r: s.r}
+		stream := stream_1_2{r: s.r, options: s.options}
 		return stream.readNextEvent()
 	}
 
-	var header eventHeader_2
-	if err := binary.Read(s.r, binary.LittleEndian, &header); err != nil {
+	if _, err := io.ReadFull(s.r, s.buf[:12]); err != nil {
 		return nil, 0, wrapLogReadError(err, false)
 	}
+	header := eventHeader_2{
+		PCRIndex:  PCRIndex(binary.LittleEndian.Uint32(s.buf[0:])),
+		EventType: EventType(binary.LittleEndian.Uint32(s.buf[4:])),
+		Count:     binary.LittleEndian.Uint32(s.buf[8:])}
+
+	if s.stopAtPadding && header.PCRIndex == math.MaxUint32 && header.EventType == math.MaxUint32 {
+		return nil, 0, io.EOF
+	}
 
 	if !isPCRIndexInRange(header.PCRIndex) {
 		return nil, 0, wrapPCRIndexOutOfRangeError(header.PCRIndex)
 	}
 
+	if max := s.options.maxDigests(); header.Count > max {
+		return nil, 0, &LimitExceededError{Limit: "MaxDigests", Value: uint64(header.Count), Max: uint64(max)}
+	}
+
 	digests := make(DigestMap)
 
 	for i := uint32(0); i < header.Count; i++ {
-		var algorithmId AlgorithmId
-		if err := binary.Read(s.r, binary.LittleEndian, &algorithmId); err != nil {
+		id, err := readUint16(s.r, s.buf[:])
+		if err != nil {
 			return nil, 0, wrapLogReadError(err, true)
 		}
+		algorithmId := AlgorithmId(id)
 
 		var digestSize uint16
 		var j int
@@ -156,8 +205,7 @@ func (s *stream_2) readNextEvent() (*Event, int, error) {
 		}
 
 		if j == len(s.algSizes) {
-			return nil, 0, fmt.Errorf("crypto-agile log entry contains a digest for an unrecognized "+
-				"algorithm (%s)", algorithmId)
+			return nil, 0, &UnknownAlgorithmError{Algorithm: algorithmId}
 		}
 
 		digest := make(Digest, digestSize)
@@ -166,39 +214,62 @@ func (s *stream_2) readNextEvent() (*Event, int, error) {
 		}
 
 		if _, exists := digests[algorithmId]; exists {
-			return nil, 0, fmt.Errorf("crypto-agile log entry contains more than one digest value "+
-				"for algorithm %s", algorithmId)
+			err := fmt.Errorf("crypto-agile log entry contains more than one digest value for algorithm %s",
+				algorithmId)
+			if s.options.Strict {
+				return nil, 0, err
+			}
+			s.warn(err)
+			continue
 		}
 		digests[algorithmId] = digest
 	}
 
 	for _, algSize := range s.algSizes {
 		if _, exists := digests[algSize.AlgorithmId]; !exists {
-			return nil, 0,
-				fmt.Errorf("crypto-agile log entry is missing a digest value for algorithm %s "+
-					"that was present in the Spec ID Event", algSize.AlgorithmId)
+			err := &MissingDigestError{Algorithm: algSize.AlgorithmId}
+			if s.options.Strict {
+				return nil, 0, err
+			}
+			s.warn(err)
 		}
 	}
 
-	for alg, _ := range digests {
-		if alg.supported() {
+	for alg, digest := range digests {
+		switch {
+		case alg.supported() && len(digest) == alg.size():
+			continue
+		case !alg.supported() && s.options.PreserveUnknownDigests:
 			continue
 		}
 		delete(digests, alg)
 	}
 
-	var eventSize uint32
-	if err := binary.Read(s.r, binary.LittleEndian, &eventSize); err != nil {
+	eventSize, err := readUint32(s.r, s.buf[:])
+	if err != nil {
 		return nil, 0, wrapLogReadError(err, true)
 	}
+	if err := checkEventSize(&s.options, eventSize); err != nil {
+		return nil, 0, err
+	}
 
 	event := make([]byte, eventSize)
 	if _, err := io.ReadFull(s.r, event); err != nil {
 		return nil, 0, wrapLogReadError(err, true)
 	}
 
+	// Use the first algorithm that is supported to determine whether this is the digest of a separator error.
+	hasDigestOfSeparatorError := false
+	for _, algSize := range s.algSizes {
+		if _, ok := digests[algSize.AlgorithmId]; ok && algSize.AlgorithmId.supported() {
+			hasDigestOfSeparatorError =
+				isDigestOfSeparatorErrorValue(digests[algSize.AlgorithmId], algSize.AlgorithmId)
+			break
+		}
+	}
+
 	data, trailing := decodeEventData(header.PCRIndex, header.EventType, event, &s.options,
-		isDigestOfSeparatorErrorValue(digests[s.algSizes[0].AlgorithmId], s.algSizes[0].AlgorithmId))
+		hasDigestOfSeparatorError)
 
 	return &Event{
 		PCRIndex:  header.PCRIndex,
@@ -222,7 +293,8 @@ func fixupSpecIdEvent(event *Event, algorithms AlgorithmIdList) {
 			continue
 		}
 
-		event.Digests[alg] = zeroDigests[alg]
+		event.Digests[alg] = make(Digest, alg.size())
+		event.SyntheticDigests = append(event.SyntheticDigests, alg)
 	}
 }
 
@@ -233,11 +305,35 @@ func isSpecIdEvent(event *Event) (out bool) {
 
 // Log corresponds to an event log parser instance, and allows the consumer to iterate over log entries.
 type Log struct {
-	Spec         Spec            // The specification to which this log conforms
-	Algorithms   AlgorithmIdList // The digest algorithms that appear in the log
-	stream       stream
-	failed       bool
-	indexTracker map[PCRIndex]uint
+	Spec            Spec            // The specification to which this log conforms
+	Algorithms      AlgorithmIdList // The digest algorithms that appear in the log
+	CCType          CCType          // The type of confidential computing environment that produced this log, if any
+	PlatformProfile PlatformProfile // The class of platform that produced this log
+	PFPRevision     PFPRevision     // The revision of the PC Client Platform Firmware Profile that this log conforms to
+	specIdEvent     *Event
+	stream          stream
+	failed          bool
+	indexTracker    map[PCRIndex]uint
+	pending         []error
+	warnings        []*LogWarning
+}
+
+func (l *Log) warn(err error) {
+	l.pending = append(l.pending, err)
+}
+
+// SpecIdEvent returns the Spec ID event at the start of the log, or nil if the log doesn't start with one. This
+// is available without iterating over the log. The event is returned as it is recorded in the log, which is
+// always in the TCG_PCClientPCREvent format with only a SHA-1 digest, even for crypto-agile logs. The returned
+// event shouldn't be modified.
+func (l *Log) SpecIdEvent() *Event {
+	return l.specIdEvent
+}
+
+// Warnings returns the specification violations that have been tolerated so far whilst parsing the log. This is
+// always empty if LogOptions.Strict is set.
+func (l *Log) Warnings() []*LogWarning {
+	return l.warnings
 }
 
 func (l *Log) nextEventInternal() (*Event, int, error) {
@@ -248,6 +344,7 @@ func (l *Log) nextEventInternal() (*Event, int, error) {
 
 	event, trailing, err := l.stream.readNextEvent()
 	if err != nil {
+		l.pending = nil
 		if err != io.EOF {
 			l.failed = true
 		}
@@ -262,6 +359,12 @@ func (l *Log) nextEventInternal() (*Event, int, error) {
 		l.indexTracker[event.PCRIndex] = 1
 	}
 
+	for _, err := range l.pending {
+		l.warnings = append(l.warnings,
+			&LogWarning{PCRIndex: event.PCRIndex, EventType: event.EventType, Index: event.Index, Err: err})
+	}
+	l.pending = nil
+
 	if isSpecIdEvent(event) {
 		fixupSpecIdEvent(event, l.Algorithms)
 	}
@@ -278,45 +381,98 @@ func (l *Log) NextEvent() (event *Event, err error) {
 
 // NewLog creates a new Log instance that reads an event log from r
 func NewLog(r io.ReaderAt, options LogOptions) (*Log, error) {
-	var stream stream = &stream_1_2{r: io.NewSectionReader(r, 0, (1<<63)-1), options: options}
+	return newLog(r, options, CCTypeNone)
+}
+
+// NewLogFromReader creates a new Log instance that reads an event log from r. Unlike NewLog, r doesn't need to
+// support random access, so logs can be consumed as they are read from a pipe, a socket or a decompressor. The
+// first event is buffered internally in order to determine the format of the log, and the rest of the log is
+// read from r as events are requested with NextEvent.
+func NewLogFromReader(r io.Reader, options LogOptions) (*Log, error) {
+	return newLogFromReader(r, options, CCTypeNone)
+}
+
+func newLog(r io.ReaderAt, options LogOptions, ccType CCType) (*Log, error) {
+	return newLogFromReader(io.NewSectionReader(r, 0, (1<<63)-1), options, ccType)
+}
+
+func newLogFromReader(r io.Reader, options LogOptions, ccType CCType) (*Log, error) {
+	// Keep a copy of the first event so that it can be read again once the format of the log is known.
+	var first bytes.Buffer
+	var stream stream = &stream_1_2{r: io.TeeReader(r, &first), options: options}
 	event, _, err := stream.readNextEvent()
-	if err != nil {
+	if err == io.EOF {
 		return nil, wrapLogReadError(err, true)
 	}
+	if err != nil {
+		return nil, err
+	}
+	r = io.MultiReader(&first, r)
 
 	var spec Spec = SpecUnknown
 	var digestSizes []EFISpecIdEventAlgorithmSize
 	var algorithms AlgorithmIdList
+	var specIdEvent *Event
 
 	switch d := event.Data.(type) {
 	case *SpecIdEventData:
 		spec = d.Spec
 		digestSizes = d.DigestSizes
+		specIdEvent = event
 	case *BrokenEventData:
-		if _, isSpecErr := d.Error.(invalidSpecIdEventError); isSpecErr {
+		switch d.Error.(type) {
+		case invalidSpecIdEventError, *InvalidDigestSizeError:
 			return nil, d.Error
 		}
 	}
 
+	log := &Log{Spec: spec,
+		CCType:          ccType,
+		PlatformProfile: determinePlatformProfile(options.PlatformProfile, specIdEvent),
+		PFPRevision:     determinePFPRevision(specIdEvent),
+		specIdEvent:     specIdEvent,
+		failed:          false,
+		indexTracker:    map[PCRIndex]uint{}}
+
 	if spec == SpecEFI_2 {
 		algorithms = make(AlgorithmIdList, 0, len(digestSizes))
 		for _, specAlgSize := range digestSizes {
 			if specAlgSize.AlgorithmId.supported() {
-				algorithms = append(algorithms, specAlgSize.AlgorithmId)
+				if int(specAlgSize.DigestSize) == specAlgSize.AlgorithmId.size() {
+					algorithms = append(algorithms, specAlgSize.AlgorithmId)
+					continue
+				}
+				// Digests for supported algorithms with the wrong size are skipped.
+				err := &DigestSizeMismatchError{Algorithm: specAlgSize.AlgorithmId,
+					DigestSize: specAlgSize.DigestSize}
+				if options.Strict {
+					return nil, err
+				}
+				log.pending = append(log.pending, err)
+				continue
+			}
+			// Digests for unsupported algorithms are skipped, or retained as opaque bytes if requested.
+			if options.PreserveUnknownDigests {
+				continue
+			}
+			err := &UnknownAlgorithmError{Algorithm: specAlgSize.AlgorithmId}
+			if options.Strict {
+				return nil, err
 			}
+			log.pending = append(log.pending, err)
 		}
-		stream = &stream_2{r: io.NewSectionReader(r, 0, (1<<63)-1),
+		stream = &stream_2{r: r,
 			options:        options,
 			algSizes:       digestSizes,
-			readFirstEvent: false}
+			readFirstEvent: false,
+			stopAtPadding:  ccType != CCTypeNone,
+			warn:           log.warn}
 	} else {
 		algorithms = AlgorithmIdList{AlgorithmSha1}
-		stream.(*stream_1_2).r.Seek(0, io.SeekStart)
+		stream = &stream_1_2{r: r, options: options}
 	}
 
-	return &Log{Spec: spec,
-		Algorithms:   algorithms,
-		stream:       stream,
-		failed:       false,
-		indexTracker: map[PCRIndex]uint{}}, nil
+	log.Algorithms = algorithms
+	log.stream = stream
+	return log, nil
 }
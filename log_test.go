@@ -0,0 +1,370 @@
+package tcglog
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+)
+
+func BenchmarkLogNextEvent(b *testing.B) {
+	// Approximately 4MB
+	data := makeTestCryptoAgileLog(b, 40000)
+	b.SetBytes(int64(len(data)))
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		log, err := NewLog(bytes.NewReader(data), LogOptions{})
+		if err != nil {
+			b.Fatalf("NewLog failed: %v", err)
+		}
+		for {
+			_, err := log.NextEvent()
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				b.Fatalf("NextEvent failed: %v", err)
+			}
+		}
+	}
+}
+
+func TestLogLimits(t *testing.T) {
+	data := makeTestCryptoAgileLog(t, 1)
+
+	// Corrupt the event size of the last event.
+	corrupt := append([]byte(nil), data...)
+	binary.LittleEndian.PutUint32(corrupt[len(corrupt)-len("Calling EFI Application from Boot Option")-4:],
+		0xffffffff)
+	log, err := NewLog(bytes.NewReader(corrupt), LogOptions{})
+	if err != nil {
+		t.Fatalf("NewLog failed: %v", err)
+	}
+	if _, err := log.NextEvent(); err != nil {
+		t.Fatalf("NextEvent failed: %v", err)
+	}
+	_, err = log.NextEvent()
+	if e, ok := err.(*LimitExceededError); !ok || e.Limit != "MaxEventSize" || e.Value != 0xffffffff ||
+		e.Max != DefaultMaxEventSize {
+		t.Errorf("Unexpected error: %v", err)
+	}
+
+	// The digest count of the last event is 2.
+	log, err = NewLog(bytes.NewReader(data), LogOptions{MaxDigests: 1})
+	if err != nil {
+		t.Fatalf("NewLog failed: %v", err)
+	}
+	if _, err := log.NextEvent(); err != nil {
+		t.Fatalf("NextEvent failed: %v", err)
+	}
+	_, err = log.NextEvent()
+	if e, ok := err.(*LimitExceededError); !ok || e.Limit != "MaxDigests" || e.Value != 2 || e.Max != 1 {
+		t.Errorf("Unexpected error: %v", err)
+	}
+
+	// The Spec ID event is larger than 16 bytes.
+	_, err = NewLog(bytes.NewReader(data), LogOptions{MaxEventSize: 16})
+	if _, ok := err.(*LimitExceededError); !ok {
+		t.Errorf("Unexpected error: %v", err)
+	}
+}
+
+func TestLogErrors(t *testing.T) {
+	data := makeTestCryptoAgileLog(t, 1)
+
+	log, err := NewLog(bytes.NewReader(data[:len(data)-1]), LogOptions{})
+	if err != nil {
+		t.Fatalf("NewLog failed: %v", err)
+	}
+	log.NextEvent()
+	_, err = log.NextEvent()
+	var readErr *LogReadError
+	if !errors.As(err, &readErr) || !errors.Is(err, ErrUnexpectedEOF) {
+		t.Errorf("Unexpected error for a truncated log: %v", err)
+	}
+
+	// Set the PCR index of the last event to 32.
+	corrupt := append([]byte(nil), data...)
+	offset := len(corrupt) - len("Calling EFI Application from Boot Option") - 4 - 20 - 2 - 32 - 2 - 12
+	binary.LittleEndian.PutUint32(corrupt[offset:], 32)
+	log, err = NewLog(bytes.NewReader(corrupt), LogOptions{})
+	if err != nil {
+		t.Fatalf("NewLog failed: %v", err)
+	}
+	log.NextEvent()
+	_, err = log.NextEvent()
+	var pcrErr *PCRIndexOutOfRangeError
+	if !errors.As(err, &pcrErr) || pcrErr.PCRIndex != 32 {
+		t.Errorf("Unexpected error for an out-of-range PCR: %v", err)
+	}
+
+	// Change the algorithm of the first digest of the last event to SHA-384.
+	corrupt = append([]byte(nil), data...)
+	binary.LittleEndian.PutUint16(corrupt[offset+12:], uint16(AlgorithmSha384))
+	log, err = NewLog(bytes.NewReader(corrupt), LogOptions{})
+	if err != nil {
+		t.Fatalf("NewLog failed: %v", err)
+	}
+	log.NextEvent()
+	_, err = log.NextEvent()
+	var algErr *UnknownAlgorithmError
+	if !errors.As(err, &algErr) || algErr.Algorithm != AlgorithmSha384 {
+		t.Errorf("Unexpected error for an unknown algorithm: %v", err)
+	}
+
+	if _, err := NewLog(bytes.NewReader(nil), LogOptions{}); !errors.Is(err, ErrUnexpectedEOF) {
+		t.Errorf("Unexpected error for an empty log: %v", err)
+	}
+}
+
+func TestLogTolerant(t *testing.T) {
+	var buf bytes.Buffer
+	buf.Write(makeTestCryptoAgileLog(t, 0))
+
+	// An event that is missing a SHA-256 digest, followed by one with a duplicate SHA-1 digest.
+	data := []byte("Calling EFI Application from Boot Option")
+	for _, algs := range [][]AlgorithmId{
+		{AlgorithmSha1},
+		{AlgorithmSha1, AlgorithmSha256, AlgorithmSha1}} {
+		binary.Write(&buf, binary.LittleEndian,
+			eventHeader_2{PCRIndex: 4, EventType: EventTypeEFIAction, Count: uint32(len(algs))})
+		for _, alg := range algs {
+			binary.Write(&buf, binary.LittleEndian, alg)
+			buf.Write(alg.hash(data))
+		}
+		binary.Write(&buf, binary.LittleEndian, uint32(len(data)))
+		buf.Write(data)
+	}
+
+	log, err := NewLog(bytes.NewReader(buf.Bytes()), LogOptions{})
+	if err != nil {
+		t.Fatalf("NewLog failed: %v", err)
+	}
+	var events []*Event
+	for {
+		event, err := log.NextEvent()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("NextEvent failed: %v", err)
+		}
+		events = append(events, event)
+	}
+	if len(events) != 3 {
+		t.Fatalf("Unexpected number of events (%d)", len(events))
+	}
+	if _, ok := events[1].Digests[AlgorithmSha256]; ok {
+		t.Errorf("Unexpected SHA-256 digest")
+	}
+	if len(events[2].Digests) != 2 {
+		t.Errorf("Unexpected digests: %v", events[2].Digests)
+	}
+	warnings := log.Warnings()
+	if len(warnings) != 2 {
+		t.Fatalf("Unexpected number of warnings (%d)", len(warnings))
+	}
+	if warnings[0].PCRIndex != 4 || warnings[0].Index != 0 || warnings[1].Index != 1 {
+		t.Errorf("Unexpected warnings: %v, %v", warnings[0], warnings[1])
+	}
+
+	// The replayed SHA-256 value of PCR 4 can't be trusted, and this is reported.
+	log, err = NewLog(bytes.NewReader(buf.Bytes()), LogOptions{})
+	if err != nil {
+		t.Fatalf("NewLog failed: %v", err)
+	}
+	result, err := replayAndValidateLog(context.Background(), log, int64(buf.Len()), LogValidateOptions{})
+	if err != nil {
+		t.Fatalf("replayAndValidateLog failed: %v", err)
+	}
+	var missing []Finding
+	for _, f := range result.AllFindings {
+		switch {
+		case f.Code == FindingMissingDigest:
+			missing = append(missing, f)
+		case f.Code == FindingSpecViolation && f.Event == result.ValidatedEvents[1].Event:
+			// The missing digest is only reported once.
+			t.Errorf("Unexpected %s finding: %v", FindingSpecViolation, f)
+		}
+	}
+	if len(missing) != 1 || missing[0].Event != result.ValidatedEvents[1].Event {
+		t.Errorf("Unexpected %s findings: %v", FindingMissingDigest, missing)
+	}
+	var missingErr *MissingDigestError
+	if !errors.As(log.Warnings()[0], &missingErr) || missingErr.Algorithm != AlgorithmSha256 {
+		t.Errorf("Unexpected warning: %v", log.Warnings()[0])
+	}
+
+	// With LogOptions.Strict, the missing and duplicate digests are hard errors.
+	log, err = NewLog(bytes.NewReader(buf.Bytes()), LogOptions{Strict: true})
+	if err != nil {
+		t.Fatalf("NewLog failed: %v", err)
+	}
+	log.NextEvent()
+	if _, err := log.NextEvent(); err == nil || !strings.Contains(err.Error(), "missing a digest value") {
+		t.Errorf("Unexpected error for a missing digest: %v", err)
+	}
+}
+
+func TestLogDuplicateDigest(t *testing.T) {
+	var buf bytes.Buffer
+	buf.Write(makeTestCryptoAgileLog(t, 0))
+
+	data := []byte("Calling EFI Application from Boot Option")
+	algs := []AlgorithmId{AlgorithmSha1, AlgorithmSha256, AlgorithmSha1}
+	binary.Write(&buf, binary.LittleEndian,
+		eventHeader_2{PCRIndex: 4, EventType: EventTypeEFIAction, Count: uint32(len(algs))})
+	for _, alg := range algs {
+		binary.Write(&buf, binary.LittleEndian, alg)
+		buf.Write(alg.hash(data))
+	}
+	binary.Write(&buf, binary.LittleEndian, uint32(len(data)))
+	buf.Write(data)
+
+	log, err := NewLog(bytes.NewReader(buf.Bytes()), LogOptions{Strict: true})
+	if err != nil {
+		t.Fatalf("NewLog failed: %v", err)
+	}
+	log.NextEvent()
+	if _, err := log.NextEvent(); err == nil || !strings.Contains(err.Error(), "more than one digest value") {
+		t.Errorf("Unexpected error for a duplicate digest: %v", err)
+	}
+	if len(log.Warnings()) > 0 {
+		t.Errorf("Unexpected warnings")
+	}
+}
+
+func TestLogUnsupportedSpecIdAlgorithm(t *testing.T) {
+	data := makeTestCryptoAgileLogWithDigestSizes(t, []EFISpecIdEventAlgorithmSize{
+		{AlgorithmId: AlgorithmSha1, DigestSize: uint16(AlgorithmSha1.size())},
+		{AlgorithmId: 0x8002, DigestSize: 32}}, 1)
+
+	var algErr *UnknownAlgorithmError
+	if _, err := NewLog(bytes.NewReader(data), LogOptions{Strict: true}); !errors.As(err, &algErr) ||
+		algErr.Algorithm != 0x8002 {
+		t.Errorf("Unexpected error for an unsupported algorithm: %v", err)
+	}
+
+	log, err := NewLog(bytes.NewReader(data), LogOptions{})
+	if err != nil {
+		t.Fatalf("NewLog failed: %v", err)
+	}
+	if len(log.Algorithms) != 1 || log.Algorithms[0] != AlgorithmSha1 {
+		t.Errorf("Unexpected algorithms: %v", log.Algorithms)
+	}
+	if _, err := log.NextEvent(); err != nil {
+		t.Fatalf("NextEvent failed: %v", err)
+	}
+	if warnings := log.Warnings(); len(warnings) != 1 || !errors.As(warnings[0], &algErr) {
+		t.Errorf("Unexpected warnings: %v", warnings)
+	}
+}
+
+func TestPreserveUnknownDigests(t *testing.T) {
+	const algorithmUnknown AlgorithmId = 0x8002
+	algSizes := []EFISpecIdEventAlgorithmSize{
+		{AlgorithmId: AlgorithmSha1, DigestSize: uint16(AlgorithmSha1.size())},
+		{AlgorithmId: algorithmUnknown, DigestSize: 6}}
+	data := makeTestCryptoAgileLogWithDigestSizes(t, algSizes, 3)
+
+	for _, preserve := range []bool{false, true} {
+		log, err := NewLog(bytes.NewReader(data), LogOptions{PreserveUnknownDigests: preserve})
+		if err != nil {
+			t.Fatalf("NewLog failed: %v", err)
+		}
+		if log.Algorithms.Contains(algorithmUnknown) {
+			t.Errorf("Unsupported algorithms shouldn't be in Log.Algorithms")
+		}
+		result, err := replayAndValidateLog(context.Background(), log, int64(len(data)), LogValidateOptions{})
+		if err != nil {
+			t.Fatalf("replayAndValidateLog failed: %v", err)
+		}
+		if _, ok := result.ExpectedPCRValues[0][algorithmUnknown]; ok {
+			t.Errorf("Unsupported algorithms shouldn't be replayed")
+		}
+
+		event := result.ValidatedEvents[1].Event
+		digest, ok := event.Digests[algorithmUnknown]
+		if ok != preserve {
+			t.Fatalf("Unexpected presence of digest for unknown algorithm (preserve: %v)", preserve)
+		}
+		if !preserve {
+			continue
+		}
+		if !bytes.Equal(digest, bytes.Repeat([]byte{0xa5}, 6)) {
+			t.Errorf("Unexpected digest: %x", digest)
+		}
+
+		// Re-serialize the log and check that nothing is lost.
+		var buf bytes.Buffer
+		w, err := NewLogWriterWithDigestSizes(&buf, algSizes)
+		if err != nil {
+			t.Fatalf("NewLogWriterWithDigestSizes failed: %v", err)
+		}
+		for _, e := range result.ValidatedEvents {
+			if err := w.WriteEvent(e.Event); err != nil {
+				t.Fatalf("WriteEvent failed: %v", err)
+			}
+		}
+		if !bytes.Equal(buf.Bytes(), data) {
+			t.Errorf("Re-serialized log is different")
+		}
+	}
+}
+
+func TestSpecIdEventDigestSizes(t *testing.T) {
+	data := makeTestCryptoAgileLogWithDigestSizes(t, []EFISpecIdEventAlgorithmSize{
+		{AlgorithmId: AlgorithmSha1, DigestSize: uint16(AlgorithmSha1.size())},
+		{AlgorithmId: AlgorithmSha256, DigestSize: 20}}, 2)
+
+	log, err := NewLog(bytes.NewReader(data), LogOptions{})
+	if err != nil {
+		t.Fatalf("NewLog failed: %v", err)
+	}
+	if len(log.Algorithms) != 1 || log.Algorithms[0] != AlgorithmSha1 {
+		t.Errorf("Unexpected algorithms: %v", log.Algorithms)
+	}
+	result, err := replayAndValidateLog(context.Background(), log, int64(len(data)), LogValidateOptions{})
+	if err != nil {
+		t.Fatalf("replayAndValidateLog failed: %v", err)
+	}
+	for _, e := range result.ValidatedEvents[1:] {
+		if _, ok := e.Event.Digests[AlgorithmSha256]; ok {
+			t.Errorf("Unexpected SHA-256 digest")
+		}
+		if len(e.IncorrectDigestValues) > 0 {
+			t.Errorf("Unexpected incorrect digests")
+		}
+	}
+	var found bool
+	for _, f := range result.AllFindings {
+		if f.Code == FindingDigestSizeMismatch {
+			found = true
+		}
+	}
+	if !found {
+		t.Errorf("Missing %s finding", FindingDigestSizeMismatch)
+	}
+
+	var sizeErr *DigestSizeMismatchError
+	if _, err := NewLog(bytes.NewReader(data), LogOptions{Strict: true}); !errors.As(err, &sizeErr) ||
+		sizeErr.Algorithm != AlgorithmSha256 || sizeErr.DigestSize != 20 {
+		t.Errorf("Unexpected error for a mismatched digest size: %v", err)
+	}
+
+	for _, size := range []uint16{0, 65} {
+		data := makeTestCryptoAgileLogWithDigestSizes(t, []EFISpecIdEventAlgorithmSize{
+			{AlgorithmId: AlgorithmSha1, DigestSize: uint16(AlgorithmSha1.size())},
+			{AlgorithmId: 0x8002, DigestSize: size}}, 1)
+		var invalidErr *InvalidDigestSizeError
+		if _, err := NewLog(bytes.NewReader(data), LogOptions{}); !errors.As(err, &invalidErr) ||
+			invalidErr.DigestSize != size {
+			t.Errorf("Unexpected error for an impossible digest size of %d: %v", size, err)
+		}
+	}
+}
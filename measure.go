@@ -0,0 +1,181 @@
+package tcglog
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// PCRExtender is implemented by TPM backends that can extend PCRs.
+type PCRExtender interface {
+	// ExtendPCR extends the specified PCR with the supplied digests, one for each bank.
+	ExtendPCR(pcr PCRIndex, digests DigestMap) error
+}
+
+// CreateLogFile creates a new crypto-agile event log at path, containing only a Spec ID event that declares the
+// specified digest algorithms. This is useful for agents that maintain their own log of measurements made to
+// PCRs that aren't used by the firmware, such as PCR 23. It fails if the file already exists.
+func CreateLogFile(path string, algorithms AlgorithmIdList) error {
+	var buf bytes.Buffer
+	w, err := NewLogWriter(&buf, SpecEFI_2, algorithms)
+	if err != nil {
+		return err
+	}
+	if err := w.WriteSpecIdEvent(0, nil); err != nil {
+		return err
+	}
+
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(buf.Bytes()); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// writeFileAtomic replaces the file at path with data by writing it to a temporary file in the same directory
+// and then renaming it, so that readers never observe a partially written file.
+func writeFileAtomic(path string, data []byte, perm os.FileMode) error {
+	f, err := ioutil.TempFile(filepath.Dir(path), "."+filepath.Base(path)+".")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Chmod(perm); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(f.Name(), path)
+}
+
+// measureMu serialises calls to MeasureAndLog within this process, including on platforms where logLock doesn't
+// provide any protection.
+var measureMu sync.Mutex
+
+// MeasureAndLog measures a new event. It computes the digests of measuredData for each algorithm in the log at
+// logPath, extends the specified PCR with them using extender, and then appends an event with the specified type
+// and event data to the log. If measuredData is nil, the event data is measured.
+//
+// An exclusive lock is held on the log for the duration of the call, so that concurrent callers in this and
+// other processes extend the TPM in the same order that their events are appended to the log. On platforms that
+// don't support file locking, only callers within this process are serialised.
+//
+// The TPM is extended before the log is updated. If updating the log fails after the TPM has been extended,
+// the log will no longer be consistent with the TPM and an error is returned.
+func MeasureAndLog(logPath string, extender PCRExtender, pcrIndex PCRIndex, eventType EventType, eventData,
+	measuredData []byte) (*Event, error) {
+	if !isPCRIndexInRange(pcrIndex) {
+		return nil, wrapPCRIndexOutOfRangeError(pcrIndex)
+	}
+	if measuredData == nil {
+		measuredData = eventData
+	}
+
+	measureMu.Lock()
+	defer measureMu.Unlock()
+
+	f, err := os.OpenFile(logPath, os.O_RDWR|os.O_APPEND, 0)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	if err := lockLogFile(f); err != nil {
+		return nil, fmt.Errorf("cannot lock log: %w", err)
+	}
+	defer unlockLogFile(f)
+
+	logData, err := ioutil.ReadAll(f)
+	if err != nil {
+		return nil, err
+	}
+
+	log, err := NewLog(bytes.NewReader(logData), LogOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("cannot read log: %w", err)
+	}
+
+	var algSizes []EFISpecIdEventAlgorithmSize
+	if log.Spec == SpecEFI_2 {
+		first, err := log.NextEvent()
+		if err != nil {
+			return nil, fmt.Errorf("cannot read log: %w", err)
+		}
+		algSizes = first.Data.(*SpecIdEventData).DigestSizes
+		for _, s := range algSizes {
+			if !s.AlgorithmId.supported() {
+				return nil, fmt.Errorf("cannot measure to a log that contains digests for an unsupported "+
+					"algorithm (%s)", s.AlgorithmId)
+			}
+		}
+	}
+
+	// Make sure that the existing log can be parsed correctly before extending anything.
+	for {
+		_, err := log.NextEvent()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("cannot read log: %w", err)
+		}
+	}
+
+	digests := make(DigestMap)
+	for _, alg := range log.Algorithms {
+		digests[alg] = alg.hash(measuredData)
+	}
+
+	var buf bytes.Buffer
+	if log.Spec == SpecEFI_2 {
+		err = writeEvent_2(&buf, pcrIndex, eventType, digests, algSizes, eventData)
+	} else {
+		err = writeEvent_1_2(&buf, pcrIndex, eventType, digests[AlgorithmSha1], eventData)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("cannot encode event: %w", err)
+	}
+
+	if err := extender.ExtendPCR(pcrIndex, digests); err != nil {
+		return nil, fmt.Errorf("cannot extend PCR: %w", err)
+	}
+
+	if _, err := f.Write(buf.Bytes()); err != nil {
+		// Don't leave a partial event at the end of the log.
+		f.Truncate(int64(len(logData)))
+		return nil, fmt.Errorf("cannot update log after extending PCR: %w", err)
+	}
+	if err := f.Sync(); err != nil {
+		return nil, fmt.Errorf("cannot update log after extending PCR: %w", err)
+	}
+
+	return &Event{
+		PCRIndex:  pcrIndex,
+		EventType: eventType,
+		Digests:   digests,
+		Data:      &opaqueEventData{data: eventData}}, nil
+}
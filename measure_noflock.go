@@ -0,0 +1,16 @@
+//go:build !linux && !darwin && !dragonfly && !freebsd && !netbsd && !openbsd
+// +build !linux,!darwin,!dragonfly,!freebsd,!netbsd,!openbsd
+
+package tcglog
+
+import (
+	"os"
+)
+
+func lockLogFile(f *os.File) error {
+	return nil
+}
+
+func unlockLogFile(f *os.File) error {
+	return nil
+}
@@ -0,0 +1,216 @@
+package tcglog
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"io/ioutil"
+	"os"
+	"fmt"
+	"path/filepath"
+	"sync"
+	"testing"
+)
+
+type mockPCRExtender struct {
+	mu     sync.Mutex
+	values map[PCRIndex]DigestMap
+	err    error
+}
+
+func (e *mockPCRExtender) ExtendPCR(pcr PCRIndex, digests DigestMap) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.err != nil {
+		return e.err
+	}
+	if e.values == nil {
+		e.values = make(map[PCRIndex]DigestMap)
+	}
+	if _, ok := e.values[pcr]; !ok {
+		e.values[pcr] = make(DigestMap)
+	}
+	for alg, digest := range digests {
+		value, ok := e.values[pcr][alg]
+		if !ok {
+			value = make(Digest, alg.size())
+		}
+		e.values[pcr][alg] = performHashExtendOperation(alg, value, digest)
+	}
+	return nil
+}
+
+func TestMeasureAndLog(t *testing.T) {
+	dir, err := ioutil.TempDir("", "tcglog-test")
+	if err != nil {
+		t.Fatalf("TempDir failed: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "log")
+	if err := CreateLogFile(path, AlgorithmIdList{AlgorithmSha1, AlgorithmSha256}); err != nil {
+		t.Fatalf("CreateLogFile failed: %v", err)
+	}
+
+	extender := &mockPCRExtender{}
+	if _, err := MeasureAndLog(path, extender, 23, EventTypeIPL, []byte("foo"), nil); err != nil {
+		t.Fatalf("MeasureAndLog failed: %v", err)
+	}
+	event, err := MeasureAndLog(path, extender, 23, EventTypeIPL, []byte("bar"), []byte("measured"))
+	if err != nil {
+		t.Fatalf("MeasureAndLog failed: %v", err)
+	}
+	if !bytes.Equal(event.Digests[AlgorithmSha256], AlgorithmSha256.hash([]byte("measured"))) {
+		t.Errorf("Unexpected digest")
+	}
+
+	result, err := ReplayAndValidateLog(path, LogOptions{})
+	if err != nil {
+		t.Fatalf("ReplayAndValidateLog failed: %v", err)
+	}
+	if len(result.ValidatedEvents) != 3 {
+		t.Fatalf("Unexpected number of events (%d)", len(result.ValidatedEvents))
+	}
+	if !bytes.Equal(result.ValidatedEvents[2].Event.Data.Bytes(), []byte("bar")) {
+		t.Errorf("Unexpected event data")
+	}
+	for _, alg := range result.Algorithms {
+		if !bytes.Equal(result.ExpectedPCRValues[23][alg], extender.values[23][alg]) {
+			t.Errorf("Unexpected PCR value for %s", alg)
+		}
+	}
+}
+
+func TestMeasureAndLogConcurrent(t *testing.T) {
+	dir, err := ioutil.TempDir("", "tcglog-test")
+	if err != nil {
+		t.Fatalf("TempDir failed: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "log")
+	if err := CreateLogFile(path, AlgorithmIdList{AlgorithmSha256}); err != nil {
+		t.Fatalf("CreateLogFile failed: %v", err)
+	}
+
+	const n = 32
+	extender := &mockPCRExtender{}
+	var wg sync.WaitGroup
+	errs := make(chan error, n)
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			_, err := MeasureAndLog(path, extender, 23, EventTypeIPL, []byte(fmt.Sprintf("event %d", i)), nil)
+			errs <- err
+		}(i)
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		if err != nil {
+			t.Errorf("MeasureAndLog failed: %v", err)
+		}
+	}
+
+	// Every event must be in the log, in the order that the PCR was extended.
+	result, err := ReplayAndValidateLog(path, LogOptions{})
+	if err != nil {
+		t.Fatalf("ReplayAndValidateLog failed: %v", err)
+	}
+	if len(result.ValidatedEvents) != n+1 {
+		t.Errorf("Unexpected number of events (%d)", len(result.ValidatedEvents))
+	}
+	if !bytes.Equal(result.ExpectedPCRValues[23][AlgorithmSha256], extender.values[23][AlgorithmSha256]) {
+		t.Errorf("Log is inconsistent with the PCR value")
+	}
+}
+
+func TestMeasureAndLogExtendFailure(t *testing.T) {
+	dir, err := ioutil.TempDir("", "tcglog-test")
+	if err != nil {
+		t.Fatalf("TempDir failed: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "log")
+	if err := CreateLogFile(path, AlgorithmIdList{AlgorithmSha256}); err != nil {
+		t.Fatalf("CreateLogFile failed: %v", err)
+	}
+
+	extender := &mockPCRExtender{err: errors.New("TPM failure")}
+	if _, err := MeasureAndLog(path, extender, 23, EventTypeIPL, []byte("foo"), nil); err == nil {
+		t.Fatalf("MeasureAndLog should have failed")
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	defer f.Close()
+	log, err := NewLog(f, LogOptions{})
+	if err != nil {
+		t.Fatalf("NewLog failed: %v", err)
+	}
+	n := 0
+	for {
+		_, err := log.NextEvent()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("NextEvent failed: %v", err)
+		}
+		n++
+	}
+	if n != 1 {
+		t.Errorf("Log should not have been modified")
+	}
+}
+
+func TestReplayAndValidateLogPCRValues(t *testing.T) {
+	dir, err := ioutil.TempDir("", "tcglog-test")
+	if err != nil {
+		t.Fatalf("TempDir failed: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "log")
+	if err := CreateLogFile(path, AlgorithmIdList{AlgorithmSha256}); err != nil {
+		t.Fatalf("CreateLogFile failed: %v", err)
+	}
+
+	extender := &mockPCRExtender{}
+	if _, err := MeasureAndLog(path, extender, 23, EventTypeIPL, []byte("foo"), nil); err != nil {
+		t.Fatalf("MeasureAndLog failed: %v", err)
+	}
+
+	values := map[PCRIndex]DigestMap{
+		22: DigestMap{AlgorithmSha256: preLaunchDRTMPCRValue(AlgorithmSha256)},
+		23: DigestMap{AlgorithmSha256: extender.values[23][AlgorithmSha256]}}
+	result, err := ReplayAndValidateLogWithOptions(path, LogOptions{}, LogValidateOptions{PCRValues: values})
+	if err != nil {
+		t.Fatalf("ReplayAndValidateLog failed: %v", err)
+	}
+	for _, f := range result.AllFindings {
+		if f.Code == FindingPCRValueMismatch {
+			t.Errorf("Unexpected finding: %s", f.Message)
+		}
+	}
+
+	values[22][AlgorithmSha256] = AlgorithmSha256.hash([]byte("bar"))
+	result, err = ReplayAndValidateLogWithOptions(path, LogOptions{},
+		LogValidateOptions{PCRValues: values, ResettablePCRs: []PCRIndex{}})
+	if err != nil {
+		t.Fatalf("ReplayAndValidateLog failed: %v", err)
+	}
+	n := 0
+	for _, f := range result.AllFindings {
+		if f.Code == FindingPCRValueMismatch {
+			n++
+		}
+	}
+	if n != 1 {
+		t.Errorf("Unexpected number of mismatches (%d)", n)
+	}
+}
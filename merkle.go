@@ -0,0 +1,153 @@
+package tcglog
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+)
+
+// https://www.rfc-editor.org/rfc/rfc6962.html
+//  (section 2.1 "Merkle Hash Trees")
+const (
+	merkleLeafPrefix = 0x00
+	merkleNodePrefix = 0x01
+)
+
+func merkleLeafHash(event *Event) (Digest, error) {
+	var buf bytes.Buffer
+	buf.WriteByte(merkleLeafPrefix)
+	if err := writeCanonicalEvent(&buf, event); err != nil {
+		return nil, err
+	}
+	h := sha256.Sum256(buf.Bytes())
+	return h[:], nil
+}
+
+func merkleNodeHash(left, right Digest) Digest {
+	h := sha256.New()
+	h.Write([]byte{merkleNodePrefix})
+	h.Write(left)
+	h.Write(right)
+	return h.Sum(nil)
+}
+
+// merkleSplit returns the largest power of 2 that is smaller than n, which must be greater than 1.
+func merkleSplit(n int) int {
+	k := 1
+	for k<<1 < n {
+		k <<= 1
+	}
+	return k
+}
+
+func merkleTreeHash(leaves []Digest) Digest {
+	switch len(leaves) {
+	case 0:
+		h := sha256.Sum256(nil)
+		return h[:]
+	case 1:
+		return leaves[0]
+	}
+	k := merkleSplit(len(leaves))
+	return merkleNodeHash(merkleTreeHash(leaves[:k]), merkleTreeHash(leaves[k:]))
+}
+
+func merklePath(index int, leaves []Digest) []Digest {
+	if len(leaves) <= 1 {
+		return nil
+	}
+	k := merkleSplit(len(leaves))
+	if index < k {
+		return append(merklePath(index, leaves[:k]), merkleTreeHash(leaves[k:]))
+	}
+	return append(merklePath(index-k, leaves[k:]), merkleTreeHash(leaves[:k]))
+}
+
+// MerkleTree is a Merkle hash tree over a sequence of events, constructed as described in RFC 6962 using
+// SHA-256. Each leaf is the hash of the canonical encoding of an event, as used by Canonicalize. The root hash
+// commits to every event in the sequence and their order, so it can be recorded during attestation in place of
+// the log, and an InclusionProof can be used later to prove that a specific event was part of it.
+type MerkleTree struct {
+	leaves []Digest
+}
+
+// NewMerkleTree builds a Merkle tree over the supplied events.
+func NewMerkleTree(events []*Event) (*MerkleTree, error) {
+	t := &MerkleTree{}
+	for i, event := range events {
+		leaf, err := merkleLeafHash(event)
+		if err != nil {
+			return nil, fmt.Errorf("event %d: %w", i, err)
+		}
+		t.leaves = append(t.leaves, leaf)
+	}
+	return t, nil
+}
+
+// Len returns the number of events in the tree.
+func (t *MerkleTree) Len() int {
+	return len(t.leaves)
+}
+
+// Root returns the root hash of the tree.
+func (t *MerkleTree) Root() Digest {
+	return merkleTreeHash(t.leaves)
+}
+
+// InclusionProof proves that an event is part of a Merkle tree with a particular root hash.
+type InclusionProof struct {
+	Index    int      // The index of the event in the sequence of events that the tree was built from
+	TreeSize int      // The number of events in the tree
+	Path     []Digest // The audit path, from the leaf to the root
+}
+
+// InclusionProof returns a proof that the event at the specified index is part of the tree.
+func (t *MerkleTree) InclusionProof(index int) (*InclusionProof, error) {
+	if index < 0 || index >= len(t.leaves) {
+		return nil, fmt.Errorf("index %d is out of range", index)
+	}
+	return &InclusionProof{Index: index, TreeSize: len(t.leaves), Path: merklePath(index, t.leaves)}, nil
+}
+
+// VerifyInclusionProof verifies that the supplied event is part of the Merkle tree with the specified root hash,
+// at the position recorded in proof. The verifier doesn't need access to the other events.
+func VerifyInclusionProof(root Digest, event *Event, proof *InclusionProof) error {
+	if proof.Index < 0 || proof.Index >= proof.TreeSize {
+		return errors.New("invalid proof index")
+	}
+
+	leaf, err := merkleLeafHash(event)
+	if err != nil {
+		return err
+	}
+
+	// https://www.rfc-editor.org/rfc/rfc9162.html
+	//  (section 2.1.3.2 "Verifying an Inclusion Proof")
+	fn := proof.Index
+	sn := proof.TreeSize - 1
+	r := leaf
+	for _, p := range proof.Path {
+		if sn == 0 {
+			return errors.New("audit path is too long")
+		}
+		if fn&1 == 1 || fn == sn {
+			r = merkleNodeHash(p, r)
+			for fn&1 == 0 && fn != 0 {
+				fn >>= 1
+				sn >>= 1
+			}
+		} else {
+			r = merkleNodeHash(r, p)
+		}
+		fn >>= 1
+		sn >>= 1
+	}
+	if sn != 0 {
+		return errors.New("audit path is too short")
+	}
+	if !bytes.Equal(r, root) {
+		return errors.New("root hash mismatch")
+	}
+	return nil
+}
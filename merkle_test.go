@@ -0,0 +1,47 @@
+package tcglog
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestMerkleTree(t *testing.T) {
+	events := readTestEvents(t, makeTestCryptoAgileLog(t, 6))
+
+	tree, err := NewMerkleTree(events)
+	if err != nil {
+		t.Fatalf("NewMerkleTree failed: %v", err)
+	}
+	if tree.Len() != len(events) {
+		t.Errorf("Unexpected length (%d)", tree.Len())
+	}
+	root := tree.Root()
+
+	for i, event := range events {
+		proof, err := tree.InclusionProof(i)
+		if err != nil {
+			t.Fatalf("InclusionProof failed: %v", err)
+		}
+		if err := VerifyInclusionProof(root, event, proof); err != nil {
+			t.Errorf("VerifyInclusionProof failed for event %d: %v", i, err)
+		}
+		// Proofs must not verify for a different event.
+		if err := VerifyInclusionProof(root, events[(i+1)%len(events)], proof); err == nil {
+			t.Errorf("VerifyInclusionProof should have failed for event %d", i)
+		}
+	}
+
+	// The root hash commits to the order of the events.
+	events[1], events[2] = events[2], events[1]
+	swapped, err := NewMerkleTree(events)
+	if err != nil {
+		t.Fatalf("NewMerkleTree failed: %v", err)
+	}
+	if bytes.Equal(swapped.Root(), root) {
+		t.Errorf("Unexpected root hash")
+	}
+
+	if _, err := tree.InclusionProof(len(events)); err == nil {
+		t.Errorf("InclusionProof should have failed")
+	}
+}
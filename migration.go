@@ -0,0 +1,131 @@
+package tcglog
+
+import (
+	"bytes"
+	"fmt"
+)
+
+// BankMigrationBlocker describes a reason why policies that use one PCR can't be moved to another PCR bank.
+type BankMigrationBlocker struct {
+	PCRIndex PCRIndex
+	Event    *Event // The event that causes this blocker, or nil if it applies to the whole PCR
+	Reason   string
+}
+
+func (b *BankMigrationBlocker) String() string {
+	if b.Event == nil {
+		return fmt.Sprintf("PCR %d: %s", b.PCRIndex, b.Reason)
+	}
+	return fmt.Sprintf("PCR %d: event %d (type: %s): %s", b.PCRIndex, b.Event.Index, b.Event.EventType, b.Reason)
+}
+
+// BankMigrationReport describes whether sealing policies can be moved from one PCR bank to another. This
+// requires every relevant PCR to have a complete measurement chain in the target bank that is consistent with
+// the event data and with the TPM.
+type BankMigrationReport struct {
+	From     AlgorithmId
+	To       AlgorithmId
+	PCRs     []PCRIndex             // The PCRs that were checked
+	Blockers []BankMigrationBlocker // The blockers, ordered by PCR
+}
+
+// Ready indicates whether there are no blockers.
+func (r *BankMigrationReport) Ready() bool {
+	return len(r.Blockers) == 0
+}
+
+// BlockersForPCR returns the blockers for the specified PCR.
+func (r *BankMigrationReport) BlockersForPCR(pcr PCRIndex) (out []BankMigrationBlocker) {
+	for _, b := range r.Blockers {
+		if b.PCRIndex == pcr {
+			out = append(out, b)
+		}
+	}
+	return
+}
+
+// isPaddedDigest indicates whether digest consists of src followed by zero bytes, which some buggy firmware
+// records in the digests for larger algorithms.
+func isPaddedDigest(digest, src Digest) bool {
+	if len(src) == 0 || len(digest) <= len(src) || !bytes.Equal(digest[:len(src)], src) {
+		return false
+	}
+	for _, b := range digest[len(src):] {
+		if b != 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// AdviseBankMigration determines whether sealing policies that use the specified PCRs can be moved from the
+// from bank to the to bank, eg, from SHA-1 to SHA-256. If pcrs is empty, every PCR with events in the log is
+// checked. Each event in these PCRs must have a digest for the target algorithm that isn't a padded copy of the
+// digest for the source algorithm, and that is consistent with the event data where that can be verified. If
+// values is not nil, it should contain the actual PCR values read from the TPM, and the values in the target
+// bank must be present and consistent with the log.
+func (r *LogValidateResult) AdviseBankMigration(from, to AlgorithmId, pcrs []PCRIndex,
+	values map[PCRIndex]DigestMap) *BankMigrationReport {
+	report := &BankMigrationReport{From: from, To: to}
+
+	if len(pcrs) == 0 {
+		report.PCRs = sortedPCRs(r.ExpectedPCRValues)
+	} else {
+		selected := make(map[PCRIndex]DigestMap)
+		for _, pcr := range pcrs {
+			selected[pcr] = nil
+		}
+		report.PCRs = sortedPCRs(selected)
+	}
+
+	for _, pcr := range report.PCRs {
+		block := func(event *Event, format string, args ...interface{}) {
+			report.Blockers = append(report.Blockers,
+				BankMigrationBlocker{PCRIndex: pcr, Event: event, Reason: fmt.Sprintf(format, args...)})
+		}
+
+		if !r.Algorithms.Contains(to) {
+			block(nil, "the log doesn't contain %s digests", to)
+			continue
+		}
+
+		for _, e := range r.ValidatedEvents {
+			if e.Event.PCRIndex != pcr || !doesEventTypeExtendPCR(e.Event.EventType) {
+				continue
+			}
+			digest, ok := e.Event.Digests[to]
+			if !ok {
+				block(e.Event, "the event doesn't have a %s digest", to)
+				continue
+			}
+			if isPaddedDigest(digest, e.Event.Digests[from]) {
+				block(e.Event, "the %s digest is a zero-padded copy of the %s digest", to, from)
+				continue
+			}
+			for _, v := range e.IncorrectDigestValues {
+				if v.Algorithm == to {
+					block(e.Event, "the %s digest is inconsistent with the event data", to)
+				}
+			}
+		}
+
+		if values == nil {
+			continue
+		}
+		actual, ok := values[pcr][to]
+		if !ok {
+			block(nil, "the %s PCR bank is not active on the TPM", to)
+			continue
+		}
+		expected, ok := r.ExpectedPCRValues[pcr][to]
+		if !ok {
+			expected = make(Digest, to.size())
+		}
+		if !bytes.Equal(actual, expected) {
+			block(nil, "the %s PCR value is inconsistent with the log (actual: %x, expected: %x)", to, actual,
+				expected)
+		}
+	}
+
+	return report
+}
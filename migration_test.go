@@ -0,0 +1,66 @@
+package tcglog
+
+import (
+	"testing"
+)
+
+func TestAdviseBankMigration(t *testing.T) {
+	data := []byte("foo")
+	good := &Event{PCRIndex: 4, EventType: EventTypeEFIAction,
+		Digests: DigestMap{AlgorithmSha1: AlgorithmSha1.hash(data), AlgorithmSha256: AlgorithmSha256.hash(data)}}
+	padded := &Event{PCRIndex: 7, EventType: EventTypeEFIAction,
+		Digests: DigestMap{AlgorithmSha1: AlgorithmSha1.hash(data),
+			AlgorithmSha256: append(AlgorithmSha1.hash(data), make([]byte, 12)...)}}
+	missing := &Event{PCRIndex: 8, Index: 1, EventType: EventTypeIPL,
+		Digests: DigestMap{AlgorithmSha1: AlgorithmSha1.hash(data)}}
+	incorrect := &Event{PCRIndex: 9, EventType: EventTypeIPL,
+		Digests: DigestMap{AlgorithmSha1: AlgorithmSha1.hash(data), AlgorithmSha256: AlgorithmSha256.hash(nil)}}
+
+	var validated []*ValidatedEvent
+	for _, e := range []*Event{good, padded, missing, incorrect} {
+		validated = append(validated, &ValidatedEvent{Event: e})
+	}
+	validated[3].IncorrectDigestValues = []IncorrectDigestValue{
+		{Algorithm: AlgorithmSha256, Expected: AlgorithmSha256.hash(data)}}
+
+	result := &LogValidateResult{
+		Algorithms:      AlgorithmIdList{AlgorithmSha1, AlgorithmSha256},
+		ValidatedEvents: validated,
+		ExpectedPCRValues: map[PCRIndex]DigestMap{
+			4: {AlgorithmSha256: performHashExtendOperation(AlgorithmSha256, make(Digest, 32), good.Digests[AlgorithmSha256])},
+			7: {}, 8: {}, 9: {}}}
+
+	report := result.AdviseBankMigration(AlgorithmSha1, AlgorithmSha256, nil, nil)
+	if report.Ready() {
+		t.Fatalf("Migration should be blocked")
+	}
+	if len(report.PCRs) != 4 || len(report.Blockers) != 3 {
+		t.Fatalf("Unexpected report: %+v", report)
+	}
+	if len(report.BlockersForPCR(4)) != 0 {
+		t.Errorf("Unexpected blockers for PCR 4: %v", report.BlockersForPCR(4))
+	}
+	for _, pcr := range []PCRIndex{7, 8, 9} {
+		if b := report.BlockersForPCR(pcr); len(b) != 1 || b[0].Event == nil {
+			t.Errorf("Unexpected blockers for PCR %d: %v", pcr, b)
+		}
+	}
+	if s := report.BlockersForPCR(8)[0].String(); s != "PCR 8: event 1 (type: EV_IPL): the event doesn't have a SHA-256 digest" {
+		t.Errorf("Unexpected blocker: %s", s)
+	}
+
+	values := map[PCRIndex]DigestMap{4: {AlgorithmSha256: result.ExpectedPCRValues[4][AlgorithmSha256]}}
+	report = result.AdviseBankMigration(AlgorithmSha1, AlgorithmSha256, []PCRIndex{4, 4}, values)
+	if !report.Ready() || len(report.PCRs) != 1 {
+		t.Errorf("Unexpected report: %+v", report)
+	}
+
+	values[4][AlgorithmSha256] = make(Digest, 32)
+	if report = result.AdviseBankMigration(AlgorithmSha1, AlgorithmSha256, []PCRIndex{4}, values); report.Ready() {
+		t.Errorf("Migration should be blocked by an inconsistent PCR value")
+	}
+
+	if report = result.AdviseBankMigration(AlgorithmSha1, AlgorithmSha384, []PCRIndex{4}, nil); report.Ready() {
+		t.Errorf("Migration should be blocked by a missing bank")
+	}
+}
@@ -0,0 +1,49 @@
+package tcglog
+
+import (
+	"encoding/json"
+	"io"
+)
+
+// NDJSONEncoder writes events as newline-delimited JSON, with one JSON object per line in the format produced by
+// Event.MarshalJSON. Each event is written as soon as it is encoded, so large logs can be piped in to tools such
+// as jq without being buffered in memory.
+type NDJSONEncoder struct {
+	w io.Writer
+}
+
+// NewNDJSONEncoder returns a new NDJSONEncoder that writes events to w.
+func NewNDJSONEncoder(w io.Writer) *NDJSONEncoder {
+	return &NDJSONEncoder{w: w}
+}
+
+// Encode writes a line for the supplied event.
+func (e *NDJSONEncoder) Encode(event *Event) error {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	data = append(data, '\n')
+	_, err = e.w.Write(data)
+	return err
+}
+
+// WriteNDJSON reads the remaining events from log and writes them to w as newline-delimited JSON.
+func WriteNDJSON(w io.Writer, log *Log) error {
+	enc := NewNDJSONEncoder(w)
+
+	for {
+		event, err := log.NextEvent()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		if err := enc.Encode(event); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,42 @@
+package tcglog
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"testing"
+)
+
+func TestWriteNDJSON(t *testing.T) {
+	data := makeTestCryptoAgileLog(t, 5)
+	events := readTestEvents(t, data)
+
+	log, err := NewLog(bytes.NewReader(data), LogOptions{})
+	if err != nil {
+		t.Fatalf("NewLog failed: %v", err)
+	}
+
+	var buf bytes.Buffer
+	if err := WriteNDJSON(&buf, log); err != nil {
+		t.Fatalf("WriteNDJSON failed: %v", err)
+	}
+
+	scanner := bufio.NewScanner(&buf)
+	n := 0
+	for scanner.Scan() {
+		if n >= len(events) {
+			t.Fatalf("Too many lines")
+		}
+		expected, err := json.Marshal(events[n])
+		if err != nil {
+			t.Fatalf("Marshal failed: %v", err)
+		}
+		if !bytes.Equal(scanner.Bytes(), expected) {
+			t.Errorf("Unexpected line %d: %s", n, scanner.Text())
+		}
+		n++
+	}
+	if n != len(events) {
+		t.Errorf("Unexpected number of lines (%d)", n)
+	}
+}
@@ -0,0 +1,67 @@
+package tcglog
+
+import (
+	"encoding/binary"
+	"fmt"
+)
+
+// NonHostEventData corresponds to the event data for EV_NONHOST_CODE, EV_NONHOST_CONFIG and EV_NONHOST_INFO
+// events, which record measurements of firmware and configuration for non-host processors such as embedded
+// controllers, baseboard management controllers or management engines. The event data is a platform manufacturer
+// defined structure, but firmware commonly records a description of the component as an ASCII or UCS-2 string,
+// or the location of the measured image in the same way as EV_POST_CODE events.
+type NonHostEventData struct {
+	data        []byte
+	Type        EventType // EventTypeNonhostCode, EventTypeNonhostConfig or EventTypeNonhostInfo
+	Description string    // The description of the non-host component, if the event data contains one
+	BlobBase    uint64    // The base address of the measured image, if the event data records its location
+	BlobLength  uint64    // The length of the measured image, if the event data records its location
+}
+
+func (e *NonHostEventData) String() string {
+	switch {
+	case e.Description != "":
+		return e.Description
+	case e.BlobLength > 0:
+		return fmt.Sprintf("non-host blob{ base: 0x%x, length: %d }", e.BlobBase, e.BlobLength)
+	default:
+		return fmt.Sprintf("vendor-specific non-host data (%d bytes)", len(e.data))
+	}
+}
+
+// IsVendorSpecific indicates that the event data is a vendor-specific structure that couldn't be decoded.
+func (e *NonHostEventData) IsVendorSpecific() bool {
+	return e.Description == "" && e.BlobLength == 0
+}
+
+func (e *NonHostEventData) Bytes() []byte {
+	return e.data
+}
+
+// https://trustedcomputinggroup.org/wp-content/uploads/TCG_PCClientSpecPlat_TPM_2p0_1p04_pub.pdf
+//  (section 9.4.1 "Event Types")
+func decodeEventDataNonHost(eventType EventType, data []byte) (*NonHostEventData, int, error) {
+	d := &NonHostEventData{data: data, Type: eventType}
+
+	if desc, ok := decodePlatformConfigDescription(data); ok {
+		d.Description = desc
+		return d, 0, nil
+	}
+	if desc, ok := decodeUCS2String(data); ok {
+		d.Description = desc
+		return d, 0, nil
+	}
+
+	// Some firmware records the location of the measured image in the same way as EV_POST_CODE events.
+	if eventType == EventTypeNonhostCode && len(data) == 16 {
+		base := binary.LittleEndian.Uint64(data[0:])
+		length := binary.LittleEndian.Uint64(data[8:])
+		if length > 0 {
+			d.BlobBase = base
+			d.BlobLength = length
+		}
+	}
+
+	// The content of this event is otherwise platform specific.
+	return d, 0, nil
+}
@@ -0,0 +1,53 @@
+package tcglog
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestDecodeNonHostEvents(t *testing.T) {
+	out, _, err := decodeEventDataTCG(EventTypeNonhostCode, []byte("EC Firmware 1.07\x00"), false)
+	if err != nil {
+		t.Fatalf("decodeEventDataTCG failed: %v", err)
+	}
+	if d, ok := out.(*NonHostEventData); !ok || d.Type != EventTypeNonhostCode || d.Description != "EC Firmware 1.07" {
+		t.Errorf("Unexpected event data %#v", out)
+	}
+
+	out, _, err = decodeEventDataTCG(EventTypeNonhostInfo, []byte{0x45, 0x00, 0x43, 0x00, 0x00, 0x00}, false)
+	if err != nil {
+		t.Fatalf("decodeEventDataTCG failed: %v", err)
+	}
+	if d, ok := out.(*NonHostEventData); !ok || d.Type != EventTypeNonhostInfo || d.Description != "EC" {
+		t.Errorf("Unexpected event data %#v", out)
+	}
+
+	blob := []byte{0x00, 0x00, 0xf0, 0xff, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0x00, 0x00, 0x00, 0x00, 0x00}
+	out, _, err = decodeEventDataTCG(EventTypeNonhostCode, blob, false)
+	if err != nil {
+		t.Fatalf("decodeEventDataTCG failed: %v", err)
+	}
+	if d, ok := out.(*NonHostEventData); !ok || d.BlobBase != 0xfff00000 || d.BlobLength != 0x20000 ||
+		d.IsVendorSpecific() {
+		t.Errorf("Unexpected event data %#v", out)
+	}
+
+	out, _, err = decodeEventDataTCG(EventTypeNonhostConfig, []byte{0x01, 0x00, 0x02, 0x00}, false)
+	if err != nil {
+		t.Fatalf("decodeEventDataTCG failed: %v", err)
+	}
+	d, ok := out.(*NonHostEventData)
+	if !ok || !d.IsVendorSpecific() {
+		t.Fatalf("Unexpected event data %#v", out)
+	}
+	if d.String() != "vendor-specific non-host data (4 bytes)" {
+		t.Errorf("Unexpected string %q", d.String())
+	}
+	j, err := json.Marshal(d)
+	if err != nil {
+		t.Fatalf("Marshal failed: %v", err)
+	}
+	if string(j) != `{"vendorSpecific":true}` {
+		t.Errorf("Unexpected JSON %s", j)
+	}
+}
@@ -0,0 +1,199 @@
+package tcglog
+
+import (
+	"bufio"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// PCRReader is implemented by TPM backends that can read PCR values.
+type PCRReader interface {
+	// ActivePCRBanks returns the PCR banks that are active on the TPM.
+	ActivePCRBanks() (AlgorithmIdList, error)
+
+	// ReadPCRs returns the values of the specified PCRs from every active PCR bank.
+	ReadPCRs(pcrs []PCRIndex) (map[PCRIndex]DigestMap, error)
+}
+
+// SysfsPCRReader is a PCRReader that reads PCR values from the sysfs interface exported by the Linux kernel,
+// which doesn't require access to the TPM device node. The PCR banks of TPM 2.0 devices are read from the
+// pcr-<alg> directories, which are available from Linux 5.12. The SHA-1 bank of TPM 1.2 devices is read from the
+// pcrs file.
+type SysfsPCRReader struct {
+	path string
+}
+
+// NewSysfsPCRReader returns a new SysfsPCRReader for the TPM device with the specified sysfs path, eg,
+// "/sys/class/tpm/tpm0".
+func NewSysfsPCRReader(path string) *SysfsPCRReader {
+	return &SysfsPCRReader{path: path}
+}
+
+var sysfsPCRBanks = []struct {
+	name string
+	alg  AlgorithmId
+}{
+	{"sha1", AlgorithmSha1},
+	{"sha256", AlgorithmSha256},
+	{"sha384", AlgorithmSha384},
+	{"sha512", AlgorithmSha512},
+	{"sm3", AlgorithmSm3_256},
+	{"sha3-256", AlgorithmSha3_256},
+	{"sha3-384", AlgorithmSha3_384},
+	{"sha3-512", AlgorithmSha3_512},
+}
+
+func (r *SysfsPCRReader) tpm1PCRsPath() string {
+	for _, p := range []string{filepath.Join(r.path, "pcrs"), filepath.Join(r.path, "device", "pcrs")} {
+		if _, err := os.Stat(p); err == nil {
+			return p
+		}
+	}
+	return ""
+}
+
+func (r *SysfsPCRReader) readTPM2PCR(name string, alg AlgorithmId, pcr PCRIndex) (Digest, error) {
+	data, err := ioutil.ReadFile(filepath.Join(r.path, "pcr-"+name, strconv.Itoa(int(pcr))))
+	if err != nil {
+		return nil, err
+	}
+	digest, err := hex.DecodeString(strings.TrimSpace(string(data)))
+	if err != nil || len(digest) != alg.size() {
+		return nil, fmt.Errorf("invalid value for PCR %d, bank %s", pcr, alg)
+	}
+	return digest, nil
+}
+
+func (r *SysfsPCRReader) activeTPM2PCRBanks() (out AlgorithmIdList) {
+	for _, b := range sysfsPCRBanks {
+		// The kernel may create directories for banks that don't have any PCRs allocated, so a bank is only
+		// considered to be active if a PCR can be read from it.
+		if _, err := r.readTPM2PCR(b.name, b.alg, 0); err == nil {
+			out = append(out, b.alg)
+		}
+	}
+	return
+}
+
+// ActivePCRBanks implements PCRReader.ActivePCRBanks.
+func (r *SysfsPCRReader) ActivePCRBanks() (AlgorithmIdList, error) {
+	if banks := r.activeTPM2PCRBanks(); len(banks) > 0 {
+		return banks, nil
+	}
+	if r.tpm1PCRsPath() != "" {
+		return AlgorithmIdList{AlgorithmSha1}, nil
+	}
+	return nil, errors.New("cannot find any PCR banks")
+}
+
+// readTPM1PCRs parses a TPM 1.2 pcrs file, which contains lines of the form "PCR-00: 3A 3F ...".
+func (r *SysfsPCRReader) readTPM1PCRs(path string) (map[PCRIndex]Digest, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	out := make(map[PCRIndex]Digest)
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		fields := strings.SplitN(scanner.Text(), ":", 2)
+		if len(fields) != 2 || !strings.HasPrefix(fields[0], "PCR-") {
+			continue
+		}
+		pcr, err := strconv.ParseUint(strings.TrimPrefix(fields[0], "PCR-"), 10, 32)
+		if err != nil {
+			return nil, fmt.Errorf("invalid PCR index \"%s\"", fields[0])
+		}
+		digest, err := hex.DecodeString(strings.Replace(strings.TrimSpace(fields[1]), " ", "", -1))
+		if err != nil || len(digest) != AlgorithmSha1.size() {
+			return nil, fmt.Errorf("invalid value for PCR %d", pcr)
+		}
+		out[PCRIndex(pcr)] = digest
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// ReadPCRs implements PCRReader.ReadPCRs.
+func (r *SysfsPCRReader) ReadPCRs(pcrs []PCRIndex) (map[PCRIndex]DigestMap, error) {
+	out := make(map[PCRIndex]DigestMap)
+	for _, pcr := range pcrs {
+		if !isPCRIndexInRange(pcr) {
+			return nil, wrapPCRIndexOutOfRangeError(pcr)
+		}
+		out[pcr] = DigestMap{}
+	}
+
+	if banks := r.activeTPM2PCRBanks(); len(banks) > 0 {
+		for _, b := range sysfsPCRBanks {
+			if !banks.Contains(b.alg) {
+				continue
+			}
+			for _, pcr := range pcrs {
+				digest, err := r.readTPM2PCR(b.name, b.alg, pcr)
+				if err != nil {
+					return nil, fmt.Errorf("cannot read PCR %d, bank %s: %w", pcr, b.alg, err)
+				}
+				out[pcr][b.alg] = digest
+			}
+		}
+		return out, nil
+	}
+
+	path := r.tpm1PCRsPath()
+	if path == "" {
+		return nil, errors.New("cannot find any PCR banks")
+	}
+	values, err := r.readTPM1PCRs(path)
+	if err != nil {
+		return nil, fmt.Errorf("cannot read PCR values: %w", err)
+	}
+	for _, pcr := range pcrs {
+		digest, ok := values[pcr]
+		if !ok {
+			return nil, fmt.Errorf("cannot read PCR %d, bank %s", pcr, AlgorithmSha1)
+		}
+		out[pcr][AlgorithmSha1] = digest
+	}
+	return out, nil
+}
+
+// MockPCRReader is a PCRReader that returns the supplied values, for testing code that reads PCRs without
+// access to a TPM.
+type MockPCRReader struct {
+	Banks  AlgorithmIdList        // The PCR banks to report as active
+	Values map[PCRIndex]DigestMap // The PCR values to return. PCRs that aren't in Values read as zero
+}
+
+// ActivePCRBanks implements PCRReader.ActivePCRBanks.
+func (r *MockPCRReader) ActivePCRBanks() (AlgorithmIdList, error) {
+	return r.Banks, nil
+}
+
+// ReadPCRs implements PCRReader.ReadPCRs.
+func (r *MockPCRReader) ReadPCRs(pcrs []PCRIndex) (map[PCRIndex]DigestMap, error) {
+	out := make(map[PCRIndex]DigestMap)
+	for _, pcr := range pcrs {
+		if !isPCRIndexInRange(pcr) {
+			return nil, wrapPCRIndexOutOfRangeError(pcr)
+		}
+		out[pcr] = DigestMap{}
+		for _, alg := range r.Banks {
+			digest, ok := r.Values[pcr][alg]
+			if !ok {
+				digest = make(Digest, alg.size())
+			}
+			out[pcr][alg] = append(Digest(nil), digest...)
+		}
+	}
+	return out, nil
+}
@@ -0,0 +1,143 @@
+package tcglog
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestSysfsPCRReader(t *testing.T) {
+	dir, err := ioutil.TempDir("", "tcglog-test")
+	if err != nil {
+		t.Fatalf("TempDir failed: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	t.Run("TPM2", func(t *testing.T) {
+		path := filepath.Join(dir, "tpm0")
+		for _, bank := range []string{"pcr-sha256", "pcr-sha384"} {
+			if err := os.MkdirAll(filepath.Join(path, bank), 0755); err != nil {
+				t.Fatalf("MkdirAll failed: %v", err)
+			}
+		}
+		// An empty directory for a bank without any PCRs allocated.
+		if err := os.MkdirAll(filepath.Join(path, "pcr-sha1"), 0755); err != nil {
+			t.Fatalf("MkdirAll failed: %v", err)
+		}
+		for i := 0; i < 24; i++ {
+			for _, b := range []struct {
+				name string
+				alg  AlgorithmId
+			}{{"pcr-sha256", AlgorithmSha256}, {"pcr-sha384", AlgorithmSha384}} {
+				value := strings.ToUpper(fmt.Sprintf("%x\n", b.alg.hash([]byte{byte(i)})))
+				if err := ioutil.WriteFile(filepath.Join(path, b.name, fmt.Sprintf("%d", i)), []byte(value), 0644); err != nil {
+					t.Fatalf("WriteFile failed: %v", err)
+				}
+			}
+		}
+
+		r := NewSysfsPCRReader(path)
+		banks, err := r.ActivePCRBanks()
+		if err != nil {
+			t.Fatalf("ActivePCRBanks failed: %v", err)
+		}
+		if len(banks) != 2 || banks[0] != AlgorithmSha256 || banks[1] != AlgorithmSha384 {
+			t.Errorf("Unexpected banks: %v", banks)
+		}
+		values, err := r.ReadPCRs([]PCRIndex{7, 23})
+		if err != nil {
+			t.Fatalf("ReadPCRs failed: %v", err)
+		}
+		for _, pcr := range []PCRIndex{7, 23} {
+			if len(values[pcr]) != 2 {
+				t.Errorf("Unexpected number of banks for PCR %d", pcr)
+			}
+			for _, alg := range banks {
+				if !bytes.Equal(values[pcr][alg], alg.hash([]byte{byte(pcr)})) {
+					t.Errorf("Unexpected value for PCR %d, bank %s", pcr, alg)
+				}
+			}
+		}
+	})
+
+	t.Run("TPM1", func(t *testing.T) {
+		path := filepath.Join(dir, "tpm1")
+		if err := os.MkdirAll(filepath.Join(path, "device"), 0755); err != nil {
+			t.Fatalf("MkdirAll failed: %v", err)
+		}
+		var buf bytes.Buffer
+		for i := 0; i < 24; i++ {
+			fmt.Fprintf(&buf, "PCR-%02d:", i)
+			for _, b := range AlgorithmSha1.hash([]byte{byte(i)}) {
+				fmt.Fprintf(&buf, " %02X", b)
+			}
+			buf.WriteString("\n")
+		}
+		if err := ioutil.WriteFile(filepath.Join(path, "device", "pcrs"), buf.Bytes(), 0644); err != nil {
+			t.Fatalf("WriteFile failed: %v", err)
+		}
+
+		r := NewSysfsPCRReader(path)
+		banks, err := r.ActivePCRBanks()
+		if err != nil {
+			t.Fatalf("ActivePCRBanks failed: %v", err)
+		}
+		if len(banks) != 1 || banks[0] != AlgorithmSha1 {
+			t.Errorf("Unexpected banks: %v", banks)
+		}
+		values, err := r.ReadPCRs([]PCRIndex{10})
+		if err != nil {
+			t.Fatalf("ReadPCRs failed: %v", err)
+		}
+		if !bytes.Equal(values[10][AlgorithmSha1], AlgorithmSha1.hash([]byte{10})) {
+			t.Errorf("Unexpected value for PCR 10: %v", values[10])
+		}
+	})
+}
+
+func TestReplayAndValidateLogPCRReader(t *testing.T) {
+	dir, err := ioutil.TempDir("", "tcglog-test")
+	if err != nil {
+		t.Fatalf("TempDir failed: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "log")
+	if err := CreateLogFile(path, AlgorithmIdList{AlgorithmSha256}); err != nil {
+		t.Fatalf("CreateLogFile failed: %v", err)
+	}
+	extender := &mockPCRExtender{}
+	if _, err := MeasureAndLog(path, extender, 14, EventTypeIPL, []byte("foo"), nil); err != nil {
+		t.Fatalf("MeasureAndLog failed: %v", err)
+	}
+
+	reader := &MockPCRReader{Banks: AlgorithmIdList{AlgorithmSha256}, Values: extender.values}
+	result, err := ReplayAndValidateLogWithOptions(path, LogOptions{}, LogValidateOptions{PCRReader: reader})
+	if err != nil {
+		t.Fatalf("ReplayAndValidateLog failed: %v", err)
+	}
+	for _, f := range result.AllFindings {
+		if f.Code == FindingPCRValueMismatch {
+			t.Errorf("Unexpected finding: %s", f.Message)
+		}
+	}
+
+	reader.Values = nil
+	result, err = ReplayAndValidateLogWithOptions(path, LogOptions{}, LogValidateOptions{PCRReader: reader})
+	if err != nil {
+		t.Fatalf("ReplayAndValidateLog failed: %v", err)
+	}
+	n := 0
+	for _, f := range result.AllFindings {
+		if f.Code == FindingPCRValueMismatch {
+			n++
+		}
+	}
+	if n != 1 {
+		t.Errorf("Unexpected number of mismatches (%d)", n)
+	}
+}
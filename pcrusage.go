@@ -0,0 +1,115 @@
+package tcglog
+
+import (
+	"strings"
+)
+
+// https://trustedcomputinggroup.org/wp-content/uploads/TCG_PCClientImplementation_1-21_1_00.pdf
+//  (section 3.3.3 "PCR Usage")
+var pcClientPCRUsage = map[PCRIndex]string{
+	0:  "CRTM, BIOS and host platform extensions",
+	1:  "Host platform configuration",
+	2:  "Option ROM code",
+	3:  "Option ROM configuration and data",
+	4:  "IPL code and boot attempts",
+	5:  "IPL code configuration and data",
+	6:  "State transitions and wake events",
+	7:  "Host platform manufacturer control",
+	16: "Debug",
+	23: "Application support",
+}
+
+// https://trustedcomputinggroup.org/wp-content/uploads/TCG_PCClientSpecPlat_TPM_2p0_1p04_pub.pdf
+//  (section 3.3.4 "PCR Usage")
+var efiPCRUsage = map[PCRIndex]string{
+	0:  "SRTM, BIOS, host platform extensions, embedded option ROMs and PI drivers",
+	1:  "Host platform configuration",
+	2:  "UEFI driver and application code",
+	3:  "UEFI driver and application configuration and data",
+	4:  "UEFI boot manager code and boot attempts",
+	5:  "Boot manager code configuration and data and GPT/partition table",
+	6:  "Host platform manufacturer specific",
+	7:  "Secure boot policy",
+	16: "Debug",
+	17: "DRTM and launch control policy",
+	18: "Trusted OS start-up code",
+	19: "Trusted OS configuration",
+	20: "Trusted OS kernel and other code",
+	21: "Defined by the trusted OS",
+	22: "Defined by the trusted OS",
+	23: "Application support",
+}
+
+// DescribePCR returns the description of what the specified PCR is used for according to the specified
+// specification, or an empty string if the PCR is out of range.
+func DescribePCR(index PCRIndex, spec Spec) string {
+	return describePCR(index, spec, PlatformProfileClient)
+}
+
+func describePCR(index PCRIndex, spec Spec, profile PlatformProfile) string {
+	if !isPCRIndexInRange(index) {
+		return ""
+	}
+
+	if profile == PlatformProfileServer && spec != SpecPCClient {
+		if desc, ok := serverPCRUsage[index]; ok {
+			return desc
+		}
+	}
+
+	usage := efiPCRUsage
+	if spec == SpecPCClient {
+		usage = pcClientPCRUsage
+	}
+	if desc, ok := usage[index]; ok {
+		return desc
+	}
+
+	switch {
+	case index >= 8 && index <= 15:
+		return "Defined for use by the static OS"
+	case isDRTMPCR(index):
+		return "Dynamic root of trust measurements"
+	default:
+		return "Reserved"
+	}
+}
+
+// DescribePCRWithOptions returns the same description as DescribePCR, along with a description of the
+// measurements made to the specified PCR by the components that are enabled in options, such as GRUB or
+// systemd's EFI stub. If options.PlatformProfile is PlatformProfileServer, the description of PCRs that are
+// used differently on server platforms is returned.
+func DescribePCRWithOptions(index PCRIndex, spec Spec, options LogOptions) string {
+	desc := describePCR(index, spec, options.PlatformProfile)
+	if desc == "" {
+		return ""
+	}
+
+	var conventions []string
+	if options.EnableGrub {
+		usage := grubPCRUsages[options.GrubVariant]
+		if isPCRInList(index, usage.commandPCRs) {
+			conventions = append(conventions, "GRUB commands and kernel commandlines")
+		}
+		if isPCRInList(index, usage.filePCRs) {
+			conventions = append(conventions, "files loaded by GRUB")
+		}
+	}
+	if options.EnableSystemdEFIStub && index == options.SystemdEFIStubPCR {
+		conventions = append(conventions, "kernel commandline measured by systemd's EFI stub")
+	}
+	if index == LinuxEFIStubPCR {
+		conventions = append(conventions, "initrd and kernel commandline measured by the Linux EFI stub")
+	}
+	if options.EnableXen && isPCRInList(index, options.xenPCRs()) {
+		conventions = append(conventions, "Xen measured launch")
+	}
+	if options.EnableAppMeasurements && index == DefaultAppMeasurementPCR {
+		conventions = append(conventions, "application-level measurements")
+	}
+
+	if len(conventions) == 0 {
+		return desc
+	}
+	return desc + " (" + strings.Join(conventions, ", ") + ")"
+}
@@ -0,0 +1,98 @@
+package tcglog
+
+import (
+	"testing"
+)
+
+func TestDescribePCR(t *testing.T) {
+	for _, data := range []struct {
+		pcr      PCRIndex
+		spec     Spec
+		expected string
+	}{
+		{0, SpecEFI_2, "SRTM, BIOS, host platform extensions, embedded option ROMs and PI drivers"},
+		{0, SpecPCClient, "CRTM, BIOS and host platform extensions"},
+		{7, SpecEFI_2, "Secure boot policy"},
+		{7, SpecPCClient, "Host platform manufacturer control"},
+		{7, SpecUnknown, "Secure boot policy"},
+		{9, SpecEFI_2, "Defined for use by the static OS"},
+		{17, SpecEFI_2, "DRTM and launch control policy"},
+		{17, SpecPCClient, "Dynamic root of trust measurements"},
+		{23, SpecPCClient, "Application support"},
+		{24, SpecEFI_2, "Reserved"},
+		{32, SpecEFI_2, ""},
+	} {
+		if desc := DescribePCR(data.pcr, data.spec); desc != data.expected {
+			t.Errorf("Unexpected description for PCR %d with spec %d: %q", data.pcr, data.spec, desc)
+		}
+	}
+}
+
+func TestDescribePCRWithOptions(t *testing.T) {
+	for _, data := range []struct {
+		desc     string
+		pcr      PCRIndex
+		options  LogOptions
+		expected string
+	}{
+		{
+			desc:     "NoOptions",
+			pcr:      8,
+			expected: "Defined for use by the static OS",
+		},
+		{
+			desc:     "GrubCommands",
+			pcr:      8,
+			options:  LogOptions{EnableGrub: true},
+			expected: "Defined for use by the static OS (GRUB commands and kernel commandlines)",
+		},
+		{
+			desc:     "TrustedGRUB2Files",
+			pcr:      13,
+			options:  LogOptions{EnableGrub: true, GrubVariant: GrubVariantTrustedGRUB2},
+			expected: "Defined for use by the static OS (files loaded by GRUB)",
+		},
+		{
+			desc:    "Multiple",
+			pcr:     9,
+			options: LogOptions{EnableGrub: true},
+			expected: "Defined for use by the static OS (files loaded by GRUB, initrd and kernel commandline " +
+				"measured by the Linux EFI stub)",
+		},
+		{
+			desc:     "SystemdEFIStub",
+			pcr:      12,
+			options:  LogOptions{EnableSystemdEFIStub: true, SystemdEFIStubPCR: 12},
+			expected: "Defined for use by the static OS (kernel commandline measured by systemd's EFI stub)",
+		},
+		{
+			desc:     "Xen",
+			pcr:      18,
+			options:  LogOptions{EnableXen: true},
+			expected: "Trusted OS start-up code (Xen measured launch)",
+		},
+		{
+			desc:     "XenCustomPCRs",
+			pcr:      18,
+			options:  LogOptions{EnableXen: true, XenPCRs: []PCRIndex{12}},
+			expected: "Trusted OS start-up code",
+		},
+		{
+			desc:     "AppMeasurements",
+			pcr:      23,
+			options:  LogOptions{EnableAppMeasurements: true},
+			expected: "Application support (application-level measurements)",
+		},
+		{
+			desc:    "OutOfRange",
+			pcr:     32,
+			options: LogOptions{EnableAppMeasurements: true},
+		},
+	} {
+		t.Run(data.desc, func(t *testing.T) {
+			if desc := DescribePCRWithOptions(data.pcr, SpecEFI_2, data.options); desc != data.expected {
+				t.Errorf("Unexpected description %q", desc)
+			}
+		})
+	}
+}
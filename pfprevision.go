@@ -0,0 +1,115 @@
+package tcglog
+
+import (
+	"context"
+	"fmt"
+)
+
+// PFPRevision corresponds to the revision of the TCG PC Client Platform Firmware Profile specification that a
+// crypto-agile log declares conformance to in its Spec ID event.
+type PFPRevision int
+
+const (
+	// PFPRevisionUnknown indicates that the log isn't a crypto-agile log, or that the version fields of its
+	// Spec ID event don't correspond to a revision of the specification.
+	PFPRevisionUnknown PFPRevision = iota
+
+	// PFPRevision1_04 indicates that the log conforms to revision 1.04 or an earlier revision.
+	PFPRevision1_04
+
+	// PFPRevision1_05 indicates that the log conforms to revision 1.05.
+	PFPRevision1_05
+
+	// PFPRevision1_06 indicates that the log conforms to revision 1.06 or a later revision.
+	PFPRevision1_06
+)
+
+// https://trustedcomputinggroup.org/wp-content/uploads/TCG_PCClientSpecPlat_TPM_2p0_1p04_pub.pdf
+//  (section 9.4.5.1 "Specification ID Version Event")
+//
+// Firmware conforming to revision 1.05 or later sets specErrata to the revision number, eg, 105 (see
+// TCG_EfiSpecIDEventStruct_SPEC_ERRATA_TPM2_REV_105 and TCG_EfiSpecIDEventStruct_SPEC_ERRATA_TPM2_REV_106 in edk2).
+// Earlier revisions use a small errata number, which is 0 for edk2.
+const (
+	pfpSpecVersionMajor uint8 = 2
+	pfpSpecVersionMinor uint8 = 0
+
+	pfpSpecErrata1_05 uint8 = 105
+	pfpSpecErrata1_06 uint8 = 106
+)
+
+func (r PFPRevision) String() string {
+	switch r {
+	case PFPRevisionUnknown:
+		return "unknown"
+	case PFPRevision1_04:
+		return "1.04"
+	case PFPRevision1_05:
+		return "1.05"
+	case PFPRevision1_06:
+		return "1.06"
+	default:
+		return fmt.Sprintf("PFPRevision(%d)", int(r))
+	}
+}
+
+// determinePFPRevision returns the revision of the PC Client Platform Firmware Profile specification indicated
+// by the supplied Spec ID event, which may be nil. Revisions are distinguished by the specErrata field, and any
+// value less than 105 is an errata number for revision 1.04 or earlier.
+func determinePFPRevision(specIdEvent *Event) PFPRevision {
+	if specIdEvent == nil {
+		return PFPRevisionUnknown
+	}
+	d, ok := specIdEvent.Data.(*SpecIdEventData)
+	if !ok || d.Spec != SpecEFI_2 || d.SpecVersionMajor != pfpSpecVersionMajor ||
+		d.SpecVersionMinor != pfpSpecVersionMinor {
+		return PFPRevisionUnknown
+	}
+	switch {
+	case d.SpecErrata >= pfpSpecErrata1_06:
+		return PFPRevision1_06
+	case d.SpecErrata >= pfpSpecErrata1_05:
+		return PFPRevision1_05
+	default:
+		return PFPRevision1_04
+	}
+}
+
+// pfpEventTypeRevisions contains the event types that were introduced after revision 1.04 of the PC Client
+// Platform Firmware Profile specification, and the revision that introduced each of them.
+var pfpEventTypeRevisions = map[EventType]PFPRevision{
+	EventTypeEFIPlatformFirmwareBlob2: PFPRevision1_05,
+	EventTypeEFIHandoffTables2:        PFPRevision1_05,
+	EventTypeEFIVariableBoot2:         PFPRevision1_05,
+	EventTypeEFISPDMFirmwareBlob:      PFPRevision1_06,
+	EventTypeEFISPDMFirmwareConfig:    PFPRevision1_06,
+	EventTypeEFISPDMDevicePolicy:      PFPRevision1_06,
+	EventTypeEFISPDMDeviceAuthority:   PFPRevision1_06,
+}
+
+// DefinesEventType indicates whether this revision of the PC Client Platform Firmware Profile specification
+// defines the specified event type. This is always true for PFPRevisionUnknown.
+func (r PFPRevision) DefinesEventType(t EventType) bool {
+	if r == PFPRevisionUnknown {
+		return true
+	}
+	introduced, ok := pfpEventTypeRevisions[t]
+	return !ok || r >= introduced
+}
+
+// pfpRevisionRule reports events with types that aren't defined by the revision of the PC Client Platform
+// Firmware Profile specification that the log declares conformance to.
+type pfpRevisionRule struct{}
+
+func (r pfpRevisionRule) Check(ctx context.Context, event *Event, state *ReplayState) []Finding {
+	if event == nil || state.PFPRevision.DefinesEventType(event.EventType) {
+		return nil
+	}
+	return []Finding{{
+		Code:     FindingSpecViolation,
+		Severity: FindingSeverityWarning,
+		Event:    event,
+		Message: fmt.Sprintf("%s events were introduced in revision %s of the PC Client Platform Firmware "+
+			"Profile specification, but the log declares conformance to revision %s", event.EventType,
+			pfpEventTypeRevisions[event.EventType], state.PFPRevision)}}
+}
@@ -0,0 +1,138 @@
+package tcglog
+
+import (
+	"bytes"
+	"context"
+	"testing"
+)
+
+// specErrataOffset is the offset of the specErrata field of the Spec ID event in a log, after the
+// TCG_PCClientPCREvent header and the signature, platformClass, specVersionMinor and specVersionMajor fields.
+const specErrataOffset = 32 + 16 + 4 + 2
+
+// makeTestPFPLog creates a crypto-agile log that declares the specified specErrata, and that contains an event
+// of the specified type that measures a BootOrder variable. EV_EFI_VARIABLE_BOOT events only measure the variable
+// data, and EV_EFI_VARIABLE_BOOT2 events measure the entire UEFI_VARIABLE_DATA structure.
+func makeTestPFPLog(t *testing.T, errata uint8, eventType EventType) []byte {
+	bootOrder := EFIVariableEventData{
+		VariableName: *NewEFIGUID(0x8be4df61, 0x93ca, 0x11d2, 0xaa0d, [...]uint8{0x00, 0xe0, 0x98, 0x03, 0x2b, 0x8c}),
+		UnicodeName:  "BootOrder",
+		VariableData: []byte{0x01, 0x00}}
+	var bootOrderData bytes.Buffer
+	if err := bootOrder.EncodeMeasuredBytes(&bootOrderData); err != nil {
+		t.Fatalf("Encode failed: %v", err)
+	}
+
+	measured := bootOrder.VariableData
+	if eventType == EventTypeEFIVariableBoot2 {
+		measured = bootOrderData.Bytes()
+	}
+
+	var buf bytes.Buffer
+	w, err := NewLogWriter(&buf, SpecEFI_2, AlgorithmIdList{AlgorithmSha256})
+	if err != nil {
+		t.Fatalf("NewLogWriter failed: %v", err)
+	}
+	if err := w.WriteSpecIdEvent(0, nil); err != nil {
+		t.Fatalf("WriteSpecIdEvent failed: %v", err)
+	}
+	if err := w.WriteEvent(&Event{
+		PCRIndex:  1,
+		EventType: eventType,
+		Digests:   DigestMap{AlgorithmSha256: AlgorithmSha256.hash(measured)},
+		Data:      &opaqueEventData{data: bootOrderData.Bytes()}}); err != nil {
+		t.Fatalf("WriteEvent failed: %v", err)
+	}
+
+	data := buf.Bytes()
+	data[specErrataOffset] = errata
+	return data
+}
+
+func TestPFPRevision(t *testing.T) {
+	for _, data := range []struct {
+		errata   uint8
+		expected PFPRevision
+	}{
+		{0, PFPRevision1_04},   // edk2 before revision 1.05
+		{2, PFPRevision1_04},   // errata 2 of revision 1.04
+		{105, PFPRevision1_05}, // TCG_EfiSpecIDEventStruct_SPEC_ERRATA_TPM2_REV_105
+		{106, PFPRevision1_06}, // TCG_EfiSpecIDEventStruct_SPEC_ERRATA_TPM2_REV_106
+		{107, PFPRevision1_06},
+	} {
+		log, err := NewLog(bytes.NewReader(makeTestPFPLog(t, data.errata, EventTypeEFIVariableBoot2)), LogOptions{})
+		if err != nil {
+			t.Fatalf("NewLog failed: %v", err)
+		}
+		if log.PFPRevision != data.expected {
+			t.Errorf("Unexpected revision %s for errata %d", log.PFPRevision, data.errata)
+		}
+	}
+
+	log, err := NewLog(bytes.NewReader(makeTestCryptoAgileLog(t, 0)), LogOptions{})
+	if err != nil {
+		t.Fatalf("NewLog failed: %v", err)
+	}
+	if log.PFPRevision != PFPRevision1_04 {
+		t.Errorf("Unexpected revision %s", log.PFPRevision)
+	}
+
+	if !PFPRevision1_05.DefinesEventType(EventTypeEFIVariableBoot2) ||
+		PFPRevision1_05.DefinesEventType(EventTypeEFISPDMFirmwareBlob) ||
+		!PFPRevisionUnknown.DefinesEventType(EventTypeEFISPDMFirmwareBlob) {
+		t.Errorf("Unexpected result from DefinesEventType")
+	}
+}
+
+func validateTestPFPLog(t *testing.T, data []byte) *LogValidateResult {
+	log, err := NewLog(bytes.NewReader(data), LogOptions{})
+	if err != nil {
+		t.Fatalf("NewLog failed: %v", err)
+	}
+	result, err := replayAndValidateLog(context.Background(), log, int64(len(data)), LogValidateOptions{})
+	if err != nil {
+		t.Fatalf("replayAndValidateLog failed: %v", err)
+	}
+	return result
+}
+
+func countSpecViolations(result *LogValidateResult) (n int) {
+	for _, f := range result.AllFindings {
+		if f.Code == FindingSpecViolation {
+			n++
+		}
+	}
+	return n
+}
+
+func TestValidatePFPRevisionEventTypes(t *testing.T) {
+	// EV_EFI_VARIABLE_BOOT2 events were introduced in revision 1.05.
+	result := validateTestPFPLog(t, makeTestPFPLog(t, 0, EventTypeEFIVariableBoot2))
+	if result.PFPRevision != PFPRevision1_04 || countSpecViolations(result) != 1 {
+		t.Errorf("Unexpected findings for revision 1.04 log: %v", result.AllFindings)
+	}
+
+	result = validateTestPFPLog(t, makeTestPFPLog(t, 105, EventTypeEFIVariableBoot2))
+	if result.PFPRevision != PFPRevision1_05 || countSpecViolations(result) != 0 {
+		t.Errorf("Unexpected findings for revision 1.05 log: %v", result.AllFindings)
+	}
+	if len(result.ValidatedEvents[1].IncorrectDigestValues) > 0 {
+		t.Errorf("Unexpected incorrect digest")
+	}
+}
+
+func TestValidatePFPRevisionBootVariableBehaviour(t *testing.T) {
+	// Measuring only the variable data for EV_EFI_VARIABLE_BOOT events is tolerated for revision 1.04.
+	result := validateTestPFPLog(t, makeTestPFPLog(t, 0, EventTypeEFIVariableBoot))
+	if result.EfiBootVariableBehaviour != EFIBootVariableBehaviourVarDataOnly || countSpecViolations(result) != 0 {
+		t.Errorf("Unexpected findings for revision 1.04 log: %v", result.AllFindings)
+	}
+
+	result = validateTestPFPLog(t, makeTestPFPLog(t, 105, EventTypeEFIVariableBoot))
+	if result.EfiBootVariableBehaviour != EFIBootVariableBehaviourVarDataOnly || countSpecViolations(result) != 1 {
+		t.Errorf("Unexpected findings for revision 1.05 log: %v", result.AllFindings)
+	}
+	if len(result.ValidatedEvents[1].IncorrectDigestValues) > 0 {
+		t.Errorf("Unexpected incorrect digest")
+	}
+}
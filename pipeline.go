@@ -0,0 +1,133 @@
+package tcglog
+
+import (
+	"io"
+)
+
+// EventTransform is implemented by types that filter, decode, redact or annotate events as part of a Pipeline.
+type EventTransform interface {
+	// TransformEvent is called for each event passing through the pipeline. It returns the event that should
+	// be passed to the next stage, or nil if the event should be dropped.
+	TransformEvent(event *Event) (*Event, error)
+}
+
+// EventTransformFunc is an adapter to allow the use of ordinary functions as an EventTransform.
+type EventTransformFunc func(event *Event) (*Event, error)
+
+func (f EventTransformFunc) TransformEvent(event *Event) (*Event, error) {
+	return f(event)
+}
+
+// EventSink is implemented by types that consume the events emitted from the end of a Pipeline, such as
+// encoders and validators.
+type EventSink interface {
+	WriteEvent(event *Event) error // Consume the supplied event
+	Close() error                  // Called once all events have been consumed
+}
+
+// EventSinkFunc is an adapter to allow the use of ordinary functions as an EventSink.
+type EventSinkFunc func(event *Event) error
+
+func (f EventSinkFunc) WriteEvent(event *Event) error {
+	return f(event)
+}
+
+func (f EventSinkFunc) Close() error {
+	return nil
+}
+
+// Pipeline reads events from a log, passes them through each of Transforms in order, and then writes the
+// resulting events to Sink.
+type Pipeline struct {
+	Transforms []EventTransform
+	Sink       EventSink
+}
+
+// Run executes the pipeline by reading every event from log until it is exhausted. The sink is always closed
+// before returning, including when a stage fails. An error from closing the sink is only returned if the pipeline
+// otherwise completed successfully.
+func (p *Pipeline) Run(log *Log) (err error) {
+	defer func() {
+		if closeErr := p.Sink.Close(); err == nil {
+			err = closeErr
+		}
+	}()
+	return p.run(log)
+}
+
+func (p *Pipeline) run(log *Log) error {
+	for {
+		event, err := log.NextEvent()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		for _, t := range p.Transforms {
+			event, err = t.TransformEvent(event)
+			if err != nil {
+				return err
+			}
+			if event == nil {
+				break
+			}
+		}
+		if event == nil {
+			continue
+		}
+
+		if err := p.Sink.WriteEvent(event); err != nil {
+			return err
+		}
+	}
+}
+
+// FilterPCRs returns an EventTransform that only passes events associated with one of the specified PCRs.
+func FilterPCRs(pcrs ...PCRIndex) EventTransform {
+	return EventTransformFunc(func(event *Event) (*Event, error) {
+		for _, pcr := range pcrs {
+			if event.PCRIndex == pcr {
+				return event, nil
+			}
+		}
+		return nil, nil
+	})
+}
+
+// FilterEventTypes returns an EventTransform that only passes events of one of the specified types.
+func FilterEventTypes(types ...EventType) EventTransform {
+	return EventTransformFunc(func(event *Event) (*Event, error) {
+		for _, t := range types {
+			if event.EventType == t {
+				return event, nil
+			}
+		}
+		return nil, nil
+	})
+}
+
+// RedactedEventData corresponds to the data of an event that has been removed by a redacting EventTransform.
+type RedactedEventData struct{}
+
+func (e *RedactedEventData) String() string {
+	return "<redacted>"
+}
+
+func (e *RedactedEventData) Bytes() []byte {
+	return nil
+}
+
+// RedactEventData returns an EventTransform that replaces the event data with RedactedEventData for events
+// for which fn returns true. The original event is not modified.
+func RedactEventData(fn func(event *Event) bool) EventTransform {
+	return EventTransformFunc(func(event *Event) (*Event, error) {
+		if !fn(event) {
+			return event, nil
+		}
+		redacted := *event
+		redacted.Data = &RedactedEventData{}
+		return &redacted, nil
+	})
+}
@@ -0,0 +1,121 @@
+package tcglog
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+)
+
+type testEventSink struct {
+	events   []*Event
+	closed   int
+	writeErr error
+	closeErr error
+}
+
+func (s *testEventSink) WriteEvent(event *Event) error {
+	if s.writeErr != nil {
+		return s.writeErr
+	}
+	s.events = append(s.events, event)
+	return nil
+}
+
+func (s *testEventSink) Close() error {
+	s.closed++
+	return s.closeErr
+}
+
+func TestPipeline(t *testing.T) {
+	data := makeTestCryptoAgileLog(t, 8)
+
+	transformErr := errors.New("transform error")
+	writeErr := errors.New("write error")
+	closeErr := errors.New("close error")
+
+	for _, d := range []struct {
+		desc       string
+		transforms []EventTransform
+		writeErr   error
+		closeErr   error
+		err        error
+		pcrs       []PCRIndex // The PCRs of the events expected to reach the sink
+	}{
+		{desc: "NoTransforms", pcrs: []PCRIndex{0, 0, 1, 2, 3, 4, 5, 6, 7}},
+		{desc: "FilterPCRs", transforms: []EventTransform{FilterPCRs(0, 4)}, pcrs: []PCRIndex{0, 0, 4}},
+		{
+			desc:       "FilterEventTypes",
+			transforms: []EventTransform{FilterEventTypes(EventTypeNoAction)},
+			pcrs:       []PCRIndex{0},
+		},
+		{
+			desc:       "Chained",
+			transforms: []EventTransform{FilterEventTypes(EventTypeEFIAction), FilterPCRs(0, 4)},
+			pcrs:       []PCRIndex{0, 4},
+		},
+		{
+			desc: "TransformError",
+			transforms: []EventTransform{EventTransformFunc(func(event *Event) (*Event, error) {
+				if event.PCRIndex == 2 {
+					return nil, transformErr
+				}
+				return event, nil
+			})},
+			err:  transformErr,
+			pcrs: []PCRIndex{0, 0, 1},
+		},
+		{desc: "WriteError", writeErr: writeErr, err: writeErr},
+		{desc: "CloseError", closeErr: closeErr, err: closeErr, pcrs: []PCRIndex{0, 0, 1, 2, 3, 4, 5, 6, 7}},
+		{desc: "WriteAndCloseError", writeErr: writeErr, closeErr: closeErr, err: writeErr},
+	} {
+		t.Run(d.desc, func(t *testing.T) {
+			log, err := NewLog(bytes.NewReader(data), LogOptions{})
+			if err != nil {
+				t.Fatalf("NewLog failed: %v", err)
+			}
+
+			sink := &testEventSink{writeErr: d.writeErr, closeErr: d.closeErr}
+			p := &Pipeline{Transforms: d.transforms, Sink: sink}
+			if err := p.Run(log); err != d.err {
+				t.Errorf("Unexpected error: %v", err)
+			}
+			if sink.closed != 1 {
+				t.Errorf("Sink closed %d times", sink.closed)
+			}
+
+			if len(sink.events) != len(d.pcrs) {
+				t.Fatalf("Unexpected number of events (%d)", len(sink.events))
+			}
+			for i, e := range sink.events {
+				if e.PCRIndex != d.pcrs[i] {
+					t.Errorf("Unexpected PCR %d for event %d", e.PCRIndex, i)
+				}
+			}
+		})
+	}
+}
+
+func TestRedactEventData(t *testing.T) {
+	event := &Event{PCRIndex: 4, EventType: EventTypeEFIAction, Data: &asciiStringEventData{data: []byte("foo")}}
+
+	redact := RedactEventData(func(event *Event) bool { return event.PCRIndex == 4 })
+	out, err := redact.TransformEvent(event)
+	if err != nil {
+		t.Fatalf("TransformEvent failed: %v", err)
+	}
+	if _, ok := out.Data.(*RedactedEventData); !ok {
+		t.Errorf("Unexpected event data type %T", out.Data)
+	}
+	if _, ok := event.Data.(*asciiStringEventData); !ok {
+		t.Errorf("The original event was modified")
+	}
+
+	event.PCRIndex = 5
+	out, err = redact.TransformEvent(event)
+	if err != nil {
+		t.Fatalf("TransformEvent failed: %v", err)
+	}
+	if out != event {
+		t.Errorf("Unexpected event")
+	}
+}
@@ -0,0 +1,175 @@
+package tcglog
+
+import (
+	"bytes"
+	"fmt"
+)
+
+const (
+	// FindingUndecodedPlatformConfig indicates that an EV_PLATFORM_CONFIG_FLAGS or EV_CPU_MICROCODE event
+	// contains vendor-specific data that couldn't be decoded. Changes to PCR 1 caused by these events can't be
+	// explained from the log alone.
+	FindingUndecodedPlatformConfig FindingCode = "undecoded-platform-config"
+)
+
+// omitBootDeviceEventsString is the event data of EV_OMIT_BOOT_DEVICE_EVENTS events.
+const omitBootDeviceEventsString = "BOOT ATTEMPTS OMITTED"
+
+// PlatformConfigFlagsEventData corresponds to the event data for EV_PLATFORM_CONFIG_FLAGS events. Some firmware
+// records a description of the measured configuration, but the event data is otherwise a vendor-specific
+// structure.
+type PlatformConfigFlagsEventData struct {
+	data        []byte
+	Description string // The description of the measured configuration, or empty if the data is vendor-specific
+}
+
+func (e *PlatformConfigFlagsEventData) String() string {
+	if e.Description == "" {
+		return fmt.Sprintf("vendor-specific platform configuration (%d bytes)", len(e.data))
+	}
+	return e.Description
+}
+
+// IsVendorSpecific indicates that the event data is a vendor-specific structure that couldn't be decoded.
+func (e *PlatformConfigFlagsEventData) IsVendorSpecific() bool {
+	return e.Description == ""
+}
+
+func (e *PlatformConfigFlagsEventData) Bytes() []byte {
+	return e.data
+}
+
+// CPUMicrocodeEventData corresponds to the event data for EV_CPU_MICROCODE events when the firmware records
+// a description of the microcode update, such as its revision.
+type CPUMicrocodeEventData struct {
+	data        []byte
+	Description string
+}
+
+func (e *CPUMicrocodeEventData) String() string {
+	return e.Description
+}
+
+func (e *CPUMicrocodeEventData) Bytes() []byte {
+	return e.data
+}
+
+// OmitBootDeviceEventsEventData corresponds to the event data for EV_OMIT_BOOT_DEVICE_EVENTS events, which
+// indicate that the firmware didn't measure attempts to boot from devices in the boot order. The event data
+// should be the ASCII string "BOOT ATTEMPTS OMITTED".
+type OmitBootDeviceEventsEventData struct {
+	data        []byte
+	Description string
+}
+
+func (e *OmitBootDeviceEventsEventData) String() string {
+	return e.Description
+}
+
+func (e *OmitBootDeviceEventsEventData) Bytes() []byte {
+	return e.data
+}
+
+// decodePlatformConfigDescription returns the description contained in data, if it consists of a printable
+// ASCII string with optional NULL terminators.
+func decodePlatformConfigDescription(data []byte) (string, bool) {
+	str := bytes.TrimRight(data, "\x00")
+	if len(str) == 0 || !isPrintableASCII(str) {
+		return "", false
+	}
+	return string(str), true
+}
+
+// https://trustedcomputinggroup.org/wp-content/uploads/TCG_PCClientSpecPlat_TPM_2p0_1p04_pub.pdf
+//  (section 9.4.1 "Event Types")
+func decodeEventDataPlatformConfigFlags(data []byte) (EventData, int, error) {
+	if desc, ok := decodePlatformConfigDescription(data); ok {
+		return &PlatformConfigFlagsEventData{data: data, Description: desc}, 0, nil
+	}
+	// The content of this event is otherwise platform specific.
+	return &PlatformConfigFlagsEventData{data: data}, 0, nil
+}
+
+// https://trustedcomputinggroup.org/wp-content/uploads/TCG_PCClientSpecPlat_TPM_2p0_1p04_pub.pdf
+//  (section 9.4.1 "Event Types")
+func decodeEventDataCPUMicrocode(data []byte) (EventData, int, error) {
+	if desc, ok := decodePlatformConfigDescription(data); ok {
+		return &CPUMicrocodeEventData{data: data, Description: desc}, 0, nil
+	}
+	// Some firmware records the location of the microcode update in the same way as EV_POST_CODE events.
+	if len(data) == 16 {
+		return decodeEventDataEFIPlatformFirmwareBlob(data)
+	}
+	return nil, 0, nil
+}
+
+// https://trustedcomputinggroup.org/wp-content/uploads/TCG_PCClientSpecPlat_TPM_2p0_1p04_pub.pdf
+//  (section 9.4.1 "Event Types")
+func decodeEventDataOmitBootDeviceEvents(data []byte) (out EventData, trailingBytes int, err error) {
+	desc, ok := decodePlatformConfigDescription(data)
+	if !ok {
+		// This is reported by checkPlatformConfigEventConformance, but the event digest can still be
+		// validated.
+		return nil, 0, nil
+	}
+	return &OmitBootDeviceEventsEventData{data: data, Description: desc}, 0, nil
+}
+
+// checkPlatformConfigEventConformance returns a description of how the supplied event violates the
+// specification, or an empty string if it conforms.
+func checkPlatformConfigEventConformance(event *Event) string {
+	switch event.EventType {
+	case EventTypePlatformConfigFlags:
+		if event.PCRIndex != 1 {
+			return fmt.Sprintf("%s event is measured to PCR %d rather than PCR 1", event.EventType,
+				event.PCRIndex)
+		}
+	case EventTypeOmitBootDeviceEvents:
+		if event.PCRIndex != 4 {
+			return fmt.Sprintf("%s event is measured to PCR %d rather than PCR 4", event.EventType,
+				event.PCRIndex)
+		}
+		if _, ok := event.Data.(*OmitBootDeviceEventsEventData); !ok ||
+			string(event.Data.Bytes()) != omitBootDeviceEventsString {
+			return fmt.Sprintf("%s event data isn't the string %q", event.EventType, omitBootDeviceEventsString)
+		}
+	}
+	return ""
+}
+
+// CheckPlatformConfigEvents returns an informational finding for each EV_PLATFORM_CONFIG_FLAGS or
+// EV_CPU_MICROCODE event in the supplied events that contains vendor-specific data which couldn't be decoded.
+// These are useful for identifying the events that may be responsible for unexpected changes to PCR 1. It also
+// returns a warning for each EV_PLATFORM_CONFIG_FLAGS or EV_OMIT_BOOT_DEVICE_EVENTS event that doesn't conform
+// to the specification.
+func CheckPlatformConfigEvents(events []*Event) (out []Finding) {
+	for _, event := range events {
+		if msg := checkPlatformConfigEventConformance(event); msg != "" {
+			out = append(out, Finding{
+				Code:     FindingSpecViolation,
+				Severity: FindingSeverityWarning,
+				Event:    event,
+				Message:  msg})
+		}
+
+		if event.EventType != EventTypePlatformConfigFlags && event.EventType != EventTypeCPUMicrocode {
+			continue
+		}
+		switch d := event.Data.(type) {
+		case *opaqueEventData:
+		case *PlatformConfigFlagsEventData:
+			if !d.IsVendorSpecific() {
+				continue
+			}
+		default:
+			continue
+		}
+		out = append(out, Finding{
+			Code:     FindingUndecodedPlatformConfig,
+			Severity: FindingSeverityInfo,
+			Event:    event,
+			Message: fmt.Sprintf("%s event contains %d bytes of vendor-specific data", event.EventType,
+				len(event.Data.Bytes()))})
+	}
+	return
+}
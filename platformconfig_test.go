@@ -0,0 +1,109 @@
+package tcglog
+
+import (
+	"testing"
+)
+
+func TestDecodePlatformConfigEvents(t *testing.T) {
+	out, _, err := decodeEventDataTCG(EventTypePlatformConfigFlags, []byte("Setup Configuration\x00"), false)
+	if err != nil {
+		t.Fatalf("decodeEventDataTCG failed: %v", err)
+	}
+	if d, ok := out.(*PlatformConfigFlagsEventData); !ok || d.Description != "Setup Configuration" {
+		t.Errorf("Unexpected event data %#v", out)
+	}
+
+	out, _, err = decodeEventDataTCG(EventTypeCPUMicrocode, []byte("Microcode Update 0x000000f0"), false)
+	if err != nil {
+		t.Fatalf("decodeEventDataTCG failed: %v", err)
+	}
+	if d, ok := out.(*CPUMicrocodeEventData); !ok || d.Description != "Microcode Update 0x000000f0" {
+		t.Errorf("Unexpected event data %#v", out)
+	}
+
+	out, _, err = decodeEventDataTCG(EventTypePlatformConfigFlags, []byte{0x01, 0x00, 0x02, 0x00}, false)
+	if err != nil {
+		t.Fatalf("decodeEventDataTCG failed: %v", err)
+	}
+	if d, ok := out.(*PlatformConfigFlagsEventData); !ok || !d.IsVendorSpecific() {
+		t.Errorf("Unexpected event data %#v", out)
+	}
+
+	out, _, err = decodeEventDataTCG(EventTypeOmitBootDeviceEvents, []byte("BOOT ATTEMPTS OMITTED"), false)
+	if err != nil {
+		t.Fatalf("decodeEventDataTCG failed: %v", err)
+	}
+	if d, ok := out.(*OmitBootDeviceEventsEventData); !ok || d.Description != "BOOT ATTEMPTS OMITTED" {
+		t.Errorf("Unexpected event data %#v", out)
+	}
+	out, _, err = decodeEventDataTCG(EventTypeOmitBootDeviceEvents, []byte{0xff, 0xfe}, false)
+	if err != nil || out != nil {
+		t.Errorf("Unexpected result for EV_OMIT_BOOT_DEVICE_EVENTS without a string: %#v, %v", out, err)
+	}
+
+	blob := []byte{0x00, 0x00, 0xf0, 0xff, 0x00, 0x00, 0x00, 0x00, 0x00, 0x80, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00}
+	out, _, err = decodeEventDataTCG(EventTypeCPUMicrocode, blob, false)
+	if err != nil {
+		t.Fatalf("decodeEventDataTCG failed: %v", err)
+	}
+	if d, ok := out.(*EFIPlatformFirmwareBlobEventData); !ok || d.BlobBase != 0xfff00000 || d.BlobLength != 0x8000 {
+		t.Errorf("Unexpected event data %#v", out)
+	}
+}
+
+func TestCheckPlatformConfigEvents(t *testing.T) {
+	options := &LogOptions{}
+	makeEvent := func(index uint, eventType EventType, data []byte) *Event {
+		d, _ := decodeEventData(1, eventType, data, options, false)
+		return &Event{Index: index, PCRIndex: 1, EventType: eventType, Data: d}
+	}
+
+	events := []*Event{
+		makeEvent(0, EventTypePlatformConfigFlags, []byte("Setup Configuration")),
+		makeEvent(1, EventTypePlatformConfigFlags, []byte{0x01, 0x00, 0x02, 0x00}),
+		makeEvent(2, EventTypeCPUMicrocode, []byte{0xde, 0xad, 0xbe, 0xef}),
+		makeEvent(3, EventTypeEFIHandoffTables, []byte{0x01, 0x02, 0x03}),
+	}
+
+	findings := CheckPlatformConfigEvents(events)
+	if len(findings) != 2 {
+		t.Fatalf("Unexpected number of findings: %d", len(findings))
+	}
+	for i, expected := range []uint{1, 2} {
+		if findings[i].Code != FindingUndecodedPlatformConfig || findings[i].Severity != FindingSeverityInfo {
+			t.Errorf("Unexpected finding: %v", findings[i])
+		}
+		if findings[i].Event.Index != expected {
+			t.Errorf("Unexpected event for finding %d: %d", i, findings[i].Event.Index)
+		}
+	}
+}
+
+func TestCheckPlatformConfigEventConformance(t *testing.T) {
+	options := &LogOptions{}
+	makeEvent := func(index uint, pcr PCRIndex, eventType EventType, data []byte) *Event {
+		d, _ := decodeEventData(pcr, eventType, data, options, false)
+		return &Event{Index: index, PCRIndex: pcr, EventType: eventType, Data: d}
+	}
+
+	events := []*Event{
+		makeEvent(0, 4, EventTypeOmitBootDeviceEvents, []byte("BOOT ATTEMPTS OMITTED")),
+		makeEvent(1, 4, EventTypeOmitBootDeviceEvents, []byte("Boot attempts omitted")),
+		makeEvent(2, 5, EventTypeOmitBootDeviceEvents, []byte("BOOT ATTEMPTS OMITTED")),
+		makeEvent(3, 0, EventTypePlatformConfigFlags, []byte("Setup Configuration")),
+		makeEvent(4, 4, EventTypeOmitBootDeviceEvents, []byte{0xff, 0xfe}),
+	}
+
+	findings := CheckPlatformConfigEvents(events)
+	if len(findings) != 4 {
+		t.Fatalf("Unexpected number of findings: %d", len(findings))
+	}
+	for i, expected := range []uint{1, 2, 3, 4} {
+		if findings[i].Code != FindingSpecViolation || findings[i].Severity != FindingSeverityWarning {
+			t.Errorf("Unexpected finding: %v", findings[i])
+		}
+		if findings[i].Event.Index != expected {
+			t.Errorf("Unexpected event for finding %d: %d", i, findings[i].Event.Index)
+		}
+	}
+}
@@ -0,0 +1,80 @@
+package tcglog
+
+import (
+	"fmt"
+)
+
+// PlatformProfile corresponds to the class of platform that produced a log. Server platforms allocate some PCRs
+// differently to client platforms, and commonly measure firmware and configuration for non-host components such
+// as baseboard management controllers.
+type PlatformProfile int
+
+const (
+	// PlatformProfileAuto infers the profile from the platformClass field of the Spec ID event. Logs without a
+	// Spec ID event are assumed to be from client platforms.
+	PlatformProfileAuto PlatformProfile = iota
+
+	// PlatformProfileClient corresponds to the TCG PC Client platform profile.
+	PlatformProfileClient
+
+	// PlatformProfileServer corresponds to the TCG server platform profile.
+	PlatformProfileServer
+)
+
+// https://trustedcomputinggroup.org/wp-content/uploads/TCG_PCClientSpecPlat_TPM_2p0_1p04_pub.pdf
+//  (section 9.4.5.1 "Specification ID Version Event")
+const (
+	platformClassClient uint32 = 0x00000000
+	platformClassServer uint32 = 0x00000001
+)
+
+func (p PlatformProfile) String() string {
+	switch p {
+	case PlatformProfileAuto:
+		return "auto"
+	case PlatformProfileClient:
+		return "client"
+	case PlatformProfileServer:
+		return "server"
+	default:
+		return fmt.Sprintf("PlatformProfile(%d)", int(p))
+	}
+}
+
+// ParsePlatformProfile returns the PlatformProfile corresponding to the supplied name.
+func ParsePlatformProfile(profile string) (PlatformProfile, error) {
+	switch profile {
+	case "auto":
+		return PlatformProfileAuto, nil
+	case "client":
+		return PlatformProfileClient, nil
+	case "server":
+		return PlatformProfileServer, nil
+	default:
+		return 0, fmt.Errorf("Unrecognized platform profile \"%s\"", profile)
+	}
+}
+
+// determinePlatformProfile returns the profile to use for a log with the supplied Spec ID event, which may be
+// nil. An explicitly selected profile takes precedence over the one indicated by the log.
+func determinePlatformProfile(selected PlatformProfile, specIdEvent *Event) PlatformProfile {
+	if selected != PlatformProfileAuto {
+		return selected
+	}
+	if specIdEvent == nil {
+		return PlatformProfileClient
+	}
+	d, ok := specIdEvent.Data.(*SpecIdEventData)
+	if !ok || d.PlatformClass != platformClassServer {
+		return PlatformProfileClient
+	}
+	return PlatformProfileServer
+}
+
+// serverPCRUsage contains the descriptions of PCRs that are used differently on server platforms to
+// efiPCRUsage.
+var serverPCRUsage = map[PCRIndex]string{
+	0: "SRTM, BIOS, host platform extensions, embedded option ROMs, PI drivers and non-host firmware",
+	1: "Host platform and non-host configuration",
+	6: "Host platform manufacturer specific, such as management controller and chassis events",
+}
@@ -0,0 +1,64 @@
+package tcglog
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestPlatformProfile(t *testing.T) {
+	makeLog := func(platformClass uint32) []byte {
+		var buf bytes.Buffer
+		w, err := NewLogWriter(&buf, SpecEFI_2, AlgorithmIdList{AlgorithmSha256})
+		if err != nil {
+			t.Fatalf("NewLogWriter failed: %v", err)
+		}
+		if err := w.WriteSpecIdEvent(platformClass, nil); err != nil {
+			t.Fatalf("WriteSpecIdEvent failed: %v", err)
+		}
+		return buf.Bytes()
+	}
+
+	for _, data := range []struct {
+		desc          string
+		platformClass uint32
+		selected      PlatformProfile
+		expected      PlatformProfile
+	}{
+		{desc: "client", platformClass: platformClassClient, expected: PlatformProfileClient},
+		{desc: "server", platformClass: platformClassServer, expected: PlatformProfileServer},
+		{desc: "unknown class", platformClass: 2, expected: PlatformProfileClient},
+		{desc: "explicit client", platformClass: platformClassServer, selected: PlatformProfileClient,
+			expected: PlatformProfileClient},
+		{desc: "explicit server", platformClass: platformClassClient, selected: PlatformProfileServer,
+			expected: PlatformProfileServer},
+	} {
+		t.Run(data.desc, func(t *testing.T) {
+			log, err := NewLog(bytes.NewReader(makeLog(data.platformClass)),
+				LogOptions{PlatformProfile: data.selected})
+			if err != nil {
+				t.Fatalf("NewLog failed: %v", err)
+			}
+			if log.PlatformProfile != data.expected {
+				t.Errorf("Unexpected profile %s", log.PlatformProfile)
+			}
+		})
+	}
+
+	serverOptions := LogOptions{PlatformProfile: PlatformProfileServer}
+	if desc := DescribePCRWithOptions(6, SpecEFI_2, serverOptions); desc != serverPCRUsage[6] {
+		t.Errorf("Unexpected description for PCR 6: %s", desc)
+	}
+	if desc := DescribePCRWithOptions(7, SpecEFI_2, serverOptions); desc != efiPCRUsage[7] {
+		t.Errorf("Unexpected description for PCR 7: %s", desc)
+	}
+	if desc := DescribePCR(6, SpecEFI_2); desc != efiPCRUsage[6] {
+		t.Errorf("Unexpected description for PCR 6: %s", desc)
+	}
+
+	if p, err := ParsePlatformProfile("server"); err != nil || p != PlatformProfileServer {
+		t.Errorf("Unexpected result from ParsePlatformProfile: %v, %v", p, err)
+	}
+	if _, err := ParsePlatformProfile("mainframe"); err == nil {
+		t.Errorf("ParsePlatformProfile should fail for an unrecognized profile")
+	}
+}
@@ -0,0 +1,211 @@
+package tcglog
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"sort"
+)
+
+const (
+	tpmCCPolicyOR  uint32 = 0x00000171
+	tpmCCPolicyPCR uint32 = 0x0000017f
+
+	policyORMaxDigests = 8
+)
+
+// PCRPolicyBranch corresponds to one combination of PCR values that is permitted by a PCR policy.
+type PCRPolicyBranch struct {
+	Values       map[PCRIndex]Digest // The value of each selected PCR
+	PCRDigest    Digest              // The digest of the selected PCR values, as passed to TPM2_PolicyPCR
+	PolicyDigest Digest              // The policy digest after executing TPM2_PolicyPCR with PCRDigest
+}
+
+// PCRPolicy describes a policy that permits a set of boot configurations, computed from the logs or the PCR
+// values that were collected from them.
+type PCRPolicy struct {
+	Algorithm AlgorithmId // The hash algorithm of the policy
+	Bank      AlgorithmId // The PCR bank that the policy is bound to
+	PCRs      []PCRIndex  // The PCRs selected by the policy, in ascending order
+
+	// Alternatives contains the distinct values that each selected PCR can have, in the order that they
+	// first appear in the supplied boot configurations.
+	Alternatives map[PCRIndex][]Digest
+
+	// Branches contains each distinct combination of PCR values. TPM2_PolicyPCR binds the values of all of
+	// the selected PCRs together, so these are the branches of the policy rather than the alternatives for
+	// individual PCRs.
+	Branches []PCRPolicyBranch
+
+	// PolicyDigest is the digest of the complete policy. If there is more than one branch, this is the digest
+	// of a tree of TPM2_PolicyOR assertions, each of which has no more than 8 branches, with the branches in
+	// the leaves in the order that they appear in Branches.
+	PolicyDigest Digest
+}
+
+// marshalPCRSelection returns the TPML_PCR_SELECTION for the specified bank and PCRs.
+func marshalPCRSelection(bank AlgorithmId, pcrs []PCRIndex) []byte {
+	size := 3
+	for _, pcr := range pcrs {
+		if int(pcr/8) >= size {
+			size = int(pcr/8) + 1
+		}
+	}
+	selection := make([]byte, size)
+	for _, pcr := range pcrs {
+		selection[pcr/8] |= 1 << (pcr % 8)
+	}
+
+	var buf bytes.Buffer
+	binary.Write(&buf, binary.BigEndian, uint32(1))
+	binary.Write(&buf, binary.BigEndian, bank)
+	buf.WriteByte(uint8(size))
+	buf.Write(selection)
+	return buf.Bytes()
+}
+
+// https://trustedcomputinggroup.org/wp-content/uploads/TCG_TPM2_r1p59_Part3_Commands_pub.pdf
+//  (section 23.7 "TPM2_PolicyPCR")
+func computePolicyPCRDigest(alg AlgorithmId, selection []byte, pcrDigest Digest) Digest {
+	h := alg.newHash()
+	h.Write(make([]byte, alg.size()))
+	binary.Write(h, binary.BigEndian, tpmCCPolicyPCR)
+	h.Write(selection)
+	h.Write(pcrDigest)
+	return h.Sum(nil)
+}
+
+// https://trustedcomputinggroup.org/wp-content/uploads/TCG_TPM2_r1p59_Part3_Commands_pub.pdf
+//  (section 23.6 "TPM2_PolicyOR")
+func computePolicyORDigest(alg AlgorithmId, digests []Digest) Digest {
+	for len(digests) > 1 {
+		var next []Digest
+		for len(digests) > 0 {
+			n := len(digests)
+			if n > policyORMaxDigests {
+				n = policyORMaxDigests
+			}
+			h := alg.newHash()
+			h.Write(make([]byte, alg.size()))
+			binary.Write(h, binary.BigEndian, tpmCCPolicyOR)
+			for _, d := range digests[:n] {
+				h.Write(d)
+			}
+			// A TPM2_PolicyOR assertion needs at least 2 branches, so a single remaining digest is
+			// duplicated.
+			if n == 1 {
+				h.Write(digests[0])
+			}
+			next = append(next, h.Sum(nil))
+			digests = digests[n:]
+		}
+		digests = next
+	}
+	return digests[0]
+}
+
+// NewPCRPolicy computes a policy for the specified hash algorithm that permits each of the supplied boot
+// configurations, described by the PCR values that they produce. The policy is bound to the specified PCRs in
+// the specified bank. Configurations that produce the same values for the selected PCRs are merged.
+func NewPCRPolicy(alg AlgorithmId, configurations []map[PCRIndex]DigestMap, bank AlgorithmId,
+	pcrs []PCRIndex) (*PCRPolicy, error) {
+	if !alg.supported() {
+		return nil, fmt.Errorf("unsupported hash algorithm %s", alg)
+	}
+	if !bank.supported() {
+		return nil, fmt.Errorf("unsupported PCR bank %s", bank)
+	}
+	if len(configurations) == 0 {
+		return nil, errors.New("no boot configurations")
+	}
+
+	sorted := make([]PCRIndex, 0, len(pcrs))
+	selected := make(map[PCRIndex]bool)
+	for _, pcr := range pcrs {
+		if !isPCRIndexInRange(pcr) {
+			return nil, wrapPCRIndexOutOfRangeError(pcr)
+		}
+		if !selected[pcr] {
+			selected[pcr] = true
+			sorted = append(sorted, pcr)
+		}
+	}
+	if len(sorted) == 0 {
+		return nil, errors.New("no PCRs selected")
+	}
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+
+	policy := &PCRPolicy{Algorithm: alg, Bank: bank, PCRs: sorted, Alternatives: make(map[PCRIndex][]Digest)}
+	selection := marshalPCRSelection(bank, sorted)
+
+	seen := make(map[string]bool)
+	for i, values := range configurations {
+		branch := PCRPolicyBranch{Values: make(map[PCRIndex]Digest)}
+		var key bytes.Buffer
+		for _, pcr := range sorted {
+			value, ok := values[pcr][bank]
+			if !ok {
+				value = make(Digest, bank.size())
+			}
+			if len(value) != bank.size() {
+				return nil, fmt.Errorf("configuration %d: invalid value for PCR %d in bank %s", i, pcr, bank)
+			}
+			branch.Values[pcr] = value
+			key.Write(value)
+
+			found := false
+			for _, v := range policy.Alternatives[pcr] {
+				if bytes.Equal(v, value) {
+					found = true
+					break
+				}
+			}
+			if !found {
+				policy.Alternatives[pcr] = append(policy.Alternatives[pcr], value)
+			}
+		}
+		if seen[key.String()] {
+			continue
+		}
+		seen[key.String()] = true
+
+		pcrDigest, err := ComputePCRDigest(alg, values, bank, sorted)
+		if err != nil {
+			return nil, fmt.Errorf("configuration %d: %w", i, err)
+		}
+		branch.PCRDigest = pcrDigest
+		branch.PolicyDigest = computePolicyPCRDigest(alg, selection, pcrDigest)
+		policy.Branches = append(policy.Branches, branch)
+	}
+
+	if len(policy.Branches) == 1 {
+		policy.PolicyDigest = policy.Branches[0].PolicyDigest
+	} else {
+		var digests []Digest
+		for _, b := range policy.Branches {
+			digests = append(digests, b.PolicyDigest)
+		}
+		policy.PolicyDigest = computePolicyORDigest(alg, digests)
+	}
+
+	return policy, nil
+}
+
+// NewPCRPolicyFromLogs computes a policy for the specified hash algorithm that permits each of the boot
+// configurations described by the supplied logs, such as logs collected from different hardware revisions or
+// with different kernel versions. Each log is replayed from its current position. See NewPCRPolicy.
+func NewPCRPolicyFromLogs(alg AlgorithmId, logs []*Log, bank AlgorithmId, pcrs []PCRIndex) (*PCRPolicy, error) {
+	var configurations []map[PCRIndex]DigestMap
+	for i, log := range logs {
+		if !log.Algorithms.Contains(bank) {
+			return nil, fmt.Errorf("log %d doesn't contain digests for the %s bank", i, bank)
+		}
+		values, err := log.ReplayPCRs()
+		if err != nil {
+			return nil, fmt.Errorf("cannot replay log %d: %w", i, err)
+		}
+		configurations = append(configurations, values)
+	}
+	return NewPCRPolicy(alg, configurations, bank, pcrs)
+}
@@ -0,0 +1,107 @@
+package tcglog
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"testing"
+)
+
+func TestNewPCRPolicy(t *testing.T) {
+	value := func(s string) DigestMap {
+		return DigestMap{AlgorithmSha256: AlgorithmSha256.hash([]byte(s))}
+	}
+	configurations := []map[PCRIndex]DigestMap{
+		{4: value("shim-a"), 7: value("db")},
+		{4: value("shim-b"), 7: value("db")},
+		{4: value("shim-a"), 7: value("db"), 8: value("ignored")},
+	}
+
+	policy, err := NewPCRPolicy(AlgorithmSha256, configurations, AlgorithmSha256, []PCRIndex{7, 4, 7})
+	if err != nil {
+		t.Fatalf("NewPCRPolicy failed: %v", err)
+	}
+	if len(policy.PCRs) != 2 || policy.PCRs[0] != 4 || policy.PCRs[1] != 7 {
+		t.Errorf("Unexpected PCRs: %v", policy.PCRs)
+	}
+	if len(policy.Alternatives[4]) != 2 || len(policy.Alternatives[7]) != 1 {
+		t.Errorf("Unexpected alternatives: %v", policy.Alternatives)
+	}
+	if len(policy.Branches) != 2 {
+		t.Fatalf("Unexpected number of branches (%d)", len(policy.Branches))
+	}
+
+	// Compute the digest of the first branch independently.
+	h := sha256.New()
+	h.Write(value("shim-a")[AlgorithmSha256])
+	h.Write(value("db")[AlgorithmSha256])
+	pcrDigest := h.Sum(nil)
+	if !bytes.Equal(policy.Branches[0].PCRDigest, pcrDigest) {
+		t.Errorf("Unexpected PCR digest: %x", policy.Branches[0].PCRDigest)
+	}
+	h = sha256.New()
+	h.Write(make([]byte, 32))
+	h.Write([]byte{0x00, 0x00, 0x01, 0x7f, 0x00, 0x00, 0x00, 0x01, 0x00, 0x0b, 0x03, 0x90, 0x00, 0x00})
+	h.Write(pcrDigest)
+	if !bytes.Equal(policy.Branches[0].PolicyDigest, h.Sum(nil)) {
+		t.Errorf("Unexpected policy digest: %x", policy.Branches[0].PolicyDigest)
+	}
+
+	h = sha256.New()
+	h.Write(make([]byte, 32))
+	h.Write([]byte{0x00, 0x00, 0x01, 0x71})
+	h.Write(policy.Branches[0].PolicyDigest)
+	h.Write(policy.Branches[1].PolicyDigest)
+	if !bytes.Equal(policy.PolicyDigest, h.Sum(nil)) {
+		t.Errorf("Unexpected policy digest: %x", policy.PolicyDigest)
+	}
+
+	single, err := NewPCRPolicy(AlgorithmSha256, configurations[:1], AlgorithmSha256, []PCRIndex{4, 7})
+	if err != nil {
+		t.Fatalf("NewPCRPolicy failed: %v", err)
+	}
+	if !bytes.Equal(single.PolicyDigest, policy.Branches[0].PolicyDigest) {
+		t.Errorf("Unexpected policy digest for a single configuration: %x", single.PolicyDigest)
+	}
+
+	// More than 8 branches requires a tree of TPM2_PolicyOR assertions.
+	configurations = nil
+	for i := 0; i < 9; i++ {
+		configurations = append(configurations, map[PCRIndex]DigestMap{4: value(string(rune('a' + i)))})
+	}
+	policy, err = NewPCRPolicy(AlgorithmSha256, configurations, AlgorithmSha256, []PCRIndex{4})
+	if err != nil {
+		t.Fatalf("NewPCRPolicy failed: %v", err)
+	}
+	var digests []Digest
+	for _, b := range policy.Branches {
+		digests = append(digests, b.PolicyDigest)
+	}
+	expected := computePolicyORDigest(AlgorithmSha256, []Digest{
+		computePolicyORDigest(AlgorithmSha256, digests[:8]),
+		computePolicyORDigest(AlgorithmSha256, []Digest{digests[8], digests[8]})})
+	if !bytes.Equal(policy.PolicyDigest, expected) {
+		t.Errorf("Unexpected policy digest: %x", policy.PolicyDigest)
+	}
+
+	if _, err := NewPCRPolicy(AlgorithmSha256, configurations, AlgorithmSha256, []PCRIndex{32}); err == nil {
+		t.Errorf("NewPCRPolicy should have failed for an out-of-range PCR")
+	}
+}
+
+func TestNewPCRPolicyFromLogs(t *testing.T) {
+	var logs []*Log
+	for _, n := range []int{2, 3, 2} {
+		log, err := NewLog(bytes.NewReader(makeTestCryptoAgileLog(t, n)), LogOptions{})
+		if err != nil {
+			t.Fatalf("NewLog failed: %v", err)
+		}
+		logs = append(logs, log)
+	}
+	policy, err := NewPCRPolicyFromLogs(AlgorithmSha256, logs, AlgorithmSha256, []PCRIndex{0, 1, 2})
+	if err != nil {
+		t.Fatalf("NewPCRPolicyFromLogs failed: %v", err)
+	}
+	if len(policy.Branches) != 2 || len(policy.Alternatives[2]) != 2 || len(policy.Alternatives[0]) != 1 {
+		t.Errorf("Unexpected policy: %+v", policy)
+	}
+}
@@ -0,0 +1,73 @@
+package tcglog
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// checkPredictionAlgorithms checks that the algorithms supplied to a PCR predictor are usable.
+func checkPredictionAlgorithms(algorithms AlgorithmIdList) error {
+	if len(algorithms) == 0 {
+		return errors.New("no digest algorithms specified")
+	}
+	for _, alg := range algorithms {
+		if !alg.supported() {
+			return fmt.Errorf("unsupported digest algorithm %s", alg)
+		}
+	}
+	return nil
+}
+
+// computeEventDigests returns the digests of an event that measures the supplied bytes.
+func computeEventDigests(algorithms AlgorithmIdList, measuredBytes []byte) DigestMap {
+	digests := DigestMap{}
+	for _, alg := range algorithms {
+		digests[alg] = alg.hash(measuredBytes)
+	}
+	return digests
+}
+
+// eventMeasuredBytes returns the bytes that are measured for the supplied event data, for event types where the
+// event data is measured. This encodes the data for types that can be constructed by the caller.
+func eventMeasuredBytes(data EventData) []byte {
+	if e, ok := data.(interface{ EncodeMeasuredBytes(io.Writer) error }); ok {
+		var buf bytes.Buffer
+		if err := e.EncodeMeasuredBytes(&buf); err == nil {
+			return buf.Bytes()
+		}
+	}
+	return data.Bytes()
+}
+
+// predictEventDigests returns the digests of an event of the specified type with the supplied data. Whether
+// this is possible depends on what firmware that conforms to the specified specification hashes for events of
+// this type, as described by MeasuredContentForEventType.
+func predictEventDigests(spec Spec, eventType EventType, data EventData, algorithms AlgorithmIdList) (DigestMap,
+	error) {
+	if content := MeasuredContentForEventType(spec, eventType); content != MeasuredContentEventData {
+		return nil, fmt.Errorf("cannot predict the digests of %s events from their data (measured content: %s)",
+			eventType, content)
+	}
+	return computeEventDigests(algorithms, eventMeasuredBytes(data)), nil
+}
+
+// extendPredictedDigests computes the value of a PCR for each of the specified algorithms from its initial
+// value of zero and the supplied sequence of measurements.
+func extendPredictedDigests(algorithms AlgorithmIdList, digests []DigestMap) (DigestMap, error) {
+	out := DigestMap{}
+	for _, alg := range algorithms {
+		out[alg] = make(Digest, alg.size())
+	}
+	for i, d := range digests {
+		for _, alg := range algorithms {
+			digest, ok := d[alg]
+			if !ok {
+				return nil, fmt.Errorf("measurement %d has no %s digest", i, alg)
+			}
+			out[alg] = performHashExtendOperation(alg, out[alg], digest)
+		}
+	}
+	return out, nil
+}
@@ -0,0 +1,155 @@
+package tcglog
+
+import (
+	"io"
+
+	"github.com/chrisccoulson/tcglog-parser/rawlog"
+)
+
+// RawDigest corresponds to a single digest recorded in a RawEvent.
+type RawDigest struct {
+	Algorithm AlgorithmId
+	Digest    Digest
+}
+
+// RawEvent corresponds to a single event in an event log without its event data decoded. It is returned from
+// ParseRawEvents, and is intended for consumers that only need to replay the log.
+type RawEvent struct {
+	Offset    int64       // Offset of the start of this event from the start of the log
+	PCRIndex  PCRIndex    // PCR index to which this event was measured
+	EventType EventType   // The type of this event
+	Digests   []RawDigest // The digests corresponding to this event for the supported algorithms
+	DataSize  uint32      // The size of the event data recorded with this event
+}
+
+// Digest returns the digest of this event for the specified algorithm, or nil if there isn't one.
+func (e *RawEvent) Digest(alg AlgorithmId) Digest {
+	for _, d := range e.Digests {
+		if d.Algorithm == alg {
+			return d.Digest
+		}
+	}
+	return nil
+}
+
+// RawLog is the result of parsing a log with ParseRawEvents.
+type RawLog struct {
+	Spec       Spec            // The specification to which this log conforms
+	Algorithms AlgorithmIdList // The digest algorithms that appear in the log
+	Events     []RawEvent
+}
+
+// rawDigestStore provides the backing storage for the digests of each RawEvent, to minimize allocations.
+type rawDigestStore struct {
+	digests []RawDigest
+}
+
+// newRawEvent converts the supplied event, keeping only the digests for supported algorithms.
+func (s *rawDigestStore) newRawEvent(event *rawlog.Event) RawEvent {
+	start := len(s.digests)
+	for _, d := range event.Digests {
+		alg := AlgorithmId(d.Algorithm)
+		if !alg.supported() || len(d.Digest) != alg.size() {
+			continue
+		}
+		if len(s.digests) == cap(s.digests) {
+			// Make sure all of the digests for this event are contiguous.
+			n := len(s.digests) - start
+			pending := s.digests[start:]
+			s.digests = make([]RawDigest, n, 1024)
+			copy(s.digests, pending)
+			start = 0
+		}
+		s.digests = append(s.digests, RawDigest{Algorithm: alg, Digest: d.Digest})
+	}
+
+	return RawEvent{
+		Offset:    int64(event.Offset),
+		PCRIndex:  PCRIndex(event.PCRIndex),
+		EventType: EventType(event.EventType),
+		Digests:   s.digests[start:len(s.digests):len(s.digests)],
+		DataSize:  uint32(len(event.Data))}
+}
+
+// wrapRawLogReadError converts an error returned from the rawlog package to the equivalent error from this
+// package.
+func wrapRawLogReadError(err error) error {
+	switch e := err.(type) {
+	case *rawlog.PCRIndexOutOfRangeError:
+		return wrapPCRIndexOutOfRangeError(PCRIndex(e.PCRIndex))
+	case *rawlog.UnknownAlgorithmError:
+		return &UnknownAlgorithmError{Algorithm: AlgorithmId(e.Algorithm)}
+	}
+	if err == rawlog.ErrTruncated {
+		return wrapLogReadError(io.ErrUnexpectedEOF, true)
+	}
+	return err
+}
+
+// ParseRawEvents parses the event log contained in data without decoding any event data, returning only the PCR
+// index, event type, digests, offset and event data size of each event. This is significantly faster than
+// using Log, and is suitable for consumers that only need to replay the log, such as quote verifiers.
+//
+// The returned digests alias data, which must not be modified whilst they are in use.
+func ParseRawEvents(data []byte) (*RawLog, error) {
+	p := rawlog.NewParser(data)
+
+	first, err := p.ReadEvent()
+	if err != nil {
+		return nil, wrapRawLogReadError(err)
+	}
+
+	log := &RawLog{Spec: SpecUnknown, Algorithms: AlgorithmIdList{AlgorithmSha1}}
+
+	var algSizes []rawlog.AlgorithmSize
+	if EventType(first.EventType) == EventTypeNoAction {
+		d, _, err := decodeEventDataNoAction(first.Data)
+		switch {
+		case err != nil:
+			switch err.(type) {
+			case invalidSpecIdEventError, *InvalidDigestSizeError:
+				return nil, err
+			}
+		case d != nil:
+			if specId, ok := d.(*SpecIdEventData); ok {
+				log.Spec = specId.Spec
+				for _, s := range specId.DigestSizes {
+					algSizes = append(algSizes, rawlog.AlgorithmSize{
+						Algorithm: rawlog.AlgorithmId(s.AlgorithmId),
+						Size:      s.DigestSize})
+				}
+			}
+		}
+	}
+
+	if log.Spec == SpecEFI_2 {
+		log.Algorithms = make(AlgorithmIdList, 0, len(algSizes))
+		for _, s := range algSizes {
+			alg := AlgorithmId(s.Algorithm)
+			if alg.supported() && int(s.Size) == alg.size() {
+				log.Algorithms = append(log.Algorithms, alg)
+			}
+		}
+	}
+
+	var store rawDigestStore
+
+	// Estimate the number of events to avoid reallocating the events slice too often.
+	log.Events = make([]RawEvent, 0, len(data)/128)
+	log.Events = append(log.Events, store.newRawEvent(&first))
+
+	for p.Len() > 0 {
+		var event rawlog.Event
+		if log.Spec == SpecEFI_2 {
+			event, err = p.ReadCryptoAgileEvent(algSizes)
+		} else {
+			event, err = p.ReadEvent()
+		}
+		if err != nil {
+			return nil, wrapRawLogReadError(err)
+		}
+		log.Events = append(log.Events, store.newRawEvent(&event))
+	}
+
+	return log, nil
+}
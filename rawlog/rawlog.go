@@ -0,0 +1,359 @@
+// Package rawlog provides a minimal parser for TCG event logs, which returns the PCR index, event type, digests
+// and event data of each event without decoding the event data. It is intended for size sensitive binaries,
+// such as those included in an initramfs, that only need to replay a log.
+//
+// Unlike the tcglog package, this package doesn't depend on fmt, os or reflect, and it has no dependencies
+// outside of the standard library. The tcglog package uses it to implement tcglog.ParseRawEvents.
+package rawlog
+
+import (
+	"bytes"
+	"errors"
+	"strconv"
+)
+
+var (
+	// ErrTruncated is returned when a log ends in the middle of an event.
+	ErrTruncated = errors.New("rawlog: log is truncated")
+
+	// ErrInvalidSpecIdEvent is returned when the Spec ID event of a crypto-agile log is invalid.
+	ErrInvalidSpecIdEvent = errors.New("rawlog: invalid Spec ID event")
+
+	// ErrUnknownAlgorithm is returned when an event in a crypto-agile log contains a digest for an algorithm
+	// that isn't described by the Spec ID event. The returned error is an *UnknownAlgorithmError, which
+	// matches this with errors.Is.
+	ErrUnknownAlgorithm = errors.New("rawlog: digest for an algorithm that isn't in the Spec ID event")
+
+	// ErrPCRIndexOutOfRange is returned when an event is associated with a PCR index that is out of range. The
+	// returned error is a *PCRIndexOutOfRangeError, which matches this with errors.Is.
+	ErrPCRIndexOutOfRange = errors.New("rawlog: PCR index is out of range")
+)
+
+// UnknownAlgorithmError is returned when an event in a crypto-agile log contains a digest for an algorithm that
+// isn't described by the Spec ID event.
+type UnknownAlgorithmError struct {
+	Algorithm AlgorithmId
+}
+
+func (e *UnknownAlgorithmError) Error() string {
+	return ErrUnknownAlgorithm.Error() + " (0x" + strconv.FormatUint(uint64(e.Algorithm), 16) + ")"
+}
+
+func (e *UnknownAlgorithmError) Is(target error) bool {
+	return target == ErrUnknownAlgorithm
+}
+
+// PCRIndexOutOfRangeError is returned when an event is associated with a PCR index that is out of range.
+type PCRIndexOutOfRangeError struct {
+	PCRIndex uint32
+}
+
+func (e *PCRIndexOutOfRangeError) Error() string {
+	return ErrPCRIndexOutOfRange.Error() + " (" + strconv.FormatUint(uint64(e.PCRIndex), 10) + ")"
+}
+
+func (e *PCRIndexOutOfRangeError) Is(target error) bool {
+	return target == ErrPCRIndexOutOfRange
+}
+
+const (
+	maxPCRIndex   = 31
+	maxDigestSize = 64 // sizeof(TPMU_HA)
+
+	eventTypeNoAction = 0x00000003
+)
+
+// AlgorithmId corresponds to the TPM_ALG_ID type.
+type AlgorithmId uint16
+
+const (
+	AlgorithmSha1     AlgorithmId = 0x0004 // TPM_ALG_SHA1
+	AlgorithmSha256   AlgorithmId = 0x000b // TPM_ALG_SHA256
+	AlgorithmSha384   AlgorithmId = 0x000c // TPM_ALG_SHA384
+	AlgorithmSha512   AlgorithmId = 0x000d // TPM_ALG_SHA512
+	AlgorithmSM3_256  AlgorithmId = 0x0012 // TPM_ALG_SM3_256
+	AlgorithmSha3_256 AlgorithmId = 0x0027 // TPM_ALG_SHA3_256
+	AlgorithmSha3_384 AlgorithmId = 0x0028 // TPM_ALG_SHA3_384
+	AlgorithmSha3_512 AlgorithmId = 0x0029 // TPM_ALG_SHA3_512
+)
+
+// Size returns the size of digests produced by this algorithm, or zero if the algorithm isn't known.
+func (a AlgorithmId) Size() int {
+	switch a {
+	case AlgorithmSha1:
+		return 20
+	case AlgorithmSha256, AlgorithmSM3_256, AlgorithmSha3_256:
+		return 32
+	case AlgorithmSha384, AlgorithmSha3_384:
+		return 48
+	case AlgorithmSha512, AlgorithmSha3_512:
+		return 64
+	default:
+		return 0
+	}
+}
+
+// AlgorithmSize corresponds to a TCG_EfiSpecIdEventAlgorithmSize structure from the Spec ID event of a
+// crypto-agile log.
+type AlgorithmSize struct {
+	Algorithm AlgorithmId
+	Size      uint16
+}
+
+// Digest corresponds to a single digest recorded with an event.
+type Digest struct {
+	Algorithm AlgorithmId
+	Digest    []byte
+}
+
+// Event corresponds to a single event in a log.
+type Event struct {
+	Offset    int      // Offset of the start of this event from the start of the log
+	PCRIndex  uint32   // PCR index to which this event was measured
+	EventType uint32   // The type of this event
+	Digests   []Digest // The digests recorded with this event, in the order that they appear in the log
+	Data      []byte   // The event data
+}
+
+// Digest returns the digest of this event for the specified algorithm, or nil if there isn't one.
+func (e *Event) Digest(alg AlgorithmId) []byte {
+	for _, d := range e.Digests {
+		if d.Algorithm == alg {
+			return d.Digest
+		}
+	}
+	return nil
+}
+
+// Log is the result of parsing a log with Parse.
+type Log struct {
+	// CryptoAgile indicates that the log is in the crypto-agile (TCG_PCR_EVENT2) format. The first event is
+	// always in the TCG_PCClientPCREvent format.
+	CryptoAgile bool
+
+	// DigestSizes contains the algorithms and digest sizes from the Spec ID event of a crypto-agile log,
+	// including algorithms that aren't known by this package.
+	DigestSizes []AlgorithmSize
+
+	Events []Event
+}
+
+// Parser parses the events in a log one at a time. It is used by Parse, and is intended for callers that decode
+// the Spec ID event themselves. The returned digests and event data alias the supplied data, which must not be
+// modified whilst they are in use.
+type Parser struct {
+	data []byte
+	off  int
+}
+
+// NewParser returns a new Parser for the log contained in data.
+func NewParser(data []byte) *Parser {
+	return &Parser{data: data}
+}
+
+// Len returns the number of bytes of the log that haven't been parsed yet.
+func (p *Parser) Len() int {
+	return len(p.data) - p.off
+}
+
+func (p *Parser) next(n int) ([]byte, error) {
+	if n < 0 || len(p.data)-p.off < n {
+		return nil, ErrTruncated
+	}
+	b := p.data[p.off : p.off+n]
+	p.off += n
+	return b, nil
+}
+
+func (p *Parser) uint16() (uint16, error) {
+	b, err := p.next(2)
+	if err != nil {
+		return 0, err
+	}
+	return uint16(b[0]) | uint16(b[1])<<8, nil
+}
+
+func (p *Parser) uint32() (uint32, error) {
+	b, err := p.next(4)
+	if err != nil {
+		return 0, err
+	}
+	return uint32(b[0]) | uint32(b[1])<<8 | uint32(b[2])<<16 | uint32(b[3])<<24, nil
+}
+
+// ReadEvent reads the next event in the TCG_PCClientPCREvent format, which is used for every event in a log that
+// isn't crypto-agile and for the first event in a crypto-agile log.
+//
+// https://trustedcomputinggroup.org/wp-content/uploads/TCG_PCClientImplementation_1-21_1_00.pdf
+//  (section 11.1.1 "TCG_PCClientPCREventStruct Structure")
+func (p *Parser) ReadEvent() (Event, error) {
+	offset := p.off
+
+	pcrIndex, err := p.uint32()
+	if err != nil {
+		return Event{}, err
+	}
+	if pcrIndex > maxPCRIndex {
+		return Event{}, &PCRIndexOutOfRangeError{PCRIndex: pcrIndex}
+	}
+	eventType, err := p.uint32()
+	if err != nil {
+		return Event{}, err
+	}
+	digest, err := p.next(AlgorithmSha1.Size())
+	if err != nil {
+		return Event{}, err
+	}
+	eventSize, err := p.uint32()
+	if err != nil {
+		return Event{}, err
+	}
+	data, err := p.next(int(eventSize))
+	if err != nil {
+		return Event{}, err
+	}
+
+	return Event{
+		Offset:    offset,
+		PCRIndex:  pcrIndex,
+		EventType: eventType,
+		Digests:   []Digest{{Algorithm: AlgorithmSha1, Digest: digest}},
+		Data:      data}, nil
+}
+
+// ReadCryptoAgileEvent reads the next event in the crypto-agile (TCG_PCR_EVENT2) format, using the algorithms
+// and digest sizes from the log's Spec ID event.
+//
+// https://trustedcomputinggroup.org/wp-content/uploads/TCG_PCClientSpecPlat_TPM_2p0_1p04_pub.pdf
+//  (section 9.2.2 "TCG_PCR_EVENT2 Structure")
+func (p *Parser) ReadCryptoAgileEvent(algSizes []AlgorithmSize) (Event, error) {
+	offset := p.off
+
+	pcrIndex, err := p.uint32()
+	if err != nil {
+		return Event{}, err
+	}
+	if pcrIndex > maxPCRIndex {
+		return Event{}, &PCRIndexOutOfRangeError{PCRIndex: pcrIndex}
+	}
+	eventType, err := p.uint32()
+	if err != nil {
+		return Event{}, err
+	}
+	count, err := p.uint32()
+	if err != nil {
+		return Event{}, err
+	}
+	if int64(count) > int64(len(p.data)-p.off)/2 {
+		// Avoid allocating space for more digests than the rest of the log could contain.
+		return Event{}, ErrTruncated
+	}
+
+	digests := make([]Digest, 0, count)
+	for i := uint32(0); i < count; i++ {
+		a, err := p.uint16()
+		if err != nil {
+			return Event{}, err
+		}
+		alg := AlgorithmId(a)
+
+		size := -1
+		for _, s := range algSizes {
+			if s.Algorithm == alg {
+				size = int(s.Size)
+				break
+			}
+		}
+		if size < 0 {
+			return Event{}, &UnknownAlgorithmError{Algorithm: alg}
+		}
+
+		digest, err := p.next(size)
+		if err != nil {
+			return Event{}, err
+		}
+		digests = append(digests, Digest{Algorithm: alg, Digest: digest})
+	}
+
+	eventSize, err := p.uint32()
+	if err != nil {
+		return Event{}, err
+	}
+	data, err := p.next(int(eventSize))
+	if err != nil {
+		return Event{}, err
+	}
+
+	return Event{
+		Offset:    offset,
+		PCRIndex:  pcrIndex,
+		EventType: eventType,
+		Digests:   digests,
+		Data:      data}, nil
+}
+
+// https://trustedcomputinggroup.org/wp-content/uploads/TCG_PCClientSpecPlat_TPM_2p0_1p04_pub.pdf
+//  (secion 9.4.5.1 "Specification ID Version Event")
+func decodeSpecIdEvent(data []byte) ([]AlgorithmSize, error) {
+	// Skip the signature, platformClass, specVersionMinor, specVersionMajor, specErrata and uintnSize fields.
+	p := &Parser{data: data, off: 24}
+	if p.off > len(data) {
+		return nil, ErrInvalidSpecIdEvent
+	}
+
+	numberOfAlgorithms, err := p.uint32()
+	if err != nil || numberOfAlgorithms < 1 || int64(numberOfAlgorithms)*4 > int64(len(data)-p.off) {
+		return nil, ErrInvalidSpecIdEvent
+	}
+
+	algSizes := make([]AlgorithmSize, 0, numberOfAlgorithms)
+	for i := uint32(0); i < numberOfAlgorithms; i++ {
+		alg, _ := p.uint16()
+		size, _ := p.uint16()
+		if size == 0 || size > maxDigestSize {
+			return nil, ErrInvalidSpecIdEvent
+		}
+		algSizes = append(algSizes, AlgorithmSize{Algorithm: AlgorithmId(alg), Size: size})
+	}
+
+	return algSizes, nil
+}
+
+// Parse parses the event log contained in data. The returned digests and event data alias data, which must not
+// be modified whilst they are in use.
+func Parse(data []byte) (*Log, error) {
+	p := NewParser(data)
+
+	first, err := p.ReadEvent()
+	if err != nil {
+		return nil, err
+	}
+
+	log := &Log{Events: []Event{first}}
+
+	if first.PCRIndex == 0 && first.EventType == eventTypeNoAction &&
+		bytes.HasPrefix(first.Data, []byte("Spec ID Event03\x00")) {
+		algSizes, err := decodeSpecIdEvent(first.Data)
+		if err != nil {
+			return nil, err
+		}
+		log.CryptoAgile = true
+		log.DigestSizes = algSizes
+	}
+
+	for p.Len() > 0 {
+		var event Event
+		var err error
+		if log.CryptoAgile {
+			event, err = p.ReadCryptoAgileEvent(log.DigestSizes)
+		} else {
+			event, err = p.ReadEvent()
+		}
+		if err != nil {
+			return nil, err
+		}
+		log.Events = append(log.Events, event)
+	}
+
+	return log, nil
+}
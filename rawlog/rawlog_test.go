@@ -0,0 +1,142 @@
+package rawlog_test
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/chrisccoulson/tcglog-parser"
+	"github.com/chrisccoulson/tcglog-parser/rawlog"
+	"github.com/chrisccoulson/tcglog-parser/samples"
+)
+
+func TestParse(t *testing.T) {
+	for _, sample := range samples.Samples() {
+		t.Run(sample.Name, func(t *testing.T) {
+			expected, err := tcglog.ParseRawEvents(sample.Data)
+			if err != nil {
+				t.Fatalf("ParseRawEvents failed: %v", err)
+			}
+
+			log, err := rawlog.Parse(sample.Data)
+			if err != nil {
+				t.Fatalf("Parse failed: %v", err)
+			}
+			if log.CryptoAgile != (expected.Spec == tcglog.SpecEFI_2) {
+				t.Errorf("Unexpected format")
+			}
+			if len(log.Events) != len(expected.Events) {
+				t.Fatalf("Unexpected number of events (%d)", len(log.Events))
+			}
+			for i, e := range log.Events {
+				x := expected.Events[i]
+				if int64(e.Offset) != x.Offset || e.PCRIndex != uint32(x.PCRIndex) ||
+					e.EventType != uint32(x.EventType) || uint32(len(e.Data)) != x.DataSize {
+					t.Errorf("Unexpected event %d", i)
+				}
+				if i == 0 {
+					continue
+				}
+				for _, alg := range expected.Algorithms {
+					if !bytes.Equal(e.Digest(rawlog.AlgorithmId(alg)), x.Digest(alg)) {
+						t.Errorf("Unexpected %s digest for event %d", alg, i)
+					}
+				}
+			}
+
+			if _, err := rawlog.Parse(sample.Data[:len(sample.Data)-1]); err != rawlog.ErrTruncated {
+				t.Errorf("Unexpected error for a truncated log: %v", err)
+			}
+		})
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	data := samples.SampleByName("crypto-agile-efi").Data
+	log, err := rawlog.Parse(data)
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+
+	pcrData := append([]byte(nil), data...)
+	binary.LittleEndian.PutUint32(pcrData[log.Events[1].Offset:], 40)
+	_, err = rawlog.Parse(pcrData)
+	var pcrErr *rawlog.PCRIndexOutOfRangeError
+	if !errors.Is(err, rawlog.ErrPCRIndexOutOfRange) || !errors.As(err, &pcrErr) || pcrErr.PCRIndex != 40 {
+		t.Errorf("Unexpected error: %v", err)
+	}
+
+	// Replace the algorithm of the first digest of the second event.
+	algData := append([]byte(nil), data...)
+	binary.LittleEndian.PutUint16(algData[log.Events[1].Offset+12:], 0x0001)
+	_, err = rawlog.Parse(algData)
+	var algErr *rawlog.UnknownAlgorithmError
+	if !errors.Is(err, rawlog.ErrUnknownAlgorithm) || !errors.As(err, &algErr) || algErr.Algorithm != 0x0001 {
+		t.Errorf("Unexpected error: %v", err)
+	}
+}
+
+func TestDependencies(t *testing.T) {
+	goBin, err := exec.LookPath("go")
+	if err != nil {
+		t.Skip("go tool not available")
+	}
+	out, err := exec.Command(goBin, "list", "-deps", ".").Output()
+	if err != nil {
+		t.Skipf("go list failed: %v", err)
+	}
+	for _, pkg := range strings.Fields(string(out)) {
+		switch pkg {
+		case "fmt", "os", "reflect":
+			t.Errorf("Unexpected dependency on %s", pkg)
+		}
+	}
+}
+
+func BenchmarkParse(b *testing.B) {
+	data := samples.SampleByName("crypto-agile-efi").Data
+	b.SetBytes(int64(len(data)))
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		if _, err := rawlog.Parse(data); err != nil {
+			b.Fatalf("Parse failed: %v", err)
+		}
+	}
+}
+
+// BenchmarkBinarySize reports the size of a stripped binary that parses a log with this package, compared with
+// one that uses tcglog.ParseRawEvents.
+func BenchmarkBinarySize(b *testing.B) {
+	goBin, err := exec.LookPath("go")
+	if err != nil {
+		b.Skip("go tool not available")
+	}
+	dir, err := ioutil.TempDir("", "rawlog-size")
+	if err != nil {
+		b.Fatalf("TempDir failed: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	for _, name := range []string{"rawlog", "tcglog"} {
+		b.Run(name, func(b *testing.B) {
+			path := filepath.Join(dir, name)
+			cmd := exec.Command(goBin, "build", "-ldflags=-s -w", "-o", path, "./testdata/size/"+name)
+			if out, err := cmd.CombinedOutput(); err != nil {
+				b.Fatalf("go build failed: %v\n%s", err, out)
+			}
+			fi, err := os.Stat(path)
+			if err != nil {
+				b.Fatalf("Stat failed: %v", err)
+			}
+			b.ReportMetric(float64(fi.Size()), "bytes")
+		})
+	}
+}
@@ -0,0 +1,21 @@
+// This program is used to measure the size of binaries that use the rawlog package.
+package main
+
+import (
+	"io/ioutil"
+	"os"
+
+	"github.com/chrisccoulson/tcglog-parser/rawlog"
+)
+
+func main() {
+	data, err := ioutil.ReadFile(os.Args[1])
+	if err != nil {
+		os.Exit(1)
+	}
+	log, err := rawlog.Parse(data)
+	if err != nil {
+		os.Exit(1)
+	}
+	os.Exit(len(log.Events) & 0x7f)
+}
@@ -0,0 +1,21 @@
+// This program is used to measure the size of binaries that use the tcglog package, for comparison.
+package main
+
+import (
+	"io/ioutil"
+	"os"
+
+	"github.com/chrisccoulson/tcglog-parser"
+)
+
+func main() {
+	data, err := ioutil.ReadFile(os.Args[1])
+	if err != nil {
+		os.Exit(1)
+	}
+	log, err := tcglog.ParseRawEvents(data)
+	if err != nil {
+		os.Exit(1)
+	}
+	os.Exit(len(log.Events) & 0x7f)
+}
@@ -0,0 +1,146 @@
+package tcglog
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+	"testing"
+)
+
+func makeTestCryptoAgileLog(t testing.TB, nevents int) []byte {
+	return makeTestCryptoAgileLogWithDigestSizes(t, []EFISpecIdEventAlgorithmSize{
+		{AlgorithmId: AlgorithmSha1, DigestSize: uint16(AlgorithmSha1.size())},
+		{AlgorithmId: AlgorithmSha256, DigestSize: uint16(AlgorithmSha256.size())}}, nevents)
+}
+
+// makeTestCryptoAgileLogWithDigestSizes creates a log with the specified banks. Digests for algorithms that
+// aren't supported, or that have the wrong size, are filled with 0xa5 bytes.
+func makeTestCryptoAgileLogWithDigestSizes(t testing.TB, algSizes []EFISpecIdEventAlgorithmSize, nevents int) []byte {
+	var buf bytes.Buffer
+
+	// Spec ID event
+	var specId bytes.Buffer
+	specId.WriteString("Spec ID Event03\x00")
+	binary.Write(&specId, binary.LittleEndian, struct {
+		PlatformClass      uint32
+		SpecVersionMinor   uint8
+		SpecVersionMajor   uint8
+		SpecErrata         uint8
+		UintnSize          uint8
+		NumberOfAlgorithms uint32
+	}{
+		SpecVersionMajor:   2,
+		UintnSize:          2,
+		NumberOfAlgorithms: uint32(len(algSizes))})
+	binary.Write(&specId, binary.LittleEndian, algSizes)
+	specId.WriteByte(0) // VendorInfoSize
+	binary.Write(&buf, binary.LittleEndian, eventHeader_1_2{PCRIndex: 0, EventType: EventTypeNoAction})
+	buf.Write(make([]byte, AlgorithmSha1.size()))
+	binary.Write(&buf, binary.LittleEndian, uint32(specId.Len()))
+	buf.Write(specId.Bytes())
+
+	for i := 0; i < nevents; i++ {
+		data := []byte("Calling EFI Application from Boot Option")
+		binary.Write(&buf, binary.LittleEndian,
+			eventHeader_2{PCRIndex: PCRIndex(i % 8), EventType: EventTypeEFIAction, Count: uint32(len(algSizes))})
+		for _, s := range algSizes {
+			binary.Write(&buf, binary.LittleEndian, s.AlgorithmId)
+			if s.AlgorithmId.supported() && int(s.DigestSize) == s.AlgorithmId.size() {
+				buf.Write(s.AlgorithmId.hash(data))
+			} else {
+				buf.Write(bytes.Repeat([]byte{0xa5}, int(s.DigestSize)))
+			}
+		}
+		binary.Write(&buf, binary.LittleEndian, uint32(len(data)))
+		buf.Write(data)
+	}
+
+	return buf.Bytes()
+}
+
+func TestParseRawEvents(t *testing.T) {
+	data := makeTestCryptoAgileLog(t, 20)
+
+	raw, err := ParseRawEvents(data)
+	if err != nil {
+		t.Fatalf("ParseRawEvents failed: %v", err)
+	}
+	if raw.Spec != SpecEFI_2 {
+		t.Errorf("Unexpected spec: %d", raw.Spec)
+	}
+	if len(raw.Algorithms) != 2 || !raw.Algorithms.Contains(AlgorithmSha1) || !raw.Algorithms.Contains(AlgorithmSha256) {
+		t.Errorf("Unexpected algorithms: %v", raw.Algorithms)
+	}
+
+	log, err := NewLog(bytes.NewReader(data), LogOptions{})
+	if err != nil {
+		t.Fatalf("NewLog failed: %v", err)
+	}
+
+	for i := 0; ; i++ {
+		event, err := log.NextEvent()
+		if err == io.EOF {
+			if i != len(raw.Events) {
+				t.Errorf("Unexpected number of raw events (got %d, expected %d)", len(raw.Events), i)
+			}
+			break
+		}
+		if err != nil {
+			t.Fatalf("NextEvent failed: %v", err)
+		}
+		if i >= len(raw.Events) {
+			t.Fatalf("Too few raw events")
+		}
+
+		r := raw.Events[i]
+		if r.PCRIndex != event.PCRIndex || r.EventType != event.EventType ||
+			int(r.DataSize) != len(event.Data.Bytes()) {
+			t.Errorf("Unexpected raw event %d", i)
+		}
+		if i == 0 {
+			continue
+		}
+		for alg, digest := range event.Digests {
+			if !bytes.Equal(r.Digest(alg), digest) {
+				t.Errorf("Unexpected %s digest for raw event %d", alg, i)
+			}
+		}
+	}
+}
+
+func TestParseRawEventsTruncated(t *testing.T) {
+	data := makeTestCryptoAgileLog(t, 2)
+	if _, err := ParseRawEvents(data[:len(data)-1]); err == nil {
+		t.Errorf("ParseRawEvents should have failed")
+	}
+}
+
+func TestParseRawEventsPCRIndexOutOfRange(t *testing.T) {
+	data := makeTestCryptoAgileLog(t, 2)
+	raw, err := ParseRawEvents(data)
+	if err != nil {
+		t.Fatalf("ParseRawEvents failed: %v", err)
+	}
+	binary.LittleEndian.PutUint32(data[raw.Events[2].Offset:], 40)
+
+	_, err = ParseRawEvents(data)
+	var e *PCRIndexOutOfRangeError
+	if !errors.As(err, &e) || e.PCRIndex != 40 {
+		t.Errorf("Unexpected error: %v", err)
+	}
+}
+
+func BenchmarkParseRawEvents(b *testing.B) {
+	// Approximately 4MB
+	data := makeTestCryptoAgileLog(b, 40000)
+	b.SetBytes(int64(len(data)))
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		if _, err := ParseRawEvents(data); err != nil {
+			b.Fatalf("ParseRawEvents failed: %v", err)
+		}
+	}
+}
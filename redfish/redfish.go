@@ -0,0 +1,409 @@
+// Package redfish provides access to the TCG event log and PCR values of a host through the Redfish service of
+// its baseboard management controller (BMC), so that hosts can be validated out-of-band.
+//
+// Redfish doesn't define a standard resource for the event log. This package looks for a LogEntry resource with
+// a DiagnosticDataType of "OEM" and an OEMDiagnosticDataType of "TCGEventLog" in the log services of the
+// computer system, and downloads the binary log from its AdditionalDataURI. Services that expose the log
+// elsewhere can be supported by supplying its location with ClientOptions.EventLogURI.
+//
+// PCR values are read from the TPM measurements of ComponentIntegrity resources, which are available from
+// Redfish 2021.4.
+package redfish
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+
+	"github.com/chrisccoulson/tcglog-parser"
+)
+
+const (
+	serviceRootPath = "/redfish/v1/"
+
+	// DefaultEventLogDataType is the OEMDiagnosticDataType of the LogEntry that contains the event log.
+	DefaultEventLogDataType = "TCGEventLog"
+
+	// DefaultMaxEventLogSize is the maximum size of the event log that is accepted when
+	// ClientOptions.MaxEventLogSize is zero.
+	DefaultMaxEventLogSize = 64 * 1024 * 1024
+
+	// maxResourceSize is the maximum size of a JSON resource that is accepted from the service.
+	maxResourceSize = 4 * 1024 * 1024
+)
+
+// ClientOptions contains options for a Client.
+type ClientOptions struct {
+	// Username and Password are the credentials used to authenticate with the Redfish service using HTTP basic
+	// authentication. No authentication is performed if Username is empty.
+	Username string
+	Password string
+
+	// HTTPClient is the client used to make requests. If nil, http.DefaultClient is used.
+	HTTPClient *http.Client
+
+	// SystemId is the Id of the ComputerSystem resource that corresponds to the host. If empty, the service
+	// must only contain a single system.
+	SystemId string
+
+	// EventLogURI is the location of the binary event log, relative to the service endpoint. If empty, the
+	// log services of the system are searched for a LogEntry that contains the event log.
+	EventLogURI string
+
+	// EventLogDataType is the OEMDiagnosticDataType of the LogEntry that contains the event log. If empty,
+	// DefaultEventLogDataType is used.
+	EventLogDataType string
+
+	// MaxEventLogSize is the maximum size of the event log that is accepted from the service. If zero,
+	// DefaultMaxEventLogSize is used.
+	MaxEventLogSize int64
+}
+
+// Client retrieves the event log and PCR values of a host from a Redfish service. It implements
+// tcglog.PCRReader.
+type Client struct {
+	endpoint *url.URL
+	options  ClientOptions
+
+	pcrValues map[tcglog.PCRIndex]tcglog.DigestMap
+}
+
+// NewClient returns a new Client for the Redfish service at the specified endpoint, eg,
+// "https://bmc.example.com".
+func NewClient(endpoint string, options *ClientOptions) (*Client, error) {
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return nil, fmt.Errorf("invalid endpoint: %w", err)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return nil, fmt.Errorf("invalid endpoint: unsupported scheme \"%s\"", u.Scheme)
+	}
+
+	c := &Client{endpoint: u}
+	if options != nil {
+		c.options = *options
+	}
+	if c.options.HTTPClient == nil {
+		c.options.HTTPClient = http.DefaultClient
+	}
+	if c.options.EventLogDataType == "" {
+		c.options.EventLogDataType = DefaultEventLogDataType
+	}
+	if c.options.MaxEventLogSize == 0 {
+		c.options.MaxEventLogSize = DefaultMaxEventLogSize
+	}
+	return c, nil
+}
+
+type link struct {
+	Id string `json:"@odata.id"`
+}
+
+type collection struct {
+	Members []link
+}
+
+type serviceRoot struct {
+	Systems            link
+	ComponentIntegrity link
+}
+
+type computerSystem struct {
+	Id          string
+	LogServices link
+}
+
+type logService struct {
+	Entries link
+}
+
+type logEntry struct {
+	DiagnosticDataType    string
+	OEMDiagnosticDataType string
+	AdditionalDataURI     string
+}
+
+type componentIntegrity struct {
+	ComponentIntegrityType    string
+	ComponentIntegrityEnabled *bool
+	TPM                       *struct {
+		MeasurementSet struct {
+			Measurements []struct {
+				Measurement              string
+				MeasurementHashAlgorithm string
+				PCR                      *int
+			}
+		}
+	}
+}
+
+// get returns the contents of the resource at the specified path, which is resolved against the service endpoint
+// and may be an absolute URI provided by the service. Resources on a different host or with a different scheme
+// are refused so that the credentials aren't sent anywhere else, and resources larger than max bytes are
+// rejected.
+func (c *Client) get(path string, max int64) ([]byte, error) {
+	ref, err := url.Parse(path)
+	if err != nil {
+		return nil, fmt.Errorf("invalid resource path \"%s\": %w", path, err)
+	}
+	u := c.endpoint.ResolveReference(ref)
+	if u.Scheme != c.endpoint.Scheme || u.Host != c.endpoint.Host {
+		return nil, fmt.Errorf("refusing to get %s, which isn't provided by the service at %s", u, c.endpoint)
+	}
+
+	req, err := http.NewRequest(http.MethodGet, u.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	if c.options.Username != "" {
+		req.SetBasicAuth(c.options.Username, c.options.Password)
+	}
+
+	rsp, err := c.options.HTTPClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer rsp.Body.Close()
+
+	if rsp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("cannot get %s: %s", u.Path, rsp.Status)
+	}
+	data, err := ioutil.ReadAll(io.LimitReader(rsp.Body, max+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(data)) > max {
+		return nil, fmt.Errorf("cannot get %s: size exceeds the maximum of %d bytes", u.Path, max)
+	}
+	return data, nil
+}
+
+func (c *Client) getResource(path string, v interface{}) error {
+	data, err := c.get(path, maxResourceSize)
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		return fmt.Errorf("cannot decode %s: %w", path, err)
+	}
+	return nil
+}
+
+func (c *Client) serviceRoot() (*serviceRoot, error) {
+	var root serviceRoot
+	if err := c.getResource(serviceRootPath, &root); err != nil {
+		return nil, err
+	}
+	return &root, nil
+}
+
+func (c *Client) system(root *serviceRoot) (*computerSystem, error) {
+	if root.Systems.Id == "" {
+		return nil, errors.New("service doesn't expose any systems")
+	}
+	var systems collection
+	if err := c.getResource(root.Systems.Id, &systems); err != nil {
+		return nil, err
+	}
+
+	var candidates []*computerSystem
+	for _, m := range systems.Members {
+		var s computerSystem
+		if err := c.getResource(m.Id, &s); err != nil {
+			return nil, err
+		}
+		if c.options.SystemId == "" || s.Id == c.options.SystemId {
+			candidates = append(candidates, &s)
+		}
+	}
+
+	switch {
+	case len(candidates) == 1:
+		return candidates[0], nil
+	case len(candidates) == 0 && c.options.SystemId != "":
+		return nil, fmt.Errorf("cannot find system \"%s\"", c.options.SystemId)
+	case len(candidates) == 0:
+		return nil, errors.New("service doesn't expose any systems")
+	default:
+		return nil, errors.New("service exposes more than one system, so a system Id must be specified")
+	}
+}
+
+func (c *Client) findEventLogURI() (string, error) {
+	root, err := c.serviceRoot()
+	if err != nil {
+		return "", err
+	}
+	system, err := c.system(root)
+	if err != nil {
+		return "", err
+	}
+	if system.LogServices.Id == "" {
+		return "", fmt.Errorf("system \"%s\" doesn't have any log services", system.Id)
+	}
+
+	var services collection
+	if err := c.getResource(system.LogServices.Id, &services); err != nil {
+		return "", err
+	}
+	for _, m := range services.Members {
+		var service logService
+		if err := c.getResource(m.Id, &service); err != nil {
+			return "", err
+		}
+		if service.Entries.Id == "" {
+			continue
+		}
+
+		var entries collection
+		if err := c.getResource(service.Entries.Id, &entries); err != nil {
+			return "", err
+		}
+		for _, e := range entries.Members {
+			var entry logEntry
+			if err := c.getResource(e.Id, &entry); err != nil {
+				return "", err
+			}
+			if entry.DiagnosticDataType == "OEM" && entry.OEMDiagnosticDataType == c.options.EventLogDataType &&
+				entry.AdditionalDataURI != "" {
+				return entry.AdditionalDataURI, nil
+			}
+		}
+	}
+
+	return "", fmt.Errorf("cannot find a %s log entry for system \"%s\"", c.options.EventLogDataType, system.Id)
+}
+
+// ReadEventLog returns the binary TCG event log of the host, which can be parsed with tcglog.NewLog.
+func (c *Client) ReadEventLog() ([]byte, error) {
+	uri := c.options.EventLogURI
+	if uri == "" {
+		var err error
+		uri, err = c.findEventLogURI()
+		if err != nil {
+			return nil, err
+		}
+	}
+	data, err := c.get(uri, c.options.MaxEventLogSize)
+	if err != nil {
+		return nil, fmt.Errorf("cannot read event log: %w", err)
+	}
+	return data, nil
+}
+
+// parseAlgorithm parses a TPM_ALG_ID name, such as "TPM_ALG_SHA256".
+func parseAlgorithm(name string) (tcglog.AlgorithmId, error) {
+	return tcglog.ParseAlgorithm(strings.ToLower(strings.TrimPrefix(name, "TPM_ALG_")))
+}
+
+func (c *Client) readPCRValues() (map[tcglog.PCRIndex]tcglog.DigestMap, error) {
+	if c.pcrValues != nil {
+		return c.pcrValues, nil
+	}
+
+	root, err := c.serviceRoot()
+	if err != nil {
+		return nil, err
+	}
+	if root.ComponentIntegrity.Id == "" {
+		return nil, errors.New("service doesn't expose any ComponentIntegrity resources")
+	}
+
+	var components collection
+	if err := c.getResource(root.ComponentIntegrity.Id, &components); err != nil {
+		return nil, err
+	}
+	for _, m := range components.Members {
+		var component componentIntegrity
+		if err := c.getResource(m.Id, &component); err != nil {
+			return nil, err
+		}
+		if component.ComponentIntegrityType != "TPM" || component.TPM == nil {
+			continue
+		}
+		if component.ComponentIntegrityEnabled != nil && !*component.ComponentIntegrityEnabled {
+			continue
+		}
+
+		values := make(map[tcglog.PCRIndex]tcglog.DigestMap)
+		for _, measurement := range component.TPM.MeasurementSet.Measurements {
+			if measurement.PCR == nil {
+				continue
+			}
+			pcr := tcglog.PCRIndex(*measurement.PCR)
+			alg, err := parseAlgorithm(measurement.MeasurementHashAlgorithm)
+			if err != nil {
+				return nil, fmt.Errorf("cannot decode measurement for PCR %d: %w", pcr, err)
+			}
+			digest, err := base64.StdEncoding.DecodeString(measurement.Measurement)
+			if err != nil {
+				return nil, fmt.Errorf("cannot decode measurement for PCR %d, bank %s: %w", pcr, alg, err)
+			}
+			if len(digest) == 0 {
+				return nil, fmt.Errorf("invalid measurement for PCR %d, bank %s", pcr, alg)
+			}
+			if _, ok := values[pcr]; !ok {
+				values[pcr] = tcglog.DigestMap{}
+			}
+			values[pcr][alg] = digest
+		}
+		if len(values) == 0 {
+			continue
+		}
+
+		c.pcrValues = values
+		return values, nil
+	}
+
+	return nil, errors.New("cannot find a TPM with PCR measurements")
+}
+
+// ActivePCRBanks implements tcglog.PCRReader.ActivePCRBanks. A bank is considered to be active if the service
+// reports a value for PCR 0 from it.
+func (c *Client) ActivePCRBanks() (tcglog.AlgorithmIdList, error) {
+	values, err := c.readPCRValues()
+	if err != nil {
+		return nil, err
+	}
+	var banks tcglog.AlgorithmIdList
+	for alg := range values[0] {
+		banks = append(banks, alg)
+	}
+	if len(banks) == 0 {
+		return nil, errors.New("cannot find any PCR banks")
+	}
+	sort.Slice(banks, func(i, j int) bool { return banks[i] < banks[j] })
+	return banks, nil
+}
+
+// ReadPCRs implements tcglog.PCRReader.ReadPCRs.
+func (c *Client) ReadPCRs(pcrs []tcglog.PCRIndex) (map[tcglog.PCRIndex]tcglog.DigestMap, error) {
+	banks, err := c.ActivePCRBanks()
+	if err != nil {
+		return nil, err
+	}
+	values, err := c.readPCRValues()
+	if err != nil {
+		return nil, err
+	}
+
+	out := make(map[tcglog.PCRIndex]tcglog.DigestMap)
+	for _, pcr := range pcrs {
+		out[pcr] = tcglog.DigestMap{}
+		for _, alg := range banks {
+			digest, ok := values[pcr][alg]
+			if !ok {
+				return nil, fmt.Errorf("cannot read PCR %d, bank %s", pcr, alg)
+			}
+			out[pcr][alg] = append(tcglog.Digest(nil), digest...)
+		}
+	}
+	return out, nil
+}
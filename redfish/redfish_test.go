@@ -0,0 +1,227 @@
+package redfish
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/chrisccoulson/tcglog-parser"
+)
+
+type mockService struct {
+	resources map[string]interface{}
+	eventLog  []byte
+	username  string
+	password  string
+}
+
+func (s *mockService) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if s.username != "" {
+		if u, p, ok := r.BasicAuth(); !ok || u != s.username || p != s.password {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+	}
+	if r.URL.Path == "/redfish/v1/Systems/1/LogServices/TPM/Entries/1/attachment" {
+		w.Header().Set("Content-Type", "application/octet-stream")
+		w.Write(s.eventLog)
+		return
+	}
+	res, ok := s.resources[r.URL.Path]
+	if !ok {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(res)
+}
+
+func ref(path string) map[string]string {
+	return map[string]string{"@odata.id": path}
+}
+
+func newMockService(eventLog []byte, pcr0Sha256 []byte) *mockService {
+	return &mockService{
+		eventLog: eventLog,
+		username: "admin",
+		password: "secret",
+		resources: map[string]interface{}{
+			"/redfish/v1/": map[string]interface{}{
+				"Systems":            ref("/redfish/v1/Systems"),
+				"ComponentIntegrity": ref("/redfish/v1/ComponentIntegrity")},
+			"/redfish/v1/Systems": map[string]interface{}{
+				"Members": []interface{}{ref("/redfish/v1/Systems/1")}},
+			"/redfish/v1/Systems/1": map[string]interface{}{
+				"Id":          "1",
+				"LogServices": ref("/redfish/v1/Systems/1/LogServices")},
+			"/redfish/v1/Systems/1/LogServices": map[string]interface{}{
+				"Members": []interface{}{
+					ref("/redfish/v1/Systems/1/LogServices/EventLog"),
+					ref("/redfish/v1/Systems/1/LogServices/TPM")}},
+			"/redfish/v1/Systems/1/LogServices/EventLog": map[string]interface{}{
+				"Entries": ref("/redfish/v1/Systems/1/LogServices/EventLog/Entries")},
+			"/redfish/v1/Systems/1/LogServices/EventLog/Entries": map[string]interface{}{
+				"Members": []interface{}{ref("/redfish/v1/Systems/1/LogServices/EventLog/Entries/1")}},
+			"/redfish/v1/Systems/1/LogServices/EventLog/Entries/1": map[string]interface{}{
+				"Message": "System powered on"},
+			"/redfish/v1/Systems/1/LogServices/TPM": map[string]interface{}{
+				"Entries": ref("/redfish/v1/Systems/1/LogServices/TPM/Entries")},
+			"/redfish/v1/Systems/1/LogServices/TPM/Entries": map[string]interface{}{
+				"Members": []interface{}{ref("/redfish/v1/Systems/1/LogServices/TPM/Entries/1")}},
+			"/redfish/v1/Systems/1/LogServices/TPM/Entries/1": map[string]interface{}{
+				"DiagnosticDataType":    "OEM",
+				"OEMDiagnosticDataType": "TCGEventLog",
+				"AdditionalDataURI":     "/redfish/v1/Systems/1/LogServices/TPM/Entries/1/attachment"},
+			"/redfish/v1/ComponentIntegrity": map[string]interface{}{
+				"Members": []interface{}{
+					ref("/redfish/v1/ComponentIntegrity/SPDM"),
+					ref("/redfish/v1/ComponentIntegrity/TPM")}},
+			"/redfish/v1/ComponentIntegrity/SPDM": map[string]interface{}{
+				"ComponentIntegrityType": "SPDM"},
+			"/redfish/v1/ComponentIntegrity/TPM": map[string]interface{}{
+				"ComponentIntegrityType":    "TPM",
+				"ComponentIntegrityEnabled": true,
+				"TPM": map[string]interface{}{
+					"MeasurementSet": map[string]interface{}{
+						"Measurements": []interface{}{
+							map[string]interface{}{
+								"PCR":                      0,
+								"MeasurementHashAlgorithm": "TPM_ALG_SHA256",
+								"Measurement":              base64.StdEncoding.EncodeToString(pcr0Sha256)},
+							map[string]interface{}{
+								"PCR":                      1,
+								"MeasurementHashAlgorithm": "TPM_ALG_SHA256",
+								"Measurement":              base64.StdEncoding.EncodeToString(make([]byte, 32))}}}}}}}
+}
+
+func TestClientReadEventLog(t *testing.T) {
+	log := []byte{0x01, 0x02, 0x03, 0x04}
+	server := httptest.NewServer(newMockService(log, make([]byte, 32)))
+	defer server.Close()
+
+	client, err := NewClient(server.URL, &ClientOptions{Username: "admin", Password: "secret"})
+	if err != nil {
+		t.Fatalf("NewClient failed: %v", err)
+	}
+	data, err := client.ReadEventLog()
+	if err != nil {
+		t.Fatalf("ReadEventLog failed: %v", err)
+	}
+	if !bytes.Equal(data, log) {
+		t.Errorf("Unexpected log %x", data)
+	}
+
+	client, _ = NewClient(server.URL, &ClientOptions{Username: "admin", Password: "secret",
+		EventLogURI: "/redfish/v1/Systems/1/LogServices/TPM/Entries/1/attachment"})
+	data, err = client.ReadEventLog()
+	if err != nil {
+		t.Fatalf("ReadEventLog failed: %v", err)
+	}
+	if !bytes.Equal(data, log) {
+		t.Errorf("Unexpected log %x", data)
+	}
+
+	client, _ = NewClient(server.URL, &ClientOptions{Username: "admin", Password: "secret", SystemId: "2"})
+	if _, err := client.ReadEventLog(); err == nil || err.Error() != "cannot find system \"2\"" {
+		t.Errorf("Unexpected error: %v", err)
+	}
+
+	client, _ = NewClient(server.URL, nil)
+	if _, err := client.ReadEventLog(); err == nil {
+		t.Errorf("ReadEventLog should fail without credentials")
+	}
+}
+
+func TestClientReadEventLogOtherHost(t *testing.T) {
+	other := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if _, _, ok := r.BasicAuth(); ok {
+			t.Errorf("Credentials were sent to another host")
+		}
+	}))
+	defer other.Close()
+
+	service := newMockService([]byte{0x01}, make([]byte, 32))
+	service.resources["/redfish/v1/Systems/1/LogServices/TPM/Entries/1"].(map[string]interface{})["AdditionalDataURI"] =
+		other.URL + "/attachment"
+	server := httptest.NewServer(service)
+	defer server.Close()
+
+	client, err := NewClient(server.URL, &ClientOptions{Username: "admin", Password: "secret"})
+	if err != nil {
+		t.Fatalf("NewClient failed: %v", err)
+	}
+	if _, err := client.ReadEventLog(); err == nil || !strings.Contains(err.Error(), "refusing to get") {
+		t.Errorf("Unexpected error: %v", err)
+	}
+
+	// A relative URI is resolved against the endpoint.
+	client, _ = NewClient(server.URL+"/redfish/v1/", &ClientOptions{Username: "admin", Password: "secret",
+		EventLogURI: "Systems/1/LogServices/TPM/Entries/1/attachment"})
+	if data, err := client.ReadEventLog(); err != nil || !bytes.Equal(data, []byte{0x01}) {
+		t.Errorf("Unexpected result: %x, %v", data, err)
+	}
+}
+
+func TestClientReadEventLogTooLarge(t *testing.T) {
+	server := httptest.NewServer(newMockService(make([]byte, 1025), make([]byte, 32)))
+	defer server.Close()
+
+	client, err := NewClient(server.URL, &ClientOptions{Username: "admin", Password: "secret",
+		MaxEventLogSize: 1024})
+	if err != nil {
+		t.Fatalf("NewClient failed: %v", err)
+	}
+	if _, err := client.ReadEventLog(); err == nil || !strings.Contains(err.Error(), "exceeds the maximum") {
+		t.Errorf("Unexpected error: %v", err)
+	}
+
+	client, _ = NewClient(server.URL, &ClientOptions{Username: "admin", Password: "secret", MaxEventLogSize: 1025})
+	if data, err := client.ReadEventLog(); err != nil || len(data) != 1025 {
+		t.Errorf("Unexpected result: %d bytes, %v", len(data), err)
+	}
+}
+
+func TestClientReadPCRs(t *testing.T) {
+	pcr0 := bytes.Repeat([]byte{0xa5}, 32)
+	server := httptest.NewServer(newMockService(nil, pcr0))
+	defer server.Close()
+
+	client, err := NewClient(server.URL, &ClientOptions{Username: "admin", Password: "secret"})
+	if err != nil {
+		t.Fatalf("NewClient failed: %v", err)
+	}
+
+	var reader tcglog.PCRReader = client
+	banks, err := reader.ActivePCRBanks()
+	if err != nil {
+		t.Fatalf("ActivePCRBanks failed: %v", err)
+	}
+	if len(banks) != 1 || banks[0] != tcglog.AlgorithmSha256 {
+		t.Errorf("Unexpected banks %v", banks)
+	}
+
+	values, err := reader.ReadPCRs([]tcglog.PCRIndex{0, 1})
+	if err != nil {
+		t.Fatalf("ReadPCRs failed: %v", err)
+	}
+	if !bytes.Equal(values[0][tcglog.AlgorithmSha256], pcr0) {
+		t.Errorf("Unexpected value for PCR 0: %x", values[0][tcglog.AlgorithmSha256])
+	}
+	if !bytes.Equal(values[1][tcglog.AlgorithmSha256], make([]byte, 32)) {
+		t.Errorf("Unexpected value for PCR 1: %x", values[1][tcglog.AlgorithmSha256])
+	}
+
+	if _, err := reader.ReadPCRs([]tcglog.PCRIndex{7}); err == nil {
+		t.Errorf("ReadPCRs should fail for a PCR that the service doesn't report")
+	}
+}
+
+func TestNewClientInvalidEndpoint(t *testing.T) {
+	if _, err := NewClient("ftp://bmc.example.com", nil); err == nil {
+		t.Errorf("NewClient should fail for an unsupported scheme")
+	}
+}
@@ -0,0 +1,54 @@
+package tcglog
+
+import (
+	"errors"
+	"fmt"
+	"hash"
+	"sync"
+)
+
+type registeredAlgorithm struct {
+	name    string
+	size    int
+	newHash func() hash.Hash
+}
+
+var (
+	registeredAlgorithmsMu sync.RWMutex
+	registeredAlgorithms   = make(map[AlgorithmId]*registeredAlgorithm)
+)
+
+func lookupRegisteredAlgorithm(alg AlgorithmId) *registeredAlgorithm {
+	registeredAlgorithmsMu.RLock()
+	defer registeredAlgorithmsMu.RUnlock()
+	return registeredAlgorithms[alg]
+}
+
+// RegisterAlgorithm registers a digest algorithm that isn't supported natively, such as a vendor-specific
+// algorithm, so that its digests are retained when parsing logs and can be validated and replayed. The digests
+// are size bytes long and are computed with hashes returned from newHash. The name is returned from
+// AlgorithmId.String and is accepted by ParseAlgorithm.
+//
+// This should be called before parsing any logs. It returns an error if the algorithm is already supported.
+func RegisterAlgorithm(alg AlgorithmId, name string, size int, newHash func() hash.Hash) error {
+	if name == "" {
+		return errors.New("no algorithm name")
+	}
+	if size <= 0 {
+		return fmt.Errorf("invalid digest size %d", size)
+	}
+	if newHash == nil {
+		return errors.New("no hash constructor")
+	}
+	if alg.supported() {
+		return fmt.Errorf("algorithm %s is already supported", alg)
+	}
+	if _, err := ParseAlgorithm(name); err == nil {
+		return fmt.Errorf("algorithm name \"%s\" is already in use", name)
+	}
+
+	registeredAlgorithmsMu.Lock()
+	defer registeredAlgorithmsMu.Unlock()
+	registeredAlgorithms[alg] = &registeredAlgorithm{name: name, size: size, newHash: newHash}
+	return nil
+}
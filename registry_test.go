@@ -0,0 +1,58 @@
+package tcglog
+
+import (
+	"bytes"
+	"crypto/md5"
+	"testing"
+)
+
+func TestRegisterAlgorithm(t *testing.T) {
+	const algorithmVendor AlgorithmId = 0x8001
+
+	if err := RegisterAlgorithm(AlgorithmSha256, "sha256-copy", 32, nil); err == nil {
+		t.Errorf("RegisterAlgorithm should have failed without a constructor")
+	}
+	if err := RegisterAlgorithm(AlgorithmSm3_256, "sm3-copy", 32, newSM3); err == nil {
+		t.Errorf("RegisterAlgorithm should have failed for a supported algorithm")
+	}
+	if err := RegisterAlgorithm(algorithmVendor, "sha1", md5.Size, md5.New); err == nil {
+		t.Errorf("RegisterAlgorithm should have failed for a name that is in use")
+	}
+	if err := RegisterAlgorithm(algorithmVendor, "vendor", md5.Size, md5.New); err != nil {
+		t.Fatalf("RegisterAlgorithm failed: %v", err)
+	}
+	defer func() {
+		registeredAlgorithmsMu.Lock()
+		defer registeredAlgorithmsMu.Unlock()
+		delete(registeredAlgorithms, algorithmVendor)
+	}()
+
+	if !algorithmVendor.supported() || algorithmVendor.size() != md5.Size || algorithmVendor.String() != "vendor" {
+		t.Errorf("Unexpected properties for registered algorithm")
+	}
+	if alg, err := ParseAlgorithm("vendor"); err != nil || alg != algorithmVendor {
+		t.Errorf("Unexpected algorithm: %v (%v)", alg, err)
+	}
+
+	// Parse a log with a SHA-1 bank and a bank for the registered algorithm.
+	data := makeTestCryptoAgileLogWithDigestSizes(t, []EFISpecIdEventAlgorithmSize{
+		{AlgorithmId: AlgorithmSha1, DigestSize: uint16(AlgorithmSha1.size())},
+		{AlgorithmId: algorithmVendor, DigestSize: md5.Size}}, 1)
+	log, err := NewLog(bytes.NewReader(data), LogOptions{Strict: true})
+	if err != nil {
+		t.Fatalf("NewLog failed: %v", err)
+	}
+	if !log.Algorithms.Contains(algorithmVendor) {
+		t.Errorf("Log should contain the registered algorithm")
+	}
+	values, err := log.ReplayPCRs()
+	if err != nil {
+		t.Fatalf("ReplayPCRs failed: %v", err)
+	}
+	h := md5.New()
+	h.Write(make([]byte, md5.Size))
+	h.Write(algorithmVendor.hash([]byte("Calling EFI Application from Boot Option")))
+	if !bytes.Equal(values[0][algorithmVendor], h.Sum(nil)) {
+		t.Errorf("Unexpected PCR value: %x", values[0][algorithmVendor])
+	}
+}
@@ -0,0 +1,164 @@
+package tcglog
+
+import (
+	"fmt"
+	"io"
+	"sort"
+)
+
+// ReplayPCRs reads the remaining events from the log and computes the expected final value of each PCR for each
+// digest algorithm in the log, without validating the digests of any events or accessing a TPM. PCRs that
+// don't have any events recorded against them are not included in the result. PCR 0 starts with the value
+// implied by the StartupLocality event if the log contains one, and with zero otherwise. PCRs 17-22 are reset to
+// zero by a dynamic launch, which is assumed to happen before the first event that is measured to one of them.
+func (l *Log) ReplayPCRs() (map[PCRIndex]DigestMap, error) {
+	return l.ReplayPCRsWithInitialValues(nil)
+}
+
+// checkInitialPCRValues checks that the supplied initial PCR values are valid for the specified algorithms,
+// and returns a copy of them.
+func checkInitialPCRValues(initial map[PCRIndex]DigestMap, algorithms AlgorithmIdList) (map[PCRIndex]DigestMap, error) {
+	values := make(map[PCRIndex]DigestMap)
+	for pcr, digests := range initial {
+		if !isPCRIndexInRange(pcr) {
+			return nil, fmt.Errorf("invalid initial PCR value: %w", wrapPCRIndexOutOfRangeError(pcr))
+		}
+		values[pcr] = DigestMap{}
+		for _, alg := range algorithms {
+			values[pcr][alg] = make(Digest, alg.size())
+		}
+		for alg, digest := range digests {
+			if !algorithms.Contains(alg) {
+				continue
+			}
+			if len(digest) != alg.size() {
+				return nil, fmt.Errorf("invalid initial value for PCR %d, bank %s", pcr, alg)
+			}
+			copy(values[pcr][alg], digest)
+		}
+	}
+	return values, nil
+}
+
+// startupLocality returns the locality from which the TPM was started if the supplied event is a
+// StartupLocality EV_NO_ACTION event.
+func startupLocality(event *Event) (uint8, bool) {
+	if event.PCRIndex != 0 || event.EventType != EventTypeNoAction {
+		return 0, false
+	}
+	d, ok := event.Data.(*startupLocalityEventData)
+	if !ok {
+		return 0, false
+	}
+	return d.Locality, true
+}
+
+// initialPCR0Values returns the values of PCR 0 in the specified banks after the TPM was started from the
+// specified locality. TPM2_Startup from locality 3 initializes the last byte of PCR 0 to 3, and a H-CRTM
+// sequence, which is recorded as a startup locality of 4, initializes it to 4. PCR 0 is zero otherwise.
+// https://trustedcomputinggroup.org/wp-content/uploads/TCG_PCClientSpecPlat_TPM_2p0_1p04_pub.pdf
+//  (section 9.4.5.3 "Startup Locality Event")
+func initialPCR0Values(algorithms AlgorithmIdList, locality uint8) DigestMap {
+	values := DigestMap{}
+	for _, alg := range algorithms {
+		values[alg] = make(Digest, alg.size())
+		if locality == 3 || locality == 4 {
+			values[alg][alg.size()-1] = locality
+		}
+	}
+	return values
+}
+
+// ReplayPCRsWithInitialValues is like ReplayPCRs, but the PCRs start with the supplied values rather than zero.
+// This supports platforms that initialize some PCRs to non-standard values, and modelling the state of the PCRs
+// after a partial reset. PCRs and banks that aren't in initial start with a value of zero. PCRs that are in
+// initial are included in the result even if they don't have any events recorded against them. Banks in initial
+// that aren't in the log are ignored.
+//
+// If PCR 0 isn't in initial, its initial value is determined from the StartupLocality event if the log
+// contains one, as it is by ReplayPCRs. Initial values for PCRs 17-22 are discarded when the log indicates that
+// a dynamic launch reset these PCRs.
+//
+// An error is returned if an event that extends a PCR doesn't have a digest for one of the log's banks, which
+// the parser tolerates unless LogOptions.Strict is set, because the value of the PCR in that bank can't be
+// computed.
+func (l *Log) ReplayPCRsWithInitialValues(initial map[PCRIndex]DigestMap) (map[PCRIndex]DigestMap, error) {
+	values, err := checkInitialPCRValues(initial, l.Algorithms)
+	if err != nil {
+		return nil, err
+	}
+	var drtm dynamicLaunchTracker
+	for {
+		event, err := l.NextEvent()
+		if err == io.EOF {
+			return values, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		if locality, ok := startupLocality(event); ok {
+			// Ignore the event if PCR 0 has an explicit initial value or has already been extended.
+			if _, exists := values[0]; !exists {
+				values[0] = initialPCR0Values(l.Algorithms, locality)
+			}
+			continue
+		}
+
+		if !doesEventTypeExtendPCR(event.EventType) {
+			continue
+		}
+		if drtm.isDynamicLaunch(event) {
+			resetDRTMPCRs(values)
+		}
+
+		if _, exists := values[event.PCRIndex]; !exists {
+			values[event.PCRIndex] = DigestMap{}
+			for _, alg := range l.Algorithms {
+				values[event.PCRIndex][alg] = make(Digest, alg.size())
+			}
+		}
+		for _, alg := range l.Algorithms {
+			digest, ok := event.Digests[alg]
+			if !ok {
+				return nil, fmt.Errorf("event %d: cannot extend PCR %d: %w", event.Index, event.PCRIndex,
+					&MissingDigestError{Algorithm: alg})
+			}
+			values[event.PCRIndex][alg] = performHashExtendOperation(alg, values[event.PCRIndex][alg], digest)
+		}
+	}
+}
+
+// ComputePCRDigest computes the digest of the values of the specified PCRs in the specified bank, using the
+// specified hash algorithm. This corresponds to the pcrDigest field of a TPMS_QUOTE_INFO structure, so it can be
+// compared against a quote. The PCR values are concatenated in ascending order of PCR index. PCRs that don't
+// have a value in values are assumed to have never been extended and to have a value of zero.
+func ComputePCRDigest(hashAlg AlgorithmId, values map[PCRIndex]DigestMap, bank AlgorithmId,
+	pcrs []PCRIndex) (Digest, error) {
+	if !hashAlg.supported() {
+		return nil, fmt.Errorf("unsupported hash algorithm %s", hashAlg)
+	}
+	if !bank.supported() {
+		return nil, fmt.Errorf("unsupported PCR bank %s", bank)
+	}
+
+	sorted := make([]PCRIndex, len(pcrs))
+	copy(sorted, pcrs)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+
+	h := hashAlg.newHash()
+	for i, pcr := range sorted {
+		if i > 0 && pcr == sorted[i-1] {
+			continue
+		}
+		value, ok := values[pcr][bank]
+		if !ok {
+			value = make(Digest, bank.size())
+		}
+		if len(value) != bank.size() {
+			return nil, fmt.Errorf("invalid value for PCR %d in bank %s", pcr, bank)
+		}
+		h.Write(value)
+	}
+	return h.Sum(nil), nil
+}
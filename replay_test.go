@@ -0,0 +1,345 @@
+package tcglog
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestReplayPCRs(t *testing.T) {
+	dir, err := ioutil.TempDir("", "tcglog-test")
+	if err != nil {
+		t.Fatalf("TempDir failed: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "log")
+	if err := CreateLogFile(path, AlgorithmIdList{AlgorithmSha1, AlgorithmSha256}); err != nil {
+		t.Fatalf("CreateLogFile failed: %v", err)
+	}
+	extender := &mockPCRExtender{}
+	for i, data := range []string{"foo", "bar", "baz"} {
+		if _, err := MeasureAndLog(path, extender, PCRIndex(22+i%2), EventTypeIPL, []byte(data), nil); err != nil {
+			t.Fatalf("MeasureAndLog failed: %v", err)
+		}
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	defer f.Close()
+	log, err := NewLog(f, LogOptions{})
+	if err != nil {
+		t.Fatalf("NewLog failed: %v", err)
+	}
+
+	values, err := log.ReplayPCRs()
+	if err != nil {
+		t.Fatalf("ReplayPCRs failed: %v", err)
+	}
+	if len(values) != 2 {
+		t.Errorf("Unexpected number of PCRs (%d)", len(values))
+	}
+	for _, pcr := range []PCRIndex{22, 23} {
+		for _, alg := range log.Algorithms {
+			if !bytes.Equal(values[pcr][alg], extender.values[pcr][alg]) {
+				t.Errorf("Unexpected value for PCR %d, bank %s", pcr, alg)
+			}
+		}
+	}
+
+	digest, err := ComputePCRDigest(AlgorithmSha256, values, AlgorithmSha256, []PCRIndex{23, 22})
+	if err != nil {
+		t.Fatalf("ComputePCRDigest failed: %v", err)
+	}
+	h := sha256.New()
+	h.Write(values[22][AlgorithmSha256])
+	h.Write(values[23][AlgorithmSha256])
+	if !bytes.Equal(digest, h.Sum(nil)) {
+		t.Errorf("Unexpected PCR digest")
+	}
+}
+
+func TestReplayPCRsWithInitialValues(t *testing.T) {
+	data := makeTestCryptoAgileLog(t, 1)
+
+	locality := make(Digest, AlgorithmSha256.size())
+	locality[len(locality)-1] = 3
+	initial := map[PCRIndex]DigestMap{
+		0:  {AlgorithmSha256: locality, AlgorithmSha384: make(Digest, AlgorithmSha384.size())},
+		17: {AlgorithmSha1: bytes.Repeat([]byte{0xff}, AlgorithmSha1.size())}}
+
+	log, err := NewLog(bytes.NewReader(data), LogOptions{})
+	if err != nil {
+		t.Fatalf("NewLog failed: %v", err)
+	}
+	values, err := log.ReplayPCRsWithInitialValues(initial)
+	if err != nil {
+		t.Fatalf("ReplayPCRsWithInitialValues failed: %v", err)
+	}
+
+	event := AlgorithmSha256.hash([]byte("Calling EFI Application from Boot Option"))
+	if !bytes.Equal(values[0][AlgorithmSha256], performHashExtendOperation(AlgorithmSha256, locality, event)) {
+		t.Errorf("Unexpected value for PCR 0: %x", values[0][AlgorithmSha256])
+	}
+	if _, ok := values[0][AlgorithmSha384]; ok {
+		t.Errorf("Unexpected value for a bank that isn't in the log")
+	}
+	if !bytes.Equal(values[17][AlgorithmSha1], initial[17][AlgorithmSha1]) ||
+		!bytes.Equal(values[17][AlgorithmSha256], make(Digest, AlgorithmSha256.size())) {
+		t.Errorf("Unexpected value for PCR 17: %v", values[17])
+	}
+
+	log, err = NewLog(bytes.NewReader(data), LogOptions{})
+	if err != nil {
+		t.Fatalf("NewLog failed: %v", err)
+	}
+	result, err := replayAndValidateLog(context.Background(), log, int64(len(data)),
+		LogValidateOptions{InitialPCRValues: initial})
+	if err != nil {
+		t.Fatalf("replayAndValidateLog failed: %v", err)
+	}
+	if !bytes.Equal(result.ExpectedPCRValues[0][AlgorithmSha256], values[0][AlgorithmSha256]) {
+		t.Errorf("Unexpected expected value for PCR 0: %x", result.ExpectedPCRValues[0][AlgorithmSha256])
+	}
+
+	log, err = NewLog(bytes.NewReader(data), LogOptions{})
+	if err != nil {
+		t.Fatalf("NewLog failed: %v", err)
+	}
+	if _, err := log.ReplayPCRsWithInitialValues(map[PCRIndex]DigestMap{0: {AlgorithmSha256: locality[1:]}}); err == nil {
+		t.Errorf("ReplayPCRsWithInitialValues should have failed for an invalid initial value")
+	}
+}
+
+func TestReplayPCRsMissingDigest(t *testing.T) {
+	var buf bytes.Buffer
+	buf.Write(makeTestCryptoAgileLog(t, 0))
+
+	// An event that is missing a SHA-256 digest, which is tolerated by the parser.
+	data := []byte("Calling EFI Application from Boot Option")
+	binary.Write(&buf, binary.LittleEndian, eventHeader_2{PCRIndex: 4, EventType: EventTypeEFIAction, Count: 1})
+	binary.Write(&buf, binary.LittleEndian, AlgorithmSha1)
+	buf.Write(AlgorithmSha1.hash(data))
+	binary.Write(&buf, binary.LittleEndian, uint32(len(data)))
+	buf.Write(data)
+
+	log, err := NewLog(bytes.NewReader(buf.Bytes()), LogOptions{})
+	if err != nil {
+		t.Fatalf("NewLog failed: %v", err)
+	}
+	var missingErr *MissingDigestError
+	if _, err := log.ReplayPCRs(); !errors.As(err, &missingErr) || missingErr.Algorithm != AlgorithmSha256 {
+		t.Errorf("Unexpected error: %v", err)
+	}
+}
+
+func TestReplayPCRsStartupLocality(t *testing.T) {
+	for _, data := range []struct {
+		desc     string
+		locality uint8
+		initial  map[PCRIndex]DigestMap
+		expected byte
+	}{
+		{desc: "locality 0", locality: 0, expected: 0},
+		{desc: "locality 3", locality: 3, expected: 3},
+		{desc: "H-CRTM", locality: 4, expected: 4},
+		{
+			desc:     "explicit initial value",
+			locality: 3,
+			initial:  map[PCRIndex]DigestMap{0: {AlgorithmSha256: make(Digest, AlgorithmSha256.size())}},
+			expected: 0,
+		},
+	} {
+		t.Run(data.desc, func(t *testing.T) {
+			var buf bytes.Buffer
+			w, err := NewLogWriter(&buf, SpecEFI_2, AlgorithmIdList{AlgorithmSha256})
+			if err != nil {
+				t.Fatalf("NewLogWriter failed: %v", err)
+			}
+			if err := w.WriteSpecIdEvent(0, nil); err != nil {
+				t.Fatalf("WriteSpecIdEvent failed: %v", err)
+			}
+			zero := DigestMap{AlgorithmSha256: make(Digest, AlgorithmSha256.size())}
+			if err := w.WriteEvent(&Event{PCRIndex: 0, EventType: EventTypeNoAction, Digests: zero,
+				Data: &opaqueEventData{data: []byte("StartupLocality\x00" + string([]byte{data.locality}))}}); err != nil {
+				t.Fatalf("WriteEvent failed: %v", err)
+			}
+			action := []byte("Calling EFI Application from Boot Option")
+			digest := AlgorithmSha256.hash(action)
+			if err := w.WriteEvent(&Event{PCRIndex: 0, EventType: EventTypeEFIAction,
+				Digests: DigestMap{AlgorithmSha256: digest}, Data: &opaqueEventData{data: action}}); err != nil {
+				t.Fatalf("WriteEvent failed: %v", err)
+			}
+
+			initial := make(Digest, AlgorithmSha256.size())
+			initial[len(initial)-1] = data.expected
+			expected := performHashExtendOperation(AlgorithmSha256, initial, digest)
+
+			log, err := NewLog(bytes.NewReader(buf.Bytes()), LogOptions{})
+			if err != nil {
+				t.Fatalf("NewLog failed: %v", err)
+			}
+			values, err := log.ReplayPCRsWithInitialValues(data.initial)
+			if err != nil {
+				t.Fatalf("ReplayPCRsWithInitialValues failed: %v", err)
+			}
+			if !bytes.Equal(values[0][AlgorithmSha256], expected) {
+				t.Errorf("Unexpected value for PCR 0: %x", values[0][AlgorithmSha256])
+			}
+
+			log, err = NewLog(bytes.NewReader(buf.Bytes()), LogOptions{})
+			if err != nil {
+				t.Fatalf("NewLog failed: %v", err)
+			}
+			result, err := replayAndValidateLog(context.Background(), log, int64(buf.Len()),
+				LogValidateOptions{InitialPCRValues: data.initial})
+			if err != nil {
+				t.Fatalf("replayAndValidateLog failed: %v", err)
+			}
+			if !bytes.Equal(result.ExpectedPCRValues[0][AlgorithmSha256], expected) {
+				t.Errorf("Unexpected expected value for PCR 0: %x", result.ExpectedPCRValues[0][AlgorithmSha256])
+			}
+
+			if data.initial != nil {
+				return
+			}
+			s := result.ReplaySnapshot()
+			if err := s.Verify(); err != nil {
+				t.Errorf("Verify failed: %v", err)
+			}
+			if !bytes.Equal(s.FinalValues[0][AlgorithmSha256], expected) {
+				t.Errorf("Unexpected final value for PCR 0 in snapshot: %x", s.FinalValues[0][AlgorithmSha256])
+			}
+		})
+	}
+}
+
+func TestReplayPCRsDynamicLaunch(t *testing.T) {
+	var buf bytes.Buffer
+	w, err := NewLogWriter(&buf, SpecEFI_2, AlgorithmIdList{AlgorithmSha256})
+	if err != nil {
+		t.Fatalf("NewLogWriter failed: %v", err)
+	}
+	if err := w.WriteSpecIdEvent(0, nil); err != nil {
+		t.Fatalf("WriteSpecIdEvent failed: %v", err)
+	}
+
+	// Two dynamic launches, the second of which is indicated by a TXT EVTYPE_HASH_START event.
+	var digests []Digest
+	for i, e := range []struct {
+		pcr       PCRIndex
+		eventType EventType
+	}{
+		{17, EventTypeTXTHashStart},
+		{18, EventTypeIPL},
+		{17, EventTypeTXTHashStart},
+		{18, EventTypeIPL},
+	} {
+		data := []byte{byte(i)}
+		digest := AlgorithmSha256.hash(data)
+		digests = append(digests, digest)
+		if err := w.WriteEvent(&Event{PCRIndex: e.pcr, EventType: e.eventType,
+			Digests: DigestMap{AlgorithmSha256: digest}, Data: &opaqueEventData{data: data}}); err != nil {
+			t.Fatalf("WriteEvent failed: %v", err)
+		}
+	}
+
+	zero := make(Digest, AlgorithmSha256.size())
+	expected := map[PCRIndex]Digest{
+		17: performHashExtendOperation(AlgorithmSha256, zero, digests[2]),
+		18: performHashExtendOperation(AlgorithmSha256, zero, digests[3]),
+		19: zero}
+
+	log, err := NewLog(bytes.NewReader(buf.Bytes()), LogOptions{})
+	if err != nil {
+		t.Fatalf("NewLog failed: %v", err)
+	}
+	initial := map[PCRIndex]DigestMap{19: {AlgorithmSha256: preLaunchDRTMPCRValue(AlgorithmSha256)}}
+	values, err := log.ReplayPCRsWithInitialValues(initial)
+	if err != nil {
+		t.Fatalf("ReplayPCRsWithInitialValues failed: %v", err)
+	}
+	for pcr, digest := range expected {
+		if !bytes.Equal(values[pcr][AlgorithmSha256], digest) {
+			t.Errorf("Unexpected value for PCR %d: %x", pcr, values[pcr][AlgorithmSha256])
+		}
+	}
+
+	log, err = NewLog(bytes.NewReader(buf.Bytes()), LogOptions{})
+	if err != nil {
+		t.Fatalf("NewLog failed: %v", err)
+	}
+	result, err := replayAndValidateLog(context.Background(), log, int64(buf.Len()), LogValidateOptions{})
+	if err != nil {
+		t.Fatalf("replayAndValidateLog failed: %v", err)
+	}
+	if !result.DynamicLaunch {
+		t.Errorf("Dynamic launch wasn't detected")
+	}
+	for _, pcr := range []PCRIndex{17, 18} {
+		if !bytes.Equal(result.ExpectedPCRValues[pcr][AlgorithmSha256], expected[pcr]) {
+			t.Errorf("Unexpected expected value for PCR %d: %x", pcr,
+				result.ExpectedPCRValues[pcr][AlgorithmSha256])
+		}
+	}
+
+	// PCRs 17-22 without events are expected to be zero after a dynamic launch.
+	pcrValues := map[PCRIndex]DigestMap{
+		17: {AlgorithmSha256: expected[17]},
+		18: {AlgorithmSha256: expected[18]},
+		19: {AlgorithmSha256: zero}}
+	if findings := result.CheckPCRValues(pcrValues, nil); len(findings) != 0 {
+		t.Errorf("Unexpected findings: %v", findings)
+	}
+
+	s := result.ReplaySnapshot()
+	if err := s.Verify(); err != nil {
+		t.Errorf("Verify failed: %v", err)
+	}
+	if !bytes.Equal(s.FinalValues[17][AlgorithmSha256], expected[17]) {
+		t.Errorf("Unexpected final value for PCR 17 in snapshot: %x", s.FinalValues[17][AlgorithmSha256])
+	}
+
+	var events []*Event
+	for _, e := range result.ValidatedEvents {
+		events = append(events, e.Event)
+	}
+	anchor, err := NewPCRAnchor(events)
+	if err != nil {
+		t.Fatalf("NewPCRAnchor failed: %v", err)
+	}
+	if !bytes.Equal(anchor.Values[17][AlgorithmSha256], expected[17]) {
+		t.Errorf("Unexpected value for PCR 17 in anchor: %x", anchor.Values[17][AlgorithmSha256])
+	}
+}
+
+func TestCheckPCRValuesWithoutDynamicLaunch(t *testing.T) {
+	log, err := NewLog(bytes.NewReader(makeTestCryptoAgileLog(t, 1)), LogOptions{})
+	if err != nil {
+		t.Fatalf("NewLog failed: %v", err)
+	}
+	result, err := replayAndValidateLog(context.Background(), log, 0, LogValidateOptions{})
+	if err != nil {
+		t.Fatalf("replayAndValidateLog failed: %v", err)
+	}
+	if result.DynamicLaunch {
+		t.Errorf("Unexpected dynamic launch")
+	}
+
+	values := map[PCRIndex]DigestMap{17: {AlgorithmSha256: preLaunchDRTMPCRValue(AlgorithmSha256)}}
+	if findings := result.CheckPCRValues(values, []PCRIndex{}); len(findings) != 0 {
+		t.Errorf("Unexpected findings: %v", findings)
+	}
+	values[17][AlgorithmSha256] = make(Digest, AlgorithmSha256.size())
+	if findings := result.CheckPCRValues(values, []PCRIndex{}); len(findings) != 1 {
+		t.Errorf("Unexpected findings: %v", findings)
+	}
+}
@@ -0,0 +1,144 @@
+package tcglog
+
+import (
+	"bytes"
+	"fmt"
+)
+
+// DefaultResettablePCRs contains the PCRs that can be reset after TPM initialization on a PC Client platform.
+// PCRs 16 and 23 are resettable from locality 0, and PCRs 17-22 are reset during a dynamic launch.
+// https://trustedcomputinggroup.org/wp-content/uploads/PC-ClientSpecific_Platform_Profile_for_TPM_2p0_Systems_v51.pdf
+//  (section 4.6 "PCR Attributes")
+var DefaultResettablePCRs = []PCRIndex{16, 17, 18, 19, 20, 21, 22, 23}
+
+const (
+	// FindingPCRValueMismatch indicates that the actual value of a PCR is not consistent with the log.
+	FindingPCRValueMismatch FindingCode = "pcr-value-mismatch"
+
+	// FindingPCRReset indicates that the value of a resettable PCR is not consistent with the log, but is
+	// consistent with the PCR having been reset part way through the log.
+	FindingPCRReset FindingCode = "pcr-reset"
+)
+
+type pcrBank struct {
+	pcr PCRIndex
+	alg AlgorithmId
+}
+
+// pcrResetDetector detects resets of PCRs part way through a log. The events are indexed by PCR once, and the
+// values that each PCR bank could have after a reset are computed the first time that the bank is queried and
+// indexed by value, so that checking several PCR values only requires a single pass over the log.
+type pcrResetDetector struct {
+	events map[PCRIndex][]*Event
+	resets map[pcrBank]map[string]int // Maps the value of a bank after a reset to the position of the reset
+}
+
+func newPCRResetDetector(events []*ValidatedEvent) *pcrResetDetector {
+	d := &pcrResetDetector{
+		events: make(map[PCRIndex][]*Event),
+		resets: make(map[pcrBank]map[string]int)}
+	for _, e := range events {
+		if !doesEventTypeExtendPCR(e.Event.EventType) {
+			continue
+		}
+		d.events[e.Event.PCRIndex] = append(d.events[e.Event.PCRIndex], e.Event)
+	}
+	return d
+}
+
+func (d *pcrResetDetector) resetValues(pcr PCRIndex, alg AlgorithmId) map[string]int {
+	bank := pcrBank{pcr: pcr, alg: alg}
+	if values, ok := d.resets[bank]; ok {
+		return values
+	}
+
+	events := d.events[pcr]
+	values := make(map[string]int)
+	// Work backwards so that the earliest reset is recorded for values that are reachable in more than one way.
+	for i := len(events); i >= 1; i-- {
+		value := make(Digest, alg.size())
+		for _, e := range events[i:] {
+			value = performHashExtendOperation(alg, value, e.Digests[alg])
+		}
+		values[string(value)] = i
+	}
+	d.resets[bank] = values
+	return values
+}
+
+func (d *pcrResetDetector) detect(pcr PCRIndex, alg AlgorithmId, actual Digest) *Finding {
+	i, ok := d.resetValues(pcr, alg)[string(actual)]
+	if !ok {
+		return nil
+	}
+
+	events := d.events[pcr]
+	f := &Finding{
+		Code:     FindingPCRReset,
+		Severity: FindingSeverityInfo}
+	if i < len(events) {
+		f.Event = events[i]
+		f.Message = fmt.Sprintf("PCR %d was reset before this event was measured", pcr)
+	} else {
+		f.Message = fmt.Sprintf("PCR %d was reset after all of the events in the log were measured", pcr)
+	}
+	return f
+}
+
+// DetectPCRReset determines whether the actual value of the specified PCR is consistent with the PCR having been
+// reset part way through the log, which is possible for resettable PCRs. If it is, a finding is returned that
+// references the first event measured after the reset. If the actual value is consistent with the log without
+// a reset, or isn't consistent with any reset, nil is returned.
+func (r *LogValidateResult) DetectPCRReset(pcr PCRIndex, alg AlgorithmId, actual Digest) *Finding {
+	if bytes.Equal(r.ExpectedPCRValues[pcr][alg], actual) {
+		return nil
+	}
+	return newPCRResetDetector(r.ValidatedEvents).detect(pcr, alg, actual)
+}
+
+// CheckPCRValues checks that the supplied PCR values, which may have been read from the local TPM or obtained
+// from a quote, are consistent with the values computed from the log. Only PCR banks that correspond to
+// digest algorithms in the log are checked. PCRs that don't have any events in the log are expected to be
+// zero, except for PCRs 17-22, which are expected to be all ones if the log doesn't indicate that a dynamic
+// launch happened. For each PCR in resettable that is inconsistent with the log, DetectPCRReset is used to determine
+// whether the PCR was reset. A finding is returned for each inconsistent PCR value.
+func (r *LogValidateResult) CheckPCRValues(values map[PCRIndex]DigestMap, resettable []PCRIndex) (out []Finding) {
+	var resets *pcrResetDetector
+	for _, pcr := range sortedPCRs(values) {
+		for _, alg := range sortedDigestAlgorithms(values[pcr]) {
+			if !r.Algorithms.Contains(alg) {
+				continue
+			}
+
+			actual := values[pcr][alg]
+			expected, ok := r.ExpectedPCRValues[pcr][alg]
+			switch {
+			case ok:
+			case isDRTMPCR(pcr) && !r.DynamicLaunch:
+				expected = preLaunchDRTMPCRValue(alg)
+			default:
+				expected = make(Digest, alg.size())
+			}
+			if bytes.Equal(expected, actual) {
+				continue
+			}
+
+			if isPCRInList(pcr, resettable) {
+				if resets == nil {
+					resets = newPCRResetDetector(r.ValidatedEvents)
+				}
+				if f := resets.detect(pcr, alg, actual); f != nil {
+					out = append(out, *f)
+					continue
+				}
+			}
+
+			out = append(out, Finding{
+				Code:     FindingPCRValueMismatch,
+				Severity: FindingSeverityError,
+				Message: fmt.Sprintf("PCR %d, bank %s - actual PCR value: %x, expected PCR value from log: %x",
+					pcr, alg, actual, expected)})
+		}
+	}
+	return
+}
@@ -0,0 +1,131 @@
+package tcglog
+
+import (
+	"testing"
+)
+
+func makeTestResetResult(events ...*Event) *LogValidateResult {
+	result := &LogValidateResult{
+		Algorithms:        AlgorithmIdList{AlgorithmSha256},
+		ExpectedPCRValues: make(map[PCRIndex]DigestMap)}
+	for i, e := range events {
+		e.Index = uint(i)
+		result.ValidatedEvents = append(result.ValidatedEvents, &ValidatedEvent{Event: e})
+		if !doesEventTypeExtendPCR(e.EventType) {
+			continue
+		}
+		if _, ok := result.ExpectedPCRValues[e.PCRIndex]; !ok {
+			result.ExpectedPCRValues[e.PCRIndex] = DigestMap{AlgorithmSha256: make(Digest, AlgorithmSha256.size())}
+		}
+		result.ExpectedPCRValues[e.PCRIndex][AlgorithmSha256] = performHashExtendOperation(AlgorithmSha256,
+			result.ExpectedPCRValues[e.PCRIndex][AlgorithmSha256], e.Digests[AlgorithmSha256])
+	}
+	return result
+}
+
+func makeTestResetEvent(pcr PCRIndex, eventType EventType, measured string) *Event {
+	return &Event{
+		PCRIndex:  pcr,
+		EventType: eventType,
+		Digests:   DigestMap{AlgorithmSha256: AlgorithmSha256.hash([]byte(measured))}}
+}
+
+func makeTestPCRValue(measurements ...string) Digest {
+	value := make(Digest, AlgorithmSha256.size())
+	for _, m := range measurements {
+		value = performHashExtendOperation(AlgorithmSha256, value, AlgorithmSha256.hash([]byte(m)))
+	}
+	return value
+}
+
+func TestCheckPCRValues(t *testing.T) {
+	result := makeTestResetResult(
+		makeTestResetEvent(23, EventTypeEventTag, "a"),
+		makeTestResetEvent(4, EventTypeEFIAction, "foo"),
+		makeTestResetEvent(23, EventTypeNoAction, "ignored"),
+		makeTestResetEvent(23, EventTypeEventTag, "b"),
+		makeTestResetEvent(23, EventTypeEventTag, "c"),
+		makeTestResetEvent(16, EventTypeEventTag, "d"))
+
+	for _, data := range []struct {
+		desc       string
+		pcr        PCRIndex
+		value      Digest
+		resettable []PCRIndex
+		code       FindingCode // Empty if no finding is expected
+		event      int         // The index of the event that the finding refers to, or -1 for none
+	}{
+		{desc: "Consistent", pcr: 23, value: makeTestPCRValue("a", "b", "c"), resettable: DefaultResettablePCRs},
+		{desc: "ConsistentNoEvents", pcr: 12, value: makeTestPCRValue(), resettable: DefaultResettablePCRs},
+		{desc: "DRTMBeforeLaunch", pcr: 17, value: preLaunchDRTMPCRValue(AlgorithmSha256),
+			resettable: DefaultResettablePCRs},
+		{desc: "Reset", pcr: 23, value: makeTestPCRValue("b", "c"), resettable: DefaultResettablePCRs,
+			code: FindingPCRReset, event: 3},
+		{desc: "ResetLast", pcr: 23, value: makeTestPCRValue("c"), resettable: DefaultResettablePCRs,
+			code: FindingPCRReset, event: 4},
+		{desc: "ResetAfterAll", pcr: 16, value: makeTestPCRValue(), resettable: DefaultResettablePCRs,
+			code: FindingPCRReset, event: -1},
+		{desc: "NotResettable", pcr: 23, value: makeTestPCRValue("b", "c"), resettable: []PCRIndex{16},
+			code: FindingPCRValueMismatch, event: -1},
+		{desc: "Mismatch", pcr: 23, value: makeTestPCRValue("x"), resettable: DefaultResettablePCRs,
+			code: FindingPCRValueMismatch, event: -1},
+		{desc: "MismatchNotResettable", pcr: 4, value: makeTestPCRValue(), resettable: DefaultResettablePCRs,
+			code: FindingPCRValueMismatch, event: -1},
+	} {
+		t.Run(data.desc, func(t *testing.T) {
+			findings := result.CheckPCRValues(map[PCRIndex]DigestMap{data.pcr: {AlgorithmSha256: data.value}},
+				data.resettable)
+			if data.code == "" {
+				if len(findings) > 0 {
+					t.Errorf("Unexpected findings: %v", findings)
+				}
+				return
+			}
+			if len(findings) != 1 {
+				t.Fatalf("Unexpected number of findings (%d)", len(findings))
+			}
+			f := findings[0]
+			if f.Code != data.code {
+				t.Errorf("Unexpected finding code %s", f.Code)
+			}
+			switch {
+			case data.event < 0 && f.Event != nil:
+				t.Errorf("Unexpected event %d", f.Event.Index)
+			case data.event >= 0 && f.Event != result.ValidatedEvents[data.event].Event:
+				t.Errorf("Unexpected event")
+			}
+		})
+	}
+}
+
+func TestCheckPCRValuesIgnoresOtherBanks(t *testing.T) {
+	result := makeTestResetResult(makeTestResetEvent(23, EventTypeEventTag, "a"))
+	findings := result.CheckPCRValues(map[PCRIndex]DigestMap{
+		23: {AlgorithmSha1: make(Digest, AlgorithmSha1.size())}}, DefaultResettablePCRs)
+	if len(findings) > 0 {
+		t.Errorf("Unexpected findings: %v", findings)
+	}
+}
+
+func TestDetectPCRReset(t *testing.T) {
+	result := makeTestResetResult(
+		makeTestResetEvent(23, EventTypeEventTag, "a"),
+		makeTestResetEvent(23, EventTypeEventTag, "b"),
+		makeTestResetEvent(23, EventTypeEventTag, "b"))
+
+	if f := result.DetectPCRReset(23, AlgorithmSha256, result.ExpectedPCRValues[23][AlgorithmSha256]); f != nil {
+		t.Errorf("Unexpected finding for a consistent value")
+	}
+
+	f := result.DetectPCRReset(23, AlgorithmSha256, makeTestPCRValue("b", "b"))
+	if f == nil {
+		t.Fatalf("Expected a finding")
+	}
+	if f.Code != FindingPCRReset || f.Event != result.ValidatedEvents[1].Event {
+		t.Errorf("Unexpected finding: %s", f)
+	}
+
+	if f := result.DetectPCRReset(23, AlgorithmSha256, makeTestPCRValue("a")); f != nil {
+		t.Errorf("Unexpected finding: %s", f)
+	}
+}
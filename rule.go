@@ -0,0 +1,202 @@
+package tcglog
+
+import (
+	"context"
+	"fmt"
+)
+
+// ReplayState describes the state of a log that is being validated by ReplayAndValidateLog, for use by rules.
+// It must not be modified by rules, other than by a replacement for RuleEventDigests (see
+// LogValidateOptions.ReplacementRules).
+type ReplayState struct {
+	Spec       Spec            // The specification to which the log conforms
+	Algorithms AlgorithmIdList // The digest algorithms that appear in the log
+	CCType     CCType          // The type of confidential computing environment that produced the log, if any
+	Profile    PlatformProfile // The class of platform that produced the log
+	LogSize    int64           // The size of the log in bytes
+
+	// PFPRevision is the revision of the PC Client Platform Firmware Profile that the log conforms to.
+	PFPRevision PFPRevision
+
+	// ValidatedEvents contains the events that have been processed so far, including the current one.
+	ValidatedEvents []*ValidatedEvent
+
+	// PCRValues contains the expected PCR values after the current event has been measured.
+	PCRValues map[PCRIndex]DigestMap
+
+	// TrailingBytes is the number of bytes at the end of the current event's data that weren't consumed
+	// when it was decoded.
+	TrailingBytes int
+
+	// EFIBootVariableBehaviour is the way that the firmware measures EV_EFI_VARIABLE_BOOT events. It is
+	// determined by RuleEventDigests from the first of these events.
+	EFIBootVariableBehaviour EFIBootVariableBehaviour
+
+	events []*Event
+}
+
+// Events returns the events that have been processed so far, including the current one. The returned slice is
+// shared with the ReplayState and must not be modified.
+func (s *ReplayState) Events() []*Event {
+	return s.events
+}
+
+// current returns the event that is currently being processed.
+func (s *ReplayState) current() *ValidatedEvent {
+	return s.ValidatedEvents[len(s.ValidatedEvents)-1]
+}
+
+// Rule is a check that is run during validation by ReplayAndValidateLog. This allows consumers to add their own
+// checks, such as checks for policies that are specific to an organization.
+//
+// Check is called for each event after it has been replayed and its digests have been validated. Once every event
+// has been processed, Check is called once more with a nil event so that rules can report findings that depend
+// on the whole log.
+type Rule interface {
+	Check(ctx context.Context, event *Event, state *ReplayState) []Finding
+}
+
+// RuleFunc is an adapter that allows an ordinary function to be used as a Rule.
+type RuleFunc func(ctx context.Context, event *Event, state *ReplayState) []Finding
+
+// Check implements Rule.Check.
+func (f RuleFunc) Check(ctx context.Context, event *Event, state *ReplayState) []Finding {
+	return f(ctx, event, state)
+}
+
+// The names of the built-in rules, which can be used to disable or replace them with
+// LogValidateOptions.DisabledRules and LogValidateOptions.ReplacementRules.
+const (
+	// RuleEventDigests checks the digests of each event against the data recorded with it where possible,
+	// populating the MeasuredBytes, MeasuredTrailingBytesCount and IncorrectDigestValues fields of each
+	// ValidatedEvent and ReplayState.EFIBootVariableBehaviour. A replacement for this rule is responsible for
+	// populating these.
+	RuleEventDigests = "event-digests"
+
+	// RuleEFIBootVariables reports firmware that measures EV_EFI_VARIABLE_BOOT events in a way that isn't
+	// permitted by the revision of the PC Client Platform Firmware Profile that the log conforms to.
+	RuleEFIBootVariables = "efi-boot-variables"
+
+	// RuleAnomalies runs DetectAnomalies, CheckImageLoadAddresses and CheckPlatformConfigEvents on the whole
+	// log.
+	RuleAnomalies = "anomalies"
+
+	// RuleSeparators reports logs that don't contain an EV_SEPARATOR event for each of PCRs 0-7.
+	RuleSeparators = "separators"
+
+	// RulePFPRevision reports events with types that aren't defined by the revision of the PC Client Platform
+	// Firmware Profile that the log conforms to.
+	RulePFPRevision = "pfp-revision"
+
+	// RuleCCEvidence runs CompareCCEvidence on the final PCR values if LogValidateOptions.CCEvidence is set.
+	RuleCCEvidence = "cc-evidence"
+)
+
+// anomalyRule runs DetectAnomalies, CheckImageLoadAddresses and CheckPlatformConfigEvents on the whole log.
+// Missing separators are reported by separatorRule instead.
+type anomalyRule struct {
+	thresholds AnomalyThresholds
+}
+
+func (r anomalyRule) Check(ctx context.Context, event *Event, state *ReplayState) (out []Finding) {
+	if event != nil {
+		return nil
+	}
+	events := state.Events()
+	for _, f := range DetectAnomalies(events, state.LogSize, r.thresholds) {
+		if f.Code == FindingLogMissingSeparators {
+			continue
+		}
+		out = append(out, f)
+	}
+	out = append(out, CheckImageLoadAddresses(events, r.thresholds.MaxPhysicalAddress)...)
+	return append(out, CheckPlatformConfigEvents(events)...)
+}
+
+// separatorRule reports logs that don't contain an EV_SEPARATOR event for each of PCRs 0-7.
+type separatorRule struct {
+	seen map[PCRIndex]bool
+}
+
+func (r *separatorRule) Check(ctx context.Context, event *Event, state *ReplayState) []Finding {
+	if event != nil {
+		if event.EventType == EventTypeSeparator {
+			r.seen[event.PCRIndex] = true
+		}
+		return nil
+	}
+	if state.CCType != CCTypeNone {
+		// Confidential computing logs record separators against measurement registers rather than PCRs.
+		return nil
+	}
+	if f := missingSeparatorsFinding(r.seen); f != nil {
+		return []Finding{*f}
+	}
+	return nil
+}
+
+// ccEvidenceRule runs CompareCCEvidence on the final PCR values.
+type ccEvidenceRule struct {
+	evidence *CCEvidence
+}
+
+func (r ccEvidenceRule) Check(ctx context.Context, event *Event, state *ReplayState) []Finding {
+	if event != nil {
+		return nil
+	}
+	return CompareCCEvidence(state.PCRValues, r.evidence)
+}
+
+// builtinRules returns the rules that are run by ReplayAndValidateLog for the specified options, before
+// LogValidateOptions.Rules. Rules that are disabled are omitted and rules that are replaced are substituted in
+// place. The returned rules keep state, so they must only be used for a single log.
+func builtinRules(options *LogValidateOptions) ([]Rule, error) {
+	thresholds := options.AnomalyThresholds
+	if thresholds == nil {
+		thresholds = &DefaultAnomalyThresholds
+	}
+
+	builtin := []struct {
+		name string
+		rule Rule
+	}{
+		{RuleEventDigests, eventDigestsRule{}},
+		{RuleEFIBootVariables, new(efiBootVariableRule)},
+		{RuleAnomalies, anomalyRule{thresholds: *thresholds}},
+		{RuleSeparators, &separatorRule{seen: make(map[PCRIndex]bool)}},
+		{RulePFPRevision, pfpRevisionRule{}},
+		{RuleCCEvidence, ccEvidenceRule{evidence: options.CCEvidence}},
+	}
+	known := make(map[string]bool)
+	for _, b := range builtin {
+		known[b.name] = true
+	}
+
+	disabled := make(map[string]bool)
+	for _, name := range options.DisabledRules {
+		if !known[name] {
+			return nil, fmt.Errorf("cannot disable unknown rule %q", name)
+		}
+		disabled[name] = true
+	}
+	for name := range options.ReplacementRules {
+		if !known[name] {
+			return nil, fmt.Errorf("cannot replace unknown rule %q", name)
+		}
+	}
+
+	var rules []Rule
+	for _, b := range builtin {
+		switch {
+		case disabled[b.name]:
+			continue
+		case options.ReplacementRules[b.name] != nil:
+			rules = append(rules, options.ReplacementRules[b.name])
+		case b.name == RuleCCEvidence && options.CCEvidence == nil:
+			continue
+		default:
+			rules = append(rules, b.rule)
+		}
+	}
+	return rules, nil
+}
@@ -0,0 +1,182 @@
+package tcglog
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestReplayAndValidateLogRules(t *testing.T) {
+	dir, err := ioutil.TempDir("", "tcglog-test")
+	if err != nil {
+		t.Fatalf("TempDir failed: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "log")
+	if err := ioutil.WriteFile(path, makeTestCryptoAgileLog(t, 5), 0644); err != nil {
+		t.Fatalf("WriteFile failed: %v", err)
+	}
+
+	const findingTest FindingCode = "test"
+
+	n := 0
+	final := false
+	rule := RuleFunc(func(ctx context.Context, event *Event, state *ReplayState) []Finding {
+		if event == nil {
+			final = true
+			return []Finding{{Code: findingTest, Severity: FindingSeverityError,
+				Message: "log has too many events"}}
+		}
+		n++
+		if len(state.ValidatedEvents) != n || state.ValidatedEvents[n-1].Event != event {
+			t.Errorf("Unexpected state for event %d", n)
+		}
+		if doesEventTypeExtendPCR(event.EventType) && state.PCRValues[event.PCRIndex] == nil {
+			t.Errorf("Missing PCR values for event %d", n)
+		}
+		return nil
+	})
+
+	result, err := ReplayAndValidateLogWithOptions(path, LogOptions{}, LogValidateOptions{Rules: []Rule{rule}})
+	if err != nil {
+		t.Fatalf("ReplayAndValidateLog failed: %v", err)
+	}
+	if n != 6 {
+		t.Errorf("Unexpected number of events checked (%d)", n)
+	}
+	if !final {
+		t.Errorf("Rule wasn't run for the whole log")
+	}
+
+	var codes []FindingCode
+	for _, f := range result.Findings {
+		codes = append(codes, f.Code)
+	}
+	if len(codes) == 0 || codes[0] != FindingLogTooSmall || codes[len(codes)-1] != findingTest {
+		t.Errorf("Unexpected findings %v", codes)
+	}
+
+	n = 0
+	result, err = ReplayAndValidateLogWithOptions(path, LogOptions{},
+		LogValidateOptions{Rules: []Rule{rule}, SuppressedFindings: []FindingCode{findingTest}})
+	if err != nil {
+		t.Fatalf("ReplayAndValidateLog failed: %v", err)
+	}
+	for _, f := range result.Findings {
+		if f.Code == findingTest {
+			t.Errorf("Finding wasn't suppressed")
+		}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := ReplayAndValidateLogContext(ctx, path, LogOptions{}, LogValidateOptions{}); err != context.Canceled {
+		t.Errorf("Unexpected error: %v", err)
+	}
+}
+
+func hasFinding(findings []Finding, code FindingCode) bool {
+	for _, f := range findings {
+		if f.Code == code {
+			return true
+		}
+	}
+	return false
+}
+
+func TestReplayAndValidateLogDisableRules(t *testing.T) {
+	data := makeTestCryptoAgileLog(t, 3)
+	for _, test := range []struct {
+		desc     string
+		disabled []string
+		expected bool
+	}{
+		{desc: "Default", expected: true},
+		{desc: "Disabled", disabled: []string{RuleSeparators}},
+		{desc: "OtherDisabled", disabled: []string{RuleAnomalies}, expected: true},
+	} {
+		t.Run(test.desc, func(t *testing.T) {
+			log, err := NewLog(bytes.NewReader(data), LogOptions{})
+			if err != nil {
+				t.Fatalf("NewLog failed: %v", err)
+			}
+			result, err := replayAndValidateLog(context.Background(), log, int64(len(data)),
+				LogValidateOptions{DisabledRules: test.disabled})
+			if err != nil {
+				t.Fatalf("replayAndValidateLog failed: %v", err)
+			}
+			if hasFinding(result.AllFindings, FindingLogMissingSeparators) != test.expected {
+				t.Errorf("Unexpected findings %v", result.AllFindings)
+			}
+		})
+	}
+
+	// EV_EFI_VARIABLE_BOOT events that only measure the variable data violate revision 1.05.
+	pfpData := makeTestPFPLog(t, 105, EventTypeEFIVariableBoot)
+	result, err := replayAndValidateLog(context.Background(), mustNewTestLog(t, pfpData), 0,
+		LogValidateOptions{DisabledRules: []string{RuleEventDigests}})
+	if err != nil {
+		t.Fatalf("replayAndValidateLog failed: %v", err)
+	}
+	if result.EfiBootVariableBehaviour != EFIBootVariableBehaviourUnknown || countSpecViolations(result) != 0 ||
+		result.ValidatedEvents[1].MeasuredBytes != nil {
+		t.Errorf("Event digests were checked")
+	}
+
+	result, err = replayAndValidateLog(context.Background(), mustNewTestLog(t, pfpData), 0,
+		LogValidateOptions{DisabledRules: []string{RuleEFIBootVariables}})
+	if err != nil {
+		t.Fatalf("replayAndValidateLog failed: %v", err)
+	}
+	if result.EfiBootVariableBehaviour != EFIBootVariableBehaviourVarDataOnly || countSpecViolations(result) != 0 {
+		t.Errorf("Unexpected result %v", result.AllFindings)
+	}
+
+	_, err = replayAndValidateLog(context.Background(), mustNewTestLog(t, data), 0,
+		LogValidateOptions{DisabledRules: []string{"foo"}})
+	if err == nil || err.Error() != `cannot disable unknown rule "foo"` {
+		t.Errorf("Unexpected error: %v", err)
+	}
+}
+
+func TestReplayAndValidateLogReplaceRules(t *testing.T) {
+	const findingTest FindingCode = "test"
+
+	var events []*Event
+	rule := RuleFunc(func(ctx context.Context, event *Event, state *ReplayState) []Finding {
+		if event != nil {
+			return nil
+		}
+		events = state.Events()
+		return []Finding{{Code: findingTest, Severity: FindingSeverityWarning, Message: "replaced"}}
+	})
+
+	result, err := replayAndValidateLog(context.Background(), mustNewTestLog(t, makeTestCryptoAgileLog(t, 3)), 0,
+		LogValidateOptions{ReplacementRules: map[string]Rule{RuleSeparators: rule}})
+	if err != nil {
+		t.Fatalf("replayAndValidateLog failed: %v", err)
+	}
+	if hasFinding(result.AllFindings, FindingLogMissingSeparators) || !hasFinding(result.AllFindings, findingTest) {
+		t.Errorf("Unexpected findings %v", result.AllFindings)
+	}
+	if len(events) != 4 || events[3] != result.ValidatedEvents[3].Event {
+		t.Errorf("Unexpected events")
+	}
+
+	if _, err := replayAndValidateLog(context.Background(), mustNewTestLog(t, makeTestCryptoAgileLog(t, 3)), 0,
+		LogValidateOptions{ReplacementRules: map[string]Rule{"foo": rule}}); err == nil {
+		t.Errorf("Expected an error")
+	}
+}
+
+func mustNewTestLog(t *testing.T, data []byte) *Log {
+	log, err := NewLog(bytes.NewReader(data), LogOptions{})
+	if err != nil {
+		t.Fatalf("NewLog failed: %v", err)
+	}
+	return log
+}
@@ -0,0 +1,383 @@
+package samples
+
+import (
+	"bytes"
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/binary"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+	"unicode/utf16"
+
+	"github.com/chrisccoulson/tcglog-parser"
+)
+
+var update = flag.Bool("update", false, "Regenerate the sample logs in the data directory")
+
+// generators contains the functions that generate the anonymized logs for samples that aren't collected from
+// hardware.
+var generators = map[string]func() ([]byte, error){
+	"tpm12-bios":       buildTPM12BIOS,
+	"crypto-agile-efi": buildCryptoAgileEFI,
+	"grub":             buildGrub,
+	"uki":              buildUKI,
+	"windows":          buildWindows,
+	"broken-firmware":  buildBrokenFirmware,
+}
+
+func TestGenerateSamples(t *testing.T) {
+	for _, s := range Samples() {
+		generate, ok := generators[s.Name]
+		if !ok {
+			continue
+		}
+		t.Run(s.Name, func(t *testing.T) {
+			data, err := generate()
+			if err != nil {
+				t.Fatalf("cannot generate log: %v", err)
+			}
+			if *update {
+				if err := ioutil.WriteFile(filepath.Join("data", s.Name+".bin"), data, 0644); err != nil {
+					t.Fatalf("WriteFile failed: %v", err)
+				}
+				return
+			}
+			if !bytes.Equal(data, s.Data) {
+				t.Errorf("Log doesn't match the generator - run with -update to regenerate it")
+			}
+		})
+	}
+}
+
+type rawEventData []byte
+
+func (d rawEventData) String() string { return fmt.Sprintf("%x", []byte(d)) }
+func (d rawEventData) Bytes() []byte  { return d }
+
+type logBuilder struct {
+	buf        bytes.Buffer
+	w          *tcglog.LogWriter
+	algorithms tcglog.AlgorithmIdList
+	err        error
+}
+
+func newLogBuilder(spec tcglog.Spec, algorithms tcglog.AlgorithmIdList) (*logBuilder, error) {
+	b := &logBuilder{algorithms: algorithms}
+	w, err := tcglog.NewLogWriter(&b.buf, spec, algorithms)
+	if err != nil {
+		return nil, err
+	}
+	if err := w.WriteSpecIdEvent(0, nil); err != nil {
+		return nil, err
+	}
+	b.w = w
+	return b, nil
+}
+
+func hashData(alg tcglog.AlgorithmId, data []byte) (tcglog.Digest, error) {
+	switch alg {
+	case tcglog.AlgorithmSha1:
+		h := sha1.Sum(data)
+		return h[:], nil
+	case tcglog.AlgorithmSha256:
+		h := sha256.Sum256(data)
+		return h[:], nil
+	default:
+		return nil, fmt.Errorf("unsupported algorithm %s", alg)
+	}
+}
+
+// event writes an event with the supplied data, and digests computed from measured. Once an event can't be
+// written, this and subsequent calls do nothing and the error is returned from bytes.
+func (b *logBuilder) event(pcr tcglog.PCRIndex, eventType tcglog.EventType, data, measured []byte) {
+	if b.err != nil {
+		return
+	}
+	digests := tcglog.DigestMap{}
+	for _, alg := range b.algorithms {
+		digest, err := hashData(alg, measured)
+		if err != nil {
+			b.err = err
+			return
+		}
+		digests[alg] = digest
+	}
+	b.err = b.w.WriteEvent(&tcglog.Event{PCRIndex: pcr, EventType: eventType, Digests: digests,
+		Data: rawEventData(data)})
+}
+
+// variableEvent writes an event for the specified EFI variable. If dataOnly is true, only the variable data is
+// measured rather than the entire UEFI_VARIABLE_DATA structure.
+func (b *logBuilder) variableEvent(pcr tcglog.PCRIndex, eventType tcglog.EventType, guid *tcglog.EFIGUID,
+	name string, data []byte, dataOnly bool) {
+	if b.err != nil {
+		return
+	}
+	var buf bytes.Buffer
+	v := &tcglog.EFIVariableEventData{VariableName: *guid, UnicodeName: name, VariableData: data}
+	if err := v.EncodeMeasuredBytes(&buf); err != nil {
+		b.err = err
+		return
+	}
+	measured := buf.Bytes()
+	if dataOnly {
+		measured = data
+	}
+	b.event(pcr, eventType, buf.Bytes(), measured)
+}
+
+func (b *logBuilder) separators(pcrs ...tcglog.PCRIndex) {
+	for _, pcr := range pcrs {
+		b.event(pcr, tcglog.EventTypeSeparator, make([]byte, 4), make([]byte, 4))
+	}
+}
+
+func (b *logBuilder) bytes() ([]byte, error) {
+	if b.err != nil {
+		return nil, b.err
+	}
+	return b.buf.Bytes(), nil
+}
+
+func encodeUTF16(s string) []byte {
+	var buf bytes.Buffer
+	binary.Write(&buf, binary.LittleEndian, utf16.Encode([]rune(s)))
+	return buf.Bytes()
+}
+
+// encodeImageLoadEvent encodes a UEFI_IMAGE_LOAD_EVENT with a device path that refers to the specified file on
+// the first GPT partition of a disk.
+func encodeImageLoadEvent(location uint64, path string) []byte {
+	var devicePath bytes.Buffer
+
+	// HD(1,GPT,...)
+	devicePath.Write([]byte{0x04, 0x01})
+	binary.Write(&devicePath, binary.LittleEndian, uint16(42))
+	binary.Write(&devicePath, binary.LittleEndian, struct {
+		PartitionNumber uint32
+		PartitionStart  uint64
+		PartitionSize   uint64
+		Signature       [16]uint8
+		MBRType         uint8
+		SignatureType   uint8
+	}{1, 2048, 1048576, [16]uint8{0x5a, 0xe3, 0x1b, 0x9e, 0x40, 0x6c, 0x6f, 0x4b, 0x9a, 0x1e, 0x4c, 0x0d, 0x70,
+		0x23, 0x8b, 0x11}, 2, 2})
+
+	// File path
+	name := append(encodeUTF16(path), 0, 0)
+	devicePath.Write([]byte{0x04, 0x04})
+	binary.Write(&devicePath, binary.LittleEndian, uint16(4+len(name)))
+	devicePath.Write(name)
+
+	// End of entire device path
+	devicePath.Write([]byte{0x7f, 0xff, 0x04, 0x00})
+
+	var buf bytes.Buffer
+	binary.Write(&buf, binary.LittleEndian, struct {
+		LocationInMemory   uint64
+		LengthInMemory     uint64
+		LinkTimeAddress    uint64
+		LengthOfDevicePath uint64
+	}{location, 0x1a0000, 0, uint64(devicePath.Len())})
+	buf.Write(devicePath.Bytes())
+	return buf.Bytes()
+}
+
+// encodeTaggedEvent encodes a TCG_PCClientTaggedEvent structure.
+func encodeTaggedEvent(id uint32, data []byte) []byte {
+	var buf bytes.Buffer
+	binary.Write(&buf, binary.LittleEndian, id)
+	binary.Write(&buf, binary.LittleEndian, uint32(len(data)))
+	buf.Write(data)
+	return buf.Bytes()
+}
+
+func writeEFIPlatformEvents(b *logBuilder) {
+	b.event(0, tcglog.EventTypeSCRTMVersion, encodeUTF16("1.0.0\x00"), encodeUTF16("1.0.0\x00"))
+	blob := make([]byte, 16)
+	binary.LittleEndian.PutUint64(blob[0:], 0xff000000)
+	binary.LittleEndian.PutUint64(blob[8:], 0x1000000)
+	b.event(0, tcglog.EventTypeEFIPlatformFirmwareBlob, blob, []byte("anonymized firmware volume"))
+
+	for _, v := range []struct {
+		guid *tcglog.EFIGUID
+		name string
+		data []byte
+	}{
+		{tcglog.EFIGlobalVariableGUID, "SecureBoot", []byte{1}},
+		{tcglog.EFIGlobalVariableGUID, "PK", []byte("anonymized PK")},
+		{tcglog.EFIGlobalVariableGUID, "KEK", []byte("anonymized KEK")},
+		{tcglog.EFIImageSecurityDatabaseGUID, "db", []byte("anonymized db")},
+		{tcglog.EFIImageSecurityDatabaseGUID, "dbx", []byte("anonymized dbx")},
+	} {
+		b.variableEvent(7, tcglog.EventTypeEFIVariableDriverConfig, v.guid, v.name, v.data, false)
+	}
+
+	b.separators(7)
+
+	// This firmware only measures the variable data for EV_EFI_VARIABLE_BOOT events.
+	b.variableEvent(1, tcglog.EventTypeEFIVariableBoot, tcglog.EFIGlobalVariableGUID, "BootOrder", []byte{0x01, 0x00},
+		true)
+	b.variableEvent(1, tcglog.EventTypeEFIVariableBoot, tcglog.EFIGlobalVariableGUID, "Boot0001",
+		[]byte("anonymized load option"), true)
+
+	action := []byte("Calling EFI Application from Boot Option")
+	b.event(4, tcglog.EventTypeEFIAction, action, action)
+	b.separators(0, 1, 2, 3, 4, 5, 6)
+}
+
+func writeExitBootServices(b *logBuilder) {
+	for _, action := range []string{"Exit Boot Services Invocation", "Exit Boot Services Returned with Success"} {
+		b.event(5, tcglog.EventTypeEFIAction, []byte(action), []byte(action))
+	}
+}
+
+func buildTPM12BIOS() ([]byte, error) {
+	b, err := newLogBuilder(tcglog.SpecPCClient, tcglog.AlgorithmIdList{tcglog.AlgorithmSha1})
+	if err != nil {
+		return nil, err
+	}
+
+	b.event(0, tcglog.EventTypeSCRTMVersion, encodeUTF16("1.0.0\x00"), encodeUTF16("1.0.0\x00"))
+	b.event(0, tcglog.EventTypePostCode, []byte("POST CODE"), []byte("anonymized BIOS image"))
+	b.event(1, tcglog.EventTypePlatformConfigFlags, []byte{0x00, 0x00, 0x00, 0x00}, []byte{0x00, 0x00, 0x00, 0x00})
+	b.event(4, tcglog.EventTypeAction, []byte("Calling INT 19h"), []byte("Calling INT 19h"))
+	b.separators(0, 1, 2, 3, 4, 5, 6, 7)
+	b.event(4, tcglog.EventTypeIPL, []byte("IPL"), []byte("anonymized boot sector"))
+	b.event(5, tcglog.EventTypeIPLPartitionData, []byte("IPL Partition Data"), []byte("anonymized partition table"))
+	return b.bytes()
+}
+
+func buildCryptoAgileEFI() ([]byte, error) {
+	b, err := newLogBuilder(tcglog.SpecEFI_2, tcglog.AlgorithmIdList{tcglog.AlgorithmSha1, tcglog.AlgorithmSha256})
+	if err != nil {
+		return nil, err
+	}
+	writeEFIPlatformEvents(b)
+	b.event(4, tcglog.EventTypeEFIBootServicesApplication, encodeImageLoadEvent(0x7a4c5000, "\\EFI\\BOOT\\BOOTX64.EFI"),
+		[]byte("anonymized application"))
+	writeExitBootServices(b)
+	return b.bytes()
+}
+
+func buildGrub() ([]byte, error) {
+	b, err := newLogBuilder(tcglog.SpecEFI_2, tcglog.AlgorithmIdList{tcglog.AlgorithmSha1, tcglog.AlgorithmSha256})
+	if err != nil {
+		return nil, err
+	}
+	writeEFIPlatformEvents(b)
+
+	b.event(4, tcglog.EventTypeEFIBootServicesApplication, encodeImageLoadEvent(0x7a4c5000, "\\EFI\\ubuntu\\shimx64.efi"),
+		[]byte("anonymized shim"))
+	b.event(4, tcglog.EventTypeEFIBootServicesApplication, encodeImageLoadEvent(0x7a2b1000, "\\EFI\\ubuntu\\grubx64.efi"),
+		[]byte("anonymized grub"))
+
+	for _, cmd := range []string{
+		"search --no-floppy --fs-uuid --set=root 00000000-0000-0000-0000-000000000000",
+		"set prefix=($root)'/boot/grub'",
+		"linux /boot/vmlinuz root=UUID=00000000-0000-0000-0000-000000000000 ro quiet splash",
+		"initrd /boot/initrd.img",
+	} {
+		b.event(8, tcglog.EventTypeIPL, []byte("grub_cmd: "+cmd+"\x00"), []byte(cmd))
+	}
+	cmdline := "/boot/vmlinuz root=UUID=00000000-0000-0000-0000-000000000000 ro quiet splash"
+	b.event(8, tcglog.EventTypeIPL, []byte("kernel_cmdline: "+cmdline+"\x00"), []byte(cmdline))
+
+	b.event(9, tcglog.EventTypeIPL, []byte("/boot/grub/grub.cfg\x00"), []byte("anonymized grub.cfg"))
+	b.event(9, tcglog.EventTypeIPL, []byte("/boot/vmlinuz\x00"), []byte("anonymized kernel"))
+	b.event(9, tcglog.EventTypeIPL, []byte("/boot/initrd.img\x00"), []byte("anonymized initrd"))
+
+	writeExitBootServices(b)
+	return b.bytes()
+}
+
+func buildUKI() ([]byte, error) {
+	b, err := newLogBuilder(tcglog.SpecEFI_2, tcglog.AlgorithmIdList{tcglog.AlgorithmSha256})
+	if err != nil {
+		return nil, err
+	}
+	writeEFIPlatformEvents(b)
+
+	b.event(4, tcglog.EventTypeEFIBootServicesApplication,
+		encodeImageLoadEvent(0x7a4c5000, "\\EFI\\systemd\\systemd-bootx64.efi"), []byte("anonymized systemd-boot"))
+	b.event(4, tcglog.EventTypeEFIBootServicesApplication,
+		encodeImageLoadEvent(0x7a2b1000, "\\EFI\\Linux\\linux.efi"), []byte("anonymized unified kernel image"))
+
+	// systemd-stub records the kernel command line as a UTF-16 string with a single trailing zero byte, but
+	// measures it with a UTF-16 null terminator.
+	cmdline := encodeUTF16("root=UUID=00000000-0000-0000-0000-000000000000 ro quiet")
+	b.event(12, tcglog.EventTypeIPL, append(cmdline, 0), append(cmdline, 0, 0))
+
+	writeExitBootServices(b)
+	return b.bytes()
+}
+
+func buildWindows() ([]byte, error) {
+	b, err := newLogBuilder(tcglog.SpecEFI_2, tcglog.AlgorithmIdList{tcglog.AlgorithmSha1, tcglog.AlgorithmSha256})
+	if err != nil {
+		return nil, err
+	}
+	writeEFIPlatformEvents(b)
+
+	b.event(4, tcglog.EventTypeEFIBootServicesApplication,
+		encodeImageLoadEvent(0x7a4c5000, "\\EFI\\Microsoft\\Boot\\bootmgfw.efi"), []byte("anonymized boot manager"))
+
+	// Windows Boot Manager records its configuration as EV_EVENT_TAG events containing SIPA events.
+	for _, e := range []struct {
+		pcr  tcglog.PCRIndex
+		id   uint32
+		data []byte
+	}{
+		{12, 0x00040002, []byte{0x07, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00}}, // SIPAEVENT_BOOTCOUNTER
+		{12, 0x00050001, []byte{0x01}},                                           // SIPAEVENT_BOOTDEBUGGING
+		{13, 0x00060001, encodeUTF16("\\Windows\\System32\\winload.efi\x00")},    // SIPAEVENT_FILEPATH
+		{14, 0x00030001, []byte("anonymized authority")},                         // SIPAEVENT_AUTHORITYISSUER
+	} {
+		data := encodeTaggedEvent(e.id, e.data)
+		b.event(e.pcr, tcglog.EventTypeEventTag, data, data)
+	}
+
+	writeExitBootServices(b)
+	return b.bytes()
+}
+
+func buildBrokenFirmware() ([]byte, error) {
+	b, err := newLogBuilder(tcglog.SpecEFI_2, tcglog.AlgorithmIdList{tcglog.AlgorithmSha1, tcglog.AlgorithmSha256})
+	if err != nil {
+		return nil, err
+	}
+
+	// The CRTM version is recorded with a trailing null terminator that isn't measured.
+	b.event(0, tcglog.EventTypeSCRTMVersion, encodeUTF16("1.0.0\x00"), encodeUTF16("1.0.0"))
+
+	for _, v := range []struct {
+		guid *tcglog.EFIGUID
+		name string
+		data []byte
+	}{
+		{tcglog.EFIGlobalVariableGUID, "SecureBoot", []byte{0}},
+		{tcglog.EFIGlobalVariableGUID, "PK", nil},
+		{tcglog.EFIGlobalVariableGUID, "KEK", nil},
+		{tcglog.EFIImageSecurityDatabaseGUID, "db", nil},
+		{tcglog.EFIImageSecurityDatabaseGUID, "dbx", nil},
+	} {
+		b.variableEvent(7, tcglog.EventTypeEFIVariableDriverConfig, v.guid, v.name, v.data, false)
+	}
+	b.separators(7)
+
+	// The action string is recorded with different contents to what was measured.
+	b.event(4, tcglog.EventTypeEFIAction, []byte("Calling EFI Application from Boot Option"),
+		[]byte("Calling EFI Application from Boot Option\x00"))
+	b.separators(0, 1, 2, 3, 4, 5, 6)
+
+	b.event(4, tcglog.EventTypeEFIBootServicesApplication, encodeImageLoadEvent(0x7a4c5000, "\\EFI\\BOOT\\BOOTX64.EFI"),
+		[]byte("anonymized application"))
+
+	// This firmware measures the ExitBootServices events to the wrong PCR.
+	b.event(4, tcglog.EventTypeEFIAction, []byte("Exit Boot Services Invocation"),
+		[]byte("Exit Boot Services Invocation"))
+	return b.bytes()
+}
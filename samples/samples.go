@@ -0,0 +1,112 @@
+// Package samples provides a corpus of sample event logs for testing code that consumes the tcglog package.
+//
+// The samples cover TPM 1.2 and crypto-agile logs, GRUB and systemd-stub (UKI) boots, Windows boots and firmware
+// with known defects. They are anonymized - image digests, variable contents, device paths and kernel command
+// lines are replaced with synthetic values, so the digests of events that measure data which isn't recorded in
+// the log don't correspond to any real component. The digests of events that measure their own event data are
+// correct, unless the sample is deliberately broken.
+//
+// The logs are stored as binary files in the data directory. Logs that are generated rather than collected from
+// hardware can be regenerated with "go test -run TestGenerateSamples -update".
+package samples
+
+import (
+	_ "embed"
+
+	"github.com/chrisccoulson/tcglog-parser"
+)
+
+// Sample is a sample event log.
+type Sample struct {
+	Name        string            // A unique name for the sample
+	Description string            // A description of the system that the sample is modelled on
+	Spec        tcglog.Spec       // The specification that the log conforms to
+	Options     tcglog.LogOptions // The options that should be passed to tcglog.NewLog to decode the log
+	Broken      bool              // The log contains defects that validation is expected to report
+	Data        []byte            // The binary log
+}
+
+var (
+	//go:embed data/tpm12-bios.bin
+	tpm12BIOSData []byte
+
+	//go:embed data/crypto-agile-efi.bin
+	cryptoAgileEFIData []byte
+
+	//go:embed data/grub.bin
+	grubData []byte
+
+	//go:embed data/uki.bin
+	ukiData []byte
+
+	//go:embed data/windows.bin
+	windowsData []byte
+
+	//go:embed data/broken-firmware.bin
+	brokenFirmwareData []byte
+)
+
+var samples = []Sample{
+	{
+		Name:        "tpm12-bios",
+		Description: "Legacy BIOS with a TPM 1.2 device",
+		Spec:        tcglog.SpecPCClient,
+		Data:        tpm12BIOSData,
+	},
+	{
+		Name:        "crypto-agile-efi",
+		Description: "UEFI firmware with a TPM 2.0 device and secure boot enabled, booting an EFI application",
+		Spec:        tcglog.SpecEFI_2,
+		Data:        cryptoAgileEFIData,
+	},
+	{
+		Name:        "grub",
+		Description: "UEFI firmware with a TPM 2.0 device, booting Linux with shim and GRUB",
+		Spec:        tcglog.SpecEFI_2,
+		Options:     tcglog.LogOptions{EnableGrub: true},
+		Data:        grubData,
+	},
+	{
+		Name:        "uki",
+		Description: "UEFI firmware with a TPM 2.0 device, booting a unified kernel image with systemd-stub",
+		Spec:        tcglog.SpecEFI_2,
+		Options:     tcglog.LogOptions{EnableSystemdEFIStub: true, SystemdEFIStubPCR: 12},
+		Data:        ukiData,
+	},
+	{
+		Name:        "windows",
+		Description: "UEFI firmware with a TPM 2.0 device, booting Windows Boot Manager",
+		Spec:        tcglog.SpecEFI_2,
+		Data:        windowsData,
+	},
+	{
+		Name: "broken-firmware",
+		Description: "UEFI firmware with a TPM 2.0 device that records event data that doesn't match the " +
+			"measured digests",
+		Spec:   tcglog.SpecEFI_2,
+		Broken: true,
+		Data:   brokenFirmwareData,
+	},
+}
+
+// Samples returns the sample event logs. A new copy of each log is returned on each call, so callers are free to
+// modify the returned samples.
+func Samples() []*Sample {
+	var out []*Sample
+	for _, s := range samples {
+		sample := s
+		sample.Data = append([]byte(nil), s.Data...)
+		out = append(out, &sample)
+	}
+	return out
+}
+
+// SampleByName returns the sample with the specified name, or nil if there is no sample with that name.
+func SampleByName(name string) *Sample {
+	for _, s := range Samples() {
+		if s.Name == name {
+			return s
+		}
+	}
+	return nil
+}
@@ -0,0 +1,115 @@
+package tcglog_test
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"testing/iotest"
+
+	"github.com/chrisccoulson/tcglog-parser"
+	"github.com/chrisccoulson/tcglog-parser/samples"
+)
+
+func TestSamples(t *testing.T) {
+	dir, err := ioutil.TempDir("", "tcglog-test")
+	if err != nil {
+		t.Fatalf("TempDir failed: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	all := samples.Samples()
+	if len(all) == 0 {
+		t.Fatalf("No samples")
+	}
+	for _, s := range all {
+		t.Run(s.Name, func(t *testing.T) {
+			path := filepath.Join(dir, s.Name)
+			if err := ioutil.WriteFile(path, s.Data, 0644); err != nil {
+				t.Fatalf("WriteFile failed: %v", err)
+			}
+
+			result, err := tcglog.ReplayAndValidateLog(path, s.Options)
+			if err != nil {
+				t.Fatalf("ReplayAndValidateLog failed: %v", err)
+			}
+			if result.Spec != s.Spec {
+				t.Errorf("Unexpected spec (%v)", result.Spec)
+			}
+
+			broken := false
+			for _, e := range result.ValidatedEvents {
+				if len(e.IncorrectDigestValues) > 0 || e.MeasuredTrailingBytesCount > 0 {
+					broken = true
+				}
+			}
+			if broken != s.Broken {
+				t.Errorf("Unexpected validation result (broken: %v)", broken)
+			}
+		})
+	}
+
+	if samples.SampleByName("grub") == nil {
+		t.Errorf("SampleByName failed")
+	}
+}
+
+func readSampleEvents(t *testing.T, log *tcglog.Log) (out [][]byte) {
+	for {
+		event, err := log.NextEvent()
+		if err == io.EOF {
+			return
+		}
+		if err != nil {
+			t.Fatalf("NextEvent failed: %v", err)
+		}
+		data, err := json.Marshal(event)
+		if err != nil {
+			t.Fatalf("Marshal failed: %v", err)
+		}
+		out = append(out, data)
+	}
+}
+
+func TestNewLogFromReader(t *testing.T) {
+	for _, s := range samples.Samples() {
+		t.Run(s.Name, func(t *testing.T) {
+			log, err := tcglog.NewLog(bytes.NewReader(s.Data), s.Options)
+			if err != nil {
+				t.Fatalf("NewLog failed: %v", err)
+			}
+			expected := readSampleEvents(t, log)
+
+			// Read the log one byte at a time from a gzip stream, which doesn't support seeking.
+			var compressed bytes.Buffer
+			w := gzip.NewWriter(&compressed)
+			w.Write(s.Data)
+			w.Close()
+			r, err := gzip.NewReader(&compressed)
+			if err != nil {
+				t.Fatalf("gzip.NewReader failed: %v", err)
+			}
+
+			log, err = tcglog.NewLogFromReader(iotest.OneByteReader(r), s.Options)
+			if err != nil {
+				t.Fatalf("NewLogFromReader failed: %v", err)
+			}
+			if log.Spec != s.Spec {
+				t.Errorf("Unexpected spec (%v)", log.Spec)
+			}
+			events := readSampleEvents(t, log)
+			if len(events) != len(expected) {
+				t.Fatalf("Unexpected number of events (%d)", len(events))
+			}
+			for i := range events {
+				if !bytes.Equal(events[i], expected[i]) {
+					t.Errorf("Unexpected event %d: %s", i, events[i])
+				}
+			}
+		})
+	}
+}
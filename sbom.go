@@ -0,0 +1,248 @@
+package tcglog
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+)
+
+// SBOMFormat corresponds to a software bill of materials document format.
+type SBOMFormat int
+
+const (
+	SBOMFormatCycloneDX SBOMFormat = iota // CycloneDX 1.5 JSON
+	SBOMFormatSPDX                        // SPDX 2.3 JSON
+)
+
+// ParseSBOMFormat parses a SBOMFormat from its name ("cyclonedx" or "spdx").
+func ParseSBOMFormat(format string) (SBOMFormat, error) {
+	switch format {
+	case "cyclonedx":
+		return SBOMFormatCycloneDX, nil
+	case "spdx":
+		return SBOMFormatSPDX, nil
+	default:
+		return 0, fmt.Errorf("Unrecognized SBOM format \"%s\"", format)
+	}
+}
+
+// MeasuredComponent is a boot component that was measured in to the log.
+type MeasuredComponent struct {
+	Event     *Event
+	Component *Component // The component identified from the event's digests, or nil if it is unknown
+}
+
+// Name returns the name of the component, or a description of the event that measured it if the component is
+// unknown.
+func (c *MeasuredComponent) Name() string {
+	if c.Component != nil {
+		return c.Component.Name
+	}
+	switch d := c.Event.Data.(type) {
+	case *EFIImageLoadEventData:
+		return d.DevicePath.String()
+	case *EFIPlatformFirmwareBlobEventData:
+		if d.Description != "" {
+			return d.Description
+		}
+	case *LinuxEFIStubEventData:
+		return d.Description
+	}
+	return fmt.Sprintf("%s in PCR %d", c.Event.EventType, c.Event.PCRIndex)
+}
+
+// isComponentEvent indicates whether the specified event measures a boot component.
+func isComponentEvent(event *Event) bool {
+	switch event.EventType {
+	case EventTypeEFIPlatformFirmwareBlob, EventTypeEFIPlatformFirmwareBlob2, EventTypeEFIBootServicesApplication,
+		EventTypeEFIBootServicesDriver, EventTypeEFIRuntimeServicesDriver:
+		return true
+	}
+	// The initrd loaded by the Linux EFI stub is measured with an EV_EVENT_TAG event.
+	d, ok := event.Data.(*LinuxEFIStubEventData)
+	return ok && d.EventID == LinuxEFIStubEventInitrd
+}
+
+// MeasuredComponents returns the boot components measured by the supplied events, such as firmware blobs,
+// drivers, applications and the initrd loaded by the Linux EFI stub. Events that measure a component in db
+// are also included, which allows components such as kernels that are measured with other event types to be
+// identified. Components are identified using db, which may be nil.
+func MeasuredComponents(events []*Event, db ComponentDatabase) (out []MeasuredComponent) {
+	identified := make(map[*Event]*Component)
+	if db != nil {
+		for _, c := range IdentifyComponents(events, db) {
+			identified[c.Event] = c.Component
+		}
+	}
+	for _, event := range events {
+		c, ok := identified[event]
+		if !ok && !isComponentEvent(event) {
+			continue
+		}
+		out = append(out, MeasuredComponent{Event: event, Component: c})
+	}
+	return
+}
+
+func (c *MeasuredComponent) cycloneDXType() string {
+	if c.Component != nil {
+		switch c.Component.Type {
+		case "kernel":
+			return "operating-system"
+		case "firmware", "driver":
+			return "firmware"
+		case "":
+		default:
+			return "application"
+		}
+	}
+	switch c.Event.EventType {
+	case EventTypeEFIPlatformFirmwareBlob, EventTypeEFIPlatformFirmwareBlob2, EventTypeEFIBootServicesDriver,
+		EventTypeEFIRuntimeServicesDriver:
+		return "firmware"
+	default:
+		return "application"
+	}
+}
+
+var sbomAlgorithmNames = []struct {
+	alg       AlgorithmId
+	cycloneDX string
+	spdx      string
+}{
+	{AlgorithmSha1, "SHA-1", "SHA1"},
+	{AlgorithmSha256, "SHA-256", "SHA256"},
+	{AlgorithmSha384, "SHA-384", "SHA384"},
+	{AlgorithmSha512, "SHA-512", "SHA512"},
+	{AlgorithmSha3_256, "SHA3-256", "SHA3-256"},
+	{AlgorithmSha3_384, "SHA3-384", "SHA3-384"},
+	{AlgorithmSha3_512, "SHA3-512", "SHA3-512"},
+}
+
+type cycloneDXProperty struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+type cycloneDXHash struct {
+	Alg     string `json:"alg"`
+	Content string `json:"content"`
+}
+
+type cycloneDXSupplier struct {
+	Name string `json:"name"`
+}
+
+type cycloneDXComponent struct {
+	Type       string              `json:"type"`
+	Name       string              `json:"name"`
+	Version    string              `json:"version,omitempty"`
+	Supplier   *cycloneDXSupplier  `json:"supplier,omitempty"`
+	Hashes     []cycloneDXHash     `json:"hashes"`
+	Properties []cycloneDXProperty `json:"properties"`
+}
+
+func writeCycloneDX(w io.Writer, components []MeasuredComponent, created time.Time) error {
+	var out []cycloneDXComponent
+	for _, c := range components {
+		component := cycloneDXComponent{Type: c.cycloneDXType(), Name: c.Name(),
+			Properties: []cycloneDXProperty{
+				{"tcg:pcr", fmt.Sprintf("%d", c.Event.PCRIndex)},
+				{"tcg:eventType", c.Event.EventType.String()}}}
+		if c.Component != nil {
+			component.Version = c.Component.Version
+			if c.Component.Vendor != "" {
+				component.Supplier = &cycloneDXSupplier{c.Component.Vendor}
+			}
+		}
+		for _, a := range sbomAlgorithmNames {
+			if digest, ok := c.Event.Digests[a.alg]; ok {
+				component.Hashes = append(component.Hashes, cycloneDXHash{a.cycloneDX, hex.EncodeToString(digest)})
+			}
+		}
+		out = append(out, component)
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(map[string]interface{}{
+		"bomFormat":   "CycloneDX",
+		"specVersion": "1.5",
+		"version":     1,
+		"metadata": map[string]interface{}{
+			"timestamp": created.UTC().Format(time.RFC3339),
+			"tools":     []map[string]string{{"name": "tcglog-parser"}}},
+		"components": out})
+}
+
+type spdxChecksum struct {
+	Algorithm     string `json:"algorithm"`
+	ChecksumValue string `json:"checksumValue"`
+}
+
+type spdxPackage struct {
+	SPDXID           string         `json:"SPDXID"`
+	Name             string         `json:"name"`
+	VersionInfo      string         `json:"versionInfo,omitempty"`
+	Supplier         string         `json:"supplier,omitempty"`
+	DownloadLocation string         `json:"downloadLocation"`
+	FilesAnalyzed    bool           `json:"filesAnalyzed"`
+	Checksums        []spdxChecksum `json:"checksums"`
+	Comment          string         `json:"comment"`
+}
+
+func writeSPDX(w io.Writer, components []MeasuredComponent, created time.Time) error {
+	// Derive the document namespace from the measurements so that it is unique for each boot
+	// configuration, as required by the specification.
+	h := sha256.New()
+	var packages []spdxPackage
+	for i, c := range components {
+		pkg := spdxPackage{
+			SPDXID:           fmt.Sprintf("SPDXRef-Component-%d", i),
+			Name:             c.Name(),
+			DownloadLocation: "NOASSERTION",
+			Comment:          fmt.Sprintf("Measured to PCR %d by a %s event", c.Event.PCRIndex, c.Event.EventType)}
+		if c.Component != nil {
+			pkg.VersionInfo = c.Component.Version
+			if c.Component.Vendor != "" {
+				pkg.Supplier = "Organization: " + c.Component.Vendor
+			}
+		}
+		for _, a := range sbomAlgorithmNames {
+			if digest, ok := c.Event.Digests[a.alg]; ok {
+				pkg.Checksums = append(pkg.Checksums, spdxChecksum{a.spdx, hex.EncodeToString(digest)})
+				h.Write(digest)
+			}
+		}
+		packages = append(packages, pkg)
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(map[string]interface{}{
+		"spdxVersion":       "SPDX-2.3",
+		"dataLicense":       "CC0-1.0",
+		"SPDXID":            "SPDXRef-DOCUMENT",
+		"name":              "measured-boot-components",
+		"documentNamespace": fmt.Sprintf("https://spdx.org/spdxdocs/tcglog-%x", h.Sum(nil)),
+		"creationInfo": map[string]interface{}{
+			"created":  created.UTC().Format(time.RFC3339),
+			"creators": []string{"Tool: tcglog-parser"}},
+		"packages": packages})
+}
+
+// WriteSBOM writes a software bill of materials document in the specified format to w, describing the supplied
+// components with their measured digests. The document is timestamped with created.
+func WriteSBOM(w io.Writer, components []MeasuredComponent, format SBOMFormat, created time.Time) error {
+	switch format {
+	case SBOMFormatCycloneDX:
+		return writeCycloneDX(w, components, created)
+	case SBOMFormatSPDX:
+		return writeSPDX(w, components, created)
+	default:
+		return fmt.Errorf("invalid SBOM format")
+	}
+}
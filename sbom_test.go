@@ -0,0 +1,99 @@
+package tcglog
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+func TestWriteSBOM(t *testing.T) {
+	shim := &Component{Name: "shim", Version: "15.8", Vendor: "Ubuntu 24.04", Type: "bootloader"}
+	kernel := &Component{Name: "linux", Version: "6.8.0", Vendor: "Ubuntu 24.04", Type: "kernel"}
+	db := NewMemoryComponentDatabase()
+	db.Add(shim, DigestMap{AlgorithmSha256: AlgorithmSha256.hash([]byte("shim"))})
+	db.Add(kernel, DigestMap{AlgorithmSha256: AlgorithmSha256.hash([]byte("linux"))})
+
+	events := []*Event{
+		{PCRIndex: 0, EventType: EventTypeEFIPlatformFirmwareBlob,
+			Digests: DigestMap{AlgorithmSha256: AlgorithmSha256.hash([]byte("blob"))}},
+		{PCRIndex: 4, EventType: EventTypeEFIAction,
+			Digests: DigestMap{AlgorithmSha256: AlgorithmSha256.hash([]byte("foo"))}},
+		{PCRIndex: 4, EventType: EventTypeEFIBootServicesApplication,
+			Digests: DigestMap{AlgorithmSha256: AlgorithmSha256.hash([]byte("shim"))}},
+		{PCRIndex: 9, EventType: EventTypeIPL,
+			Digests: DigestMap{AlgorithmSha256: AlgorithmSha256.hash([]byte("linux"))}},
+	}
+
+	components := MeasuredComponents(events, db)
+	if len(components) != 3 {
+		t.Fatalf("Unexpected number of components (%d)", len(components))
+	}
+	if components[0].Component != nil || components[1].Component.Name != "shim" ||
+		components[2].Component.Name != "linux" {
+		t.Errorf("Unexpected components: %+v", components)
+	}
+	if len(MeasuredComponents(events, nil)) != 2 {
+		t.Errorf("Unexpected number of components without a database")
+	}
+
+	created := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	var buf bytes.Buffer
+	if err := WriteSBOM(&buf, components, SBOMFormatCycloneDX, created); err != nil {
+		t.Fatalf("WriteSBOM failed: %v", err)
+	}
+	var bom struct {
+		BOMFormat  string `json:"bomFormat"`
+		Components []struct {
+			Type     string
+			Name     string
+			Version  string
+			Supplier *struct{ Name string }
+			Hashes   []struct{ Alg, Content string }
+		}
+	}
+	if err := json.Unmarshal(buf.Bytes(), &bom); err != nil {
+		t.Fatalf("Invalid JSON: %v", err)
+	}
+	if bom.BOMFormat != "CycloneDX" || len(bom.Components) != 3 {
+		t.Fatalf("Unexpected document: %s", buf.String())
+	}
+	if c := bom.Components[0]; c.Type != "firmware" || c.Supplier != nil || c.Hashes[0].Alg != "SHA-256" {
+		t.Errorf("Unexpected firmware component: %+v", c)
+	}
+	if c := bom.Components[1]; c.Type != "application" || c.Name != "shim" || c.Version != "15.8" ||
+		c.Supplier == nil || c.Supplier.Name != "Ubuntu 24.04" ||
+		c.Hashes[0].Content != hex.EncodeToString(AlgorithmSha256.hash([]byte("shim"))) {
+		t.Errorf("Unexpected shim component: %+v", c)
+	}
+	if bom.Components[2].Type != "operating-system" {
+		t.Errorf("Unexpected kernel component type %s", bom.Components[2].Type)
+	}
+
+	buf.Reset()
+	if err := WriteSBOM(&buf, components, SBOMFormatSPDX, created); err != nil {
+		t.Fatalf("WriteSBOM failed: %v", err)
+	}
+	var spdx struct {
+		SPDXVersion  string `json:"spdxVersion"`
+		CreationInfo struct{ Created string }
+		Packages     []struct {
+			Name      string
+			Supplier  string
+			Checksums []struct{ Algorithm, ChecksumValue string }
+		}
+	}
+	if err := json.Unmarshal(buf.Bytes(), &spdx); err != nil {
+		t.Fatalf("Invalid JSON: %v", err)
+	}
+	if spdx.SPDXVersion != "SPDX-2.3" || spdx.CreationInfo.Created != "2024-01-01T00:00:00Z" ||
+		len(spdx.Packages) != 3 {
+		t.Fatalf("Unexpected document: %s", buf.String())
+	}
+	if p := spdx.Packages[1]; p.Name != "shim" || p.Supplier != "Organization: Ubuntu 24.04" ||
+		p.Checksums[0].Algorithm != "SHA256" {
+		t.Errorf("Unexpected shim package: %+v", p)
+	}
+}
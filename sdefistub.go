@@ -30,7 +30,7 @@ func decodeEventDataSystemdEFIStub(data []byte) (EventData, int, error) {
 	reader := bytes.NewReader(data[:len(data)-1])
 
 	utf16Str := make([]uint16, len(data)/2)
-	binary.Read(reader, binary.LittleEndian, &utf16Str)
+	binary.Read(reader, binary.LittleEndian, utf16Str)
 
 	return &SystemdEFIStubEventData{data: data, Str: convertUtf16ToString(utf16Str)}, 0, nil
 }
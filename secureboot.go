@@ -0,0 +1,188 @@
+package tcglog
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+)
+
+var (
+	// EFIGlobalVariableGUID is the vendor GUID of the SecureBoot, PK and KEK variables.
+	EFIGlobalVariableGUID = NewEFIGUID(0x8be4df61, 0x93ca, 0x11d2, 0xaa0d, [...]uint8{0x00, 0xe0, 0x98, 0x03, 0x2b, 0x8c})
+
+	// EFIImageSecurityDatabaseGUID is the vendor GUID of the db and dbx variables.
+	EFIImageSecurityDatabaseGUID = NewEFIGUID(0xd719b2cb, 0x3d3a, 0x4596, 0xa3bc, [...]uint8{0xda, 0xd0, 0x0e, 0x67, 0x65, 0x6f})
+)
+
+// SecureBootPCR is the PCR that the secure boot policy is measured to.
+const SecureBootPCR PCRIndex = 7
+
+// PCR7PredictionOrder describes how PredictPCR7 orders the measurements of the secure boot policy.
+type PCR7PredictionOrder int
+
+const (
+	// PCR7PredictionOrderLog reuses the events in the supplied log in the order that they appear, including
+	// any events that the specification doesn't require, and measures updated variables in the same way that
+	// the firmware measured them in the log. This is the most accurate mode on real machines.
+	PCR7PredictionOrderLog PCR7PredictionOrder = iota
+
+	// PCR7PredictionOrderSpec measures the SecureBoot, PK, KEK, db and dbx variables in the order defined by
+	// the specification, followed by a separator and the EV_EFI_VARIABLE_AUTHORITY events from the log.
+	PCR7PredictionOrderSpec
+)
+
+// https://trustedcomputinggroup.org/wp-content/uploads/TCG_PCClientSpecPlat_TPM_2p0_1p04_pub.pdf
+//  (section 3.3.4.8 "PCR[7] - Secure Boot Policy Measurements")
+var secureBootPolicyVariables = []struct {
+	guid *EFIGUID
+	name string
+}{
+	{EFIGlobalVariableGUID, "SecureBoot"},
+	{EFIGlobalVariableGUID, "PK"},
+	{EFIGlobalVariableGUID, "KEK"},
+	{EFIImageSecurityDatabaseGUID, "db"},
+	{EFIImageSecurityDatabaseGUID, "dbx"},
+}
+
+func findEFIVariableUpdate(updates []*EFIVariableEventData, guid *EFIGUID, name string) *EFIVariableEventData {
+	for _, u := range updates {
+		if u.VariableName == *guid && u.UnicodeName == name {
+			return u
+		}
+	}
+	return nil
+}
+
+func predictPCR7FromLog(events []*Event, algorithms AlgorithmIdList, updates []*EFIVariableEventData) ([]DigestMap, error) {
+	applied := make(map[*EFIVariableEventData]bool)
+
+	var out []DigestMap
+	for _, event := range events {
+		if event.PCRIndex != SecureBootPCR || !doesEventTypeExtendPCR(event.EventType) {
+			continue
+		}
+
+		d, isVar := event.Data.(*EFIVariableEventData)
+		if !isVar || event.EventType != EventTypeEFIVariableDriverConfig {
+			out = append(out, event.Digests)
+			continue
+		}
+		update := findEFIVariableUpdate(updates, &d.VariableName, d.UnicodeName)
+		if update == nil {
+			out = append(out, event.Digests)
+			continue
+		}
+		applied[update] = true
+
+		// Measure the new value in the same way that the firmware measured the existing value.
+		current, err := predictEventDigests(SpecUnknown, event.EventType, d, algorithms)
+		if err != nil {
+			return nil, err
+		}
+		var digests DigestMap
+		for _, alg := range algorithms {
+			switch {
+			case bytes.Equal(event.Digests[alg], current[alg]):
+				digests, err = predictEventDigests(SpecUnknown, event.EventType, update, algorithms)
+				if err != nil {
+					return nil, err
+				}
+			case bytes.Equal(event.Digests[alg], alg.hash(d.VariableData)):
+				// Some firmware only measures the variable data.
+				digests = computeEventDigests(algorithms, update.VariableData)
+			default:
+				continue
+			}
+			break
+		}
+		if digests == nil {
+			return nil, fmt.Errorf("cannot determine how the %s variable was measured", d.UnicodeName)
+		}
+		out = append(out, digests)
+	}
+
+	for _, u := range updates {
+		if !applied[u] {
+			return nil, fmt.Errorf("the %s variable is not measured in the log", u.UnicodeName)
+		}
+	}
+	return out, nil
+}
+
+func predictPCR7FromSpec(events []*Event, algorithms AlgorithmIdList, updates []*EFIVariableEventData) ([]DigestMap, error) {
+	var current []*EFIVariableEventData
+	var separator DigestMap
+	var authorities []DigestMap
+	for _, event := range events {
+		if event.PCRIndex != SecureBootPCR {
+			continue
+		}
+		switch event.EventType {
+		case EventTypeEFIVariableDriverConfig:
+			if d, ok := event.Data.(*EFIVariableEventData); ok {
+				current = append(current, d)
+			}
+		case EventTypeSeparator:
+			if separator == nil {
+				separator = event.Digests
+			}
+		case EventTypeEFIVariableAuthority:
+			authorities = append(authorities, event.Digests)
+		}
+	}
+
+	var out []DigestMap
+	for _, v := range secureBootPolicyVariables {
+		variable := findEFIVariableUpdate(updates, v.guid, v.name)
+		if variable == nil {
+			variable = findEFIVariableUpdate(current, v.guid, v.name)
+		}
+		if variable == nil {
+			// Variables that don't exist are measured with no data.
+			variable = &EFIVariableEventData{VariableName: *v.guid, UnicodeName: v.name}
+		}
+		digests, err := predictEventDigests(SpecUnknown, EventTypeEFIVariableDriverConfig, variable, algorithms)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, digests)
+	}
+
+	if separator == nil {
+		var err error
+		separator, err = predictEventDigests(SpecUnknown, EventTypeSeparator,
+			&separatorEventData{data: make([]byte, 4)}, algorithms)
+		if err != nil {
+			return nil, err
+		}
+	}
+	out = append(out, separator)
+	return append(out, authorities...), nil
+}
+
+// PredictPCR7 predicts the value of PCR 7 for each of the specified algorithms after the secure boot variables
+// are updated with the values in updates, using the supplied events from the current boot as a template. The
+// order parameter determines whether the ordering and conventions observed in the log are reused, or whether an
+// idealized ordering from the specification is used. Events that are not secure boot configuration
+// measurements, such as EV_EFI_VARIABLE_AUTHORITY events, are reused with their existing digests in both modes.
+func PredictPCR7(events []*Event, algorithms AlgorithmIdList, updates []*EFIVariableEventData,
+	order PCR7PredictionOrder) (DigestMap, error) {
+	if err := checkPredictionAlgorithms(algorithms); err != nil {
+		return nil, err
+	}
+
+	var digests []DigestMap
+	var err error
+	switch order {
+	case PCR7PredictionOrderLog:
+		digests, err = predictPCR7FromLog(events, algorithms, updates)
+	case PCR7PredictionOrderSpec:
+		digests, err = predictPCR7FromSpec(events, algorithms, updates)
+	default:
+		return nil, errors.New("invalid prediction order")
+	}
+	if err != nil {
+		return nil, err
+	}
+	return extendPredictedDigests(algorithms, digests)
+}
@@ -0,0 +1,91 @@
+package tcglog
+
+import (
+	"bytes"
+	"testing"
+)
+
+func makeTestPCR7Event(eventType EventType, data EventData, measuredBytes []byte) *Event {
+	return &Event{
+		PCRIndex:  SecureBootPCR,
+		EventType: eventType,
+		Digests:   DigestMap{AlgorithmSha256: AlgorithmSha256.hash(measuredBytes)},
+		Data:      data}
+}
+
+func makeTestPCR7Variable(guid *EFIGUID, name string, data []byte) *EFIVariableEventData {
+	return &EFIVariableEventData{VariableName: *guid, UnicodeName: name, VariableData: data}
+}
+
+func TestPredictPCR7(t *testing.T) {
+	var events []*Event
+	for _, v := range []*EFIVariableEventData{
+		makeTestPCR7Variable(EFIGlobalVariableGUID, "SecureBoot", []byte{1}),
+		makeTestPCR7Variable(EFIGlobalVariableGUID, "PK", []byte("pk")),
+		makeTestPCR7Variable(EFIGlobalVariableGUID, "KEK", []byte("kek")),
+		makeTestPCR7Variable(EFIImageSecurityDatabaseGUID, "db", []byte("db")),
+	} {
+		events = append(events, makeTestPCR7Event(EventTypeEFIVariableDriverConfig, v, eventMeasuredBytes(v)))
+	}
+	// This firmware only measures the variable data for dbx.
+	dbx := makeTestPCR7Variable(EFIImageSecurityDatabaseGUID, "dbx", []byte("dbx"))
+	events = append(events, makeTestPCR7Event(EventTypeEFIVariableDriverConfig, dbx, dbx.VariableData))
+	// This event isn't required by the specification.
+	events = append(events, makeTestPCR7Event(EventTypeEFIAction, &asciiStringEventData{data: []byte("foo")},
+		[]byte("foo")))
+	events = append(events, makeTestPCR7Event(EventTypeSeparator, &separatorEventData{data: make([]byte, 4)},
+		make([]byte, 4)))
+	events = append(events, makeTestPCR7Event(EventTypeEFIVariableAuthority, &opaqueEventData{data: []byte("auth")},
+		[]byte("auth")))
+
+	newDbx := makeTestPCR7Variable(EFIImageSecurityDatabaseGUID, "dbx", []byte("newdbx"))
+
+	extend := func(measurements ...[]byte) Digest {
+		out := make(Digest, AlgorithmSha256.size())
+		for _, m := range measurements {
+			out = performHashExtendOperation(AlgorithmSha256, out, AlgorithmSha256.hash(m))
+		}
+		return out
+	}
+
+	t.Run("Log", func(t *testing.T) {
+		value, err := PredictPCR7(events, AlgorithmIdList{AlgorithmSha256}, []*EFIVariableEventData{newDbx},
+			PCR7PredictionOrderLog)
+		if err != nil {
+			t.Fatalf("PredictPCR7 failed: %v", err)
+		}
+		var m [][]byte
+		for _, e := range events[:4] {
+			m = append(m, eventMeasuredBytes(e.Data.(*EFIVariableEventData)))
+		}
+		expected := extend(append(m, []byte("newdbx"), []byte("foo"), make([]byte, 4), []byte("auth"))...)
+		if !bytes.Equal(value[AlgorithmSha256], expected) {
+			t.Errorf("Unexpected prediction")
+		}
+	})
+
+	t.Run("Spec", func(t *testing.T) {
+		value, err := PredictPCR7(events, AlgorithmIdList{AlgorithmSha256}, []*EFIVariableEventData{newDbx},
+			PCR7PredictionOrderSpec)
+		if err != nil {
+			t.Fatalf("PredictPCR7 failed: %v", err)
+		}
+		var m [][]byte
+		for _, e := range events[:4] {
+			m = append(m, eventMeasuredBytes(e.Data.(*EFIVariableEventData)))
+		}
+		expected := extend(append(m, eventMeasuredBytes(newDbx), make([]byte, 4), []byte("auth"))...)
+		if !bytes.Equal(value[AlgorithmSha256], expected) {
+			t.Errorf("Unexpected prediction")
+		}
+	})
+
+	t.Run("MissingVariable", func(t *testing.T) {
+		_, err := PredictPCR7(events, AlgorithmIdList{AlgorithmSha256},
+			[]*EFIVariableEventData{makeTestPCR7Variable(EFIImageSecurityDatabaseGUID, "dbt", nil)},
+			PCR7PredictionOrderLog)
+		if err == nil {
+			t.Errorf("PredictPCR7 should fail for a variable that isn't in the log")
+		}
+	})
+}
@@ -0,0 +1,11 @@
+//go:build go1.24
+// +build go1.24
+
+package tcglog
+
+import (
+	// Register the SHA-3 family with the crypto package, which is required for SHA3-256, SHA3-384 and
+	// SHA3-512 digests to be supported. These are silently dropped from logs when building with older
+	// versions of Go.
+	_ "crypto/sha3"
+)
@@ -0,0 +1,27 @@
+//go:build go1.24
+// +build go1.24
+
+package tcglog
+
+import (
+	"encoding/hex"
+	"testing"
+)
+
+func TestSHA3(t *testing.T) {
+	for _, data := range []struct {
+		alg      AlgorithmId
+		expected string
+	}{
+		{AlgorithmSha3_256, "3a985da74fe225b2045c172d6bd390bd855f086e3e9d525b46bfe24511431532"},
+		{AlgorithmSha3_384, "ec01498288516fc926459f58e2c6ad8df9b473cb0fc08c2596da7cf0e49be4b298d88cea927ac7f539f1edf228376d25"},
+		{AlgorithmSha3_512, "b751850b1a57168a5693cd924b6b096e08f621827444f70d884f5d0240d2712e10e116e9192af3c91a7ec57647e3934057340b4cf408d5a56592f8274eec53f0"},
+	} {
+		if !data.alg.supported() {
+			t.Fatalf("%s should be supported", data.alg)
+		}
+		if d := hex.EncodeToString(data.alg.hash([]byte("abc"))); d != data.expected {
+			t.Errorf("Unexpected %s digest: %s", data.alg, d)
+		}
+	}
+}
@@ -0,0 +1,43 @@
+package tcglog
+
+import (
+	"encoding/hex"
+	"strings"
+	"testing"
+)
+
+func TestSM3(t *testing.T) {
+	for _, data := range []struct {
+		in       string
+		expected string
+	}{
+		// GB/T 32905-2016 Appendix A
+		{"abc", "66c7f0f462eeedd9d1f2d46bdc10e4e24167c4875cf2f7a2297da02b8f4ba8e0"},
+		{strings.Repeat("abcd", 16), "debe9ff92275b8a138604889c18e5a4d6fdb70e5387e5765293dcba39c0c5732"},
+	} {
+		if d := hex.EncodeToString(AlgorithmSm3_256.hash([]byte(data.in))); d != data.expected {
+			t.Errorf("Unexpected digest for %q: %s", data.in, d)
+		}
+
+		// Check that writes that don't align with the block size produce the same digest.
+		h := AlgorithmSm3_256.newHash()
+		for i := 0; i < len(data.in); i += 7 {
+			end := i + 7
+			if end > len(data.in) {
+				end = len(data.in)
+			}
+			h.Write([]byte(data.in[i:end]))
+		}
+		if d := hex.EncodeToString(h.Sum(nil)); d != data.expected {
+			t.Errorf("Unexpected digest for %q with partial writes: %s", data.in, d)
+		}
+	}
+
+	if !AlgorithmSm3_256.supported() || AlgorithmSm3_256.size() != 32 {
+		t.Errorf("SM3-256 should be supported")
+	}
+	alg, err := ParseAlgorithm(AlgorithmSm3_256.jsonName())
+	if err != nil || alg != AlgorithmSm3_256 {
+		t.Errorf("Unexpected algorithm: %v (%v)", alg, err)
+	}
+}
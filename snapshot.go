@@ -0,0 +1,190 @@
+package tcglog
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"sort"
+)
+
+// ReplayStep describes a single PCR extend operation performed during the replay of a log.
+type ReplayStep struct {
+	EventIndex uint        // The index of the event in the log
+	PCRIndex   PCRIndex    // The PCR that was extended
+	EventType  EventType   // The type of the event
+	Algorithm  AlgorithmId // The PCR bank that was extended
+	Digest     Digest      // The event digest that was extended
+	Before     Digest      // The value of the PCR before the extend operation
+	After      Digest      // The value of the PCR after the extend operation
+}
+
+// ReplaySnapshot is a record of the complete computation performed when replaying a log, which can be exported
+// as evidence so that the arithmetic can be independently re-verified without rerunning the replay.
+type ReplaySnapshot struct {
+	Spec        Spec
+	Algorithms  AlgorithmIdList
+	Steps       []ReplayStep // Extend operations, in log order and then in ascending order of algorithm
+	FinalValues map[PCRIndex]DigestMap
+}
+
+func sortedAlgorithms(algorithms AlgorithmIdList) AlgorithmIdList {
+	out := make(AlgorithmIdList, len(algorithms))
+	copy(out, algorithms)
+	sort.Slice(out, func(i, j int) bool { return out[i] < out[j] })
+	return out
+}
+
+func sortedPCRs(values map[PCRIndex]DigestMap) (out []PCRIndex) {
+	for pcr := range values {
+		out = append(out, pcr)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i] < out[j] })
+	return
+}
+
+// ReplaySnapshot returns a record of the computation performed to obtain the expected PCR values in this result.
+func (r *LogValidateResult) ReplaySnapshot() *ReplaySnapshot {
+	s := &ReplaySnapshot{
+		Spec:        r.Spec,
+		Algorithms:  sortedAlgorithms(r.Algorithms),
+		FinalValues: make(map[PCRIndex]DigestMap)}
+
+	pcr0Extended := false
+	var drtm dynamicLaunchTracker
+	for _, e := range r.ValidatedEvents {
+		event := e.Event
+		if locality, ok := startupLocality(event); ok && !pcr0Extended {
+			s.FinalValues[0] = initialPCR0Values(s.Algorithms, locality)
+		}
+		if drtm.isDynamicLaunch(event) {
+			resetDRTMPCRs(s.FinalValues)
+		}
+		if _, exists := s.FinalValues[event.PCRIndex]; !exists {
+			s.FinalValues[event.PCRIndex] = DigestMap{}
+			for _, alg := range s.Algorithms {
+				s.FinalValues[event.PCRIndex][alg] = make(Digest, alg.size())
+			}
+		}
+		if !doesEventTypeExtendPCR(event.EventType) {
+			continue
+		}
+		if event.PCRIndex == 0 {
+			pcr0Extended = true
+		}
+
+		for _, alg := range s.Algorithms {
+			before := s.FinalValues[event.PCRIndex][alg]
+			after := performHashExtendOperation(alg, before, event.Digests[alg])
+			s.Steps = append(s.Steps, ReplayStep{
+				EventIndex: event.Index,
+				PCRIndex:   event.PCRIndex,
+				EventType:  event.EventType,
+				Algorithm:  alg,
+				Digest:     event.Digests[alg],
+				Before:     before,
+				After:      after})
+			s.FinalValues[event.PCRIndex][alg] = after
+		}
+	}
+
+	return s
+}
+
+// Verify recomputes each extend operation in the snapshot and checks that the steps for each PCR are chained
+// correctly, starting from zero, and that they are consistent with the final values. PCR 0 may also start from
+// the value implied by a startup locality of 3 or 4, and PCRs 17-22 start from zero again after a TXT
+// EVTYPE_HASH_START event indicates a subsequent dynamic launch.
+func (s *ReplaySnapshot) Verify() error {
+	current := make(map[PCRIndex]DigestMap)
+	for i, step := range s.Steps {
+		if !step.Algorithm.supported() {
+			return fmt.Errorf("step %d: unsupported algorithm %s", i, step.Algorithm)
+		}
+		if step.PCRIndex == firstDRTMPCR && step.EventType == EventTypeTXTHashStart {
+			for pcr, digests := range current {
+				if isDRTMPCR(pcr) {
+					delete(digests, step.Algorithm)
+				}
+			}
+		}
+		if _, exists := current[step.PCRIndex]; !exists {
+			current[step.PCRIndex] = DigestMap{}
+		}
+		expectedBefore, exists := current[step.PCRIndex][step.Algorithm]
+		if !exists {
+			expectedBefore = make(Digest, step.Algorithm.size())
+			if step.PCRIndex == 0 && len(step.Before) == len(expectedBefore) {
+				if locality := step.Before[len(step.Before)-1]; locality == 3 || locality == 4 {
+					expectedBefore[len(expectedBefore)-1] = locality
+				}
+			}
+		}
+		if !bytes.Equal(step.Before, expectedBefore) {
+			return fmt.Errorf("step %d: initial value of PCR %d (%s) is not chained from the previous step", i,
+				step.PCRIndex, step.Algorithm)
+		}
+		if !bytes.Equal(performHashExtendOperation(step.Algorithm, step.Before, step.Digest), step.After) {
+			return fmt.Errorf("step %d: incorrect result for extend of PCR %d (%s)", i, step.PCRIndex,
+				step.Algorithm)
+		}
+		current[step.PCRIndex][step.Algorithm] = step.After
+	}
+
+	for pcr, digests := range current {
+		for alg, value := range digests {
+			if !bytes.Equal(s.FinalValues[pcr][alg], value) {
+				return fmt.Errorf("final value of PCR %d (%s) is inconsistent with the steps", pcr, alg)
+			}
+		}
+	}
+	return nil
+}
+
+// Encode writes the snapshot to w in a canonical, line-oriented text form that is suitable for signing. The
+// output only depends on the contents of the snapshot. Numeric identifiers are used for event types and
+// algorithms so that the encoding doesn't change if the names used by this package change.
+//
+// The format is:
+//	tcglog-replay-snapshot 1
+//	spec <spec>
+//	algorithms <alg> ...
+//	step <event index> <pcr> <event type> <alg> <digest> <before> <after>
+//	...
+//	final <pcr> <alg> <value>
+//	...
+func (s *ReplaySnapshot) Encode(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+
+	fmt.Fprintf(bw, "tcglog-replay-snapshot 1\n")
+	fmt.Fprintf(bw, "spec %d\n", s.Spec)
+	fmt.Fprintf(bw, "algorithms")
+	for _, alg := range sortedAlgorithms(s.Algorithms) {
+		fmt.Fprintf(bw, " 0x%04x", uint16(alg))
+	}
+	fmt.Fprintf(bw, "\n")
+
+	for _, step := range s.Steps {
+		fmt.Fprintf(bw, "step %d %d 0x%08x 0x%04x %x %x %x\n", step.EventIndex, step.PCRIndex,
+			uint32(step.EventType), uint16(step.Algorithm), step.Digest, step.Before, step.After)
+	}
+
+	for _, pcr := range sortedPCRs(s.FinalValues) {
+		var algs AlgorithmIdList
+		for alg := range s.FinalValues[pcr] {
+			algs = append(algs, alg)
+		}
+		for _, alg := range sortedAlgorithms(algs) {
+			fmt.Fprintf(bw, "final %d 0x%04x %x\n", pcr, uint16(alg), s.FinalValues[pcr][alg])
+		}
+	}
+
+	return bw.Flush()
+}
+
+// Bytes returns the canonical encoding of the snapshot (see Encode).
+func (s *ReplaySnapshot) Bytes() []byte {
+	var buf bytes.Buffer
+	s.Encode(&buf)
+	return buf.Bytes()
+}
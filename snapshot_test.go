@@ -0,0 +1,58 @@
+package tcglog
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestReplaySnapshot(t *testing.T) {
+	dir, err := ioutil.TempDir("", "tcglog-test")
+	if err != nil {
+		t.Fatalf("TempDir failed: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "log")
+	if err := CreateLogFile(path, AlgorithmIdList{AlgorithmSha256, AlgorithmSha1}); err != nil {
+		t.Fatalf("CreateLogFile failed: %v", err)
+	}
+	extender := &mockPCRExtender{}
+	for _, data := range []string{"foo", "bar", "baz"} {
+		if _, err := MeasureAndLog(path, extender, 23, EventTypeIPL, []byte(data), nil); err != nil {
+			t.Fatalf("MeasureAndLog failed: %v", err)
+		}
+	}
+
+	result, err := ReplayAndValidateLog(path, LogOptions{})
+	if err != nil {
+		t.Fatalf("ReplayAndValidateLog failed: %v", err)
+	}
+
+	s := result.ReplaySnapshot()
+	if len(s.Steps) != 6 {
+		t.Fatalf("Unexpected number of steps (%d)", len(s.Steps))
+	}
+	if s.Steps[0].Algorithm != AlgorithmSha1 || s.Steps[1].Algorithm != AlgorithmSha256 {
+		t.Errorf("Steps should be ordered by algorithm")
+	}
+	if err := s.Verify(); err != nil {
+		t.Errorf("Verify failed: %v", err)
+	}
+	for _, alg := range s.Algorithms {
+		if !bytes.Equal(s.FinalValues[23][alg], result.ExpectedPCRValues[23][alg]) {
+			t.Errorf("Unexpected final value for %s", alg)
+		}
+	}
+
+	if !bytes.Equal(s.Bytes(), result.ReplaySnapshot().Bytes()) {
+		t.Errorf("Encoding should be deterministic")
+	}
+
+	s.Steps[3].After = s.Steps[1].After
+	if err := s.Verify(); err == nil {
+		t.Errorf("Verify should fail with a tampered step")
+	}
+}
@@ -0,0 +1,403 @@
+package tcglog
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+)
+
+const (
+	spdmDeviceSecurityEventSignature  = "SPDM Device Sec\x00"
+	spdmDeviceSecurityEventSignature2 = "SPDM Device Sec2"
+)
+
+// SPDMAuthState describes the result of authenticating a device with SPDM.
+type SPDMAuthState uint8
+
+const (
+	SPDMAuthStateSuccess     SPDMAuthState = 0    // The device was authenticated
+	SPDMAuthStateNoAuth      SPDMAuthState = 1    // The device doesn't support authentication
+	SPDMAuthStateNoBinding   SPDMAuthState = 2    // The device supports authentication but there is no policy for it
+	SPDMAuthStateFailCrypto  SPDMAuthState = 3    // Authentication failed because of a cryptographic error
+	SPDMAuthStateFailInvalid SPDMAuthState = 4    // Authentication failed because of an invalid certificate chain
+	SPDMAuthStateNoSPDM      SPDMAuthState = 0xff // The device doesn't support SPDM
+)
+
+func (s SPDMAuthState) String() string {
+	switch s {
+	case SPDMAuthStateSuccess:
+		return "success"
+	case SPDMAuthStateNoAuth:
+		return "no-auth"
+	case SPDMAuthStateNoBinding:
+		return "no-binding"
+	case SPDMAuthStateFailCrypto:
+		return "fail-crypto"
+	case SPDMAuthStateFailInvalid:
+		return "fail-invalid"
+	case SPDMAuthStateNoSPDM:
+		return "no-spdm"
+	default:
+		return fmt.Sprintf("%#x", uint8(s))
+	}
+}
+
+// SPDMDeviceType describes the bus of a device that was measured with SPDM.
+type SPDMDeviceType uint32
+
+const (
+	SPDMDeviceTypeNull SPDMDeviceType = 0
+	SPDMDeviceTypePCI  SPDMDeviceType = 1
+	SPDMDeviceTypeUSB  SPDMDeviceType = 2
+)
+
+func (t SPDMDeviceType) String() string {
+	switch t {
+	case SPDMDeviceTypeNull:
+		return "null"
+	case SPDMDeviceTypePCI:
+		return "pci"
+	case SPDMDeviceTypeUSB:
+		return "usb"
+	default:
+		return fmt.Sprintf("%#x", uint32(t))
+	}
+}
+
+// SPDMSubHeaderType describes what an SPDM device security event records.
+type SPDMSubHeaderType uint32
+
+const (
+	SPDMSubHeaderMeasurementBlock SPDMSubHeaderType = 0 // The event records SPDM measurement blocks
+	SPDMSubHeaderCertChain        SPDMSubHeaderType = 1 // The event records an SPDM certificate chain
+)
+
+func (t SPDMSubHeaderType) String() string {
+	switch t {
+	case SPDMSubHeaderMeasurementBlock:
+		return "measurement-block"
+	case SPDMSubHeaderCertChain:
+		return "cert-chain"
+	default:
+		return fmt.Sprintf("%#x", uint32(t))
+	}
+}
+
+// SPDMMeasurementValueType corresponds to the DMTFSpecMeasurementValueType field of a DMTF measurement, with
+// the bit that indicates whether the value is a raw bit stream masked off.
+type SPDMMeasurementValueType uint8
+
+const (
+	SPDMMeasurementImmutableROM            SPDMMeasurementValueType = 0
+	SPDMMeasurementMutableFirmware         SPDMMeasurementValueType = 1
+	SPDMMeasurementHardwareConfig          SPDMMeasurementValueType = 2
+	SPDMMeasurementFirmwareConfig          SPDMMeasurementValueType = 3
+	SPDMMeasurementManifest                SPDMMeasurementValueType = 4
+	SPDMMeasurementDeviceMode              SPDMMeasurementValueType = 5
+	SPDMMeasurementFirmwareVersion         SPDMMeasurementValueType = 6
+	SPDMMeasurementFirmwareSecurityVersion SPDMMeasurementValueType = 7
+)
+
+func (t SPDMMeasurementValueType) String() string {
+	switch t {
+	case SPDMMeasurementImmutableROM:
+		return "immutable-rom"
+	case SPDMMeasurementMutableFirmware:
+		return "mutable-firmware"
+	case SPDMMeasurementHardwareConfig:
+		return "hardware-config"
+	case SPDMMeasurementFirmwareConfig:
+		return "firmware-config"
+	case SPDMMeasurementManifest:
+		return "manifest"
+	case SPDMMeasurementDeviceMode:
+		return "device-mode"
+	case SPDMMeasurementFirmwareVersion:
+		return "firmware-version"
+	case SPDMMeasurementFirmwareSecurityVersion:
+		return "firmware-security-version"
+	default:
+		return fmt.Sprintf("%#x", uint8(t))
+	}
+}
+
+// spdmMeasurementSpecificationDMTF is the bit of the MeasurementSpecification field of a measurement block that
+// indicates that the measurement is in the DMTF format.
+const spdmMeasurementSpecificationDMTF = 0x01
+
+// spdmMeasurementRawBitStream is the bit of the DMTFSpecMeasurementValueType field that indicates that the
+// measurement value is a raw bit stream rather than a digest.
+const spdmMeasurementRawBitStream = 0x80
+
+// SPDMMeasurementBlock corresponds to a measurement block from an SPDM MEASUREMENTS response.
+type SPDMMeasurementBlock struct {
+	Index                    uint8
+	MeasurementSpecification uint8
+	Measurement              []byte // The measurement as recorded in the block
+
+	// The following fields are only valid for measurements in the DMTF format.
+	IsDMTF       bool
+	ValueType    SPDMMeasurementValueType
+	RawBitStream bool   // The value is a raw bit stream rather than a digest
+	Value        []byte // The measurement value
+}
+
+// SPDMPCIDeviceContext corresponds to the TCG_DEVICE_SECURITY_EVENT_DATA_PCI_CONTEXT structure, which identifies a
+// PCI device that was measured with SPDM.
+type SPDMPCIDeviceContext struct {
+	VendorId          uint16
+	DeviceId          uint16
+	RevisionId        uint8
+	ClassCode         [3]uint8
+	SubsystemVendorId uint16
+	SubsystemId       uint16
+}
+
+// SPDMDeviceSecurityEventData corresponds to the TCG_DEVICE_SECURITY_EVENT_DATA and TCG_DEVICE_SECURITY_EVENT_DATA2
+// structures, which are the event data for EV_EFI_SPDM_FIRMWARE_BLOB and EV_EFI_SPDM_FIRMWARE_CONFIG events. These
+// record the measurements of a device's firmware and configuration that were obtained with SPDM, or the
+// certificate chain that the device was authenticated with.
+type SPDMDeviceSecurityEventData struct {
+	data          []byte
+	Type          EventType         // EventTypeEFISPDMFirmwareBlob or EventTypeEFISPDMFirmwareConfig
+	Version       uint16            // 1 for TCG_DEVICE_SECURITY_EVENT_DATA or 2 for TCG_DEVICE_SECURITY_EVENT_DATA2
+	AuthState     SPDMAuthState     // The authentication state, which is only recorded by version 2
+	DeviceType    SPDMDeviceType    // The bus of the device
+	DevicePath    EFIDevicePath     // The path of the device, which is only recorded by version 2
+	SubHeaderType SPDMSubHeaderType // Whether the event records measurement blocks or a certificate chain
+	SubHeaderUID  uint64            // Binds the measurement block and certificate chain events for a device
+	SPDMVersion   uint16            // The SPDM version negotiated with the device, only recorded by version 2
+
+	// HashAlgo is the SPDM hash algorithm of the measurement blocks or certificate chain, which is a bit
+	// from the MeasurementHashAlgo or BaseHashAlgo fields of the SPDM ALGORITHMS response.
+	HashAlgo uint32
+
+	MeasurementBlocks []SPDMMeasurementBlock // The measurement blocks, for SPDMSubHeaderMeasurementBlock
+
+	SlotId    uint8  // The certificate slot, for SPDMSubHeaderCertChain
+	CertChain []byte // The SPDM certificate chain, for SPDMSubHeaderCertChain
+
+	DeviceContext []byte                // The device context
+	PCIDevice     *SPDMPCIDeviceContext // The decoded device context for PCI devices
+}
+
+func (e *SPDMDeviceSecurityEventData) String() string {
+	var builder bytes.Buffer
+	if e.Version == 1 {
+		builder.WriteString("TCG_DEVICE_SECURITY_EVENT_DATA{ ")
+	} else {
+		builder.WriteString("TCG_DEVICE_SECURITY_EVENT_DATA2{ ")
+	}
+	if e.Version != 1 {
+		fmt.Fprintf(&builder, "AuthState: %s, ", e.AuthState)
+	}
+	fmt.Fprintf(&builder, "DeviceType: %s", e.DeviceType)
+	if e.PCIDevice != nil {
+		fmt.Fprintf(&builder, ", PCIDevice: %04x:%04x", e.PCIDevice.VendorId, e.PCIDevice.DeviceId)
+	}
+	if len(e.DevicePath) > 0 {
+		fmt.Fprintf(&builder, ", DevicePath: %s", e.DevicePath)
+	}
+	switch e.SubHeaderType {
+	case SPDMSubHeaderMeasurementBlock:
+		fmt.Fprintf(&builder, ", MeasurementBlocks: [")
+		for i, b := range e.MeasurementBlocks {
+			if i > 0 {
+				builder.WriteString(",")
+			}
+			if b.IsDMTF {
+				fmt.Fprintf(&builder, " %d (%s)", b.Index, b.ValueType)
+			} else {
+				fmt.Fprintf(&builder, " %d", b.Index)
+			}
+		}
+		builder.WriteString(" ]")
+	case SPDMSubHeaderCertChain:
+		fmt.Fprintf(&builder, ", SlotId: %d, CertChainSize: %d", e.SlotId, len(e.CertChain))
+	}
+	builder.WriteString(" }")
+	return builder.String()
+}
+
+func (e *SPDMDeviceSecurityEventData) Bytes() []byte {
+	return e.data
+}
+
+// https://www.dmtf.org/sites/default/files/standards/documents/DSP0274_1.2.1.pdf
+//  ("MEASUREMENTS response" and "Measurement block")
+func readSPDMMeasurementBlock(r io.Reader) (*SPDMMeasurementBlock, error) {
+	// Index, MeasurementSpecification, MeasurementSize
+	var hdr [4]byte
+	if _, err := io.ReadFull(r, hdr[:]); err != nil {
+		return nil, err
+	}
+	b := &SPDMMeasurementBlock{
+		Index:                    hdr[0],
+		MeasurementSpecification: hdr[1],
+		Measurement:              make([]byte, binary.LittleEndian.Uint16(hdr[2:]))}
+	if _, err := io.ReadFull(r, b.Measurement); err != nil {
+		return nil, err
+	}
+
+	if b.MeasurementSpecification&spdmMeasurementSpecificationDMTF == 0 || len(b.Measurement) < 3 {
+		return b, nil
+	}
+	size := binary.LittleEndian.Uint16(b.Measurement[1:])
+	if int(size) != len(b.Measurement)-3 {
+		return nil, fmt.Errorf("invalid DMTF measurement value size (got %d, expected %d)", size,
+			len(b.Measurement)-3)
+	}
+	b.IsDMTF = true
+	b.ValueType = SPDMMeasurementValueType(b.Measurement[0] &^ spdmMeasurementRawBitStream)
+	b.RawBitStream = b.Measurement[0]&spdmMeasurementRawBitStream != 0
+	b.Value = b.Measurement[3:]
+	return b, nil
+}
+
+func (e *SPDMDeviceSecurityEventData) decodeDeviceContext(data []byte) {
+	e.DeviceContext = data
+	if e.DeviceType != SPDMDeviceTypePCI || len(data) < 16 {
+		return
+	}
+
+	// TCG_DEVICE_SECURITY_EVENT_DATA_PCI_CONTEXT, following the Version and Length fields
+	ctx := &SPDMPCIDeviceContext{
+		VendorId:          binary.LittleEndian.Uint16(data[4:]),
+		DeviceId:          binary.LittleEndian.Uint16(data[6:]),
+		RevisionId:        data[8],
+		SubsystemVendorId: binary.LittleEndian.Uint16(data[12:]),
+		SubsystemId:       binary.LittleEndian.Uint16(data[14:])}
+	copy(ctx.ClassCode[:], data[9:12])
+	e.PCIDevice = ctx
+}
+
+// TCG_DEVICE_SECURITY_EVENT_DATA, from the TCG PC Client Platform Firmware Profile Specification version 1.06
+func (e *SPDMDeviceSecurityEventData) decodeVersion1(stream *bytes.Reader) error {
+	// TCG_DEVICE_SECURITY_EVENT_DATA_HEADER.{Length, SpdmHashAlgo, DeviceType}
+	var hdr [10]byte
+	if _, err := io.ReadFull(stream, hdr[:]); err != nil {
+		return err
+	}
+	e.HashAlgo = binary.LittleEndian.Uint32(hdr[2:])
+	e.DeviceType = SPDMDeviceType(binary.LittleEndian.Uint32(hdr[6:]))
+	e.SubHeaderType = SPDMSubHeaderMeasurementBlock
+
+	// TCG_DEVICE_SECURITY_EVENT_DATA_HEADER.SpdmMeasurementBlock
+	b, err := readSPDMMeasurementBlock(stream)
+	if err != nil {
+		return err
+	}
+	e.MeasurementBlocks = []SPDMMeasurementBlock{*b}
+
+	e.decodeDeviceContext(e.data[len(e.data)-stream.Len():])
+	return nil
+}
+
+// TCG_DEVICE_SECURITY_EVENT_DATA2, from the TCG PC Client Platform Firmware Profile Specification version 1.06
+func (e *SPDMDeviceSecurityEventData) decodeVersion2(stream *bytes.Reader) error {
+	// TCG_DEVICE_SECURITY_EVENT_DATA_HEADER2.{AuthState, Reserved, Length, DeviceType, SubHeaderType,
+	// SubHeaderLength, SubHeaderUID, DevicePathLength}
+	var hdr [34]byte
+	if _, err := io.ReadFull(stream, hdr[:]); err != nil {
+		return err
+	}
+	e.AuthState = SPDMAuthState(hdr[0])
+	e.DeviceType = SPDMDeviceType(binary.LittleEndian.Uint32(hdr[6:]))
+	e.SubHeaderType = SPDMSubHeaderType(binary.LittleEndian.Uint32(hdr[10:]))
+	subHeaderLength := binary.LittleEndian.Uint32(hdr[14:])
+	e.SubHeaderUID = binary.LittleEndian.Uint64(hdr[18:])
+	devicePathLength := binary.LittleEndian.Uint64(hdr[26:])
+
+	// TCG_DEVICE_SECURITY_EVENT_DATA_HEADER2.DevicePath
+	if devicePathLength > uint64(stream.Len()) {
+		return io.ErrUnexpectedEOF
+	}
+	path := make([]byte, devicePathLength)
+	if _, err := io.ReadFull(stream, path); err != nil {
+		return err
+	}
+	if len(path) > 0 {
+		var err error
+		if e.DevicePath, err = decodeDevicePath(path); err != nil {
+			return fmt.Errorf("cannot decode device path: %w", err)
+		}
+	}
+
+	// TCG_DEVICE_SECURITY_EVENT_DATA_SUB_HEADER
+	if int64(subHeaderLength) > int64(stream.Len()) {
+		return io.ErrUnexpectedEOF
+	}
+	subHeader := make([]byte, subHeaderLength)
+	if _, err := io.ReadFull(stream, subHeader); err != nil {
+		return err
+	}
+	sub := bytes.NewReader(subHeader)
+
+	switch e.SubHeaderType {
+	case SPDMSubHeaderMeasurementBlock:
+		// TCG_DEVICE_SECURITY_EVENT_DATA_SUB_HEADER_SPDM_MEASUREMENT_BLOCK.{SpdmVersion, NumberOfBlocks,
+		// Reserved, SpdmHashAlgo}
+		var s [8]byte
+		if _, err := io.ReadFull(sub, s[:]); err != nil {
+			return err
+		}
+		e.SPDMVersion = binary.LittleEndian.Uint16(s[0:])
+		e.HashAlgo = binary.LittleEndian.Uint32(s[4:])
+		for i := 0; i < int(s[2]); i++ {
+			b, err := readSPDMMeasurementBlock(sub)
+			if err != nil {
+				return fmt.Errorf("cannot decode measurement block %d: %w", i, err)
+			}
+			e.MeasurementBlocks = append(e.MeasurementBlocks, *b)
+		}
+	case SPDMSubHeaderCertChain:
+		// TCG_DEVICE_SECURITY_EVENT_DATA_SUB_HEADER_SPDM_CERT_CHAIN.{SpdmVersion, SpdmSlotId, Reserved,
+		// SpdmHashAlgo}
+		var s [8]byte
+		if _, err := io.ReadFull(sub, s[:]); err != nil {
+			return err
+		}
+		e.SPDMVersion = binary.LittleEndian.Uint16(s[0:])
+		e.SlotId = s[2]
+		e.HashAlgo = binary.LittleEndian.Uint32(s[4:])
+		e.CertChain = subHeader[len(subHeader)-sub.Len():]
+	default:
+		return fmt.Errorf("unrecognized sub header type %s", e.SubHeaderType)
+	}
+
+	e.decodeDeviceContext(e.data[len(e.data)-stream.Len():])
+	return nil
+}
+
+// decodeEventDataSPDMDeviceSecurity decodes the event data for EV_EFI_SPDM_FIRMWARE_BLOB and
+// EV_EFI_SPDM_FIRMWARE_CONFIG events.
+func decodeEventDataSPDMDeviceSecurity(eventType EventType, data []byte) (*SPDMDeviceSecurityEventData, int, error) {
+	stream := bytes.NewReader(data)
+
+	// TCG_DEVICE_SECURITY_EVENT_DATA_HEADER{,2}.{Signature, Version}
+	var hdr [18]byte
+	if _, err := io.ReadFull(stream, hdr[:]); err != nil {
+		return nil, 0, err
+	}
+	signature := string(hdr[:16])
+	version := binary.LittleEndian.Uint16(hdr[16:])
+
+	d := &SPDMDeviceSecurityEventData{data: data, Type: eventType, Version: version}
+	switch {
+	case signature == spdmDeviceSecurityEventSignature && version == 1:
+		if err := d.decodeVersion1(stream); err != nil {
+			return nil, 0, err
+		}
+	case signature == spdmDeviceSecurityEventSignature2 && version == 2:
+		if err := d.decodeVersion2(stream); err != nil {
+			return nil, 0, err
+		}
+	default:
+		return nil, 0, errors.New("invalid SPDM device security event signature or version")
+	}
+
+	// The device context consumes the remainder of the event data.
+	return d, 0, nil
+}
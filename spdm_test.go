@@ -0,0 +1,199 @@
+package tcglog
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func makeTestSPDMMeasurementBlock(index, valueType uint8, value []byte) []byte {
+	var b bytes.Buffer
+	b.WriteByte(index)
+	b.WriteByte(spdmMeasurementSpecificationDMTF)
+	binary.Write(&b, binary.LittleEndian, uint16(3+len(value)))
+	b.WriteByte(valueType)
+	binary.Write(&b, binary.LittleEndian, uint16(len(value)))
+	b.Write(value)
+	return b.Bytes()
+}
+
+func makeTestSPDMPCIContext() []byte {
+	var b bytes.Buffer
+	binary.Write(&b, binary.LittleEndian, struct {
+		Version           uint16
+		Length            uint16
+		VendorId          uint16
+		DeviceId          uint16
+		RevisionId        uint8
+		ClassCode         [3]uint8
+		SubsystemVendorId uint16
+		SubsystemId       uint16
+	}{0, 16, 0x8086, 0x1234, 1, [3]uint8{0x00, 0x08, 0x01}, 0x8086, 0x0001})
+	return b.Bytes()
+}
+
+func makeTestSPDMEventData2(subHeaderType SPDMSubHeaderType, devicePath, subHeader []byte) []byte {
+	context := makeTestSPDMPCIContext()
+
+	var b bytes.Buffer
+	b.WriteString(spdmDeviceSecurityEventSignature2)
+	binary.Write(&b, binary.LittleEndian, struct {
+		Version          uint16
+		AuthState        SPDMAuthState
+		Reserved         uint8
+		Length           uint32
+		DeviceType       SPDMDeviceType
+		SubHeaderType    SPDMSubHeaderType
+		SubHeaderLength  uint32
+		SubHeaderUID     uint64
+		DevicePathLength uint64
+	}{2, SPDMAuthStateSuccess, 0, uint32(len(devicePath) + len(subHeader) + len(context)), SPDMDeviceTypePCI,
+		subHeaderType, uint32(len(subHeader)), 5, uint64(len(devicePath))})
+	b.Write(devicePath)
+	b.Write(subHeader)
+	b.Write(context)
+	return b.Bytes()
+}
+
+func TestDecodeSPDMMeasurementBlockEvent(t *testing.T) {
+	var sub bytes.Buffer
+	binary.Write(&sub, binary.LittleEndian, struct {
+		SPDMVersion uint16
+		BlockCount  uint8
+		Reserved    uint8
+		HashAlgo    uint32
+	}{0x12, 2, 0, 0x2})
+	sub.Write(makeTestSPDMMeasurementBlock(1, uint8(SPDMMeasurementMutableFirmware), bytes.Repeat([]byte{0xaa}, 48)))
+	sub.Write(makeTestSPDMMeasurementBlock(2, uint8(SPDMMeasurementFirmwareVersion)|spdmMeasurementRawBitStream,
+		[]byte("1.2.3")))
+
+	// Pci(0x0,0x1) followed by the end of the device path
+	devicePath := []byte{0x01, 0x01, 0x06, 0x00, 0x01, 0x00, 0x7f, 0xff, 0x04, 0x00}
+
+	data := makeTestSPDMEventData2(SPDMSubHeaderMeasurementBlock, devicePath, sub.Bytes())
+	out, _ := decodeEventData(2, EventTypeEFISPDMFirmwareBlob, data, &LogOptions{}, false)
+	d, ok := out.(*SPDMDeviceSecurityEventData)
+	if !ok {
+		t.Fatalf("Unexpected event data %#v", out)
+	}
+	if d.Version != 2 || d.AuthState != SPDMAuthStateSuccess || d.DeviceType != SPDMDeviceTypePCI ||
+		d.SubHeaderUID != 5 || d.SPDMVersion != 0x12 || d.HashAlgo != 0x2 || len(d.DevicePath) == 0 {
+		t.Errorf("Unexpected header fields %#v", d)
+	}
+	if len(d.MeasurementBlocks) != 2 {
+		t.Fatalf("Unexpected number of measurement blocks %d", len(d.MeasurementBlocks))
+	}
+	b := d.MeasurementBlocks[0]
+	if !b.IsDMTF || b.Index != 1 || b.ValueType != SPDMMeasurementMutableFirmware || b.RawBitStream ||
+		!bytes.Equal(b.Value, bytes.Repeat([]byte{0xaa}, 48)) {
+		t.Errorf("Unexpected measurement block %#v", b)
+	}
+	b = d.MeasurementBlocks[1]
+	if b.ValueType != SPDMMeasurementFirmwareVersion || !b.RawBitStream || string(b.Value) != "1.2.3" {
+		t.Errorf("Unexpected measurement block %#v", b)
+	}
+	if d.PCIDevice == nil || d.PCIDevice.VendorId != 0x8086 || d.PCIDevice.DeviceId != 0x1234 {
+		t.Errorf("Unexpected PCI device %#v", d.PCIDevice)
+	}
+	if !strings.HasPrefix(d.String(), "TCG_DEVICE_SECURITY_EVENT_DATA2{ AuthState: success, DeviceType: pci, "+
+		"PCIDevice: 8086:1234") ||
+		!strings.HasSuffix(d.String(), "MeasurementBlocks: [ 1 (mutable-firmware), 2 (firmware-version) ] }") {
+		t.Errorf("Unexpected string %s", d)
+	}
+
+	j, err := json.Marshal(d)
+	if err != nil {
+		t.Fatalf("Marshal failed: %v", err)
+	}
+	if !strings.Contains(string(j), `"measurement":"312e322e33"`) {
+		t.Errorf("Unexpected JSON %s", j)
+	}
+}
+
+func TestDecodeSPDMCertChainEvent(t *testing.T) {
+	var sub bytes.Buffer
+	binary.Write(&sub, binary.LittleEndian, struct {
+		SPDMVersion uint16
+		SlotId      uint8
+		Reserved    uint8
+		HashAlgo    uint32
+	}{0x11, 1, 0, 0x1})
+	sub.WriteString("cert chain")
+
+	data := makeTestSPDMEventData2(SPDMSubHeaderCertChain, nil, sub.Bytes())
+	out, _ := decodeEventData(2, EventTypeEFISPDMFirmwareBlob, data, &LogOptions{}, false)
+	d, ok := out.(*SPDMDeviceSecurityEventData)
+	if !ok {
+		t.Fatalf("Unexpected event data %#v", out)
+	}
+	if d.SubHeaderType != SPDMSubHeaderCertChain || d.SlotId != 1 || string(d.CertChain) != "cert chain" ||
+		len(d.DevicePath) != 0 {
+		t.Errorf("Unexpected event data %#v", d)
+	}
+}
+
+func TestDecodeSPDMEventVersion1(t *testing.T) {
+	var b bytes.Buffer
+	b.WriteString(spdmDeviceSecurityEventSignature)
+	binary.Write(&b, binary.LittleEndian, struct {
+		Version    uint16
+		Length     uint16
+		HashAlgo   uint32
+		DeviceType SPDMDeviceType
+	}{1, 0, 0x1, SPDMDeviceTypeUSB})
+	b.Write(makeTestSPDMMeasurementBlock(3, uint8(SPDMMeasurementFirmwareConfig), make([]byte, 32)))
+	b.Write([]byte{0x00, 0x00, 0x04, 0x00})
+
+	out, _ := decodeEventData(3, EventTypeEFISPDMFirmwareConfig, b.Bytes(), &LogOptions{}, false)
+	d, ok := out.(*SPDMDeviceSecurityEventData)
+	if !ok {
+		t.Fatalf("Unexpected event data %#v", out)
+	}
+	if d.Version != 1 || d.DeviceType != SPDMDeviceTypeUSB || len(d.MeasurementBlocks) != 1 ||
+		d.PCIDevice != nil || len(d.DeviceContext) != 4 {
+		t.Errorf("Unexpected event data %#v", d)
+	}
+	if d.String() != "TCG_DEVICE_SECURITY_EVENT_DATA{ DeviceType: usb, MeasurementBlocks: [ 3 (firmware-config) ] }" {
+		t.Errorf("Unexpected string %s", d)
+	}
+}
+
+func TestDecodeSPDMEventInvalidSignature(t *testing.T) {
+	data := make([]byte, 64)
+	out, _ := decodeEventData(2, EventTypeEFISPDMFirmwareBlob, data, &LogOptions{}, false)
+	if _, ok := out.(*BrokenEventData); !ok {
+		t.Errorf("Unexpected event data %#v", out)
+	}
+}
+
+func TestDecodeSPDMDevicePolicyEvent(t *testing.T) {
+	var b bytes.Buffer
+	binary.Write(&b, binary.LittleEndian, *EFIImageSecurityDatabaseGUID)
+	binary.Write(&b, binary.LittleEndian, uint64(5))
+	binary.Write(&b, binary.LittleEndian, uint64(0))
+	binary.Write(&b, binary.LittleEndian, convertStringToUtf16("devdb"))
+
+	out, _ := decodeEventData(7, EventTypeEFISPDMDevicePolicy, b.Bytes(), &LogOptions{}, false)
+	if d, ok := out.(*EFIVariableEventData); !ok || d.UnicodeName != "devdb" {
+		t.Errorf("Unexpected event data %#v", out)
+	}
+}
+
+func TestDecodeSPDMEventTruncated(t *testing.T) {
+	var sub bytes.Buffer
+	sub.Write([]byte{0x12, 0x00, 0x01, 0x00, 0x02, 0x00, 0x00, 0x00})
+	sub.Write(makeTestSPDMMeasurementBlock(1, uint8(SPDMMeasurementMutableFirmware), make([]byte, 32)))
+	data := makeTestSPDMEventData2(SPDMSubHeaderMeasurementBlock, nil, sub.Bytes())
+
+	// The device context consumes the remainder of the event data, so only truncating the fixed headers and the
+	// sub header results in an error.
+	contextOffset := len(data) - len(makeTestSPDMPCIContext())
+	for n := 0; n < contextOffset; n++ {
+		out, _ := decodeEventData(2, EventTypeEFISPDMFirmwareBlob, data[:n], &LogOptions{}, false)
+		if _, ok := out.(*BrokenEventData); !ok {
+			t.Errorf("Unexpected event data for %d bytes: %#v", n, out)
+		}
+	}
+}
@@ -0,0 +1,198 @@
+package tcglog
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+)
+
+// PCRStability describes how stable the value of a PCR is expected to be across reboots and updates.
+type PCRStability int
+
+const (
+	PCRStabilityStable      PCRStability = iota // Not expected to change
+	PCRStabilityUntilUpdate                     // Expected to change only when measured components are updated
+	PCRStabilityVolatile                        // Expected to change between boots, even without updates
+)
+
+func (s PCRStability) String() string {
+	switch s {
+	case PCRStabilityStable:
+		return "stable"
+	case PCRStabilityUntilUpdate:
+		return "stable until update"
+	case PCRStabilityVolatile:
+		return "volatile"
+	default:
+		return fmt.Sprintf("PCRStability(%d)", int(s))
+	}
+}
+
+// PCRStabilityEstimate describes the expected stability of a single PCR.
+type PCRStabilityEstimate struct {
+	PCRIndex  PCRIndex
+	Stability PCRStability
+
+	// Score is between 0 and 1, with higher values indicating that the PCR is more stable. It combines the
+	// classification of the events measured to the PCR with the proportion of the supplied logs that agree
+	// on the most common sequence of measurements.
+	Score float64
+
+	// Recommended indicates whether the PCR is suitable for inclusion in sealing policies. PCRs that are
+	// stable until update are recommended, but policies that include them need to be updated along with the
+	// measured components.
+	Recommended bool
+
+	Reasons []string // Human readable explanations for the estimate
+}
+
+var pcrStabilityBaseScores = map[PCRStability]float64{
+	PCRStabilityStable:      1.0,
+	PCRStabilityUntilUpdate: 0.75,
+	PCRStabilityVolatile:    0.25,
+}
+
+// classifyEventStability returns the expected stability of the measurement made by an event of the specified
+// type, along with an explanation.
+func classifyEventStability(t EventType) (PCRStability, string) {
+	switch t {
+	case EventTypeSeparator, EventTypeAction, EventTypeEFIAction, EventTypeOmitBootDeviceEvents:
+		return PCRStabilityStable, ""
+	case EventTypeEFIVariableBoot, EventTypeEFIVariableBoot2:
+		return PCRStabilityVolatile, fmt.Sprintf("contains %s events, which change when the boot order or "+
+			"boot options are changed", t)
+	case EventTypePlatformConfigFlags, EventTypeEFIHandoffTables, EventTypeEFIHandoffTables2,
+		EventTypeTableOfDevices, EventTypeNonhostConfig, EventTypeEFISPDMFirmwareConfig:
+		return PCRStabilityVolatile, fmt.Sprintf("contains %s events, which measure platform configuration "+
+			"that can change between boots", t)
+	default:
+		return PCRStabilityUntilUpdate, fmt.Sprintf("contains %s events, which change when the measured "+
+			"components are updated", t)
+	}
+}
+
+// stabilityEventKey returns a key that identifies the measurement made by an event.
+func stabilityEventKey(event *Event) []byte {
+	var key bytes.Buffer
+	binary.Write(&key, binary.LittleEndian, event.EventType)
+	for _, alg := range sortedDigestAlgorithms(event.Digests) {
+		binary.Write(&key, binary.LittleEndian, alg)
+		key.Write(event.Digests[alg])
+	}
+	return key.Bytes()
+}
+
+// EstimatePCRStability estimates the stability of each PCR that is extended by the events in the supplied
+// logs, in order to provide guidance for which PCRs to include in sealing policies. Each element of logs
+// contains the events from one boot of the same platform. The estimate is based on the types of the events
+// measured to each PCR and, when more than one log is supplied, on where the measurements differ between logs.
+// The estimates are returned in ascending order of PCR index.
+func EstimatePCRStability(logs [][]*Event) ([]PCRStabilityEstimate, error) {
+	if len(logs) == 0 {
+		return nil, errors.New("no logs")
+	}
+
+	// Collect the measurements made to each PCR by each log.
+	measurements := make(map[PCRIndex][][]*Event)
+	for i, events := range logs {
+		for _, event := range events {
+			if !doesEventTypeExtendPCR(event.EventType) {
+				continue
+			}
+			if _, ok := measurements[event.PCRIndex]; !ok {
+				measurements[event.PCRIndex] = make([][]*Event, len(logs))
+			}
+			measurements[event.PCRIndex][i] = append(measurements[event.PCRIndex][i], event)
+		}
+	}
+
+	pcrs := make(map[PCRIndex]DigestMap)
+	for pcr := range measurements {
+		pcrs[pcr] = nil
+	}
+
+	var out []PCRStabilityEstimate
+	for _, pcr := range sortedPCRs(pcrs) {
+		estimate := PCRStabilityEstimate{PCRIndex: pcr}
+		seenReasons := make(map[string]bool)
+		addReason := func(reason string) {
+			if reason == "" || seenReasons[reason] {
+				return
+			}
+			seenReasons[reason] = true
+			estimate.Reasons = append(estimate.Reasons, reason)
+		}
+		degrade := func(s PCRStability) {
+			if s > estimate.Stability {
+				estimate.Stability = s
+			}
+		}
+
+		for _, events := range measurements[pcr] {
+			for _, event := range events {
+				s, reason := classifyEventStability(event.EventType)
+				degrade(s)
+				addReason(reason)
+			}
+		}
+
+		// Find the most common sequence of measurements, which is used as the reference for the other logs.
+		keys := make([][][]byte, len(logs))
+		seqs := make([]string, len(logs))
+		counts := make(map[string]int)
+		for i, events := range measurements[pcr] {
+			var seq bytes.Buffer
+			for _, event := range events {
+				k := stabilityEventKey(event)
+				keys[i] = append(keys[i], k)
+				seq.Write(k)
+			}
+			seqs[i] = seq.String()
+			counts[seqs[i]]++
+		}
+		ref := 0
+		refCount := 0
+		for i, seq := range seqs {
+			if counts[seq] > refCount {
+				ref, refCount = i, counts[seq]
+			}
+		}
+
+		for i, events := range measurements[pcr] {
+			if i == ref {
+				continue
+			}
+			refEvents := measurements[pcr][ref]
+			n := 0
+			for n < len(events) && n < len(refEvents) && bytes.Equal(keys[i][n], keys[ref][n]) {
+				n++
+			}
+			if n == len(events) && n == len(refEvents) {
+				continue
+			}
+
+			var changed *Event
+			if n < len(events) {
+				changed = events[n]
+			} else {
+				changed = refEvents[n]
+			}
+			s, _ := classifyEventStability(changed.EventType)
+			if s == PCRStabilityUntilUpdate {
+				addReason(fmt.Sprintf("differs in log %d from event %d (type: %s), possibly because of an "+
+					"update", i, changed.Index, changed.EventType))
+				continue
+			}
+			degrade(PCRStabilityVolatile)
+			addReason(fmt.Sprintf("differs in log %d from event %d (type: %s), which isn't explained by an "+
+				"update", i, changed.Index, changed.EventType))
+		}
+
+		estimate.Score = pcrStabilityBaseScores[estimate.Stability] * float64(refCount) / float64(len(logs))
+		estimate.Recommended = estimate.Stability != PCRStabilityVolatile
+		out = append(out, estimate)
+	}
+
+	return out, nil
+}
@@ -0,0 +1,68 @@
+package tcglog
+
+import (
+	"crypto/sha256"
+	"testing"
+)
+
+func TestEstimatePCRStability(t *testing.T) {
+	makeEvent := func(index uint, pcr PCRIndex, eventType EventType, data string) *Event {
+		h := sha256.Sum256([]byte(data))
+		return &Event{Index: index, PCRIndex: pcr, EventType: eventType, Digests: DigestMap{AlgorithmSha256: h[:]}}
+	}
+	makeLog := func(firmware, separator string) []*Event {
+		return []*Event{
+			makeEvent(0, 0, EventTypeEFIPlatformFirmwareBlob, firmware),
+			makeEvent(1, 7, EventTypeEFIVariableDriverConfig, "SecureBoot"),
+			makeEvent(2, 1, EventTypeEFIVariableBoot, "BootOrder"),
+			makeEvent(3, 0, EventTypeSeparator, "sep"),
+			makeEvent(4, 1, EventTypeSeparator, "sep"),
+			makeEvent(5, 7, EventTypeSeparator, separator),
+		}
+	}
+
+	estimates, err := EstimatePCRStability([][]*Event{
+		makeLog("fw1", "sep"),
+		makeLog("fw1", "sep"),
+		makeLog("fw2", "sep-error"),
+	})
+	if err != nil {
+		t.Fatalf("EstimatePCRStability failed: %v", err)
+	}
+
+	expected := []struct {
+		pcr         PCRIndex
+		stability   PCRStability
+		score       float64
+		recommended bool
+	}{
+		{pcr: 0, stability: PCRStabilityUntilUpdate, score: 0.5, recommended: true},
+		{pcr: 1, stability: PCRStabilityVolatile, score: 0.25, recommended: false},
+		{pcr: 7, stability: PCRStabilityVolatile, score: 0.25 * 2 / 3, recommended: false},
+	}
+	if len(estimates) != len(expected) {
+		t.Fatalf("Unexpected number of estimates: %d", len(estimates))
+	}
+	for i, e := range expected {
+		estimate := estimates[i]
+		if estimate.PCRIndex != e.pcr {
+			t.Errorf("Unexpected PCR %d", estimate.PCRIndex)
+		}
+		if estimate.Stability != e.stability {
+			t.Errorf("Unexpected stability for PCR %d: %s", e.pcr, estimate.Stability)
+		}
+		if estimate.Score != e.score {
+			t.Errorf("Unexpected score for PCR %d: %f", e.pcr, estimate.Score)
+		}
+		if estimate.Recommended != e.recommended {
+			t.Errorf("Unexpected recommendation for PCR %d", e.pcr)
+		}
+		if len(estimate.Reasons) == 0 {
+			t.Errorf("No reasons for PCR %d", e.pcr)
+		}
+	}
+
+	if _, err := EstimatePCRStability(nil); err == nil {
+		t.Errorf("EstimatePCRStability should fail with no logs")
+	}
+}
@@ -0,0 +1,317 @@
+package tcglog
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// LocalHost is the host name that refers to the machine on which the store is being used.
+const LocalHost = ""
+
+// ErrNotStored is returned from Store implementations when the requested record doesn't exist.
+var ErrNotStored = errors.New("record not stored")
+
+// StoredBoot describes a boot for which a Store contains a log.
+type StoredBoot struct {
+	Host    string    // The host that the log was obtained from
+	BootID  string    // The boot ID of the boot that the log was obtained from
+	ModTime time.Time // The time at which the log was stored
+}
+
+// Store is implemented by persistent storage for the logs and findings of previous boots and for the golden PCR
+// values of each host, so that features which compare boots or hosts can share the same records. Records are
+// identified by a host name and, for logs and findings, a boot ID. Implementations must return ErrNotStored
+// when a requested record doesn't exist.
+type Store interface {
+	// SaveLog stores the log for the specified boot. The log for a boot can't be changed once it has been
+	// stored, although storing the same log again succeeds.
+	SaveLog(host, bootID string, data []byte) error
+
+	// LoadLog returns the log for the specified boot.
+	LoadLog(host, bootID string) ([]byte, error)
+
+	// ListBoots returns the boots of the specified host for which a log is stored, ordered from the most
+	// recently stored.
+	ListBoots(host string) ([]StoredBoot, error)
+
+	// RemoveBoot removes the log and findings for the specified boot.
+	RemoveBoot(host, bootID string) error
+
+	// SaveFindings stores the findings for the specified boot, replacing any that were stored previously.
+	SaveFindings(host, bootID string, findings []Finding) error
+
+	// LoadFindings returns the findings for the specified boot. The Event field of each finding is not
+	// preserved.
+	LoadFindings(host, bootID string) ([]Finding, error)
+
+	// SaveGoldenValues stores the golden PCR values for the specified host, replacing any that were stored
+	// previously.
+	SaveGoldenValues(host string, values map[PCRIndex]DigestMap) error
+
+	// LoadGoldenValues returns the golden PCR values for the specified host.
+	LoadGoldenValues(host string) (map[PCRIndex]DigestMap, error)
+
+	// ListHosts returns the names of the hosts other than LocalHost for which records are stored, in
+	// lexical order.
+	ListHosts() ([]string, error)
+}
+
+const (
+	storeHostsDir        = "hosts"
+	storeFindingsSuffix  = ".findings.json"
+	storeGoldenValueFile = "golden.json"
+)
+
+type fileStore struct {
+	dir string
+}
+
+func isValidHostName(host string) bool {
+	if host == "" || host[0] == '.' {
+		return false
+	}
+	for _, c := range host {
+		if !(c >= '0' && c <= '9') && !(c >= 'a' && c <= 'z') && !(c >= 'A' && c <= 'Z') && c != '-' &&
+			c != '_' && c != '.' {
+			return false
+		}
+	}
+	return true
+}
+
+func (s *fileStore) hostDir(host string) (string, error) {
+	if host == LocalHost {
+		return s.dir, nil
+	}
+	if !isValidHostName(host) {
+		return "", fmt.Errorf("invalid host name \"%s\"", host)
+	}
+	return filepath.Join(s.dir, storeHostsDir, host), nil
+}
+
+func (s *fileStore) bootPath(host, bootID, suffix string) (string, error) {
+	if !isValidBootID(bootID) {
+		return "", fmt.Errorf("invalid boot ID \"%s\"", bootID)
+	}
+	dir, err := s.hostDir(host)
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, bootID+suffix), nil
+}
+
+func (s *fileStore) readFile(path string) ([]byte, error) {
+	data, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, ErrNotStored
+	}
+	return data, err
+}
+
+func (s *fileStore) writeFile(path string, data []byte) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return err
+	}
+	return writeFileAtomic(path, data, 0600)
+}
+
+func (s *fileStore) SaveLog(host, bootID string, data []byte) error {
+	path, err := s.bootPath(host, bootID, archivedLogSuffix)
+	if err != nil {
+		return err
+	}
+
+	existing, err := s.readFile(path)
+	switch {
+	case err == nil && bytes.Equal(existing, data):
+		return nil
+	case err == nil:
+		return errors.New("a different log has already been stored for this boot")
+	case err != ErrNotStored:
+		return err
+	}
+
+	return s.writeFile(path, data)
+}
+
+func (s *fileStore) LoadLog(host, bootID string) ([]byte, error) {
+	path, err := s.bootPath(host, bootID, archivedLogSuffix)
+	if err != nil {
+		return nil, err
+	}
+	return s.readFile(path)
+}
+
+func (s *fileStore) ListBoots(host string) ([]StoredBoot, error) {
+	dir, err := s.hostDir(host)
+	if err != nil {
+		return nil, err
+	}
+	entries, err := ioutil.ReadDir(dir)
+	switch {
+	case os.IsNotExist(err):
+		return nil, nil
+	case err != nil:
+		return nil, err
+	}
+
+	var boots []StoredBoot
+	for _, entry := range entries {
+		if !entry.Mode().IsRegular() || !strings.HasSuffix(entry.Name(), archivedLogSuffix) {
+			continue
+		}
+		bootID := strings.TrimSuffix(entry.Name(), archivedLogSuffix)
+		if !isValidBootID(bootID) {
+			continue
+		}
+		boots = append(boots, StoredBoot{Host: host, BootID: bootID, ModTime: entry.ModTime()})
+	}
+
+	sort.SliceStable(boots, func(i, j int) bool { return boots[i].ModTime.After(boots[j].ModTime) })
+	return boots, nil
+}
+
+func (s *fileStore) RemoveBoot(host, bootID string) error {
+	for _, suffix := range []string{archivedLogSuffix, storeFindingsSuffix} {
+		path, err := s.bootPath(host, bootID, suffix)
+		if err != nil {
+			return err
+		}
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+	return nil
+}
+
+// storedFinding is the representation of a Finding in a file store.
+type storedFinding struct {
+	Code     FindingCode `json:"code"`
+	Severity string      `json:"severity"`
+	Message  string      `json:"message"`
+}
+
+func (s *fileStore) SaveFindings(host, bootID string, findings []Finding) error {
+	path, err := s.bootPath(host, bootID, storeFindingsSuffix)
+	if err != nil {
+		return err
+	}
+	records := make([]storedFinding, 0, len(findings))
+	for _, f := range findings {
+		records = append(records, storedFinding{Code: f.Code, Severity: f.Severity.String(), Message: f.Message})
+	}
+	data, err := json.Marshal(records)
+	if err != nil {
+		return err
+	}
+	return s.writeFile(path, data)
+}
+
+func (s *fileStore) LoadFindings(host, bootID string) ([]Finding, error) {
+	path, err := s.bootPath(host, bootID, storeFindingsSuffix)
+	if err != nil {
+		return nil, err
+	}
+	data, err := s.readFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var records []storedFinding
+	if err := json.Unmarshal(data, &records); err != nil {
+		return nil, fmt.Errorf("cannot decode findings: %w", err)
+	}
+	var findings []Finding
+	for _, r := range records {
+		severity, err := ParseFindingSeverity(r.Severity)
+		if err != nil {
+			return nil, fmt.Errorf("cannot decode findings: %w", err)
+		}
+		findings = append(findings, Finding{Code: r.Code, Severity: severity, Message: r.Message})
+	}
+	return findings, nil
+}
+
+func (s *fileStore) SaveGoldenValues(host string, values map[PCRIndex]DigestMap) error {
+	dir, err := s.hostDir(host)
+	if err != nil {
+		return err
+	}
+	for pcr, digests := range values {
+		for alg := range digests {
+			if !alg.supported() {
+				return fmt.Errorf("cannot store value for PCR %d: unsupported algorithm %s", pcr, alg)
+			}
+		}
+	}
+	data, err := json.Marshal(values)
+	if err != nil {
+		return err
+	}
+	return s.writeFile(filepath.Join(dir, storeGoldenValueFile), data)
+}
+
+func (s *fileStore) LoadGoldenValues(host string) (map[PCRIndex]DigestMap, error) {
+	dir, err := s.hostDir(host)
+	if err != nil {
+		return nil, err
+	}
+	data, err := s.readFile(filepath.Join(dir, storeGoldenValueFile))
+	if err != nil {
+		return nil, err
+	}
+
+	var records map[PCRIndex]map[string]string
+	if err := json.Unmarshal(data, &records); err != nil {
+		return nil, fmt.Errorf("cannot decode golden values: %w", err)
+	}
+	values := make(map[PCRIndex]DigestMap)
+	for pcr, digests := range records {
+		values[pcr] = DigestMap{}
+		for name, digest := range digests {
+			alg, err := ParseAlgorithm(name)
+			if err != nil {
+				return nil, fmt.Errorf("cannot decode golden values: %w", err)
+			}
+			values[pcr][alg], err = hex.DecodeString(digest)
+			if err != nil {
+				return nil, fmt.Errorf("cannot decode golden value for PCR %d, bank %s: %w", pcr, alg, err)
+			}
+		}
+	}
+	return values, nil
+}
+
+func (s *fileStore) ListHosts() ([]string, error) {
+	entries, err := ioutil.ReadDir(filepath.Join(s.dir, storeHostsDir))
+	switch {
+	case os.IsNotExist(err):
+		return nil, nil
+	case err != nil:
+		return nil, err
+	}
+
+	var hosts []string
+	for _, entry := range entries {
+		if entry.IsDir() && isValidHostName(entry.Name()) {
+			hosts = append(hosts, entry.Name())
+		}
+	}
+	return hosts, nil
+}
+
+// NewFileStore returns a Store that keeps records in files beneath dir. Records for LocalHost are kept in dir
+// itself, using the same layout as ArchiveLog, and records for other hosts are kept in a subdirectory for each
+// host.
+func NewFileStore(dir string) Store {
+	return &fileStore{dir: dir}
+}
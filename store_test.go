@@ -0,0 +1,114 @@
+package tcglog
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestFileStore(t *testing.T) {
+	dir, err := ioutil.TempDir("", "tcglog-store-test")
+	if err != nil {
+		t.Fatalf("TempDir failed: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	store := NewFileStore(dir)
+	bootID := "0a1b2c3d-0000-0000-0000-000000000001"
+	log := makeTestCryptoAgileLog(t, 2)
+
+	for _, host := range []string{LocalHost, "host1.example.com"} {
+		if _, err := store.LoadLog(host, bootID); err != ErrNotStored {
+			t.Errorf("Unexpected error for a log that isn't stored: %v", err)
+		}
+		if err := store.SaveLog(host, bootID, log); err != nil {
+			t.Fatalf("SaveLog failed: %v", err)
+		}
+		if err := store.SaveLog(host, bootID, log); err != nil {
+			t.Errorf("Storing the same log twice failed: %v", err)
+		}
+		if err := store.SaveLog(host, bootID, log[:len(log)-1]); err == nil {
+			t.Errorf("SaveLog should fail for a different log for the same boot")
+		}
+		data, err := store.LoadLog(host, bootID)
+		if err != nil {
+			t.Fatalf("LoadLog failed: %v", err)
+		}
+		if !bytes.Equal(data, log) {
+			t.Errorf("Unexpected log")
+		}
+
+		boots, err := store.ListBoots(host)
+		if err != nil {
+			t.Fatalf("ListBoots failed: %v", err)
+		}
+		if len(boots) != 1 || boots[0].Host != host || boots[0].BootID != bootID {
+			t.Errorf("Unexpected boots: %v", boots)
+		}
+
+		findings := []Finding{
+			{Code: FindingBankDowngrade, Severity: FindingSeverityError, Message: "foo"},
+			{Code: FindingPCRReset, Severity: FindingSeverityInfo, Message: "bar"}}
+		if err := store.SaveFindings(host, bootID, findings); err != nil {
+			t.Fatalf("SaveFindings failed: %v", err)
+		}
+		loaded, err := store.LoadFindings(host, bootID)
+		if err != nil {
+			t.Fatalf("LoadFindings failed: %v", err)
+		}
+		if !reflect.DeepEqual(loaded, findings) {
+			t.Errorf("Unexpected findings: %v", loaded)
+		}
+
+		values := map[PCRIndex]DigestMap{
+			0: {AlgorithmSha1: AlgorithmSha1.hash([]byte("foo")), AlgorithmSha256: AlgorithmSha256.hash([]byte("foo"))},
+			7: {AlgorithmSha256: AlgorithmSha256.hash([]byte("bar"))}}
+		if err := store.SaveGoldenValues(host, values); err != nil {
+			t.Fatalf("SaveGoldenValues failed: %v", err)
+		}
+		loadedValues, err := store.LoadGoldenValues(host)
+		if err != nil {
+			t.Fatalf("LoadGoldenValues failed: %v", err)
+		}
+		if !reflect.DeepEqual(loadedValues, values) {
+			t.Errorf("Unexpected golden values: %v", loadedValues)
+		}
+
+		if err := store.RemoveBoot(host, bootID); err != nil {
+			t.Fatalf("RemoveBoot failed: %v", err)
+		}
+		if _, err := store.LoadFindings(host, bootID); err != ErrNotStored {
+			t.Errorf("Unexpected error for findings that aren't stored: %v", err)
+		}
+	}
+
+	// Logs for the local host use the same layout as ArchiveLog.
+	logPath := filepath.Join(dir, "log")
+	if err := ioutil.WriteFile(logPath, log, 0600); err != nil {
+		t.Fatalf("WriteFile failed: %v", err)
+	}
+	if _, err := ArchiveLog(dir, logPath, bootID); err != nil {
+		t.Fatalf("ArchiveLog failed: %v", err)
+	}
+	if _, err := store.LoadLog(LocalHost, bootID); err != nil {
+		t.Errorf("LoadLog failed for an archived log: %v", err)
+	}
+
+	hosts, err := store.ListHosts()
+	if err != nil {
+		t.Fatalf("ListHosts failed: %v", err)
+	}
+	if len(hosts) != 1 || hosts[0] != "host1.example.com" {
+		t.Errorf("Unexpected hosts: %v", hosts)
+	}
+
+	if err := store.SaveLog("../foo", bootID, log); err == nil {
+		t.Errorf("SaveLog should fail with an invalid host name")
+	}
+	if err := store.SaveLog(LocalHost, "../foo", log); err == nil {
+		t.Errorf("SaveLog should fail with an invalid boot ID")
+	}
+}
@@ -0,0 +1,69 @@
+package tcglog
+
+import (
+	"fmt"
+	"strings"
+)
+
+// MeasuredStringSource describes where a MeasuredString was obtained from.
+type MeasuredStringSource int
+
+const (
+	MeasuredStringAction          MeasuredStringSource = iota // EV_ACTION or EV_EFI_ACTION event
+	MeasuredStringGrubCommand                                 // Command executed by GRUB
+	MeasuredStringGrubFile                                    // File loaded by GRUB
+	MeasuredStringKernelCmdline                               // Kernel commandline measured by GRUB or systemd's EFI stub
+	MeasuredStringEFIVariableName                             // Name of a measured EFI variable
+)
+
+func (s MeasuredStringSource) String() string {
+	switch s {
+	case MeasuredStringAction:
+		return "action"
+	case MeasuredStringGrubCommand:
+		return "grub_cmd"
+	case MeasuredStringGrubFile:
+		return "grub_file"
+	case MeasuredStringKernelCmdline:
+		return "kernel_cmdline"
+	case MeasuredStringEFIVariableName:
+		return "efi_variable_name"
+	default:
+		return fmt.Sprintf("MeasuredStringSource(%d)", int(s))
+	}
+}
+
+// MeasuredString corresponds to a free-form string recorded with an event.
+type MeasuredString struct {
+	Event  *Event               // The event that this string was obtained from
+	Source MeasuredStringSource // Where this string was obtained from
+	Str    string
+}
+
+// ExtractStrings returns every free-form string recorded in the supplied events, such as actions, GRUB
+// commands, kernel commandlines and EFI variable names, along with a reference to the event that each
+// string was obtained from. This is useful for indexing the contents of logs.
+func ExtractStrings(events []*Event) (out []MeasuredString) {
+	for _, event := range events {
+		switch d := event.Data.(type) {
+		case *asciiStringEventData:
+			// This is only decoded for EV_ACTION and EV_EFI_ACTION events.
+			str := strings.TrimRight(d.String(), "\x00")
+			out = append(out, MeasuredString{Event: event, Source: MeasuredStringAction, Str: str})
+		case *GrubFileEventData:
+			out = append(out, MeasuredString{Event: event, Source: MeasuredStringGrubFile, Str: d.Path})
+		case *GrubStringEventData:
+			source := MeasuredStringGrubCommand
+			if d.Type == KernelCmdline {
+				source = MeasuredStringKernelCmdline
+			}
+			out = append(out, MeasuredString{Event: event, Source: source, Str: d.Str})
+		case *SystemdEFIStubEventData:
+			out = append(out, MeasuredString{Event: event, Source: MeasuredStringKernelCmdline, Str: d.Str})
+		case *EFIVariableEventData:
+			out = append(out, MeasuredString{Event: event, Source: MeasuredStringEFIVariableName,
+				Str: d.UnicodeName})
+		}
+	}
+	return
+}
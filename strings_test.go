@@ -0,0 +1,60 @@
+package tcglog
+
+import (
+	"testing"
+)
+
+func TestExtractStrings(t *testing.T) {
+	options := &LogOptions{
+		EnableGrub:           true,
+		EnableSystemdEFIStub: true,
+		SystemdEFIStubPCR:    12}
+
+	makeEvent := func(pcr PCRIndex, eventType EventType, data []byte) *Event {
+		d, _ := decodeEventData(pcr, eventType, data, options, false)
+		return &Event{PCRIndex: pcr, EventType: eventType, Data: d}
+	}
+
+	events := []*Event{
+		makeEvent(4, EventTypeEFIAction, []byte("Calling EFI Application from Boot Option")),
+		makeEvent(8, EventTypeIPL, []byte("grub_cmd: linux /vmlinuz\x00")),
+		makeEvent(8, EventTypeIPL, []byte("kernel_cmdline: /vmlinuz ro\x00")),
+		makeEvent(9, EventTypeIPL, []byte("/boot/vmlinuz\x00")),
+		// An EV_IPL event that isn't decoded as GRUB event data shouldn't be labelled as a GRUB file.
+		makeEvent(10, EventTypeIPL, []byte("foo\x00")),
+		makeEvent(12, EventTypeIPL, []byte("c\x00o\x00n\x00s\x00o\x00l\x00e\x00=\x00t\x00t\x00y\x00S\x000\x00\x00")),
+		{PCRIndex: 7, EventType: EventTypeEFIVariableDriverConfig,
+			Data: &EFIVariableEventData{VariableName: *EFIGlobalVariableGUID, UnicodeName: "SecureBoot",
+				VariableData: []byte{1}}},
+		makeEvent(4, EventTypeEFIBootServicesApplication, nil),
+	}
+
+	expected := []struct {
+		event  int
+		source MeasuredStringSource
+		str    string
+	}{
+		{0, MeasuredStringAction, "Calling EFI Application from Boot Option"},
+		{1, MeasuredStringGrubCommand, "linux /vmlinuz"},
+		{2, MeasuredStringKernelCmdline, "/vmlinuz ro"},
+		{3, MeasuredStringGrubFile, "/boot/vmlinuz"},
+		{5, MeasuredStringKernelCmdline, "console=ttyS0"},
+		{6, MeasuredStringEFIVariableName, "SecureBoot"},
+	}
+
+	strs := ExtractStrings(events)
+	if len(strs) != len(expected) {
+		t.Fatalf("Unexpected number of strings (%d): %v", len(strs), strs)
+	}
+	for i, s := range strs {
+		if s.Event != events[expected[i].event] {
+			t.Errorf("Unexpected event for string %d", i)
+		}
+		if s.Source != expected[i].source {
+			t.Errorf("Unexpected source %s for string %d", s.Source, i)
+		}
+		if s.Str != expected[i].str {
+			t.Errorf("Unexpected string %q for string %d", s.Str, i)
+		}
+	}
+}
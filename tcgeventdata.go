@@ -6,7 +6,7 @@ import (
 	"fmt"
 	"io"
 	"math"
-	"unsafe"
+	"unicode"
 )
 
 type invalidSpecIdEventError struct {
@@ -127,24 +127,18 @@ type specIdEventCommon struct {
 // https://trustedcomputinggroup.org/wp-content/uploads/TCG_PCClientSpecPlat_TPM_2p0_1p04_pub.pdf
 //  (secion 9.4.5.1 "Specification ID Version Event")
 func decodeSpecIdEvent(stream io.Reader, data []byte, helper func(io.Reader, *SpecIdEventData) error) (*SpecIdEventData, error) {
-	var common struct{
-		PlatformClass uint32
-		SpecVersionMinor uint8
-		SpecVersionMajor uint8
-		SpecErrata uint8
-		UintnSize uint8
-	}
-	if err := binary.Read(stream, binary.LittleEndian, &common); err != nil {
+	var common [8]byte
+	if _, err := io.ReadFull(stream, common[:]); err != nil {
 		return nil, wrapSpecIdEventReadError(err)
 	}
 
 	eventData := &SpecIdEventData{
 		data:             data,
-		PlatformClass:    common.PlatformClass,
-		SpecVersionMinor: common.SpecVersionMinor,
-		SpecVersionMajor: common.SpecVersionMajor,
-		SpecErrata:       common.SpecErrata,
-		UintnSize:	  common.UintnSize}
+		PlatformClass:    binary.LittleEndian.Uint32(common[0:]),
+		SpecVersionMinor: common[4],
+		SpecVersionMajor: common[5],
+		SpecErrata:       common[6],
+		UintnSize:        common[7]}
 
 	if err := helper(stream, eventData); err != nil {
 		return nil, err
@@ -162,13 +156,141 @@ type asciiStringEventData struct {
 }
 
 func (e *asciiStringEventData) String() string {
-	return *(*string)(unsafe.Pointer(&e.data))
+	return string(e.data)
 }
 
 func (e *asciiStringEventData) Bytes() []byte {
 	return e.data
 }
 
+// PostCodeStringEventData corresponds to the event data for EV_POST_CODE events that are recorded in the ASCII
+// string form, which describes the measured code or data rather than its location.
+type PostCodeStringEventData struct {
+	data        []byte
+	Description string // The description of the measurement, eg, "POST CODE" or "ACPI DATA"
+}
+
+func (e *PostCodeStringEventData) String() string {
+	return e.Description
+}
+
+func (e *PostCodeStringEventData) Bytes() []byte {
+	return e.data
+}
+
+// https://trustedcomputinggroup.org/wp-content/uploads/TCG_PCClientSpecPlat_TPM_2p0_1p04_pub.pdf
+//  (section 9.4.1 "Event Types", section 9.4.2 "EV_POST_CODE Event Types")
+func decodeEventDataPostCode(data []byte) (out EventData, trailingBytes int, err error) {
+	// The string form may have a NULL terminator, even though the specification doesn't require one.
+	str := bytes.TrimRight(data, "\x00")
+	if len(str) > 0 && isPrintableASCII(str) {
+		return &PostCodeStringEventData{data: data, Description: string(str)}, 0, nil
+	}
+
+	d, n, err := decodeEventDataEFIPlatformFirmwareBlob(data)
+	if err != nil {
+		return nil, 0, fmt.Errorf("event data is neither a UEFI_PLATFORM_FIRMWARE_BLOB structure or a "+
+			"string: %w", err)
+	}
+	return d, n, nil
+}
+
+// SCRTMVersionEventData corresponds to the event data for EV_S_CRTM_VERSION events, which identifies the
+// version of the SRTM. This is either a string or a GUID.
+type SCRTMVersionEventData struct {
+	data    []byte
+	Version string   // The version string, if the event data is a string
+	GUID    *EFIGUID // The version GUID, if the event data is a GUID
+}
+
+func (e *SCRTMVersionEventData) String() string {
+	if e.GUID != nil {
+		return e.GUID.String()
+	}
+	return e.Version
+}
+
+func (e *SCRTMVersionEventData) Bytes() []byte {
+	return e.data
+}
+
+// decodeUCS2String decodes data as a NULL terminated UCS-2 string, which may be empty, returning false if it
+// isn't one or if it contains unprintable characters.
+func decodeUCS2String(data []byte) (string, bool) {
+	if len(data) < 2 || len(data)%2 != 0 {
+		return "", false
+	}
+	chars := make([]uint16, len(data)/2)
+	binary.Read(bytes.NewReader(data), binary.LittleEndian, chars)
+
+	n := len(chars)
+	for n > 0 && chars[n-1] == 0 {
+		n--
+	}
+	if n == len(chars) {
+		return "", false
+	}
+	str := convertUtf16ToString(chars[:n])
+	for _, r := range str {
+		if !unicode.IsPrint(r) {
+			return "", false
+		}
+	}
+	return str, true
+}
+
+// https://trustedcomputinggroup.org/wp-content/uploads/TCG_PCClientSpecPlat_TPM_2p0_1p04_pub.pdf
+//  (section 9.4.1 "Event Types")
+func decodeEventDataSCRTMVersion(data []byte) (out EventData, trailingBytes int, err error) {
+	if str, ok := decodeUCS2String(data); ok {
+		return &SCRTMVersionEventData{data: data, Version: str}, 0, nil
+	}
+
+	if len(data) == efiGUIDSize {
+		guid := decodeEFIGUID(data)
+		return &SCRTMVersionEventData{data: data, GUID: &guid}, 0, nil
+	}
+
+	// Some firmware records the version as an ASCII string, even though the specification doesn't permit
+	// this.
+	if str := bytes.TrimRight(data, "\x00"); len(str) > 0 && isPrintableASCII(str) {
+		return &SCRTMVersionEventData{data: data, Version: string(str)}, 0, nil
+	}
+
+	// The version is otherwise in a format that isn't understood, but the event digest can still be
+	// validated.
+	return nil, 0, nil
+}
+
+// CompactHashEventData corresponds to the event data for EV_COMPACT_HASH events. These are commonly used by
+// platform vendors for measurements to PCR 6. The digest is of data supplied by the measuring component, which
+// isn't recorded in the log, and the event data is only informative.
+type CompactHashEventData struct {
+	data        []byte
+	Description string // The event data as a string, if it is printable ASCII
+}
+
+func (e *CompactHashEventData) String() string {
+	if e.Description != "" {
+		return e.Description
+	}
+	return fmt.Sprintf("%x", e.data)
+}
+
+func (e *CompactHashEventData) Bytes() []byte {
+	return e.data
+}
+
+// https://trustedcomputinggroup.org/wp-content/uploads/TCG_PCClientSpecPlat_TPM_2p0_1p04_pub.pdf
+//  (section 9.4.1 "Event Types")
+func decodeEventDataCompactHash(data []byte) (*CompactHashEventData, int, error) {
+	d := &CompactHashEventData{data: data}
+	if str := bytes.TrimRight(data, "\x00"); len(str) > 0 && isPrintableASCII(str) {
+		d.Description = string(str)
+	}
+	return d, 0, nil
+}
+
 type unknownNoActionEventData struct {
 	data []byte
 }
@@ -198,7 +320,7 @@ func decodeEventDataNoAction(data []byte) (out EventData, trailingBytes int, err
 		return nil, 0, err
 	}
 
-	switch *(*string)(unsafe.Pointer(&signature)) {
+	switch string(signature) {
 	case "Spec ID Event00\x00":
 		d, e := decodeSpecIdEvent(stream, data, parsePCClientSpecIdEvent)
 		if d != nil {
@@ -217,8 +339,15 @@ func decodeEventDataNoAction(data []byte) (out EventData, trailingBytes int, err
 			out = d
 		}
 		err = e
-	case "SP800-155 Event\x00":
-		d, e := decodeBIMReferenceManifestEvent(stream, data)
+	case "SP800-155 Event\x00", "SP800-155 Event2", "SP800-155 Event3":
+		version := 1
+		switch signature[15] {
+		case '2':
+			version = 2
+		case '3':
+			version = 3
+		}
+		d, e := decodeBIMReferenceManifestEvent(stream, data, version)
 		if d != nil {
 			out = d
 		}
@@ -262,25 +391,147 @@ func decodeEventDataSeparator(data []byte, isError bool) (*separatorEventData, i
 	return &separatorEventData{data: data, isError: isError}, 0, nil
 }
 
+// TaggedEvent corresponds to a TCG_PCClientTaggedEvent structure.
+type TaggedEvent struct {
+	EventID uint32
+	Data    []byte
+
+	// Nested contains the tagged events that Data consists of, if it consists entirely of a sequence of
+	// TCG_PCClientTaggedEvent structures. This is common for option ROM configuration measured to PCR 1.
+	Nested []*TaggedEvent
+}
+
+func (e *TaggedEvent) String() string {
+	var builder bytes.Buffer
+	fmt.Fprintf(&builder, "TCG_PCClientTaggedEvent{ taggedEventID=0x%08x, taggedEventDataSize=%d", e.EventID,
+		len(e.Data))
+	if len(e.Nested) > 0 {
+		builder.WriteString(", nested=[")
+		for i, n := range e.Nested {
+			if i > 0 {
+				builder.WriteString(", ")
+			}
+			builder.WriteString(n.String())
+		}
+		builder.WriteString("]")
+	}
+	builder.WriteString(" }")
+	return builder.String()
+}
+
+// TaggedEventData corresponds to the event data for an EV_EVENT_TAG event that isn't recognized by a more
+// specific decoder.
+type TaggedEventData struct {
+	data []byte
+	TaggedEvent
+}
+
+func (e *TaggedEventData) String() string {
+	return e.TaggedEvent.String()
+}
+
+func (e *TaggedEventData) Bytes() []byte {
+	return e.data
+}
+
+// maxTaggedEventDepth limits how deeply tagged events are decoded recursively.
+const maxTaggedEventDepth = 8
+
+// decodeTaggedEvent decodes a single TCG_PCClientTaggedEvent structure from the start of data, and returns the
+// number of bytes consumed.
+func decodeTaggedEvent(data []byte, depth int) (*TaggedEvent, int, error) {
+	if len(data) < 8 {
+		return nil, 0, io.ErrUnexpectedEOF
+	}
+
+	id := binary.LittleEndian.Uint32(data[0:])
+	size := binary.LittleEndian.Uint32(data[4:])
+	if int64(size) > int64(len(data)-8) {
+		return nil, 0, fmt.Errorf("taggedEventDataSize is too large (%d)", size)
+	}
+
+	e := &TaggedEvent{EventID: id, Data: data[8 : 8+size]}
+	if depth < maxTaggedEventDepth {
+		e.Nested = decodeNestedTaggedEvents(e.Data, depth+1)
+	}
+	return e, 8 + int(size), nil
+}
+
+// decodeNestedTaggedEvents decodes data as a sequence of TCG_PCClientTaggedEvent structures, returning nil if
+// it doesn't consist entirely of tagged events.
+func decodeNestedTaggedEvents(data []byte, depth int) (out []*TaggedEvent) {
+	for len(data) > 0 {
+		e, n, err := decodeTaggedEvent(data, depth)
+		if err != nil {
+			return nil
+		}
+		out = append(out, e)
+		data = data[n:]
+	}
+	return out
+}
+
+// https://trustedcomputinggroup.org/wp-content/uploads/TCG_PCClientImplementation_1-21_1_00.pdf
+//  (section 11.3.2.1 "Event Tagging Structures")
+func decodeEventDataTaggedEvent(data []byte) (out EventData, trailingBytes int, err error) {
+	e, n, err := decodeTaggedEvent(data, 0)
+	if err != nil {
+		// EV_EVENT_TAG events are measured by many components that don't all conform to the
+		// specification, so treat data that isn't a tagged event as opaque rather than broken.
+		return nil, 0, nil
+	}
+	return &TaggedEventData{data: data, TaggedEvent: *e}, len(data) - n, nil
+}
+
 // https://trustedcomputinggroup.org/wp-content/uploads/TCG_PCClientImplementation_1-21_1_00.pdf (section 11.3.1 "Event Types")
 // https://trustedcomputinggroup.org/wp-content/uploads/TCG_EFI_Platform_1_22_Final_-v15.pdf (section 7.2 "Event Types")
 // https://trustedcomputinggroup.org/wp-content/uploads/TCG_PCClientSpecPlat_TPM_2p0_1p04_pub.pdf (section 9.4.1 "Event Types")
 func decodeEventDataTCG(eventType EventType, data []byte,
 	hasDigestOfSeparatorError bool) (out EventData, trailingBytes int, err error) {
 	switch eventType {
+	case EventTypePostCode:
+		return decodeEventDataPostCode(data)
 	case EventTypeNoAction:
 		return decodeEventDataNoAction(data)
+	case EventTypeSCRTMVersion:
+		return decodeEventDataSCRTMVersion(data)
+	case EventTypeCompactHash:
+		return decodeEventDataCompactHash(data)
+	case EventTypePlatformConfigFlags:
+		return decodeEventDataPlatformConfigFlags(data)
+	case EventTypeOmitBootDeviceEvents:
+		return decodeEventDataOmitBootDeviceEvents(data)
+	case EventTypeCPUMicrocode:
+		return decodeEventDataCPUMicrocode(data)
+	case EventTypeNonhostCode, EventTypeNonhostConfig, EventTypeNonhostInfo:
+		return decodeEventDataNonHost(eventType, data)
 	case EventTypeSeparator:
 		return decodeEventDataSeparator(data, hasDigestOfSeparatorError)
 	case EventTypeAction, EventTypeEFIAction:
 		return decodeEventDataAction(data)
-	case EventTypeEFIVariableDriverConfig, EventTypeEFIVariableBoot, EventTypeEFIVariableAuthority:
+	case EventTypeEFIVariableDriverConfig, EventTypeEFIVariableBoot, EventTypeEFIVariableBoot2,
+		EventTypeEFIVariableAuthority, EventTypeEFISPDMDevicePolicy, EventTypeEFISPDMDeviceAuthority:
 		return decodeEventDataEFIVariable(data, eventType)
+	case EventTypeEFISPDMFirmwareBlob, EventTypeEFISPDMFirmwareConfig:
+		return decodeEventDataSPDMDeviceSecurity(eventType, data)
 	case EventTypeEFIBootServicesApplication, EventTypeEFIBootServicesDriver,
 		EventTypeEFIRuntimeServicesDriver:
 		return decodeEventDataEFIImageLoad(data)
 	case EventTypeEFIGPTEvent:
 		return decodeEventDataEFIGPT(data)
+	case EventTypeEFIPlatformFirmwareBlob:
+		return decodeEventDataEFIPlatformFirmwareBlob(data)
+	case EventTypeEFIPlatformFirmwareBlob2:
+		return decodeEventDataEFIPlatformFirmwareBlob2(data)
+	case EventTypeEFIHandoffTables:
+		return decodeEventDataEFIHandoffTables(data)
+	case EventTypeEFIHandoffTables2:
+		return decodeEventDataEFIHandoffTables2(data)
+	case EventTypeEventTag:
+		if d, n := decodeEventDataLinuxEFIStub(data); d != nil {
+			return d, n, nil
+		}
+		return decodeEventDataTaggedEvent(data)
 	default:
 	}
 	return nil, 0, nil
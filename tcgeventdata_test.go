@@ -0,0 +1,219 @@
+package tcglog
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"testing"
+)
+
+func TestDecodeEventDataPostCode(t *testing.T) {
+	for _, data := range []struct {
+		desc  string
+		data  []byte
+		descr string
+	}{
+		{desc: "POST CODE", data: []byte("POST CODE"), descr: "POST CODE"},
+		{desc: "ACPI DATA", data: []byte("ACPI DATA\x00"), descr: "ACPI DATA"},
+		{desc: "Embedded UEFI Driver", data: []byte("Embedded UEFI Driver"), descr: "Embedded UEFI Driver"},
+	} {
+		t.Run(data.desc, func(t *testing.T) {
+			out, _, err := decodeEventDataTCG(EventTypePostCode, data.data, false)
+			if err != nil {
+				t.Fatalf("decodeEventDataTCG failed: %v", err)
+			}
+			d, ok := out.(*PostCodeStringEventData)
+			if !ok {
+				t.Fatalf("Unexpected event data type %T", out)
+			}
+			if d.Description != data.descr {
+				t.Errorf("Unexpected description %q", d.Description)
+			}
+		})
+	}
+
+	t.Run("blob", func(t *testing.T) {
+		data := make([]byte, 16)
+		binary.LittleEndian.PutUint64(data[0:], 0xffd00000)
+		binary.LittleEndian.PutUint64(data[8:], 0x30000)
+
+		out, trailing, err := decodeEventDataTCG(EventTypePostCode, data, false)
+		if err != nil {
+			t.Fatalf("decodeEventDataTCG failed: %v", err)
+		}
+		if trailing != 0 {
+			t.Errorf("Unexpected trailing bytes %d", trailing)
+		}
+		d, ok := out.(*EFIPlatformFirmwareBlobEventData)
+		if !ok {
+			t.Fatalf("Unexpected event data type %T", out)
+		}
+		if d.BlobBase != 0xffd00000 || d.BlobLength != 0x30000 {
+			t.Errorf("Unexpected blob %s", d)
+		}
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		if _, _, err := decodeEventDataTCG(EventTypePostCode, []byte{0x00, 0x01, 0x02}, false); err == nil {
+			t.Errorf("decodeEventDataTCG should have failed")
+		}
+	})
+}
+
+func TestDecodeEventDataSCRTMVersion(t *testing.T) {
+	for _, data := range []struct {
+		desc    string
+		data    []byte
+		version string
+		guid    *EFIGUID
+	}{
+		{
+			desc:    "ucs2",
+			data:    []byte{0x31, 0x00, 0x2e, 0x00, 0x32, 0x00, 0x37, 0x00, 0x00, 0x00},
+			version: "1.27",
+		},
+		{
+			desc: "guid",
+			data: []byte{0xcb, 0xb2, 0x19, 0xd7, 0x3a, 0x3d, 0x96, 0x45, 0xa3, 0xbc, 0xda, 0xd0, 0x0e,
+				0x67, 0x65, 0x6f},
+			guid: NewEFIGUID(0xd719b2cb, 0x3d3a, 0x4596, 0xa3bc, [...]uint8{0xda, 0xd0, 0x0e, 0x67, 0x65, 0x6f}),
+		},
+		{
+			desc:    "ascii",
+			data:    []byte("1.2.3\x00"),
+			version: "1.2.3",
+		},
+		{
+			desc: "empty",
+			data: []byte{0x00, 0x00},
+		},
+	} {
+		t.Run(data.desc, func(t *testing.T) {
+			out, _, err := decodeEventDataTCG(EventTypeSCRTMVersion, data.data, false)
+			if err != nil {
+				t.Fatalf("decodeEventDataTCG failed: %v", err)
+			}
+			d, ok := out.(*SCRTMVersionEventData)
+			if !ok {
+				t.Fatalf("Unexpected event data type %T", out)
+			}
+			if d.Version != data.version {
+				t.Errorf("Unexpected version %q", d.Version)
+			}
+			switch {
+			case data.guid == nil && d.GUID != nil:
+				t.Errorf("Unexpected GUID %s", d.GUID)
+			case data.guid != nil && (d.GUID == nil || *d.GUID != *data.guid):
+				t.Errorf("Unexpected GUID %v", d.GUID)
+			}
+		})
+	}
+}
+
+func TestDecodeEventDataSCRTMVersionUnrecognized(t *testing.T) {
+	// Data that is neither a string or a GUID is opaque rather than broken, so that its digest is still validated.
+	out, _ := decodeEventData(0, EventTypeSCRTMVersion, []byte{0x01, 0x02, 0x03}, &LogOptions{}, false)
+	if _, ok := out.(*opaqueEventData); !ok {
+		t.Errorf("Unexpected event data type %T", out)
+	}
+}
+
+func TestDecodeEventDataTaggedEvent(t *testing.T) {
+	inner := append(makeTestTaggedEventData(0x10, "foo"), makeTestTaggedEventData(0x11, "bar")...)
+	data := make([]byte, 8, 8+len(inner))
+	binary.LittleEndian.PutUint32(data[0:], 0x1234)
+	binary.LittleEndian.PutUint32(data[4:], uint32(len(inner)))
+	data = append(data, inner...)
+
+	out, trailing, err := decodeEventDataTCG(EventTypeEventTag, data, false)
+	if err != nil {
+		t.Fatalf("decodeEventDataTCG failed: %v", err)
+	}
+	if trailing != 0 {
+		t.Errorf("Unexpected trailing bytes: %d", trailing)
+	}
+	d, ok := out.(*TaggedEventData)
+	if !ok {
+		t.Fatalf("Unexpected event data type %T", out)
+	}
+	if d.EventID != 0x1234 || !bytes.Equal(d.Data, inner) {
+		t.Errorf("Unexpected tagged event: %s", d)
+	}
+	if len(d.Nested) != 2 {
+		t.Fatalf("Unexpected number of nested events: %d", len(d.Nested))
+	}
+	for i, e := range []struct {
+		id   uint32
+		data string
+	}{
+		{id: 0x10, data: "foo\x00"},
+		{id: 0x11, data: "bar\x00"},
+	} {
+		nested := d.Nested[i]
+		if nested.EventID != e.id || string(nested.Data) != e.data || len(nested.Nested) != 0 {
+			t.Errorf("Unexpected nested event: %s", nested)
+		}
+	}
+
+	// An event whose data isn't a sequence of tagged events, with a trailing byte.
+	out, trailing, err = decodeEventDataTCG(EventTypeEventTag, append(makeTestTaggedEventData(0x10, "foo"), 0),
+		false)
+	if err != nil {
+		t.Fatalf("decodeEventDataTCG failed: %v", err)
+	}
+	if d, ok := out.(*TaggedEventData); !ok || len(d.Nested) != 0 || trailing != 1 {
+		t.Errorf("Unexpected event data %s (trailing: %d)", out, trailing)
+	}
+
+	// Truncated or malformed data is opaque rather than broken, so that its digest is still validated.
+	for _, d := range [][]byte{data[:len(data)-1], {0x01, 0x02, 0x03}} {
+		out, _ := decodeEventData(0, EventTypeEventTag, d, &LogOptions{}, false)
+		if _, ok := out.(*opaqueEventData); !ok {
+			t.Errorf("Unexpected event data type %T for %x", out, d)
+		}
+	}
+}
+
+func TestCompactHash(t *testing.T) {
+	out, _, err := decodeEventDataTCG(EventTypeCompactHash, []byte("Vendor Data\x00"), false)
+	if err != nil {
+		t.Fatalf("decodeEventDataTCG failed: %v", err)
+	}
+	d, ok := out.(*CompactHashEventData)
+	if !ok || d.Description != "Vendor Data" {
+		t.Errorf("Unexpected event data: %s", out)
+	}
+
+	// The digest is of data that isn't recorded in the log, and shouldn't be reported as incorrect.
+	var buf bytes.Buffer
+	w, err := NewLogWriter(&buf, SpecEFI_2, AlgorithmIdList{AlgorithmSha256})
+	if err != nil {
+		t.Fatalf("NewLogWriter failed: %v", err)
+	}
+	if err := w.WriteSpecIdEvent(0, nil); err != nil {
+		t.Fatalf("WriteSpecIdEvent failed: %v", err)
+	}
+	if err := w.WriteEvent(&Event{
+		PCRIndex:  6,
+		EventType: EventTypeCompactHash,
+		Digests:   DigestMap{AlgorithmSha256: AlgorithmSha256.hash([]byte("external data"))},
+		Data:      &opaqueEventData{data: []byte{0x01, 0x00, 0x00, 0x00}}}); err != nil {
+		t.Fatalf("WriteEvent failed: %v", err)
+	}
+
+	log, err := NewLog(bytes.NewReader(buf.Bytes()), LogOptions{})
+	if err != nil {
+		t.Fatalf("NewLog failed: %v", err)
+	}
+	result, err := replayAndValidateLog(context.Background(), log, int64(buf.Len()), LogValidateOptions{})
+	if err != nil {
+		t.Fatalf("replayAndValidateLog failed: %v", err)
+	}
+	e := result.ValidatedEvents[1]
+	if d, ok := e.Event.Data.(*CompactHashEventData); !ok || d.Description != "" || d.String() != "01000000" {
+		t.Errorf("Unexpected event data: %s", e.Event.Data)
+	}
+	if len(e.IncorrectDigestValues) > 0 {
+		t.Errorf("Unexpected incorrect digest")
+	}
+}
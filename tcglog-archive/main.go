@@ -0,0 +1,87 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/chrisccoulson/tcglog-parser"
+)
+
+var (
+	dir     string
+	logPath string
+	bootID  string
+	keep    int
+)
+
+func init() {
+	flag.StringVar(&dir, "dir", "/var/lib/tcglog/boots", "Directory in which to archive logs")
+	flag.StringVar(&logPath, "log-path", tcglog.DefaultLogPath, "Path of the log to archive")
+	flag.StringVar(&bootID, "boot-id", "", "Archive the log with the specified boot ID rather than the ID of "+
+		"the current boot")
+	flag.IntVar(&keep, "keep", 10, "Number of archived logs to retain")
+}
+
+// checkBanks prints a warning for each PCR bank that was present in the log from the previous boot but which is
+// missing from the archived log at path.
+func checkBanks(path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	log, err := tcglog.NewLog(f, tcglog.LogOptions{})
+	if err != nil {
+		return err
+	}
+
+	findings, err := tcglog.CompareBanksWithPreviousBoot(dir, bootID, log.Algorithms)
+	if err != nil {
+		return err
+	}
+	for _, finding := range findings {
+		fmt.Fprintf(os.Stderr, "%s\n", &finding)
+	}
+	return nil
+}
+
+func main() {
+	flag.Parse()
+
+	if bootID == "" {
+		var err error
+		bootID, err = tcglog.CurrentBootID()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Cannot determine the current boot ID: %v\n", err)
+			os.Exit(1)
+		}
+	}
+
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		fmt.Fprintf(os.Stderr, "Cannot create archive directory: %v\n", err)
+		os.Exit(1)
+	}
+
+	path, err := tcglog.ArchiveLog(dir, logPath, bootID)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Cannot archive log: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Printf("Archived log for boot %s to %s\n", bootID, path)
+
+	if err := checkBanks(path); err != nil {
+		fmt.Fprintf(os.Stderr, "Cannot compare PCR banks with the previous boot: %v\n", err)
+		os.Exit(1)
+	}
+
+	removed, err := tcglog.PruneArchivedLogs(dir, keep)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Cannot prune archived logs: %v\n", err)
+		os.Exit(1)
+	}
+	for _, log := range removed {
+		fmt.Printf("Removed archived log for boot %s\n", log.BootID)
+	}
+}
@@ -0,0 +1,111 @@
+package main
+
+import (
+	"bytes"
+	"crypto"
+	_ "crypto/sha1"
+	_ "crypto/sha256"
+	_ "crypto/sha512"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+
+	"github.com/chrisccoulson/tcglog-parser"
+)
+
+var (
+	dbPath  string
+	name    string
+	version string
+	vendor  string
+	typ     string
+	flat    bool
+)
+
+func init() {
+	flag.StringVar(&dbPath, "db", "components.json", "Path of the component database to update. It is created if "+
+		"it doesn't exist")
+	flag.StringVar(&name, "name", "", "Name of the component (eg, shim)")
+	flag.StringVar(&version, "version", "", "Version of the component (eg, 15.8)")
+	flag.StringVar(&vendor, "vendor", "", "Vendor or distribution that built the component (eg, \"Ubuntu 24.04\")")
+	flag.StringVar(&typ, "type", "", "Kind of component (eg, bootloader or kernel)")
+	flag.BoolVar(&flat, "flat", false, "Add the digests of the file contents rather than the Authenticode digests "+
+		"of PE images, for components that are measured as flat files (eg, kernels loaded by GRUB)")
+}
+
+var algorithms = map[tcglog.AlgorithmId]crypto.Hash{
+	tcglog.AlgorithmSha1:   crypto.SHA1,
+	tcglog.AlgorithmSha256: crypto.SHA256,
+	tcglog.AlgorithmSha384: crypto.SHA384,
+	tcglog.AlgorithmSha512: crypto.SHA512}
+
+func computeDigests(path string) (tcglog.DigestMap, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	digests := tcglog.DigestMap{}
+	for alg, hashAlg := range algorithms {
+		if flat {
+			h := hashAlg.New()
+			h.Write(data)
+			digests[alg] = h.Sum(nil)
+			continue
+		}
+		digest, err := tcglog.ComputeAuthenticodeDigest(bytes.NewReader(data), int64(len(data)), alg)
+		if err != nil {
+			return nil, fmt.Errorf("cannot compute Authenticode digest: %v", err)
+		}
+		digests[alg] = digest
+	}
+	return digests, nil
+}
+
+func main() {
+	flag.Parse()
+
+	if name == "" {
+		fmt.Fprintf(os.Stderr, "A component name must be specified with -name\n")
+		os.Exit(1)
+	}
+	if flag.NArg() == 0 {
+		fmt.Fprintf(os.Stderr, "No files specified\n")
+		os.Exit(1)
+	}
+
+	db := tcglog.NewMemoryComponentDatabase()
+	if f, err := os.Open(dbPath); err == nil {
+		db, err = tcglog.ReadComponentDatabase(f)
+		f.Close()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Cannot read component database: %v\n", err)
+			os.Exit(1)
+		}
+	} else if !os.IsNotExist(err) {
+		fmt.Fprintf(os.Stderr, "Cannot open component database: %v\n", err)
+		os.Exit(1)
+	}
+
+	for _, path := range flag.Args() {
+		digests, err := computeDigests(path)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Cannot compute digests for %s: %v\n", path, err)
+			os.Exit(1)
+		}
+		component := &tcglog.Component{Name: name, Version: version, Vendor: vendor, Type: typ}
+		db.Add(component, digests)
+		fmt.Printf("Added %s as %s (sha256: %x)\n", path, component, digests[tcglog.AlgorithmSha256])
+	}
+
+	var buf bytes.Buffer
+	if err := db.Write(&buf); err != nil {
+		fmt.Fprintf(os.Stderr, "Cannot encode component database: %v\n", err)
+		os.Exit(1)
+	}
+	if err := ioutil.WriteFile(dbPath, buf.Bytes(), 0644); err != nil {
+		fmt.Fprintf(os.Stderr, "Cannot write component database: %v\n", err)
+		os.Exit(1)
+	}
+}
@@ -2,33 +2,112 @@ package main
 
 import (
 	"bytes"
+	"encoding/hex"
 	"flag"
 	"fmt"
 	"io"
+	"io/ioutil"
 	"os"
+	"time"
 
 	"github.com/chrisccoulson/tcglog-parser"
+	"github.com/chrisccoulson/tcglog-parser/internal/output"
 )
 
 var (
 	alg           string
 	verbose       bool
 	withGrub      bool
+	grubVariant   string
 	withSdEfiStub bool
 	sdEfiStubPcr  int
+	withXen       bool
+	withApp       bool
+	hexdump       bool
+	celFormat     string
+	ccel          bool
+	drtmLog       string
+	strict        bool
+	sbomFormat    string
+	componentDb   string
+	format        string
+	out           output.Formatter
 	pcrs          tcglog.PCRArgList
+	eventTypes    eventTypeArgList
 )
 
 func init() {
 	flag.StringVar(&alg, "alg", "sha1", "Name of the hash algorithm to display")
 	flag.BoolVar(&verbose, "verbose", false, "Display details of event data")
-	flag.BoolVar(&withGrub, "with-grub", false, "Interpret measurements made by GRUB to PCR's 8 and 9")
+	flag.BoolVar(&withGrub, "with-grub", false, "Interpret measurements made by GRUB")
+	flag.StringVar(&grubVariant, "grub-variant", "upstream", "Specify the variant of GRUB that made measurements "+
+		"(upstream or trustedgrub2)")
 	flag.BoolVar(&withSdEfiStub, "with-systemd-efi-stub", false, "Interpret measurements made by systemd's EFI stub Linux loader")
 	flag.IntVar(&sdEfiStubPcr, "systemd-efi-stub-pcr", 8, "Specify the PCR that systemd's EFI stub Linux loader measures to")
+	flag.BoolVar(&withXen, "with-xen", false, "Interpret measurements made by Xen during a measured launch to PCRs 17-19")
+	flag.BoolVar(&withApp, "with-app-measurements", false, "Interpret application-level measurements of files, "+
+		"configurations and container images")
+	flag.BoolVar(&hexdump, "hexdump", false, "Display a hexdump of the raw event data")
+	flag.StringVar(&celFormat, "cel", "", "Write the log to stdout as a TCG Canonical Event Log in the specified "+
+		"format (tlv, json or cbor) instead of displaying it")
+	flag.StringVar(&sbomFormat, "sbom", "", "Write the measured boot components to stdout as a software bill of "+
+		"materials in the specified format (cyclonedx or spdx) instead of displaying the log")
+	flag.StringVar(&componentDb, "component-db", "", "Path of a component database used to identify the "+
+		"components in the software bill of materials")
+	flag.BoolVar(&ccel, "ccel", false, "Read the confidential computing event log described by the CCEL ACPI "+
+		"table, such as the one produced by TDX guest firmware. Events are displayed with the measurement "+
+		"register they were measured to")
+	flag.StringVar(&drtmLog, "drtm-log", "", "Path of the event log for a dynamic launch, such as the TXT heap "+
+		"event log exposed by tboot. Its events are displayed after those from the SRTM log")
+	flag.BoolVar(&strict, "strict", false, "Fail on any violation of the specification rather than displaying a "+
+		"warning and continuing")
+	flag.StringVar(&format, "format", "text", "Display events in the specified format (text or ndjson). The ndjson "+
+		"format writes one JSON object per event as it is parsed")
+	flag.IntVar(&out.Width, "output-width", 0, "Truncate each line of output to the specified number of "+
+		"characters. The default is not to truncate lines")
+	flag.IntVar(&out.DigestChars, "truncate-digests", 0, "Display only the specified number of hexadecimal "+
+		"characters of each digest. The default is to display digests in full")
 	flag.Var(&pcrs, "pcr", "Display events associated with the specified PCR. Can be specified multiple times")
+	flag.Var(&eventTypes, "event-type", "Display events of the specified type (eg, EV_SEPARATOR). Can be "+
+		"specified multiple times")
+}
+
+type eventTypeArgList []tcglog.EventType
+
+func (l *eventTypeArgList) String() string {
+	var builder bytes.Buffer
+	for i, t := range *l {
+		if i > 0 {
+			builder.WriteString(", ")
+		}
+		fmt.Fprintf(&builder, "%s", t)
+	}
+	return builder.String()
+}
+
+func (l *eventTypeArgList) Set(value string) error {
+	t, err := tcglog.ParseEventType(value)
+	if err != nil {
+		return err
+	}
+	*l = append(*l, t)
+	return nil
 }
 
 func shouldDisplayEvent(event *tcglog.Event) bool {
+	if len(eventTypes) > 0 {
+		found := false
+		for _, t := range eventTypes {
+			if t == event.EventType {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+
 	if len(pcrs) == 0 {
 		return true
 	}
@@ -42,46 +121,105 @@ func shouldDisplayEvent(event *tcglog.Event) bool {
 	return false
 }
 
-func main() {
-	flag.Parse()
+func openLog(args []string, options tcglog.LogOptions) (*tcglog.Log, error) {
+	path := tcglog.DefaultLogPath
+	if len(args) == 1 {
+		path = args[0]
+	}
 
-	algorithmId, err := tcglog.ParseAlgorithm(alg)
+	if path == "-" {
+		log, err := tcglog.NewLogFromReader(os.Stdin, options)
+		if err != nil {
+			return nil, fmt.Errorf("Failed to parse log: %v", err)
+		}
+		return log, nil
+	}
+
+	file, err := os.Open(path)
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "%v\n", err)
-		os.Exit(1)
+		return nil, fmt.Errorf("Failed to open log file: %v", err)
 	}
 
-	args := flag.Args()
-	if len(args) > 1 {
-		fmt.Fprintf(os.Stderr, "Too many arguments\n")
-		os.Exit(1)
+	log, err := tcglog.NewLog(file, options)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to parse log file: %v", err)
 	}
+	return log, nil
+}
 
-	var path string
+func openCCELLog(args []string, options tcglog.LogOptions) (*tcglog.Log, error) {
+	table, err := tcglog.ReadCCELTable()
+	if err != nil {
+		return nil, fmt.Errorf("Failed to read CCEL table: %v", err)
+	}
+
+	path := tcglog.CCELDataPath
 	if len(args) == 1 {
 		path = args[0]
-	} else {
-		path = "/sys/kernel/security/tpm0/binary_bios_measurements"
 	}
 
-	file, err := os.Open(path)
+	data, err := ioutil.ReadFile(path)
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "Failed to open log file: %v\n", err)
-		os.Exit(1)
+		return nil, fmt.Errorf("Failed to read log file: %v", err)
 	}
 
-	log, err := tcglog.NewLog(file, tcglog.LogOptions{EnableGrub: withGrub, EnableSystemdEFIStub: withSdEfiStub, SystemdEFIStubPCR: tcglog.PCRIndex(sdEfiStubPcr)})
+	log, err := tcglog.NewCCELLog(data, table.CCType, options)
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "Failed to parse log file: %v\n", err)
-		os.Exit(1)
+		return nil, fmt.Errorf("Failed to parse log file: %v", err)
 	}
+	return log, nil
+}
 
-	if !log.Algorithms.Contains(algorithmId) {
-		fmt.Fprintf(os.Stderr,
-			"The log doesn't contain entries for the %s digest algorithm\n", algorithmId)
-		os.Exit(1)
+func openDRTMLog(path string, options tcglog.LogOptions) (*tcglog.Log, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to read DRTM log file: %v", err)
+	}
+
+	log, err := tcglog.NewTXTLog(data, options)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to parse DRTM log file: %v", err)
+	}
+	return log, nil
+}
+
+func writeSBOM(log *tcglog.Log) error {
+	format, err := tcglog.ParseSBOMFormat(sbomFormat)
+	if err != nil {
+		return err
 	}
 
+	var db tcglog.ComponentDatabase
+	if componentDb != "" {
+		f, err := os.Open(componentDb)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		mdb, err := tcglog.ReadComponentDatabase(f)
+		if err != nil {
+			return err
+		}
+		db = mdb
+	}
+
+	var events []*tcglog.Event
+	for {
+		event, err := log.NextEvent()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		events = append(events, event)
+	}
+
+	return tcglog.WriteSBOM(os.Stdout, tcglog.MeasuredComponents(events, db), format, time.Now())
+}
+
+func displayLog(log *tcglog.Log, algorithmId tcglog.AlgorithmId, enc *tcglog.NDJSONEncoder) {
+	nwarnings := 0
 	for {
 		event, err := log.NextEvent()
 		if err != nil {
@@ -93,22 +231,140 @@ func main() {
 			os.Exit(1)
 		}
 
+		for _, w := range log.Warnings()[nwarnings:] {
+			fmt.Fprintf(os.Stderr, "Warning: %v\n", w)
+		}
+		nwarnings = len(log.Warnings())
+
 		if !shouldDisplayEvent(event) {
 			continue
 		}
 
+		if enc != nil {
+			if err := enc.Encode(event); err != nil {
+				fmt.Fprintf(os.Stderr, "Failed to write event: %v\n", err)
+				os.Exit(1)
+			}
+			continue
+		}
+
 		var builder bytes.Buffer
-		fmt.Fprintf(&builder, "%2d %x %s", event.PCRIndex, event.Digests[algorithmId], event.EventType)
+		if log.CCType != tcglog.CCTypeNone {
+			fmt.Fprintf(&builder, "%5s %s %s", tcglog.MRIndex(event.PCRIndex),
+				out.Digest(event.Digests[algorithmId]), event.EventType)
+		} else {
+			fmt.Fprintf(&builder, "%2d %s %s", event.PCRIndex, out.Digest(event.Digests[algorithmId]),
+				event.EventType)
+		}
 		if verbose {
 			data := event.Data.String()
 			if data != "" {
 				fmt.Fprintf(&builder, " [ %s ]", data)
 			}
+			if d, alg, ok := tcglog.IdentifyWellKnownDigest(event.Digests[algorithmId]); ok {
+				fmt.Fprintf(&builder, " (%s)", d.Label(alg))
+			}
 
 		}
 		if err != nil {
 			fmt.Fprintf(&builder, " (WARNING: %s)", err)
 		}
-		fmt.Println(builder.String())
+		out.Println(builder.String())
+		if hexdump {
+			out.Printf("%s", hex.Dump(event.Data.Bytes()))
+		}
+	}
+}
+
+func main() {
+	flag.Parse()
+
+	algorithmId, err := tcglog.ParseAlgorithm(alg)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%v\n", err)
+		os.Exit(1)
+	}
+
+	variant, err := tcglog.ParseGrubVariant(grubVariant)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%v\n", err)
+		os.Exit(1)
+	}
+
+	if format != "text" && format != "ndjson" {
+		fmt.Fprintf(os.Stderr, "Unrecognized format \"%s\"\n", format)
+		os.Exit(1)
+	}
+
+	if out.Width < 0 || out.DigestChars < 0 {
+		fmt.Fprintf(os.Stderr, "The output width and digest length must not be negative\n")
+		os.Exit(1)
+	}
+
+	args := flag.Args()
+	if len(args) > 1 {
+		fmt.Fprintf(os.Stderr, "Too many arguments\n")
+		os.Exit(1)
+	}
+
+	options := tcglog.LogOptions{EnableGrub: withGrub, GrubVariant: variant, EnableSystemdEFIStub: withSdEfiStub, SystemdEFIStubPCR: tcglog.PCRIndex(sdEfiStubPcr), EnableXen: withXen, EnableAppMeasurements: withApp, Strict: strict}
+
+	var log *tcglog.Log
+	if ccel {
+		log, err = openCCELLog(args, options)
+	} else {
+		log, err = openLog(args, options)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%v\n", err)
+		os.Exit(1)
+	}
+
+	if celFormat != "" {
+		format, err := tcglog.ParseCELFormat(celFormat)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%v\n", err)
+			os.Exit(1)
+		}
+		if err := tcglog.WriteCEL(os.Stdout, log, format); err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to write CEL: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	if sbomFormat != "" {
+		if err := writeSBOM(log); err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to write SBOM: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	logs := []*tcglog.Log{log}
+	if drtmLog != "" {
+		drtm, err := openDRTMLog(drtmLog, options)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%v\n", err)
+			os.Exit(1)
+		}
+		logs = append(logs, drtm)
+	}
+
+	var enc *tcglog.NDJSONEncoder
+	if format == "ndjson" {
+		enc = tcglog.NewNDJSONEncoder(os.Stdout)
+	} else {
+		for _, l := range logs {
+			if !l.Algorithms.Contains(algorithmId) {
+				fmt.Fprintf(os.Stderr,
+					"The log doesn't contain entries for the %s digest algorithm\n", algorithmId)
+				os.Exit(1)
+			}
+		}
+	}
+
+	for _, l := range logs {
+		displayLog(l, algorithmId, enc)
 	}
 }
@@ -0,0 +1,277 @@
+// tcglog-monitord is a reference daemon that periodically re-reads the event log, the IMA measurement list and
+// the PCR values, and writes a notification to stdout as a JSON object on a single line whenever it detects that
+// the PCRs have been extended since the previous check, along with whether the change is explained by events
+// that were appended to the logs.
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/chrisccoulson/tcglog-parser"
+	"github.com/chrisccoulson/tcglog-parser/tpmdevice"
+)
+
+const defaultIMAPath = "/sys/kernel/security/ima/binary_runtime_measurements"
+
+var (
+	logPath    string
+	imaPath    string
+	imaAlg     string
+	tpmPath    string
+	archiveDir string
+	interval   time.Duration
+	once       bool
+)
+
+func init() {
+	flag.StringVar(&logPath, "log-path", tcglog.DefaultLogPath, "Path of the event log")
+	flag.StringVar(&imaPath, "ima-path", defaultIMAPath, "Path of the binary IMA measurement list, or an empty "+
+		"string to ignore IMA")
+	flag.StringVar(&imaAlg, "ima-alg", "sha1", "Algorithm of the template hashes in the IMA measurement list. "+
+		"The template hashes for the other PCR banks in the event log are computed from the template data")
+	flag.StringVar(&tpmPath, "tpm-path", "/dev/tpmrm0", "Path of the TPM device. The PCR values are read from "+
+		"sysfs if the device can't be opened")
+	flag.StringVar(&archiveDir, "archive-dir", "", "Directory containing logs from previous boots archived by "+
+		"tcglog-archive. If set, the digest algorithms in the log are compared with those from the previous boot")
+	flag.DurationVar(&interval, "interval", time.Minute, "Interval between checks")
+	flag.BoolVar(&once, "once", false, "Perform a single check and exit")
+}
+
+// notification is written to stdout for each change that is detected.
+type notification struct {
+	Time      time.Time        `json:"time"`
+	Type      string           `json:"type"`
+	PCR       *tcglog.PCRIndex `json:"pcr,omitempty"`
+	Algorithm string           `json:"algorithm,omitempty"`
+	Old       string           `json:"old,omitempty"`
+	New       string           `json:"new,omitempty"`
+	Explained *bool            `json:"explained,omitempty"`
+	Events    int              `json:"events,omitempty"`
+	Code      string           `json:"code,omitempty"`
+	Severity  string           `json:"severity,omitempty"`
+	Message   string           `json:"message,omitempty"`
+}
+
+func notify(n notification) {
+	n.Time = time.Now().UTC()
+	data, err := json.Marshal(n)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Cannot encode notification: %v\n", err)
+		return
+	}
+	fmt.Printf("%s\n", data)
+}
+
+type monitor struct {
+	pcrReader   tcglog.PCRReader
+	imaAlg      tcglog.AlgorithmId
+	algorithms  tcglog.AlgorithmIdList
+	logEvents   int
+	anchor      *tcglog.PCRAnchor
+	imaEvents   int
+	lastValues  map[tcglog.PCRIndex]tcglog.DigestMap
+	initialized bool
+}
+
+func readLogEvents(path string) (tcglog.AlgorithmIdList, []*tcglog.Event, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer f.Close()
+
+	log, err := tcglog.NewLog(f, tcglog.LogOptions{})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var events []*tcglog.Event
+	for {
+		event, err := log.NextEvent()
+		if err == io.EOF {
+			return log.Algorithms, events, nil
+		}
+		if err != nil {
+			return nil, nil, err
+		}
+		events = append(events, event)
+	}
+}
+
+func readIMAEvents(path string, alg tcglog.AlgorithmId) ([]*tcglog.Event, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return tcglog.ParseIMAMeasurementList(f, alg)
+}
+
+// expectedPCRValues updates the anchor for the event log with any new events, and returns the PCR values
+// predicted by the event log and the IMA measurement list.
+func (m *monitor) expectedPCRValues() (map[tcglog.PCRIndex]tcglog.DigestMap, error) {
+	algorithms, events, err := readLogEvents(logPath)
+	if err != nil {
+		return nil, fmt.Errorf("cannot read event log: %v", err)
+	}
+	m.algorithms = algorithms
+	switch {
+	case m.anchor == nil:
+		m.anchor, err = tcglog.NewPCRAnchor(events)
+	case len(events) > m.logEvents:
+		notify(notification{Type: "log-extended", Events: len(events) - m.logEvents})
+		m.anchor, err = m.anchor.Advance(events[m.logEvents:])
+	case len(events) < m.logEvents:
+		err = fmt.Errorf("event log has shrunk from %d to %d events", m.logEvents, len(events))
+	}
+	if err != nil {
+		return nil, err
+	}
+	m.logEvents = len(events)
+
+	// This returns a copy of the values at the anchor point.
+	values, err := tcglog.ReplayFromAnchor(m.anchor, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	if imaPath == "" {
+		return values, nil
+	}
+	imaEvents, err := readIMAEvents(imaPath, m.imaAlg)
+	if err != nil {
+		return nil, fmt.Errorf("cannot read IMA measurement list: %v", err)
+	}
+	if m.initialized && len(imaEvents) > m.imaEvents {
+		notify(notification{Type: "ima-extended", Events: len(imaEvents) - m.imaEvents})
+	}
+	m.imaEvents = len(imaEvents)
+	// The measurement list only records the template hashes for one algorithm, but the kernel extends every
+	// PCR bank with a template hash computed with the bank's algorithm.
+	if err := tcglog.AddIMATemplateHashes(imaEvents, m.algorithms); err != nil {
+		return nil, fmt.Errorf("cannot compute IMA template hashes: %v", err)
+	}
+	if err := tcglog.ReplayIMAEvents(values, imaEvents); err != nil {
+		return nil, fmt.Errorf("cannot replay IMA measurement list: %v", err)
+	}
+	return values, nil
+}
+
+// checkBanks notifies about any PCR banks that were present in the log from the previous boot but which are
+// missing from the log for the current boot.
+func checkBanks() error {
+	f, err := os.Open(logPath)
+	if err != nil {
+		return fmt.Errorf("cannot read event log: %v", err)
+	}
+	defer f.Close()
+
+	log, err := tcglog.NewLog(f, tcglog.LogOptions{})
+	if err != nil {
+		return fmt.Errorf("cannot read event log: %v", err)
+	}
+
+	bootID, err := tcglog.CurrentBootID()
+	if err != nil {
+		return fmt.Errorf("cannot determine the current boot ID: %v", err)
+	}
+	findings, err := tcglog.CompareBanksWithPreviousBoot(archiveDir, bootID, log.Algorithms)
+	if err != nil {
+		return fmt.Errorf("cannot compare PCR banks with the previous boot: %v", err)
+	}
+	for _, finding := range findings {
+		notify(notification{Type: "finding", Code: string(finding.Code), Severity: finding.Severity.String(),
+			Message: finding.Message})
+	}
+	return nil
+}
+
+func (m *monitor) check() error {
+	expected, err := m.expectedPCRValues()
+	if err != nil {
+		return err
+	}
+
+	var pcrs []tcglog.PCRIndex
+	for pcr := tcglog.PCRIndex(0); pcr < 24; pcr++ {
+		pcrs = append(pcrs, pcr)
+	}
+	values, err := m.pcrReader.ReadPCRs(pcrs)
+	if err != nil {
+		return fmt.Errorf("cannot read PCR values: %v", err)
+	}
+
+	if !m.initialized {
+		m.initialized = true
+		m.lastValues = values
+		notify(notification{Type: "started", Events: m.logEvents + m.imaEvents})
+		return nil
+	}
+
+	for _, pcr := range pcrs {
+		for _, alg := range m.algorithms {
+			value, ok := values[pcr][alg]
+			if !ok {
+				continue
+			}
+			old := m.lastValues[pcr][alg]
+			if bytes.Equal(value, old) {
+				continue
+			}
+			explained := bytes.Equal(value, expected[pcr][alg])
+			pcr := pcr
+			notify(notification{Type: "pcr-changed", PCR: &pcr, Algorithm: alg.String(),
+				Old: fmt.Sprintf("%x", []byte(old)), New: fmt.Sprintf("%x", []byte(value)),
+				Explained: &explained})
+		}
+	}
+	m.lastValues = values
+	return nil
+}
+
+func newPCRReader() (tcglog.PCRReader, func()) {
+	if r, err := tpmdevice.NewPCRReader(tpmPath); err == nil {
+		return r, func() { r.Close() }
+	}
+	// The kernel creates a tpmrmN device for each tpmN device.
+	name := strings.Replace(filepath.Base(tpmPath), "tpmrm", "tpm", 1)
+	return tcglog.NewSysfsPCRReader(filepath.Join("/sys/class/tpm", name)), func() {}
+}
+
+func main() {
+	flag.Parse()
+
+	alg, err := tcglog.ParseAlgorithm(imaAlg)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%v\n", err)
+		os.Exit(1)
+	}
+
+	pcrReader, closePCRReader := newPCRReader()
+	defer closePCRReader()
+
+	if archiveDir != "" {
+		if err := checkBanks(); err != nil {
+			notify(notification{Type: "error", Message: err.Error()})
+		}
+	}
+
+	m := &monitor{pcrReader: pcrReader, imaAlg: alg}
+	for {
+		if err := m.check(); err != nil {
+			notify(notification{Type: "error", Message: err.Error()})
+		}
+		if once {
+			break
+		}
+		time.Sleep(interval)
+	}
+}
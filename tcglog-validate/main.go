@@ -2,17 +2,40 @@ package main
 
 import (
 	"bytes"
-	"errors"
+	"encoding/json"
 	"flag"
 	"fmt"
+	"io/ioutil"
 	"os"
+	"os/exec"
 	"path/filepath"
 	"sort"
+	"strings"
 
-	"github.com/chrisccoulson/go-tpm2"
 	"github.com/chrisccoulson/tcglog-parser"
+	"github.com/chrisccoulson/tcglog-parser/internal/output"
+	"github.com/chrisccoulson/tcglog-parser/redfish"
+	"github.com/chrisccoulson/tcglog-parser/tpmdevice"
 )
 
+type findingCodeArgList []tcglog.FindingCode
+
+func (l *findingCodeArgList) String() string {
+	var builder bytes.Buffer
+	for i, code := range *l {
+		if i > 0 {
+			builder.WriteString(", ")
+		}
+		builder.WriteString(string(code))
+	}
+	return builder.String()
+}
+
+func (l *findingCodeArgList) Set(value string) error {
+	*l = append(*l, tcglog.FindingCode(value))
+	return nil
+}
+
 type AlgorithmIdArgList tcglog.AlgorithmIdList
 
 func (l *AlgorithmIdArgList) String() string {
@@ -36,114 +59,288 @@ func (l *AlgorithmIdArgList) Set(value string) error {
 }
 
 var (
-	withGrub      bool
-	withSdEfiStub bool
-	sdEfiStubPcr  int
-	noDefaultPcrs bool
-	tpmPath       string
-	logPath       string
-	pcrs          tcglog.PCRArgList
-	algorithms    AlgorithmIdArgList
+	withGrub          bool
+	grubVariant       string
+	platformProfile   string
+	withSdEfiStub     bool
+	sdEfiStubPcr      int
+	withXen           bool
+	withApp           bool
+	noDefaultPcrs     bool
+	osPresentOnly     bool
+	strict            bool
+	fingerprint       bool
+	snapshotPath      string
+	complianceReport  bool
+	capabilities      bool
+	bankMigration     string
+	interopCheck      bool
+	requireSecureBoot bool
+	requiredEvents    eventMatcherArgList
+	forbiddenEvents   eventMatcherArgList
+	minSeverity       string
+	suppressed        findingCodeArgList
+	remediationHints  bool
+	out               output.Formatter
+	tpmPath           string
+	pcrSource         string
+	tpmSocket         string
+	tpmSocketProtocol string
+	redfishEndpoint   string
+	redfishUser       string
+	redfishSystem     string
+	logPath           string
+	pcrs              tcglog.PCRArgList
+	resettable        tcglog.PCRArgList
+	algorithms        AlgorithmIdArgList
 )
 
 func init() {
-	flag.BoolVar(&withGrub, "with-grub", false, "Validate log entries made by GRUB in to PCR's 8 and 9")
+	flag.BoolVar(&withGrub, "with-grub", false, "Validate log entries made by GRUB")
+	flag.StringVar(&grubVariant, "grub-variant", "upstream", "Specify the variant of GRUB that made measurements "+
+		"(upstream or trustedgrub2)")
+	flag.StringVar(&platformProfile, "platform-profile", "auto", "Specify the class of platform that produced "+
+		"the log (client or server), or infer it from the log (auto)")
 	flag.BoolVar(&withSdEfiStub, "with-systemd-efi-stub", false, "Interpret measurements made by systemd's EFI stub Linux loader")
 	flag.IntVar(&sdEfiStubPcr, "systemd-efi-stub-pcr", 8, "Specify the PCR that systemd's EFI stub Linux loader measures to")
+	flag.BoolVar(&withXen, "with-xen", false, "Interpret measurements made by Xen during a measured launch to PCRs 17-19")
+	flag.BoolVar(&withApp, "with-app-measurements", false, "Interpret application-level measurements of files, "+
+		"configurations and container images")
 	flag.BoolVar(&noDefaultPcrs, "no-default-pcrs", false, "Don't validate log entries for PCRs 0 - 7")
+	flag.BoolVar(&strict, "strict", false, "Fail on any violation of the specification rather than reporting it "+
+		"as a finding")
+	flag.BoolVar(&osPresentOnly, "os-present-only", false, "Only validate the digests of events measured after "+
+		"the transition to the OS-present environment")
+	flag.BoolVar(&fingerprint, "quirk-fingerprint", false, "Only print a stable fingerprint of the quirks and "+
+		"findings associated with the log, and a list of the quirks that it was computed from")
+	flag.StringVar(&snapshotPath, "replay-snapshot", "", "Write a canonical record of the replay computation to the "+
+		"specified file, so that it can be independently re-verified")
+	flag.BoolVar(&requireSecureBoot, "require-secureboot", false, "Fail if the log doesn't indicate that secure "+
+		"boot is enabled")
+	flag.Var(&requiredEvents, "require-event", "Fail if the log doesn't contain an event matching the specified "+
+		"criteria (eg, pcr=7,type=EV_EFI_VARIABLE_AUTHORITY,subject=<SHA-256 certificate fingerprint>). "+
+		"Events can also be matched by the EFI variable they measure with variable=[<GUID>/]<name>. Can be "+
+		"specified multiple times")
+	flag.Var(&forbiddenEvents, "forbid-event", "Fail if the log contains an event matching the specified "+
+		"criteria. Can be specified multiple times")
+	flag.StringVar(&minSeverity, "min-finding-severity", "info", "Only display findings with at least the "+
+		"specified severity (info, warning or error)")
+	flag.Var(&suppressed, "suppress-finding", "Don't display findings with the specified code. Can be specified "+
+		"multiple times")
+	flag.BoolVar(&remediationHints, "remediation-hints", false, "Display hints that suggest how to resolve "+
+		"or mitigate the cause of each finding")
+	flag.IntVar(&out.Width, "output-width", 0, "Truncate lines that describe events, findings and PCR values to "+
+		"the specified number of characters. The default is not to truncate lines")
+	flag.IntVar(&out.DigestChars, "truncate-digests", 0, "Display only the specified number of hexadecimal "+
+		"characters of each digest. The default is to display digests in full")
+	flag.StringVar(&bankMigration, "bank-migration", "", "Only report whether sealing policies can be moved from "+
+		"the SHA-1 PCR bank to the specified bank (eg, sha256), listing the blockers for each PCR")
+	flag.BoolVar(&interopCheck, "interop-check", false, "Only compare the interpretation of the log with that of "+
+		"tpm2_eventlog from tpm2-tools, if it is installed, and print any disagreements")
+	flag.BoolVar(&complianceReport, "compliance-report", false, "Only print a JSON report that associates "+
+		"findings with relevant NIST SP 800-155 and SP 800-193 guidance")
+	flag.BoolVar(&capabilities, "capabilities", false, "Only print a JSON description of the specifications, "+
+		"event types, digest algorithms and formats that this build supports")
 	flag.StringVar(&tpmPath, "tpm-path", "/dev/tpm0", "Validate log entries associated with the specified TPM")
+	flag.StringVar(&pcrSource, "pcr-source", "auto", "Read PCR values from the TPM device (device), from the "+
+		"kernel's sysfs interface (sysfs), or from the TPM device with a fallback to sysfs if the device can't "+
+		"be opened (auto)")
+	flag.StringVar(&tpmSocket, "tpm-socket", "", "Read PCR values from the TPM simulator at the specified "+
+		"address (tcp:<host>:<port> or unix:<path>) rather than from a TPM device. Requires -log-path")
+	flag.StringVar(&tpmSocketProtocol, "tpm-socket-protocol", "raw", "Specify the protocol used by the TPM "+
+		"simulator socket (raw for swtpm, or mssim for the reference simulator)")
+	flag.StringVar(&redfishEndpoint, "redfish-endpoint", "", "Read the log and PCR values from the Redfish "+
+		"service of the host's BMC at the specified URL rather than from the local TPM. The password is read "+
+		"from the REDFISH_PASSWORD environment variable")
+	flag.StringVar(&redfishUser, "redfish-user", "", "Specify the user name for the Redfish service")
+	flag.StringVar(&redfishSystem, "redfish-system", "", "Specify the Id of the system in the Redfish service, "+
+		"if it exposes more than one")
 	flag.StringVar(&logPath, "log-path", "", "")
 	flag.Var(&pcrs, "pcr", "Validate log entries for the specified PCR. Can be specified multiple times")
+	flag.Var(&resettable, "resettable-pcr", "Specify a PCR that is resettable on this platform. Can be specified "+
+		"multiple times. Defaults to PCRs 16-23")
 	flag.Var(&algorithms, "alg", "Validate log entries for the specified algorithm. Can be specified "+
 		"multiple times")
 }
 
-func pcrIndexListToSelectionData(l []tcglog.PCRIndex) (out tpm2.PCRSelectionData) {
-	for _, i := range l {
-		out = append(out, int(i))
-	}
-	return
+func newSysfsPCRReader() *tcglog.SysfsPCRReader {
+	// The kernel creates a tpmrmN device for each tpmN device.
+	name := strings.Replace(filepath.Base(tpmPath), "tpmrm", "tpm", 1)
+	return tcglog.NewSysfsPCRReader(filepath.Join("/sys/class/tpm", name))
 }
 
-func readPCRsFromTPM2Device(tpm *tpm2.TPMContext) (map[tcglog.PCRIndex]tcglog.DigestMap, error) {
-	result := make(map[tcglog.PCRIndex]tcglog.DigestMap)
+func newRedfishClient() (*redfish.Client, error) {
+	return redfish.NewClient(redfishEndpoint, &redfish.ClientOptions{
+		Username: redfishUser,
+		Password: os.Getenv("REDFISH_PASSWORD"),
+		SystemId: redfishSystem})
+}
 
-	var selections tpm2.PCRSelectionList
-	for _, alg := range algorithms {
-		selections = append(selections,
-			tpm2.PCRSelection{Hash: tpm2.HashAlgorithmId(alg), Select: pcrIndexListToSelectionData(pcrs)})
+// readRedfishLog reads the log from the Redfish service and writes it to a temporary file, returning its path.
+func readRedfishLog() (string, error) {
+	client, err := newRedfishClient()
+	if err != nil {
+		return "", err
 	}
-
-	for _, i := range pcrs {
-		result[i] = tcglog.DigestMap{}
+	data, err := client.ReadEventLog()
+	if err != nil {
+		return "", err
 	}
 
-	_, digests, err := tpm.PCRRead(selections)
+	f, err := ioutil.TempFile("", "tcglog-redfish")
 	if err != nil {
-		return nil, fmt.Errorf("cannot read PCR values: %v", err)
+		return "", err
+	}
+	defer f.Close()
+	if _, err := f.Write(data); err != nil {
+		os.Remove(f.Name())
+		return "", err
 	}
+	return f.Name(), nil
+}
 
-	for _, s := range selections {
-		for _, i := range s.Select {
-			result[tcglog.PCRIndex(i)][tcglog.AlgorithmId(s.Hash)] = tcglog.Digest(digests[s.Hash][i])
+// newPCRReader returns a tcglog.PCRReader for the TPM at tpmPath, using the source selected with -pcr-source.
+func newPCRReader() (tcglog.PCRReader, func(), error) {
+	if redfishEndpoint != "" {
+		r, err := newRedfishClient()
+		if err != nil {
+			return nil, nil, err
 		}
+		return r, func() {}, nil
 	}
-	return result, nil
-}
 
-func readPCRsFromTPM1Device(tpm *tpm2.TPMContext) (map[tcglog.PCRIndex]tcglog.DigestMap, error) {
-	result := make(map[tcglog.PCRIndex]tcglog.DigestMap)
-	for _, i := range pcrs {
-		in, err := tpm2.MarshalToBytes(uint32(i))
+	if tpmSocket != "" {
+		protocol, err := tpmdevice.ParseSimulatorProtocol(tpmSocketProtocol)
 		if err != nil {
-			return nil, fmt.Errorf("cannot read PCR values due to a marshalling error: %v", err)
+			return nil, nil, err
 		}
-		rc, _, out, err := tpm.RunCommandBytes(tpm2.StructTag(0x00c1), tpm2.CommandCode(0x00000015), in)
+		r, err := tpmdevice.NewSimulatorPCRReader(tpmSocket, protocol)
 		if err != nil {
-			return nil, fmt.Errorf("cannot read PCR values: %v", err)
+			return nil, nil, err
+		}
+		return r, func() { r.Close() }, nil
+	}
+
+	switch pcrSource {
+	case "device", "auto":
+		// Prefer the in-kernel resource manager device, which can be shared with other TPM users.
+		devicePath := tpmPath
+		rmPath := filepath.Join(filepath.Dir(tpmPath), strings.Replace(filepath.Base(tpmPath), "tpm", "tpmrm", 1))
+		if _, err := os.Stat(rmPath); err == nil && !strings.HasPrefix(filepath.Base(tpmPath), "tpmrm") {
+			devicePath = rmPath
+		}
+		r, err := tpmdevice.NewPCRReader(devicePath)
+		if err == nil {
+			return r, func() { r.Close() }, nil
 		}
-		if rc != tpm2.Success {
-			return nil, fmt.Errorf("cannot read PCR values: unexpected response code (0x%08x)", rc)
+		if pcrSource == "device" {
+			return nil, nil, err
 		}
-		result[i] = tcglog.DigestMap{}
-		result[i][tcglog.AlgorithmSha1] = out
+		// Opening the device normally requires root, but the PCR values are readable by anyone on kernels
+		// that expose the per-bank sysfs interface.
+		sysfs := newSysfsPCRReader()
+		if _, err2 := sysfs.ActivePCRBanks(); err2 != nil {
+			return nil, nil, err
+		}
+		return sysfs, func() {}, nil
+	case "sysfs":
+		return newSysfsPCRReader(), func() {}, nil
+	default:
+		return nil, nil, fmt.Errorf("unrecognized PCR source \"%s\"", pcrSource)
 	}
-	return result, nil
 }
 
-func getTPMDeviceVersion(tpm *tpm2.TPMContext) int {
-	if _, err := tpm.GetCapabilityTPMProperties(tpm2.PropertyManufacturer, 1); err == nil {
-		return 2
+// runInteropCheck runs tpm2_eventlog on the log and prints any disagreements with result. It returns false if
+// the check couldn't be performed or the parsers disagree.
+func runInteropCheck(result *tcglog.LogValidateResult) bool {
+	path, err := exec.LookPath("tpm2_eventlog")
+	if err != nil {
+		fmt.Printf("tpm2_eventlog is not installed, skipping interoperability check\n")
+		return true
 	}
 
-	in, err := tpm2.MarshalToBytes(uint32(0x00000005), uint32(4), uint32(0x00000103))
+	output, err := exec.Command(path, logPath).Output()
 	if err != nil {
-		return 0
+		fmt.Fprintf(os.Stderr, "Cannot run tpm2_eventlog: %v\n", err)
+		return false
 	}
-	if rc, _, _, err := tpm.RunCommandBytes(tpm2.StructTag(0x00c1), tpm2.CommandCode(0x00000065),
-		in); err == nil && rc == tpm2.Success {
-		return 1
+
+	var events []*tcglog.Event
+	for _, e := range result.ValidatedEvents {
+		events = append(events, e.Event)
+	}
+	disagreements, err := tcglog.CompareTPM2EventlogOutput(events, result.ExpectedPCRValues, bytes.NewReader(output))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%v\n", err)
+		return false
+	}
+	if len(disagreements) == 0 {
+		fmt.Printf("- The interpretation of the log is consistent with tpm2_eventlog\n")
+		return true
 	}
 
-	return 0
+	fmt.Printf("- The interpretation of the log is not consistent with tpm2_eventlog:\n")
+	for _, d := range disagreements {
+		out.Printf("  - %s\n", d)
+	}
+	return false
 }
 
-func readPCRs() (map[tcglog.PCRIndex]tcglog.DigestMap, error) {
-	tcti, err := tpm2.OpenTPMDevice(tpmPath)
+func runBankMigrationCheck(result *tcglog.LogValidateResult, reader tcglog.PCRReader) bool {
+	to, err := tcglog.ParseAlgorithm(bankMigration)
 	if err != nil {
-		return nil, fmt.Errorf("could not open TPM device: %v", err)
+		fmt.Fprintf(os.Stderr, "%v\n", err)
+		return false
 	}
-	tpm, _ := tpm2.NewTPMContext(tcti)
-	defer tpm.Close()
 
-	switch getTPMDeviceVersion(tpm) {
-	case 2:
-		return readPCRsFromTPM2Device(tpm)
-	case 1:
-		return readPCRsFromTPM1Device(tpm)
+	var values map[tcglog.PCRIndex]tcglog.DigestMap
+	if reader != nil {
+		selected := []tcglog.PCRIndex(pcrs)
+		if len(selected) == 0 {
+			for pcr := range result.ExpectedPCRValues {
+				selected = append(selected, pcr)
+			}
+		}
+		values, err = reader.ReadPCRs(selected)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Cannot read PCR values from TPM, only checking the log: %v\n", err)
+			values = nil
+		}
+	}
+
+	report := result.AdviseBankMigration(tcglog.AlgorithmSha1, to, pcrs, values)
+	if report.Ready() {
+		fmt.Printf("- Sealing policies for PCRs %v can be moved from the %s bank to the %s bank\n", report.PCRs,
+			report.From, report.To)
+		return true
 	}
 
-	return nil, errors.New("not a valid TPM device")
+	fmt.Printf("- Sealing policies can't be moved from the %s bank to the %s bank:\n", report.From, report.To)
+	for _, pcr := range report.PCRs {
+		blockers := report.BlockersForPCR(pcr)
+		if len(blockers) == 0 {
+			fmt.Printf("  - PCR %d: ready\n", pcr)
+			continue
+		}
+		for _, b := range blockers {
+			out.Printf("  - %s\n", &b)
+		}
+	}
+	return false
+}
+
+func printRemediationHints(code tcglog.FindingCode) {
+	if !remediationHints {
+		return
+	}
+	for _, hint := range tcglog.RemediationHintsForFinding(code) {
+		fmt.Printf("    hint: %s\n", hint)
+	}
+}
+
+func printFinding(f *tcglog.Finding) {
+	out.Printf("  - %s\n", f)
+	printRemediationHints(f.Code)
 }
 
 func main() {
@@ -155,16 +352,76 @@ func main() {
 		os.Exit(1)
 	}
 
+	if capabilities {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(tcglog.Capabilities()); err != nil {
+			fmt.Fprintf(os.Stderr, "Cannot encode capabilities: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	variant, err := tcglog.ParseGrubVariant(grubVariant)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%v\n", err)
+		os.Exit(1)
+	}
+
+	profile, err := tcglog.ParsePlatformProfile(platformProfile)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%v\n", err)
+		os.Exit(1)
+	}
+
+	severity, err := tcglog.ParseFindingSeverity(minSeverity)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%v\n", err)
+		os.Exit(1)
+	}
+
+	if out.Width < 0 || out.DigestChars < 0 {
+		fmt.Fprintf(os.Stderr, "The output width and digest length must not be negative\n")
+		os.Exit(1)
+	}
+
 	if !noDefaultPcrs {
 		pcrs = append(pcrs, 0, 1, 2, 3, 4, 5, 6, 7)
 		if withGrub {
-			pcrs = append(pcrs, 8, 9)
+			pcrs = append(pcrs, variant.PCRs()...)
+		}
+		if withXen {
+			pcrs = append(pcrs, tcglog.XenPCRs()...)
 		}
 	}
 
 	sort.SliceStable(pcrs, func(i, j int) bool { return pcrs[i] < pcrs[j] })
 
+	if len(resettable) == 0 {
+		resettable = tcglog.DefaultResettablePCRs
+	}
+
+	if redfishEndpoint != "" {
+		if tpmSocket != "" {
+			fmt.Fprintf(os.Stderr, "-redfish-endpoint and -tpm-socket can't be used together\n")
+			os.Exit(1)
+		}
+		if logPath == "" {
+			path, err := readRedfishLog()
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Cannot read log from Redfish service: %v\n", err)
+				os.Exit(1)
+			}
+			defer os.Remove(path)
+			logPath = path
+		}
+	}
+
 	if logPath == "" {
+		if tpmSocket != "" {
+			fmt.Fprintf(os.Stderr, "A log path must be specified with -tpm-socket\n")
+			os.Exit(1)
+		}
 		if filepath.Dir(tpmPath) != "/dev" {
 			fmt.Fprintf(os.Stderr, "Expected TPM path to be a device node in /dev")
 			os.Exit(1)
@@ -173,8 +430,31 @@ func main() {
 	} else {
 		tpmPath = ""
 	}
+	if tpmSocket != "" {
+		tpmPath = tpmSocket
+	}
+	if redfishEndpoint != "" {
+		tpmPath = redfishEndpoint
+	}
 
-	result, err := tcglog.ReplayAndValidateLog(logPath, tcglog.LogOptions{EnableGrub: withGrub, EnableSystemdEFIStub: withSdEfiStub, SystemdEFIStubPCR: tcglog.PCRIndex(sdEfiStubPcr)})
+	var pcrReader tcglog.PCRReader
+	var pcrReaderErr error
+	if tpmPath != "" {
+		r, closeReader, err := newPCRReader()
+		if err != nil {
+			pcrReaderErr = err
+		} else {
+			pcrReader = r
+			defer closeReader()
+		}
+	}
+
+	logOptions := tcglog.LogOptions{EnableGrub: withGrub, GrubVariant: variant, EnableSystemdEFIStub: withSdEfiStub, SystemdEFIStubPCR: tcglog.PCRIndex(sdEfiStubPcr), EnableXen: withXen, EnableAppMeasurements: withApp, Strict: strict, PlatformProfile: profile}
+	result, err := tcglog.ReplayAndValidateLogWithOptions(logPath, logOptions,
+		tcglog.LogValidateOptions{
+			OSPresentOnly:          osPresentOnly,
+			MinimumFindingSeverity: severity,
+			SuppressedFindings:     suppressed})
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "Failed to replay and validate log file: %v\n", err)
 		os.Exit(1)
@@ -182,6 +462,26 @@ func main() {
 
 	if len(algorithms) == 0 {
 		algorithms = AlgorithmIdArgList(result.Algorithms)
+		if tpmPath != "" {
+			// Restrict validation to the banks that are both active on the TPM and present in the log.
+			var tpmBanks tcglog.AlgorithmIdList
+			err := pcrReaderErr
+			if err == nil {
+				tpmBanks, err = pcrReader.ActivePCRBanks()
+			}
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Cannot determine the active PCR banks, validating all algorithms "+
+					"in the log: %v\n", err)
+			} else {
+				// Any mismatch between the active banks and the log is reported with the PCR values below.
+				algorithms = AlgorithmIdArgList(result.Algorithms.Intersection(tpmBanks))
+			}
+			if len(algorithms) == 0 {
+				fmt.Fprintf(os.Stderr, "None of the digest algorithms in the log correspond to an active "+
+					"PCR bank\n")
+				os.Exit(1)
+			}
+		}
 	}
 	for _, alg := range algorithms {
 		if !result.Algorithms.Contains(alg) {
@@ -190,6 +490,62 @@ func main() {
 		}
 	}
 
+	if failures := checkRequirements(result); len(failures) > 0 {
+		for _, f := range failures {
+			fmt.Fprintf(os.Stderr, "Requirement not met: %s\n", f)
+		}
+		os.Exit(1)
+	}
+
+	if snapshotPath != "" {
+		if err := ioutil.WriteFile(snapshotPath, result.ReplaySnapshot().Bytes(), 0644); err != nil {
+			fmt.Fprintf(os.Stderr, "Cannot write replay snapshot: %v\n", err)
+			os.Exit(1)
+		}
+	}
+
+	if interopCheck {
+		if !runInteropCheck(result) {
+			os.Exit(1)
+		}
+		return
+	}
+
+	if bankMigration != "" {
+		if !runBankMigrationCheck(result, pcrReader) {
+			os.Exit(1)
+		}
+		return
+	}
+
+	if complianceReport {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(result.AnnotateCompliance()); err != nil {
+			fmt.Fprintf(os.Stderr, "Cannot encode compliance report: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	if fingerprint {
+		fmt.Printf("fingerprint %x\n", result.QuirkFingerprint())
+		for _, q := range result.Quirks() {
+			fmt.Printf("quirk %s\n", q)
+		}
+		return
+	}
+
+	if result.OSPresentOnly {
+		fmt.Printf("- Only the digests of events measured after the transition to the OS-present environment " +
+			"have been validated\n\n")
+	}
+
+	if result.PFPRevision != tcglog.PFPRevisionUnknown {
+		fmt.Printf("- The log declares conformance to revision %s of the PC Client Platform Firmware Profile "+
+			"specification\n\n", result.PFPRevision)
+	}
+
 	if result.EfiBootVariableBehaviour == tcglog.EFIBootVariableBehaviourVarDataOnly {
 		fmt.Printf("- EV_EFI_VARIABLE_BOOT events only contain measurement of variable data rather than the entire UEFI_VARIABLE_DATA structure\n\n")
 	}
@@ -206,9 +562,9 @@ func main() {
 				"that was hashed and measured:\n")
 		}
 
-		fmt.Printf("  - Event %d in PCR %d (type: %s): %x (%d bytes)\n", e.Event.Index, e.Event.PCRIndex,
+		out.Printf("  - Event %d in PCR %d (type: %s): %x (%s)\n", e.Event.Index, e.Event.PCRIndex,
 			e.Event.EventType, e.MeasuredBytes[len(e.MeasuredBytes)-e.MeasuredTrailingBytesCount:len(e.MeasuredBytes)],
-			e.MeasuredTrailingBytesCount)
+			out.Size(int64(e.MeasuredTrailingBytesCount)))
 	}
 	if seenTrailingMeasuredBytes {
 		fmt.Printf("  This trailing bytes should be taken in to account when calculating updated " +
@@ -229,9 +585,9 @@ func main() {
 		}
 
 		for _, v := range e.IncorrectDigestValues {
-			fmt.Printf("  - Event %d in PCR %d (type: %s, alg: %s) - expected (from data): %x, "+
-				"got: %x\n", e.Event.Index, e.Event.PCRIndex, e.Event.EventType, v.Algorithm,
-				v.Expected, e.Event.Digests[v.Algorithm])
+			out.Printf("  - Event %d in PCR %d (type: %s, alg: %s) - expected (from data): %s, "+
+				"got: %s\n", e.Event.Index, e.Event.PCRIndex, e.Event.EventType, v.Algorithm,
+				out.Digest(v.Expected), out.Digest(e.Event.Digests[v.Algorithm]))
 		}
 	}
 	if seenIncorrectDigests {
@@ -240,35 +596,80 @@ func main() {
 			"when the components being measured are upgraded or changed in some way.\n\n")
 	}
 
+	if len(result.Findings) > 0 {
+		fmt.Printf("- The following findings were reported for the log:\n")
+		for _, f := range result.Findings {
+			printFinding(&f)
+		}
+		fmt.Printf("\n")
+	}
+
 	if tpmPath == "" {
+		logOptions.PlatformProfile = result.PlatformProfile
 		fmt.Printf("- Expected PCR values from log:\n")
 		for _, i := range pcrs {
+			out.Printf("PCR %d: %s\n", i, tcglog.DescribePCRWithOptions(i, result.Spec, logOptions))
 			for _, alg := range algorithms {
-				fmt.Printf("PCR %d, bank %s: %x\n", i, alg, result.ExpectedPCRValues[i][alg])
+				out.Printf("PCR %d, bank %s: %s\n", i, alg, out.Digest(result.ExpectedPCRValues[i][alg]))
 			}
 		}
 		return
 	}
 
-	tpmPCRValues, err := readPCRs()
+	if pcrReaderErr != nil {
+		fmt.Fprintf(os.Stderr, "Cannot read PCR values from TPM: %v", pcrReaderErr)
+		os.Exit(1)
+	}
+	activeBanks, err := pcrReader.ActivePCRBanks()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Cannot read PCR values from TPM: %v", err)
+		os.Exit(1)
+	}
+	tpmPCRValues, err := pcrReader.ReadPCRs(pcrs)
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "Cannot read PCR values from TPM: %v", err)
 		os.Exit(1)
 	}
 
-	seenLogConsistencyError := false
+	if bankFindings := tcglog.CompareBanks(result.Algorithms, activeBanks); len(bankFindings) > 0 {
+		fmt.Printf("- The PCR banks that are active on the TPM don't match the digest algorithms in the log:\n")
+		for _, f := range bankFindings {
+			printFinding(&f)
+		}
+		fmt.Printf("  Consistency checks will not be performed for PCR banks that are not active.\n\n")
+	}
+
+	values := make(map[tcglog.PCRIndex]tcglog.DigestMap)
 	for _, i := range pcrs {
+		values[i] = tcglog.DigestMap{}
 		for _, alg := range algorithms {
-			if bytes.Equal(result.ExpectedPCRValues[i][alg], tpmPCRValues[i][alg]) {
+			if !activeBanks.Contains(alg) {
 				continue
 			}
-			if !seenLogConsistencyError {
-				seenLogConsistencyError = true
-				fmt.Printf("- The log is not consistent with what was measured in to the TPM " +
-					"for some PCRs:\n")
-			}
-			fmt.Printf("  - PCR %d, bank %s - actual PCR value: %x, expected PCR value from log: %x\n",
-				i, alg, tpmPCRValues[i][alg], result.ExpectedPCRValues[i][alg])
+			values[i][alg] = tpmPCRValues[i][alg]
+		}
+	}
+
+	seenLogConsistencyError := false
+	var resets []tcglog.Finding
+	for _, f := range result.CheckPCRValues(values, resettable) {
+		if f.Code == tcglog.FindingPCRReset {
+			resets = append(resets, f)
+			continue
+		}
+		if !seenLogConsistencyError {
+			seenLogConsistencyError = true
+			fmt.Printf("- The log is not consistent with what was measured in to the TPM " +
+				"for some PCRs:\n")
+		}
+		out.Printf("  - %s\n", f.Message)
+		printRemediationHints(f.Code)
+	}
+
+	if len(resets) > 0 {
+		fmt.Printf("- The following resettable PCRs were reset after some events were measured:\n")
+		for _, f := range resets {
+			printFinding(&f)
 		}
 	}
 
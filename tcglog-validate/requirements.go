@@ -0,0 +1,196 @@
+package main
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/chrisccoulson/tcglog-parser"
+)
+
+// eventMatcher matches events against the criteria supplied to the -require-event and -forbid-event options.
+type eventMatcher struct {
+	spec      string
+	pcr       *tcglog.PCRIndex
+	eventType *tcglog.EventType
+	subject   []byte // SHA-256 fingerprint of the certificate in an EV_EFI_VARIABLE_AUTHORITY event
+	guid      string // The vendor GUID of the measured EFI variable, if specified
+	name      string // The name of the measured EFI variable
+}
+
+// parseEFIGUID checks that the supplied string is an EFI GUID in registry format, with or without braces, and
+// returns it in the form used by EFIGUID.String.
+func parseEFIGUID(s string) (string, error) {
+	s = strings.ToLower(strings.Trim(s, "{}"))
+	fields := strings.Split(s, "-")
+	if len(fields) != 5 {
+		return "", errors.New("invalid number of fields")
+	}
+	for i, n := range []int{8, 4, 4, 4, 12} {
+		if len(fields[i]) != n {
+			return "", fmt.Errorf("invalid length for field %d", i)
+		}
+		if _, err := hex.DecodeString(fields[i]); err != nil {
+			return "", err
+		}
+	}
+	return "{" + s + "}", nil
+}
+
+func parseEventMatcher(spec string) (*eventMatcher, error) {
+	m := &eventMatcher{spec: spec}
+	for _, field := range strings.Split(spec, ",") {
+		kv := strings.SplitN(field, "=", 2)
+		if len(kv) != 2 {
+			return nil, fmt.Errorf("invalid field \"%s\"", field)
+		}
+		switch kv[0] {
+		case "pcr":
+			v, err := strconv.ParseUint(kv[1], 10, 32)
+			if err != nil {
+				return nil, fmt.Errorf("invalid PCR: %v", err)
+			}
+			pcr := tcglog.PCRIndex(v)
+			m.pcr = &pcr
+		case "type":
+			t, err := tcglog.ParseEventType(kv[1])
+			if err != nil {
+				return nil, err
+			}
+			m.eventType = &t
+		case "subject":
+			s, err := hex.DecodeString(strings.Replace(kv[1], ":", "", -1))
+			if err != nil || len(s) != sha256.Size {
+				return nil, fmt.Errorf("invalid subject fingerprint \"%s\"", kv[1])
+			}
+			m.subject = s
+		case "variable":
+			// The name of an EFI variable, optionally preceded by its vendor GUID
+			// (eg, d719b2cb-3d3a-4596-a3bc-dad00e67656f/db).
+			name := kv[1]
+			if i := strings.LastIndexByte(name, '/'); i >= 0 {
+				guid, err := parseEFIGUID(name[:i])
+				if err != nil {
+					return nil, fmt.Errorf("invalid variable GUID \"%s\": %v", name[:i], err)
+				}
+				m.guid = guid
+				name = name[i+1:]
+			}
+			if name == "" {
+				return nil, fmt.Errorf("invalid variable \"%s\"", kv[1])
+			}
+			m.name = name
+		default:
+			return nil, fmt.Errorf("unrecognized field \"%s\"", kv[0])
+		}
+	}
+	return m, nil
+}
+
+func (m *eventMatcher) matches(event *tcglog.Event) bool {
+	if m.pcr != nil && event.PCRIndex != *m.pcr {
+		return false
+	}
+	if m.eventType != nil && event.EventType != *m.eventType {
+		return false
+	}
+	if m.subject != nil {
+		// The variable data of an EV_EFI_VARIABLE_AUTHORITY event is an EFI_SIGNATURE_DATA structure,
+		// which is the owner GUID followed by the certificate.
+		d, ok := event.Data.(*tcglog.EFIVariableEventData)
+		if !ok || len(d.VariableData) < 16 {
+			return false
+		}
+		h := sha256.Sum256(d.VariableData[16:])
+		if !bytes.Equal(h[:], m.subject) {
+			return false
+		}
+	}
+	if m.name != "" {
+		// Match against the decoded variable, which for an EV_EFI_VARIABLE_AUTHORITY event is the
+		// signature database that contains the authority, such as db or MokList.
+		d, ok := event.Data.(*tcglog.EFIVariableEventData)
+		if !ok || d.UnicodeName != m.name {
+			return false
+		}
+		if m.guid != "" && d.VariableName.String() != m.guid {
+			return false
+		}
+	}
+	return true
+}
+
+type eventMatcherArgList []*eventMatcher
+
+func (l *eventMatcherArgList) String() string {
+	var specs []string
+	for _, m := range *l {
+		specs = append(specs, m.spec)
+	}
+	return strings.Join(specs, " ")
+}
+
+func (l *eventMatcherArgList) Set(value string) error {
+	m, err := parseEventMatcher(value)
+	if err != nil {
+		return err
+	}
+	*l = append(*l, m)
+	return nil
+}
+
+func isSecureBootEnabled(result *tcglog.LogValidateResult) (bool, error) {
+	for _, e := range result.ValidatedEvents {
+		if e.Event.PCRIndex != 7 || e.Event.EventType != tcglog.EventTypeEFIVariableDriverConfig {
+			continue
+		}
+		d, ok := e.Event.Data.(*tcglog.EFIVariableEventData)
+		if !ok || d.VariableName != *tcglog.EFIGlobalVariableGUID || d.UnicodeName != "SecureBoot" {
+			continue
+		}
+		return bytes.Equal(d.VariableData, []byte{0x01}), nil
+	}
+	return false, errors.New("the log doesn't contain a measurement of the SecureBoot variable")
+}
+
+// checkRequirements evaluates the assertions supplied on the commandline against the log, and returns a
+// description of each one that isn't satisfied.
+func checkRequirements(result *tcglog.LogValidateResult) (failures []string) {
+	if requireSecureBoot {
+		enabled, err := isSecureBootEnabled(result)
+		switch {
+		case err != nil:
+			failures = append(failures, fmt.Sprintf("cannot determine whether secure boot is enabled: %v", err))
+		case !enabled:
+			failures = append(failures, "secure boot is not enabled")
+		}
+	}
+
+	for _, m := range requiredEvents {
+		found := false
+		for _, e := range result.ValidatedEvents {
+			if m.matches(e.Event) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			failures = append(failures, fmt.Sprintf("the log doesn't contain an event matching \"%s\"", m.spec))
+		}
+	}
+
+	for _, m := range forbiddenEvents {
+		for _, e := range result.ValidatedEvents {
+			if m.matches(e.Event) {
+				failures = append(failures, fmt.Sprintf("event %d in PCR %d (type: %s) matches forbidden "+
+					"event \"%s\"", e.Event.Index, e.Event.PCRIndex, e.Event.EventType, m.spec))
+			}
+		}
+	}
+
+	return
+}
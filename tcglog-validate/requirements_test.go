@@ -0,0 +1,194 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/chrisccoulson/tcglog-parser"
+)
+
+func TestParseEventMatcher(t *testing.T) {
+	for _, data := range []struct {
+		spec string
+		err  string
+	}{
+		{spec: "pcr=7"},
+		{spec: "pcr=7,type=EV_EFI_VARIABLE_AUTHORITY,variable=db"},
+		{spec: "subject=" + certFingerprint},
+		{spec: "subject=06:29:84:32:e8:06:6b:29:e2:22:3b:cc:23:aa:95:04:b5:6a:e5:08:fa:bf:34:35:50:88:69:b9:c3:19:0e:22"},
+		{spec: "subject=062984", err: "invalid subject fingerprint \"062984\""},
+		{spec: "subject=foo", err: "invalid subject fingerprint \"foo\""},
+		{spec: "type=0x800000e0,variable=d719b2cb-3d3a-4596-a3bc-dad00e67656f/db"},
+		{spec: "variable={D719B2CB-3D3A-4596-A3BC-DAD00E67656F}/db"},
+		{spec: "pcr", err: "invalid field \"pcr\""},
+		{spec: "pcr=foo", err: "invalid PCR: strconv.ParseUint: parsing \"foo\": invalid syntax"},
+		{spec: "type=foo", err: "unrecognized event type \"foo\""},
+		{spec: "variable=", err: "invalid variable \"\""},
+		{spec: "variable=d719b2cb-3d3a-4596-a3bc-dad00e67656f/", err: "invalid variable \"d719b2cb-3d3a-4596-a3bc-dad00e67656f/\""},
+		{spec: "variable=d719b2cb/db", err: "invalid variable GUID \"d719b2cb\": invalid number of fields"},
+		{spec: "variable=d719b2cb-3d3a-4596-a3bc-dad00e67656/db",
+			err: "invalid variable GUID \"d719b2cb-3d3a-4596-a3bc-dad00e67656\": invalid length for field 4"},
+		{spec: "foo=bar", err: "unrecognized field \"foo\""},
+	} {
+		t.Run(data.spec, func(t *testing.T) {
+			_, err := parseEventMatcher(data.spec)
+			switch {
+			case data.err == "" && err != nil:
+				t.Errorf("parseEventMatcher failed: %v", err)
+			case data.err != "" && (err == nil || err.Error() != data.err):
+				t.Errorf("Unexpected error: %v", err)
+			}
+		})
+	}
+}
+
+// certFingerprint is the SHA-256 fingerprint of the certificate in the events created by makeTestAuthorityEvent.
+const certFingerprint = "06298432e8066b29e2223bcc23aa9504b56ae508fabf3435508869b9c3190e22"
+
+func makeTestAuthorityEvent(pcr tcglog.PCRIndex, guid *tcglog.EFIGUID, name string) *tcglog.Event {
+	return &tcglog.Event{
+		PCRIndex:  pcr,
+		EventType: tcglog.EventTypeEFIVariableAuthority,
+		Data: &tcglog.EFIVariableEventData{VariableName: *guid, UnicodeName: name,
+			VariableData: append(make([]byte, 16), "cert"...)}}
+}
+
+func TestEventMatcherMatches(t *testing.T) {
+	shimGUID := tcglog.NewEFIGUID(0x605dab50, 0xe046, 0x4300, 0xabb6, [...]uint8{0x3d, 0xd8, 0x10, 0xdd, 0x8b, 0x23})
+
+	db := makeTestAuthorityEvent(7, tcglog.EFIImageSecurityDatabaseGUID, "db")
+	mok := makeTestAuthorityEvent(7, shimGUID, "MokList")
+	separator := &tcglog.Event{PCRIndex: 7, EventType: tcglog.EventTypeSeparator}
+
+	for _, data := range []struct {
+		spec    string
+		event   *tcglog.Event
+		matches bool
+	}{
+		{spec: "pcr=7", event: separator, matches: true},
+		{spec: "pcr=4", event: separator},
+		{spec: "type=EV_SEPARATOR", event: separator, matches: true},
+		{spec: "type=EV_SEPARATOR", event: db},
+		{spec: "pcr=7,type=EV_EFI_VARIABLE_AUTHORITY,variable=db", event: db, matches: true},
+		{spec: "variable=db", event: mok},
+		{spec: "variable=db", event: separator},
+		{spec: "variable=MokList", event: mok, matches: true},
+		{spec: "variable=d719b2cb-3d3a-4596-a3bc-dad00e67656f/db", event: db, matches: true},
+		{spec: "variable={D719B2CB-3D3A-4596-A3BC-DAD00E67656F}/db", event: db, matches: true},
+		{spec: "variable=605dab50-e046-4300-abb6-3dd810dd8b23/db", event: db},
+		{spec: "pcr=7,type=EV_EFI_VARIABLE_AUTHORITY,subject=" + certFingerprint, event: db, matches: true},
+		{spec: "subject=" + certFingerprint, event: mok, matches: true},
+		{spec: "subject=" + certFingerprint + ",variable=MokList", event: db},
+		{spec: "subject=d9298a10d1b0735837dc4bd85dac641b0f3cef27a47e5d53a54f2f3f5b2fcffa", event: db},
+		{spec: "subject=" + certFingerprint, event: separator},
+	} {
+		t.Run(data.spec, func(t *testing.T) {
+			m, err := parseEventMatcher(data.spec)
+			if err != nil {
+				t.Fatalf("parseEventMatcher failed: %v", err)
+			}
+			if m.matches(data.event) != data.matches {
+				t.Errorf("Unexpected result")
+			}
+		})
+	}
+}
+
+func TestCheckRequirements(t *testing.T) {
+	defer func(secureBoot bool, required, forbidden eventMatcherArgList) {
+		requireSecureBoot = secureBoot
+		requiredEvents = required
+		forbiddenEvents = forbidden
+	}(requireSecureBoot, requiredEvents, forbiddenEvents)
+
+	makeResult := func(secureBoot []byte, events ...*tcglog.Event) *tcglog.LogValidateResult {
+		result := new(tcglog.LogValidateResult)
+		if secureBoot != nil {
+			events = append([]*tcglog.Event{{
+				PCRIndex:  7,
+				EventType: tcglog.EventTypeEFIVariableDriverConfig,
+				Data: &tcglog.EFIVariableEventData{VariableName: *tcglog.EFIGlobalVariableGUID,
+					UnicodeName: "SecureBoot", VariableData: secureBoot}}}, events...)
+		}
+		for i, e := range events {
+			e.Index = uint(i)
+			result.ValidatedEvents = append(result.ValidatedEvents, &tcglog.ValidatedEvent{Event: e})
+		}
+		return result
+	}
+
+	for _, data := range []struct {
+		desc       string
+		secureBoot bool
+		required   []string
+		forbidden  []string
+		result     *tcglog.LogValidateResult
+		failures   []string
+	}{
+		{
+			desc:       "SecureBootEnabled",
+			secureBoot: true,
+			result:     makeResult([]byte{1}),
+		},
+		{
+			desc:       "SecureBootDisabled",
+			secureBoot: true,
+			result:     makeResult([]byte{0}),
+			failures:   []string{"secure boot is not enabled"},
+		},
+		{
+			desc:       "SecureBootMissing",
+			secureBoot: true,
+			result:     makeResult(nil),
+			failures: []string{"cannot determine whether secure boot is enabled: the log doesn't contain a " +
+				"measurement of the SecureBoot variable"},
+		},
+		{
+			desc:     "Required",
+			required: []string{"variable=db"},
+			result:   makeResult([]byte{1}, makeTestAuthorityEvent(7, tcglog.EFIImageSecurityDatabaseGUID, "db")),
+		},
+		{
+			desc:     "RequiredMissing",
+			required: []string{"variable=MokList"},
+			result:   makeResult([]byte{1}, makeTestAuthorityEvent(7, tcglog.EFIImageSecurityDatabaseGUID, "db")),
+			failures: []string{"the log doesn't contain an event matching \"variable=MokList\""},
+		},
+		{
+			desc:     "RequiredSubject",
+			required: []string{"subject=" + certFingerprint},
+			result:   makeResult([]byte{1}, makeTestAuthorityEvent(7, tcglog.EFIImageSecurityDatabaseGUID, "db")),
+		},
+		{
+			desc:      "Forbidden",
+			forbidden: []string{"variable=db"},
+			result:    makeResult([]byte{1}, makeTestAuthorityEvent(7, tcglog.EFIImageSecurityDatabaseGUID, "db")),
+			failures:  []string{"event 1 in PCR 7 (type: EV_EFI_VARIABLE_AUTHORITY) matches forbidden event \"variable=db\""},
+		},
+	} {
+		t.Run(data.desc, func(t *testing.T) {
+			requireSecureBoot = data.secureBoot
+			requiredEvents = nil
+			for _, s := range data.required {
+				if err := requiredEvents.Set(s); err != nil {
+					t.Fatalf("Set failed: %v", err)
+				}
+			}
+			forbiddenEvents = nil
+			for _, s := range data.forbidden {
+				if err := forbiddenEvents.Set(s); err != nil {
+					t.Fatalf("Set failed: %v", err)
+				}
+			}
+
+			failures := checkRequirements(data.result)
+			if len(failures) != len(data.failures) {
+				t.Fatalf("Unexpected failures: %q", failures)
+			}
+			for i := range failures {
+				if failures[i] != data.failures[i] {
+					t.Errorf("Unexpected failure: %s", failures[i])
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,53 @@
+//go:build tinygo
+// +build tinygo
+
+package tcglog_test
+
+import (
+	"bytes"
+	"io"
+	"testing"
+
+	"github.com/chrisccoulson/tcglog-parser"
+	"github.com/chrisccoulson/tcglog-parser/samples"
+)
+
+// TestTinyGo checks that the core parser works when built with tinygo, which has limited support for
+// reflection and for some runtime features. Run it with "tinygo test".
+func TestTinyGo(t *testing.T) {
+	for _, s := range samples.Samples() {
+		t.Run(s.Name, func(t *testing.T) {
+			raw, err := tcglog.ParseRawEvents(s.Data)
+			if err != nil {
+				t.Fatalf("ParseRawEvents failed: %v", err)
+			}
+
+			log, err := tcglog.NewLog(bytes.NewReader(s.Data), s.Options)
+			if err != nil {
+				t.Fatalf("NewLog failed: %v", err)
+			}
+			if log.Spec != s.Spec {
+				t.Errorf("Unexpected spec (%v)", log.Spec)
+			}
+
+			n := 0
+			for {
+				event, err := log.NextEvent()
+				if err == io.EOF {
+					break
+				}
+				if err != nil {
+					t.Fatalf("NextEvent failed: %v", err)
+				}
+				if event.Data == nil {
+					t.Errorf("Event %d has no data", event.Index)
+				}
+				_ = event.Data.String()
+				n++
+			}
+			if n != len(raw.Events) {
+				t.Errorf("Unexpected number of events (got %d, expected %d)", n, len(raw.Events))
+			}
+		})
+	}
+}
@@ -0,0 +1,146 @@
+package tpmdevice
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+
+	"github.com/chrisccoulson/go-tpm2"
+)
+
+// SimulatorProtocol describes the protocol used to send commands to a TPM simulator over a socket.
+type SimulatorProtocol int
+
+const (
+	// SimulatorProtocolRaw corresponds to the protocol used by the server socket of swtpm, where commands and
+	// responses are sent without any additional framing.
+	SimulatorProtocolRaw SimulatorProtocol = iota
+
+	// SimulatorProtocolMssim corresponds to the protocol used by the TPM port of the Microsoft / IBM TPM 2.0
+	// reference simulator, where each command is framed with a TPM_SEND_COMMAND header.
+	SimulatorProtocolMssim
+)
+
+// ParseSimulatorProtocol parses a SimulatorProtocol from its name ("raw" or "mssim").
+func ParseSimulatorProtocol(protocol string) (SimulatorProtocol, error) {
+	switch protocol {
+	case "raw", "swtpm":
+		return SimulatorProtocolRaw, nil
+	case "mssim":
+		return SimulatorProtocolMssim, nil
+	default:
+		return 0, fmt.Errorf("Unrecognized simulator protocol \"%s\"", protocol)
+	}
+}
+
+// https://trustedcomputinggroup.org/wp-content/uploads/TCG_TPM2_r1p59_Part1_Architecture_pub.pdf
+//  (section 18 "TPM Command/Response Structure")
+const tpmHeaderSize = 10
+
+// maxResponseSize is the largest response that is accepted from a simulator, which is the MAX_RESPONSE_SIZE of
+// a PC Client TPM.
+const maxResponseSize = 4096
+
+// mssimSendCommand is the TPM_SEND_COMMAND code of the reference simulator's TPM port.
+const mssimSendCommand uint32 = 8
+
+// socketTCTI sends TPM commands over a stream socket. Reads return one complete response at a time, as they do
+// for a TPM character device.
+type socketTCTI struct {
+	conn     net.Conn
+	protocol SimulatorProtocol
+	rsp      *bytes.Reader
+}
+
+func (t *socketTCTI) Write(data []byte) (int, error) {
+	t.rsp = nil
+
+	var buf bytes.Buffer
+	if t.protocol == SimulatorProtocolMssim {
+		binary.Write(&buf, binary.BigEndian, mssimSendCommand)
+		buf.WriteByte(0) // Locality
+		binary.Write(&buf, binary.BigEndian, uint32(len(data)))
+	}
+	buf.Write(data)
+	if _, err := t.conn.Write(buf.Bytes()); err != nil {
+		return 0, err
+	}
+	return len(data), nil
+}
+
+func (t *socketTCTI) readResponse() ([]byte, error) {
+	if t.protocol == SimulatorProtocolMssim {
+		var size uint32
+		if err := binary.Read(t.conn, binary.BigEndian, &size); err != nil {
+			return nil, err
+		}
+		if size > maxResponseSize {
+			return nil, errors.New("invalid response size")
+		}
+		rsp := make([]byte, size)
+		if _, err := io.ReadFull(t.conn, rsp); err != nil {
+			return nil, err
+		}
+		var ack uint32
+		if err := binary.Read(t.conn, binary.BigEndian, &ack); err != nil {
+			return nil, err
+		}
+		if ack != 0 {
+			return nil, fmt.Errorf("simulator returned an error (%d)", ack)
+		}
+		return rsp, nil
+	}
+
+	hdr := make([]byte, tpmHeaderSize)
+	if _, err := io.ReadFull(t.conn, hdr); err != nil {
+		return nil, err
+	}
+	size := binary.BigEndian.Uint32(hdr[2:6])
+	if size < tpmHeaderSize || size > maxResponseSize {
+		return nil, errors.New("invalid response size")
+	}
+	rsp := make([]byte, size)
+	copy(rsp, hdr)
+	if _, err := io.ReadFull(t.conn, rsp[tpmHeaderSize:]); err != nil {
+		return nil, err
+	}
+	return rsp, nil
+}
+
+func (t *socketTCTI) Read(data []byte) (int, error) {
+	if t.rsp == nil {
+		rsp, err := t.readResponse()
+		if err != nil {
+			return 0, err
+		}
+		t.rsp = bytes.NewReader(rsp)
+	}
+	return t.rsp.Read(data)
+}
+
+func (t *socketTCTI) Close() error {
+	return t.conn.Close()
+}
+
+// NewSimulatorPCRReader connects to the TPM simulator at the specified address, which is of the form
+// "tcp:<host>:<port>" or "unix:<path>", and returns a new PCRReader for it. This allows validation to be
+// performed against swtpm or the reference simulator in environments without TPM hardware. The simulator must
+// already be powered on and started. The returned PCRReader should be closed with Close when it is no longer
+// needed.
+func NewSimulatorPCRReader(address string, protocol SimulatorProtocol) (*PCRReader, error) {
+	fields := strings.SplitN(address, ":", 2)
+	if len(fields) != 2 || (fields[0] != "tcp" && fields[0] != "unix") {
+		return nil, fmt.Errorf("invalid simulator address \"%s\"", address)
+	}
+	conn, err := net.Dial(fields[0], fields[1])
+	if err != nil {
+		return nil, fmt.Errorf("cannot connect to simulator: %w", err)
+	}
+
+	tpm, _ := tpm2.NewTPMContext(&socketTCTI{conn: conn, protocol: protocol})
+	return newPCRReader(tpm)
+}
@@ -0,0 +1,94 @@
+package tpmdevice
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"net"
+	"testing"
+)
+
+func TestSocketTCTI(t *testing.T) {
+	cmd := []byte{0x80, 0x01, 0x00, 0x00, 0x00, 0x0c, 0x00, 0x00, 0x01, 0x7b, 0x00, 0x08}
+	rsp := []byte{0x80, 0x01, 0x00, 0x00, 0x00, 0x0e, 0x00, 0x00, 0x00, 0x00, 0xde, 0xad, 0xbe, 0xef}
+
+	for _, data := range []struct {
+		desc     string
+		protocol SimulatorProtocol
+		framed   bool
+	}{
+		{"Raw", SimulatorProtocolRaw, false},
+		{"Mssim", SimulatorProtocolMssim, true},
+	} {
+		t.Run(data.desc, func(t *testing.T) {
+			client, server := net.Pipe()
+			defer client.Close()
+			defer server.Close()
+
+			go func() {
+				var expected bytes.Buffer
+				if data.framed {
+					binary.Write(&expected, binary.BigEndian, mssimSendCommand)
+					expected.WriteByte(0)
+					binary.Write(&expected, binary.BigEndian, uint32(len(cmd)))
+				}
+				expected.Write(cmd)
+				received := make([]byte, expected.Len())
+				if _, err := io.ReadFull(server, received); err != nil || !bytes.Equal(received, expected.Bytes()) {
+					server.Close()
+					return
+				}
+
+				var out bytes.Buffer
+				if data.framed {
+					binary.Write(&out, binary.BigEndian, uint32(len(rsp)))
+				}
+				out.Write(rsp)
+				if data.framed {
+					binary.Write(&out, binary.BigEndian, uint32(0))
+				}
+				// Send the response in two parts to check that it is reassembled.
+				server.Write(out.Bytes()[:3])
+				server.Write(out.Bytes()[3:])
+			}()
+
+			tcti := &socketTCTI{conn: client, protocol: data.protocol}
+			if _, err := tcti.Write(cmd); err != nil {
+				t.Fatalf("Write failed: %v", err)
+			}
+			buf := make([]byte, 4096)
+			n, err := tcti.Read(buf)
+			if err != nil {
+				t.Fatalf("Read failed: %v", err)
+			}
+			if !bytes.Equal(buf[:n], rsp) {
+				t.Errorf("Unexpected response: %x", buf[:n])
+			}
+		})
+	}
+}
+
+func TestSocketTCTIInvalidResponseSize(t *testing.T) {
+	for _, data := range []struct {
+		desc     string
+		protocol SimulatorProtocol
+		rsp      []byte
+	}{
+		{"Raw", SimulatorProtocolRaw, []byte{0x80, 0x01, 0xff, 0xff, 0xff, 0xff, 0x00, 0x00, 0x00, 0x00}},
+		{"Mssim", SimulatorProtocolMssim, []byte{0xff, 0xff, 0xff, 0xff}},
+		{"MssimTooLarge", SimulatorProtocolMssim, []byte{0x00, 0x00, 0x10, 0x01}},
+	} {
+		t.Run(data.desc, func(t *testing.T) {
+			client, server := net.Pipe()
+			defer client.Close()
+			defer server.Close()
+
+			go server.Write(data.rsp)
+
+			tcti := &socketTCTI{conn: client, protocol: data.protocol}
+			if _, err := tcti.Read(make([]byte, 4096)); err == nil || err.Error() != "invalid response size" {
+				t.Errorf("Unexpected error: %v", err)
+			}
+		})
+	}
+}
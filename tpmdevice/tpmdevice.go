@@ -0,0 +1,163 @@
+// Package tpmdevice provides access to the PCRs of a TPM through its character device, such as /dev/tpmrm0 or
+// /dev/tpm0, or through the socket of a TPM simulator.
+package tpmdevice
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/chrisccoulson/go-tpm2"
+	"github.com/chrisccoulson/tcglog-parser"
+)
+
+var allBanks = tcglog.AlgorithmIdList{tcglog.AlgorithmSha1, tcglog.AlgorithmSha256, tcglog.AlgorithmSha384,
+	tcglog.AlgorithmSha512}
+
+// PCRReader is a tcglog.PCRReader that reads PCR values from a TPM device. Both TPM 1.2 and TPM 2.0 devices are
+// supported.
+type PCRReader struct {
+	tpm     *tpm2.TPMContext
+	version int
+}
+
+func getTPMDeviceVersion(tpm *tpm2.TPMContext) int {
+	if _, err := tpm.GetCapabilityTPMProperties(tpm2.PropertyManufacturer, 1); err == nil {
+		return 2
+	}
+
+	in, err := tpm2.MarshalToBytes(uint32(0x00000005), uint32(4), uint32(0x00000103))
+	if err != nil {
+		return 0
+	}
+	if rc, _, _, err := tpm.RunCommandBytes(tpm2.StructTag(0x00c1), tpm2.CommandCode(0x00000065),
+		in); err == nil && rc == tpm2.Success {
+		return 1
+	}
+
+	return 0
+}
+
+// NewPCRReader opens the TPM device at the specified path and returns a new PCRReader for it. The returned
+// PCRReader should be closed with Close when it is no longer needed.
+func NewPCRReader(path string) (*PCRReader, error) {
+	tcti, err := tpm2.OpenTPMDevice(path)
+	if err != nil {
+		return nil, fmt.Errorf("could not open TPM device: %w", err)
+	}
+	tpm, _ := tpm2.NewTPMContext(tcti)
+	return newPCRReader(tpm)
+}
+
+func newPCRReader(tpm *tpm2.TPMContext) (*PCRReader, error) {
+	version := getTPMDeviceVersion(tpm)
+	if version == 0 {
+		tpm.Close()
+		return nil, errors.New("not a valid TPM device")
+	}
+	return &PCRReader{tpm: tpm, version: version}, nil
+}
+
+// Close closes the TPM device.
+func (r *PCRReader) Close() error {
+	return r.tpm.Close()
+}
+
+func pcrIndexListToSelectionData(l []tcglog.PCRIndex) (out tpm2.PCRSelectionData) {
+	for _, i := range l {
+		out = append(out, int(i))
+	}
+	return
+}
+
+// maxPCRReadDigests is the maximum number of digests that a TPM2_PCR_Read response is guaranteed to contain. The
+// response to a command that selects more PCRs than this may only contain some of them.
+const maxPCRReadDigests = 8
+
+// readTPM2PCRs reads the specified PCRs from every bank. Inactive banks are detected by the TPM omitting them
+// from the TPM2_PCR_Read response rather than by using TPM2_GetCapability, which some virtual TPMs don't
+// implement correctly. Each bank is read separately and in chunks, so that all of the selected PCRs are read
+// even from TPMs that return a partial response.
+func (r *PCRReader) readTPM2PCRs(pcrs []tcglog.PCRIndex) (map[tcglog.PCRIndex]tcglog.DigestMap, tcglog.AlgorithmIdList, error) {
+	result := make(map[tcglog.PCRIndex]tcglog.DigestMap)
+	for _, i := range pcrs {
+		result[i] = tcglog.DigestMap{}
+	}
+
+	var activeBanks tcglog.AlgorithmIdList
+	for _, alg := range allBanks {
+		hash := tpm2.HashAlgorithmId(alg)
+		for start := 0; start < len(pcrs) || start == 0; start += maxPCRReadDigests {
+			end := start + maxPCRReadDigests
+			if end > len(pcrs) {
+				end = len(pcrs)
+			}
+			chunk := pcrs[start:end]
+			if len(chunk) == 0 {
+				// Read PCR 0 to determine whether the bank is active.
+				chunk = []tcglog.PCRIndex{0}
+			}
+
+			_, digests, err := r.tpm.PCRRead(tpm2.PCRSelectionList{
+				tpm2.PCRSelection{Hash: hash, Select: pcrIndexListToSelectionData(chunk)}})
+			if err != nil {
+				return nil, nil, fmt.Errorf("cannot read PCR values: %w", err)
+			}
+			if len(digests[hash]) == 0 {
+				if start == 0 {
+					// The bank isn't active.
+					break
+				}
+				return nil, nil, fmt.Errorf("cannot read PCR values from bank %s", alg)
+			}
+			if start == 0 {
+				activeBanks = append(activeBanks, alg)
+			}
+			for _, i := range pcrs[start:end] {
+				digest, ok := digests[hash][int(i)]
+				if !ok {
+					return nil, nil, fmt.Errorf("TPM didn't return a value for PCR %d, bank %s", i, alg)
+				}
+				result[i][alg] = tcglog.Digest(digest)
+			}
+		}
+	}
+	return result, activeBanks, nil
+}
+
+func (r *PCRReader) readTPM1PCRs(pcrs []tcglog.PCRIndex) (map[tcglog.PCRIndex]tcglog.DigestMap, error) {
+	result := make(map[tcglog.PCRIndex]tcglog.DigestMap)
+	for _, i := range pcrs {
+		in, err := tpm2.MarshalToBytes(uint32(i))
+		if err != nil {
+			return nil, fmt.Errorf("cannot read PCR values due to a marshalling error: %w", err)
+		}
+		rc, _, out, err := r.tpm.RunCommandBytes(tpm2.StructTag(0x00c1), tpm2.CommandCode(0x00000015), in)
+		if err != nil {
+			return nil, fmt.Errorf("cannot read PCR values: %w", err)
+		}
+		if rc != tpm2.Success {
+			return nil, fmt.Errorf("cannot read PCR values: unexpected response code (0x%08x)", rc)
+		}
+		result[i] = tcglog.DigestMap{}
+		result[i][tcglog.AlgorithmSha1] = out
+	}
+	return result, nil
+}
+
+// ActivePCRBanks implements tcglog.PCRReader.ActivePCRBanks.
+func (r *PCRReader) ActivePCRBanks() (tcglog.AlgorithmIdList, error) {
+	if r.version == 1 {
+		return tcglog.AlgorithmIdList{tcglog.AlgorithmSha1}, nil
+	}
+	_, banks, err := r.readTPM2PCRs([]tcglog.PCRIndex{0})
+	return banks, err
+}
+
+// ReadPCRs implements tcglog.PCRReader.ReadPCRs.
+func (r *PCRReader) ReadPCRs(pcrs []tcglog.PCRIndex) (map[tcglog.PCRIndex]tcglog.DigestMap, error) {
+	if r.version == 1 {
+		return r.readTPM1PCRs(pcrs)
+	}
+	values, _, err := r.readTPM2PCRs(pcrs)
+	return values, err
+}
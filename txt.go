@@ -0,0 +1,63 @@
+package tcglog
+
+import (
+	"encoding/hex"
+	"fmt"
+)
+
+// txtEventDescriptions contains a description of what is measured by each Intel TXT event type.
+var txtEventDescriptions = map[EventType]string{
+	EventTypeTXTPCRMapping:         "PCR mapping",
+	EventTypeTXTHashStart:          "SINIT ACM measurement",
+	EventTypeTXTCombinedHash:       "Combined hash of the launch components",
+	EventTypeTXTMLEHash:            "MLE measurement",
+	EventTypeTXTBIOSACRegData:      "BIOS ACM registration data",
+	EventTypeTXTCPUSCRTMStat:       "CPU S-CRTM status",
+	EventTypeTXTLCPControlHash:     "Launch control policy control field",
+	EventTypeTXTElementsDataHash:   "Launch control policy elements data",
+	EventTypeTXTSTMHash:            "SMI transfer monitor measurement",
+	EventTypeTXTOSSINITDataCapHash: "OsSinitData capabilities",
+	EventTypeTXTSINITPubKeyHash:    "SINIT ACM public key",
+	EventTypeTXTLCPHash:            "Launch control policy",
+	EventTypeTXTLCPDetailsHash:     "Launch control policy details",
+	EventTypeTXTLCPAuthoritiesHash: "Launch control policy authorities",
+	EventTypeTXTNVInfoHash:         "Launch control policy NV index information",
+	EventTypeTXTColdBootBIOSHash:   "BIOS measurement from a cold boot",
+	EventTypeTXTKMHash:             "Boot Guard key manifest",
+	EventTypeTXTBPMHash:            "Boot Guard boot policy manifest",
+	EventTypeTXTKMInfoHash:         "Boot Guard key manifest signer",
+	EventTypeTXTBPMInfoHash:        "Boot Guard boot policy manifest signer",
+	EventTypeTXTBootPolicyHash:     "Boot Guard boot policy",
+	EventTypeTXTRandomValue:        "Random value",
+	EventTypeTXTCapValue:           "Cap value",
+}
+
+// isTXTEventType indicates whether the specified event type is one that is recorded by Intel TXT.
+func isTXTEventType(t EventType) bool {
+	_, ok := txtEventDescriptions[t]
+	return ok
+}
+
+// TXTEventData corresponds to the data of an event recorded by Intel TXT in PCRs 17 and 18 during a dynamic
+// launch. The event data is the measured data for some event types, such as EVTYPE_CPU_SCRTM_STAT, and is empty
+// for events that measure components that aren't recorded in the log, such as the MLE.
+type TXTEventData struct {
+	data        []byte
+	Type        EventType
+	Description string // A description of what is measured by this type of event
+}
+
+func (e *TXTEventData) String() string {
+	if len(e.data) == 0 {
+		return fmt.Sprintf("txt{ %s }", e.Description)
+	}
+	return fmt.Sprintf("txt{ %s, data: %s }", e.Description, hex.EncodeToString(e.data))
+}
+
+func (e *TXTEventData) Bytes() []byte {
+	return e.data
+}
+
+func decodeEventDataTXT(eventType EventType, data []byte) *TXTEventData {
+	return &TXTEventData{data: data, Type: eventType, Description: txtEventDescriptions[eventType]}
+}
@@ -0,0 +1,33 @@
+package tcglog
+
+import (
+	"testing"
+)
+
+func TestDecodeTXTEvents(t *testing.T) {
+	options := &LogOptions{}
+
+	out, _ := decodeEventData(17, EventTypeTXTHashStart, nil, options, false)
+	d, ok := out.(*TXTEventData)
+	if !ok || d.Type != EventTypeTXTHashStart || d.Description != "SINIT ACM measurement" {
+		t.Fatalf("Unexpected event data %#v", out)
+	}
+	if d.String() != "txt{ SINIT ACM measurement }" {
+		t.Errorf("Unexpected string %s", d)
+	}
+
+	out, _ = decodeEventData(17, EventTypeTXTCPUSCRTMStat, []byte{0x01, 0x00, 0x00, 0x00}, options, false)
+	if d, ok := out.(*TXTEventData); !ok || d.String() != "txt{ CPU S-CRTM status, data: 01000000 }" {
+		t.Errorf("Unexpected event data %#v", out)
+	}
+
+	if eventType, err := ParseEventType("EVTYPE_MLE_HASH"); err != nil || eventType != EventTypeTXTMLEHash {
+		t.Errorf("Unexpected result from ParseEventType: %v, %v", eventType, err)
+	}
+
+	// TXT event types are only decoded in PCRs 17-22.
+	out, _ = decodeEventData(0, EventTypeTXTMLEHash, nil, options, false)
+	if _, ok := out.(*TXTEventData); ok {
+		t.Errorf("Unexpected event data %#v", out)
+	}
+}
@@ -0,0 +1,148 @@
+package tcglog
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+)
+
+// https://www.intel.com/content/dam/www/public/us/en/documents/guides/intel-txt-software-development-guide.pdf
+//  (appendix F.1 "TPM 1.2 Event Log")
+const (
+	txtEventContainerSignature  = "TXT Event Container\x00"
+	txtEventContainerHeaderSize = 48
+)
+
+// TXTEventContainer corresponds to the header of the TPM 1.2 TXT event log container, which tboot and SINIT ACMs
+// place in the TXT heap to record the measurements made during a dynamic launch.
+type TXTEventContainer struct {
+	ContainerVersionMajor uint8
+	ContainerVersionMinor uint8
+	PCREventVersionMajor  uint8
+	PCREventVersionMinor  uint8
+	Size                  uint32 // The size of the container, including the header
+	PCREventsOffset       uint32 // The offset of the first event from the start of the container
+	NextEventOffset       uint32 // The offset at which the next event will be written
+}
+
+// decodeTXTEventContainer decodes the TXT event log container header at the start of data. If data doesn't
+// begin with a container header, it returns nil and no error.
+func decodeTXTEventContainer(data []byte) (*TXTEventContainer, error) {
+	if !bytes.HasPrefix(data, []byte(txtEventContainerSignature)) {
+		return nil, nil
+	}
+	if len(data) < txtEventContainerHeaderSize {
+		return nil, fmt.Errorf("TXT event container header is too small (got %d bytes, expected %d bytes)",
+			len(data), txtEventContainerHeaderSize)
+	}
+
+	c := &TXTEventContainer{
+		ContainerVersionMajor: data[32],
+		ContainerVersionMinor: data[33],
+		PCREventVersionMajor:  data[34],
+		PCREventVersionMinor:  data[35],
+		Size:                  binary.LittleEndian.Uint32(data[36:]),
+		PCREventsOffset:       binary.LittleEndian.Uint32(data[40:]),
+		NextEventOffset:       binary.LittleEndian.Uint32(data[44:])}
+
+	switch {
+	case c.ContainerVersionMajor != 1:
+		return nil, fmt.Errorf("unsupported TXT event container version %d.%d", c.ContainerVersionMajor,
+			c.ContainerVersionMinor)
+	case c.PCREventsOffset < txtEventContainerHeaderSize || c.PCREventsOffset > c.NextEventOffset:
+		return nil, fmt.Errorf("invalid TXT event container events offset (%d)", c.PCREventsOffset)
+	case c.NextEventOffset > c.Size || int64(c.NextEventOffset) > int64(len(data)):
+		return nil, fmt.Errorf("invalid TXT event container next event offset (%d)", c.NextEventOffset)
+	}
+	return c, nil
+}
+
+// NewTXTLog creates a new Log instance that reads the event log of a dynamic launch from the TXT heap, as exposed
+// by tboot. For TPM 1.2, the log is wrapped in a TXT event log container and contains SHA-1 events in the TCG 1.2
+// format. For TPM 2.0, the log is in the crypto-agile format and is read like any other log.
+func NewTXTLog(data []byte, options LogOptions) (*Log, error) {
+	c, err := decodeTXTEventContainer(data)
+	if err != nil {
+		return nil, err
+	}
+	if c != nil {
+		data = data[c.PCREventsOffset:c.NextEventOffset]
+	}
+	return NewLog(bytes.NewReader(data), options)
+}
+
+// LaunchChain is the result of merging the log of the static root of trust for measurement, produced by the
+// platform firmware, with the log of a subsequent dynamic launch, so that a complete measured launch can be
+// analyzed in one place.
+type LaunchChain struct {
+	Spec       Spec            // The specification to which the SRTM log conforms
+	Algorithms AlgorithmIdList // The digest algorithms that appear in both logs
+	SRTMEvents []*Event        // The events from the SRTM log
+	DRTMEvents []*Event        // The events from the DRTM log
+
+	// PCRValues contains the expected PCR values after replaying the events from both logs.
+	PCRValues map[PCRIndex]DigestMap
+}
+
+// Events returns the events from both logs as a single stream, in the order in which they were measured. The
+// Index field of each event is relative to the log that it was read from.
+func (c *LaunchChain) Events() []*Event {
+	events := make([]*Event, 0, len(c.SRTMEvents)+len(c.DRTMEvents))
+	events = append(events, c.SRTMEvents...)
+	return append(events, c.DRTMEvents...)
+}
+
+func readAllEvents(log *Log) ([]*Event, error) {
+	var events []*Event
+	for {
+		event, err := log.NextEvent()
+		if err == io.EOF {
+			return events, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		events = append(events, event)
+	}
+}
+
+// MergeLaunchLogs reads all of the events from the supplied SRTM and DRTM logs, which should be in their initial
+// state, and merges them in to a single launch chain. Events in the DRTM log that extend PCRs other than 17-22
+// are rejected, as these PCRs are the only ones that a dynamic launch can measure to. The expected PCR values are
+// computed by replaying the SRTM events followed by the DRTM events, with PCRs 17-22 being reset at the start of
+// the dynamic launch. An error is returned if an event has a digest for an algorithm that isn't supported.
+func MergeLaunchLogs(srtm, drtm *Log) (*LaunchChain, error) {
+	out := &LaunchChain{Spec: srtm.Spec}
+	for _, alg := range srtm.Algorithms {
+		if drtm.Algorithms.Contains(alg) {
+			out.Algorithms = append(out.Algorithms, alg)
+		}
+	}
+
+	var err error
+	if out.SRTMEvents, err = readAllEvents(srtm); err != nil {
+		return nil, fmt.Errorf("cannot read SRTM log: %w", err)
+	}
+	if out.DRTMEvents, err = readAllEvents(drtm); err != nil {
+		return nil, fmt.Errorf("cannot read DRTM log: %w", err)
+	}
+	for _, event := range out.DRTMEvents {
+		if doesEventTypeExtendPCR(event.EventType) && !isDRTMPCR(event.PCRIndex) {
+			return nil, fmt.Errorf("DRTM log event %d extends PCR %d, which isn't reset by a dynamic launch",
+				event.Index, event.PCRIndex)
+		}
+	}
+
+	out.PCRValues = make(map[PCRIndex]DigestMap)
+	if err := extendPCRValues(out.PCRValues, out.SRTMEvents); err != nil {
+		return nil, err
+	}
+	// Some firmware measures to PCRs 17-22 before the dynamic launch, so reset them explicitly here rather
+	// than relying on detecting the launch from the DRTM events.
+	resetDRTMPCRs(out.PCRValues)
+	if err := extendPCRValues(out.PCRValues, out.DRTMEvents); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
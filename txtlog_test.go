@@ -0,0 +1,113 @@
+package tcglog
+
+import (
+	"bytes"
+	"crypto/sha1"
+	"encoding/binary"
+	"strings"
+	"testing"
+)
+
+type testTXTEvent struct {
+	pcr       PCRIndex
+	eventType EventType
+	data      []byte
+}
+
+// makeTestTXTLog creates a TPM 1.2 TXT event log container with SHA-1 events.
+func makeTestTXTLog(events []testTXTEvent) []byte {
+	var log bytes.Buffer
+	for _, e := range events {
+		digest := sha1.Sum(e.data)
+		binary.Write(&log, binary.LittleEndian, eventHeader_1_2{PCRIndex: e.pcr, EventType: e.eventType})
+		log.Write(digest[:])
+		binary.Write(&log, binary.LittleEndian, uint32(len(e.data)))
+		log.Write(e.data)
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString(txtEventContainerSignature)
+	buf.Write(make([]byte, 12))
+	buf.Write([]byte{1, 0, 1, 0})
+	binary.Write(&buf, binary.LittleEndian, uint32(txtEventContainerHeaderSize+log.Len()+64))
+	binary.Write(&buf, binary.LittleEndian, uint32(txtEventContainerHeaderSize))
+	binary.Write(&buf, binary.LittleEndian, uint32(txtEventContainerHeaderSize+log.Len()))
+	buf.Write(log.Bytes())
+	// Unused space at the end of the container
+	buf.Write(make([]byte, 64))
+	return buf.Bytes()
+}
+
+func TestMergeLaunchLogs(t *testing.T) {
+	drtmData := makeTestTXTLog([]testTXTEvent{
+		{pcr: 17, eventType: EventTypeTXTHashStart, data: []byte("sinit")},
+		{pcr: 18, eventType: EventTypeTXTMLEHash, data: []byte("mle")}})
+
+	drtm, err := NewTXTLog(drtmData, LogOptions{})
+	if err != nil {
+		t.Fatalf("NewTXTLog failed: %v", err)
+	}
+	if !drtm.Algorithms.Contains(AlgorithmSha1) || len(drtm.Algorithms) != 1 {
+		t.Errorf("Unexpected DRTM log algorithms %v", drtm.Algorithms)
+	}
+
+	srtm, err := NewLog(bytes.NewReader(makeTestCryptoAgileLog(t, 3)), LogOptions{})
+	if err != nil {
+		t.Fatalf("NewLog failed: %v", err)
+	}
+
+	chain, err := MergeLaunchLogs(srtm, drtm)
+	if err != nil {
+		t.Fatalf("MergeLaunchLogs failed: %v", err)
+	}
+	if len(chain.Algorithms) != 1 || chain.Algorithms[0] != AlgorithmSha1 {
+		t.Errorf("Unexpected algorithms %v", chain.Algorithms)
+	}
+	if len(chain.SRTMEvents) != 4 || len(chain.DRTMEvents) != 2 {
+		t.Fatalf("Unexpected number of events (SRTM: %d, DRTM: %d)", len(chain.SRTMEvents), len(chain.DRTMEvents))
+	}
+	events := chain.Events()
+	if len(events) != 6 || events[4].EventType != EventTypeTXTHashStart {
+		t.Errorf("Unexpected merged events")
+	}
+	if _, ok := events[4].Data.(*TXTEventData); !ok {
+		t.Errorf("Unexpected event data type %T", events[4].Data)
+	}
+
+	for i, pcr := range []PCRIndex{17, 18} {
+		digest := chain.DRTMEvents[i].Digests[AlgorithmSha1]
+		expected := performHashExtendOperation(AlgorithmSha1, make(Digest, AlgorithmSha1.size()), digest)
+		if !bytes.Equal(chain.PCRValues[pcr][AlgorithmSha1], expected) {
+			t.Errorf("Unexpected value for PCR %d: %x", pcr, chain.PCRValues[pcr][AlgorithmSha1])
+		}
+	}
+	if _, exists := chain.PCRValues[0][AlgorithmSha256]; !exists {
+		t.Errorf("Missing SRTM PCR values")
+	}
+}
+
+func TestMergeLaunchLogsRejectsNonDRTMPCRs(t *testing.T) {
+	drtm, err := NewTXTLog(makeTestTXTLog([]testTXTEvent{
+		{pcr: 17, eventType: EventTypeTXTHashStart, data: []byte("sinit")},
+		{pcr: 4, eventType: EventTypeTXTMLEHash, data: []byte("mle")}}), LogOptions{})
+	if err != nil {
+		t.Fatalf("NewTXTLog failed: %v", err)
+	}
+	srtm, err := NewLog(bytes.NewReader(makeTestCryptoAgileLog(t, 1)), LogOptions{})
+	if err != nil {
+		t.Fatalf("NewLog failed: %v", err)
+	}
+
+	_, err = MergeLaunchLogs(srtm, drtm)
+	if err == nil || !strings.Contains(err.Error(), "extends PCR 4") {
+		t.Errorf("Unexpected error: %v", err)
+	}
+}
+
+func TestNewTXTLogInvalidContainer(t *testing.T) {
+	data := makeTestTXTLog(nil)
+	binary.LittleEndian.PutUint32(data[44:], uint32(len(data)+1))
+	if _, err := NewTXTLog(data, LogOptions{}); err == nil {
+		t.Errorf("NewTXTLog should have failed")
+	}
+}
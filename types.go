@@ -33,21 +33,42 @@ func (a AlgorithmId) getHash() crypto.Hash {
 		return crypto.SHA384
 	case AlgorithmSha512:
 		return crypto.SHA512
+	case AlgorithmSha3_256:
+		return crypto.SHA3_256
+	case AlgorithmSha3_384:
+		return crypto.SHA3_384
+	case AlgorithmSha3_512:
+		return crypto.SHA3_512
 	default:
 		return 0
 	}
 }
 
+// supported indicates whether digests with this algorithm can be computed. The SHA-3 family is only supported
+// when building with a version of Go that provides it. Other algorithms are supported if they have been
+// registered with RegisterAlgorithm.
 func (a AlgorithmId) supported() bool {
-	return a.getHash() != crypto.Hash(0)
+	if h := a.getHash(); h != crypto.Hash(0) && h.Available() {
+		return true
+	}
+	return lookupRegisteredAlgorithm(a) != nil
 }
 
 func (a AlgorithmId) size() int {
-	return a.getHash().Size()
+	if h := a.getHash(); h != crypto.Hash(0) {
+		return h.Size()
+	}
+	if r := lookupRegisteredAlgorithm(a); r != nil {
+		return r.size
+	}
+	return 0
 }
 
 func (a AlgorithmId) newHash() hash.Hash {
-	return a.getHash().New()
+	if h := a.getHash(); h != crypto.Hash(0) && h.Available() {
+		return h.New()
+	}
+	return lookupRegisteredAlgorithm(a).newHash()
 }
 
 func (a AlgorithmId) hash(data []byte) []byte {
@@ -118,10 +139,76 @@ func (e EventType) String() string {
 		return "EV_EFI_PLATFORM_FIRMWARE_BLOB"
 	case EventTypeEFIHandoffTables:
 		return "EV_EFI_HANDOFF_TABLES"
+	case EventTypeEFIPlatformFirmwareBlob2:
+		return "EV_EFI_PLATFORM_FIRMWARE_BLOB2"
+	case EventTypeEFIHandoffTables2:
+		return "EV_EFI_HANDOFF_TABLES2"
+	case EventTypeEFIVariableBoot2:
+		return "EV_EFI_VARIABLE_BOOT2"
 	case EventTypeEFIHCRTMEvent:
 		return "EV_EFI_HCRTM_EVENT"
 	case EventTypeEFIVariableAuthority:
 		return "EV_EFI_VARIABLE_AUTHORITY"
+	case EventTypeEFISPDMFirmwareBlob:
+		return "EV_EFI_SPDM_FIRMWARE_BLOB"
+	case EventTypeEFISPDMFirmwareConfig:
+		return "EV_EFI_SPDM_FIRMWARE_CONFIG"
+	case EventTypeEFISPDMDevicePolicy:
+		return "EV_EFI_SPDM_DEVICE_POLICY"
+	case EventTypeEFISPDMDeviceAuthority:
+		return "EV_EFI_SPDM_DEVICE_AUTHORITY"
+	case EventTypeTXTPCRMapping:
+		return "EVTYPE_PCRMAPPING"
+	case EventTypeTXTHashStart:
+		return "EVTYPE_HASH_START"
+	case EventTypeTXTCombinedHash:
+		return "EVTYPE_COMBINED_HASH"
+	case EventTypeTXTMLEHash:
+		return "EVTYPE_MLE_HASH"
+	case EventTypeTXTBIOSACRegData:
+		return "EVTYPE_BIOSAC_REG_DATA"
+	case EventTypeTXTCPUSCRTMStat:
+		return "EVTYPE_CPU_SCRTM_STAT"
+	case EventTypeTXTLCPControlHash:
+		return "EVTYPE_LCP_CONTROL_HASH"
+	case EventTypeTXTElementsDataHash:
+		return "EVTYPE_ELEMENTS_DATA_HASH"
+	case EventTypeTXTSTMHash:
+		return "EVTYPE_STM_HASH"
+	case EventTypeTXTOSSINITDataCapHash:
+		return "EVTYPE_OSSINITDATA_CAP_HASH"
+	case EventTypeTXTSINITPubKeyHash:
+		return "EVTYPE_SINIT_PUBKEY_HASH"
+	case EventTypeTXTLCPHash:
+		return "EVTYPE_LCP_HASH"
+	case EventTypeTXTLCPDetailsHash:
+		return "EVTYPE_LCP_DETAILS_HASH"
+	case EventTypeTXTLCPAuthoritiesHash:
+		return "EVTYPE_LCP_AUTHORITIES_HASH"
+	case EventTypeTXTNVInfoHash:
+		return "EVTYPE_NV_INFO_HASH"
+	case EventTypeTXTColdBootBIOSHash:
+		return "EVTYPE_COLD_BOOT_BIOS_HASH"
+	case EventTypeTXTKMHash:
+		return "EVTYPE_KM_HASH"
+	case EventTypeTXTBPMHash:
+		return "EVTYPE_BPM_HASH"
+	case EventTypeTXTKMInfoHash:
+		return "EVTYPE_KM_INFO_HASH"
+	case EventTypeTXTBPMInfoHash:
+		return "EVTYPE_BPM_INFO_HASH"
+	case EventTypeTXTBootPolicyHash:
+		return "EVTYPE_BOOT_POL_HASH"
+	case EventTypeTXTRandomValue:
+		return "EVTYPE_RANDOM_VALUE"
+	case EventTypeTXTCapValue:
+		return "EVTYPE_CAP_VALUE"
+	case EventTypeSLaunch:
+		return "DLE_EVTYPE_SLAUNCH"
+	case EventTypeSLaunchStart:
+		return "DLE_EVTYPE_SLAUNCH_START"
+	case EventTypeSLaunchEnd:
+		return "DLE_EVTYPE_SLAUNCH_END"
 	default:
 		return fmt.Sprintf("%08x", uint32(e))
 	}
@@ -146,7 +233,18 @@ func (a AlgorithmId) String() string {
 		return "SHA-384"
 	case AlgorithmSha512:
 		return "SHA-512"
+	case AlgorithmSm3_256:
+		return "SM3-256"
+	case AlgorithmSha3_256:
+		return "SHA3-256"
+	case AlgorithmSha3_384:
+		return "SHA3-384"
+	case AlgorithmSha3_512:
+		return "SHA3-512"
 	default:
+		if r := lookupRegisteredAlgorithm(a); r != nil {
+			return r.name
+		}
 		return fmt.Sprintf("%04x", uint16(a))
 	}
 }
@@ -172,6 +270,16 @@ func (l AlgorithmIdList) Contains(a AlgorithmId) bool {
 	return false
 }
 
+// Intersection returns the algorithms in this list that are also contained in other.
+func (l AlgorithmIdList) Intersection(other AlgorithmIdList) (out AlgorithmIdList) {
+	for _, alg := range l {
+		if other.Contains(alg) {
+			out = append(out, alg)
+		}
+	}
+	return
+}
+
 // Event corresponds to a single event in an event log.
 type Event struct {
 	Index     uint      // Sequential index of event in the log
@@ -179,4 +287,15 @@ type Event struct {
 	EventType EventType // The type of this event
 	Digests   DigestMap // The digests corresponding to this event for the supported algorithms
 	Data      EventData // The data recorded with this event
+
+	// SyntheticDigests contains the algorithms for which digests in Digests weren't recorded in the log, but
+	// were inserted by the parser. The Spec ID event of a crypto-agile log only contains a SHA-1 digest, so a
+	// zero digest is inserted for each of the other algorithms in the log.
+	SyntheticDigests AlgorithmIdList
+}
+
+// IsDigestSynthetic indicates whether the digest for the specified algorithm was inserted by the parser rather
+// than being recorded in the log.
+func (e *Event) IsDigestSynthetic(alg AlgorithmId) bool {
+	return e.SyntheticDigests.Contains(alg)
 }
@@ -58,11 +58,106 @@ func ParseAlgorithm(alg string) (AlgorithmId, error) {
 		return AlgorithmSha384, nil
 	case "sha512":
 		return AlgorithmSha512, nil
+	case "sm3_256":
+		return AlgorithmSm3_256, nil
+	case "sha3_256":
+		return AlgorithmSha3_256, nil
+	case "sha3_384":
+		return AlgorithmSha3_384, nil
+	case "sha3_512":
+		return AlgorithmSha3_512, nil
 	default:
+		registeredAlgorithmsMu.RLock()
+		defer registeredAlgorithmsMu.RUnlock()
+		for id, r := range registeredAlgorithms {
+			if r.name == alg {
+				return id, nil
+			}
+		}
 		return 0, fmt.Errorf("Unrecognized algorithm \"%s\"", alg)
 	}
 }
 
+var knownEventTypes = [...]EventType{
+	EventTypePrebootCert,
+	EventTypePostCode,
+	EventTypeNoAction,
+	EventTypeSeparator,
+	EventTypeAction,
+	EventTypeEventTag,
+	EventTypeSCRTMContents,
+	EventTypeSCRTMVersion,
+	EventTypeCPUMicrocode,
+	EventTypePlatformConfigFlags,
+	EventTypeTableOfDevices,
+	EventTypeCompactHash,
+	EventTypeIPL,
+	EventTypeIPLPartitionData,
+	EventTypeNonhostCode,
+	EventTypeNonhostConfig,
+	EventTypeNonhostInfo,
+	EventTypeOmitBootDeviceEvents,
+	EventTypeEFIVariableDriverConfig,
+	EventTypeEFIVariableBoot,
+	EventTypeEFIBootServicesApplication,
+	EventTypeEFIBootServicesDriver,
+	EventTypeEFIRuntimeServicesDriver,
+	EventTypeEFIGPTEvent,
+	EventTypeEFIAction,
+	EventTypeEFIPlatformFirmwareBlob,
+	EventTypeEFIHandoffTables,
+	EventTypeEFIPlatformFirmwareBlob2,
+	EventTypeEFIHandoffTables2,
+	EventTypeEFIVariableBoot2,
+	EventTypeEFIHCRTMEvent,
+	EventTypeEFIVariableAuthority,
+	EventTypeEFISPDMFirmwareBlob,
+	EventTypeEFISPDMFirmwareConfig,
+	EventTypeEFISPDMDevicePolicy,
+	EventTypeEFISPDMDeviceAuthority,
+	EventTypeTXTPCRMapping,
+	EventTypeTXTHashStart,
+	EventTypeTXTCombinedHash,
+	EventTypeTXTMLEHash,
+	EventTypeTXTBIOSACRegData,
+	EventTypeTXTCPUSCRTMStat,
+	EventTypeTXTLCPControlHash,
+	EventTypeTXTElementsDataHash,
+	EventTypeTXTSTMHash,
+	EventTypeTXTOSSINITDataCapHash,
+	EventTypeTXTSINITPubKeyHash,
+	EventTypeTXTLCPHash,
+	EventTypeTXTLCPDetailsHash,
+	EventTypeTXTLCPAuthoritiesHash,
+	EventTypeTXTNVInfoHash,
+	EventTypeTXTColdBootBIOSHash,
+	EventTypeTXTKMHash,
+	EventTypeTXTBPMHash,
+	EventTypeTXTKMInfoHash,
+	EventTypeTXTBPMInfoHash,
+	EventTypeTXTBootPolicyHash,
+	EventTypeTXTRandomValue,
+	EventTypeTXTCapValue,
+	EventTypeSLaunch,
+	EventTypeSLaunchStart,
+	EventTypeSLaunchEnd}
+
+// ParseEventType parses an event type from its name (eg, "EV_SEPARATOR") or from its numeric value.
+func ParseEventType(eventType string) (EventType, error) {
+	for _, t := range knownEventTypes {
+		if t.String() == eventType {
+			return t, nil
+		}
+	}
+	if eventType == "EV_EFI_GPT_EVENT" {
+		return EventTypeEFIGPTEvent, nil
+	}
+	if v, err := strconv.ParseUint(eventType, 0, 32); err == nil {
+		return EventType(v), nil
+	}
+	return 0, fmt.Errorf("unrecognized event type %q", eventType)
+}
+
 func convertStringToUtf16(str string) []uint16 {
 	var unicodePoints []rune
 	for len(str) > 0 {
@@ -82,3 +177,12 @@ func convertUtf16ToString(u []uint16) string {
 	}
 	return string(utf8Str)
 }
+
+func isPrintableASCII(data []byte) bool {
+	for _, c := range data {
+		if c < 0x20 || c > 0x7e {
+			return false
+		}
+	}
+	return true
+}
@@ -2,9 +2,11 @@ package tcglog
 
 import (
 	"bytes"
+	"context"
 	"encoding/binary"
+	"fmt"
 	"io"
-	"os"
+	"io/ioutil"
 )
 
 type EFIBootVariableBehaviour int
@@ -15,6 +17,20 @@ const (
 	EFIBootVariableBehaviourVarDataOnly
 )
 
+// FindingSpecViolation indicates that the log violates the specification in a way that the parser tolerated
+// because LogOptions.Strict was not set. See Log.Warnings.
+const FindingSpecViolation FindingCode = "spec-violation"
+
+// FindingDigestSizeMismatch indicates that the Spec ID event declares a digest size for a supported algorithm
+// that doesn't match the size of its digests. Digests for the algorithm are skipped, so the corresponding PCR
+// bank can't be validated.
+const FindingDigestSizeMismatch FindingCode = "digest-size-mismatch"
+
+// FindingMissingDigest indicates that an event doesn't have a digest for one of the log's PCR banks, which the
+// parser tolerated because LogOptions.Strict was not set. The expected value of the PCR in that bank can't be
+// computed from the log.
+const FindingMissingDigest FindingCode = "missing-digest"
+
 type IncorrectDigestValue struct {
 	Algorithm AlgorithmId
 	Expected  Digest
@@ -25,14 +41,76 @@ type ValidatedEvent struct {
 	MeasuredBytes              []byte
 	MeasuredTrailingBytesCount int
 	IncorrectDigestValues      []IncorrectDigestValue
+	DigestsNotValidated        bool // The digests for this event were taken as given and not validated
+}
+
+// LogValidateOptions allows the behaviour of ReplayAndValidateLogWithOptions to be controlled.
+type LogValidateOptions struct {
+	// OSPresentOnly indicates that only events measured after the transition to the OS-present environment
+	// should have their digests validated. The transition is the point in the log at which each of PCRs 0-7
+	// has been terminated with an EV_SEPARATOR event. Every event up to and including this point is still
+	// replayed, whichever PCR it is measured to, but its digests are taken as given. If the log doesn't
+	// contain these separators, no digests are validated. This is useful for consumers that only control the
+	// OS-present portion of the boot.
+	OSPresentOnly bool
+
+	// AnomalyThresholds controls the heuristics used to detect anomalies in the log. DefaultAnomalyThresholds
+	// is used if this is nil.
+	AnomalyThresholds *AnomalyThresholds
+
+	// CCEvidence contains measurement register values from confidential computing evidence to cross-reference
+	// against the values replayed from a confidential computing event log, if not nil.
+	CCEvidence *CCEvidence
+
+	// MinimumFindingSeverity is the minimum severity of findings to include in LogValidateResult.Findings.
+	MinimumFindingSeverity FindingSeverity
+
+	// SuppressedFindings contains the codes of findings to omit from LogValidateResult.Findings, such as known
+	// benign quirks on a particular fleet of devices.
+	SuppressedFindings []FindingCode
+
+	// PCRValues contains actual PCR values to check the log for consistency with, if not nil. These don't
+	// have to be read from the local TPM - they could be obtained from a quote so that validation can be
+	// performed by a remote verifier. See LogValidateResult.CheckPCRValues.
+	PCRValues map[PCRIndex]DigestMap
+
+	// PCRReader is used to read the actual values of the PCRs that are extended by the log, if not nil and
+	// PCRValues is nil. The values are checked in the same way as PCRValues.
+	PCRReader PCRReader
+
+	// ResettablePCRs contains the PCRs that can be reset on the platform, for the purposes of checking
+	// PCRValues. DefaultResettablePCRs is used if this is nil.
+	ResettablePCRs []PCRIndex
+
+	// Rules contains additional rules to run during validation. These are run after the built-in rules.
+	Rules []Rule
+
+	// DisabledRules contains the names of built-in rules that shouldn't be run, eg, RuleSeparators.
+	DisabledRules []string
+
+	// ReplacementRules contains rules to run in place of the built-in rules with the specified names.
+	ReplacementRules map[string]Rule
+
+	// InitialPCRValues contains the values that PCRs have before any events are replayed, for platforms that
+	// initialize some PCRs to non-standard values. PCRs and banks that aren't in this start with a value of
+	// zero, except for PCR 0, which starts with the value implied by the StartupLocality event if the log
+	// contains one. Values for PCRs 17-22 are discarded if the log indicates that a dynamic launch reset them.
+	// See Log.ReplayPCRsWithInitialValues.
+	InitialPCRValues map[PCRIndex]DigestMap
 }
 
 type LogValidateResult struct {
 	EfiBootVariableBehaviour EFIBootVariableBehaviour
 	ValidatedEvents          []*ValidatedEvent
 	Spec                     Spec
+	PlatformProfile          PlatformProfile
+	PFPRevision              PFPRevision
 	Algorithms               AlgorithmIdList
 	ExpectedPCRValues        map[PCRIndex]DigestMap
+	DynamicLaunch            bool // The log indicates that a dynamic launch reset PCRs 17-22
+	OSPresentOnly            bool
+	Findings                 []Finding // Findings filtered according to the severity and suppression options
+	AllFindings              []Finding // All findings, before filtering
 }
 
 func doesEventTypeExtendPCR(t EventType) bool {
@@ -42,6 +120,14 @@ func doesEventTypeExtendPCR(t EventType) bool {
 	return true
 }
 
+// isPreOSPCR indicates whether the specified PCR is used for measurements made by the firmware, terminated by
+// an EV_SEPARATOR event on the transition to the OS-present environment.
+// https://trustedcomputinggroup.org/wp-content/uploads/TCG_PCClientSpecPlat_TPM_2p0_1p04_pub.pdf
+//  (section 3.3.4 "PCR Usage", section 8.2.4 "Measuring Boot Events")
+func isPreOSPCR(pcr PCRIndex) bool {
+	return pcr <= 7
+}
+
 func performHashExtendOperation(alg AlgorithmId, initial Digest, event Digest) Digest {
 	hash := alg.newHash()
 	hash.Write(initial)
@@ -49,46 +135,48 @@ func performHashExtendOperation(alg AlgorithmId, initial Digest, event Digest) D
 	return hash.Sum(nil)
 }
 
-func determineMeasuredBytes(event *Event, efiBootVariableQuirk bool) ([]byte, bool) {
+func determineMeasuredBytes(event *Event, spec Spec, efiBootVariableQuirk bool) ([]byte, bool) {
+	// Events recorded by bootloaders and other components define their own measured content.
+	switch d := event.Data.(type) {
+	case *BrokenEventData:
+		return nil, false
+	case *GrubStringEventData:
+		return []byte(d.Str), false
+	case *AppMeasurementEventData:
+		return d.measuredBytes(), false
+	case *SystemdEFIStubEventData:
+		// The event data is a UTF-16 string terminated with a single zero byte, but the measured
+		// data is a UTF-16 string with a UTF-16 null terminator. Add an extra zero byte here
+		c := make([]byte, len(d.data)+1)
+		copy(c, d.data)
+		return c, false
+	case *LinuxEFIStubEventData, *XenEventData:
+		return nil, false
+	}
+
+	if MeasuredContentForEventType(spec, event.EventType) != MeasuredContentEventData {
+		return nil, false
+	}
+
 	switch d := event.Data.(type) {
-	case *opaqueEventData:
-		switch event.EventType {
-		case EventTypeEventTag, EventTypeSCRTMVersion, EventTypePlatformConfigFlags,
-			EventTypeTableOfDevices, EventTypeNonhostInfo, EventTypeOmitBootDeviceEvents:
-			return event.Data.Bytes(), false
-		}
 	case *separatorEventData:
-		if !d.isError {
-			return event.Data.Bytes(), false
-		} else {
+		if d.isError {
 			out := make([]byte, 4)
 			binary.LittleEndian.PutUint32(out, separatorEventErrorValue)
 			return out, false
 		}
-	case *asciiStringEventData:
-		switch event.EventType {
-		case EventTypeAction, EventTypeEFIAction:
-			return event.Data.Bytes(), false
-		}
 	case *EFIVariableEventData:
+		// Some firmware only measures the variable data for EV_EFI_VARIABLE_BOOT events. EV_EFI_VARIABLE_BOOT2
+		// events always measure the entire UEFI_VARIABLE_DATA structure.
 		if event.EventType == EventTypeEFIVariableBoot && efiBootVariableQuirk {
 			return d.VariableData, false
-		} else {
-			return event.Data.Bytes(), true
 		}
+		return event.Data.Bytes(), true
 	case *efiGPTEventData:
 		return event.Data.Bytes(), true
-	case *GrubStringEventData:
-		return []byte(d.Str), false
-	case *SystemdEFIStubEventData:
-		// The event data is a UTF-16 string terminated with a single zero byte, but the measured
-		// data is a UTF-16 string with a UTF-16 null terminator. Add an extra zero byte here
-		c := make([]byte, len(d.data)+1)
-		copy(c, d.data)
-		return c, false
 	}
 
-	return nil, false
+	return event.Data.Bytes(), false
 }
 
 func isExpectedDigestValue(digest Digest, alg AlgorithmId, measuredBytes []byte) (bool, []byte) {
@@ -97,14 +185,36 @@ func isExpectedDigestValue(digest Digest, alg AlgorithmId, measuredBytes []byte)
 }
 
 type logValidator struct {
-	log                      *Log
-	expectedPCRValues        map[PCRIndex]DigestMap
-	efiBootVariableBehaviour EFIBootVariableBehaviour
-	validatedEvents          []*ValidatedEvent
+	ctx               context.Context
+	log               *Log
+	options           LogValidateOptions
+	rules             []Rule
+	findings          []Finding
+	seenSeparator     map[PCRIndex]bool
+	osPresent         bool
+	pcr0Initialized   bool
+	drtm              dynamicLaunchTracker
+	expectedPCRValues map[PCRIndex]DigestMap
+	state             *ReplayState
 }
 
-func (v *logValidator) checkEventDigests(e *ValidatedEvent, trailingBytes int) {
+// eventDigestsRule checks the digests of each event against the data recorded with it where possible.
+type eventDigestsRule struct{}
+
+func (r eventDigestsRule) Check(ctx context.Context, event *Event, state *ReplayState) []Finding {
+	if event == nil || !doesEventTypeExtendPCR(event.EventType) {
+		return nil
+	}
+	e := state.current()
+	if e.DigestsNotValidated {
+		return nil
+	}
+	trailingBytes := state.TrailingBytes
+
 	for alg, digest := range e.Event.Digests {
+		if !alg.supported() {
+			continue
+		}
 		if len(e.MeasuredBytes) > 0 {
 			// We've already determined the bytes measured for this event for a previous digest
 			if ok, expected := isExpectedDigestValue(digest, alg, e.MeasuredBytes); !ok {
@@ -114,14 +224,14 @@ func (v *logValidator) checkEventDigests(e *ValidatedEvent, trailingBytes int) {
 			continue
 		}
 
-		efiBootVariableBehaviourTry := v.efiBootVariableBehaviour
+		efiBootVariableBehaviourTry := state.EFIBootVariableBehaviour
 
 	Loop:
 		for {
 			// Determine what we expect to be measured
-			provisionalMeasuredBytes, checkTrailingBytes := determineMeasuredBytes(e.Event, efiBootVariableBehaviourTry == EFIBootVariableBehaviourVarDataOnly)
+			provisionalMeasuredBytes, checkTrailingBytes := determineMeasuredBytes(e.Event, state.Spec, efiBootVariableBehaviourTry == EFIBootVariableBehaviourVarDataOnly)
 			if provisionalMeasuredBytes == nil {
-				return
+				return nil
 			}
 
 			var provisionalMeasuredTrailingBytes int
@@ -137,11 +247,11 @@ func (v *logValidator) checkEventDigests(e *ValidatedEvent, trailingBytes int) {
 					// All good
 					e.MeasuredBytes = provisionalMeasuredBytes
 					e.MeasuredTrailingBytesCount = provisionalMeasuredTrailingBytes
-					if e.Event.EventType == EventTypeEFIVariableBoot && v.efiBootVariableBehaviour == EFIBootVariableBehaviourUnknown {
+					if e.Event.EventType == EventTypeEFIVariableBoot && state.EFIBootVariableBehaviour == EFIBootVariableBehaviourUnknown {
 						// This is the first EV_EFI_VARIABLE_BOOT event, so record the measurement behaviour.
-						v.efiBootVariableBehaviour = efiBootVariableBehaviourTry
+						state.EFIBootVariableBehaviour = efiBootVariableBehaviourTry
 						if efiBootVariableBehaviourTry == EFIBootVariableBehaviourUnknown {
-							v.efiBootVariableBehaviour = EFIBootVariableBehaviourFull
+							state.EFIBootVariableBehaviour = EFIBootVariableBehaviourFull
 						}
 					}
 					break Loop
@@ -160,7 +270,7 @@ func (v *logValidator) checkEventDigests(e *ValidatedEvent, trailingBytes int) {
 						continue Loop
 					}
 					// Record the expected digest on the event
-					expectedMeasuredBytes, _ := determineMeasuredBytes(e.Event, false)
+					expectedMeasuredBytes, _ := determineMeasuredBytes(e.Event, state.Spec, false)
 					e.IncorrectDigestValues = append(
 						e.IncorrectDigestValues,
 						IncorrectDigestValue{Algorithm: alg, Expected: alg.hash(expectedMeasuredBytes)})
@@ -169,9 +279,37 @@ func (v *logValidator) checkEventDigests(e *ValidatedEvent, trailingBytes int) {
 			}
 		}
 	}
+	return nil
+}
+
+// efiBootVariableRule reports firmware that measures only the variable data for EV_EFI_VARIABLE_BOOT events in a
+// log that conforms to revision 1.05 or later of the PC Client Platform Firmware Profile. These revisions require
+// the entire UEFI_VARIABLE_DATA structure to be measured, and boot variables to be measured with
+// EV_EFI_VARIABLE_BOOT2 events. This is only reported once.
+type efiBootVariableRule struct {
+	reported bool
+}
+
+func (r *efiBootVariableRule) Check(ctx context.Context, event *Event, state *ReplayState) []Finding {
+	if event == nil || r.reported || event.EventType != EventTypeEFIVariableBoot ||
+		state.EFIBootVariableBehaviour != EFIBootVariableBehaviourVarDataOnly || state.PFPRevision < PFPRevision1_05 {
+		return nil
+	}
+	r.reported = true
+	return []Finding{{Code: FindingSpecViolation, Severity: FindingSeverityWarning, Event: event,
+		Message: fmt.Sprintf("%s events only measure the variable data, but revision %s of the PC Client "+
+			"Platform Firmware Profile specification requires the entire UEFI_VARIABLE_DATA structure to be "+
+			"measured with %s events", event.EventType, state.PFPRevision, EventTypeEFIVariableBoot2)}}
 }
 
-func (v *logValidator) processEvent(event *Event, trailingBytes int) {
+func (v *logValidator) processEvent(event *Event) {
+	if locality, ok := startupLocality(event); ok && !v.pcr0Initialized {
+		v.expectedPCRValues[0] = initialPCR0Values(v.log.Algorithms, locality)
+	}
+	if v.drtm.isDynamicLaunch(event) {
+		resetDRTMPCRs(v.expectedPCRValues)
+	}
+
 	if _, exists := v.expectedPCRValues[event.PCRIndex]; !exists {
 		v.expectedPCRValues[event.PCRIndex] = DigestMap{}
 		for _, alg := range v.log.Algorithms {
@@ -180,49 +318,170 @@ func (v *logValidator) processEvent(event *Event, trailingBytes int) {
 	}
 
 	ve := &ValidatedEvent{Event: event}
-	v.validatedEvents = append(v.validatedEvents, ve)
+	v.state.ValidatedEvents = append(v.state.ValidatedEvents, ve)
+	v.state.events = append(v.state.events, event)
 
 	if !doesEventTypeExtendPCR(event.EventType) {
 		return
 	}
+	if event.PCRIndex == 0 {
+		v.pcr0Initialized = true
+	}
 
 	for alg, digest := range event.Digests {
+		if !alg.supported() {
+			continue
+		}
 		v.expectedPCRValues[event.PCRIndex][alg] =
 			performHashExtendOperation(alg, v.expectedPCRValues[event.PCRIndex][alg], digest)
 	}
+	for _, alg := range v.log.Algorithms {
+		if _, ok := event.Digests[alg]; ok {
+			continue
+		}
+		v.findings = append(v.findings, Finding{Code: FindingMissingDigest, Severity: FindingSeverityError,
+			Event: event, Message: fmt.Sprintf("event has no %s digest, so the expected value of PCR %d in the "+
+				"%s bank is wrong", alg, event.PCRIndex, alg)})
+	}
+
+	if v.options.OSPresentOnly && !v.osPresent {
+		ve.DigestsNotValidated = true
+	}
+	if event.EventType == EventTypeSeparator && isPreOSPCR(event.PCRIndex) {
+		// The transition to the OS-present environment happens once each of PCRs 0-7 has been terminated
+		// with an EV_SEPARATOR event.
+		v.seenSeparator[event.PCRIndex] = true
+		v.osPresent = len(v.seenSeparator) == 8
+	}
+}
 
-	v.checkEventDigests(ve, trailingBytes)
+func (v *logValidator) runRules(event *Event) {
+	for _, rule := range v.rules {
+		v.findings = append(v.findings, rule.Check(v.ctx, event, v.state)...)
+	}
 }
 
 func (v *logValidator) run() (*LogValidateResult, error) {
 	for {
+		if err := v.ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		nwarnings := len(v.log.warnings)
 		event, trailingBytes, err := v.log.nextEventInternal()
+		if err == io.EOF {
+			break
+		}
 		if err != nil {
-			if err == io.EOF {
-				return &LogValidateResult{
-					EfiBootVariableBehaviour: v.efiBootVariableBehaviour,
-					ValidatedEvents:          v.validatedEvents,
-					Spec:                     v.log.Spec,
-					Algorithms:               v.log.Algorithms,
-					ExpectedPCRValues:        v.expectedPCRValues}, nil
-			}
 			return nil, err
 		}
-		v.processEvent(event, trailingBytes)
+		for _, w := range v.log.warnings[nwarnings:] {
+			code := FindingSpecViolation
+			switch e := w.Err.(type) {
+			case *DigestSizeMismatchError:
+				code = FindingDigestSizeMismatch
+			case *MissingDigestError:
+				if doesEventTypeExtendPCR(event.EventType) && v.log.Algorithms.Contains(e.Algorithm) {
+					// This is reported by processEvent as FindingMissingDigest.
+					continue
+				}
+			}
+			v.findings = append(v.findings, Finding{Code: code, Severity: FindingSeverityWarning,
+				Event: event, Message: w.Err.Error()})
+		}
+		v.processEvent(event)
+		v.state.TrailingBytes = trailingBytes
+		v.runRules(event)
+	}
+
+	v.state.TrailingBytes = 0
+	v.runRules(nil)
+
+	result := &LogValidateResult{
+		EfiBootVariableBehaviour: v.state.EFIBootVariableBehaviour,
+		ValidatedEvents:          v.state.ValidatedEvents,
+		Spec:                     v.log.Spec,
+		PlatformProfile:          v.log.PlatformProfile,
+		PFPRevision:              v.log.PFPRevision,
+		Algorithms:               v.log.Algorithms,
+		ExpectedPCRValues:        v.expectedPCRValues,
+		DynamicLaunch:            v.drtm.launched,
+		OSPresentOnly:            v.options.OSPresentOnly}
+	findings := v.findings
+	values := v.options.PCRValues
+	if values == nil && v.options.PCRReader != nil {
+		var err error
+		values, err = v.options.PCRReader.ReadPCRs(sortedPCRs(v.expectedPCRValues))
+		if err != nil {
+			return nil, fmt.Errorf("cannot read PCR values: %w", err)
+		}
 	}
+	if values != nil {
+		resettable := v.options.ResettablePCRs
+		if resettable == nil {
+			resettable = DefaultResettablePCRs
+		}
+		findings = append(findings, result.CheckPCRValues(values, resettable)...)
+	}
+	result.Findings = FilterFindings(findings, v.options.MinimumFindingSeverity,
+		v.options.SuppressedFindings)
+	result.AllFindings = findings
+	return result, nil
 }
 
+// ReplayAndValidateLog reads the event log at logPath, replays it in order to compute the expected PCR values
+// and validates the digests of each event against the data recorded with it where possible.
 func ReplayAndValidateLog(logPath string, options LogOptions) (*LogValidateResult, error) {
-	file, err := os.Open(logPath)
+	return ReplayAndValidateLogWithOptions(logPath, options, LogValidateOptions{})
+}
+
+// ReplayAndValidateLogWithOptions is a version of ReplayAndValidateLog that accepts options to control validation.
+func ReplayAndValidateLogWithOptions(logPath string, options LogOptions, validateOptions LogValidateOptions) (*LogValidateResult, error) {
+	return ReplayAndValidateLogContext(context.Background(), logPath, options, validateOptions)
+}
+
+// ReplayAndValidateLogContext is a version of ReplayAndValidateLogWithOptions that accepts a context, which is
+// passed to each of the rules in LogValidateOptions.Rules. Validation stops if the context is cancelled.
+func ReplayAndValidateLogContext(ctx context.Context, logPath string, options LogOptions, validateOptions LogValidateOptions) (*LogValidateResult, error) {
+	// Read the whole log rather than using the size reported by stat, because securityfs reports a size of
+	// zero for the firmware event log.
+	data, err := ioutil.ReadFile(logPath)
 	if err != nil {
 		return nil, err
 	}
 
-	log, err := NewLog(file, options)
+	log, err := NewLog(bytes.NewReader(data), options)
 	if err != nil {
 		return nil, err
 	}
 
-	v := &logValidator{log: log, expectedPCRValues: make(map[PCRIndex]DigestMap)}
+	return replayAndValidateLog(ctx, log, int64(len(data)), validateOptions)
+}
+
+func replayAndValidateLog(ctx context.Context, log *Log, logSize int64, validateOptions LogValidateOptions) (*LogValidateResult, error) {
+	initial, err := checkInitialPCRValues(validateOptions.InitialPCRValues, log.Algorithms)
+	if err != nil {
+		return nil, err
+	}
+	rules, err := builtinRules(&validateOptions)
+	if err != nil {
+		return nil, err
+	}
+	v := &logValidator{ctx: ctx,
+		log:               log,
+		options:           validateOptions,
+		rules:             append(rules, validateOptions.Rules...),
+		seenSeparator:     make(map[PCRIndex]bool),
+		expectedPCRValues: initial}
+	v.state = &ReplayState{
+		Spec:        log.Spec,
+		Algorithms:  log.Algorithms,
+		CCType:      log.CCType,
+		Profile:     log.PlatformProfile,
+		PFPRevision: log.PFPRevision,
+		LogSize:     logSize,
+		PCRValues:   initial}
+	// An explicit initial value for PCR 0 takes precedence over the StartupLocality event.
+	_, v.pcr0Initialized = validateOptions.InitialPCRValues[0]
 	return v.run()
 }
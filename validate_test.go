@@ -0,0 +1,126 @@
+package tcglog
+
+import (
+	"bytes"
+	"context"
+	"testing"
+)
+
+type testValidateEvent struct {
+	pcr       PCRIndex
+	eventType EventType
+	data      []byte
+	corrupt   bool // Record a digest that doesn't match the data
+}
+
+// makeTestValidateLog creates a crypto-agile log containing the supplied events.
+func makeTestValidateLog(t *testing.T, events []testValidateEvent) []byte {
+	var buf bytes.Buffer
+	w, err := NewLogWriter(&buf, SpecEFI_2, AlgorithmIdList{AlgorithmSha256})
+	if err != nil {
+		t.Fatalf("NewLogWriter failed: %v", err)
+	}
+	if err := w.WriteSpecIdEvent(0, nil); err != nil {
+		t.Fatalf("WriteSpecIdEvent failed: %v", err)
+	}
+	for _, e := range events {
+		measured := e.data
+		if e.corrupt {
+			measured = append([]byte("corrupt "), e.data...)
+		}
+		if err := w.WriteEvent(&Event{
+			PCRIndex:  e.pcr,
+			EventType: e.eventType,
+			Digests:   DigestMap{AlgorithmSha256: AlgorithmSha256.hash(measured)},
+			Data:      &opaqueEventData{data: e.data}}); err != nil {
+			t.Fatalf("WriteEvent failed: %v", err)
+		}
+	}
+	return buf.Bytes()
+}
+
+func TestReplayAndValidateLogOSPresentOnly(t *testing.T) {
+	action := []byte("Calling EFI Application from Boot Option")
+	separators := func(pcrs ...PCRIndex) (out []testValidateEvent) {
+		for _, pcr := range pcrs {
+			out = append(out, testValidateEvent{pcr: pcr, eventType: EventTypeSeparator, data: make([]byte, 4)})
+		}
+		return out
+	}
+	var events []testValidateEvent
+	events = append(events, testValidateEvent{pcr: 0, eventType: EventTypeEFIAction, data: action, corrupt: true})
+	events = append(events, separators(7)...)
+	// Firmware measurements to PCRs other than 0-7 before the transition are still taken as given.
+	events = append(events, testValidateEvent{pcr: 14, eventType: EventTypeEFIAction, data: action, corrupt: true})
+	events = append(events, testValidateEvent{pcr: 4, eventType: EventTypeEFIAction, data: action, corrupt: true})
+	events = append(events, separators(0, 1, 2, 3, 4, 5, 6)...)
+	events = append(events, testValidateEvent{pcr: 4, eventType: EventTypeEFIAction, data: action, corrupt: true})
+	events = append(events, testValidateEvent{pcr: 14, eventType: EventTypeEFIAction, data: action, corrupt: true})
+	events = append(events, testValidateEvent{pcr: 5, eventType: EventTypeEFIAction, data: action})
+
+	complete := makeTestValidateLog(t, events)
+	// Without the PCR 6 separator, the log never transitions to the OS-present environment.
+	incomplete := makeTestValidateLog(t, append(append([]testValidateEvent(nil), events[:10]...), events[11:]...))
+
+	for _, data := range []struct {
+		desc          string
+		log           []byte
+		osPresentOnly bool
+		notValidated  int   // The number of events with DigestsNotValidated, after the Spec ID event
+		incorrect     []int // The indices of events with incorrect digests
+	}{
+		{desc: "Default", log: complete, incorrect: []int{1, 3, 4, 12, 13}},
+		{desc: "OSPresentOnly", log: complete, osPresentOnly: true, notValidated: 11, incorrect: []int{12, 13}},
+		{desc: "NoSeparatorsDefault", log: incomplete, incorrect: []int{1, 3, 4, 11, 12}},
+		{desc: "NoSeparatorsOSPresentOnly", log: incomplete, osPresentOnly: true, notValidated: 13},
+	} {
+		t.Run(data.desc, func(t *testing.T) {
+			log, err := NewLog(bytes.NewReader(data.log), LogOptions{})
+			if err != nil {
+				t.Fatalf("NewLog failed: %v", err)
+			}
+			result, err := replayAndValidateLog(context.Background(), log, int64(len(data.log)),
+				LogValidateOptions{OSPresentOnly: data.osPresentOnly})
+			if err != nil {
+				t.Fatalf("replayAndValidateLog failed: %v", err)
+			}
+			if result.OSPresentOnly != data.osPresentOnly {
+				t.Errorf("Unexpected OSPresentOnly")
+			}
+
+			notValidated := 0
+			var incorrect []int
+			for i, e := range result.ValidatedEvents {
+				if e.DigestsNotValidated {
+					notValidated++
+				}
+				if len(e.IncorrectDigestValues) > 0 {
+					incorrect = append(incorrect, i)
+				}
+			}
+			if notValidated != data.notValidated {
+				t.Errorf("Unexpected number of events that weren't validated (%d)", notValidated)
+			}
+			if len(incorrect) != len(data.incorrect) {
+				t.Fatalf("Unexpected events with incorrect digests %v", incorrect)
+			}
+			for i := range incorrect {
+				if incorrect[i] != data.incorrect[i] {
+					t.Errorf("Unexpected events with incorrect digests %v", incorrect)
+					break
+				}
+			}
+
+			// The PCR values are computed from every event in both modes.
+			pcr4 := make(Digest, AlgorithmSha256.size())
+			for _, e := range result.ValidatedEvents {
+				if e.Event.PCRIndex == 4 && doesEventTypeExtendPCR(e.Event.EventType) {
+					pcr4 = performHashExtendOperation(AlgorithmSha256, pcr4, e.Event.Digests[AlgorithmSha256])
+				}
+			}
+			if !bytes.Equal(result.ExpectedPCRValues[4][AlgorithmSha256], pcr4) {
+				t.Errorf("Unexpected value for PCR 4")
+			}
+		})
+	}
+}
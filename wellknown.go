@@ -0,0 +1,116 @@
+package tcglog
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+)
+
+// WellKnownDigest describes data that is commonly measured, along with its digest for each supported algorithm.
+type WellKnownDigest struct {
+	Name    string    // A description of the measured data
+	Data    []byte    // The measured data
+	Digests DigestMap // The digest of Data for each supported algorithm
+}
+
+// Label returns a description of the digest of this data for the specified algorithm, suitable for labelling
+// digests in reports, eg, "SHA-256 of 0x00000000 separator".
+func (d *WellKnownDigest) Label(alg AlgorithmId) string {
+	return fmt.Sprintf("%s of %s", alg, d.Name)
+}
+
+func newWellKnownDigest(name string, data []byte) *WellKnownDigest {
+	d := &WellKnownDigest{Name: name, Data: data, Digests: DigestMap{}}
+	for _, alg := range []AlgorithmId{AlgorithmSha1, AlgorithmSha256, AlgorithmSha384, AlgorithmSha512,
+		AlgorithmSm3_256, AlgorithmSha3_256, AlgorithmSha3_384, AlgorithmSha3_512} {
+		if alg.supported() {
+			d.Digests[alg] = alg.hash(data)
+		}
+	}
+	return d
+}
+
+func newWellKnownStringDigest(s string) *WellKnownDigest {
+	return newWellKnownDigest(fmt.Sprintf("\"%s\"", s), []byte(s))
+}
+
+func uint32Bytes(v uint32) []byte {
+	b := make([]byte, 4)
+	binary.LittleEndian.PutUint32(b, v)
+	return b
+}
+
+// https://trustedcomputinggroup.org/wp-content/uploads/TCG_PCClientSpecPlat_TPM_2p0_1p04_pub.pdf
+//  (section 9.4.1 "Event Types", section 10.4.4 "EV_SEPARATOR")
+var (
+	// WellKnownDigestEmpty is the digest of zero bytes of data.
+	WellKnownDigestEmpty = newWellKnownDigest("empty data", nil)
+
+	// WellKnownDigestSeparator is the digest of the data measured by a EV_SEPARATOR event in the normal case.
+	WellKnownDigestSeparator = newWellKnownDigest("0x00000000 separator", uint32Bytes(0))
+
+	// WellKnownDigestSeparatorError is the digest of the data measured by a EV_SEPARATOR event to indicate an
+	// error.
+	WellKnownDigestSeparatorError = newWellKnownDigest("0x00000001 error separator",
+		uint32Bytes(separatorEventErrorValue))
+
+	// WellKnownDigestCallingEFIApplication is the digest of the EV_EFI_ACTION string measured before calling
+	// an EFI application from a boot option.
+	WellKnownDigestCallingEFIApplication = newWellKnownStringDigest("Calling EFI Application from Boot Option")
+
+	// WellKnownDigestReturningFromEFIApplication is the digest of the EV_EFI_ACTION string measured when an EFI
+	// application that was started from a boot option returns.
+	WellKnownDigestReturningFromEFIApplication = newWellKnownStringDigest(
+		"Returning from EFI Application from Boot Option")
+
+	// WellKnownDigestExitBootServicesInvocation is the digest of the EV_EFI_ACTION string measured when
+	// ExitBootServices is called.
+	WellKnownDigestExitBootServicesInvocation = newWellKnownStringDigest("Exit Boot Services Invocation")
+
+	// WellKnownDigestExitBootServicesSuccess is the digest of the EV_EFI_ACTION string measured when
+	// ExitBootServices succeeds.
+	WellKnownDigestExitBootServicesSuccess = newWellKnownStringDigest("Exit Boot Services Returned with Success")
+
+	// WellKnownDigestExitBootServicesFailure is the digest of the EV_EFI_ACTION string measured when
+	// ExitBootServices fails.
+	WellKnownDigestExitBootServicesFailure = newWellKnownStringDigest("Exit Boot Services Returned with Failure")
+
+	// WellKnownDigestUEFIDebugMode is the digest of the EV_EFI_ACTION string measured to PCR 7 when the
+	// platform is in UEFI debug mode.
+	WellKnownDigestUEFIDebugMode = newWellKnownStringDigest("UEFI Debug Mode")
+
+	// WellKnownDigestDMAProtectionDisabled is the digest of the EV_EFI_ACTION string measured to PCR 7 when
+	// DMA protection is disabled.
+	WellKnownDigestDMAProtectionDisabled = newWellKnownStringDigest("DMA Protection Disabled")
+
+	// WellKnownDigestBootAttemptsOmitted is the digest of the EV_OMIT_BOOT_DEVICE_EVENTS string.
+	WellKnownDigestBootAttemptsOmitted = newWellKnownStringDigest("Boot Attempts Omitted")
+)
+
+// WellKnownDigests contains all of the well-known digests known to this package.
+var WellKnownDigests = []*WellKnownDigest{
+	WellKnownDigestEmpty,
+	WellKnownDigestSeparator,
+	WellKnownDigestSeparatorError,
+	WellKnownDigestCallingEFIApplication,
+	WellKnownDigestReturningFromEFIApplication,
+	WellKnownDigestExitBootServicesInvocation,
+	WellKnownDigestExitBootServicesSuccess,
+	WellKnownDigestExitBootServicesFailure,
+	WellKnownDigestUEFIDebugMode,
+	WellKnownDigestDMAProtectionDisabled,
+	WellKnownDigestBootAttemptsOmitted,
+}
+
+// IdentifyWellKnownDigest looks up the supplied digest in WellKnownDigests, and returns the data that it is the
+// digest of along with the algorithm used to compute it. If the digest isn't recognized, ok will be false.
+func IdentifyWellKnownDigest(digest Digest) (d *WellKnownDigest, alg AlgorithmId, ok bool) {
+	for _, d := range WellKnownDigests {
+		for alg, known := range d.Digests {
+			if bytes.Equal(digest, known) {
+				return d, alg, true
+			}
+		}
+	}
+	return nil, 0, false
+}
@@ -0,0 +1,33 @@
+package tcglog
+
+import (
+	"encoding/hex"
+	"testing"
+)
+
+func TestIdentifyWellKnownDigest(t *testing.T) {
+	for _, data := range []struct {
+		digest string
+		label  string
+	}{
+		{"df3f619804a92fdb4057192dc43dd748ea778adc52bc498ce80524c014b81119",
+			"SHA-256 of 0x00000000 separator"},
+		{"e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855", "SHA-256 of empty data"},
+		{"cd0fdb4531a6ec41be2753ba042637d6e5f7f256",
+			"SHA-1 of \"Calling EFI Application from Boot Option\""},
+	} {
+		digest, _ := hex.DecodeString(data.digest)
+		d, alg, ok := IdentifyWellKnownDigest(digest)
+		if !ok {
+			t.Errorf("Digest %s wasn't identified", data.digest)
+			continue
+		}
+		if d.Label(alg) != data.label {
+			t.Errorf("Unexpected label for %s: %s", data.digest, d.Label(alg))
+		}
+	}
+
+	if _, _, ok := IdentifyWellKnownDigest(make(Digest, 32)); ok {
+		t.Errorf("Zero digest shouldn't be identified")
+	}
+}
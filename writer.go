@@ -0,0 +1,118 @@
+package tcglog
+
+import (
+	"errors"
+	"fmt"
+	"io"
+)
+
+// LogWriter serializes events to an event log in the binary format defined by a particular specification.
+// Logs that conform to SpecEFI_2 are written in the crypto-agile (TCG_PCR_EVENT2) format, with the exception of
+// the first event which is always written in the TCG_PCClientPCREvent format as required by the specification.
+// Logs that conform to other specifications are written in the TCG_PCClientPCREvent format, which only supports
+// SHA-1.
+type LogWriter struct {
+	w        io.Writer
+	spec     Spec
+	algSizes []EFISpecIdEventAlgorithmSize
+	first    bool
+}
+
+// NewLogWriter returns a new LogWriter that writes events to w. Events for logs that conform to SpecEFI_2 must
+// contain digests for each of the specified algorithms. For other specifications, algorithms must only contain
+// AlgorithmSha1.
+//
+// This doesn't write a Spec ID event. Call WriteSpecIdEvent to create a new log, or call WriteEvent with the
+// Spec ID event from an existing log to re-emit it.
+func NewLogWriter(w io.Writer, spec Spec, algorithms AlgorithmIdList) (*LogWriter, error) {
+	if len(algorithms) == 0 {
+		return nil, errors.New("no digest algorithms specified")
+	}
+	for _, alg := range algorithms {
+		if !alg.supported() {
+			return nil, fmt.Errorf("unsupported digest algorithm %s", alg)
+		}
+	}
+	if spec != SpecEFI_2 && (len(algorithms) != 1 || algorithms[0] != AlgorithmSha1) {
+		return nil, errors.New("logs in the TCG_PCClientPCREvent format only support SHA-1")
+	}
+
+	return &LogWriter{w: w, spec: spec, algSizes: algSizesForAlgorithms(algorithms), first: true}, nil
+}
+
+// NewLogWriterWithDigestSizes returns a new LogWriter that writes events to w in the crypto-agile format, with
+// digests for each of the specified algorithms and sizes. Unlike NewLogWriter, the algorithms don't need to be
+// supported, so logs that were parsed with LogOptions.PreserveUnknownDigests can be re-serialized without losing
+// any digests. The sizes can be obtained from the Spec ID event of the original log.
+func NewLogWriterWithDigestSizes(w io.Writer, algSizes []EFISpecIdEventAlgorithmSize) (*LogWriter, error) {
+	if len(algSizes) == 0 {
+		return nil, errors.New("no digest algorithms specified")
+	}
+	for _, s := range algSizes {
+		if s.AlgorithmId.supported() && int(s.DigestSize) != s.AlgorithmId.size() {
+			return nil, fmt.Errorf("invalid digest size for algorithm %s", s.AlgorithmId)
+		}
+	}
+
+	return &LogWriter{w: w, spec: SpecEFI_2, algSizes: algSizes, first: true}, nil
+}
+
+// NewLogWriterForLog returns a new LogWriter that writes events to w in the same format as the supplied log. For
+// logs that conform to SpecEFI_2, the digest algorithms and sizes are obtained from the log's Spec ID event
+// rather than from Log.Algorithms, so that writing every event from the log, starting with the Spec ID event,
+// reproduces the original header exactly.
+func NewLogWriterForLog(w io.Writer, log *Log) (*LogWriter, error) {
+	if log.Spec != SpecEFI_2 {
+		return NewLogWriter(w, log.Spec, AlgorithmIdList{AlgorithmSha1})
+	}
+	return NewLogWriterWithDigestSizes(w, log.SpecIdEvent().Data.(*SpecIdEventData).DigestSizes)
+}
+
+// WriteSpecIdEvent writes a Spec ID event that describes the log, with the specified platform class and vendor
+// information. This must be called before any other events are written. It is an error to call this for a log
+// that conforms to SpecUnknown.
+func (w *LogWriter) WriteSpecIdEvent(platformClass uint32, vendorInfo []byte) error {
+	if !w.first {
+		return errors.New("the Spec ID event must be the first event in the log")
+	}
+
+	data, err := encodeSpecIdEvent(w.spec, w.algSizes, platformClass, vendorInfo)
+	if err != nil {
+		return err
+	}
+
+	return w.WriteEvent(&Event{
+		PCRIndex:  0,
+		EventType: EventTypeNoAction,
+		Digests:   DigestMap{AlgorithmSha1: make(Digest, AlgorithmSha1.size())},
+		Data:      &opaqueEventData{data: data}})
+}
+
+// WriteEvent writes the supplied event to the log. The event data is obtained from Event.Data.Bytes(). The Index
+// field of the event is ignored.
+func (w *LogWriter) WriteEvent(event *Event) error {
+	if !isPCRIndexInRange(event.PCRIndex) {
+		return wrapPCRIndexOutOfRangeError(event.PCRIndex)
+	}
+
+	var data []byte
+	if event.Data != nil {
+		data = event.Data.Bytes()
+	}
+
+	first := w.first
+	w.first = false
+
+	if w.spec == SpecEFI_2 && !first {
+		return writeEvent_2(w.w, event.PCRIndex, event.EventType, event.Digests, w.algSizes, data)
+	}
+
+	digest, ok := event.Digests[AlgorithmSha1]
+	if !ok && first && event.EventType == EventTypeNoAction {
+		digest = make(Digest, AlgorithmSha1.size())
+	}
+	if !ok && digest == nil {
+		return fmt.Errorf("missing digest for algorithm %s", AlgorithmSha1)
+	}
+	return writeEvent_1_2(w.w, event.PCRIndex, event.EventType, digest, data)
+}
@@ -0,0 +1,128 @@
+package tcglog
+
+import (
+	"bytes"
+	"io"
+	"testing"
+)
+
+func TestLogWriterReemit(t *testing.T) {
+	data := makeTestCryptoAgileLog(t, 10)
+
+	log, err := NewLog(bytes.NewReader(data), LogOptions{})
+	if err != nil {
+		t.Fatalf("NewLog failed: %v", err)
+	}
+
+	var buf bytes.Buffer
+	w, err := NewLogWriter(&buf, log.Spec, log.Algorithms)
+	if err != nil {
+		t.Fatalf("NewLogWriter failed: %v", err)
+	}
+	for {
+		event, err := log.NextEvent()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("NextEvent failed: %v", err)
+		}
+		if err := w.WriteEvent(event); err != nil {
+			t.Fatalf("WriteEvent failed: %v", err)
+		}
+	}
+
+	if !bytes.Equal(buf.Bytes(), data) {
+		t.Errorf("Re-emitted log is different to the original")
+	}
+}
+
+func TestLogWriterPCClient(t *testing.T) {
+	var buf bytes.Buffer
+	w, err := NewLogWriter(&buf, SpecPCClient, AlgorithmIdList{AlgorithmSha1})
+	if err != nil {
+		t.Fatalf("NewLogWriter failed: %v", err)
+	}
+	if err := w.WriteSpecIdEvent(0, []byte("foo")); err != nil {
+		t.Fatalf("WriteSpecIdEvent failed: %v", err)
+	}
+	if err := w.WriteEvent(&Event{
+		PCRIndex:  4,
+		EventType: EventTypeSeparator,
+		Digests:   DigestMap{AlgorithmSha1: AlgorithmSha1.hash([]byte{0, 0, 0, 0})},
+		Data:      &opaqueEventData{data: []byte{0, 0, 0, 0}}}); err != nil {
+		t.Fatalf("WriteEvent failed: %v", err)
+	}
+
+	log, err := NewLog(bytes.NewReader(buf.Bytes()), LogOptions{})
+	if err != nil {
+		t.Fatalf("NewLog failed: %v", err)
+	}
+	if log.Spec != SpecPCClient {
+		t.Errorf("Unexpected spec %d", log.Spec)
+	}
+
+	event, err := log.NextEvent()
+	if err != nil {
+		t.Fatalf("NextEvent failed: %v", err)
+	}
+	specId, ok := event.Data.(*SpecIdEventData)
+	if !ok || !bytes.Equal(specId.VendorInfo, []byte("foo")) {
+		t.Errorf("Unexpected Spec ID event: %s", event.Data)
+	}
+
+	event, err = log.NextEvent()
+	if err != nil {
+		t.Fatalf("NextEvent failed: %v", err)
+	}
+	if event.PCRIndex != 4 || event.EventType != EventTypeSeparator {
+		t.Errorf("Unexpected event")
+	}
+
+	if _, err := NewLogWriter(&buf, SpecPCClient, AlgorithmIdList{AlgorithmSha256}); err == nil {
+		t.Errorf("NewLogWriter should fail for SHA-256 with a 1.2 format log")
+	}
+}
+
+func TestNewLogWriterForLog(t *testing.T) {
+	const algorithmUnknown AlgorithmId = 0x8003
+	data := makeTestCryptoAgileLogWithDigestSizes(t, []EFISpecIdEventAlgorithmSize{
+		{AlgorithmId: algorithmUnknown, DigestSize: 20},
+		{AlgorithmId: AlgorithmSha256, DigestSize: uint16(AlgorithmSha256.size())}}, 5)
+
+	log, err := NewLog(bytes.NewReader(data), LogOptions{PreserveUnknownDigests: true})
+	if err != nil {
+		t.Fatalf("NewLog failed: %v", err)
+	}
+
+	// The Spec ID event is available before iterating over the log.
+	specId := log.SpecIdEvent()
+	if specId == nil {
+		t.Fatalf("No Spec ID event")
+	}
+	if d, ok := specId.Data.(*SpecIdEventData); !ok || d.Spec != SpecEFI_2 || len(d.DigestSizes) != 2 {
+		t.Errorf("Unexpected Spec ID event data %v", specId.Data)
+	}
+
+	var buf bytes.Buffer
+	w, err := NewLogWriterForLog(&buf, log)
+	if err != nil {
+		t.Fatalf("NewLogWriterForLog failed: %v", err)
+	}
+	for {
+		event, err := log.NextEvent()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("NextEvent failed: %v", err)
+		}
+		if err := w.WriteEvent(event); err != nil {
+			t.Fatalf("WriteEvent failed: %v", err)
+		}
+	}
+
+	if !bytes.Equal(buf.Bytes(), data) {
+		t.Errorf("Re-emitted log is different to the original")
+	}
+}
@@ -0,0 +1,70 @@
+package tcglog
+
+import (
+	"bytes"
+	"fmt"
+	"unicode/utf8"
+)
+
+// xenEventInfoSize is the size of the event info string that Secure Launch records with each event
+// (TPM_EVENT_INFO_LENGTH).
+const xenEventInfoSize = 32
+
+// XenPCRs returns the PCRs that Xen and other hypervisors measure to as part of a dynamic measured launch by
+// default. LogOptions.XenPCRs can be used to override these.
+func XenPCRs() []PCRIndex {
+	return []PCRIndex{17, 18, 19}
+}
+
+// xenPCRs returns the PCRs that events recorded by Xen are decoded from.
+func (o *LogOptions) xenPCRs() []PCRIndex {
+	if o.XenPCRs != nil {
+		return o.XenPCRs
+	}
+	return XenPCRs()
+}
+
+// XenEventData corresponds to the data of an event recorded by Xen or another hypervisor that implements Secure
+// Launch, as part of a dynamic measured launch. The data is the event info string from the DRTM policy entry
+// that describes what was measured, such as the hypervisor, a dom0 kernel or initrd, or a commandline. The
+// measured data itself is not recorded in the log.
+type XenEventData struct {
+	data []byte
+	Str  string
+}
+
+func (e *XenEventData) String() string {
+	return fmt.Sprintf("xen{ %s }", e.Str)
+}
+
+func (e *XenEventData) Bytes() []byte {
+	return e.data
+}
+
+// decodeEventDataXen decodes the data of DLE_EVTYPE_SLAUNCH, DLE_EVTYPE_SLAUNCH_START and DLE_EVTYPE_SLAUNCH_END
+// events, which is an event info string of up to TPM_EVENT_INFO_LENGTH bytes. The string is NUL terminated if
+// it is shorter than this, and may be padded with NUL bytes.
+func decodeEventDataXen(eventType EventType, data []byte) (EventData, int) {
+	switch eventType {
+	case EventTypeSLaunch, EventTypeSLaunchStart, EventTypeSLaunchEnd:
+	default:
+		return nil, 0
+	}
+	if len(data) > xenEventInfoSize {
+		return nil, 0
+	}
+
+	str := data
+	if i := bytes.IndexByte(data, 0); i >= 0 {
+		str = data[:i]
+		if len(bytes.TrimRight(data[i:], "\x00")) > 0 {
+			// There's data after the terminator.
+			return nil, 0
+		}
+	}
+	if !utf8.Valid(str) {
+		return nil, 0
+	}
+
+	return &XenEventData{data: data, Str: string(str)}, 0
+}
@@ -0,0 +1,122 @@
+package tcglog
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestDecodeEventDataXen(t *testing.T) {
+	padded := make([]byte, xenEventInfoSize)
+	copy(padded, "Xen hypervisor")
+
+	for _, data := range []struct {
+		desc      string
+		options   LogOptions
+		pcr       PCRIndex
+		eventType EventType
+		data      []byte
+		str       string // Empty if the data shouldn't be decoded as XenEventData
+	}{
+		{
+			desc:      "SLaunch",
+			options:   LogOptions{EnableXen: true},
+			pcr:       17,
+			eventType: EventTypeSLaunch,
+			data:      []byte("Xen hypervisor\x00"),
+			str:       "Xen hypervisor",
+		},
+		{
+			desc:      "SLaunchStart",
+			options:   LogOptions{EnableXen: true},
+			pcr:       18,
+			eventType: EventTypeSLaunchStart,
+			data:      []byte("dom0 kernel"),
+			str:       "dom0 kernel",
+		},
+		{
+			desc:      "SLaunchEndPadded",
+			options:   LogOptions{EnableXen: true},
+			pcr:       18,
+			eventType: EventTypeSLaunchEnd,
+			data:      padded,
+			str:       "Xen hypervisor",
+		},
+		{
+			desc:      "NotEnabled",
+			pcr:       17,
+			eventType: EventTypeSLaunch,
+			data:      []byte("Xen hypervisor\x00"),
+		},
+		{
+			desc:      "WrongType",
+			options:   LogOptions{EnableXen: true},
+			pcr:       19,
+			eventType: EventTypeIPL,
+			data:      []byte("Xen hypervisor\x00"),
+		},
+		{
+			desc:      "TooLong",
+			options:   LogOptions{EnableXen: true},
+			pcr:       17,
+			eventType: EventTypeSLaunch,
+			data:      bytes.Repeat([]byte("a"), xenEventInfoSize+1),
+		},
+		{
+			desc:      "DataAfterTerminator",
+			options:   LogOptions{EnableXen: true},
+			pcr:       17,
+			eventType: EventTypeSLaunch,
+			data:      []byte("Xen\x00hypervisor"),
+		},
+		{
+			desc:      "PCRNotInList",
+			options:   LogOptions{EnableXen: true},
+			pcr:       12,
+			eventType: EventTypeSLaunch,
+			data:      []byte("Xen hypervisor\x00"),
+		},
+		{
+			desc:      "CustomPCRs",
+			options:   LogOptions{EnableXen: true, XenPCRs: []PCRIndex{12}},
+			pcr:       12,
+			eventType: EventTypeSLaunch,
+			data:      []byte("Xen hypervisor\x00"),
+			str:       "Xen hypervisor",
+		},
+		{
+			desc:      "CustomPCRsExcludeDefault",
+			options:   LogOptions{EnableXen: true, XenPCRs: []PCRIndex{12}},
+			pcr:       19,
+			eventType: EventTypeSLaunch,
+			data:      []byte("Xen hypervisor\x00"),
+		},
+	} {
+		t.Run(data.desc, func(t *testing.T) {
+			out, _ := decodeEventData(data.pcr, data.eventType, data.data, &data.options, false)
+			d, ok := out.(*XenEventData)
+			if data.str == "" {
+				if ok {
+					t.Errorf("Unexpected XenEventData")
+				}
+				return
+			}
+			if !ok {
+				t.Fatalf("Unexpected event data type %T", out)
+			}
+			if d.Str != data.str {
+				t.Errorf("Unexpected string %q", d.Str)
+			}
+			if !bytes.Equal(d.Bytes(), data.data) {
+				t.Errorf("Unexpected bytes")
+			}
+		})
+	}
+}
+
+func TestXenPCRs(t *testing.T) {
+	pcrs := XenPCRs()
+	pcrs[0] = 0
+	if XenPCRs()[0] != 17 {
+		t.Errorf("XenPCRs returned a shared slice")
+	}
+}